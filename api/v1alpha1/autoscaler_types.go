@@ -0,0 +1,65 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+const (
+	// AutoscalerReleaseName is the name of the release of cluster-autoscaler deployed on the clusters.
+	AutoscalerReleaseName = "hmc-cluster-autoscaler"
+	// AutoscalerNamespace is the namespace of cluster-autoscaler on the clusters.
+	AutoscalerNamespace = "cluster-autoscaler"
+	// AutoscalerKubeconfigSecretName is the name of the Secret holding the kubeconfig
+	// of the management cluster written to the clusters.
+	AutoscalerKubeconfigSecretName = "hmc-cluster-autoscaler-kubeconfig"
+	// AutoscalerKubeconfigPath is the path the kubeconfig of the management cluster
+	// is mounted at in the cluster-autoscaler container.
+	AutoscalerKubeconfigPath = "/etc/kubernetes/management/value"
+)
+
+// NodePoolAutoscaling sets the size limits of a worker pool for cluster-autoscaler.
+type NodePoolAutoscaling struct {
+	// +kubebuilder:validation:Minimum=0
+
+	// MinSize is the minimum number of the nodes of the pool.
+	MinSize int32 `json:"minSize"`
+
+	// +kubebuilder:validation:Minimum=1
+
+	// MaxSize is the maximum number of the nodes of the pool.
+	MaxSize int32 `json:"maxSize"`
+}
+
+// ClusterAutoscalerSpec configures the cluster-autoscaler deployed on the managed cluster as
+// a service. The autoscaler scales the worker pools of the cluster through the CAPI objects of
+// the cluster in the management cluster, it is granted the updates of the CAPI objects of the
+// cluster only with a short-lived token rotated by HMC.
+type ClusterAutoscalerSpec struct {
+	// +kubebuilder:validation:MinLength=1
+
+	// Template is the ServiceTemplate of cluster-autoscaler in the namespace of the ManagedCluster.
+	// The values set by HMC follow the layout of the cluster-autoscaler chart.
+	Template string `json:"template"`
+
+	// +kubebuilder:validation:Pattern=`^https://`
+
+	// ManagementEndpoint is the URL of the API server of the management cluster
+	// reachable from the managed cluster.
+	ManagementEndpoint string `json:"managementEndpoint"`
+	// Values are merged over the values of cluster-autoscaler set by HMC.
+	Values *apiextensionsv1.JSON `json:"values,omitempty"`
+}
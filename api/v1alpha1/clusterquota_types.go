@@ -0,0 +1,138 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"fmt"
+	"path"
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ClusterQuotaKind is the string representation of a ClusterQuota.
+	ClusterQuotaKind = "ClusterQuota"
+
+	// QuotaExceededCondition indicates the usage of the namespace exceeds the limits of the ClusterQuota.
+	QuotaExceededCondition = "QuotaExceeded"
+
+	// LimitsExceededReason is set when the usage of the namespace exceeds some of the limits.
+	LimitsExceededReason = "LimitsExceeded"
+	// WithinLimitsReason is set when the usage of the namespace is within the limits.
+	WithinLimitsReason = "WithinLimits"
+)
+
+// ClusterQuotaSpec defines the desired state of ClusterQuota
+type ClusterQuotaSpec struct {
+	// +kubebuilder:validation:Minimum=0
+
+	// MaxClusters is the maximum number of the ManagedClusters in the namespace.
+	MaxClusters *int32 `json:"maxClusters,omitempty"`
+
+	// +kubebuilder:validation:Minimum=0
+
+	// MaxWorkers is the maximum total number of the worker machines
+	// of the ManagedClusters in the namespace.
+	MaxWorkers *int32 `json:"maxWorkers,omitempty"`
+
+	// AllowedTemplates is the list of the ClusterTemplates the ManagedClusters
+	// in the namespace may use, the entries may contain the shell patterns,
+	// e.g. aws-standalone-cp-*. All of the templates are allowed if empty.
+	AllowedTemplates []string `json:"allowedTemplates,omitempty"`
+
+	// AllowedProviders is the list of the CAPI providers the templates of the
+	// ManagedClusters in the namespace may require, e.g. infrastructure-aws.
+	// All of the providers are allowed if empty.
+	AllowedProviders []string `json:"allowedProviders,omitempty"`
+}
+
+// ClusterQuotaStatus defines the observed state of ClusterQuota
+type ClusterQuotaStatus struct {
+	// Conditions contains details for the current state of the ClusterQuota.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Clusters is the number of the ManagedClusters in the namespace.
+	Clusters int32 `json:"clusters"`
+	// Workers is the total number of the worker machines of the ManagedClusters in the namespace.
+	Workers int32 `json:"workers"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=cq
+// +kubebuilder:printcolumn:name="Clusters",type=integer,JSONPath=`.status.clusters`
+// +kubebuilder:printcolumn:name="Max clusters",type=integer,JSONPath=`.spec.maxClusters`
+// +kubebuilder:printcolumn:name="Workers",type=integer,JSONPath=`.status.workers`
+// +kubebuilder:printcolumn:name="Max workers",type=integer,JSONPath=`.spec.maxWorkers`
+
+// ClusterQuota is the Schema for the clusterquotas API. It limits the
+// ManagedClusters which may be created in its namespace.
+type ClusterQuota struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterQuotaSpec   `json:"spec,omitempty"`
+	Status ClusterQuotaStatus `json:"status,omitempty"`
+}
+
+func (in *ClusterQuota) GetConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// AllowsTemplate returns an error if the ManagedClusters may not use the given ClusterTemplate.
+func (in *ClusterQuota) AllowsTemplate(template *ClusterTemplate) error {
+	if len(in.Spec.AllowedTemplates) > 0 && !slices.ContainsFunc(in.Spec.AllowedTemplates, func(pattern string) bool {
+		matched, _ := path.Match(pattern, template.Name)
+		return matched
+	}) {
+		return fmt.Errorf("the ClusterTemplate %s is not allowed by the ClusterQuota %s", template.Name, in.Name)
+	}
+
+	if len(in.Spec.AllowedProviders) > 0 {
+		for _, provider := range template.Status.Providers {
+			if !slices.Contains(in.Spec.AllowedProviders, provider) {
+				return fmt.Errorf("the provider %s of the ClusterTemplate %s is not allowed by the ClusterQuota %s", provider, template.Name, in.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Exceeded returns the descriptions of the limits of the ClusterQuota
+// exceeded by the given number of the clusters and the worker machines.
+func (in *ClusterQuota) Exceeded(clusters, workers int32) []string {
+	var exceeded []string
+	if in.Spec.MaxClusters != nil && clusters > *in.Spec.MaxClusters {
+		exceeded = append(exceeded, fmt.Sprintf("%d clusters exceed the limit of %d", clusters, *in.Spec.MaxClusters))
+	}
+	if in.Spec.MaxWorkers != nil && workers > *in.Spec.MaxWorkers {
+		exceeded = append(exceeded, fmt.Sprintf("%d workers exceed the limit of %d", workers, *in.Spec.MaxWorkers))
+	}
+	return exceeded
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterQuotaList contains a list of ClusterQuota
+type ClusterQuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterQuota `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterQuota{}, &ClusterQuotaList{})
+}
@@ -0,0 +1,108 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterQuotaAllowsTemplate(t *testing.T) {
+	quota := &ClusterQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "quota"},
+		Spec: ClusterQuotaSpec{
+			AllowedTemplates: []string{"aws-standalone-cp-*"},
+			AllowedProviders: []string{"infrastructure-aws", "control-plane-k0smotron"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		providers Providers
+		allowed   bool
+	}{
+		{"aws-standalone-cp-0-0-2", Providers{"infrastructure-aws"}, true},
+		{"aws-hosted-cp-0-0-2", Providers{"infrastructure-aws"}, false},
+		{"aws-standalone-cp-0-0-3", Providers{"infrastructure-aws", "infrastructure-azure"}, false},
+	}
+
+	for _, test := range tests {
+		template := &ClusterTemplate{ObjectMeta: metav1.ObjectMeta{Name: test.name}}
+		template.Status.Providers = test.providers
+		if err := quota.AllowsTemplate(template); (err == nil) != test.allowed {
+			t.Errorf("AllowsTemplate(%s, %v) error = %v, want allowed %v", test.name, test.providers, err, test.allowed)
+		}
+	}
+}
+
+func TestManagedClusterWorkersNumber(t *testing.T) {
+	replicas := int32(3)
+
+	template := &ClusterTemplate{}
+	template.Status.Config = &apiextensionsv1.JSON{Raw: []byte(`{"workersNumber":1}`)}
+	mgmt := &Management{Spec: ManagementSpec{GlobalClusterDefaults: &apiextensionsv1.JSON{Raw: []byte(`{"workersNumber":4}`)}}}
+
+	tests := []struct {
+		name     string
+		spec     ManagedClusterSpec
+		template *ClusterTemplate
+		mgmt     *Management
+		workers  int32
+	}{
+		{name: "no defaults", workers: 0},
+		{name: "template defaults", template: template, workers: 1},
+		{name: "global defaults", template: template, mgmt: mgmt, workers: 4},
+		{
+			name:     "config",
+			spec:     ManagedClusterSpec{Config: &apiextensionsv1.JSON{Raw: []byte(`{"workersNumber":2}`)}},
+			template: template, mgmt: mgmt, workers: 2,
+		},
+		{
+			name: "worker pools",
+			spec: ManagedClusterSpec{
+				Config:  &apiextensionsv1.JSON{Raw: []byte(`{"workersNumber":2}`)},
+				Workers: []WorkerPoolConfig{{Name: "worker", Replicas: &replicas}, {Name: "gpu"}},
+			},
+			workers: 3,
+		},
+		{
+			name: "extra pool",
+			spec: ManagedClusterSpec{
+				Workers: []WorkerPoolConfig{{Name: "gpu", Replicas: &replicas}},
+			},
+			template: template, workers: 4,
+		},
+		{
+			name: "autoscaled pools",
+			spec: ManagedClusterSpec{
+				Workers: []WorkerPoolConfig{{Name: "worker", Replicas: &replicas}},
+				NodePools: []NodePoolSpec{
+					{Name: "worker", Autoscaling: &NodePoolAutoscaling{MinSize: 1, MaxSize: 5}},
+					{Name: "gpu", Autoscaling: &NodePoolAutoscaling{MinSize: 0, MaxSize: 2}},
+				},
+			},
+			template: template, workers: 7,
+		},
+	}
+
+	for _, test := range tests {
+		mc := &ManagedCluster{Spec: test.spec}
+		if workers := mc.WorkersNumber(test.template, test.mgmt); workers != test.workers {
+			t.Errorf("%s: WorkersNumber() = %d, want %d", test.name, workers, test.workers)
+		}
+	}
+}
@@ -15,9 +15,13 @@
 package v1alpha1
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/Masterminds/semver/v3"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -26,8 +30,25 @@ const (
 	ClusterTemplateKind = "ClusterTemplate"
 	// ChartAnnotationKubernetesVersion is an annotation containing the Kubernetes exact version in the SemVer format associated with a ClusterTemplate.
 	ChartAnnotationKubernetesVersion = "hmc.mirantis.com/k8s-version"
+	// ChartAnnotationHostedControlPlane is an annotation marking the ClusterTemplate as deploying
+	// the control plane of the clusters within the management cluster, e.g. with k0smotron.
+	ChartAnnotationHostedControlPlane = "hmc.mirantis.com/hosted-control-plane"
+	// ChartAnnotationProviderVersionPrefix is the prefix of the annotations holding the SemVer
+	// constraints of the provider versions required by a ClusterTemplate, the rest of the key
+	// is the name of the provider, e.g. "hmc.mirantis.com/provider-version.infrastructure-aws: >= 2.5.0".
+	ChartAnnotationProviderVersionPrefix = "hmc.mirantis.com/provider-version."
+	// ChartAnnotationIdentityValuesPaths is an annotation containing the comma-separated paths of the values
+	// the reference to the ClusterIdentity of the Credential is passed to the ClusterTemplate in,
+	// e.g. "hmc.mirantis.com/identity-values-paths: cluster.identityRef,controlPlane.identityRef".
+	ChartAnnotationIdentityValuesPaths = "hmc.mirantis.com/identity-values-paths"
+
+	// DefaultIdentityValuesPath is the path of the value the reference to the ClusterIdentity
+	// is passed in unless the ClusterTemplate declares the paths.
+	DefaultIdentityValuesPath = "clusterIdentity"
 )
 
+var identityValuesPathRe = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`)
+
 // ClusterTemplateSpec defines the desired state of ClusterTemplate
 type ClusterTemplateSpec struct {
 	Helm HelmSpec `json:"helm"`
@@ -38,12 +59,40 @@ type ClusterTemplateSpec struct {
 	//
 	// [contract versions]: https://cluster-api.sigs.k8s.io/developer/providers/contracts
 	ProviderContracts CompatibilityContracts `json:"providerContracts,omitempty"`
+	// +kubebuilder:validation:Pattern=`^v?(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`
+
 	// Kubernetes exact version in the SemVer format provided by this ClusterTemplate.
 	KubernetesVersion string `json:"k8sVersion,omitempty"`
 	// Providers represent required CAPI providers with supported contract versions.
 	// Should be set if not present in the Helm chart metadata.
 	// Compatibility attributes are optional to be defined.
 	Providers Providers `json:"providers,omitempty"`
+	// ClusterClass references the CAPI ClusterClass the clusters are created from.
+	// The Helm chart of the template is expected to render the Cluster topology
+	// of the class, e.g. the "clusterclass" chart of the HMC repository.
+	// The name of the ClusterClass is passed to the chart in the "clusterClass.name" value.
+	ClusterClass *ClusterClassReference `json:"clusterClass,omitempty"`
+	// HostedControlPlane marks the control plane of the clusters as hosted within
+	// the management cluster, e.g. by k0smotron, so no Machines are created for it.
+	// Should be set if the chart is not annotated with "hmc.mirantis.com/hosted-control-plane".
+	HostedControlPlane bool `json:"hostedControlPlane,omitempty"`
+	// +kubebuilder:validation:items:Pattern=`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`
+
+	// IdentityValuesPaths are the dot-separated paths of the values the reference to the ClusterIdentity
+	// of the Credential is passed to the chart in, e.g. "cluster.identityRef". Should be set if the chart
+	// is not annotated with "hmc.mirantis.com/identity-values-paths", defaults to "clusterIdentity".
+	IdentityValuesPaths []string `json:"identityValuesPaths,omitempty"`
+
+	TemplateDeprecation `json:",inline"`
+}
+
+// ClusterClassReference references a CAPI ClusterClass.
+type ClusterClassReference struct {
+	// +kubebuilder:validation:MinLength=1
+
+	// Name is the name of the ClusterClass located in the namespace of the template,
+	// the clusters using the template are created in the same namespace as the ClusterClass.
+	Name string `json:"name"`
 }
 
 // ClusterTemplateStatus defines the observed state of ClusterTemplate
@@ -60,10 +109,45 @@ type ClusterTemplateStatus struct {
 	// Providers represent required CAPI providers with supported contract versions
 	// if the latter has been given.
 	Providers Providers `json:"providers,omitempty"`
+	// HostedControlPlane is true if the control plane of the clusters is hosted within the management cluster.
+	HostedControlPlane bool `json:"hostedControlPlane,omitempty"`
+	// IdentityValuesPaths are the paths of the values the reference to the ClusterIdentity is passed in.
+	IdentityValuesPaths []string `json:"identityValuesPaths,omitempty"`
+	// ProviderVersions holds key-value pairs, where the key is the name of the provider
+	// and the value is the SemVer constraint of the provider version required by the template.
+	ProviderVersions map[string]string `json:"providerVersions,omitempty"`
+	// UsedByClusters is the list of the names of the ManagedClusters using the template.
+	UsedByClusters []string `json:"usedByClusters,omitempty"`
+	// ConfigSchema is the JSON schema of the values of the Helm chart, the config of
+	// the ManagedClusters is pruned of the values the schema disallows.
+	ConfigSchema *apiextensionsv1.JSON `json:"configSchema,omitempty"`
+	// Parameters summarizes the values of the template collected from the values schema,
+	// the default values and the README of the chart.
+	Parameters []TemplateParameter `json:"parameters,omitempty"`
+	// ConfigSections maps the typed config sections of the ManagedCluster supported by the template,
+	// e.g. spec.sshKey, to the paths of the values they are set at. The sections are declared in the
+	// values schema of the chart with the x-hmc-config-section keyword, the ManagedClusters setting
+	// the sections not supported by the template are rejected.
+	ConfigSections map[string][]string `json:"configSections,omitempty"`
 
 	TemplateStatusCommon `json:",inline"`
 }
 
+// TemplateParameter describes a value of the chart of the template.
+type TemplateParameter struct {
+	// Name is the dot-separated path of the value, e.g. "controlPlane.instanceType".
+	Name string `json:"name"`
+	// Type is the JSON schema type of the value, the types are separated with "|"
+	// if the value is of several types, e.g. "string|null".
+	Type string `json:"type,omitempty"`
+	// Default is the default value of the parameter.
+	Default *apiextensionsv1.JSON `json:"default,omitempty"`
+	// Description is the description of the value.
+	Description string `json:"description,omitempty"`
+	// Required is true if the value must be set.
+	Required bool `json:"required,omitempty"`
+}
+
 // FillStatusWithProviders sets the status of the template with providers
 // either from the spec or from the given annotations.
 func (t *ClusterTemplate) FillStatusWithProviders(annotations map[string]string) error {
@@ -75,24 +159,105 @@ func (t *ClusterTemplate) FillStatusWithProviders(annotations map[string]string)
 	}
 
 	t.Status.ProviderContracts = contractsStatus
+	t.Status.HostedControlPlane = t.Spec.HostedControlPlane || annotations[ChartAnnotationHostedControlPlane] == "true"
 
-	kversion := annotations[ChartAnnotationKubernetesVersion]
-	if t.Spec.KubernetesVersion != "" {
-		kversion = t.Spec.KubernetesVersion
-	}
-	if kversion == "" {
-		return nil
+	providerVersions, err := getProviderVersions(annotations)
+	if err != nil {
+		return fmt.Errorf("failed to get required provider versions for ClusterTemplate %s/%s: %w", t.GetNamespace(), t.GetName(), err)
 	}
+	t.Status.ProviderVersions = providerVersions
 
-	if _, err := semver.NewVersion(kversion); err != nil {
-		return fmt.Errorf("failed to parse kubernetes version %s for ClusterTemplate %s/%s: %w", kversion, t.GetNamespace(), t.GetName(), err)
+	identityPaths, err := getIdentityValuesPaths(t.Spec.IdentityValuesPaths, annotations)
+	if err != nil {
+		return fmt.Errorf("failed to get identity values paths for ClusterTemplate %s/%s: %w", t.GetNamespace(), t.GetName(), err)
 	}
+	t.Status.IdentityValuesPaths = identityPaths
 
+	kversion, err := getKubernetesVersion(t.Spec.KubernetesVersion, annotations)
+	if err != nil {
+		return fmt.Errorf("failed to get kubernetes version for ClusterTemplate %s/%s: %w", t.GetNamespace(), t.GetName(), err)
+	}
 	t.Status.KubernetesVersion = kversion
 
 	return nil
 }
 
+// getKubernetesVersion returns the Kubernetes version given in the spec or in the
+// ChartAnnotationKubernetesVersion annotation. The version must be an exact SemVer
+// version optionally prefixed with "v", e.g. "v1.31.1+k0s.0".
+func getKubernetesVersion(specVersion string, annotations map[string]string) (string, error) {
+	kversion, source := specVersion, ".spec.k8sVersion"
+	if kversion == "" {
+		kversion, source = annotations[ChartAnnotationKubernetesVersion], "the "+ChartAnnotationKubernetesVersion+" annotation"
+	}
+	if kversion == "" {
+		return "", nil
+	}
+
+	if _, err := semver.StrictNewVersion(strings.TrimPrefix(kversion, "v")); err != nil {
+		return "", fmt.Errorf("incorrect kubernetes version %s given in %s, expected the exact SemVer version, e.g. v1.31.1: %w", kversion, source, err)
+	}
+	return kversion, nil
+}
+
+// getProviderVersions returns the SemVer constraints of the provider versions
+// given in the annotations with the ChartAnnotationProviderVersionPrefix.
+func getProviderVersions(annotations map[string]string) (map[string]string, error) {
+	var (
+		versions map[string]string
+		merr     error
+	)
+	for k, constraint := range annotations {
+		provider, ok := strings.CutPrefix(k, ChartAnnotationProviderVersionPrefix)
+		if !ok || provider == "" {
+			continue
+		}
+
+		if _, err := semver.NewConstraint(constraint); err != nil {
+			merr = errors.Join(merr, fmt.Errorf("incorrect version constraint %s given for the %s annotation: %w", constraint, k, err))
+			continue
+		}
+
+		if versions == nil {
+			versions = make(map[string]string)
+		}
+		versions[provider] = constraint
+	}
+	return versions, merr
+}
+
+// getIdentityValuesPaths returns the paths of the values given in the spec
+// or in the ChartAnnotationIdentityValuesPaths annotation.
+func getIdentityValuesPaths(specPaths []string, annotations map[string]string) ([]string, error) {
+	paths := specPaths
+	if len(paths) == 0 && annotations[ChartAnnotationIdentityValuesPaths] != "" {
+		for _, path := range strings.Split(annotations[ChartAnnotationIdentityValuesPaths], ",") {
+			paths = append(paths, strings.TrimSpace(path))
+		}
+	}
+
+	for _, path := range paths {
+		if !IsIdentityValuesPath(path) {
+			return nil, fmt.Errorf("invalid values path %q, expected the dot-separated keys", path)
+		}
+	}
+	return paths, nil
+}
+
+// IsIdentityValuesPath returns true if the path is the dot-separated keys of the values, e.g. "cluster.identityRef".
+func IsIdentityValuesPath(path string) bool {
+	return identityValuesPathRe.MatchString(path)
+}
+
+// IdentityValuesPaths returns the paths of the values the reference to the ClusterIdentity
+// is passed to the template in, the DefaultIdentityValuesPath unless the template declares the paths.
+func (t *ClusterTemplate) IdentityValuesPaths() []string {
+	if len(t.Status.IdentityValuesPaths) == 0 {
+		return []string{DefaultIdentityValuesPath}
+	}
+	return t.Status.IdentityValuesPaths
+}
+
 // GetSpecProviders returns .spec.providers of the Template.
 func (t *ClusterTemplate) GetSpecProviders() Providers {
 	return t.Spec.Providers
@@ -120,7 +285,7 @@ type ClusterTemplate struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="Spec is immutable"
+	// +kubebuilder:validation:XValidation:rule="self.helm == oldSelf.helm && has(self.providerContracts) == has(oldSelf.providerContracts) && (!has(self.providerContracts) || self.providerContracts == oldSelf.providerContracts) && has(self.k8sVersion) == has(oldSelf.k8sVersion) && (!has(self.k8sVersion) || self.k8sVersion == oldSelf.k8sVersion) && has(self.providers) == has(oldSelf.providers) && (!has(self.providers) || self.providers == oldSelf.providers) && has(self.clusterClass) == has(oldSelf.clusterClass) && (!has(self.clusterClass) || self.clusterClass == oldSelf.clusterClass) && has(self.hostedControlPlane) == has(oldSelf.hostedControlPlane) && (!has(self.hostedControlPlane) || self.hostedControlPlane == oldSelf.hostedControlPlane)",message="Spec is immutable except for the deprecation fields"
 
 	Spec   ClusterTemplateSpec   `json:"spec,omitempty"`
 	Status ClusterTemplateStatus `json:"status,omitempty"`
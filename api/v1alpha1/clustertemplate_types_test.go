@@ -0,0 +1,133 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func Test_getProviderVersions(t *testing.T) {
+	tests := []struct {
+		annotations map[string]string
+		versions    map[string]string
+		isValid     bool
+	}{
+		{nil, nil, true},
+		{map[string]string{ChartAnnotationKubernetesVersion: "v1.31.1"}, nil, true},
+		{
+			map[string]string{ChartAnnotationProviderVersionPrefix + "infrastructure-aws": ">= 2.5.0"},
+			map[string]string{"infrastructure-aws": ">= 2.5.0"},
+			true,
+		},
+		{
+			map[string]string{
+				ChartAnnotationProviderVersionPrefix + "infrastructure-aws":      ">= 2.5.0, < 3",
+				ChartAnnotationProviderVersionPrefix + "control-plane-k0smotron": "~0.1",
+			},
+			map[string]string{"infrastructure-aws": ">= 2.5.0, < 3", "control-plane-k0smotron": "~0.1"},
+			true,
+		},
+		{map[string]string{ChartAnnotationProviderVersionPrefix: ">= 2.5.0"}, nil, true},
+		{map[string]string{ChartAnnotationProviderVersionPrefix + "infrastructure-aws": "invalid"}, nil, false},
+	}
+
+	for _, test := range tests {
+		versions, err := getProviderVersions(test.annotations)
+		if (err == nil) != test.isValid {
+			t.Errorf("getProviderVersions(%v) error = %v, want valid %v", test.annotations, err, test.isValid)
+		}
+		if !maps.Equal(versions, test.versions) {
+			t.Errorf("getProviderVersions(%v) = %v, want %v", test.annotations, versions, test.versions)
+		}
+	}
+}
+
+func Test_getKubernetesVersion(t *testing.T) {
+	tests := []struct {
+		specVersion string
+		annotations map[string]string
+		version     string
+		isValid     bool
+	}{
+		{"", nil, "", true},
+		{"", map[string]string{ChartAnnotationKubernetesVersion: "v1.31.1"}, "v1.31.1", true},
+		{"", map[string]string{ChartAnnotationKubernetesVersion: "1.31.1+k0s.0"}, "1.31.1+k0s.0", true},
+		{"v1.30.4", map[string]string{ChartAnnotationKubernetesVersion: "v1.31.1"}, "v1.30.4", true},
+		{"", map[string]string{ChartAnnotationKubernetesVersion: "1.31"}, "", false},
+		{"", map[string]string{ChartAnnotationKubernetesVersion: "latest"}, "", false},
+		{"v1.31", nil, "", false},
+	}
+
+	for _, test := range tests {
+		version, err := getKubernetesVersion(test.specVersion, test.annotations)
+		if (err == nil) != test.isValid {
+			t.Errorf("getKubernetesVersion(%q, %v) error = %v, want valid %v", test.specVersion, test.annotations, err, test.isValid)
+		}
+		if version != test.version {
+			t.Errorf("getKubernetesVersion(%q, %v) = %q, want %q", test.specVersion, test.annotations, version, test.version)
+		}
+	}
+}
+
+func Test_getIdentityValuesPaths(t *testing.T) {
+	tests := []struct {
+		specPaths   []string
+		annotations map[string]string
+		paths       []string
+		isValid     bool
+	}{
+		{nil, nil, nil, true},
+		{nil, map[string]string{ChartAnnotationIdentityValuesPaths: "cluster.identityRef, controlPlane.identityRef"}, []string{"cluster.identityRef", "controlPlane.identityRef"}, true},
+		{[]string{"identity"}, map[string]string{ChartAnnotationIdentityValuesPaths: "cluster.identityRef"}, []string{"identity"}, true},
+		{nil, map[string]string{ChartAnnotationIdentityValuesPaths: "cluster..identityRef"}, nil, false},
+	}
+
+	for _, test := range tests {
+		paths, err := getIdentityValuesPaths(test.specPaths, test.annotations)
+		if (err == nil) != test.isValid {
+			t.Errorf("getIdentityValuesPaths(%v, %v) error = %v, want valid %v", test.specPaths, test.annotations, err, test.isValid)
+		}
+		if !slices.Equal(paths, test.paths) {
+			t.Errorf("getIdentityValuesPaths(%v, %v) = %v, want %v", test.specPaths, test.annotations, paths, test.paths)
+		}
+	}
+
+	template := &ClusterTemplate{}
+	if paths := template.IdentityValuesPaths(); !slices.Equal(paths, []string{DefaultIdentityValuesPath}) {
+		t.Errorf("IdentityValuesPaths() = %v, want the default path", paths)
+	}
+}
+
+func TestManagedClusterIdentityCredentialsByPath(t *testing.T) {
+	template := &ClusterTemplate{Status: ClusterTemplateStatus{IdentityValuesPaths: []string{"cluster.identityRef", "controlPlane.identityRef"}}}
+	cluster := &ManagedCluster{Spec: ManagedClusterSpec{
+		Credential:          "cred",
+		IdentityCredentials: map[string]string{"controlPlane.identityRef": "cp-cred", "dns.identityRef": "dns-cred"},
+	}}
+
+	expected := map[string]string{
+		"cluster.identityRef":      "cred",
+		"controlPlane.identityRef": "cp-cred",
+		"dns.identityRef":          "dns-cred",
+	}
+	if credentials := cluster.IdentityCredentialsByPath(template); !maps.Equal(credentials, expected) {
+		t.Errorf("IdentityCredentialsByPath() = %v, want %v", credentials, expected)
+	}
+	if names := ExtractCredentialName(cluster); !slices.Equal(names, []string{"cp-cred", "cred", "dns-cred"}) {
+		t.Errorf("ExtractCredentialName() = %v, want all of the Credentials", names)
+	}
+}
@@ -32,7 +32,12 @@ func (t *ClusterTemplateChain) GetSpec() *TemplateChainSpec {
 	return &t.Spec
 }
 
+func (t *ClusterTemplateChain) GetStatus() *TemplateChainStatus {
+	return &t.Status
+}
+
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
 
 // ClusterTemplateChain is the Schema for the clustertemplatechains API
 type ClusterTemplateChain struct {
@@ -41,7 +46,8 @@ type ClusterTemplateChain struct {
 
 	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="Spec is immutable"
 
-	Spec TemplateChainSpec `json:"spec,omitempty"`
+	Spec   TemplateChainSpec   `json:"spec,omitempty"`
+	Status TemplateChainStatus `json:"status,omitempty"`
 }
 
 // +kubebuilder:object:root=true
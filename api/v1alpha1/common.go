@@ -16,6 +16,7 @@ package v1alpha1
 
 import (
 	"context"
+	"slices"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -79,6 +80,18 @@ func SetupIndexers(ctx context.Context, mgr ctrl.Manager) error {
 		return err
 	}
 
+	if err := SetupManagedClusterCredentialIndexer(ctx, mgr); err != nil {
+		return err
+	}
+
+	if err := SetupManagedClusterDNSIndexer(ctx, mgr); err != nil {
+		return err
+	}
+
+	if err := SetupTemplateChartIndexer(ctx, mgr); err != nil {
+		return err
+	}
+
 	if err := SetupClusterTemplateChainIndexer(ctx, mgr); err != nil {
 		return err
 	}
@@ -144,10 +157,87 @@ func ExtractServiceTemplateName(rawObj client.Object) []string {
 	for _, s := range cluster.Spec.Services {
 		templates = append(templates, s.Template)
 	}
+	if cluster.Spec.Backup != nil {
+		templates = append(templates, cluster.Spec.Backup.Template)
+	}
 
 	return templates
 }
 
+const CredentialKey = ".spec.credential"
+
+func SetupManagedClusterCredentialIndexer(ctx context.Context, mgr ctrl.Manager) error {
+	return mgr.GetFieldIndexer().IndexField(ctx, &ManagedCluster{}, CredentialKey, ExtractCredentialName)
+}
+
+// ExtractCredentialName returns the names of the Credentials of the ManagedCluster
+// including the Credentials of the identity values.
+func ExtractCredentialName(rawObj client.Object) []string {
+	cluster, ok := rawObj.(*ManagedCluster)
+	if !ok {
+		return nil
+	}
+
+	var names []string
+	if cluster.Spec.Credential != "" {
+		names = append(names, cluster.Spec.Credential)
+	}
+	for _, name := range cluster.Spec.IdentityCredentials {
+		if name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+const DNSKey = ".spec.dns"
+
+func SetupManagedClusterDNSIndexer(ctx context.Context, mgr ctrl.Manager) error {
+	return mgr.GetFieldIndexer().IndexField(ctx, &ManagedCluster{}, DNSKey, ExtractDNSEnabled)
+}
+
+// ExtractDNSEnabled returns "true" if the DNS records of the ManagedCluster are managed,
+// such clusters are affected by the DNS defaults of the Management.
+func ExtractDNSEnabled(rawObj client.Object) []string {
+	cluster, ok := rawObj.(*ManagedCluster)
+	if !ok || cluster.Spec.DNS == nil {
+		return nil
+	}
+	return []string{"true"}
+}
+
+const ChartRefKey = ".spec.helm.chartRef"
+
+func SetupTemplateChartIndexer(ctx context.Context, mgr ctrl.Manager) error {
+	for _, obj := range []client.Object{&ClusterTemplate{}, &ServiceTemplate{}, &ProviderTemplate{}} {
+		if err := mgr.GetFieldIndexer().IndexField(ctx, obj, ChartRefKey, ExtractChartRef); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExtractChartRef returns the namespaced name of the HelmChart referenced by the template,
+// the HelmCharts created by HMC for the templates are owned by them instead.
+func ExtractChartRef(rawObj client.Object) []string {
+	var spec *HelmSpec
+	switch template := rawObj.(type) {
+	case *ClusterTemplate:
+		spec = template.GetHelmSpec()
+	case *ServiceTemplate:
+		spec = template.GetHelmSpec()
+	case *ProviderTemplate:
+		spec = template.GetHelmSpec()
+	default:
+		return nil
+	}
+	if spec.ChartRef == nil {
+		return nil
+	}
+	return []string{client.ObjectKey{Namespace: spec.ChartRef.Namespace, Name: spec.ChartRef.Name}.String()}
+}
+
 const SupportedTemplateKey = ".spec.supportedTemplates[].Name"
 
 func SetupClusterTemplateChainIndexer(ctx context.Context, mgr ctrl.Manager) error {
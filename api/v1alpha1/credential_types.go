@@ -22,9 +22,25 @@ import (
 type CredentialState string
 
 const (
-	CredentialReady     CredentialState = "Ready"
-	CredentialNotFound  CredentialState = "Cluster Identity not found"
-	CredentialWrongType CredentialState = "Mismatched type"
+	CredentialReady      CredentialState = "Ready"
+	CredentialNotFound   CredentialState = "Cluster Identity not found"
+	CredentialWrongType  CredentialState = "Mismatched type"
+	CredentialNotAllowed CredentialState = "Namespace not allowed"
+)
+
+const (
+	// CredentialKind is the string representation of a Credential.
+	CredentialKind = "Credential"
+
+	// CredentialFinalizer ensures the isolated copy of the ClusterIdentity is removed with the Credential.
+	CredentialFinalizer = "hmc.mirantis.com/credential"
+
+	// IdentityNamespacesAnnotation is set on a ClusterIdentity to the comma-separated list
+	// of the namespaces allowed to create isolated copies of the identity.
+	IdentityNamespacesAnnotation = "hmc.mirantis.com/identity-namespaces"
+	// IsolatedIdentityAnnotation is set on the isolated copies of the ClusterIdentities
+	// to the namespace/name of the Credential the copy has been created for.
+	IsolatedIdentityAnnotation = "hmc.mirantis.com/credential"
 )
 
 // CredentialSpec defines the desired state of Credential
@@ -33,11 +49,29 @@ type CredentialSpec struct {
 	IdentityRef *corev1.ObjectReference `json:"identityRef"`
 	// Description of the Credential object
 	Description string `json:"description,omitempty"` // WARN: noop
+	// IsolateIdentity makes the controller create a copy of the referenced ClusterIdentity
+	// allowed to be used only from the namespace of the Credential. The namespace has to be listed
+	// in the hmc.mirantis.com/identity-namespaces annotation of the referenced ClusterIdentity,
+	// the referenced ClusterIdentity itself is expected to not allow any namespaces.
+	// The isolation has to be enabled in the controller with the --isolate-identities flag.
+	IsolateIdentity bool `json:"isolateIdentity,omitempty"`
 }
 
 // CredentialStatus defines the observed state of Credential
 type CredentialStatus struct {
-	State CredentialState `json:"state,omitempty"`
+	// IdentityRef is the reference to the ClusterIdentity the clusters using the Credential
+	// are deployed with, it references the isolated copy if the identity is isolated.
+	IdentityRef *corev1.ObjectReference `json:"identityRef,omitempty"`
+	State       CredentialState         `json:"state,omitempty"`
+}
+
+// ClusterIdentityRef returns the reference to the ClusterIdentity
+// the clusters using the Credential are deployed with.
+func (in *Credential) ClusterIdentityRef() *corev1.ObjectReference {
+	if in.Status.IdentityRef != nil {
+		return in.Status.IdentityRef
+	}
+	return in.Spec.IdentityRef
 }
 
 // +kubebuilder:object:root=true
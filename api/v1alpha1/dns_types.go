@@ -0,0 +1,108 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+const (
+	// DNSReleaseName is the name of the release of external-dns deployed on the clusters.
+	DNSReleaseName = "hmc-external-dns"
+	// DNSNamespace is the namespace of external-dns on the clusters.
+	DNSNamespace = "external-dns"
+	// DNSCredentialsSecretName is the name of the Secret holding the credentials
+	// of the DNS provider written to the clusters.
+	DNSCredentialsSecretName = "hmc-external-dns-credentials"
+	// DNSEndpointServiceName is the name of the Service publishing the name of
+	// the control plane endpoint of the cluster written to the clusters.
+	DNSEndpointServiceName = "hmc-api-endpoint"
+)
+
+// DNSConfig configures the external-dns deployed on the managed clusters as a service.
+// The external-dns of a cluster manages the records of the cluster subdomain of the zone
+// only, named after the cluster, e.g. <name>.<namespace>.clusters.example.com.
+type DNSConfig struct {
+	// Provider is the DNS provider of external-dns, e.g. aws, azure or cloudflare.
+	Provider string `json:"provider,omitempty"`
+	// Zone is the DNS zone the cluster subdomains are created in, e.g. clusters.example.com.
+	Zone string `json:"zone,omitempty"`
+	// CredentialsSecret is the name of the Secret holding the credentials of the DNS provider,
+	// located in the namespace of the ManagedCluster, the Management defaults name the Secret
+	// each of the namespaces provides. The Secret is written to the clusters and each of its
+	// keys is passed to external-dns in the environment variable of the same name.
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+	// Template is the ServiceTemplate of external-dns in the namespace of the ManagedCluster.
+	// The values set by HMC follow the layout of the external-dns chart.
+	Template string `json:"template,omitempty"`
+	// Values are merged over the values of external-dns set by HMC.
+	Values *apiextensionsv1.JSON `json:"values,omitempty"`
+}
+
+// WithDefaults returns the config with the fields not set taken from the defaults,
+// the defaults are typically the DNS config of the Management. The defaulted CredentialsSecret
+// names the Secret of the namespace of the cluster, the credentials never cross the namespaces.
+func (in *DNSConfig) WithDefaults(defaults *DNSConfig) *DNSConfig {
+	if defaults == nil {
+		return in.DeepCopy()
+	}
+
+	out := in.DeepCopy()
+	out.Provider = cmp.Or(out.Provider, defaults.Provider)
+	out.Zone = cmp.Or(out.Zone, defaults.Zone)
+	out.CredentialsSecret = cmp.Or(out.CredentialsSecret, defaults.CredentialsSecret)
+	out.Template = cmp.Or(out.Template, defaults.Template)
+	if out.Values == nil {
+		out.Values = defaults.Values.DeepCopy()
+	}
+	return out
+}
+
+// Validate returns an error if any of the provider, the zone, the credentials Secret and the template is not set.
+func (in *DNSConfig) Validate() error {
+	var errs error
+	if in.Provider == "" {
+		errs = errors.Join(errs, errors.New("the DNS provider is not set"))
+	}
+	if in.Zone == "" {
+		errs = errors.Join(errs, errors.New("the DNS zone is not set"))
+	}
+	if in.CredentialsSecret == "" {
+		errs = errors.Join(errs, errors.New("the credentials Secret of the DNS provider is not set"))
+	}
+	if in.Template == "" {
+		errs = errors.Join(errs, errors.New("the ServiceTemplate of external-dns is not set"))
+	}
+	return errs
+}
+
+// Domain returns the subdomain of the zone assigned to the ManagedCluster.
+func (in *DNSConfig) Domain(namespace, name string) string {
+	return fmt.Sprintf("%s.%s.%s", name, namespace, strings.TrimSuffix(in.Zone, "."))
+}
+
+// ManagedClusterDNSStatus is the state of the DNS integration of the cluster.
+type ManagedClusterDNSStatus struct {
+	// Domain is the subdomain of the zone assigned to the cluster.
+	Domain string `json:"domain,omitempty"`
+	// EndpointName is the DNS name of the control plane endpoint of the cluster,
+	// published once the endpoint of the cluster is known.
+	EndpointName string `json:"endpointName,omitempty"`
+}
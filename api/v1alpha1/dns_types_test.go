@@ -0,0 +1,52 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestDNSConfigWithDefaults(t *testing.T) {
+	defaults := &DNSConfig{
+		Provider:          "aws",
+		Zone:              "clusters.example.com.",
+		CredentialsSecret: "route53",
+		Template:          "external-dns-1-15-0",
+		Values:            &apiextensionsv1.JSON{Raw: []byte(`{"interval":"5m"}`)},
+	}
+
+	config := (&DNSConfig{Zone: "dev.example.com"}).WithDefaults(defaults)
+	if config.Provider != "aws" || config.Zone != "dev.example.com" || config.Template != "external-dns-1-15-0" {
+		t.Errorf("unexpected config %+v", config)
+	}
+	if config.CredentialsSecret != "route53" {
+		t.Errorf("expected the default name of the credentials Secret, got %s", config.CredentialsSecret)
+	}
+	if string(config.Values.Raw) != `{"interval":"5m"}` {
+		t.Errorf("expected the default values, got %s", config.Values.Raw)
+	}
+	if err := config.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if domain := config.Domain("team-a", "dev"); domain != "dev.team-a.dev.example.com" {
+		t.Errorf("unexpected domain %s", domain)
+	}
+
+	if err := (&DNSConfig{}).WithDefaults(nil).Validate(); err == nil {
+		t.Error("expected the empty config to be invalid")
+	}
+}
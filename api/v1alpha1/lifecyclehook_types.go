@@ -0,0 +1,149 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// LifecycleHookPhase is the phase of the ManagedCluster lifecycle a hook participates in.
+type LifecycleHookPhase string
+
+const (
+	// LifecycleHookPreProvision hooks are awaited before the cluster is provisioned.
+	LifecycleHookPreProvision LifecycleHookPhase = "PreProvision"
+	// LifecycleHookPostReady hooks are awaited after the cluster becomes ready
+	// and before the services are deployed.
+	LifecycleHookPostReady LifecycleHookPhase = "PostReady"
+	// LifecycleHookPreDelete hooks are awaited before the cluster is deleted.
+	LifecycleHookPreDelete LifecycleHookPhase = "PreDelete"
+)
+
+const (
+	// LifecycleHookKind is the string representation of a LifecycleHook.
+	LifecycleHookKind = "LifecycleHook"
+
+	// LifecycleHooksCompletedCondition indicates all of the lifecycle hooks
+	// of the current phase have been acknowledged.
+	LifecycleHooksCompletedCondition = "LifecycleHooksCompleted"
+)
+
+// LifecycleHookSpec defines the desired state of LifecycleHook
+type LifecycleHookSpec struct {
+	// ClusterSelector selects the ManagedClusters in the namespace of the hook
+	// the hook applies to. An empty selector matches all of the ManagedClusters.
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector,omitempty"`
+
+	// Phase is the phase of the ManagedCluster lifecycle the hook participates in.
+	// +kubebuilder:validation:Enum=PreProvision;PostReady;PreDelete
+	Phase LifecycleHookPhase `json:"phase"`
+}
+
+// LifecycleHookStatus defines the observed state of LifecycleHook
+type LifecycleHookStatus struct {
+	// Acknowledgements is the list of the ManagedClusters the external
+	// controller has completed the hook for.
+	Acknowledgements []LifecycleHookAcknowledgement `json:"acknowledgements,omitempty"`
+}
+
+// LifecycleHookAcknowledgement records the completion of the hook for a ManagedCluster.
+type LifecycleHookAcknowledgement struct {
+	// Timestamp is the time the hook has been acknowledged.
+	Timestamp metav1.Time `json:"timestamp"`
+	// Cluster is the name of the ManagedCluster.
+	Cluster string `json:"cluster"`
+	// ClusterUID is the UID of the ManagedCluster, it prevents stale
+	// acknowledgements from being applied to a recreated ManagedCluster.
+	ClusterUID types.UID `json:"clusterUID"`
+	// Message is an optional message provided by the external controller.
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=lch
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.spec.phase`
+
+// LifecycleHook is the Schema for the lifecyclehooks API
+type LifecycleHook struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   LifecycleHookSpec   `json:"spec,omitempty"`
+	Status LifecycleHookStatus `json:"status,omitempty"`
+}
+
+// Matches returns true if the hook applies to the given ManagedCluster.
+func (in *LifecycleHook) Matches(cluster *ManagedCluster) (bool, error) {
+	if in.Namespace != cluster.Namespace {
+		return false, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&in.Spec.ClusterSelector)
+	if err != nil {
+		return false, err
+	}
+
+	return selector.Matches(labels.Set(cluster.Labels)), nil
+}
+
+// IsAcknowledged returns true if the hook has been completed for the given ManagedCluster.
+func (in *LifecycleHook) IsAcknowledged(cluster *ManagedCluster) bool {
+	for _, ack := range in.Status.Acknowledgements {
+		if ack.Cluster == cluster.Name && ack.ClusterUID == cluster.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// Acknowledge records the completion of the hook for the given ManagedCluster.
+// It returns false if the hook has already been acknowledged.
+func (in *LifecycleHook) Acknowledge(cluster *ManagedCluster, message string) bool {
+	if in.IsAcknowledged(cluster) {
+		return false
+	}
+
+	// drop acknowledgements of previous incarnations of the cluster
+	acks := in.Status.Acknowledgements[:0]
+	for _, ack := range in.Status.Acknowledgements {
+		if ack.Cluster != cluster.Name {
+			acks = append(acks, ack)
+		}
+	}
+
+	in.Status.Acknowledgements = append(acks, LifecycleHookAcknowledgement{
+		Timestamp:  metav1.Now(),
+		Cluster:    cluster.Name,
+		ClusterUID: cluster.UID,
+		Message:    message,
+	})
+	return true
+}
+
+// +kubebuilder:object:root=true
+
+// LifecycleHookList contains a list of LifecycleHook
+type LifecycleHookList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LifecycleHook `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LifecycleHook{}, &LifecycleHookList{})
+}
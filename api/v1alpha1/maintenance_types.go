@@ -0,0 +1,159 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The changes of a deployed ManagedCluster held back until the maintenance window opens.
+const (
+	// PendingChangeHelmRelease is the update of the HelmRelease of the cluster,
+	// e.g. the template upgrade or the configuration change.
+	PendingChangeHelmRelease = "HelmRelease"
+	// PendingChangeServices is the update of the services deployed on the cluster.
+	PendingChangeServices = "Services"
+)
+
+// Weekday is a day of the week.
+// +kubebuilder:validation:Enum=Monday;Tuesday;Wednesday;Thursday;Friday;Saturday;Sunday
+type Weekday string
+
+// MaintenanceWindow defines the time the changes of the deployed clusters are applied at.
+// The changes are held back outside of the windows.
+type MaintenanceWindow struct {
+	// +kubebuilder:validation:MinItems=1
+
+	// Windows is the list of the recurring time windows the changes are applied in.
+	Windows []TimeWindow `json:"windows"`
+	// TimeZone is the IANA name of the time zone the windows are defined in, UTC by default.
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// TimeWindow is a recurring time window.
+type TimeWindow struct {
+	// Days are the days of the week the window opens on, every day if empty.
+	Days []Weekday `json:"days,omitempty"`
+
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+
+	// Start is the time of the day the window opens at in the HH:MM format.
+	Start string `json:"start"`
+	// Duration is the time the window stays open for.
+	Duration metav1.Duration `json:"duration"`
+}
+
+// PendingMaintenance lists the changes of the cluster held back until the maintenance window opens.
+type PendingMaintenance struct {
+	// NextWindow is the time the next maintenance window opens at.
+	NextWindow *metav1.Time `json:"nextWindow,omitempty"`
+	// Changes are the kinds of the changes held back, HelmRelease or Services.
+	Changes []string `json:"changes"`
+}
+
+// Validate returns an error if the time zone or the start of a window is invalid.
+func (in *MaintenanceWindow) Validate() error {
+	if _, err := in.location(); err != nil {
+		return err
+	}
+	for _, window := range in.Windows {
+		if _, err := time.Parse("15:04", window.Start); err != nil {
+			return fmt.Errorf("invalid start %q of the maintenance window: %w", window.Start, err)
+		}
+		if window.Duration.Duration <= 0 {
+			return fmt.Errorf("the duration of the maintenance window starting at %s must be positive", window.Start)
+		}
+	}
+	return nil
+}
+
+// IsOpen returns true if the given time falls into any of the windows.
+func (in *MaintenanceWindow) IsOpen(now time.Time) (bool, error) {
+	openings, err := in.openings(now, -maxWindowDays, 0)
+	if err != nil {
+		return false, err
+	}
+	for _, opening := range openings {
+		if !now.Before(opening.start) && now.Before(opening.start.Add(opening.duration)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// NextOpening returns the time the next window opens at after the given time.
+func (in *MaintenanceWindow) NextOpening(now time.Time) (time.Time, error) {
+	openings, err := in.openings(now, 0, maxWindowDays)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var next time.Time
+	for _, opening := range openings {
+		if opening.start.After(now) && (next.IsZero() || opening.start.Before(next)) {
+			next = opening.start
+		}
+	}
+	return next, nil
+}
+
+// maxWindowDays is the number of the days the windows are looked up for around the given time.
+const maxWindowDays = 8
+
+type windowOpening struct {
+	start    time.Time
+	duration time.Duration
+}
+
+// openings returns the openings of the windows on the days from the given offsets from the day of now.
+func (in *MaintenanceWindow) openings(now time.Time, fromDay, toDay int) ([]windowOpening, error) {
+	loc, err := in.location()
+	if err != nil {
+		return nil, err
+	}
+
+	now = now.In(loc)
+	var openings []windowOpening
+	for _, window := range in.Windows {
+		start, err := time.Parse("15:04", window.Start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start %q of the maintenance window: %w", window.Start, err)
+		}
+
+		for day := fromDay; day <= toDay; day++ {
+			opening := time.Date(now.Year(), now.Month(), now.Day()+day, start.Hour(), start.Minute(), 0, 0, loc)
+			if len(window.Days) > 0 && !slices.Contains(window.Days, Weekday(opening.Weekday().String())) {
+				continue
+			}
+			openings = append(openings, windowOpening{start: opening, duration: window.Duration.Duration})
+		}
+	}
+	return openings, nil
+}
+
+func (in *MaintenanceWindow) location() (*time.Location, error) {
+	if in.TimeZone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(in.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q of the maintenance window: %w", in.TimeZone, err)
+	}
+	return loc, nil
+}
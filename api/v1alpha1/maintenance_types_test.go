@@ -0,0 +1,81 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMaintenanceWindow(t *testing.T) {
+	window := &MaintenanceWindow{
+		Windows: []TimeWindow{
+			{Days: []Weekday{"Saturday", "Sunday"}, Start: "22:00", Duration: metav1.Duration{Duration: 4 * time.Hour}},
+		},
+	}
+
+	tests := []struct {
+		now  time.Time
+		open bool
+		next time.Time
+	}{
+		// Friday
+		{time.Date(2024, 11, 1, 23, 0, 0, 0, time.UTC), false, time.Date(2024, 11, 2, 22, 0, 0, 0, time.UTC)},
+		// Saturday
+		{time.Date(2024, 11, 2, 22, 0, 0, 0, time.UTC), true, time.Date(2024, 11, 3, 22, 0, 0, 0, time.UTC)},
+		// Sunday, the window opened on Saturday is still open
+		{time.Date(2024, 11, 3, 1, 59, 0, 0, time.UTC), true, time.Date(2024, 11, 3, 22, 0, 0, 0, time.UTC)},
+		// Monday, the window opened on Sunday is closed
+		{time.Date(2024, 11, 4, 2, 0, 0, 0, time.UTC), false, time.Date(2024, 11, 9, 22, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		open, err := window.IsOpen(test.now)
+		if err != nil {
+			t.Fatalf("IsOpen(%s) error = %v", test.now, err)
+		}
+		if open != test.open {
+			t.Errorf("IsOpen(%s) = %v, want %v", test.now, open, test.open)
+		}
+
+		next, err := window.NextOpening(test.now)
+		if err != nil {
+			t.Fatalf("NextOpening(%s) error = %v", test.now, err)
+		}
+		if !next.Equal(test.next) {
+			t.Errorf("NextOpening(%s) = %s, want %s", test.now, next, test.next)
+		}
+	}
+}
+
+func TestMaintenanceWindowValidate(t *testing.T) {
+	tests := []struct {
+		window  MaintenanceWindow
+		isValid bool
+	}{
+		{MaintenanceWindow{Windows: []TimeWindow{{Start: "03:30", Duration: metav1.Duration{Duration: time.Hour}}}, TimeZone: "Europe/Berlin"}, true},
+		{MaintenanceWindow{Windows: []TimeWindow{{Start: "03:30", Duration: metav1.Duration{Duration: time.Hour}}}, TimeZone: "Mars/Olympus"}, false},
+		{MaintenanceWindow{Windows: []TimeWindow{{Start: "25:00", Duration: metav1.Duration{Duration: time.Hour}}}}, false},
+		{MaintenanceWindow{Windows: []TimeWindow{{Start: "03:30"}}}, false},
+	}
+
+	for _, test := range tests {
+		if err := test.window.Validate(); (err == nil) != test.isValid {
+			t.Errorf("Validate(%+v) error = %v, want valid %v", test.window, err, test.isValid)
+		}
+	}
+}
@@ -15,9 +15,15 @@
 package v1alpha1
 
 import (
+	"maps"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/yaml"
 )
 
@@ -32,6 +38,80 @@ const (
 	HMCManagedLabelValue = "true"
 
 	ClusterNameLabelKey = "cluster.x-k8s.io/cluster-name"
+
+	// ManagedClusterLabelKey is set on the Sveltos Profiles deploying the services
+	// of a ManagedCluster and on the ConfigMaps with the rendered manifests
+	// of a ManagedCluster to the name of the ManagedCluster.
+	ManagedClusterLabelKey = "hmc.mirantis.com/managed-cluster"
+
+	// ServicesDrainingLabelKey is set on the CAPI Cluster of a deleted ManagedCluster
+	// to withdraw the services of the MultiClusterServices from the cluster, the Sveltos
+	// profiles do not select the clusters with the label.
+	ServicesDrainingLabelKey = "hmc.mirantis.com/services-draining"
+
+	// GitOpsRegistrationAnnotation is set on the Secrets registering the clusters in the GitOps
+	// tooling to the namespace/name of the registered ManagedCluster, the Secrets without
+	// the annotation are neither overwritten nor deleted by HMC.
+	GitOpsRegistrationAnnotation = "hmc.mirantis.com/gitops-registration"
+
+	// ShardLabelKey assigns the namespace to the controller shard with the given name.
+	// The objects in the namespaces without the label are reconciled by the default shard.
+	ShardLabelKey = "hmc.mirantis.com/shard"
+
+	// AllowTemplateChangeAnnotation allows to change the ClusterTemplate of the ManagedCluster
+	// to one which is not in the list of the available upgrades when set to "true".
+	AllowTemplateChangeAnnotation = "hmc.mirantis.com/allow-template-change"
+
+	// DiffRequestedAnnotation requests a report of the changes the next reconciliation
+	// applies to the ManagedCluster. The annotation is removed once the report is ready.
+	DiffRequestedAnnotation = "hmc.mirantis.com/diff-requested"
+
+	// ManagedClusterBackupScheduleName is the name of the backup schedule created on the cluster.
+	ManagedClusterBackupScheduleName = "hmc-cluster-backup"
+	// ManagedClusterBackupReleaseName is the name of the release of the backup agent.
+	ManagedClusterBackupReleaseName = "hmc-backup-agent"
+	// ManagedClusterBackupDefaultNamespace is the default namespace of the backup agent.
+	ManagedClusterBackupDefaultNamespace = "velero"
+
+	// PropagatedLabelsAnnotation lists the keys of the labels propagated to the CAPI Cluster
+	// from the ManagedCluster, so that the labels removed from the ManagedCluster are removed as well.
+	PropagatedLabelsAnnotation = "hmc.mirantis.com/propagated-labels"
+	// PropagatedAnnotationsAnnotation lists the keys of the annotations propagated to the CAPI Cluster.
+	PropagatedAnnotationsAnnotation = "hmc.mirantis.com/propagated-annotations"
+	// AppliedValuesAnnotation lists the paths of the values applied to the HelmRelease reusing its values,
+	// so that the values removed from the config are removed from the release as well.
+	AppliedValuesAnnotation = "hmc.mirantis.com/applied-values"
+
+	// ForceNamespaceDeletionAnnotation allows to delete the namespace hosting ManagedClusters
+	// when set to "true" on the namespace. The cloud resources of the clusters may be left orphaned.
+	ForceNamespaceDeletionAnnotation = "hmc.mirantis.com/force-namespace-deletion"
+
+	// ManagedClusterHistoryLimit is the maximum number of entries kept in the ManagedCluster history.
+	ManagedClusterHistoryLimit = 10
+
+	// ManagedClusterGenerationLabelKey is set on the ConfigMaps with the rendered manifests
+	// of a ManagedCluster to the generation of the ManagedCluster the manifests are rendered for.
+	ManagedClusterGenerationLabelKey = "hmc.mirantis.com/generation"
+
+	// CostCenterAnnotation attributes the cloud spend of the ManagedCluster to the given
+	// cost center, the value is added to the usage metrics of the cluster.
+	CostCenterAnnotation = "hmc.mirantis.com/cost-center"
+
+	// NamespaceDefaultAnnotation marks the Credential or the ClusterTemplate as the default of its namespace
+	// when set to "true". The ManagedClusters created in the namespace without the credential or the template
+	// are defaulted to the marked ones.
+	NamespaceDefaultAnnotation = "hmc.mirantis.com/namespace-default"
+
+	// LatestTemplateSuffix resolves the template of the ManagedCluster set to "<chain>@latest"
+	// to the latest ClusterTemplate of the ClusterTemplateChain with the given name.
+	LatestTemplateSuffix = "@latest"
+
+	// workersNumberKey is the key of the number of the worker machines
+	// in the configuration values of the HMC cluster templates.
+	workersNumberKey = "workersNumber"
+	// DefaultWorkerPoolName is the name of the single worker pool of the HMC
+	// templates, the pool has the workersNumber machines unless set otherwise.
+	DefaultWorkerPoolName = "worker"
 )
 
 const (
@@ -44,27 +124,136 @@ const (
 	CredentialsPropagatedCondition = "CredentialsApplied"
 	// TemplateReadyCondition indicates the referenced Template exists and valid.
 	TemplateReadyCondition = "TemplateReady"
+	// ProvidersEnabledCondition indicates all of the providers required by the Template are enabled in the Management.
+	ProvidersEnabledCondition = "ProvidersEnabled"
 	// HelmChartReadyCondition indicates the corresponding HelmChart is valid and ready.
 	HelmChartReadyCondition = "HelmChartReady"
 	// HelmReleaseReadyCondition indicates the corresponding HelmRelease is ready and fully reconciled.
 	HelmReleaseReadyCondition = "HelmReleaseReady"
+	// ServicesK8sCompatibleCondition indicates the Kubernetes version of the cluster
+	// satisfies the constraints of the ServiceTemplates of the cluster services.
+	ServicesK8sCompatibleCondition = "ServicesK8sCompatible"
+	// TemplateDeprecatedCondition indicates the ClusterTemplate or some of the ServiceTemplates
+	// of the cluster are deprecated. The condition is set only while the templates are deprecated.
+	TemplateDeprecatedCondition = "TemplateDeprecated"
+	// CleanupIncompleteCondition indicates the provider objects of the deleted ManagedCluster
+	// are left behind, the objects may hold the cloud resources of the cluster.
+	CleanupIncompleteCondition = "CleanupIncomplete"
+	// DeletingCondition indicates the deletion of the ManagedCluster waits for the objects listed
+	// in its message, e.g. the HelmRelease or the Machines. The condition is set only while the
+	// deletion is blocked.
+	DeletingCondition = "Deleting"
+	// GitOpsRegisteredCondition indicates the cluster is registered in the GitOps tooling.
+	GitOpsRegisteredCondition = "GitOpsRegistered"
+	// ServiceConflictCondition indicates some of the services are not deployed since another
+	// object already manages them. The condition is set only while the conflicts persist.
+	ServiceConflictCondition = "ServiceConflict"
+	// ApplyConflictCondition indicates some of the fields of the objects applied by HMC, e.g. the
+	// HelmRelease or the Sveltos profiles, are managed by other field managers, so the objects are
+	// not updated. The condition is set to True only while the conflict persists.
+	ApplyConflictCondition = "ApplyConflict"
+	// ServicesDrainedCondition indicates the services are withdrawn from the deleted cluster
+	// before the cluster is torn down. The condition is set only once the cluster is deleted.
+	ServicesDrainedCondition = "ServicesDrained"
+	// CloudResourcesCleanedCondition indicates the LoadBalancer Services and the PersistentVolumeClaims
+	// are removed from the deleted cluster. The condition is set only if the pre-delete cleanup is enabled.
+	CloudResourcesCleanedCondition = "CloudResourcesCleaned"
+	// ImagesValidCondition indicates the machine images pinned in the node pools are available
+	// in the cloud. The condition is set only if the images are pinned.
+	ImagesValidCondition = "ImagesValid"
+	// QuotaAdmittedCondition indicates the ManagedCluster fits the ClusterQuotas of its namespace
+	// counted along with the clusters created before it. The cluster is not deployed until it fits,
+	// the condition is set only if the namespace has any ClusterQuota.
+	QuotaAdmittedCondition = "QuotaAdmitted"
+	// KubeconfigValidCondition indicates the kubeconfig Secret of the cluster holds a valid kubeconfig
+	// the fingerprint of the CA certificate is recorded from. The condition is set once the Secret is created.
+	KubeconfigValidCondition = "KubeconfigValid"
 	// ReadyCondition indicates the ManagedCluster is ready and fully reconciled.
 	ReadyCondition string = "Ready"
 )
 
+// ManagedClusterConditionTypes are the types of the conditions HMC sets on the ManagedClusters,
+// the readiness gates can not refer to them.
+var ManagedClusterConditionTypes = []string{
+	CredentialReadyCondition,
+	CredentialsPropagatedCondition,
+	TemplateReadyCondition,
+	ProvidersEnabledCondition,
+	HelmChartReadyCondition,
+	HelmReleaseReadyCondition,
+	ServicesK8sCompatibleCondition,
+	TemplateDeprecatedCondition,
+	CleanupIncompleteCondition,
+	DeletingCondition,
+	GitOpsRegisteredCondition,
+	ServiceConflictCondition,
+	ApplyConflictCondition,
+	ServicesDrainedCondition,
+	CloudResourcesCleanedCondition,
+	ImagesValidCondition,
+	QuotaAdmittedCondition,
+	KubeconfigValidCondition,
+	LifecycleHooksCompletedCondition,
+	ReadyCondition,
+}
+
+const (
+	// DeprecatedReason is set when the templates used by the cluster are deprecated.
+	DeprecatedReason = "Deprecated"
+	// SunsetReason is set when the sunset date of some of the templates used by the cluster has passed.
+	SunsetReason = "Sunset"
+	// ConflictReason is set when some of the services are managed by another object.
+	ConflictReason = "Conflict"
+	// DownloadFailedReason is set when the Helm chart of the template cannot be downloaded.
+	DownloadFailedReason = "DownloadFailed"
+	// ChecksumMismatchReason is set when the downloaded Helm chart does not match the digest of the Flux Artifact.
+	ChecksumMismatchReason = "ChecksumMismatch"
+	// TimedOutReason is set when the services are not withdrawn from the deleted cluster in time.
+	TimedOutReason = "TimedOut"
+	// ImageNotFoundReason is set when some of the machine images pinned in the node pools are not available in the cloud.
+	ImageNotFoundReason = "ImageNotFound"
+)
+
+const (
+	// DefaultServicesDrainTimeout is the default time the deletion of the cluster
+	// waits for the services to be withdrawn from the cluster.
+	DefaultServicesDrainTimeout = 10 * time.Minute
+	// DefaultPreDeleteCleanupTimeout is the default time the deletion of the cluster
+	// waits for the cloud resources to be removed by the pre-delete cleanup.
+	DefaultPreDeleteCleanupTimeout = 10 * time.Minute
+)
+
 // ManagedClusterSpec defines the desired state of ManagedCluster
 type ManagedClusterSpec struct {
+	// CloneFrom is the name of an existing ManagedCluster in the same namespace the cluster
+	// is created from. The fields of the spec not set on the creation are copied from
+	// the existing cluster, the config is merged onto the config of the existing cluster
+	// without the instance-specific values, e.g. the control plane endpoint. The field is immutable.
+	CloneFrom string `json:"cloneFrom,omitempty"`
+
 	// Config allows to provide parameters for template customization.
 	// If no Config provided, the field will be populated with the default values for
 	// the template and DryRun will be enabled.
 	Config *apiextensionsv1.JSON `json:"config,omitempty"`
 
+	// +kubebuilder:default:=Merge
+
+	// ConfigMergeStrategy defines how the Config is combined with the default values of the template.
+	// Merge deep merges the maps and replaces the lists, StrategicMerge additionally merges the lists
+	// of objects by their "name" field, Replace replaces the top-level values of the defaults entirely.
+	ConfigMergeStrategy ConfigMergeStrategy `json:"configMergeStrategy,omitempty"`
+
 	// +kubebuilder:validation:MinLength=1
 
 	// Template is a reference to a Template object located in the same namespace.
+	// The "<chain>@latest" reference is resolved to the latest ClusterTemplate of the ClusterTemplateChain.
 	Template string `json:"template"`
 	// Name reference to the related Credentials object.
 	Credential string `json:"credential,omitempty"`
+	// IdentityCredentials maps the values paths of the template to the names of the Credentials of the
+	// namespace whose ClusterIdentity is passed at the path instead of the one of the Credential,
+	// e.g. "controlPlane.identityRef: cp-cred" for the templates using several identities.
+	IdentityCredentials map[string]string `json:"identityCredentials,omitempty"`
 	// Services is a list of services created via ServiceTemplates
 	// that could be installed on the target cluster.
 	Services []ServiceSpec `json:"services,omitempty"`
@@ -88,30 +277,470 @@ type ManagedClusterSpec struct {
 	// By default the remaining services will be deployed even if conflict is detected.
 	// If set to true, the deployment will stop after encountering the first conflict.
 	StopOnConflict bool `json:"stopOnConflict,omitempty"`
+	// ServicesDrainTimeout is the time the deletion of the cluster waits for the services
+	// to be withdrawn from the cluster before the cluster is torn down, e.g. to let the
+	// applications deprovision the cloud load balancers and volumes. Defaults to 10m,
+	// 0s tears the cluster down right away.
+	ServicesDrainTimeout *metav1.Duration `json:"servicesDrainTimeout,omitempty"`
+	// PreDeleteCleanup enables the removal of the LoadBalancer Services and the PersistentVolumeClaims
+	// from the cluster before the cluster is torn down, so that the cloud provider releases the load
+	// balancers and the disks of the cluster.
+	PreDeleteCleanup *PreDeleteCleanupSpec `json:"preDeleteCleanup,omitempty"`
+	// Backup enables the scheduled backups of the workloads of the cluster.
+	Backup *ManagedClusterBackupSpec `json:"backup,omitempty"`
+	// DNS deploys external-dns on the cluster managing the records of the cluster subdomain,
+	// the fields not set are taken from the DNS config of the Management.
+	DNS *DNSConfig `json:"dns,omitempty"`
+	// Autoscaler deploys cluster-autoscaler on the cluster scaling the worker pools
+	// with the autoscaling set in the NodePools.
+	Autoscaler *ClusterAutoscalerSpec `json:"autoscaler,omitempty"`
+	// ClusterLabels are the labels ensured on the CAPI Cluster object of the cluster.
+	// The labels can be used by the cluster selectors of the services, e.g. env=prod.
+	ClusterLabels map[string]string `json:"clusterLabels,omitempty"`
+	// ClusterAnnotations are the annotations ensured on the CAPI Cluster object of the cluster.
+	ClusterAnnotations map[string]string `json:"clusterAnnotations,omitempty"`
+
+	// MaintenanceWindow restricts the time the changes of the deployed cluster are applied at:
+	// the template upgrades, the configuration changes and the service changes are held back
+	// outside of the window. The window of the Management is used if not set.
+	MaintenanceWindow *MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+
+	// +listType=map
+	// +listMapKey=conditionType
+
+	// ReadinessGates are the conditions in the status of the ManagedCluster which must be True
+	// for the cluster to become Ready, in addition to the conditions set by HMC. The conditions
+	// are expected to be set by the external controllers, e.g. the validation pipelines.
+	ReadinessGates []ReadinessGate `json:"readinessGates,omitempty"`
+
+	// +listType=map
+	// +listMapKey=name
+
+	// NodePools declares the labels, taints and autoscaling of the nodes of the worker pools of the cluster.
+	// The pools are passed to the template in the "nodePools" value keyed by the pool name,
+	// the templates apply them to the nodes when the nodes join the cluster. Only the pools
+	// declared in the values schema of the template are accepted.
+	NodePools []NodePoolSpec `json:"nodePools,omitempty"`
+	// MachineHealthCheck enables the remediation of the unhealthy worker machines of the cluster.
+	// It is passed to the template in the "machineHealthCheck" value, the templates render it
+	// into the CAPI MachineHealthCheck of the worker machines.
+	MachineHealthCheck *MachineHealthCheckSpec `json:"machineHealthCheck,omitempty"`
+
+	// The typed config sections below are merged into the values of the template
+	// over the Config. A section is set only at the values defined by the default
+	// values of the template, the values follow the layout of the HMC templates.
+
+	// ControlPlane configures the control plane machines of the cluster.
+	ControlPlane *ControlPlaneConfig `json:"controlPlane,omitempty"`
+
+	// +listType=map
+	// +listMapKey=name
+
+	// Workers configures the worker pools of the cluster,
+	// the HMC templates define a single pool named "worker".
+	Workers []WorkerPoolConfig `json:"workers,omitempty"`
+	// Network configures the infrastructure network the machines of the cluster are attached to.
+	Network *NetworkConfig `json:"network,omitempty"`
+	// SSHKey is the SSH key the machines of the cluster are accessible with:
+	// the name of the EC2 key pair on AWS, the public key on Azure and vSphere.
+	SSHKey string `json:"sshKey,omitempty"`
+	// HelmRelease tunes the HelmRelease deploying the cluster,
+	// e.g. increases the timeouts for the slow providers.
+	HelmRelease *HelmReleaseTuning `json:"helmRelease,omitempty"`
+	// GitOps registers the cluster in the GitOps tooling running in the management cluster
+	// once the cluster is ready, so the tooling can target the cluster right away.
+	GitOps *GitOpsRegistration `json:"gitops,omitempty"`
+}
+
+// GitOpsProvider is the GitOps tooling the cluster is registered in.
+type GitOpsProvider string
+
+const (
+	// GitOpsProviderArgoCD registers the cluster with an ArgoCD cluster Secret.
+	GitOpsProviderArgoCD GitOpsProvider = "ArgoCD"
+	// GitOpsProviderFlux provides the kubeconfig Secret of the cluster
+	// to be referenced by the kubeConfig of the Flux objects.
+	GitOpsProviderFlux GitOpsProvider = "Flux"
+)
+
+// GitOpsRegistration defines the registration of the cluster in the GitOps tooling.
+type GitOpsRegistration struct {
+	// +kubebuilder:validation:Enum=ArgoCD;Flux
+
+	// Provider is the GitOps tooling the cluster is registered in.
+	Provider GitOpsProvider `json:"provider"`
+	// Namespace is the namespace of the registration Secret, defaults to "argocd"
+	// for ArgoCD and to the namespace of the ManagedCluster for Flux. The namespace
+	// must be one of the GitOps namespaces the controller is allowed to write to.
+	Namespace string `json:"namespace,omitempty"`
+	// Labels are set on the registration Secret, e.g. to be matched by the ArgoCD cluster generator.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// HelmReleaseTuning tunes the install and upgrade of the HelmRelease of the cluster.
+type HelmReleaseTuning struct {
+	// Timeout is the time to wait for the install and upgrade of the release,
+	// defaults to 5 minutes.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// +kubebuilder:validation:Minimum=0
+
+	// RemediationRetries is the number of retries of the failed install and upgrade of the release.
+	// The failures are not retried by default.
+	RemediationRetries int32 `json:"remediationRetries,omitempty"`
+
+	// +kubebuilder:validation:Enum=enabled;warn;disabled
+
+	// DriftDetectionMode defines how the drift of the deployed resources from the release is handled.
+	// "enabled" corrects the drift, "warn" only reports it, the drift detection is disabled by default.
+	DriftDetectionMode string `json:"driftDetectionMode,omitempty"`
+
+	// Suspend suspends the reconciliation of the HelmRelease of the cluster, e.g. for a maintenance.
+	// The changes of the ManagedCluster are not applied to the cluster while suspended.
+	Suspend bool `json:"suspend,omitempty"`
+	// ReuseValues keeps the values of the deployed release and merges the config onto them
+	// on the upgrades, the equivalent of the helm upgrade --reuse-values.
+	// The values removed from the config are removed from the release as well.
+	ReuseValues bool `json:"reuseValues,omitempty"`
+}
+
+// NodePoolSpec declares the labels and taints of the nodes of a worker pool.
+type NodePoolSpec struct {
+	// +kubebuilder:validation:MinLength=1
+
+	// Name is the name of the worker pool as defined by the template,
+	// the HMC templates define a single pool named "worker".
+	Name string `json:"name"`
+	// Labels are the labels set on the nodes of the pool. The kubelet rejects the labels
+	// in the kubernetes.io and k8s.io namespaces not allowed by the NodeRestriction admission plugin.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Taints are the taints set on the nodes of the pool.
+	Taints []corev1.Taint `json:"taints,omitempty"`
+	// Autoscaling sets the size limits of the pool for cluster-autoscaler, the number
+	// of the nodes of the pool is left to the autoscaler once set.
+	Autoscaling *NodePoolAutoscaling `json:"autoscaling,omitempty"`
+	// ImageRef pins the machine image of the nodes of the pool, the image is verified
+	// to be available in the cloud before the machines of the cluster are created.
+	ImageRef *ImageRef `json:"imageRef,omitempty"`
+}
+
+// ImageRef refers to the machine image of the nodes, exactly one of the fields is set.
+type ImageRef struct {
+	// ID is the ID of the image, the AMI ID on AWS or the name of the VM template on vSphere.
+	ID string `json:"id,omitempty"`
+	// Marketplace is the Azure Marketplace image.
+	Marketplace *MarketplaceImage `json:"marketplace,omitempty"`
+}
+
+// MarketplaceImage refers to an image of the Azure Marketplace.
+type MarketplaceImage struct {
+	// +kubebuilder:validation:MinLength=1
+
+	// Publisher is the publisher of the image.
+	Publisher string `json:"publisher"`
+	// +kubebuilder:validation:MinLength=1
+
+	// Offer is the offer of the image.
+	Offer string `json:"offer"`
+	// +kubebuilder:validation:MinLength=1
+
+	// SKU is the SKU of the image.
+	SKU string `json:"sku"`
+	// +kubebuilder:validation:MinLength=1
+
+	// Version is the version of the image.
+	Version string `json:"version"`
 }
 
+// MachineHealthCheckSpec configures the remediation of the unhealthy worker machines,
+// the fields follow the CAPI MachineHealthCheck.
+type MachineHealthCheckSpec struct {
+	// UnhealthyConditions are the conditions of the nodes which mark the machines unhealthy
+	// once they last longer than the timeout. Defaults to the Ready condition being False
+	// or Unknown for 5m.
+	UnhealthyConditions []UnhealthyCondition `json:"unhealthyConditions,omitempty"`
+
+	// +kubebuilder:validation:XIntOrString
+	// +kubebuilder:validation:Pattern=`^((100|[0-9]{1,2})%|[0-9]+)$`
+
+	// MaxUnhealthy is the number or the percentage of the unhealthy worker machines
+	// above which the remediation is stopped. The remediation is not limited if not set.
+	MaxUnhealthy *intstr.IntOrString `json:"maxUnhealthy,omitempty"`
+	// NodeStartupTimeout is the time a machine waits for its node to join the cluster
+	// before it is considered unhealthy. Defaults to the CAPI default of 10m.
+	NodeStartupTimeout *metav1.Duration `json:"nodeStartupTimeout,omitempty"`
+}
+
+// UnhealthyCondition is a condition of the nodes marking the machines unhealthy.
+type UnhealthyCondition struct {
+	// Type is the type of the node condition, e.g. Ready.
+	Type corev1.NodeConditionType `json:"type"`
+
+	// +kubebuilder:validation:Enum=True;False;Unknown
+
+	// Status is the status of the node condition.
+	Status corev1.ConditionStatus `json:"status"`
+	// Timeout is the time the node condition lasts before the machine is considered unhealthy.
+	Timeout metav1.Duration `json:"timeout"`
+}
+
+// ControlPlaneConfig configures the control plane machines of the cluster.
+type ControlPlaneConfig struct {
+	// +kubebuilder:validation:Minimum=1
+
+	// Replicas is the number of the control plane machines.
+	// The templates with the hosted control plane ignore it.
+	Replicas *int32 `json:"replicas,omitempty"`
+}
+
+// WorkerPoolConfig configures a worker pool of the cluster.
+type WorkerPoolConfig struct {
+	// +kubebuilder:validation:MinLength=1
+
+	// Name is the name of the worker pool as defined by the template.
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:Minimum=0
+
+	// Replicas is the number of the machines of the pool.
+	Replicas *int32 `json:"replicas,omitempty"`
+}
+
+// NetworkConfig configures the infrastructure network of the cluster.
+type NetworkConfig struct {
+	// VPCID is the ID of the existing AWS VPC the cluster is deployed into.
+	VPCID string `json:"vpcID,omitempty"`
+	// Name is the name of the network the machines are attached to,
+	// the Azure virtual network or the vSphere network.
+	Name string `json:"name,omitempty"`
+	// Subnet is the name of the Azure subnet the machines are attached to.
+	Subnet string `json:"subnet,omitempty"`
+}
+
+// ConfigMergeStrategy defines how the config is combined with the default values.
+// +kubebuilder:validation:Enum=Merge;StrategicMerge;Replace
+type ConfigMergeStrategy string
+
+const (
+	// ConfigMergeStrategyMerge deep merges the maps, the lists of the config replace the defaults.
+	ConfigMergeStrategyMerge ConfigMergeStrategy = "Merge"
+	// ConfigMergeStrategyStrategicMerge deep merges the maps and the lists of objects by their "name" field.
+	ConfigMergeStrategyStrategicMerge ConfigMergeStrategy = "StrategicMerge"
+	// ConfigMergeStrategyReplace replaces the top-level values of the defaults with the values of the config.
+	ConfigMergeStrategyReplace ConfigMergeStrategy = "Replace"
+)
+
+// ManagedClusterBackupSpec configures the backups of the workloads of the cluster
+// performed by a backup agent deployed to the cluster as a service.
+type ManagedClusterBackupSpec struct {
+	// Values is the helm values passed to the backup agent chart,
+	// e.g. the configuration of the backup storage location.
+	Values *apiextensionsv1.JSON `json:"values,omitempty"`
+
+	// +kubebuilder:validation:MinLength=1
+
+	// Template is a reference to the ServiceTemplate of the backup agent located
+	// in the same namespace. The chart is expected to accept the values of the Velero chart.
+	Template string `json:"template"`
+	// Namespace is the namespace the backup agent will be installed in.
+	// It will default to "velero" if not provided.
+	Namespace string `json:"namespace,omitempty"`
+
+	// +kubebuilder:validation:MinLength=1
+
+	// Schedule is the Cron expression defining when to run the backups.
+	Schedule string `json:"schedule"`
+	// TTL is the retention period of the backups. The default retention of the agent is used if not provided.
+	TTL metav1.Duration `json:"ttl,omitempty"`
+	// IncludedNamespaces is the list of the namespaces to back up, all of the namespaces are backed up if empty.
+	IncludedNamespaces []string `json:"includedNamespaces,omitempty"`
+}
+
+// ReadinessGate refers to a condition of the ManagedCluster required for the cluster to be Ready.
+type ReadinessGate struct {
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=316
+
+	// ConditionType is the type of the condition in the status of the ManagedCluster.
+	ConditionType string `json:"conditionType"`
+}
+
+// PreDeleteCleanupSpec configures the removal of the cloud resources
+// of the workloads before the cluster is torn down.
+type PreDeleteCleanupSpec struct {
+	// Timeout is the time the deletion of the cluster waits for the cloud resources to be
+	// removed before the cluster is torn down anyway. Defaults to 10m.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// SkipLoadBalancers disables the removal of the Services of the LoadBalancer type.
+	SkipLoadBalancers bool `json:"skipLoadBalancers,omitempty"`
+	// SkipVolumes disables the removal of the PersistentVolumeClaims.
+	SkipVolumes bool `json:"skipVolumes,omitempty"`
+}
+
+// ManagedClusterPhase is a summary of the current state of the ManagedCluster.
+type ManagedClusterPhase string
+
+const (
+	// ManagedClusterPhasePending means the cluster is yet to be deployed.
+	ManagedClusterPhasePending ManagedClusterPhase = "Pending"
+	// ManagedClusterPhaseProvisioning means the cluster is being deployed for the first time.
+	ManagedClusterPhaseProvisioning ManagedClusterPhase = "Provisioning"
+	// ManagedClusterPhaseReady means the cluster is deployed and all of its conditions are satisfied.
+	ManagedClusterPhaseReady ManagedClusterPhase = "Ready"
+	// ManagedClusterPhaseUpdating means the previously deployed cluster is being updated.
+	ManagedClusterPhaseUpdating ManagedClusterPhase = "Updating"
+	// ManagedClusterPhaseDeleting means the cluster is being deleted.
+	ManagedClusterPhaseDeleting ManagedClusterPhase = "Deleting"
+	// ManagedClusterPhaseFailed means the deployment of the cluster has failed.
+	ManagedClusterPhaseFailed ManagedClusterPhase = "Failed"
+)
+
 // ManagedClusterStatus defines the observed state of ManagedCluster
 type ManagedClusterStatus struct {
 	// Currently compatible exact Kubernetes version of the cluster. Being set only if
 	// provided by the corresponding ClusterTemplate.
 	KubernetesVersion string `json:"k8sVersion,omitempty"`
+	// HostedControlPlane is true if the control plane of the cluster is hosted
+	// within the management cluster as set by the corresponding ClusterTemplate.
+	HostedControlPlane bool `json:"hostedControlPlane,omitempty"`
+	// ControlPlaneEndpoint is the endpoint of the API server of the cluster as reported by the CAPI Cluster.
+	ControlPlaneEndpoint *APIEndpoint `json:"controlPlaneEndpoint,omitempty"`
+	// CAFingerprint is the SHA-256 fingerprint of the CA certificate of the cluster taken from
+	// the kubeconfig Secret of the cluster, e.g. sha256:9f86d0...
+	CAFingerprint string `json:"caFingerprint,omitempty"`
 	// Conditions contains details for the current state of the ManagedCluster.
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Phase is a summary of the current state of the ManagedCluster computed from its conditions.
+	// +kubebuilder:validation:Enum=Pending;Provisioning;Ready;Updating;Deleting;Failed
+	Phase ManagedClusterPhase `json:"phase,omitempty"`
 
 	// AvailableUpgrades is the list of ClusterTemplate names to which
 	// this cluster can be upgraded. It can be an empty array, which means no upgrades are
 	// available.
 	AvailableUpgrades []string `json:"availableUpgrades,omitempty"`
+	// DryRun contains the preview of the changes, it is set only if the dry run is enabled.
+	DryRun *ManagedClusterDryRunStatus `json:"dryRun,omitempty"`
+	// DiffReport references the last report requested with the DiffRequestedAnnotation.
+	DiffReport *ManagedClusterDiffReport `json:"diffReport,omitempty"`
+	// Backup is the state of the backups of the workloads of the cluster, it is set only if the backups are enabled.
+	Backup *ManagedClusterBackupStatus `json:"backup,omitempty"`
+	// DNS is the state of the DNS integration of the cluster, it is set only if the DNS is enabled.
+	DNS *ManagedClusterDNSStatus `json:"dns,omitempty"`
+	// GitOpsSecret references the Secret registering the cluster in the GitOps tooling.
+	GitOpsSecret *corev1.SecretReference `json:"gitopsSecret,omitempty"`
+	// ServiceConflicts lists the services not deployed on the cluster
+	// since another object already manages them.
+	ServiceConflicts []ServiceConflict `json:"serviceConflicts,omitempty"`
+	// History contains the last deployments of the ManagedCluster, the newest entry comes last.
+	// A new entry is recorded every time the generated HelmRelease changes.
+	History []ManagedClusterHistoryEntry `json:"history,omitempty"`
+	// PendingMaintenance lists the changes held back until the maintenance window opens.
+	PendingMaintenance *PendingMaintenance `json:"pendingMaintenance,omitempty"`
+	// Usage reports the machines of the cluster for the attribution of the cloud spend.
+	Usage *ManagedClusterUsage `json:"usage,omitempty"`
 	// ObservedGeneration is the last observed generation.
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
+// APIEndpoint is the endpoint of the API server of a cluster.
+type APIEndpoint struct {
+	// Host is the hostname or the IP address of the API server.
+	Host string `json:"host"`
+	// Port is the port of the API server.
+	Port int32 `json:"port"`
+}
+
+// ManagedClusterUsage reports the machines of the cluster for the attribution of the cloud spend.
+type ManagedClusterUsage struct {
+	// ProvisionedAt is the time the cluster has become ready for the first time.
+	ProvisionedAt *metav1.Time `json:"provisionedAt,omitempty"`
+	// ControlPlaneMachines is the number of the control plane machines of the cluster.
+	ControlPlaneMachines int32 `json:"controlPlaneMachines"`
+	// WorkerMachines is the number of the worker machines of the cluster.
+	WorkerMachines int32 `json:"workerMachines"`
+	// Machines lists the number of the machines of the cluster by the role and the instance type.
+	Machines []MachineUsage `json:"machines,omitempty"`
+}
+
+// MachineUsage is the number of the machines of the given role and instance type.
+type MachineUsage struct {
+	// +kubebuilder:validation:Enum=control-plane;worker
+
+	// Role is the role of the machines.
+	Role string `json:"role"`
+	// InstanceType is the instance type of the machines as defined by the infrastructure
+	// provider, e.g. the AWS instance type or the Azure VM size. Empty if not known.
+	InstanceType string `json:"instanceType,omitempty"`
+	// Count is the number of the machines.
+	Count int32 `json:"count"`
+}
+
+// ManagedClusterDryRunStatus is a preview of the changes the ManagedCluster would apply
+// to the currently deployed release. The objects are referenced as Kind/namespace/name.
+type ManagedClusterDryRunStatus struct {
+	// ManifestsConfigMap is the name of the ConfigMap in the ManagedCluster namespace
+	// containing the full rendered manifests. It is empty if the manifests are too large.
+	ManifestsConfigMap string `json:"manifestsConfigMap,omitempty"`
+	// Summary is a short summary of the changes.
+	Summary string `json:"summary,omitempty"`
+	// Added is the list of the objects to be created.
+	Added []string `json:"added,omitempty"`
+	// Changed is the list of the objects to be changed.
+	Changed []string `json:"changed,omitempty"`
+	// Removed is the list of the objects to be removed.
+	Removed []string `json:"removed,omitempty"`
+}
+
+// ManagedClusterDiffReport references the report of the changes applied to the ManagedCluster.
+type ManagedClusterDiffReport struct {
+	// Timestamp is the time the report has been produced.
+	Timestamp metav1.Time `json:"timestamp"`
+	// ConfigMap is the name of the ConfigMap in the ManagedCluster namespace containing the report.
+	ConfigMap string `json:"configMap"`
+	// Summary is a short summary of the changes.
+	Summary string `json:"summary,omitempty"`
+}
+
+// ManagedClusterBackupStatus is the state of the backups performed on the cluster.
+type ManagedClusterBackupStatus struct {
+	// LastBackup is the most recent backup created by the schedule.
+	LastBackup *ManagementBackupRef `json:"lastBackup,omitempty"`
+	// LastSuccessfulBackup is the most recent backup completed successfully.
+	LastSuccessfulBackup *ManagementBackupRef `json:"lastSuccessfulBackup,omitempty"`
+	// Error is the error message occurred while collecting the state of the backups (if any).
+	Error string `json:"error,omitempty"`
+}
+
+// ManagedClusterHistoryEntry describes a single deployment of the ManagedCluster.
+type ManagedClusterHistoryEntry struct {
+	// Timestamp is the time the generated HelmRelease has been changed.
+	Timestamp metav1.Time `json:"timestamp"`
+	// Template is the name of the ClusterTemplate used for the deployment.
+	Template string `json:"template"`
+	// ConfigHash is the SHA-256 hash of the values passed to the HelmRelease.
+	ConfigHash string `json:"configHash"`
+	// Generation is the generation of the ManagedCluster deployed.
+	Generation int64 `json:"generation,omitempty"`
+	// ManifestsConfigMap is the name of the ConfigMap in the ManagedCluster namespace containing
+	// the rendered manifests of the generation deployed. It is empty if the manifests are too large
+	// or dropped, the manifests of the oldest entries are dropped to limit the total size of the manifests.
+	ManifestsConfigMap string `json:"manifestsConfigMap,omitempty"`
+	// Outcome is the outcome of the deployment.
+	// +kubebuilder:validation:Enum=Progressing;Succeeded;Failed
+	Outcome string `json:"outcome"`
+	// Message contains details on the outcome of the deployment.
+	Message string `json:"message,omitempty"`
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:shortName=mcluster;mcl
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="phase",type="string",JSONPath=".status.phase",description="Phase",priority=0
 // +kubebuilder:printcolumn:name="ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="Ready",priority=0
 // +kubebuilder:printcolumn:name="status",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].message",description="Status",priority=0
 // +kubebuilder:printcolumn:name="dryRun",type="string",JSONPath=".spec.dryRun",description="Dry Run",priority=1
+// +kubebuilder:printcolumn:name="template",type="string",JSONPath=".spec.template",description="Template",priority=1
 
 // ManagedCluster is the Schema for the managedclusters API
 type ManagedCluster struct {
@@ -133,6 +762,99 @@ func (in *ManagedCluster) GetConditions() *[]metav1.Condition {
 	return &in.Status.Conditions
 }
 
+// AddHistoryEntry appends the given entry to the ManagedCluster history
+// dropping the oldest entries over the ManagedClusterHistoryLimit.
+func (in *ManagedCluster) AddHistoryEntry(entry ManagedClusterHistoryEntry) {
+	in.Status.History = append(in.Status.History, entry)
+	if overflow := len(in.Status.History) - ManagedClusterHistoryLimit; overflow > 0 {
+		in.Status.History = in.Status.History[overflow:]
+	}
+}
+
+// ServicesDrainTimeout returns the time the deletion of the cluster waits for the services to be withdrawn.
+func (in *ManagedCluster) ServicesDrainTimeout() time.Duration {
+	if in.Spec.ServicesDrainTimeout == nil {
+		return DefaultServicesDrainTimeout
+	}
+	return in.Spec.ServicesDrainTimeout.Duration
+}
+
+// PreDeleteCleanupTimeout returns the time the deletion of the cluster waits for the pre-delete cleanup.
+func (in *ManagedCluster) PreDeleteCleanupTimeout() time.Duration {
+	if in.Spec.PreDeleteCleanup == nil || in.Spec.PreDeleteCleanup.Timeout == nil {
+		return DefaultPreDeleteCleanupTimeout
+	}
+	return in.Spec.PreDeleteCleanup.Timeout.Duration
+}
+
+// WorkersNumber returns the maximum number of the worker machines of the ManagedCluster.
+// The pools autoscaled are counted with their maximum size, the pools with the replicas
+// set in the spec with the replicas. The default worker pool of the HMC templates has the
+// workersNumber configuration value, or the one of the global cluster defaults of the
+// Management or of the default values of the ClusterTemplate otherwise. The Management
+// and the ClusterTemplate may be nil.
+func (in *ManagedCluster) WorkersNumber(template *ClusterTemplate, mgmt *Management) int32 {
+	pools := []string{DefaultWorkerPoolName}
+	for _, pool := range in.Spec.Workers {
+		pools = append(pools, pool.Name)
+	}
+	for _, pool := range in.Spec.NodePools {
+		pools = append(pools, pool.Name)
+	}
+	slices.Sort(pools)
+	pools = slices.Compact(pools)
+
+	var workers int32
+	for _, name := range pools {
+		if i := slices.IndexFunc(in.Spec.NodePools, func(pool NodePoolSpec) bool { return pool.Name == name }); i >= 0 &&
+			in.Spec.NodePools[i].Autoscaling != nil {
+			workers += in.Spec.NodePools[i].Autoscaling.MaxSize
+			continue
+		}
+		if i := slices.IndexFunc(in.Spec.Workers, func(pool WorkerPoolConfig) bool { return pool.Name == name }); i >= 0 &&
+			in.Spec.Workers[i].Replicas != nil {
+			workers += *in.Spec.Workers[i].Replicas
+			continue
+		}
+		if name == DefaultWorkerPoolName {
+			workers += in.defaultWorkersNumber(template, mgmt)
+		}
+	}
+	return workers
+}
+
+// defaultWorkersNumber returns the first workersNumber value set in the config of
+// the ManagedCluster, the global cluster defaults or the default values of the template.
+func (in *ManagedCluster) defaultWorkersNumber(template *ClusterTemplate, mgmt *Management) int32 {
+	var layers []*apiextensionsv1.JSON
+	layers = append(layers, in.Spec.Config)
+	if mgmt != nil {
+		layers = append(layers, mgmt.Spec.GlobalClusterDefaults)
+	}
+	if template != nil {
+		layers = append(layers, template.Status.Config)
+	}
+
+	for _, layer := range layers {
+		if layer == nil {
+			continue
+		}
+		var values map[string]any
+		if err := yaml.Unmarshal(layer.Raw, &values); err != nil {
+			continue
+		}
+		switch v := values[workersNumberKey].(type) {
+		case int:
+			return int32(v)
+		case int64:
+			return int32(v)
+		case float64:
+			return int32(v)
+		}
+	}
+	return 0
+}
+
 func (in *ManagedCluster) InitConditions() {
 	apimeta.SetStatusCondition(in.GetConditions(), metav1.Condition{
 		Type:    TemplateReadyCondition,
@@ -162,6 +884,11 @@ func (in *ManagedCluster) InitConditions() {
 	})
 }
 
+// IsNamespaceDefault returns true if the object is marked as the default of its namespace.
+func IsNamespaceDefault(obj metav1.Object) bool {
+	return obj.GetAnnotations()[NamespaceDefaultAnnotation] == "true"
+}
+
 // +kubebuilder:object:root=true
 
 // ManagedClusterList contains a list of ManagedCluster
@@ -171,6 +898,18 @@ type ManagedClusterList struct {
 	Items           []ManagedCluster `json:"items"`
 }
 
+// IdentityCredentialsByPath returns the names of the Credentials whose ClusterIdentity is passed
+// to the template at each of the values paths: the Credential of the cluster at the paths declared
+// by the template unless the IdentityCredentials map the paths to the other Credentials.
+func (in *ManagedCluster) IdentityCredentialsByPath(template *ClusterTemplate) map[string]string {
+	credentials := make(map[string]string, len(in.Spec.IdentityCredentials)+1)
+	for _, path := range template.IdentityValuesPaths() {
+		credentials[path] = in.Spec.Credential
+	}
+	maps.Copy(credentials, in.Spec.IdentityCredentials)
+	return credentials
+}
+
 func init() {
 	SchemeBuilder.Register(&ManagedCluster{}, &ManagedClusterList{})
 }
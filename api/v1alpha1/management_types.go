@@ -15,6 +15,9 @@
 package v1alpha1
 
 import (
+	"slices"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/yaml"
@@ -29,6 +32,11 @@ const (
 	ManagementName         = "hmc"
 	ManagementFinalizer    = "hmc.mirantis.com/management"
 	TemplateManagementName = "hmc"
+
+	// MonitoringMultiClusterServiceName is the name of the MultiClusterService
+	// deploying the monitoring agent on the managed clusters. The existing
+	// MultiClusterService of the name not created by HMC is not taken over.
+	MonitoringMultiClusterServiceName = "hmc-monitoring"
 )
 
 // ManagementSpec defines the desired state of Management
@@ -41,6 +49,231 @@ type ManagementSpec struct {
 
 	// Providers is the list of supported CAPI providers.
 	Providers []Provider `json:"providers,omitempty"`
+
+	// ImageOverrides is the list of registry rewrite rules applied to the
+	// image settings exposed by ClusterTemplate and ServiceTemplate charts.
+	// Rules are evaluated in order, the first matching rule wins.
+	ImageOverrides []ImageOverride `json:"imageOverrides,omitempty"`
+
+	// Telemetry configures the collection of the anonymous usage data.
+	Telemetry *Telemetry `json:"telemetry,omitempty"`
+
+	// GlobalClusterDefaults are the values merged into the values of every ManagedCluster
+	// on top of the template default values, e.g. registry mirrors, proxy settings or SSH keys.
+	// The values of the ManagedCluster config take precedence over them.
+	GlobalClusterDefaults *apiextensionsv1.JSON `json:"globalClusterDefaults,omitempty"`
+
+	// ChartVerification is the list of the signature verification policies
+	// of the Helm charts per HelmRepository. The templates with the charts
+	// from a HelmRepository with a policy are valid only once the signature
+	// of the chart has been verified.
+	ChartVerification []ChartVerificationPolicy `json:"chartVerification,omitempty"`
+
+	// Monitoring deploys a monitoring agent on the managed clusters
+	// writing the metrics to the storage in the management cluster.
+	Monitoring *Monitoring `json:"monitoring,omitempty"`
+
+	// DeletionVerification verifies the removal of the cloud instances of the
+	// deleted clusters by the provider API. By default the infrastructure cluster
+	// is released once the Machines of the cluster are removed.
+	DeletionVerification *DeletionVerification `json:"deletionVerification,omitempty"`
+
+	// Notifications configures the receivers notified about the provisioning
+	// of the ManagedClusters and the upgrades available for them.
+	Notifications *Notifications `json:"notifications,omitempty"`
+
+	// MaintenanceWindow is the default maintenance window of the ManagedClusters
+	// not defining their own, see ManagedClusterSpec.MaintenanceWindow.
+	MaintenanceWindow *MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+
+	// DNS is the default DNS config of the ManagedClusters enabling the DNS integration,
+	// see ManagedClusterSpec.DNS. The credentials Secret is looked up in the namespace of each cluster.
+	DNS *DNSConfig `json:"dns,omitempty"`
+
+	// Proxy is the HTTP proxy the machines of the ManagedClusters and the services
+	// deployed by HMC on them reach the external endpoints through.
+	Proxy *ProxyConfig `json:"proxy,omitempty"`
+
+	// TrustedCA is the CA bundle trusted by the machines of the ManagedClusters
+	// and the services deployed by HMC on them in addition to the system CAs.
+	TrustedCA *TrustedCA `json:"trustedCA,omitempty"`
+}
+
+// Notifications configures the notifications about the changes of the ManagedClusters.
+type Notifications struct {
+	// +listType=map
+	// +listMapKey=name
+
+	// Receivers are the receivers of the notifications.
+	Receivers []NotificationReceiver `json:"receivers,omitempty"`
+}
+
+// NotificationReceiver is a receiver of the notifications.
+type NotificationReceiver struct {
+	// +kubebuilder:validation:MinLength=1
+
+	// Name is the name of the receiver.
+	Name string `json:"name"`
+	// Type is the type of the receiver: a generic webhook receiving the notifications
+	// as JSON, a Slack incoming webhook, or the event receiver of the Flux
+	// notification-controller. The Flux events are reported against the HelmRelease
+	// of the cluster, so that the Flux Alerts select them with the HelmRelease event sources.
+	Type NotificationReceiverType `json:"type"`
+	// Address is the URL the notifications are sent to. The address of the
+	// flux receiver defaults to the notification-controller in the flux-system namespace.
+	Address string `json:"address,omitempty"`
+	// SecretRef is the name of a Secret in the system namespace with the address
+	// in the "address" key, used instead of the Address, e.g. for the Slack webhook URLs.
+	SecretRef string `json:"secretRef,omitempty"`
+	// Events are the events the receiver is notified about, all of the events by default.
+	Events []NotificationEvent `json:"events,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=webhook;slack;flux
+
+// NotificationReceiverType is the type of a receiver of the notifications.
+type NotificationReceiverType string
+
+const (
+	// NotificationReceiverWebhook is a generic webhook receiving the notifications as JSON.
+	NotificationReceiverWebhook NotificationReceiverType = "webhook"
+	// NotificationReceiverSlack is a Slack incoming webhook.
+	NotificationReceiverSlack NotificationReceiverType = "slack"
+	// NotificationReceiverFlux is the event receiver of the Flux notification-controller.
+	NotificationReceiverFlux NotificationReceiverType = "flux"
+)
+
+// +kubebuilder:validation:Enum=ProvisioningStarted;ProvisioningSucceeded;ProvisioningFailed;UpgradeAvailable
+
+// NotificationEvent is an event of a ManagedCluster the receivers are notified about.
+type NotificationEvent string
+
+const (
+	// NotificationProvisioningStarted is sent once the deployment of the ManagedCluster, or of a change to it, starts.
+	NotificationProvisioningStarted NotificationEvent = "ProvisioningStarted"
+	// NotificationProvisioningSucceeded is sent once the deployment of the ManagedCluster succeeds.
+	NotificationProvisioningSucceeded NotificationEvent = "ProvisioningSucceeded"
+	// NotificationProvisioningFailed is sent once the deployment of the ManagedCluster fails.
+	NotificationProvisioningFailed NotificationEvent = "ProvisioningFailed"
+	// NotificationUpgradeAvailable is sent once new upgrades are available for the ManagedCluster.
+	NotificationUpgradeAvailable NotificationEvent = "UpgradeAvailable"
+)
+
+// DeletionVerification configures the verification of the removal of the
+// cloud instances of the deleted clusters.
+type DeletionVerification struct {
+	// Providers are the infrastructure providers the infrastructure cluster
+	// is released for only once the provider API reports no instances of the cluster.
+	// Only the aws provider is supported.
+	Providers []DeletionVerificationProvider `json:"providers,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=aws
+
+// DeletionVerificationProvider is the infrastructure provider the removal of the instances is verified for.
+type DeletionVerificationProvider string
+
+// Monitoring configures the monitoring agent deployed on the managed clusters.
+// The agent is deployed by the MonitoringMultiClusterServiceName MultiClusterService.
+type Monitoring struct {
+	// +kubebuilder:validation:MinLength=1
+
+	// RemoteWriteURL is the remote write endpoint of the metrics storage,
+	// e.g. the VictoriaMetrics or Prometheus exposed by the management cluster.
+	RemoteWriteURL string `json:"remoteWriteURL"`
+
+	// +kubebuilder:default:=victoria-metrics-agent-0-14-0
+
+	// Template is the ServiceTemplate of the agent in the system namespace.
+	// The values set by HMC follow the layout of the victoria-metrics-agent chart.
+	Template string `json:"template,omitempty"`
+	// ClusterSelector selects the clusters the agent is deployed on, all of the clusters by default.
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector,omitempty"`
+	// Values are merged over the values of the agent set by HMC, e.g. the credentials of the storage.
+	Values *apiextensionsv1.JSON `json:"values,omitempty"`
+}
+
+// ChartVerificationPolicy configures the signature verification of the Helm
+// charts from a HelmRepository. The charts from OCI HelmRepositories are
+// verified by their cosign or notation signatures, the charts from HTTP
+// HelmRepositories are verified by their provenance files.
+// +kubebuilder:validation:XValidation:rule="has(self.verify) != has(self.provenance)",message="exactly one of verify or provenance must be set"
+type ChartVerificationPolicy struct {
+	// +kubebuilder:validation:MinLength=1
+
+	// Repository is the name of the HelmRepository the policy applies to.
+	Repository string `json:"repository"`
+
+	// Verify configures the verification of the signatures of the charts
+	// from an OCI HelmRepository, the SecretRef is resolved in the namespace
+	// of the HelmChart.
+	Verify *sourcev1.OCIRepositoryVerification `json:"verify,omitempty"`
+
+	// Provenance configures the verification of the provenance files of the
+	// charts from an HTTP HelmRepository.
+	Provenance *ProvenanceVerification `json:"provenance,omitempty"`
+}
+
+// ProvenanceVerification configures the verification of the provenance files
+// of the Helm charts against a keyring of trusted public keys.
+type ProvenanceVerification struct {
+	// +kubebuilder:validation:MinLength=1
+
+	// KeyringSecretRef is the name of a Secret in the namespace of the HelmChart
+	// holding the keyring of the trusted PGP public keys in the "keyring.gpg" key.
+	KeyringSecretRef string `json:"keyringSecretRef"`
+}
+
+// TelemetryMode defines how the telemetry events are handled.
+type TelemetryMode string
+
+const (
+	// TelemetryModeEnabled sends the telemetry events to the HMC maintainers.
+	TelemetryModeEnabled TelemetryMode = "enabled"
+	// TelemetryModeLocal writes the telemetry events to the local sink only.
+	TelemetryModeLocal TelemetryMode = "local"
+	// TelemetryModeDisabled drops the telemetry events.
+	TelemetryModeDisabled TelemetryMode = "disabled"
+)
+
+// TelemetrySink defines where the telemetry events are written in the local mode.
+type TelemetrySink string
+
+const (
+	// TelemetrySinkLogs writes the telemetry events to the controller logs.
+	TelemetrySinkLogs TelemetrySink = "logs"
+	// TelemetrySinkConfigMap writes the telemetry events to a ConfigMap in the system namespace.
+	TelemetrySinkConfigMap TelemetrySink = "configmap"
+)
+
+// Telemetry configures the collection of the anonymous usage data.
+type Telemetry struct {
+	// +kubebuilder:default:=enabled
+	// +kubebuilder:validation:Enum=enabled;local;disabled
+
+	// Mode defines how the telemetry events are handled.
+	Mode TelemetryMode `json:"mode,omitempty"`
+
+	// +kubebuilder:default:=logs
+	// +kubebuilder:validation:Enum=logs;configmap
+
+	// LocalSink defines where the telemetry events are written in the local mode.
+	LocalSink TelemetrySink `json:"localSink,omitempty"`
+}
+
+// ImageOverride is a registry rewrite rule.
+type ImageOverride struct {
+	// +kubebuilder:validation:MinLength=1
+
+	// Source is the registry (optionally followed by a repository path)
+	// to be replaced, e.g. docker.io or registry.k8s.io/capi.
+	Source string `json:"source"`
+
+	// +kubebuilder:validation:MinLength=1
+
+	// Target is the registry (optionally followed by a repository path)
+	// replacing the Source, e.g. registry.example.com/mirror.
+	Target string `json:"target"`
 }
 
 // Core represents a structure describing core Management components.
@@ -75,6 +308,31 @@ func (in *Component) HelmValues() (values map[string]any, err error) {
 	return values, err
 }
 
+// GlobalClusterDefaultsValues returns the parsed GlobalClusterDefaults.
+func (in *Management) GlobalClusterDefaultsValues() (values map[string]any, err error) {
+	if in.Spec.GlobalClusterDefaults != nil {
+		err = yaml.Unmarshal(in.Spec.GlobalClusterDefaults.Raw, &values)
+	}
+	return values, err
+}
+
+// ChartVerificationPolicy returns the signature verification policy of
+// the Helm charts from the given HelmRepository or nil if there is none.
+func (in *Management) ChartVerificationPolicy(repository string) *ChartVerificationPolicy {
+	for i := range in.Spec.ChartVerification {
+		if in.Spec.ChartVerification[i].Repository == repository {
+			return &in.Spec.ChartVerification[i]
+		}
+	}
+	return nil
+}
+
+// DeletionVerified returns true if the removal of the cloud instances of
+// the deleted clusters is verified for the given infrastructure provider.
+func (in *Management) DeletionVerified(provider string) bool {
+	return in.Spec.DeletionVerification != nil && slices.Contains(in.Spec.DeletionVerification.Providers, DeletionVerificationProvider(provider))
+}
+
 func GetDefaultProviders() []Provider {
 	return []Provider{
 		{Name: ProviderK0smotronName},
@@ -101,6 +359,9 @@ type ManagementStatus struct {
 	// AvailableProviders holds all CAPI providers available along with
 	// their supported contract versions, if specified in ProviderTemplates, on the Management cluster.
 	AvailableProviders Providers `json:"availableProviders,omitempty"`
+	// ProviderVersions holds the versions of the installed CAPI providers,
+	// where the key is the name of the provider.
+	ProviderVersions map[string]string `json:"providerVersions,omitempty"`
 	// ObservedGeneration is the last observed generation.
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
@@ -113,11 +374,26 @@ type ComponentStatus struct {
 	Error string `json:"error,omitempty"`
 	// Success represents if a component installation was successful
 	Success bool `json:"success,omitempty"`
+	// HelmReleaseReady reflects the Ready condition of the HelmRelease of the component.
+	HelmReleaseReady bool `json:"helmReleaseReady,omitempty"`
+	// Version is the version of the chart installed by the HelmRelease of the component.
+	Version string `json:"version,omitempty"`
+	// CRDsEstablished indicates all of the CustomResourceDefinitions
+	// installed by the component are established.
+	CRDsEstablished bool `json:"crdsEstablished,omitempty"`
+	// PendingCRDs lists the CustomResourceDefinitions installed by the
+	// component which are not established yet.
+	PendingCRDs []string `json:"pendingCRDs,omitempty"`
+	// AvailableUpgrades is the list of the ProviderTemplates the component
+	// can be upgraded to according to the valid ProviderTemplateChains,
+	// only the templates compatible with the core CAPI are listed.
+	AvailableUpgrades []string `json:"availableUpgrades,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:shortName=hmc-mgmt;mgmt,scope=Cluster
+// +kubebuilder:storageversion
 
 // Management is the Schema for the managements API
 type Management struct {
@@ -128,6 +404,18 @@ type Management struct {
 	Status ManagementStatus `json:"status,omitempty"`
 }
 
+// MissingProviders returns the providers of the given list
+// which are not available on the Management cluster.
+func (in *Management) MissingProviders(required Providers) []string {
+	var missing []string
+	for _, p := range required {
+		if !slices.Contains(in.Status.AvailableProviders, p) {
+			missing = append(missing, p)
+		}
+	}
+	return missing
+}
+
 // +kubebuilder:object:root=true
 
 // ManagementList contains a list of Management
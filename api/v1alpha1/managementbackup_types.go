@@ -0,0 +1,89 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ManagementBackupKind is the string representation of a ManagementBackup.
+const ManagementBackupKind = "ManagementBackup"
+
+// ManagementBackupSpec defines the desired state of ManagementBackup
+type ManagementBackupSpec struct {
+	// Schedule is a Cron expression defining when to run the backups.
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+	// StorageLocation is the name of the Velero BackupStorageLocation the
+	// backups are stored in. Defaults to the default location of Velero.
+	StorageLocation string `json:"storageLocation,omitempty"`
+	// TTL is the time the backups are retained for. Defaults to the Velero default (30 days).
+	TTL metav1.Duration `json:"ttl,omitempty"`
+	// Paused pauses the creation of the new backups.
+	Paused bool `json:"paused,omitempty"`
+}
+
+// ManagementBackupStatus defines the observed state of ManagementBackup
+type ManagementBackupStatus struct {
+	// LastBackup is the most recent backup created by the schedule.
+	LastBackup *ManagementBackupRef `json:"lastBackup,omitempty"`
+	// LastSuccessfulBackup is the most recent backup completed successfully.
+	LastSuccessfulBackup *ManagementBackupRef `json:"lastSuccessfulBackup,omitempty"`
+	// Error is the error message occurred during the reconciliation (if any).
+	Error string `json:"error,omitempty"`
+	// ObservedGeneration is the last observed generation.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// ManagementBackupRef references a Velero Backup.
+type ManagementBackupRef struct {
+	// Time is the creation time of the backup.
+	Time *metav1.Time `json:"time,omitempty"`
+	// Name is the name of the Velero Backup.
+	Name string `json:"name"`
+	// Phase is the phase of the Velero Backup.
+	Phase string `json:"phase,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=mgmtbackup,scope=Cluster
+// +kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.spec.schedule`
+// +kubebuilder:printcolumn:name="LastSuccessfulBackup",type=string,JSONPath=`.status.lastSuccessfulBackup.name`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ManagementBackup is the Schema for the managementbackups API. It schedules
+// the Velero backups of the management plane: the HMC objects, the Flux
+// sources and releases, the CAPI objects and the Sveltos profiles.
+type ManagementBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ManagementBackupSpec   `json:"spec,omitempty"`
+	Status ManagementBackupStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ManagementBackupList contains a list of ManagementBackup
+type ManagementBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ManagementBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ManagementBackup{}, &ManagementBackupList{})
+}
@@ -0,0 +1,74 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ManagementRestoreKind is the string representation of a ManagementRestore.
+const ManagementRestoreKind = "ManagementRestore"
+
+// ManagementRestoreSpec defines the desired state of ManagementRestore
+type ManagementRestoreSpec struct {
+	// BackupName is the name of the Velero Backup of the management plane
+	// to restore. The backups of the storage location are synced by Velero
+	// into the new cluster once the location is configured.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf", message="BackupName is immutable"
+	BackupName string `json:"backupName"`
+}
+
+// ManagementRestoreStatus defines the observed state of ManagementRestore
+type ManagementRestoreStatus struct {
+	// CompletionTime is the time the restore has been completed.
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// RestoreName is the name of the Velero Restore.
+	RestoreName string `json:"restoreName,omitempty"`
+	// Phase is the phase of the Velero Restore.
+	Phase string `json:"phase,omitempty"`
+	// Error is the error message occurred during the restore (if any).
+	Error string `json:"error,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=mgmtrestore,scope=Cluster
+// +kubebuilder:printcolumn:name="Backup",type=string,JSONPath=`.spec.backupName`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ManagementRestore is the Schema for the managementrestores API. It restores
+// the management plane from a backup created by a ManagementBackup.
+type ManagementRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ManagementRestoreSpec   `json:"spec,omitempty"`
+	Status ManagementRestoreStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ManagementRestoreList contains a list of ManagementRestore
+type ManagementRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ManagementRestore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ManagementRestore{}, &ManagementRestoreList{})
+}
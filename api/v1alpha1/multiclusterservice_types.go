@@ -17,6 +17,7 @@ package v1alpha1
 import (
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 const (
@@ -24,6 +25,31 @@ const (
 	MultiClusterServiceFinalizer = "hmc.mirantis.com/multicluster-service"
 	// MultiClusterServiceKind is the string representation of a MultiClusterServiceKind.
 	MultiClusterServiceKind = "MultiClusterService"
+	// MultiClusterServiceLabelKey is set on the Sveltos ClusterProfiles of the matchers
+	// of a MultiClusterService to the name of the MultiClusterService.
+	MultiClusterServiceLabelKey = "hmc.mirantis.com/multiclusterservice"
+)
+
+// ServiceConflictPolicy defines what to do if another object already manages the service.
+type ServiceConflictPolicy string
+
+const (
+	// ServiceConflictPolicyStop stops the deployment of the remaining services on conflict.
+	ServiceConflictPolicyStop ServiceConflictPolicy = "Stop"
+	// ServiceConflictPolicySkip skips the conflicting service and deploys the remaining services.
+	ServiceConflictPolicySkip ServiceConflictPolicy = "Skip"
+	// ServiceConflictPolicyForce takes the service over regardless of the priority of the other object.
+	ServiceConflictPolicyForce ServiceConflictPolicy = "Force"
+)
+
+// ServiceDeletionPolicy defines what happens to the release of the service once the service is removed.
+type ServiceDeletionPolicy string
+
+const (
+	// ServiceDeletionPolicyDelete uninstalls the release of the removed service.
+	ServiceDeletionPolicyDelete ServiceDeletionPolicy = "Delete"
+	// ServiceDeletionPolicyOrphan leaves the release of the removed service in place.
+	ServiceDeletionPolicyOrphan ServiceDeletionPolicy = "Orphan"
 )
 
 // ServiceSpec represents a Service to be managed
@@ -45,6 +71,20 @@ type ServiceSpec struct {
 	Namespace string `json:"namespace,omitempty"`
 	// Disable can be set to disable handling of this service.
 	Disable bool `json:"disable,omitempty"`
+
+	// +kubebuilder:validation:Enum=Stop;Skip;Force
+
+	// ConflictPolicy specifies what to do if another object already manages the service.
+	// Defaults to the policy set by StopOnConflict.
+	ConflictPolicy ServiceConflictPolicy `json:"conflictPolicy,omitempty"`
+
+	// +kubebuilder:default:=Delete
+	// +kubebuilder:validation:Enum=Delete;Orphan
+
+	// DeletionPolicy specifies whether the release is uninstalled from the cluster once the service
+	// is removed from the spec or disabled. The Orphan policy leaves the release in place, the same
+	// applies when the object deploying the service is deleted or the cluster stops matching it.
+	DeletionPolicy ServiceDeletionPolicy `json:"deletionPolicy,omitempty"`
 }
 
 // MultiClusterServiceSpec defines the desired state of MultiClusterService
@@ -72,6 +112,129 @@ type MultiClusterServiceSpec struct {
 	// By default the remaining services will be deployed even if conflict is detected.
 	// If set to true, the deployment will stop after encountering the first conflict.
 	StopOnConflict bool `json:"stopOnConflict,omitempty"`
+
+	// Matchers override the values of the services on the subsets of the selected clusters,
+	// e.g. to set a different ingress class on the clusters of each infrastructure provider.
+	// The services are deployed on the matching clusters by a dedicated Sveltos ClusterProfile,
+	// the first matcher matching a cluster takes precedence.
+	Matchers []ServiceMatcher `json:"matchers,omitempty"`
+
+	// Rollout rolls a change of the services out to the selected clusters step by step.
+	// The services are deployed on all of the selected clusters at once if unset.
+	Rollout *ServiceRollout `json:"rollout,omitempty"`
+}
+
+// ServiceRollout defines how a change of the services is rolled out to the selected clusters.
+// The first deployment of the services is not staged.
+type ServiceRollout struct {
+	// +kubebuilder:validation:XIntOrString
+	// +kubebuilder:validation:Pattern=`^((100|[1-9][0-9]?)%|[1-9][0-9]*)$`
+
+	// MaxClusters is the number or the percentage of the selected clusters
+	// the change is rolled out to at each step. Defaults to 1.
+	MaxClusters *intstr.IntOrString `json:"maxClusters,omitempty"`
+	// SoakTime is how long the clusters of a step run the change
+	// before the change is rolled out to the next step.
+	SoakTime metav1.Duration `json:"soakTime,omitempty"`
+	// AbortOnFailure stops the rollout once the change fails on any of the clusters,
+	// the clusters already running the change are rolled back. The rollout proceeds
+	// past the failing clusters otherwise.
+	AbortOnFailure bool `json:"abortOnFailure,omitempty"`
+	// HealthChecks are evaluated on each cluster before the change counts as deployed on it.
+	HealthChecks []ServiceHealthCheck `json:"healthChecks,omitempty"`
+}
+
+// ServiceHealthCheck evaluates the health of the resources of a kind deployed on a cluster.
+type ServiceHealthCheck struct {
+	// +kubebuilder:validation:MinLength=1
+
+	// Name identifies the health check.
+	Name string `json:"name"`
+	// Group of the resources.
+	Group string `json:"group,omitempty"`
+	// +kubebuilder:validation:MinLength=1
+
+	// Version of the resources.
+	Version string `json:"version"`
+	// +kubebuilder:validation:MinLength=1
+
+	// Kind of the resources.
+	Kind string `json:"kind"`
+	// Namespace of the resources, the resources of all namespaces are evaluated if empty.
+	Namespace string `json:"namespace,omitempty"`
+	// Script is the Lua script evaluating each resource, it defines the function evaluate
+	// returning hs.healthy and hs.message. The resources only need to exist if empty.
+	Script string `json:"script,omitempty"`
+}
+
+// ServiceRolloutPhase is the phase of the rollout of a change of the services.
+type ServiceRolloutPhase string
+
+const (
+	// ServiceRolloutPhaseProgressing means the change is being deployed on the clusters of the current step.
+	ServiceRolloutPhaseProgressing ServiceRolloutPhase = "Progressing"
+	// ServiceRolloutPhaseSoaking means the clusters of the current step run the change for the soak time.
+	ServiceRolloutPhaseSoaking ServiceRolloutPhase = "Soaking"
+	// ServiceRolloutPhaseCompleted means the change is rolled out to all of the selected clusters.
+	ServiceRolloutPhaseCompleted ServiceRolloutPhase = "Completed"
+	// ServiceRolloutPhaseAborted means the change failed and the clusters are rolled back.
+	ServiceRolloutPhaseAborted ServiceRolloutPhase = "Aborted"
+)
+
+// ServiceRolloutStatus is the state of the rollout of a change of the services.
+type ServiceRolloutStatus struct {
+	// Revision identifies the change of the services.
+	Revision string `json:"revision"`
+	// Phase is the phase of the rollout.
+	Phase ServiceRolloutPhase `json:"phase"`
+	// Clusters lists the namespace/name of the clusters the change is rolled out to so far.
+	Clusters []string `json:"clusters,omitempty"`
+	// FailedClusters lists the namespace/name of the clusters the change failed on.
+	FailedClusters []string `json:"failedClusters,omitempty"`
+	// SoakStartedAt is the time the clusters of the current step started to soak.
+	SoakStartedAt *metav1.Time `json:"soakStartedAt,omitempty"`
+	// Message explains the phase.
+	Message string `json:"message,omitempty"`
+}
+
+// ServiceMatcher overrides the values of the services on the clusters matching the selector.
+type ServiceMatcher struct {
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+
+	// Name identifies the matcher. The ClusterProfile of the matcher
+	// is named after the MultiClusterService and the matcher.
+	Name string `json:"name"`
+	// ClusterSelector identifies the clusters the overrides apply to
+	// among the clusters selected by the MultiClusterService.
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector"`
+	// Services is the list of the overrides of the values of the services.
+	Services []ServiceValuesOverride `json:"services,omitempty"`
+}
+
+// ServiceValuesOverride overrides the values of a service.
+type ServiceValuesOverride struct {
+	// +kubebuilder:validation:MinLength=1
+
+	// Name is the name of the service defined in the MultiClusterService.
+	Name string `json:"name"`
+	// Values is merged over the values of the service.
+	Values *apiextensionsv1.JSON `json:"values,omitempty"`
+}
+
+// ServiceConflict is a service not deployed on a cluster since another Sveltos profile manages its release.
+type ServiceConflict struct {
+	// Cluster is the namespace/name of the cluster, it is set only for the MultiClusterService.
+	Cluster string `json:"cluster,omitempty"`
+	// Name is the name of the release of the service.
+	Name string `json:"name"`
+	// Namespace is the namespace of the release of the service.
+	Namespace string `json:"namespace,omitempty"`
+	// ManagedBy is the Kind/name of the Sveltos profile deploying the release on the cluster.
+	ManagedBy string `json:"managedBy,omitempty"`
+	// Message is the conflict message reported by Sveltos.
+	Message string `json:"message,omitempty"`
 }
 
 // MultiClusterServiceStatus defines the observed state of MultiClusterService
@@ -80,8 +243,13 @@ type MultiClusterServiceSpec struct {
 // If this status ends up being common with ManagedClusterStatus,
 // then make a common status struct that can be shared by both.
 type MultiClusterServiceStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// Conditions contains details for the current state of the MultiClusterService.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// ServiceConflicts lists the services not deployed on the selected clusters
+	// since another object already manages them.
+	ServiceConflicts []ServiceConflict `json:"serviceConflicts,omitempty"`
+	// Rollout is the state of the rollout of the last change of the services.
+	Rollout *ServiceRolloutStatus `json:"rollout,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -97,6 +265,10 @@ type MultiClusterService struct {
 	Status MultiClusterServiceStatus `json:"status,omitempty"`
 }
 
+func (in *MultiClusterService) GetConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
 // +kubebuilder:object:root=true
 
 // MultiClusterServiceList contains a list of MultiClusterService
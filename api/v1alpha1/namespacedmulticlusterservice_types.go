@@ -0,0 +1,65 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// NamespacedMultiClusterServiceFinalizer is finalizer applied to NamespacedMultiClusterService objects.
+	NamespacedMultiClusterServiceFinalizer = "hmc.mirantis.com/namespaced-multicluster-service"
+	// NamespacedMultiClusterServiceKind is the string representation of a NamespacedMultiClusterService.
+	NamespacedMultiClusterServiceKind = "NamespacedMultiClusterService"
+	// NamespacedMultiClusterServiceLabelKey is set on the Sveltos Profiles of a
+	// NamespacedMultiClusterService to the name of the NamespacedMultiClusterService.
+	NamespacedMultiClusterServiceLabelKey = "hmc.mirantis.com/namespaced-multiclusterservice"
+	// NamespacedMultiClusterServiceProfilePrefix prefixes the names of the Sveltos Profiles
+	// of the NamespacedMultiClusterServices. The prefix contains a dot, so the names never
+	// collide with the Profiles of the ManagedClusters named with the DNS-1123 labels.
+	NamespacedMultiClusterServiceProfilePrefix = "nmcs."
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=nmcs
+
+// NamespacedMultiClusterService is the namespaced variant of the MultiClusterService
+// for the tenant self-service. It deploys the ServiceTemplates of its namespace
+// only on the ManagedClusters of its namespace selected by the cluster selector.
+type NamespacedMultiClusterService struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MultiClusterServiceSpec   `json:"spec,omitempty"`
+	Status MultiClusterServiceStatus `json:"status,omitempty"`
+}
+
+func (in *NamespacedMultiClusterService) GetConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// +kubebuilder:object:root=true
+
+// NamespacedMultiClusterServiceList contains a list of NamespacedMultiClusterService
+type NamespacedMultiClusterServiceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NamespacedMultiClusterService `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NamespacedMultiClusterService{}, &NamespacedMultiClusterServiceList{})
+}
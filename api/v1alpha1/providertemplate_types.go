@@ -17,6 +17,7 @@ package v1alpha1
 import (
 	"fmt"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -39,6 +40,17 @@ type ProviderTemplateStatus struct {
 	// Providers represent exposed CAPI providers with supported contract versions
 	// if the latter has been given.
 	Providers Providers `json:"providers,omitempty"`
+	// ProviderVersion is the version of the providers deployed by the template,
+	// it is taken from the appVersion of the Helm chart.
+	ProviderVersion string `json:"providerVersion,omitempty"`
+	// ConfigSchema is the JSON schema of the values of the Helm chart,
+	// the config of the Management component using the template is validated against it.
+	ConfigSchema *apiextensionsv1.JSON `json:"configSchema,omitempty"`
+	// ServedCRDVersions holds the versions served by the CustomResourceDefinitions
+	// installed by the template, where the key is the name of the CustomResourceDefinition.
+	// The upgrade of the HMC to the template is not started if the versions stored
+	// in the cluster are not served anymore.
+	ServedCRDVersions map[string][]string `json:"servedCRDVersions,omitempty"`
 
 	TemplateStatusCommon `json:",inline"`
 }
@@ -0,0 +1,67 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const ProviderTemplateChainKind = "ProviderTemplateChain"
+
+func (*ProviderTemplateChain) Kind() string {
+	return ProviderTemplateChainKind
+}
+
+func (*ProviderTemplateChain) TemplateKind() string {
+	return ProviderTemplateKind
+}
+
+func (t *ProviderTemplateChain) GetSpec() *TemplateChainSpec {
+	return &t.Spec
+}
+
+func (t *ProviderTemplateChain) GetStatus() *TemplateChainStatus {
+	return &t.Status
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+
+// ProviderTemplateChain is the Schema for the providertemplatechains API.
+// It defines the supported upgrade paths of the ProviderTemplates of the
+// components managed by the Management object.
+type ProviderTemplateChain struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="Spec is immutable"
+
+	Spec   TemplateChainSpec   `json:"spec,omitempty"`
+	Status TemplateChainStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ProviderTemplateChainList contains a list of ProviderTemplateChain
+type ProviderTemplateChainList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProviderTemplateChain `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ProviderTemplateChain{}, &ProviderTemplateChainList{})
+}
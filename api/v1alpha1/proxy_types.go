@@ -0,0 +1,75 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+const (
+	// TrustedCAConfigMapName is the name of the ConfigMap holding the trusted CA bundle
+	// written to the namespaces of the services deployed by HMC on the clusters.
+	TrustedCAConfigMapName = "hmc-trusted-ca"
+	// TrustedCADefaultKey is the default key of the trusted CA bundle in the ConfigMap.
+	TrustedCADefaultKey = "ca-bundle.crt"
+)
+
+// ProxyConfig configures the HTTP proxy the machines and the services deployed by HMC
+// on the managed clusters reach the external endpoints through.
+type ProxyConfig struct {
+	// HTTPProxy is the proxy of the HTTP requests, e.g. http://proxy.example.com:3128.
+	HTTPProxy string `json:"httpProxy,omitempty"`
+	// HTTPSProxy is the proxy of the HTTPS requests, e.g. http://proxy.example.com:3128.
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+	// NoProxy lists the hosts, the domains and the CIDRs reached directly. The networks
+	// of the pods and the services of the cluster and the cluster domain are always added.
+	NoProxy []string `json:"noProxy,omitempty"`
+}
+
+// Validate checks the proxies are valid HTTP or HTTPS URLs.
+func (in *ProxyConfig) Validate() error {
+	var errs error
+	for _, p := range []struct{ name, proxy string }{{"httpProxy", in.HTTPProxy}, {"httpsProxy", in.HTTPSProxy}} {
+		name, proxy := p.name, p.proxy
+		if proxy == "" {
+			continue
+		}
+		u, err := url.Parse(proxy)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("invalid %s: %w", name, err))
+			continue
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = errors.Join(errs, fmt.Errorf("invalid %s %q: must be an http or https URL", name, proxy))
+		}
+	}
+	if in.HTTPProxy == "" && in.HTTPSProxy == "" {
+		errs = errors.Join(errs, errors.New("neither httpProxy nor httpsProxy is set"))
+	}
+	return errs
+}
+
+// TrustedCA refers to the bundle of the CA certificates trusted by the machines and the services
+// deployed by HMC on the managed clusters in addition to the system CAs, e.g. the CA of the proxy.
+type TrustedCA struct {
+	// +kubebuilder:validation:MinLength=1
+
+	// ConfigMap is the name of the ConfigMap in the system namespace holding the PEM-encoded bundle.
+	ConfigMap string `json:"configMap"`
+	// Key is the key of the bundle in the ConfigMap, defaults to ca-bundle.crt.
+	Key string `json:"key,omitempty"`
+}
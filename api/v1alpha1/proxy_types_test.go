@@ -0,0 +1,60 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProxyConfigValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		proxy ProxyConfig
+		err   string
+	}{
+		{
+			name:  "valid",
+			proxy: ProxyConfig{HTTPProxy: "http://proxy.example.com:3128", HTTPSProxy: "https://proxy.example.com:3129", NoProxy: []string{".example.com"}},
+		},
+		{
+			name:  "no scheme",
+			proxy: ProxyConfig{HTTPSProxy: "proxy.example.com:3128"},
+			err:   `invalid httpsProxy "proxy.example.com:3128": must be an http or https URL`,
+		},
+		{
+			name:  "unsupported scheme",
+			proxy: ProxyConfig{HTTPProxy: "socks5://proxy.example.com:1080"},
+			err:   "must be an http or https URL",
+		},
+		{
+			name: "empty",
+			err:  "neither httpProxy nor httpsProxy is set",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.proxy.Validate()
+			if tc.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 
 	"github.com/Masterminds/semver/v3"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -36,6 +37,14 @@ type ServiceTemplateSpec struct {
 	// Providers represent requested CAPI providers.
 	// Should be set if not present in the Helm chart metadata.
 	Providers Providers `json:"providers,omitempty"`
+	// DefaultValues are the values merged under the values of the services using the template,
+	// e.g. the hardening defaults shipped along with the template.
+	DefaultValues *apiextensionsv1.JSON `json:"defaultValues,omitempty"`
+	// DefaultValuesFile is the path of a values file within the chart, e.g. "values-hardened.yaml",
+	// merged under the DefaultValues.
+	DefaultValuesFile string `json:"defaultValuesFile,omitempty"`
+
+	TemplateDeprecation `json:",inline"`
 }
 
 // ServiceTemplateStatus defines the observed state of ServiceTemplate
@@ -44,6 +53,11 @@ type ServiceTemplateStatus struct {
 	KubernetesConstraint string `json:"k8sConstraint,omitempty"`
 	// Providers represent requested CAPI providers.
 	Providers Providers `json:"providers,omitempty"`
+	// DefaultValues are the default values of the services resolved from
+	// the DefaultValues and the DefaultValuesFile of the spec.
+	DefaultValues *apiextensionsv1.JSON `json:"defaultValues,omitempty"`
+	// UsedByClusters is the list of the names of the ManagedClusters using the template for their services.
+	UsedByClusters []string `json:"usedByClusters,omitempty"`
 
 	TemplateStatusCommon `json:",inline"`
 }
@@ -70,6 +84,27 @@ func (t *ServiceTemplate) FillStatusWithProviders(annotations map[string]string)
 	return nil
 }
 
+// IsCompatibleWith returns true if the given Kubernetes version satisfies
+// the Kubernetes constraint of the ServiceTemplate. A template without the
+// constraint is compatible with any version.
+func (t *ServiceTemplate) IsCompatibleWith(k8sVersion string) (bool, error) {
+	if t.Status.KubernetesConstraint == "" {
+		return true, nil
+	}
+
+	version, err := semver.NewVersion(k8sVersion)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse k8s version %s: %w", k8sVersion, err)
+	}
+
+	constraint, err := semver.NewConstraint(t.Status.KubernetesConstraint)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse k8s constrained version %s of the ServiceTemplate %s/%s: %w", t.Status.KubernetesConstraint, t.Namespace, t.Name, err)
+	}
+
+	return constraint.Check(version), nil
+}
+
 // GetSpecProviders returns .spec.providers of the Template.
 func (t *ServiceTemplate) GetSpecProviders() Providers {
 	return t.Spec.Providers
@@ -97,7 +132,7 @@ type ServiceTemplate struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="Spec is immutable"
+	// +kubebuilder:validation:XValidation:rule="self.helm == oldSelf.helm && has(self.k8sConstraint) == has(oldSelf.k8sConstraint) && (!has(self.k8sConstraint) || self.k8sConstraint == oldSelf.k8sConstraint) && has(self.providers) == has(oldSelf.providers) && (!has(self.providers) || self.providers == oldSelf.providers) && has(self.defaultValues) == has(oldSelf.defaultValues) && (!has(self.defaultValues) || self.defaultValues == oldSelf.defaultValues) && has(self.defaultValuesFile) == has(oldSelf.defaultValuesFile) && (!has(self.defaultValuesFile) || self.defaultValuesFile == oldSelf.defaultValuesFile)",message="Spec is immutable except for the deprecation fields"
 
 	Spec   ServiceTemplateSpec   `json:"spec,omitempty"`
 	Status ServiceTemplateStatus `json:"status,omitempty"`
@@ -32,7 +32,12 @@ func (t *ServiceTemplateChain) GetSpec() *TemplateChainSpec {
 	return &t.Spec
 }
 
+func (t *ServiceTemplateChain) GetStatus() *TemplateChainStatus {
+	return &t.Status
+}
+
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
 
 // ServiceTemplateChain is the Schema for the servicetemplatechains API
 type ServiceTemplateChain struct {
@@ -41,7 +46,8 @@ type ServiceTemplateChain struct {
 
 	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="Spec is immutable"
 
-	Spec TemplateChainSpec `json:"spec,omitempty"`
+	Spec   TemplateChainSpec   `json:"spec,omitempty"`
+	Status TemplateChainStatus `json:"status,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -14,6 +14,18 @@
 
 package v1alpha1
 
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TemplateChainValidCondition indicates the TemplateChain spec is valid.
+const TemplateChainValidCondition = "Valid"
+
 // TemplateChainSpec defines the observed state of TemplateChain
 type TemplateChainSpec struct {
 	// SupportedTemplates is the list of supported Templates definitions and all available upgrade sequences for it.
@@ -33,3 +45,140 @@ type AvailableUpgrade struct {
 	// Name is the name of the Template to which the upgrade is available.
 	Name string `json:"name"`
 }
+
+// TemplateChainStatus defines the observed state of TemplateChain
+type TemplateChainStatus struct {
+	// Conditions contains details for the current state of the TemplateChain.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// ObservedGeneration is the last observed generation.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// IsTemplateChainValid returns false only if the TemplateChain has been
+// observed to be invalid.
+func IsTemplateChainValid(status *TemplateChainStatus) bool {
+	cond := apimeta.FindStatusCondition(status.Conditions, TemplateChainValidCondition)
+	return cond == nil || cond.Status != metav1.ConditionFalse
+}
+
+// UpgradesOf returns whether the template is listed in the SupportedTemplates
+// of the TemplateChain and the names of the templates it can be upgraded to.
+func (in *TemplateChainSpec) UpgradesOf(template string) (supported bool, upgrades []string) {
+	for _, supportedTemplate := range in.SupportedTemplates {
+		if supportedTemplate.Name != template {
+			continue
+		}
+		supported = true
+		for _, upgrade := range supportedTemplate.AvailableUpgrades {
+			upgrades = append(upgrades, upgrade.Name)
+		}
+	}
+	return supported, upgrades
+}
+
+// LatestTemplates returns the sorted names of the templates of the TemplateChain
+// which can not be upgraded any further. The valid chain of a single upgrade
+// sequence has exactly one latest template.
+func (in *TemplateChainSpec) LatestTemplates() []string {
+	var latest []string
+	for _, supportedTemplate := range in.SupportedTemplates {
+		if len(supportedTemplate.AvailableUpgrades) == 0 && !slices.Contains(latest, supportedTemplate.Name) {
+			latest = append(latest, supportedTemplate.Name)
+		}
+	}
+	slices.Sort(latest)
+	return latest
+}
+
+// Validate returns the list of the problems of the TemplateChain spec:
+// duplicated templates, upgrades to the templates not reachable since they
+// are missing in the SupportedTemplates, upgrades of a template to itself
+// and cyclic upgrade sequences.
+func (in *TemplateChainSpec) Validate() []string {
+	var (
+		problems  []string
+		supported = make(map[string]bool, len(in.SupportedTemplates))
+		upgrades  = make(map[string][]string, len(in.SupportedTemplates))
+		missing   = make(map[string]bool)
+	)
+	for _, supportedTemplate := range in.SupportedTemplates {
+		if supported[supportedTemplate.Name] {
+			problems = append(problems, fmt.Sprintf("template %s is listed in spec.SupportedTemplates more than once", supportedTemplate.Name))
+		}
+		supported[supportedTemplate.Name] = true
+	}
+
+	for _, supportedTemplate := range in.SupportedTemplates {
+		for _, upgrade := range supportedTemplate.AvailableUpgrades {
+			switch {
+			case upgrade.Name == supportedTemplate.Name:
+				problems = append(problems, fmt.Sprintf("template %s is allowed for upgrade to itself", upgrade.Name))
+			case !supported[upgrade.Name]:
+				missing[upgrade.Name] = true
+			case !slices.Contains(upgrades[supportedTemplate.Name], upgrade.Name):
+				upgrades[supportedTemplate.Name] = append(upgrades[supportedTemplate.Name], upgrade.Name)
+			}
+		}
+	}
+
+	for _, template := range sortedKeys(missing) {
+		problems = append(problems, fmt.Sprintf("template %s is allowed for upgrade but is not present in the list of spec.SupportedTemplates", template))
+	}
+
+	for _, cycle := range findUpgradeCycles(upgrades) {
+		problems = append(problems, fmt.Sprintf("upgrade sequence %s is cyclic", strings.Join(cycle, " -> ")))
+	}
+
+	return problems
+}
+
+// findUpgradeCycles returns the cycles of the upgrade graph found by the
+// depth-first search, each cycle starts and ends with the same template.
+func findUpgradeCycles(upgrades map[string][]string) [][]string {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+
+	var (
+		cycles [][]string
+		state  = make(map[string]int, len(upgrades))
+		path   []string
+		visit  func(template string)
+	)
+	visit = func(template string) {
+		state[template] = inProgress
+		path = append(path, template)
+
+		for _, next := range upgrades[template] {
+			switch state[next] {
+			case unvisited:
+				visit(next)
+			case inProgress:
+				start := slices.Index(path, next)
+				cycles = append(cycles, append(slices.Clone(path[start:]), next))
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[template] = done
+	}
+
+	for _, template := range sortedKeys(upgrades) {
+		if state[template] == unvisited {
+			visit(template)
+		}
+	}
+
+	return cycles
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,56 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTemplateChainLatestTemplates(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		templates []SupportedTemplate
+		expected  []string
+	}{
+		{name: "empty"},
+		{
+			name:      "single template",
+			templates: []SupportedTemplate{{Name: "aws-0-0-1"}},
+			expected:  []string{"aws-0-0-1"},
+		},
+		{
+			name: "upgrade sequence",
+			templates: []SupportedTemplate{
+				{Name: "aws-0-0-1", AvailableUpgrades: []AvailableUpgrade{{Name: "aws-0-0-2"}, {Name: "aws-0-0-3"}}},
+				{Name: "aws-0-0-2", AvailableUpgrades: []AvailableUpgrade{{Name: "aws-0-0-3"}}},
+				{Name: "aws-0-0-3"},
+			},
+			expected: []string{"aws-0-0-3"},
+		},
+		{
+			name:      "several sequences",
+			templates: []SupportedTemplate{{Name: "azure-0-0-1"}, {Name: "aws-0-0-1"}, {Name: "aws-0-0-1"}},
+			expected:  []string{"aws-0-0-1", "azure-0-0-1"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := TemplateChainSpec{SupportedTemplates: tc.templates}
+			if actual := spec.LatestTemplates(); !slices.Equal(tc.expected, actual) {
+				t.Errorf("expected latest templates %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
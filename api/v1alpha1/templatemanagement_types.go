@@ -25,6 +25,25 @@ type TemplateManagementSpec struct {
 	// AccessRules is the list of access rules. Each AccessRule enforces
 	// Templates distribution to the TargetNamespaces.
 	AccessRules []AccessRule `json:"accessRules,omitempty"`
+	// TemplatesSource is the optional Git source of the template catalog.
+	// The ClusterTemplates and ServiceTemplates found in the source are
+	// applied into the system namespace.
+	TemplatesSource *TemplatesSource `json:"templatesSource,omitempty"`
+}
+
+// TemplatesSource references the GitRepository containing the manifests
+// of the ClusterTemplates and ServiceTemplates.
+type TemplatesSource struct {
+	// GitRepository is the name of the Flux GitRepository in the system namespace.
+	// +kubebuilder:validation:MinLength=1
+	GitRepository string `json:"gitRepository"`
+	// Path is the path to the directory in the repository containing the
+	// template manifests. Defaults to the root of the repository.
+	Path string `json:"path,omitempty"`
+	// Prune enables the deletion of the templates previously applied
+	// from the source once they are removed from the repository. Nothing is
+	// pruned if no templates are found in the repository.
+	Prune bool `json:"prune,omitempty"`
 }
 
 // TemplateManagementStatus defines the observed state of TemplateManagement
@@ -33,6 +52,11 @@ type TemplateManagementStatus struct {
 	Error string `json:"error,omitempty"`
 	// Current reflects the applied access rules configuration.
 	Current []AccessRule `json:"current,omitempty"`
+	// TemplatesSourceRevision is the revision of the templates source applied last.
+	TemplatesSourceRevision string `json:"templatesSourceRevision,omitempty"`
+	// TemplatesSourceError is the error message occurred during the sync
+	// of the templates source (if any).
+	TemplatesSourceError string `json:"templatesSourceError,omitempty"`
 	// ObservedGeneration is the last observed generation.
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
@@ -49,6 +73,12 @@ type AccessRule struct {
 	// ServiceTemplateChains lists the names of ServiceTemplateChains whose ServiceTemplates
 	// will be distributed to all namespaces specified in TargetNamespaces.
 	ServiceTemplateChains []string `json:"serviceTemplateChains,omitempty"`
+	// ClusterTemplates lists the names of ClusterTemplates from the system namespace
+	// that will be distributed to all namespaces specified in TargetNamespaces.
+	ClusterTemplates []string `json:"clusterTemplates,omitempty"`
+	// ServiceTemplates lists the names of ServiceTemplates from the system namespace
+	// that will be distributed to all namespaces specified in TargetNamespaces.
+	ServiceTemplates []string `json:"serviceTemplates,omitempty"`
 }
 
 // +kubebuilder:validation:XValidation:rule="((has(self.stringSelector) ? 1 : 0) + (has(self.selector) ? 1 : 0) + (has(self.list) ? 1 : 0)) <= 1", message="only one of spec.targetNamespaces.selector or spec.targetNamespaces.stringSelector or spec.targetNamespaces.list can be specified"
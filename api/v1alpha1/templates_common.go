@@ -19,9 +19,11 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"time"
 
 	helmcontrollerv2 "github.com/fluxcd/helm-controller/api/v2"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
@@ -30,9 +32,26 @@ const (
 	ChartAnnotationProviderName = "cluster.x-k8s.io/provider"
 
 	chartAnnoCAPIPrefix = "cluster.x-k8s.io/"
+
+	// ForceTemplateDeletionAnnotation allows to delete the ClusterTemplate or the ServiceTemplate
+	// used by ManagedClusters when set to "true" on the template.
+	ForceTemplateDeletionAnnotation = "hmc.mirantis.com/force-deletion"
+
+	// ProviderContractValidatedCondition reports whether the chart of the ClusterTemplate
+	// has been verified to render the kinds of the providers declared by the template.
+	ProviderContractValidatedCondition = "ProviderContractValidated"
+
+	// CRDVersionsDetectedCondition reports whether the versions served by the CustomResourceDefinitions
+	// of the ProviderTemplate have been detected, the HMC is not upgraded to the template otherwise.
+	CRDVersionsDetectedCondition = "CRDVersionsDetected"
+
+	// RenderFailedReason is used when a check of the template is skipped
+	// since the chart can not be rendered with its default values.
+	RenderFailedReason = "RenderFailed"
 )
 
 // +kubebuilder:validation:XValidation:rule="(has(self.chartName) && !has(self.chartRef)) || (!has(self.chartName) && has(self.chartRef))", message="either chartName or chartRef must be set"
+// +kubebuilder:validation:XValidation:rule="!has(self.repository) || has(self.chartName)", message="repository can only be set along with chartName"
 
 // HelmSpec references a Helm chart representing the HMC template
 type HelmSpec struct {
@@ -43,6 +62,10 @@ type HelmSpec struct {
 	ChartName string `json:"chartName,omitempty"`
 	// ChartVersion is a version of a Helm chart representing the template in the HMC repository.
 	ChartVersion string `json:"chartVersion,omitempty"`
+	// Repository is a name of a HelmRepository in the namespace of the template
+	// the chart named ChartName is pulled from, both the HTTP and the OCI
+	// repositories are supported. Defaults to the HMC repository.
+	Repository string `json:"repository,omitempty"`
 }
 
 func (s *HelmSpec) String() string {
@@ -53,6 +76,41 @@ func (s *HelmSpec) String() string {
 	return s.ChartName + ": " + s.ChartVersion
 }
 
+// TemplateDeprecation holds the deprecation metadata of a template.
+type TemplateDeprecation struct {
+	// Deprecated marks the template as deprecated, the objects using
+	// the template are warned to move to another template.
+	Deprecated bool `json:"deprecated,omitempty"`
+	// SunsetDate is the time starting from which the template can not be
+	// used by new objects. The template is deprecated once the date is set.
+	SunsetDate *metav1.Time `json:"sunsetDate,omitempty"`
+}
+
+// IsDeprecated returns true if the template is deprecated.
+func (d *TemplateDeprecation) IsDeprecated() bool {
+	return d.Deprecated || d.SunsetDate != nil
+}
+
+// IsSunset returns true if the sunset date of the template has passed at the given time.
+func (d *TemplateDeprecation) IsSunset(now time.Time) bool {
+	return d.SunsetDate != nil && !now.Before(d.SunsetDate.Time)
+}
+
+// DeprecationMessage returns the human-readable deprecation status of the template
+// of the given kind and name at the given time or an empty string if it is not deprecated.
+func (d *TemplateDeprecation) DeprecationMessage(kind, name string, now time.Time) string {
+	switch {
+	case d.IsSunset(now):
+		return fmt.Sprintf("%s %s is sunset since %s", kind, name, d.SunsetDate.UTC().Format(time.RFC3339))
+	case d.SunsetDate != nil:
+		return fmt.Sprintf("%s %s is deprecated and will be sunset on %s", kind, name, d.SunsetDate.UTC().Format(time.RFC3339))
+	case d.Deprecated:
+		return fmt.Sprintf("%s %s is deprecated", kind, name)
+	default:
+		return ""
+	}
+}
+
 // TemplateStatusCommon defines the observed state of Template common for all Template types
 type TemplateStatusCommon struct {
 	// Config demonstrates available parameters for template customization,
@@ -66,6 +124,12 @@ type TemplateStatusCommon struct {
 
 	TemplateValidationStatus `json:",inline"`
 
+	// Conditions report the checks of the template not affecting its validity,
+	// e.g. the checks skipped since the chart can not be rendered.
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
 	// ObservedGeneration is the last observed generation.
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
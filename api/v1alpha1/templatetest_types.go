@@ -0,0 +1,172 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"fmt"
+	"time"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TemplateTestKind is the string representation of a TemplateTest.
+	TemplateTestKind = "TemplateTest"
+
+	// TemplateTestLabelKey is the label of the ManagedClusters provisioned by a TemplateTest,
+	// the value is the name of the TemplateTest.
+	TemplateTestLabelKey = "hmc.mirantis.com/template-test"
+
+	// TemplateTestPassedCondition indicates whether the last run of the TemplateTest passed.
+	TemplateTestPassedCondition = "Passed"
+)
+
+// TemplateTestSpec defines the desired state of TemplateTest
+type TemplateTestSpec struct {
+	// Config allows to provide parameters for the template of the test cluster,
+	// the same way as the config of a ManagedCluster.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Config *apiextensionsv1.JSON `json:"config,omitempty"`
+
+	// +kubebuilder:validation:MinLength=1
+
+	// Template is the name of the ClusterTemplate to test.
+	Template string `json:"template"`
+	// Credential is the name of the Credential of the test cluster.
+	Credential string `json:"credential,omitempty"`
+
+	// +kubebuilder:default:="24h"
+
+	// Interval is the time between the starts of the runs of the test.
+	Interval metav1.Duration `json:"interval,omitempty"`
+
+	// +kubebuilder:default:="1h"
+
+	// Timeout is how long the test cluster has to become ready, and to be deleted afterwards.
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+
+	// +kubebuilder:default:=10
+	// +kubebuilder:validation:Minimum=1
+
+	// HistoryLimit is the number of the last runs kept in the status.
+	HistoryLimit int32 `json:"historyLimit,omitempty"`
+
+	// Suspend stops starting the new runs, the current run is completed.
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// TemplateTestResult is the result of a run of a TemplateTest.
+type TemplateTestResult string
+
+const (
+	// TemplateTestResultPassed means the test cluster became ready and was deleted in time.
+	TemplateTestResultPassed TemplateTestResult = "Passed"
+	// TemplateTestResultFailed means the test cluster failed to be provisioned or deleted in time.
+	TemplateTestResultFailed TemplateTestResult = "Failed"
+)
+
+// TemplateTestRun describes a single run of a TemplateTest.
+type TemplateTestRun struct {
+	// StartedAt is the time the run was started.
+	StartedAt metav1.Time `json:"startedAt"`
+	// VerifiedAt is the time the readiness of the test cluster was verified.
+	VerifiedAt *metav1.Time `json:"verifiedAt,omitempty"`
+	// FinishedAt is the time the test cluster was deleted.
+	FinishedAt *metav1.Time `json:"finishedAt,omitempty"`
+	// Cluster is the name of the test ManagedCluster, empty until the cluster is created.
+	Cluster string `json:"cluster,omitempty"`
+	// Template is the name of the tested ClusterTemplate.
+	Template string `json:"template"`
+	// +kubebuilder:validation:Enum=Passed;Failed
+
+	// Result is the result of the run, empty while the run is in progress.
+	Result TemplateTestResult `json:"result,omitempty"`
+	// Message contains details on the result of the run.
+	Message string `json:"message,omitempty"`
+}
+
+// TemplateTestStatus defines the observed state of TemplateTest
+type TemplateTestStatus struct {
+	// Conditions contains details for the current state of the TemplateTest.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// CurrentRun is the run in progress.
+	CurrentRun *TemplateTestRun `json:"currentRun,omitempty"`
+	// RunNumber is the number of the last started run, the test cluster of
+	// the run is named after the test and the number, e.g. aws-test-3.
+	RunNumber int64 `json:"runNumber,omitempty"`
+	// History contains the last completed runs, the newest entry comes last.
+	History []TemplateTestRun `json:"history,omitempty"`
+	// ObservedGeneration is the last observed generation.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=tt
+// +kubebuilder:printcolumn:name="Template",type=string,JSONPath=`.spec.template`
+// +kubebuilder:printcolumn:name="Passed",type=string,JSONPath=`.status.conditions[?(@.type=="Passed")].status`
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.status.currentRun.cluster`
+// +kubebuilder:printcolumn:name="Suspended",type=boolean,JSONPath=`.spec.suspend`,priority=1
+
+// TemplateTest is the Schema for the templatetests API. It periodically provisions
+// a short-lived ManagedCluster from the template, verifies its readiness and deletes it.
+type TemplateTest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TemplateTestSpec   `json:"spec,omitempty"`
+	Status TemplateTestStatus `json:"status,omitempty"`
+}
+
+func (in *TemplateTest) GetConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// NextRunTime returns the time the next run of the test is due,
+// the zero time if the test has never run or its spec changed since the last run.
+func (in *TemplateTest) NextRunTime() time.Time {
+	if len(in.Status.History) == 0 || in.Status.ObservedGeneration != in.Generation {
+		return time.Time{}
+	}
+	return in.Status.History[len(in.Status.History)-1].StartedAt.Add(in.Spec.Interval.Duration)
+}
+
+// RunClusterName returns the name of the test ManagedCluster of the current run.
+func (in *TemplateTest) RunClusterName() string {
+	return fmt.Sprintf("%s-%d", in.Name, in.Status.RunNumber)
+}
+
+// AddHistoryEntry appends the given run to the TemplateTest history
+// dropping the oldest entries over the HistoryLimit.
+func (in *TemplateTest) AddHistoryEntry(run TemplateTestRun) {
+	in.Status.History = append(in.Status.History, run)
+	if overflow := len(in.Status.History) - int(max(in.Spec.HistoryLimit, 1)); overflow > 0 {
+		in.Status.History = in.Status.History[overflow:]
+	}
+}
+
+// +kubebuilder:object:root=true
+
+// TemplateTestList contains a list of TemplateTest
+type TemplateTestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TemplateTest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&TemplateTest{}, &TemplateTestList{})
+}
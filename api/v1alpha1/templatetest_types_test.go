@@ -0,0 +1,55 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTemplateTestNextRunTime(t *testing.T) {
+	started := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	test := &TemplateTest{
+		ObjectMeta: metav1.ObjectMeta{Generation: 1},
+		Spec:       TemplateTestSpec{Interval: metav1.Duration{Duration: time.Hour}},
+	}
+	if got := test.NextRunTime(); !got.IsZero() {
+		t.Errorf("expected the first run to be due immediately, got %s", got)
+	}
+
+	test.Status.ObservedGeneration = 1
+	test.Status.History = []TemplateTestRun{{StartedAt: metav1.NewTime(started)}}
+	if got, expected := test.NextRunTime(), started.Add(time.Hour); !got.Equal(expected) {
+		t.Errorf("expected the next run at %s, got %s", expected, got)
+	}
+
+	test.Generation = 2
+	if got := test.NextRunTime(); !got.IsZero() {
+		t.Errorf("expected the run to be due immediately once the spec changes, got %s", got)
+	}
+}
+
+func TestTemplateTestAddHistoryEntry(t *testing.T) {
+	test := &TemplateTest{Spec: TemplateTestSpec{HistoryLimit: 2}}
+	for _, cluster := range []string{"a", "b", "c"} {
+		test.AddHistoryEntry(TemplateTestRun{Cluster: cluster})
+	}
+
+	if len(test.Status.History) != 2 || test.Status.History[0].Cluster != "b" || test.Status.History[1].Cluster != "c" {
+		t.Errorf("expected the runs b and c to be kept, got %v", test.Status.History)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// UpgradeGroupKind is the string representation of an UpgradeGroup.
+	UpgradeGroupKind = "UpgradeGroup"
+
+	// UpgradeHaltedCondition indicates the UpgradeGroup stopped upgrading the clusters
+	// since too many of them failed to upgrade.
+	UpgradeHaltedCondition = "UpgradeHalted"
+
+	// FailuresExceededReason is set when more clusters failed to upgrade than allowed.
+	FailuresExceededReason = "FailuresExceeded"
+	// WithinFailureBudgetReason is set when no more clusters failed to upgrade than allowed.
+	WithinFailureBudgetReason = "WithinFailureBudget"
+)
+
+// UpgradeGroupSpec defines the desired state of UpgradeGroup
+type UpgradeGroupSpec struct {
+	// ClusterSelector identifies the ManagedClusters in the namespace to upgrade.
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector"`
+
+	// +kubebuilder:validation:MinLength=1
+
+	// Template is the name of the ClusterTemplate to upgrade the clusters to.
+	// It must be in the available upgrades of each of the clusters.
+	Template string `json:"template"`
+
+	// +kubebuilder:validation:XIntOrString
+	// +kubebuilder:validation:Pattern=`^((100|[1-9][0-9]?)%|[1-9][0-9]*)$`
+
+	// BatchSize is the number or the percentage of the selected clusters
+	// upgraded at the same time. Defaults to 1.
+	BatchSize *intstr.IntOrString `json:"batchSize,omitempty"`
+
+	// +kubebuilder:default:="1h"
+
+	// BatchTimeout is how long the clusters of a batch have to become ready on the new
+	// template, the clusters not ready in time count as failed.
+	BatchTimeout metav1.Duration `json:"batchTimeout,omitempty"`
+
+	// +kubebuilder:validation:Minimum=0
+
+	// MaxFailures is the number of the clusters allowed to fail to upgrade
+	// before the upgrade of the remaining clusters is halted.
+	MaxFailures int32 `json:"maxFailures,omitempty"`
+}
+
+// UpgradeGroupPhase is the phase of the upgrade of the clusters of an UpgradeGroup.
+type UpgradeGroupPhase string
+
+const (
+	// UpgradeGroupPhaseProgressing means the clusters are being upgraded batch by batch.
+	UpgradeGroupPhaseProgressing UpgradeGroupPhase = "Progressing"
+	// UpgradeGroupPhaseCompleted means all of the selected clusters are upgraded or failed within the budget.
+	UpgradeGroupPhaseCompleted UpgradeGroupPhase = "Completed"
+	// UpgradeGroupPhaseHalted means the upgrade is stopped since too many clusters failed.
+	UpgradeGroupPhaseHalted UpgradeGroupPhase = "Halted"
+)
+
+// ClusterUpgradePhase is the phase of the upgrade of a single cluster.
+type ClusterUpgradePhase string
+
+const (
+	// ClusterUpgradePhasePending means the cluster is yet to be upgraded.
+	ClusterUpgradePhasePending ClusterUpgradePhase = "Pending"
+	// ClusterUpgradePhaseUpgrading means the cluster uses the template and is not ready yet.
+	ClusterUpgradePhaseUpgrading ClusterUpgradePhase = "Upgrading"
+	// ClusterUpgradePhaseUpgraded means the cluster is deployed with the template and ready.
+	ClusterUpgradePhaseUpgraded ClusterUpgradePhase = "Upgraded"
+	// ClusterUpgradePhaseFailed means the cluster failed to upgrade.
+	ClusterUpgradePhaseFailed ClusterUpgradePhase = "Failed"
+	// ClusterUpgradePhaseSkipped means the cluster is upgraded by another UpgradeGroup selecting it.
+	ClusterUpgradePhaseSkipped ClusterUpgradePhase = "Skipped"
+)
+
+// ClusterUpgradeStatus is the state of the upgrade of a cluster.
+type ClusterUpgradeStatus struct {
+	// Name is the name of the ManagedCluster.
+	Name string `json:"name"`
+	// Phase is the phase of the upgrade of the cluster.
+	Phase ClusterUpgradePhase `json:"phase"`
+	// Message explains the failure.
+	Message string `json:"message,omitempty"`
+}
+
+// UpgradeGroupStatus defines the observed state of UpgradeGroup
+type UpgradeGroupStatus struct {
+	// Conditions contains details for the current state of the UpgradeGroup.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Phase is the phase of the upgrade.
+	Phase UpgradeGroupPhase `json:"phase,omitempty"`
+	// Batch is the number of the current batch, starting from 1.
+	Batch int32 `json:"batch,omitempty"`
+	// BatchStartedAt is the time the clusters of the current batch started to upgrade.
+	BatchStartedAt *metav1.Time `json:"batchStartedAt,omitempty"`
+	// Clusters lists the state of the upgrade of the selected clusters.
+	Clusters []ClusterUpgradeStatus `json:"clusters,omitempty"`
+	// ObservedGeneration is the last observed generation.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=ug
+// +kubebuilder:printcolumn:name="Template",type=string,JSONPath=`.spec.template`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Batch",type=integer,JSONPath=`.status.batch`
+
+// UpgradeGroup is the Schema for the upgradegroups API. It upgrades the selected
+// ManagedClusters of its namespace to the template batch by batch.
+type UpgradeGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   UpgradeGroupSpec   `json:"spec,omitempty"`
+	Status UpgradeGroupStatus `json:"status,omitempty"`
+}
+
+func (in *UpgradeGroup) GetConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// BatchClusters returns the number of the clusters upgraded at the same time
+// out of the given number of the selected clusters, at least one.
+func (in *UpgradeGroup) BatchClusters(total int) int {
+	if in.Spec.BatchSize == nil {
+		return 1
+	}
+	size, err := intstr.GetScaledValueFromIntOrPercent(in.Spec.BatchSize, total, true)
+	if err != nil || size < 1 {
+		return 1
+	}
+	return size
+}
+
+// Precedes returns true if the UpgradeGroup takes precedence over the other one over the
+// clusters they both select: the group created first upgrades them, the groups created
+// within the same second are ordered by name.
+func (in *UpgradeGroup) Precedes(other *UpgradeGroup) bool {
+	if !in.CreationTimestamp.Equal(&other.CreationTimestamp) {
+		return in.CreationTimestamp.Before(&other.CreationTimestamp)
+	}
+	return in.Name < other.Name
+}
+
+// +kubebuilder:object:root=true
+
+// UpgradeGroupList contains a list of UpgradeGroup
+type UpgradeGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []UpgradeGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&UpgradeGroup{}, &UpgradeGroupList{})
+}
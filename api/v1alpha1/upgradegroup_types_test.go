@@ -0,0 +1,43 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+)
+
+func TestUpgradeGroupBatchClusters(t *testing.T) {
+	tests := []struct {
+		batchSize *intstr.IntOrString
+		total     int
+		expected  int
+	}{
+		{nil, 10, 1},
+		{ptr.To(intstr.FromInt32(3)), 10, 3},
+		{ptr.To(intstr.FromString("25%")), 10, 3},
+		{ptr.To(intstr.FromString("100%")), 10, 10},
+		{ptr.To(intstr.FromString("10%")), 0, 1},
+	}
+
+	for _, tt := range tests {
+		group := &UpgradeGroup{Spec: UpgradeGroupSpec{BatchSize: tt.batchSize}}
+		if got := group.BatchClusters(tt.total); got != tt.expected {
+			t.Errorf("BatchClusters(%d) with batch size %v = %d, expected %d", tt.total, tt.batchSize, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1beta1
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	hmcv1alpha1 "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// The v1alpha1 API is the hub and the storage version, the v1beta1 objects
+// are converted to and from it by the conversion webhook. The v1beta1 API
+// groups the service settings of the ManagedCluster in spec.services and the
+// ManagedCluster defaults of the Management in spec.clusterDefaults, and renames
+// status.k8sVersion of the ManagedCluster to status.kubernetesVersion. The
+// rest of the fields are the same in both versions, the statuses differ only
+// in the JSON names and are converted as is.
+
+// ConvertTo converts the ManagedCluster to the hub version.
+func (src *ManagedCluster) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*hmcv1alpha1.ManagedCluster)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = hmcv1alpha1.ManagedClusterSpec{
+		CloneFrom:            src.Spec.CloneFrom,
+		Config:               src.Spec.Config,
+		ConfigMergeStrategy:  src.Spec.ConfigMergeStrategy,
+		Template:             src.Spec.Template,
+		Credential:           src.Spec.Credential,
+		IdentityCredentials:  src.Spec.IdentityCredentials,
+		Services:             src.Spec.Services.Templates,
+		ServicesPriority:     src.Spec.Services.Priority,
+		DryRun:               src.Spec.DryRun,
+		StopOnConflict:       src.Spec.Services.StopOnConflict,
+		ServicesDrainTimeout: src.Spec.Services.DrainTimeout,
+		PreDeleteCleanup:     src.Spec.PreDeleteCleanup,
+		Backup:               src.Spec.Backup,
+		DNS:                  src.Spec.DNS,
+		Autoscaler:           src.Spec.Autoscaler,
+		ClusterLabels:        src.Spec.ClusterLabels,
+		ClusterAnnotations:   src.Spec.ClusterAnnotations,
+		MaintenanceWindow:    src.Spec.MaintenanceWindow,
+		ReadinessGates:       src.Spec.ReadinessGates,
+		NodePools:            src.Spec.NodePools,
+		MachineHealthCheck:   src.Spec.MachineHealthCheck,
+		ControlPlane:         src.Spec.ControlPlane,
+		Workers:              src.Spec.Workers,
+		Network:              src.Spec.Network,
+		SSHKey:               src.Spec.SSHKey,
+		HelmRelease:          src.Spec.HelmRelease,
+		GitOps:               src.Spec.GitOps,
+	}
+	dst.Status = hmcv1alpha1.ManagedClusterStatus(src.Status)
+	return nil
+}
+
+// ConvertFrom converts the ManagedCluster from the hub version.
+func (dst *ManagedCluster) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*hmcv1alpha1.ManagedCluster)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = ManagedClusterSpec{
+		CloneFrom:           src.Spec.CloneFrom,
+		Config:              src.Spec.Config,
+		ConfigMergeStrategy: src.Spec.ConfigMergeStrategy,
+		Template:            src.Spec.Template,
+		Credential:          src.Spec.Credential,
+		IdentityCredentials: src.Spec.IdentityCredentials,
+		Services: ServicesSpec{
+			Templates:      src.Spec.Services,
+			Priority:       src.Spec.ServicesPriority,
+			StopOnConflict: src.Spec.StopOnConflict,
+			DrainTimeout:   src.Spec.ServicesDrainTimeout,
+		},
+		DryRun:             src.Spec.DryRun,
+		PreDeleteCleanup:   src.Spec.PreDeleteCleanup,
+		Backup:             src.Spec.Backup,
+		DNS:                src.Spec.DNS,
+		Autoscaler:         src.Spec.Autoscaler,
+		ClusterLabels:      src.Spec.ClusterLabels,
+		ClusterAnnotations: src.Spec.ClusterAnnotations,
+		MaintenanceWindow:  src.Spec.MaintenanceWindow,
+		ReadinessGates:     src.Spec.ReadinessGates,
+		NodePools:          src.Spec.NodePools,
+		MachineHealthCheck: src.Spec.MachineHealthCheck,
+		ControlPlane:       src.Spec.ControlPlane,
+		Workers:            src.Spec.Workers,
+		Network:            src.Spec.Network,
+		SSHKey:             src.Spec.SSHKey,
+		HelmRelease:        src.Spec.HelmRelease,
+		GitOps:             src.Spec.GitOps,
+	}
+	dst.Status = ManagedClusterStatus(src.Status)
+	return nil
+}
+
+// ConvertTo converts the Management to the hub version.
+func (src *Management) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*hmcv1alpha1.Management)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = hmcv1alpha1.ManagementSpec{
+		Release:               src.Spec.Release,
+		Core:                  src.Spec.Core,
+		Providers:             src.Spec.Providers,
+		ImageOverrides:        src.Spec.ImageOverrides,
+		Telemetry:             src.Spec.Telemetry,
+		GlobalClusterDefaults: src.Spec.ClusterDefaults.Values,
+		ChartVerification:     src.Spec.ChartVerification,
+		Monitoring:            src.Spec.Monitoring,
+		DeletionVerification:  src.Spec.DeletionVerification,
+		Notifications:         src.Spec.Notifications,
+		MaintenanceWindow:     src.Spec.ClusterDefaults.MaintenanceWindow,
+		DNS:                   src.Spec.ClusterDefaults.DNS,
+		Proxy:                 src.Spec.ClusterDefaults.Proxy,
+		TrustedCA:             src.Spec.ClusterDefaults.TrustedCA,
+	}
+	dst.Status = hmcv1alpha1.ManagementStatus(src.Status)
+	return nil
+}
+
+// ConvertFrom converts the Management from the hub version.
+func (dst *Management) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*hmcv1alpha1.Management)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = ManagementSpec{
+		Release:        src.Spec.Release,
+		Core:           src.Spec.Core,
+		Providers:      src.Spec.Providers,
+		ImageOverrides: src.Spec.ImageOverrides,
+		Telemetry:      src.Spec.Telemetry,
+		ClusterDefaults: ClusterDefaults{
+			Values:            src.Spec.GlobalClusterDefaults,
+			MaintenanceWindow: src.Spec.MaintenanceWindow,
+			DNS:               src.Spec.DNS,
+			Proxy:             src.Spec.Proxy,
+			TrustedCA:         src.Spec.TrustedCA,
+		},
+		ChartVerification:    src.Spec.ChartVerification,
+		Monitoring:           src.Spec.Monitoring,
+		DeletionVerification: src.Spec.DeletionVerification,
+		Notifications:        src.Spec.Notifications,
+	}
+	dst.Status = ManagementStatus(src.Status)
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1beta1
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
+
+	hmcv1alpha1 "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+func TestFuzzyConversion(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(hmcv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(AddToScheme(scheme))
+
+	t.Run("for ManagedCluster", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &hmcv1alpha1.ManagedCluster{},
+		Spoke:  &ManagedCluster{},
+	}))
+
+	t.Run("for Management", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &hmcv1alpha1.Management{},
+		Spoke:  &Management{},
+	}))
+}
+
+func TestManagedClusterConversion(t *testing.T) {
+	hub := &hmcv1alpha1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "dev"},
+		Spec: hmcv1alpha1.ManagedClusterSpec{
+			Template:             "aws-standalone-cp-0-0-7",
+			Services:             []hmcv1alpha1.ServiceSpec{{Name: "ingress", Template: "ingress-nginx-4-11-3"}},
+			ServicesPriority:     200,
+			StopOnConflict:       true,
+			ServicesDrainTimeout: &metav1.Duration{Duration: 5 * time.Minute},
+		},
+		Status: hmcv1alpha1.ManagedClusterStatus{KubernetesVersion: "v1.31.2"},
+	}
+
+	spoke := &ManagedCluster{}
+	if err := spoke.ConvertFrom(hub); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := ServicesSpec{
+		Templates:      hub.Spec.Services,
+		Priority:       200,
+		StopOnConflict: true,
+		DrainTimeout:   hub.Spec.ServicesDrainTimeout,
+	}
+	if !reflect.DeepEqual(spoke.Spec.Services, expected) {
+		t.Errorf("expected services %+v, got %+v", expected, spoke.Spec.Services)
+	}
+	if spoke.Spec.Template != hub.Spec.Template || spoke.Status.KubernetesVersion != hub.Status.KubernetesVersion {
+		t.Errorf("expected the template and the version to be kept, got %+v", spoke)
+	}
+
+	converted := &hmcv1alpha1.ManagedCluster{}
+	if err := spoke.ConvertTo(converted); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(converted, hub) {
+		t.Errorf("expected %+v, got %+v", hub, converted)
+	}
+}
+
+func TestManagementConversion(t *testing.T) {
+	hub := &hmcv1alpha1.Management{
+		ObjectMeta: metav1.ObjectMeta{Name: hmcv1alpha1.ManagementName},
+		Spec: hmcv1alpha1.ManagementSpec{
+			Release:               "hmc-0-0-3",
+			GlobalClusterDefaults: &apiextensionsv1.JSON{Raw: []byte(`{"sshKey":"hmc"}`)},
+			Proxy:                 &hmcv1alpha1.ProxyConfig{HTTPProxy: "http://proxy:3128"},
+		},
+	}
+
+	spoke := &Management{}
+	if err := spoke.ConvertFrom(hub); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := ClusterDefaults{Values: hub.Spec.GlobalClusterDefaults, Proxy: hub.Spec.Proxy}
+	if !reflect.DeepEqual(spoke.Spec.ClusterDefaults, expected) {
+		t.Errorf("expected cluster defaults %+v, got %+v", expected, spoke.Spec.ClusterDefaults)
+	}
+
+	converted := &hmcv1alpha1.Management{}
+	if err := spoke.ConvertTo(converted); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(converted, hub) {
+		t.Errorf("expected %+v, got %+v", hub, converted)
+	}
+}
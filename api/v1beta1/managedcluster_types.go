@@ -0,0 +1,240 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hmcv1alpha1 "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// ManagedClusterSpec defines the desired state of ManagedCluster
+type ManagedClusterSpec struct {
+	// CloneFrom is the name of an existing ManagedCluster in the same namespace the cluster
+	// is created from. The fields of the spec not set on the creation are copied from
+	// the existing cluster, the config is merged onto the config of the existing cluster
+	// without the instance-specific values, e.g. the control plane endpoint. The field is immutable.
+	CloneFrom string `json:"cloneFrom,omitempty"`
+
+	// Config allows to provide parameters for template customization.
+	// If no Config provided, the field will be populated with the default values for
+	// the template and DryRun will be enabled.
+	Config *apiextensionsv1.JSON `json:"config,omitempty"`
+
+	// +kubebuilder:default:=Merge
+
+	// ConfigMergeStrategy defines how the Config is combined with the default values of the template.
+	// Merge deep merges the maps and replaces the lists, StrategicMerge additionally merges the lists
+	// of objects by their "name" field, Replace replaces the top-level values of the defaults entirely.
+	ConfigMergeStrategy hmcv1alpha1.ConfigMergeStrategy `json:"configMergeStrategy,omitempty"`
+
+	// +kubebuilder:validation:MinLength=1
+
+	// Template is a reference to a Template object located in the same namespace.
+	// The "<chain>@latest" reference is resolved to the latest ClusterTemplate of the ClusterTemplateChain.
+	Template string `json:"template"`
+	// Name reference to the related Credentials object.
+	Credential string `json:"credential,omitempty"`
+	// IdentityCredentials maps the values paths of the template to the names of the Credentials of the
+	// namespace whose ClusterIdentity is passed at the path instead of the one of the Credential,
+	// e.g. "controlPlane.identityRef: cp-cred" for the templates using several identities.
+	IdentityCredentials map[string]string `json:"identityCredentials,omitempty"`
+
+	// +kubebuilder:default:={}
+
+	// Services configures the services installed on the cluster.
+	Services ServicesSpec `json:"services,omitempty"`
+	// DryRun specifies whether the template should be applied after validation or only validated.
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// PreDeleteCleanup enables the removal of the LoadBalancer Services and the PersistentVolumeClaims
+	// from the cluster before the cluster is torn down, so that the cloud provider releases the load
+	// balancers and the disks of the cluster.
+	PreDeleteCleanup *hmcv1alpha1.PreDeleteCleanupSpec `json:"preDeleteCleanup,omitempty"`
+	// Backup enables the scheduled backups of the workloads of the cluster.
+	Backup *hmcv1alpha1.ManagedClusterBackupSpec `json:"backup,omitempty"`
+	// DNS deploys external-dns on the cluster managing the records of the cluster subdomain,
+	// the fields not set are taken from the DNS config of the Management.
+	DNS *hmcv1alpha1.DNSConfig `json:"dns,omitempty"`
+	// Autoscaler deploys cluster-autoscaler on the cluster scaling the worker pools
+	// with the autoscaling set in the NodePools.
+	Autoscaler *hmcv1alpha1.ClusterAutoscalerSpec `json:"autoscaler,omitempty"`
+	// ClusterLabels are the labels ensured on the CAPI Cluster object of the cluster.
+	// The labels can be used by the cluster selectors of the services, e.g. env=prod.
+	ClusterLabels map[string]string `json:"clusterLabels,omitempty"`
+	// ClusterAnnotations are the annotations ensured on the CAPI Cluster object of the cluster.
+	ClusterAnnotations map[string]string `json:"clusterAnnotations,omitempty"`
+
+	// MaintenanceWindow restricts the time the changes of the deployed cluster are applied at:
+	// the template upgrades, the configuration changes and the service changes are held back
+	// outside of the window. The window of the Management is used if not set.
+	MaintenanceWindow *hmcv1alpha1.MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+
+	// +listType=map
+	// +listMapKey=conditionType
+
+	// ReadinessGates are the conditions in the status of the ManagedCluster which must be True
+	// for the cluster to become Ready, in addition to the conditions set by HMC. The conditions
+	// are expected to be set by the external controllers, e.g. the validation pipelines.
+	ReadinessGates []hmcv1alpha1.ReadinessGate `json:"readinessGates,omitempty"`
+
+	// +listType=map
+	// +listMapKey=name
+
+	// NodePools declares the labels, taints and autoscaling of the nodes of the worker pools of the cluster.
+	// The pools are passed to the template in the "nodePools" value keyed by the pool name,
+	// the templates apply them to the nodes when the nodes join the cluster. Only the pools
+	// declared in the values schema of the template are accepted.
+	NodePools []hmcv1alpha1.NodePoolSpec `json:"nodePools,omitempty"`
+	// MachineHealthCheck enables the remediation of the unhealthy worker machines of the cluster.
+	// It is passed to the template in the "machineHealthCheck" value, the templates render it
+	// into the CAPI MachineHealthCheck of the worker machines.
+	MachineHealthCheck *hmcv1alpha1.MachineHealthCheckSpec `json:"machineHealthCheck,omitempty"`
+
+	// The typed config sections below are merged into the values of the template
+	// over the Config. A section is set only at the values defined by the default
+	// values of the template, the values follow the layout of the HMC templates.
+
+	// ControlPlane configures the control plane machines of the cluster.
+	ControlPlane *hmcv1alpha1.ControlPlaneConfig `json:"controlPlane,omitempty"`
+
+	// +listType=map
+	// +listMapKey=name
+
+	// Workers configures the worker pools of the cluster,
+	// the HMC templates define a single pool named "worker".
+	Workers []hmcv1alpha1.WorkerPoolConfig `json:"workers,omitempty"`
+	// Network configures the infrastructure network the machines of the cluster are attached to.
+	Network *hmcv1alpha1.NetworkConfig `json:"network,omitempty"`
+	// SSHKey is the SSH key the machines of the cluster are accessible with:
+	// the name of the EC2 key pair on AWS, the public key on Azure and vSphere.
+	SSHKey string `json:"sshKey,omitempty"`
+	// HelmRelease tunes the HelmRelease deploying the cluster,
+	// e.g. increases the timeouts for the slow providers.
+	HelmRelease *hmcv1alpha1.HelmReleaseTuning `json:"helmRelease,omitempty"`
+	// GitOps registers the cluster in the GitOps tooling running in the management cluster
+	// once the cluster is ready, so the tooling can target the cluster right away.
+	GitOps *hmcv1alpha1.GitOpsRegistration `json:"gitops,omitempty"`
+}
+
+// ServicesSpec configures the services installed on the cluster.
+type ServicesSpec struct {
+	// Templates is a list of services created via ServiceTemplates
+	// that could be installed on the target cluster.
+	Templates []hmcv1alpha1.ServiceSpec `json:"templates,omitempty"`
+
+	// +kubebuilder:default:=100
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=2147483646
+
+	// Priority sets the priority for the services defined in this spec.
+	// Higher value means higher priority and lower means lower.
+	// In case of conflict with another object managing the service,
+	// the one with higher priority will get to deploy its services.
+	Priority int32 `json:"priority,omitempty"`
+
+	// +kubebuilder:default:=false
+
+	// StopOnConflict specifies what to do in case of a conflict.
+	// E.g. If another object is already managing a service.
+	// By default the remaining services will be deployed even if conflict is detected.
+	// If set to true, the deployment will stop after encountering the first conflict.
+	StopOnConflict bool `json:"stopOnConflict,omitempty"`
+	// DrainTimeout is the time the deletion of the cluster waits for the services
+	// to be withdrawn from the cluster before the cluster is torn down, e.g. to let the
+	// applications deprovision the cloud load balancers and volumes. Defaults to 10m,
+	// 0s tears the cluster down right away.
+	DrainTimeout *metav1.Duration `json:"drainTimeout,omitempty"`
+}
+
+// ManagedClusterStatus defines the observed state of ManagedCluster
+type ManagedClusterStatus struct {
+	// KubernetesVersion is the currently compatible exact Kubernetes version of the cluster.
+	// Being set only if provided by the corresponding ClusterTemplate.
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+	// HostedControlPlane is true if the control plane of the cluster is hosted
+	// within the management cluster as set by the corresponding ClusterTemplate.
+	HostedControlPlane bool `json:"hostedControlPlane,omitempty"`
+	// ControlPlaneEndpoint is the endpoint of the API server of the cluster as reported by the CAPI Cluster.
+	ControlPlaneEndpoint *hmcv1alpha1.APIEndpoint `json:"controlPlaneEndpoint,omitempty"`
+	// CAFingerprint is the SHA-256 fingerprint of the CA certificate of the cluster taken from
+	// the kubeconfig Secret of the cluster, e.g. sha256:9f86d0...
+	CAFingerprint string `json:"caFingerprint,omitempty"`
+	// Conditions contains details for the current state of the ManagedCluster.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Phase is a summary of the current state of the ManagedCluster computed from its conditions.
+	// +kubebuilder:validation:Enum=Pending;Provisioning;Ready;Updating;Deleting;Failed
+	Phase hmcv1alpha1.ManagedClusterPhase `json:"phase,omitempty"`
+
+	// AvailableUpgrades is the list of ClusterTemplate names to which
+	// this cluster can be upgraded. It can be an empty array, which means no upgrades are
+	// available.
+	AvailableUpgrades []string `json:"availableUpgrades,omitempty"`
+	// DryRun contains the preview of the changes, it is set only if the dry run is enabled.
+	DryRun *hmcv1alpha1.ManagedClusterDryRunStatus `json:"dryRun,omitempty"`
+	// DiffReport references the last report requested with the DiffRequestedAnnotation.
+	DiffReport *hmcv1alpha1.ManagedClusterDiffReport `json:"diffReport,omitempty"`
+	// Backup is the state of the backups of the workloads of the cluster, it is set only if the backups are enabled.
+	Backup *hmcv1alpha1.ManagedClusterBackupStatus `json:"backup,omitempty"`
+	// DNS is the state of the DNS integration of the cluster, it is set only if the DNS is enabled.
+	DNS *hmcv1alpha1.ManagedClusterDNSStatus `json:"dns,omitempty"`
+	// GitOpsSecret references the Secret registering the cluster in the GitOps tooling.
+	GitOpsSecret *corev1.SecretReference `json:"gitopsSecret,omitempty"`
+	// ServiceConflicts lists the services not deployed on the cluster
+	// since another object already manages them.
+	ServiceConflicts []hmcv1alpha1.ServiceConflict `json:"serviceConflicts,omitempty"`
+	// History contains the last deployments of the ManagedCluster, the newest entry comes last.
+	// A new entry is recorded every time the generated HelmRelease changes.
+	History []hmcv1alpha1.ManagedClusterHistoryEntry `json:"history,omitempty"`
+	// PendingMaintenance lists the changes held back until the maintenance window opens.
+	PendingMaintenance *hmcv1alpha1.PendingMaintenance `json:"pendingMaintenance,omitempty"`
+	// Usage reports the machines of the cluster for the attribution of the cloud spend.
+	Usage *hmcv1alpha1.ManagedClusterUsage `json:"usage,omitempty"`
+	// ObservedGeneration is the last observed generation.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=mcluster;mcl
+// +kubebuilder:printcolumn:name="phase",type="string",JSONPath=".status.phase",description="Phase",priority=0
+// +kubebuilder:printcolumn:name="ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="Ready",priority=0
+// +kubebuilder:printcolumn:name="status",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].message",description="Status",priority=0
+// +kubebuilder:printcolumn:name="dryRun",type="string",JSONPath=".spec.dryRun",description="Dry Run",priority=1
+// +kubebuilder:printcolumn:name="template",type="string",JSONPath=".spec.template",description="Template",priority=1
+
+// ManagedCluster is the Schema for the managedclusters API
+type ManagedCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ManagedClusterSpec   `json:"spec,omitempty"`
+	Status ManagedClusterStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ManagedClusterList contains a list of ManagedCluster
+type ManagedClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ManagedCluster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ManagedCluster{}, &ManagedClusterList{})
+}
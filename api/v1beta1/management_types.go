@@ -0,0 +1,137 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1beta1
+
+import (
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hmcv1alpha1 "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// ManagementSpec defines the desired state of Management
+type ManagementSpec struct {
+	// Release references the Release object.
+	Release string `json:"release"`
+	// Core holds the core Management components that are mandatory.
+	// If not specified, will be populated with the default values.
+	Core *hmcv1alpha1.Core `json:"core,omitempty"`
+
+	// Providers is the list of supported CAPI providers.
+	Providers []hmcv1alpha1.Provider `json:"providers,omitempty"`
+
+	// ImageOverrides is the list of registry rewrite rules applied to the
+	// image settings exposed by ClusterTemplate and ServiceTemplate charts.
+	// Rules are evaluated in order, the first matching rule wins.
+	ImageOverrides []hmcv1alpha1.ImageOverride `json:"imageOverrides,omitempty"`
+
+	// Telemetry configures the collection of the anonymous usage data.
+	Telemetry *hmcv1alpha1.Telemetry `json:"telemetry,omitempty"`
+
+	// ClusterDefaults are the defaults of the ManagedClusters.
+	ClusterDefaults ClusterDefaults `json:"clusterDefaults,omitempty"`
+
+	// ChartVerification is the list of the signature verification policies
+	// of the Helm charts per HelmRepository. The templates with the charts
+	// from a HelmRepository with a policy are valid only once the signature
+	// of the chart has been verified.
+	ChartVerification []hmcv1alpha1.ChartVerificationPolicy `json:"chartVerification,omitempty"`
+
+	// Monitoring deploys a monitoring agent on the managed clusters
+	// writing the metrics to the storage in the management cluster.
+	Monitoring *hmcv1alpha1.Monitoring `json:"monitoring,omitempty"`
+
+	// DeletionVerification verifies the removal of the cloud instances of the
+	// deleted clusters by the provider API. By default the infrastructure cluster
+	// is released once the Machines of the cluster are removed.
+	DeletionVerification *hmcv1alpha1.DeletionVerification `json:"deletionVerification,omitempty"`
+
+	// Notifications configures the receivers notified about the provisioning
+	// of the ManagedClusters and the upgrades available for them.
+	Notifications *hmcv1alpha1.Notifications `json:"notifications,omitempty"`
+}
+
+// ClusterDefaults are the defaults of the ManagedClusters set in the Management.
+type ClusterDefaults struct {
+	// Values are merged into the values of every ManagedCluster on top of the template
+	// default values, e.g. registry mirrors, proxy settings or SSH keys.
+	// The values of the ManagedCluster config take precedence over them.
+	Values *apiextensionsv1.JSON `json:"values,omitempty"`
+
+	// MaintenanceWindow is the default maintenance window of the ManagedClusters
+	// not defining their own, see ManagedClusterSpec.MaintenanceWindow.
+	MaintenanceWindow *hmcv1alpha1.MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+
+	// DNS is the default DNS config of the ManagedClusters enabling the DNS integration,
+	// see ManagedClusterSpec.DNS. The credentials Secret is looked up in the namespace of each cluster.
+	DNS *hmcv1alpha1.DNSConfig `json:"dns,omitempty"`
+
+	// Proxy is the HTTP proxy the machines of the ManagedClusters and the services
+	// deployed by HMC on them reach the external endpoints through.
+	Proxy *hmcv1alpha1.ProxyConfig `json:"proxy,omitempty"`
+
+	// TrustedCA is the CA bundle trusted by the machines of the ManagedClusters
+	// and the services deployed by HMC on them in addition to the system CAs.
+	TrustedCA *hmcv1alpha1.TrustedCA `json:"trustedCA,omitempty"`
+}
+
+// ManagementStatus defines the observed state of Management
+type ManagementStatus struct {
+	// For each CAPI provider name holds its compatibility [contract versions]
+	// in a key-value pairs, where the key is the core CAPI contract version,
+	// and the value is an underscore-delimited (_) list of provider contract versions
+	// supported by the core CAPI.
+	//
+	// [contract versions]: https://cluster-api.sigs.k8s.io/developer/providers/contracts
+	CAPIContracts map[string]hmcv1alpha1.CompatibilityContracts `json:"capiContracts,omitempty"`
+	// Components indicates the status of installed HMC components and CAPI providers.
+	Components map[string]hmcv1alpha1.ComponentStatus `json:"components,omitempty"`
+	// Release indicates the current Release object.
+	Release string `json:"release,omitempty"`
+	// AvailableProviders holds all CAPI providers available along with
+	// their supported contract versions, if specified in ProviderTemplates, on the Management cluster.
+	AvailableProviders hmcv1alpha1.Providers `json:"availableProviders,omitempty"`
+	// ProviderVersions holds the versions of the installed CAPI providers,
+	// where the key is the name of the provider.
+	ProviderVersions map[string]string `json:"providerVersions,omitempty"`
+	// ObservedGeneration is the last observed generation.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=hmc-mgmt;mgmt,scope=Cluster
+
+// Management is the Schema for the managements API
+type Management struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ManagementSpec   `json:"spec,omitempty"`
+	Status ManagementStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ManagementList contains a list of Management
+type ManagementList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Management `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Management{}, &ManagementList{})
+}
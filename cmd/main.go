@@ -15,31 +15,48 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
 	"flag"
+	"fmt"
 	"os"
+	"strings"
+	"time"
+	// the time zones of the maintenance windows are resolved in the distroless image
+	_ "time/tzdata"
 
 	hcv2 "github.com/fluxcd/helm-controller/api/v2"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	sveltosv1alpha1 "github.com/projectsveltos/addon-controller/api/v1alpha1"
 	sveltosv1beta1 "github.com/projectsveltos/addon-controller/api/v1beta1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	capz "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	capv "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	hmcmirantiscomv1alpha1 "github.com/Mirantis/hmc/api/v1alpha1"
+	hmcmirantiscomv1beta1 "github.com/Mirantis/hmc/api/v1beta1"
+	"github.com/Mirantis/hmc/internal/audit"
 	"github.com/Mirantis/hmc/internal/controller"
+	"github.com/Mirantis/hmc/internal/credspropagation"
 	"github.com/Mirantis/hmc/internal/helm"
+	"github.com/Mirantis/hmc/internal/notifications"
 	"github.com/Mirantis/hmc/internal/telemetry"
 	"github.com/Mirantis/hmc/internal/utils"
+	"github.com/Mirantis/hmc/internal/velero"
 	hmcwebhook "github.com/Mirantis/hmc/internal/webhook"
 )
 
@@ -50,10 +67,13 @@ var (
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
 
 	utilruntime.Must(hmcmirantiscomv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(hmcmirantiscomv1beta1.AddToScheme(scheme))
 	utilruntime.Must(sourcev1.AddToScheme(scheme))
 	utilruntime.Must(hcv2.AddToScheme(scheme))
+	utilruntime.Must(sveltosv1alpha1.AddToScheme(scheme))
 	utilruntime.Must(sveltosv1beta1.AddToScheme(scheme))
 	utilruntime.Must(capz.AddToScheme(scheme))
 	utilruntime.Must(capv.AddToScheme(scheme))
@@ -78,7 +98,28 @@ func main() {
 		enableWebhook             bool
 		webhookPort               int
 		webhookCertDir            string
+		statusSyncConcurrency     int
+		veleroNamespace           string
+		watchNamespaces           string
+		shard                     string
+		leaderElect               bool
+		leaseDuration             time.Duration
+		renewDeadline             time.Duration
+		retryPeriod               time.Duration
+		enabledControllers        string
+		secretStore               string
+		vaultAddress              string
+		vaultMount                string
+		vaultKeyPrefix            string
+		clusterSecretStore        string
+		auditConfigMap            string
+		auditWebhookURL           string
+		autoscalerClusterRole     string
+		autoscalerNamespaces      string
+		gitOpsNamespaces          string
+		isolateIdentities         bool
 	)
+	downloadBackoff := helm.DefaultDownloadBackoff()
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -101,6 +142,54 @@ func main() {
 	flag.BoolVar(&enableTelemetry, "enable-telemetry", true, "Collect and send telemetry data.")
 	flag.BoolVar(&enableWebhook, "enable-webhook", true, "Enable admission webhook.")
 	flag.IntVar(&webhookPort, "webhook-port", 9443, "Admission webhook port.")
+	flag.IntVar(&statusSyncConcurrency, "status-sync-concurrency", controller.DefaultStatusSyncConcurrency,
+		"The number of ManagedCluster status syncs allowed to run concurrently.")
+	flag.StringVar(&veleroNamespace, "velero-namespace", velero.DefaultNamespace,
+		"The namespace Velero is installed into, used for the management backups.")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", "",
+		"Comma-separated list of the namespaces to watch the namespaced objects in, all of the namespaces are watched if empty. "+
+			"The system namespace is always watched.")
+	flag.StringVar(&shard, "shard", "",
+		"The name of the shard to reconcile the ManagedClusters and the Credentials of. The namespaces are assigned to the shard with the "+
+			hmcmirantiscomv1alpha1.ShardLabelKey+" label. The cluster-wide controllers and the webhooks run in the default shard only.")
+	flag.BoolVar(&isolateIdentities, "isolate-identities", false,
+		"Allow the Credentials to isolate the ClusterIdentities, requires the permissions to create the ClusterIdentities.")
+	flag.BoolVar(&leaderElect, "leader-elect", true,
+		"Enable leader election for the controller manager, ensures there is only one active controller manager.")
+	flag.DurationVar(&leaseDuration, "leader-elect-lease-duration", 15*time.Second,
+		"The duration the non-leader candidates wait to force acquire the leadership.")
+	flag.DurationVar(&renewDeadline, "leader-elect-renew-deadline", 10*time.Second,
+		"The duration the acting leader retries refreshing the leadership before giving it up.")
+	flag.DurationVar(&retryPeriod, "leader-elect-retry-period", 2*time.Second,
+		"The duration the clients wait between tries of the leader election actions.")
+	flag.StringVar(&enabledControllers, "controllers", "*",
+		"Comma-separated list of the controllers to enable. '*' enables all of the controllers, "+
+			"'Foo' enables the controller named Foo, '-Foo' disables the controller named Foo.")
+	flag.StringVar(&secretStore, "secret-store", "kubernetes",
+		"The store of the CCM credentials propagated to the managed clusters and the kubeconfig copies registering the clusters in the GitOps tooling. "+
+			"'kubernetes' writes plain Secrets, 'vault' writes the data to Vault and creates the ExternalSecrets reading it instead of the Secrets, "+
+			"the Vault token is read from the VAULT_TOKEN environment variable.")
+	flag.StringVar(&vaultAddress, "vault-address", "", "The address of Vault used by the 'vault' secret store.")
+	flag.StringVar(&vaultMount, "vault-mount", "secret", "The path the KV version 2 secrets engine of Vault is mounted at.")
+	flag.StringVar(&vaultKeyPrefix, "vault-key-prefix", "hmc", "The prefix of the keys the data is written to Vault under.")
+	flag.StringVar(&clusterSecretStore, "cluster-secret-store", "",
+		"The name of the ClusterSecretStore of the External Secrets Operator reading the Vault in the management and the managed clusters.")
+	flag.StringVar(&auditConfigMap, "audit-configmap", "",
+		"The name of the ConfigMap in the system namespace the latest audit entries of the changes made by HMC are kept in, the entries are only logged if empty.")
+	flag.StringVar(&auditWebhookURL, "audit-webhook-url", "",
+		"The URL of the external endpoint the audit entries of the changes made by HMC are posted to.")
+	flag.StringVar(&autoscalerClusterRole, "cluster-autoscaler-role", "",
+		"The name of the ClusterRole bound to cluster-autoscaler of the managed clusters in the namespace of the ManagedCluster.")
+	flag.StringVar(&autoscalerNamespaces, "cluster-autoscaler-namespaces", "",
+		"Comma-separated list of the namespaces cluster-autoscaler may be enabled on the ManagedClusters in, "+
+			"requires the permissions to write the ServiceAccounts and the Roles in the namespaces.")
+	flag.StringVar(&gitOpsNamespaces, "gitops-namespaces", "",
+		"Comma-separated list of the namespaces the clusters may be registered in the GitOps tooling in, "+
+			"requires the permissions to write the Secrets in the namespaces.")
+	flag.IntVar(&downloadBackoff.Steps, "chart-download-attempts", downloadBackoff.Steps,
+		"The number of attempts to download the Helm charts of the templates, the failed downloads are retried with an exponential backoff.")
+	flag.DurationVar(&downloadBackoff.Duration, "chart-download-backoff", downloadBackoff.Duration,
+		"The initial backoff of the retries of the failed Helm chart downloads.")
 	flag.StringVar(&webhookCertDir, "webhook-cert-dir", "/tmp/k8s-webhook-server/serving-certs/",
 		"Webhook cert dir, only used when webhook-port is specified.")
 	opts := zap.Options{
@@ -111,6 +200,12 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	secretWriter, err := newSecretWriter(secretStore, vaultAddress, vaultMount, vaultKeyPrefix, clusterSecretStore)
+	if err != nil {
+		setupLog.Error(err, "failed to configure the secret store")
+		os.Exit(1)
+	}
+
 	determinedRepositoryType, err := utils.DetermineDefaultRepositoryType(defaultRegistryURL)
 	if err != nil {
 		setupLog.Error(err, "failed to determine default repository type")
@@ -133,6 +228,27 @@ func main() {
 		tlsOpts = append(tlsOpts, disableHTTP2)
 	}
 
+	currentNamespace := utils.CurrentNamespace()
+
+	auditRecorder, err := newAuditRecorder(auditConfigMap, currentNamespace, auditWebhookURL)
+	if err != nil {
+		setupLog.Error(err, "failed to configure the audit")
+		os.Exit(1)
+	}
+
+	restConfig := ctrl.GetConfigOrDie()
+	// the cache strips the managed fields, the applied objects are read from the API server
+	apiReader, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		setupLog.Error(err, "unable to create the API reader")
+		os.Exit(1)
+	}
+
+	leaderElectionID := "31c555b4.hmc.mirantis.com"
+	if shard != "" {
+		leaderElectionID = shard + "." + leaderElectionID
+	}
+
 	managerOpts := ctrl.Options{
 		Scheme: scheme,
 		Metrics: metricsserver.Options{
@@ -140,9 +256,34 @@ func main() {
 			SecureServing: secureMetrics,
 			TLSOpts:       tlsOpts,
 		},
+		Cache: cache.Options{
+			// the managed fields are never used by the controllers
+			DefaultTransform: cache.TransformStripManagedFields(),
+			ByObject: map[client.Object]cache.ByObject{
+				&hcv2.HelmRelease{}: {Transform: controller.StripHelmRelease},
+			},
+		},
+		Client: client.Options{
+			Cache: &client.CacheOptions{
+				// the HelmReleases are watched stripped down to the status, the HelmCharts
+				// are watched metadata-only, both are read from the API server to not keep
+				// thousands of full objects in memory; the CustomResourceDefinitions are
+				// read by name on demand and never watched
+				DisableFor: []client.Object{&hcv2.HelmRelease{}, &sourcev1.HelmChart{}, &apiextensionsv1.CustomResourceDefinition{}},
+			},
+		},
+		// the controllers record the changes they make with the audit recorder carried by the context,
+		// retry the chart downloads with the configured backoff and read the applied objects uncached
+		BaseContext: func() context.Context {
+			ctx := helm.WithDownloadBackoff(audit.IntoContext(context.Background(), auditRecorder), downloadBackoff)
+			return utils.WithAPIReader(ctx, apiReader)
+		},
 		HealthProbeBindAddress: probeAddr,
-		LeaderElection:         true,
-		LeaderElectionID:       "31c555b4.hmc.mirantis.com",
+		LeaderElection:         leaderElect,
+		LeaderElectionID:       leaderElectionID,
+		LeaseDuration:          &leaseDuration,
+		RenewDeadline:          &renewDeadline,
+		RetryPeriod:            &retryPeriod,
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -156,6 +297,18 @@ func main() {
 		// LeaderElectionReleaseOnCancel: true,
 	}
 
+	if watchNamespaces != "" {
+		managerOpts.Cache.DefaultNamespaces = map[string]cache.Config{currentNamespace: {}}
+		for _, ns := range splitNamespaces(watchNamespaces) {
+			managerOpts.Cache.DefaultNamespaces[ns] = cache.Config{}
+		}
+	}
+
+	if shard != "" && enableWebhook {
+		setupLog.Info("admission webhooks are served by the default shard only, disabling", "shard", shard)
+		enableWebhook = false
+	}
+
 	if enableWebhook {
 		managerOpts.WebhookServer = webhook.NewServer(webhook.Options{
 			Port:    webhookPort,
@@ -164,12 +317,17 @@ func main() {
 		})
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), managerOpts)
+	mgr, err := ctrl.NewManager(restConfig, managerOpts)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
+	if err = mgr.Add(auditRecorder); err != nil {
+		setupLog.Error(err, "unable to create audit recorder")
+		os.Exit(1)
+	}
+
 	dc, err := dynamic.NewForConfig(mgr.GetConfig())
 	if err != nil {
 		setupLog.Error(err, "failed to create dynamic client")
@@ -182,124 +340,178 @@ func main() {
 		os.Exit(1)
 	}
 
-	currentNamespace := utils.CurrentNamespace()
-
-	templateReconciler := controller.TemplateReconciler{
-		Client:          mgr.GetClient(),
-		SystemNamespace: currentNamespace,
-		DefaultRegistryConfig: helm.DefaultRegistryConfig{
-			URL:               defaultRegistryURL,
-			RepoType:          determinedRepositoryType,
-			CredentialsSecret: registryCredentialsSecret,
-			Insecure:          insecureRegistry,
-		},
-	}
-
-	if err = (&controller.ClusterTemplateReconciler{
-		TemplateReconciler: templateReconciler,
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ClusterTemplate")
-		os.Exit(1)
-	}
-	if err = (&controller.ServiceTemplateReconciler{
-		TemplateReconciler: templateReconciler,
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ServiceTemplate")
-		os.Exit(1)
-	}
-	if err = (&controller.ProviderTemplateReconciler{
-		TemplateReconciler: templateReconciler,
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ProviderTemplate")
-		os.Exit(1)
-	}
-	if err = (&controller.ManagedClusterReconciler{
-		Client:          mgr.GetClient(),
-		Config:          mgr.GetConfig(),
-		DynamicClient:   dc,
-		SystemNamespace: currentNamespace,
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ManagedCluster")
-		os.Exit(1)
-	}
-	if err = (&controller.ManagementReconciler{
-		Client:                   mgr.GetClient(),
-		Scheme:                   mgr.GetScheme(),
-		Config:                   mgr.GetConfig(),
-		DynamicClient:            dc,
-		SystemNamespace:          currentNamespace,
-		CreateTemplateManagement: createTemplateManagement,
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "Management")
-		os.Exit(1)
-	}
-	if err = (&controller.TemplateManagementReconciler{
-		Client:          mgr.GetClient(),
-		Config:          mgr.GetConfig(),
-		SystemNamespace: currentNamespace,
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "TemplateManagement")
-		os.Exit(1)
+	controllers := strings.Split(enabledControllers, ",")
+	setupController := func(name string, r interface{ SetupWithManager(ctrl.Manager) error }) {
+		if !isControllerEnabled(name, controllers) {
+			setupLog.Info("controller is disabled", "controller", name)
+			return
+		}
+		if err := r.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", name)
+			os.Exit(1)
+		}
 	}
 
-	templateChainReconciler := controller.TemplateChainReconciler{
-		Client:          mgr.GetClient(),
-		SystemNamespace: currentNamespace,
-	}
-	if err = (&controller.ClusterTemplateChainReconciler{
-		TemplateChainReconciler: templateChainReconciler,
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ClusterTemplateChain")
-		os.Exit(1)
-	}
-	if err = (&controller.ServiceTemplateChainReconciler{
-		TemplateChainReconciler: templateChainReconciler,
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ServiceTemplateChain")
+	notifier := notifications.NewNotifier(mgr.GetClient(), currentNamespace)
+	if err = mgr.Add(notifier); err != nil {
+		setupLog.Error(err, "unable to create notifier")
 		os.Exit(1)
 	}
-
-	if err = (&controller.ReleaseReconciler{
+	setupController("ManagedCluster", &controller.ManagedClusterReconciler{
 		Client:                mgr.GetClient(),
 		Config:                mgr.GetConfig(),
-		CreateManagement:      createManagement,
-		CreateRelease:         createRelease,
-		CreateTemplates:       createTemplates,
-		HMCTemplatesChartName: hmcTemplatesChartName,
+		DynamicClient:         dc,
+		Recorder:              mgr.GetEventRecorderFor("managedcluster-controller"),
 		SystemNamespace:       currentNamespace,
-		DefaultRegistryConfig: helm.DefaultRegistryConfig{
-			URL:               defaultRegistryURL,
-			RepoType:          determinedRepositoryType,
-			CredentialsSecret: registryCredentialsSecret,
-			Insecure:          insecureRegistry,
-		},
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "Release")
-		os.Exit(1)
-	}
+		Shard:                 shard,
+		SecretWriter:          secretWriter,
+		Notifier:              notifier,
+		AutoscalerClusterRole: autoscalerClusterRole,
+		AutoscalerNamespaces:  splitNamespaces(autoscalerNamespaces),
+		GitOpsNamespaces:      splitNamespaces(gitOpsNamespaces),
+	})
+	setupController("ManagedClusterStatus", &controller.ManagedClusterStatusReconciler{
+		Client:                  mgr.GetClient(),
+		DynamicClient:           dc,
+		MaxConcurrentReconciles: statusSyncConcurrency,
+		Shard:                   shard,
+		Notifier:                notifier,
+	})
+	setupController("ClusterQuota", &controller.ClusterQuotaReconciler{
+		Client:   mgr.GetClient(),
+		Recorder: mgr.GetEventRecorderFor("clusterquota-controller"),
+		Shard:    shard,
+	})
+	setupController("UpgradeGroup", &controller.UpgradeGroupReconciler{
+		Client:   mgr.GetClient(),
+		Recorder: mgr.GetEventRecorderFor("upgradegroup-controller"),
+		Shard:    shard,
+	})
+	setupController("TemplateTest", &controller.TemplateTestReconciler{
+		Client:   mgr.GetClient(),
+		Recorder: mgr.GetEventRecorderFor("templatetest-controller"),
+		Shard:    shard,
+	})
+	setupController("Credential", &controller.CredentialReconciler{
+		Client:            mgr.GetClient(),
+		Shard:             shard,
+		SystemNamespace:   currentNamespace,
+		IsolateIdentities: isolateIdentities,
+	})
+
+	if shard == "" {
+		templateReconciler := controller.TemplateReconciler{
+			Client:          mgr.GetClient(),
+			Recorder:        mgr.GetEventRecorderFor("template-controller"),
+			SystemNamespace: currentNamespace,
+			DefaultRegistryConfig: helm.DefaultRegistryConfig{
+				URL:               defaultRegistryURL,
+				RepoType:          determinedRepositoryType,
+				CredentialsSecret: registryCredentialsSecret,
+				Insecure:          insecureRegistry,
+			},
+		}
+
+		setupController("ClusterTemplate", &controller.ClusterTemplateReconciler{
+			TemplateReconciler: templateReconciler,
+		})
+		setupController("ServiceTemplate", &controller.ServiceTemplateReconciler{
+			TemplateReconciler: templateReconciler,
+		})
+		setupController("ProviderTemplate", &controller.ProviderTemplateReconciler{
+			TemplateReconciler: templateReconciler,
+		})
+		setupController("Management", &controller.ManagementReconciler{
+			Client:                   mgr.GetClient(),
+			Scheme:                   mgr.GetScheme(),
+			Config:                   mgr.GetConfig(),
+			DynamicClient:            dc,
+			SystemNamespace:          currentNamespace,
+			CreateTemplateManagement: createTemplateManagement,
+		})
+		setupController("TemplateManagement", &controller.TemplateManagementReconciler{
+			Client:          mgr.GetClient(),
+			Config:          mgr.GetConfig(),
+			SystemNamespace: currentNamespace,
+		})
+		setupController("TemplatesSource", &controller.TemplatesSourceReconciler{
+			Client:          mgr.GetClient(),
+			SystemNamespace: currentNamespace,
+		})
+
+		discoveryClient, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+		if err != nil {
+			setupLog.Error(err, "failed to create discovery client")
+			os.Exit(1)
+		}
+		setupController("ManagementBackup", &controller.ManagementBackupReconciler{
+			Client:          mgr.GetClient(),
+			DiscoveryClient: discoveryClient,
+			VeleroNamespace: veleroNamespace,
+		})
+		setupController("ManagementRestore", &controller.ManagementRestoreReconciler{
+			Client:          mgr.GetClient(),
+			DiscoveryClient: discoveryClient,
+			VeleroNamespace: veleroNamespace,
+		})
 
-	if enableTelemetry {
-		if err = mgr.Add(&telemetry.Tracker{
+		templateChainReconciler := controller.TemplateChainReconciler{
 			Client:          mgr.GetClient(),
 			SystemNamespace: currentNamespace,
-		}); err != nil {
-			setupLog.Error(err, "unable to create telemetry tracker")
+		}
+		setupController("ClusterTemplateChain", &controller.ClusterTemplateChainReconciler{
+			TemplateChainReconciler: templateChainReconciler,
+		})
+		setupController("ServiceTemplateChain", &controller.ServiceTemplateChainReconciler{
+			TemplateChainReconciler: templateChainReconciler,
+		})
+		setupController("ProviderTemplateChain", &controller.ProviderTemplateChainReconciler{
+			TemplateChainReconciler: templateChainReconciler,
+		})
+
+		setupController("Release", &controller.ReleaseReconciler{
+			Client:                mgr.GetClient(),
+			Config:                mgr.GetConfig(),
+			CreateManagement:      createManagement,
+			CreateRelease:         createRelease,
+			CreateTemplates:       createTemplates,
+			HMCTemplatesChartName: hmcTemplatesChartName,
+			SystemNamespace:       currentNamespace,
+			DefaultRegistryConfig: helm.DefaultRegistryConfig{
+				URL:               defaultRegistryURL,
+				RepoType:          determinedRepositoryType,
+				CredentialsSecret: registryCredentialsSecret,
+				Insecure:          insecureRegistry,
+			},
+		})
+
+		telemetry.RegisterSink(hmcmirantiscomv1alpha1.TelemetrySinkConfigMap, &telemetry.ConfigMapSink{
+			Client:    mgr.GetClient(),
+			Namespace: currentNamespace,
+		})
+
+		if err = mgr.Add(&telemetry.Worker{}); err != nil {
+			setupLog.Error(err, "unable to create telemetry worker")
 			os.Exit(1)
 		}
-	}
 
-	if err = (&controller.CredentialReconciler{
-		Client: mgr.GetClient(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "Credential")
-		os.Exit(1)
-	}
+		if enableTelemetry {
+			if err = mgr.Add(&telemetry.Tracker{
+				Client:          mgr.GetClient(),
+				SystemNamespace: currentNamespace,
+			}); err != nil {
+				setupLog.Error(err, "unable to create telemetry tracker")
+				os.Exit(1)
+			}
+		}
 
-	if err = (&controller.MultiClusterServiceReconciler{
-		Client: mgr.GetClient(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "MultiClusterService")
-		os.Exit(1)
+		setupController("MultiClusterService", &controller.MultiClusterServiceReconciler{
+			Client: mgr.GetClient(),
+		})
+		setupController("NamespacedMultiClusterService", &controller.NamespacedMultiClusterServiceReconciler{
+			Client: mgr.GetClient(),
+			Shard:  shard,
+		})
 	}
 	// +kubebuilder:scaffold:builder
 
@@ -326,11 +538,37 @@ func main() {
 	}
 }
 
+// isControllerEnabled returns true if the controller with the given name is enabled
+// by the list of the controllers: "*" enables all of the controllers not disabled
+// explicitly, "Foo" enables the controller Foo and "-Foo" disables it.
+func isControllerEnabled(name string, controllers []string) bool {
+	enabledByDefault := false
+	for _, c := range controllers {
+		switch c = strings.TrimSpace(c); {
+		case strings.EqualFold(c, name):
+			return true
+		case strings.EqualFold(c, "-"+name):
+			return false
+		case c == "*":
+			enabledByDefault = true
+		}
+	}
+	return enabledByDefault
+}
+
 func setupWebhooks(mgr ctrl.Manager, currentNamespace string) error {
 	if err := (&hmcwebhook.ManagedClusterValidator{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "ManagedCluster")
 		return err
 	}
+	if err := (&hmcwebhook.MultiClusterServiceValidator{SystemNamespace: currentNamespace}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "MultiClusterService")
+		return err
+	}
+	if err := (&hmcwebhook.NamespacedMultiClusterServiceValidator{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "NamespacedMultiClusterService")
+		return err
+	}
 	if err := (&hmcwebhook.ManagementValidator{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "Management")
 		return err
@@ -347,6 +585,10 @@ func setupWebhooks(mgr ctrl.Manager, currentNamespace string) error {
 		setupLog.Error(err, "unable to create webhook", "webhook", "ServiceTemplateChain")
 		return err
 	}
+	if err := (&hmcwebhook.ProviderTemplateChainValidator{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "ProviderTemplateChain")
+		return err
+	}
 	if err := (&hmcwebhook.ClusterTemplateValidator{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "ClusterTemplate")
 		return err
@@ -359,5 +601,67 @@ func setupWebhooks(mgr ctrl.Manager, currentNamespace string) error {
 		setupLog.Error(err, "unable to create webhook", "webhook", "ProviderTemplate")
 		return err
 	}
+	if err := (&hmcwebhook.NamespaceValidator{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "Namespace")
+		return err
+	}
+	if err := (&hmcwebhook.UpgradeGroupValidator{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "UpgradeGroup")
+		return err
+	}
 	return nil
 }
+
+// newSecretWriter returns the writer of the propagated Secrets for the given secret store.
+func newSecretWriter(store, vaultAddress, vaultMount, vaultKeyPrefix, clusterSecretStore string) (credspropagation.SecretWriter, error) {
+	switch store {
+	case "kubernetes":
+		return credspropagation.ApplySecretWriter{}, nil
+	case "vault":
+		if vaultAddress == "" || clusterSecretStore == "" {
+			return nil, errors.New("the vault-address and the cluster-secret-store are required for the vault secret store")
+		}
+		return &credspropagation.ExternalSecretWriter{
+			Store: &credspropagation.VaultStore{
+				Address: vaultAddress,
+				Mount:   vaultMount,
+				Token:   os.Getenv("VAULT_TOKEN"),
+			},
+			ClusterSecretStore: clusterSecretStore,
+			KeyPrefix:          vaultKeyPrefix,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported secret store %q", store)
+	}
+}
+
+// newAuditRecorder returns the recorder of the changes made by HMC writing
+// the entries to the log and to the configured ConfigMap and endpoint.
+func newAuditRecorder(configMap, namespace, webhookURL string) (*audit.Recorder, error) {
+	sinks := []audit.Sink{audit.LogSink{}}
+
+	if configMap != "" {
+		// the ConfigMap is written with the uncached client to not watch all of the ConfigMaps
+		cl, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the client of the audit ConfigMap: %w", err)
+		}
+		sinks = append(sinks, &audit.ConfigMapSink{Client: cl, Namespace: namespace, Name: configMap})
+	}
+	if webhookURL != "" {
+		sinks = append(sinks, &audit.WebhookSink{URL: webhookURL})
+	}
+
+	return audit.NewRecorder(sinks...), nil
+}
+
+// splitNamespaces returns the namespaces of the comma-separated list.
+func splitNamespaces(list string) []string {
+	var namespaces []string
+	for _, ns := range strings.Split(list, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces
+}
@@ -0,0 +1,46 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_isControllerEnabled(t *testing.T) {
+	tests := []struct {
+		controllers string
+		name        string
+		enabled     bool
+	}{
+		{"*", "ManagedCluster", true},
+		{"", "ManagedCluster", false},
+		{"ManagedCluster", "ManagedCluster", true},
+		{"ManagedCluster", "Credential", false},
+		{"managedcluster", "ManagedCluster", true},
+		{"*,-ManagedClusterStatus", "ManagedClusterStatus", false},
+		{"*,-ManagedClusterStatus", "ManagedCluster", true},
+		{"-ManagedClusterStatus,*", "ManagedClusterStatus", false},
+		{"* , -Credential", "Credential", false},
+		{"-Credential", "ManagedCluster", false},
+		{"ManagedCluster,-ManagedCluster", "ManagedCluster", true},
+	}
+
+	for _, test := range tests {
+		if enabled := isControllerEnabled(test.name, strings.Split(test.controllers, ",")); enabled != test.enabled {
+			t.Errorf("isControllerEnabled(%q, %q) = %v, want %v", test.name, test.controllers, enabled, test.enabled)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+#!/bin/sh
+# Copyright 2024
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+set -eu
+
+# Adds the conversion webhook to the CustomResourceDefinitions of the HMC
+# objects served in multiple versions. The webhook is configured only if
+# the admission webhook of HMC is enabled, the versions other than the storage
+# one are not served without it, since they are not converted.
+
+CRDS_DIR=${CRDS_DIR:-templates/provider/hmc/templates/crds}
+
+for crd in "$CRDS_DIR"/*.yaml; do
+    [ "$(grep -c '^    served: true' "$crd")" -gt 1 ] || continue
+    grep -q '^  conversion:' "$crd" && continue
+
+    awk '
+    served != "" {
+        if ($0 == "    storage: false") {
+            print "    served: {{ .Values.admissionWebhook.enabled }}"
+        } else {
+            print served
+        }
+        served = ""
+    }
+    /^    served: true$/ { served = $0; next }
+    { print }
+    /^    controller-gen.kubebuilder.io\/version:/ {
+        print "    {{- if .Values.admissionWebhook.enabled }}"
+        print "    cert-manager.io/inject-ca-from: {{ include \"hmc.webhook.certNamespace\" . }}/{{ include \"hmc.webhook.certName\" . }}"
+        print "    {{- end }}"
+    }
+    /^spec:$/ {
+        print "  {{- if .Values.admissionWebhook.enabled }}"
+        print "  conversion:"
+        print "    strategy: Webhook"
+        print "    webhook:"
+        print "      clientConfig:"
+        print "        service:"
+        print "          name: {{ include \"hmc.webhook.serviceName\" . }}"
+        print "          namespace: {{ include \"hmc.webhook.serviceNamespace\" . }}"
+        print "          path: /convert"
+        print "      conversionReviewVersions:"
+        print "      - v1"
+        print "  {{- end }}"
+    }
+    ' "$crd" > "$crd.tmp"
+    mv "$crd.tmp" "$crd"
+done
@@ -0,0 +1,228 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit records the changes HMC makes to the objects it manages.
+// The entries are recorded with the Recorder carried by the context, the
+// controller and the ManagedCluster the change is made for are taken from
+// the context as well.
+package audit
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Action is the kind of the change made by HMC.
+type Action string
+
+const (
+	// ActionCreate is recorded when an object is created.
+	ActionCreate Action = "Create"
+	// ActionUpdate is recorded when an object is updated.
+	ActionUpdate Action = "Update"
+	// ActionRemoveFinalizer is recorded when a finalizer is removed from an object.
+	ActionRemoveFinalizer Action = "RemoveFinalizer"
+	// ActionPropagate is recorded when an object is propagated to a managed cluster.
+	ActionPropagate Action = "Propagate"
+)
+
+// Entry is a recorded change.
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Actor is the controller made the change.
+	Actor string `json:"actor,omitempty"`
+	// Cluster is the namespaced name of the ManagedCluster the change is made for.
+	Cluster   string `json:"cluster,omitempty"`
+	Action    Action `json:"action"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// Diff is the summary of the changed fields.
+	Diff string `json:"diff,omitempty"`
+}
+
+// Sink stores the recorded entries.
+type Sink interface {
+	// Write stores the batch of the entries in the order they were recorded.
+	Write(ctx context.Context, entries []Entry) error
+}
+
+const (
+	defaultQueueSize    = 1000
+	defaultBatchSize    = 100
+	defaultFlushTimeout = 10 * time.Second
+)
+
+// Recorder writes the entries to the sinks asynchronously, so the changes
+// are not delayed by the latency and the failures of the sinks. The entries
+// are buffered in a bounded queue and written in batches by the running
+// Recorder, the entries not fitting into the queue are dropped.
+type Recorder struct {
+	sinks   []Sink
+	queue   chan Entry
+	dropped atomic.Int64
+
+	// BatchSize is the maximum number of the entries written at once.
+	BatchSize int
+	// FlushTimeout is the maximum time a batch is written to a single sink for.
+	FlushTimeout time.Duration
+}
+
+// NewRecorder returns the Recorder writing the entries to the given sinks.
+func NewRecorder(sinks ...Sink) *Recorder {
+	return &Recorder{
+		sinks:        sinks,
+		queue:        make(chan Entry, defaultQueueSize),
+		BatchSize:    defaultBatchSize,
+		FlushTimeout: defaultFlushTimeout,
+	}
+}
+
+// Record enqueues the entry to be written to all of the sinks, the entry is
+// dropped if the queue is full. The audit never blocks the changes.
+func (r *Recorder) Record(ctx context.Context, entry Entry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	select {
+	case r.queue <- entry:
+	default:
+		r.dropped.Add(1)
+		ctrl.LoggerFrom(ctx).Info("Dropping the audit entry, the queue is full", "action", entry.Action, "kind", entry.Kind, "name", entry.Name)
+	}
+}
+
+// Dropped returns the number of the entries dropped because the queue was full.
+func (r *Recorder) Dropped() int64 {
+	return r.dropped.Load()
+}
+
+// NeedLeaderElection implements the LeaderElectionRunnable interface, the
+// Recorder writes the entries of any replica.
+func (*Recorder) NeedLeaderElection() bool {
+	return false
+}
+
+// Start writes the queued entries until the context is done, the remaining
+// entries are flushed on exit.
+func (r *Recorder) Start(ctx context.Context) error {
+	batchSize := max(r.BatchSize, 1)
+	batch := make([]Entry, 0, batchSize)
+	for {
+		select {
+		case entry := <-r.queue:
+			batch = append(batch, entry)
+		case <-ctx.Done():
+			for {
+				select {
+				case entry := <-r.queue:
+					batch = append(batch, entry)
+				default:
+					r.flush(context.WithoutCancel(ctx), batch)
+					return nil
+				}
+			}
+		}
+
+		// collect whatever is queued already into the same batch
+	collect:
+		for len(batch) < batchSize {
+			select {
+			case entry := <-r.queue:
+				batch = append(batch, entry)
+			default:
+				break collect
+			}
+		}
+
+		r.flush(ctx, batch)
+		batch = batch[:0]
+	}
+}
+
+func (r *Recorder) flush(ctx context.Context, batch []Entry) {
+	if len(batch) == 0 {
+		return
+	}
+
+	timeout := r.FlushTimeout
+	if timeout <= 0 {
+		timeout = defaultFlushTimeout
+	}
+	for _, sink := range r.sinks {
+		sinkCtx, cancel := context.WithTimeout(ctx, timeout)
+		if err := sink.Write(sinkCtx, batch); err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, "failed to write the audit entries", "count", len(batch))
+		}
+		cancel()
+	}
+}
+
+type (
+	recorderKey struct{}
+	actorKey    struct{}
+	clusterKey  struct{}
+)
+
+// IntoContext returns the context carrying the Recorder.
+func IntoContext(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// WithActor returns the context recording the entries for the given controller.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// WithCluster returns the context recording the entries for the given ManagedCluster.
+func WithCluster(ctx context.Context, cluster client.ObjectKey) context.Context {
+	return context.WithValue(ctx, clusterKey{}, cluster.String())
+}
+
+// FinalizerRemoved returns the summary of the removal of the finalizer.
+func FinalizerRemoved(finalizer string) string {
+	return "metadata.finalizers: removed " + finalizer
+}
+
+// Record records the change of the object with the Recorder carried by the
+// context, nothing is recorded if the context carries no Recorder.
+func Record(ctx context.Context, action Action, obj client.Object, diff string) {
+	r, ok := ctx.Value(recorderKey{}).(*Recorder)
+	if !ok || r == nil {
+		return
+	}
+
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if kind == "" {
+		// the typed objects fetched with the client have no type meta set
+		kind = reflect.Indirect(reflect.ValueOf(obj)).Type().Name()
+	}
+
+	actor, _ := ctx.Value(actorKey{}).(string)
+	cluster, _ := ctx.Value(clusterKey{}).(string)
+	r.Record(ctx, Entry{
+		Actor:     actor,
+		Cluster:   cluster,
+		Action:    action,
+		Kind:      kind,
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		Diff:      diff,
+	})
+}
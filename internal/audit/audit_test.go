@@ -0,0 +1,189 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type memorySink struct {
+	mu      sync.Mutex
+	entries []Entry
+	batches int
+}
+
+func (s *memorySink) Write(_ context.Context, entries []Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entries...)
+	s.batches++
+	return nil
+}
+
+func (s *memorySink) recorded() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.entries)
+}
+
+// blockingSink blocks the writes until the context is done.
+type blockingSink struct{}
+
+func (blockingSink) Write(ctx context.Context, _ []Entry) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestRecord(t *testing.T) {
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "cloud-config"}}
+
+	// nothing is recorded without the recorder in the context
+	Record(context.Background(), ActionPropagate, secret, "")
+
+	sink := &memorySink{}
+	recorder := NewRecorder(sink)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- recorder.Start(ctx) }()
+
+	recordCtx := IntoContext(context.Background(), recorder)
+	recordCtx = WithCluster(WithActor(recordCtx, "managedcluster"), types.NamespacedName{Namespace: "default", Name: "dev"})
+	Record(recordCtx, ActionPropagate, secret, "")
+
+	require.Eventually(t, func() bool { return len(sink.recorded()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+
+	entry := sink.recorded()[0]
+	require.False(t, entry.Time.IsZero())
+	entry.Time = time.Time{}
+	require.Equal(t, Entry{
+		Actor:     "managedcluster",
+		Cluster:   "default/dev",
+		Action:    ActionPropagate,
+		Kind:      "Secret",
+		Namespace: "kube-system",
+		Name:      "cloud-config",
+	}, entry)
+}
+
+func TestRecorderQueue(t *testing.T) {
+	sink := &memorySink{}
+	recorder := NewRecorder(sink)
+
+	// the entries are queued until the recorder is started, the overflow is dropped
+	for range defaultQueueSize + 5 {
+		recorder.Record(context.Background(), Entry{Action: ActionUpdate, Kind: "HelmRelease", Name: "test"})
+	}
+	require.EqualValues(t, 5, recorder.Dropped())
+
+	// the queued entries are flushed on stop
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, recorder.Start(ctx))
+	require.Len(t, sink.recorded(), defaultQueueSize)
+}
+
+func TestRecorderFlushTimeout(t *testing.T) {
+	sink := &memorySink{}
+	recorder := NewRecorder(blockingSink{}, sink)
+	recorder.FlushTimeout = 10 * time.Millisecond
+	recorder.Record(context.Background(), Entry{Action: ActionUpdate, Kind: "HelmRelease", Name: "test"})
+
+	// the stuck sink times out and does not hold the others
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, recorder.Start(ctx))
+	require.Len(t, sink.recorded(), 1)
+}
+
+func TestDiff(t *testing.T) {
+	oldCM := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", ResourceVersion: "1", Labels: map[string]string{"a": "1"}},
+		Data:       map[string]string{"a": "1", "b": "2"},
+	}
+	newCM := oldCM.DeepCopy()
+	newCM.ResourceVersion = "2"
+	newCM.Labels["a"] = "2"
+	newCM.Data["b"] = "3"
+	newCM.Data["c"] = "4"
+
+	diff, err := Diff(oldCM, newCM)
+	require.NoError(t, err)
+	require.Equal(t, "data.b, data.c, metadata.labels", diff)
+}
+
+func TestConfigMapSink(t *testing.T) {
+	cl := fake.NewClientBuilder().Build()
+	sink := &ConfigMapSink{Client: cl, Namespace: "hmc-system", Name: "hmc-audit", MaxEntries: 2}
+
+	ctx := context.Background()
+	require.NoError(t, sink.Write(ctx, []Entry{{Action: ActionUpdate, Kind: "HelmRelease", Name: "a"}}))
+	require.NoError(t, sink.Write(ctx, []Entry{
+		{Action: ActionUpdate, Kind: "HelmRelease", Name: "b"},
+		{Action: ActionUpdate, Kind: "HelmRelease", Name: "c"},
+	}))
+
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, cl.Get(ctx, client.ObjectKey{Namespace: "hmc-system", Name: "hmc-audit"}, cm))
+	lines := strings.Split(cm.Data[ConfigMapKey], "\n")
+	require.Len(t, lines, 2)
+
+	names := make([]string, 0, len(lines))
+	for _, line := range lines {
+		entry := Entry{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		names = append(names, entry.Name)
+	}
+	require.Equal(t, []string{"b", "c"}, names)
+}
+
+func TestWebhookSink(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry := Entry{}
+		if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received = append(received, entry.Name)
+	}))
+	defer server.Close()
+
+	sink := &WebhookSink{URL: server.URL}
+	require.NoError(t, sink.Write(context.Background(), []Entry{
+		{Action: ActionRemoveFinalizer, Kind: "ManagedCluster", Name: "dev"},
+		{Action: ActionRemoveFinalizer, Kind: "ManagedCluster", Name: "prod"},
+	}))
+	require.Equal(t, []string{"dev", "prod"}, received)
+
+	server.Config.Handler = http.NotFoundHandler()
+	require.Error(t, sink.Write(context.Background(), []Entry{{}}))
+}
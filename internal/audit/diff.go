@@ -0,0 +1,87 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// diffDepth is the depth of the fields listed in the summary of the changes.
+const diffDepth = 2
+
+// ignoredFields are not listed in the summary of the changes.
+var ignoredFields = map[string]bool{
+	"status":                     true,
+	"metadata.resourceVersion":   true,
+	"metadata.generation":        true,
+	"metadata.managedFields":     true,
+	"metadata.creationTimestamp": true,
+	"metadata.uid":               true,
+}
+
+// Diff returns the comma-separated list of the fields changed between the objects.
+func Diff(oldObj, newObj runtime.Object) (string, error) {
+	oldMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(oldObj)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert the object: %w", err)
+	}
+	newMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(newObj)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert the object: %w", err)
+	}
+
+	var changed []string
+	diffFields("", oldMap, newMap, 1, &changed)
+	sort.Strings(changed)
+	return strings.Join(changed, ", "), nil
+}
+
+func diffFields(prefix string, oldMap, newMap map[string]any, depth int, changed *[]string) {
+	keys := make(map[string]struct{}, len(oldMap)+len(newMap))
+	for k := range oldMap {
+		keys[k] = struct{}{}
+	}
+	for k := range newMap {
+		keys[k] = struct{}{}
+	}
+
+	for k := range keys {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		if ignoredFields[path] {
+			continue
+		}
+
+		oldValue, newValue := oldMap[k], newMap[k]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+
+		oldNested, oldOK := oldValue.(map[string]any)
+		newNested, newOK := newValue.(map[string]any)
+		if depth < diffDepth && oldOK && newOK {
+			diffFields(path, oldNested, newNested, depth+1, changed)
+			continue
+		}
+		*changed = append(*changed, path)
+	}
+}
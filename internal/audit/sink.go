@@ -0,0 +1,173 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// LogSink writes the entries to the structured log of the context.
+type LogSink struct{}
+
+func (LogSink) Write(ctx context.Context, entries []Entry) error {
+	l := ctrl.LoggerFrom(ctx).WithName("audit")
+	for _, entry := range entries {
+		l.Info("Audit",
+			"time", entry.Time,
+			"actor", entry.Actor,
+			"cluster", entry.Cluster,
+			"action", entry.Action,
+			"kind", entry.Kind,
+			"namespace", entry.Namespace,
+			"name", entry.Name,
+			"diff", entry.Diff,
+		)
+	}
+	return nil
+}
+
+const (
+	// ConfigMapKey is the key of the ConfigMap the entries are stored under as JSON lines.
+	ConfigMapKey = "audit.log"
+	// DefaultMaxEntries is the default number of the entries kept in the ConfigMap.
+	DefaultMaxEntries = 1000
+)
+
+// ConfigMapSink keeps the latest entries in the ConfigMap.
+type ConfigMapSink struct {
+	Client    client.Client
+	Namespace string
+	Name      string
+	// MaxEntries is the number of the entries kept, defaults to DefaultMaxEntries.
+	MaxEntries int
+}
+
+// Write appends the batch to the ConfigMap with a single update.
+func (s *ConfigMapSink) Write(ctx context.Context, entries []Entry) error {
+	newLines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal the audit entry: %w", err)
+		}
+		newLines = append(newLines, string(line))
+	}
+	if len(newLines) == 0 {
+		return nil
+	}
+
+	maxEntries := s.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm := &corev1.ConfigMap{}
+		err := s.Client.Get(ctx, client.ObjectKey{Namespace: s.Namespace, Name: s.Name}, cm)
+		if apierrors.IsNotFound(err) {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: s.Namespace, Name: s.Name},
+				Data:       map[string]string{ConfigMapKey: strings.Join(tail(newLines, maxEntries), "\n")},
+			}
+			return s.Client.Create(ctx, cm)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get the audit ConfigMap %s/%s: %w", s.Namespace, s.Name, err)
+		}
+
+		var lines []string
+		if existing := cm.Data[ConfigMapKey]; existing != "" {
+			lines = strings.Split(existing, "\n")
+		}
+		lines = tail(append(lines, newLines...), maxEntries)
+
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		cm.Data[ConfigMapKey] = strings.Join(lines, "\n")
+		return s.Client.Update(ctx, cm)
+	})
+}
+
+// tail returns the last n lines.
+func tail(lines []string, n int) []string {
+	if len(lines) > n {
+		return lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// defaultWebhookTimeout is the timeout of the posts of the WebhookSink without the HTTP client set.
+const defaultWebhookTimeout = 10 * time.Second
+
+var defaultWebhookClient = &http.Client{Timeout: defaultWebhookTimeout}
+
+// WebhookSink posts each of the entries as JSON to the external endpoint.
+type WebhookSink struct {
+	// HTTPClient posts the entries, defaults to the client with the 10s timeout.
+	HTTPClient *http.Client
+	URL        string
+}
+
+// Write posts the entries of the batch one by one, stopping at the first failure.
+func (s *WebhookSink) Write(ctx context.Context, entries []Entry) error {
+	for _, entry := range entries {
+		if err := s.post(ctx, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *WebhookSink) post(ctx context.Context, entry Entry) error {
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the audit entry: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	httpClient := s.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultWebhookClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post the audit entry: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("failed to post the audit entry: unexpected status %s", resp.Status)
+	}
+	return nil
+}
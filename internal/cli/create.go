@@ -0,0 +1,232 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+var (
+	// pollInterval is the interval the ManagedCluster is polled at while waiting for it.
+	pollInterval = 5 * time.Second
+	// failedGracePeriod is the time the Failed phase must persist for before the wait fails,
+	// the retries and the remediation of the HelmRelease may still recover the deployment.
+	failedGracePeriod = 2 * time.Minute
+)
+
+type createOptions struct {
+	*Options
+
+	template    string
+	credential  string
+	configFile  string
+	set         []string
+	interactive bool
+	wait        bool
+	timeout     time.Duration
+}
+
+func newCreateCommand(o *Options) *cobra.Command {
+	co := &createOptions{Options: o}
+
+	cmd := &cobra.Command{
+		Use:   "create NAME [--template TEMPLATE] [--credential CREDENTIAL]",
+		Short: "Create a ManagedCluster from a ClusterTemplate",
+		Long: "Create a ManagedCluster from a ClusterTemplate. The ClusterTemplate and the Credential default\n" +
+			"to the ones annotated with \"hmc.mirantis.com/namespace-default: true\" in the namespace.",
+		Args: exactlyOneArg("NAME"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return co.run(cmd.Context(), args[0])
+		},
+	}
+	cmd.Flags().StringVarP(&co.template, "template", "t", "", "Name of the ClusterTemplate, defaults to the default of the namespace.")
+	cmd.Flags().StringVarP(&co.credential, "credential", "c", "", "Name of the Credential, defaults to the default of the namespace.")
+	cmd.Flags().StringVarP(&co.configFile, "config", "f", "", "Path to a YAML file with the cluster configuration.")
+	cmd.Flags().StringArrayVar(&co.set, "set", nil, "Set a configuration value, e.g. --set workersNumber=3.")
+	cmd.Flags().BoolVarP(&co.interactive, "interactive", "i", false, "Prompt for the configuration values of the template.")
+	cmd.Flags().BoolVarP(&co.wait, "wait", "w", false, "Wait for the cluster to be provisioned.")
+	cmd.Flags().DurationVar(&co.timeout, "timeout", time.Hour, "Time to wait for the cluster to be provisioned.")
+	return cmd
+}
+
+func (co *createOptions) run(ctx context.Context, name string) error {
+	cl, err := co.Client()
+	if err != nil {
+		return err
+	}
+
+	if co.template == "" {
+		if co.template, err = namespaceDefaultTemplate(ctx, cl, co.Namespace); err != nil {
+			return err
+		}
+	}
+
+	template := &hmc.ClusterTemplate{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: co.Namespace, Name: co.template}, template); err != nil {
+		return fmt.Errorf("failed to get ClusterTemplate %s/%s: %w", co.Namespace, co.template, err)
+	}
+	if !template.Status.Valid {
+		return fmt.Errorf("ClusterTemplate %s/%s is not valid: %s", co.Namespace, co.template, template.Status.ValidationError)
+	}
+
+	values, err := readValues(co.configFile, co.set)
+	if err != nil {
+		return err
+	}
+	if co.interactive {
+		if err := promptValues(co.In, co.Out, template.Status.Config, template.Status.Parameters, values); err != nil {
+			return err
+		}
+	}
+
+	cluster := &hmc.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: co.Namespace,
+		},
+		Spec: hmc.ManagedClusterSpec{
+			Template:   co.template,
+			Credential: co.credential,
+		},
+	}
+	if len(values) > 0 {
+		raw, err := json.Marshal(values)
+		if err != nil {
+			return fmt.Errorf("failed to marshal the cluster configuration: %w", err)
+		}
+		cluster.Spec.Config = &apiextensionsv1.JSON{Raw: raw}
+	}
+
+	if err := cl.Create(ctx, cluster); err != nil {
+		return fmt.Errorf("failed to create ManagedCluster %s/%s: %w", co.Namespace, name, err)
+	}
+	fmt.Fprintf(co.Out, "ManagedCluster %s/%s created\n", co.Namespace, name)
+
+	if !co.wait {
+		return nil
+	}
+	return watchCluster(ctx, cl, co.Out, client.ObjectKeyFromObject(cluster), co.timeout)
+}
+
+// namespaceDefaultTemplate returns the name of the ClusterTemplate annotated as the default of the namespace.
+func namespaceDefaultTemplate(ctx context.Context, cl client.Client, namespace string) (string, error) {
+	templates := &hmc.ClusterTemplateList{}
+	if err := cl.List(ctx, templates, client.InNamespace(namespace)); err != nil {
+		return "", fmt.Errorf("failed to list ClusterTemplates in %s: %w", namespace, err)
+	}
+
+	var names []string
+	for _, template := range templates.Items {
+		if hmc.IsNamespaceDefault(&template) {
+			names = append(names, template.Name)
+		}
+	}
+	switch len(names) {
+	case 0:
+		return "", fmt.Errorf("no ClusterTemplate is annotated as the default of the namespace %s, set --template", namespace)
+	case 1:
+		return names[0], nil
+	default:
+		sort.Strings(names)
+		return "", fmt.Errorf("several ClusterTemplates are annotated as the default of the namespace %s: %s, set --template", namespace, strings.Join(names, ", "))
+	}
+}
+
+func newWatchCommand(o *Options) *cobra.Command {
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "watch NAME",
+		Short: "Watch the provisioning progress of a ManagedCluster",
+		Args:  exactlyOneArg("NAME"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl, err := o.Client()
+			if err != nil {
+				return err
+			}
+			return watchCluster(cmd.Context(), cl, o.Out, client.ObjectKey{Namespace: o.Namespace, Name: args[0]}, timeout)
+		},
+	}
+	cmd.Flags().DurationVar(&timeout, "timeout", time.Hour, "Time to wait for the cluster to be provisioned.")
+	return cmd
+}
+
+// watchCluster prints the changes of the phase and of the conditions of the ManagedCluster
+// until it is either ready or failed for longer than the failedGracePeriod.
+func watchCluster(ctx context.Context, cl client.Client, out io.Writer, key client.ObjectKey, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var (
+		phase       hmc.ManagedClusterPhase
+		failedSince time.Time
+	)
+	seen := map[string]string{}
+
+	err := wait.PollUntilContextCancel(ctx, pollInterval, true, func(ctx context.Context) (bool, error) {
+		cluster := &hmc.ManagedCluster{}
+		if err := cl.Get(ctx, key, cluster); err != nil {
+			return false, fmt.Errorf("failed to get ManagedCluster %s: %w", key, err)
+		}
+
+		for _, c := range cluster.Status.Conditions {
+			state := string(c.Status) + "/" + c.Reason + "/" + c.Message
+			if seen[c.Type] == state {
+				continue
+			}
+			seen[c.Type] = state
+			fmt.Fprintf(out, "%s\t%s=%s\t%s\n", c.LastTransitionTime.Format(time.RFC3339), c.Type, c.Status, c.Message)
+		}
+
+		if cluster.Status.Phase != phase {
+			phase = cluster.Status.Phase
+			fmt.Fprintf(out, "ManagedCluster %s is %s\n", key, phase)
+			if phase == hmc.ManagedClusterPhaseFailed {
+				failedSince = time.Now()
+			}
+		}
+
+		switch phase {
+		case hmc.ManagedClusterPhaseReady:
+			return true, nil
+		case hmc.ManagedClusterPhaseFailed:
+			if time.Since(failedSince) >= failedGracePeriod {
+				return false, fmt.Errorf("ManagedCluster %s failed", key)
+			}
+			return false, nil
+		default:
+			return false, nil
+		}
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("timed out waiting for ManagedCluster %s to be ready", key)
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+func TestWatchCluster(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, hmc.AddToScheme(testScheme))
+
+	interval, grace := pollInterval, failedGracePeriod
+	pollInterval, failedGracePeriod = 10*time.Millisecond, 100*time.Millisecond
+	t.Cleanup(func() { pollInterval, failedGracePeriod = interval, grace })
+
+	// watch returns the result of the wait for the cluster going through the given phases,
+	// the last phase is kept once all of them are observed.
+	watch := func(phases ...hmc.ManagedClusterPhase) (string, error) {
+		mc := &hmc.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "dev"}}
+		polls := 0
+		cl := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(mc).WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, cl client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if err := cl.Get(ctx, key, obj, opts...); err != nil {
+					return err
+				}
+				obj.(*hmc.ManagedCluster).Status.Phase = phases[min(polls, len(phases)-1)]
+				polls++
+				return nil
+			},
+		}).Build()
+
+		out := &bytes.Buffer{}
+		err := watchCluster(context.Background(), cl, out, client.ObjectKeyFromObject(mc), time.Second)
+		return out.String(), err
+	}
+
+	// the Failed phase reported while the cluster is provisioned does not fail the wait
+	out, err := watch(hmc.ManagedClusterPhaseProvisioning, hmc.ManagedClusterPhaseFailed,
+		hmc.ManagedClusterPhaseProvisioning, hmc.ManagedClusterPhaseReady)
+	require.NoError(t, err)
+	require.Contains(t, out, "ManagedCluster default/dev is Failed")
+	require.Contains(t, out, "ManagedCluster default/dev is Ready")
+
+	// the cluster failed for longer than the grace period fails the wait
+	_, err = watch(hmc.ManagedClusterPhaseProvisioning, hmc.ManagedClusterPhaseFailed)
+	require.EqualError(t, err, "ManagedCluster default/dev failed")
+}
@@ -0,0 +1,99 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// capiClusterListGVK is the GroupVersionKind of the list of the CAPI Clusters.
+var capiClusterListGVK = schema.GroupVersionKind{Group: "cluster.x-k8s.io", Version: "v1beta1", Kind: "ClusterList"}
+
+func newKubeconfigCommand(o *Options) *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "kubeconfig NAME",
+		Short: "Fetch the kubeconfig of a ManagedCluster",
+		Args:  exactlyOneArg("NAME"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.kubeconfig(cmd.Context(), args[0], output)
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the kubeconfig to the given file instead of the standard output.")
+	return cmd
+}
+
+func (o *Options) kubeconfig(ctx context.Context, name, output string) error {
+	cl, err := o.Client()
+	if err != nil {
+		return err
+	}
+
+	key, err := kubeconfigSecretKey(ctx, cl, o.Namespace, name)
+	if err != nil {
+		return err
+	}
+
+	secret := &corev1.Secret{}
+	if err := cl.Get(ctx, key, secret); err != nil {
+		return fmt.Errorf("failed to get the kubeconfig Secret %s: %w", key, err)
+	}
+
+	data, ok := secret.Data["value"]
+	if !ok {
+		return fmt.Errorf("kubeconfig Secret %s has no value", key)
+	}
+
+	if output == "" {
+		_, err := o.Out.Write(data)
+		return err
+	}
+	if err := os.WriteFile(output, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write the kubeconfig to %s: %w", output, err)
+	}
+	return nil
+}
+
+// kubeconfigSecretKey returns the key of the kubeconfig Secret of the CAPI Cluster deployed by
+// the ManagedCluster. CAPI names the Secret after the Cluster, which is looked up by the label
+// set by Flux on the objects of the release of the ManagedCluster.
+func kubeconfigSecretKey(ctx context.Context, cl client.Client, namespace, name string) (client.ObjectKey, error) {
+	mc := &hmc.ManagedCluster{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, mc); err != nil {
+		return client.ObjectKey{}, fmt.Errorf("failed to get ManagedCluster %s/%s: %w", namespace, name, err)
+	}
+
+	clusters := &metav1.PartialObjectMetadataList{}
+	clusters.SetGroupVersionKind(capiClusterListGVK)
+	if err := cl.List(ctx, clusters, client.InNamespace(namespace), client.MatchingLabels{hmc.FluxHelmChartNameKey: name}); err != nil {
+		return client.ObjectKey{}, fmt.Errorf("failed to list the Clusters of ManagedCluster %s/%s: %w", namespace, name, err)
+	}
+	if len(clusters.Items) == 0 {
+		return client.ObjectKey{}, fmt.Errorf("no Cluster is deployed by ManagedCluster %s/%s yet", namespace, name)
+	}
+
+	return client.ObjectKey{Namespace: namespace, Name: clusters.Items[0].Name + "-kubeconfig"}, nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+func TestKubeconfigSecretKey(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, hmc.AddToScheme(testScheme))
+	testScheme.AddKnownTypeWithName(capiClusterListGVK.GroupVersion().WithKind("Cluster"), &unstructured.Unstructured{})
+	testScheme.AddKnownTypeWithName(capiClusterListGVK, &unstructured.UnstructuredList{})
+	metav1.AddToGroupVersion(testScheme, schema.GroupVersion{Version: "v1"})
+
+	mc := &hmc.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "dev"}}
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(capiClusterListGVK.GroupVersion().WithKind("Cluster"))
+	cluster.SetNamespace("default")
+	// The name of the Cluster is set by the template and differs from the name of the ManagedCluster.
+	cluster.SetName("dev-cluster")
+	cluster.SetLabels(map[string]string{hmc.FluxHelmChartNameKey: "dev"})
+
+	cl := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(mc).Build()
+
+	// No Cluster is deployed yet.
+	_, err := kubeconfigSecretKey(context.Background(), cl, "default", "dev")
+	require.ErrorContains(t, err, "no Cluster is deployed")
+
+	require.NoError(t, cl.Create(context.Background(), cluster))
+	key, err := kubeconfigSecretKey(context.Background(), cl, "default", "dev")
+	require.NoError(t, err)
+	require.Equal(t, client.ObjectKey{Namespace: "default", Name: "dev-cluster-kubeconfig"}, key)
+
+	// An unknown ManagedCluster is reported.
+	_, err = kubeconfigSecretKey(context.Background(), cl, "default", "prod")
+	require.ErrorContains(t, err, "failed to get ManagedCluster default/prod")
+}
@@ -0,0 +1,70 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+func newListCommand(o *Options) *cobra.Command {
+	var allNamespaces bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the ManagedClusters",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return o.list(cmd.Context(), allNamespaces)
+		},
+	}
+	cmd.Flags().BoolVarP(&allNamespaces, "all-namespaces", "A", false, "List the ManagedClusters across all namespaces.")
+	return cmd
+}
+
+func (o *Options) list(ctx context.Context, allNamespaces bool) error {
+	cl, err := o.Client()
+	if err != nil {
+		return err
+	}
+
+	var opts []client.ListOption
+	if !allNamespaces {
+		opts = append(opts, client.InNamespace(o.Namespace))
+	}
+
+	clusters := &hmc.ManagedClusterList{}
+	if err := cl.List(ctx, clusters, opts...); err != nil {
+		return fmt.Errorf("failed to list ManagedClusters: %w", err)
+	}
+
+	w := tabwriter.NewWriter(o.Out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tTEMPLATE\tPHASE\tREADY\tUPGRADES")
+	for _, c := range clusters.Items {
+		ready := "Unknown"
+		if cond := apimeta.FindStatusCondition(c.Status.Conditions, hmc.ReadyCondition); cond != nil {
+			ready = string(cond.Status)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\n", c.Namespace, c.Name, c.Spec.Template, c.Status.Phase, ready, len(c.Status.AvailableUpgrades))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,223 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/sveltos"
+	"github.com/Mirantis/hmc/pkg/conditions"
+)
+
+// NewPluginCommand returns the root command of the kubectl-hmc plugin.
+func NewPluginCommand() *cobra.Command {
+	cmd := NewRootCommand("kubectl-hmc")
+	cmd.Annotations = map[string]string{cobra.CommandDisplayNameAnnotation: "kubectl hmc"}
+	return cmd
+}
+
+func newGetCommand(o *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get",
+		Short: "Display the HMC objects",
+	}
+
+	var allNamespaces bool
+	clusters := &cobra.Command{
+		Use:     "clusters",
+		Aliases: []string{"cluster", "managedclusters"},
+		Short:   "Display the ManagedClusters with their credential, versions and failing conditions",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return o.getClusters(cmd.Context(), allNamespaces)
+		},
+	}
+	clusters.Flags().BoolVarP(&allNamespaces, "all-namespaces", "A", false, "List the ManagedClusters across all namespaces.")
+
+	cmd.AddCommand(clusters)
+	return cmd
+}
+
+func (o *Options) getClusters(ctx context.Context, allNamespaces bool) error {
+	cl, err := o.Client()
+	if err != nil {
+		return err
+	}
+
+	var opts []client.ListOption
+	if !allNamespaces {
+		opts = append(opts, client.InNamespace(o.Namespace))
+	}
+
+	clusters := &hmc.ManagedClusterList{}
+	if err := cl.List(ctx, clusters, opts...); err != nil {
+		return fmt.Errorf("failed to list ManagedClusters: %w", err)
+	}
+
+	w := tabwriter.NewWriter(o.Out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tTEMPLATE\tCREDENTIAL\tK8S\tPHASE\tSERVICES\tUPGRADES\tAGE\tMESSAGE")
+	for _, c := range clusters.Items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+			c.Namespace, c.Name, c.Spec.Template, c.Spec.Credential, orNone(c.Status.KubernetesVersion),
+			orNone(string(c.Status.Phase)), len(c.Spec.Services), orNone(strings.Join(c.Status.AvailableUpgrades, ",")),
+			duration.HumanDuration(time.Since(c.CreationTimestamp.Time)), failingConditions(c.Status.Conditions))
+	}
+	return w.Flush()
+}
+
+// failingConditions returns the issues reported by the conditions, the most severe first.
+func failingConditions(conds []metav1.Condition) string {
+	issues := conditions.Issues(conds, hmc.ReadyCondition)
+	if len(issues) == 0 {
+		return "<none>"
+	}
+
+	messages := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		messages = append(messages, issue.Type+": "+issue.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+func newServicesCommand(o *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "services",
+		Short: "Inspect the services of the ManagedClusters",
+	}
+
+	status := &cobra.Command{
+		Use:   "status NAME",
+		Short: "Display the deployment status of the services of a ManagedCluster",
+		Args:  exactlyOneArg("NAME"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.servicesStatus(cmd.Context(), args[0])
+		},
+	}
+
+	cmd.AddCommand(status)
+	return cmd
+}
+
+func (o *Options) servicesStatus(ctx context.Context, name string) error {
+	cl, err := o.Client()
+	if err != nil {
+		return err
+	}
+
+	cluster := &hmc.ManagedCluster{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: o.Namespace, Name: name}, cluster); err != nil {
+		return fmt.Errorf("failed to get ManagedCluster %s/%s: %w", o.Namespace, name, err)
+	}
+
+	statuses, err := sveltos.ClusterServiceStatuses(ctx, cl, cluster.Namespace, cluster.Name)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(o.Out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "PROFILE\tNAMESPACE\tNAME\tSTATUS\tMESSAGE")
+	for _, s := range statuses {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Profile, s.Namespace, s.Name, orNone(s.Status), orNone(s.Message))
+	}
+	return w.Flush()
+}
+
+func newCredsCommand(o *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "creds",
+		Aliases: []string{"credentials"},
+		Short:   "Inspect the Credentials",
+	}
+
+	check := &cobra.Command{
+		Use:   "check [NAME]",
+		Short: "Check the Credentials are ready to deploy clusters",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var name string
+			if len(args) > 0 {
+				name = args[0]
+			}
+			return o.checkCredentials(cmd.Context(), name)
+		},
+	}
+
+	cmd.AddCommand(check)
+	return cmd
+}
+
+func (o *Options) checkCredentials(ctx context.Context, name string) error {
+	cl, err := o.Client()
+	if err != nil {
+		return err
+	}
+
+	var credentials []hmc.Credential
+	if name != "" {
+		cred := &hmc.Credential{}
+		if err := cl.Get(ctx, client.ObjectKey{Namespace: o.Namespace, Name: name}, cred); err != nil {
+			return fmt.Errorf("failed to get Credential %s/%s: %w", o.Namespace, name, err)
+		}
+		credentials = append(credentials, *cred)
+	} else {
+		list := &hmc.CredentialList{}
+		if err := cl.List(ctx, list, client.InNamespace(o.Namespace)); err != nil {
+			return fmt.Errorf("failed to list Credentials: %w", err)
+		}
+		credentials = list.Items
+	}
+
+	var notReady int
+	w := tabwriter.NewWriter(o.Out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tIDENTITY\tSTATE")
+	for _, c := range credentials {
+		identity := "<none>"
+		if ref := c.ClusterIdentityRef(); ref != nil {
+			identity = ref.Kind + "/" + ref.Name
+			if ref.Namespace != "" {
+				identity = ref.Kind + "/" + ref.Namespace + "/" + ref.Name
+			}
+		}
+		if c.Status.State != hmc.CredentialReady {
+			notReady++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", c.Name, identity, orNone(string(c.Status.State)))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if notReady > 0 {
+		return fmt.Errorf("%d of %d Credentials are not ready", notReady, len(credentials))
+	}
+	return nil
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
@@ -0,0 +1,38 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+func TestFailingConditions(t *testing.T) {
+	require.Equal(t, "<none>", failingConditions([]metav1.Condition{
+		{Type: hmc.ReadyCondition, Status: metav1.ConditionTrue},
+		{Type: hmc.HelmReleaseReadyCondition, Status: metav1.ConditionTrue},
+		{Type: hmc.ServiceConflictCondition, Status: metav1.ConditionFalse},
+	}))
+
+	require.Equal(t, "CredentialReady: not found; TemplateDeprecated: deprecated", failingConditions([]metav1.Condition{
+		{Type: hmc.ReadyCondition, Status: metav1.ConditionFalse, Message: "not ready"},
+		{Type: hmc.CredentialReadyCondition, Status: metav1.ConditionFalse, Message: "not found"},
+		{Type: hmc.TemplateDeprecatedCondition, Status: metav1.ConditionTrue, Message: "deprecated"},
+	}))
+}
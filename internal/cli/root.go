@@ -0,0 +1,130 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cli implements the hmc command line interface managing the
+// ManagedClusters through the HMC API, the controller remains the source
+// of truth for the state of the clusters.
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(hmc.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
+}
+
+// Options are the options shared by the commands.
+type Options struct {
+	// Kubeconfig is the path of the kubeconfig of the management cluster.
+	Kubeconfig string
+	// Namespace is the namespace of the ManagedClusters, defaults to the namespace of the current context.
+	Namespace string
+
+	In  io.Reader
+	Out io.Writer
+
+	client client.Client
+}
+
+// NewRootCommand returns the root command of the CLI with the given name, e.g. "hmc" or "kubectl-hmc".
+func NewRootCommand(name string) *cobra.Command {
+	o := &Options{In: os.Stdin, Out: os.Stdout}
+
+	cmd := &cobra.Command{
+		Use:           name,
+		Short:         "Manage the HMC ManagedClusters",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.SetIn(o.In)
+			cmd.SetOut(o.Out)
+			return nil
+		},
+	}
+	cmd.PersistentFlags().StringVar(&o.Kubeconfig, "kubeconfig", "", "Path to the kubeconfig of the management cluster.")
+	cmd.PersistentFlags().StringVarP(&o.Namespace, "namespace", "n", "", "Namespace of the ManagedClusters.")
+
+	cmd.AddCommand(
+		newCreateCommand(o),
+		newListCommand(o),
+		newUpgradeCommand(o),
+		newKubeconfigCommand(o),
+		newWatchCommand(o),
+		newGetCommand(o),
+		newServicesCommand(o),
+		newCredsCommand(o),
+		newValidateCommand(o),
+		newTemplateCommand(o),
+	)
+	return cmd
+}
+
+// Client returns the client of the management cluster resolving the namespace of the current context.
+func (o *Options) Client() (client.Client, error) {
+	if o.client != nil {
+		return o.client, nil
+	}
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = o.Kubeconfig
+	config := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
+
+	if o.Namespace == "" {
+		namespace, _, err := config.Namespace()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the namespace of the current context: %w", err)
+		}
+		o.Namespace = namespace
+	}
+
+	restConfig, err := config.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the kubeconfig: %w", err)
+	}
+
+	o.client, err = client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the client: %w", err)
+	}
+	return o.client, nil
+}
+
+// exactlyOneArg returns an error naming the expected argument unless exactly one argument is given.
+func exactlyOneArg(name string) cobra.PositionalArgs {
+	return func(_ *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("expected the " + name + " argument")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/helm"
+)
+
+type templateTestOptions struct {
+	*Options
+
+	files       []string
+	kubeVersion string
+	offline     bool
+}
+
+func newTemplateCommand(o *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "template",
+		Short: "Develop the ClusterTemplate charts",
+	}
+	cmd.AddCommand(newTemplateTestCommand(o))
+	return cmd
+}
+
+func newTemplateTestCommand(o *Options) *cobra.Command {
+	to := &templateTestOptions{Options: o}
+
+	cmd := &cobra.Command{
+		Use:   "test CHART",
+		Short: "Render a ClusterTemplate chart with the sample values and validate the rendered objects",
+		Long: "Render a ClusterTemplate chart with the sample values and validate the rendered objects.\n" +
+			"The chart is rendered with each of the values files given with -f, or with the sample values\n" +
+			"in the ci directory of the chart, or with its default values. The rendered objects are validated\n" +
+			"against the CustomResourceDefinitions and the API of the management cluster, unless --offline\n" +
+			"is set, in which case only the objects of the Kubernetes kinds are validated.",
+		Args: exactlyOneArg("CHART"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return to.run(cmd.Context(), args[0])
+		},
+	}
+	cmd.Flags().StringArrayVarP(&to.files, "values", "f", nil, "Path to the sample values, can be repeated.")
+	cmd.Flags().StringVar(&to.kubeVersion, "kube-version", "", "Kubernetes version to render the chart against, defaults to the version of the chart annotation "+hmc.ChartAnnotationKubernetesVersion+".")
+	cmd.Flags().BoolVar(&to.offline, "offline", false, "Validate the rendered objects without the management cluster.")
+	return cmd
+}
+
+func (to *templateTestOptions) run(ctx context.Context, chartPath string) error {
+	helmChart, err := loader.Load(chartPath)
+	if err != nil {
+		return fmt.Errorf("failed to load the chart %s: %w", chartPath, err)
+	}
+
+	samples, err := helm.SampleValues(helmChart)
+	if err != nil {
+		return err
+	}
+	if len(to.files) > 0 {
+		samples = make(map[string]map[string]any, len(to.files))
+		for _, file := range to.files {
+			if samples[file], err = readValues(file, nil); err != nil {
+				return err
+			}
+		}
+	}
+	if len(samples) == 0 {
+		samples = map[string]map[string]any{"default values": nil}
+	}
+
+	validator := &helm.ManifestValidator{}
+	if !to.offline {
+		cl, err := to.Client()
+		if err != nil {
+			return err
+		}
+		validator.GetCRD = func(name string) (*apiextensionsv1.CustomResourceDefinition, error) {
+			crd := new(apiextensionsv1.CustomResourceDefinition)
+			if err := cl.Get(ctx, client.ObjectKey{Name: name}, crd); err != nil {
+				return nil, client.IgnoreNotFound(err)
+			}
+			return crd, nil
+		}
+		validator.Mapper = cl.RESTMapper()
+	}
+
+	kubeVersion := to.kubeVersion
+	if kubeVersion == "" && helmChart.Metadata != nil {
+		kubeVersion = helmChart.Metadata.Annotations[hmc.ChartAnnotationKubernetesVersion]
+	}
+
+	if !testTemplate(to.Out, helmChart, samples, kubeVersion, validator) {
+		return errors.New("the chart is invalid")
+	}
+	return nil
+}
+
+// testTemplate renders the chart with each of the samples, validates the rendered objects
+// and reports the results to out. Returns true if all of the samples pass.
+func testTemplate(out io.Writer, helmChart *chart.Chart, samples map[string]map[string]any, kubeVersion string, validator *helm.ManifestValidator) bool {
+	names := make([]string, 0, len(samples))
+	for name := range samples {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	passed := true
+	for _, name := range names {
+		objects, err := helm.RenderObjects(helmChart, samples[name], helm.RenderOptions{
+			ReleaseName:      helmChart.Name(),
+			ReleaseNamespace: "default",
+			KubeVersion:      kubeVersion,
+		})
+		if err != nil {
+			passed = false
+			fmt.Fprintf(out, "[FAIL] %s: %v\n", name, err)
+			continue
+		}
+
+		// the kinds are not going to be served while the command runs
+		problems, pending := validator.Validate(objects)
+		problems = append(problems, pending...)
+		if len(problems) == 0 {
+			fmt.Fprintf(out, "[PASS] %s: %d objects\n", name, len(objects))
+			continue
+		}
+		passed = false
+		fmt.Fprintf(out, "[FAIL] %s\n", name)
+		for _, problem := range problems {
+			fmt.Fprintf(out, "  %s\n", problem)
+		}
+	}
+	return passed
+}
@@ -0,0 +1,55 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+
+	"github.com/Mirantis/hmc/internal/helm"
+)
+
+func TestTestTemplate(t *testing.T) {
+	helmChart := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "test", Version: "0.1.0"},
+		Values:   map[string]any{"replicas": 1},
+		Schema:   []byte(`{"type": "object", "properties": {"replicas": {"type": "integer"}}}`),
+		Templates: []*chart.File{
+			{Name: "templates/deployment.yaml", Data: []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}
+spec:
+  {{ if gt (int .Values.replicas) 1 }}replicaz{{ else }}replicas{{ end }}: {{ .Values.replicas }}
+`)},
+		},
+	}
+
+	out := &bytes.Buffer{}
+	require.True(t, testTemplate(out, helmChart, map[string]map[string]any{"ci/values.yaml": nil}, "v1.31.0", &helm.ManifestValidator{}))
+	require.Equal(t, "[PASS] ci/values.yaml: 1 objects\n", out.String())
+
+	out.Reset()
+	require.False(t, testTemplate(out, helmChart, map[string]map[string]any{
+		"ci/invalid-values.yaml": {"replicas": "one"},
+		"ci/unknown-values.yaml": {"replicas": 3},
+	}, "v1.31.0", &helm.ManifestValidator{}))
+	require.Contains(t, out.String(), "[FAIL] ci/invalid-values.yaml: ")
+	require.Contains(t, out.String(), "[FAIL] ci/unknown-values.yaml\n  test/templates/deployment.yaml: Deployment/test: ")
+	require.Contains(t, out.String(), `unknown field "spec.replicaz"`)
+}
@@ -0,0 +1,81 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+func newUpgradeCommand(o *Options) *cobra.Command {
+	var template string
+
+	cmd := &cobra.Command{
+		Use:   "upgrade NAME [--to-template TEMPLATE]",
+		Short: "List the available upgrades of a ManagedCluster or upgrade it to the given ClusterTemplate",
+		Args:  exactlyOneArg("NAME"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.upgrade(cmd.Context(), args[0], template)
+		},
+	}
+	cmd.Flags().StringVarP(&template, "template", "t", "", "Name of the ClusterTemplate to upgrade to.")
+	cmd.Flags().StringVar(&template, "to-template", "", "Alias of --template.")
+	return cmd
+}
+
+func (o *Options) upgrade(ctx context.Context, name, template string) error {
+	cl, err := o.Client()
+	if err != nil {
+		return err
+	}
+
+	cluster := &hmc.ManagedCluster{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: o.Namespace, Name: name}, cluster); err != nil {
+		return fmt.Errorf("failed to get ManagedCluster %s/%s: %w", o.Namespace, name, err)
+	}
+
+	if template == "" {
+		if len(cluster.Status.AvailableUpgrades) == 0 {
+			fmt.Fprintf(o.Out, "No upgrades available for ManagedCluster %s/%s\n", o.Namespace, name)
+			return nil
+		}
+		for _, upgrade := range cluster.Status.AvailableUpgrades {
+			fmt.Fprintln(o.Out, upgrade)
+		}
+		return nil
+	}
+
+	if cluster.Spec.Template == template {
+		fmt.Fprintf(o.Out, "ManagedCluster %s/%s already uses ClusterTemplate %s\n", o.Namespace, name, template)
+		return nil
+	}
+	if !slices.Contains(cluster.Status.AvailableUpgrades, template) {
+		return fmt.Errorf("ClusterTemplate %s is not an available upgrade of ManagedCluster %s/%s", template, o.Namespace, name)
+	}
+
+	patch := client.MergeFrom(cluster.DeepCopy())
+	cluster.Spec.Template = template
+	if err := cl.Patch(ctx, cluster, patch); err != nil {
+		return fmt.Errorf("failed to upgrade ManagedCluster %s/%s: %w", o.Namespace, name, err)
+	}
+	fmt.Fprintf(o.Out, "ManagedCluster %s/%s upgraded to ClusterTemplate %s\n", o.Namespace, name, template)
+	return nil
+}
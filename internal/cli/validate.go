@@ -0,0 +1,82 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+type validateOptions struct {
+	*Options
+
+	file string
+}
+
+func newValidateCommand(o *Options) *cobra.Command {
+	vo := &validateOptions{Options: o}
+
+	cmd := &cobra.Command{
+		Use:   "validate -f FILE",
+		Short: "Validate a ManagedCluster manifest without creating it",
+		Long: "Validate a ManagedCluster manifest without creating it. The manifest is created in the dry-run\n" +
+			"mode, which requires the permission to create ManagedClusters in the namespace, and the\n" +
+			"HMC admission webhook reports all of the failed preflight checks at once.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return vo.run(cmd.Context())
+		},
+	}
+	cmd.Flags().StringVarP(&vo.file, "filename", "f", "", "Path to the ManagedCluster manifest.")
+	_ = cmd.MarkFlagRequired("filename")
+	return cmd
+}
+
+func (vo *validateOptions) run(ctx context.Context) error {
+	manifest, err := os.ReadFile(vo.file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", vo.file, err)
+	}
+
+	mc := &hmc.ManagedCluster{}
+	if err := yaml.UnmarshalStrict(manifest, mc); err != nil {
+		return fmt.Errorf("invalid ManagedCluster manifest: %w", err)
+	}
+	if mc.Kind != "" && mc.Kind != hmc.ManagedClusterKind {
+		return fmt.Errorf("expected ManagedCluster but got %s", mc.Kind)
+	}
+
+	cl, err := vo.Client()
+	if err != nil {
+		return err
+	}
+	if mc.Namespace == "" {
+		mc.Namespace = vo.Namespace
+	}
+
+	if err := cl.Create(ctx, mc, client.DryRunAll); err != nil {
+		return fmt.Errorf("failed to validate the ManagedCluster: %w", err)
+	}
+
+	fmt.Fprintf(vo.Out, "ManagedCluster %s/%s is valid\n", mc.Namespace, mc.Name)
+	return nil
+}
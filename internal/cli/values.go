@@ -0,0 +1,150 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/strvals"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// readValues reads the cluster configuration from the given file and applies the --set overrides.
+func readValues(file string, set []string) (map[string]any, error) {
+	values := map[string]any{}
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		if values == nil {
+			values = map[string]any{}
+		}
+	}
+
+	for _, s := range set {
+		if err := parseSet(s, values); err != nil {
+			return nil, fmt.Errorf("failed to parse --set %s: %w", s, err)
+		}
+	}
+	return values, nil
+}
+
+// parseSet sets the value of the given path=value pair in values. Unlike strvals.ParseInto, the
+// value is taken verbatim, so that commas and backslashes are kept instead of splitting the value
+// into several pairs, while the path syntax and the typing of the value are those of helm --set.
+func parseSet(s string, values map[string]any) error {
+	path, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return strvals.ParseInto(s, values)
+	}
+	value = strings.NewReplacer(`\`, `\\`, ",", `\,`).Replace(value)
+	return strvals.ParseInto(path+"="+value, values)
+}
+
+// promptValues asks for each of the scalar values of the template defaults not already set in values.
+// An empty answer keeps the default of the template. The descriptions of the parameters of the
+// template are printed before the prompts.
+func promptValues(in io.Reader, out io.Writer, defaults *apiextensionsv1.JSON, params []hmc.TemplateParameter, values map[string]any) error {
+	if defaults == nil || len(defaults.Raw) == 0 {
+		return nil
+	}
+
+	var defaultValues map[string]any
+	if err := json.Unmarshal(defaults.Raw, &defaultValues); err != nil {
+		return fmt.Errorf("failed to parse the template defaults: %w", err)
+	}
+
+	leaves := map[string]any{}
+	flatten("", defaultValues, leaves)
+	paths := make([]string, 0, len(leaves))
+	for path := range leaves {
+		if _, ok := lookup(values, path); !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	descriptions := make(map[string]string, len(params))
+	for _, param := range params {
+		descriptions[param.Name] = param.Description
+	}
+
+	reader := bufio.NewReader(in)
+	for _, path := range paths {
+		if description := descriptions[path]; description != "" {
+			fmt.Fprintf(out, "# %s\n", description)
+		}
+		fmt.Fprintf(out, "%s [%v]: ", path, leaves[path])
+		answer, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read the value of %s: %w", path, err)
+		}
+		answer = strings.TrimSpace(answer)
+		if answer != "" {
+			if err := parseSet(path+"="+answer, values); err != nil {
+				return fmt.Errorf("invalid value of %s: %w", path, err)
+			}
+		}
+		if err == io.EOF {
+			fmt.Fprintln(out)
+			return nil
+		}
+	}
+	return nil
+}
+
+// flatten collects the scalar values of the given map keyed by their dotted path.
+// Lists are considered scalars since they cannot be prompted for element by element.
+func flatten(prefix string, values map[string]any, leaves map[string]any) {
+	for k, v := range values {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]any); ok && len(nested) > 0 {
+			flatten(path, nested, leaves)
+			continue
+		}
+		leaves[path] = v
+	}
+}
+
+// lookup returns the value at the given dotted path.
+func lookup(values map[string]any, path string) (any, bool) {
+	var cur any = values
+	for _, k := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[k]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
@@ -0,0 +1,65 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+func TestReadValues(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "values.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("controlPlane:\n  instanceType: t3.small\nworkersNumber: 1\n"), 0o600))
+
+	values, err := readValues(file, []string{"workersNumber=3", "region=us-west-2", `sans=a.example.com,b.example.com`, `path=C:\\hmc`})
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		"controlPlane":  map[string]any{"instanceType": "t3.small"},
+		"workersNumber": int64(3),
+		"region":        "us-west-2",
+		"sans":          "a.example.com,b.example.com",
+		"path":          `C:\\hmc`,
+	}, values)
+
+	_, err = readValues(filepath.Join(t.TempDir(), "missing.yaml"), nil)
+	require.Error(t, err)
+}
+
+func TestPromptValues(t *testing.T) {
+	defaults := &apiextensionsv1.JSON{Raw: []byte(`{"region":"","workersNumber":2,"controlPlane":{"instanceType":"t3.small"},"publicIP":false}`)}
+	values := map[string]any{"region": "us-east-2"}
+
+	in := bytes.NewBufferString("t3.large\n\n4\n")
+	out := &bytes.Buffer{}
+	params := []hmc.TemplateParameter{
+		{Name: "workersNumber", Description: "The number of the worker machines"},
+		{Name: "publicIP"},
+	}
+	require.NoError(t, promptValues(in, out, defaults, params, values))
+
+	require.Equal(t, "controlPlane.instanceType [t3.small]: publicIP [false]: # The number of the worker machines\nworkersNumber [2]: ", out.String())
+	require.Equal(t, map[string]any{
+		"region":        "us-east-2",
+		"controlPlane":  map[string]any{"instanceType": "t3.large"},
+		"workersNumber": int64(4),
+	}, values)
+}
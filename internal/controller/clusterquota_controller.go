@@ -0,0 +1,121 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// ClusterQuotaReconciler records the usage of the namespace in the status of the ClusterQuota
+// and reports the limits exceeded, e.g. after the quota has been lowered. The limits are
+// enforced on the ManagedClusters by the admission webhook and re-checked by the
+// ManagedCluster controller before the clusters are deployed.
+type ClusterQuotaReconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+	Shard    string
+}
+
+func (r *ClusterQuotaReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	quota := &hmc.ClusterQuota{}
+	if err := r.Get(ctx, req.NamespacedName, quota); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	clusters := &hmc.ManagedClusterList{}
+	if err := r.List(ctx, clusters, client.InNamespace(quota.Namespace)); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list ManagedClusters: %w", err)
+	}
+
+	mgmt := &hmc.Management{}
+	if err := r.Get(ctx, client.ObjectKey{Name: hmc.ManagementName}, mgmt); client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get Management: %w", err)
+	}
+
+	var workers int32
+	templates := make(map[string]*hmc.ClusterTemplate)
+	for _, cluster := range clusters.Items {
+		clusterWorkers, err := clusterWorkersNumber(ctx, r.Client, &cluster, mgmt, templates)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		workers += clusterWorkers
+	}
+
+	patch := client.MergeFrom(quota.DeepCopy())
+	quota.Status.Clusters = int32(len(clusters.Items))
+	quota.Status.Workers = workers
+
+	condition := metav1.Condition{
+		Type:               hmc.QuotaExceededCondition,
+		Status:             metav1.ConditionFalse,
+		Reason:             hmc.WithinLimitsReason,
+		ObservedGeneration: quota.Generation,
+	}
+	if exceeded := quota.Exceeded(quota.Status.Clusters, quota.Status.Workers); len(exceeded) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = hmc.LimitsExceededReason
+		condition.Message = strings.Join(exceeded, ", ")
+	}
+	if apimeta.SetStatusCondition(&quota.Status.Conditions, condition) && condition.Status == metav1.ConditionTrue {
+		r.Recorder.Eventf(quota, corev1.EventTypeWarning, EventReasonQuotaExceeded,
+			"The ManagedClusters in the namespace exceed the ClusterQuota: %s", condition.Message)
+	}
+
+	if err := r.Status().Patch(ctx, quota, patch); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update the status of ClusterQuota %s: %w", req.NamespacedName, err)
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClusterQuotaReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	shards, err := newShardFilter(mgr, r.Shard)
+	if err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&hmc.ClusterQuota{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&hmc.ManagedCluster{}, handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, o client.Object) []ctrl.Request {
+			quotas := &hmc.ClusterQuotaList{}
+			if err := mgr.GetClient().List(ctx, quotas, client.InNamespace(o.GetNamespace())); err != nil {
+				return nil
+			}
+
+			requests := make([]ctrl.Request, 0, len(quotas.Items))
+			for _, quota := range quotas.Items {
+				requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&quota)})
+			}
+			return requests
+		}), builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(shards.watchNamespaces(mgr.GetClient(), &hmc.ClusterQuotaList{})).
+		WithEventFilter(shards.predicate()).
+		Complete(r)
+}
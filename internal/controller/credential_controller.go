@@ -18,29 +18,63 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/audit"
 )
 
 // CredentialReconciler reconciles a Credential object
 type CredentialReconciler struct {
 	client.Client
+	// Shard is the name of the shard of the namespaces the controller
+	// reconciles the Credentials in, the default shard is empty.
+	Shard           string
+	SystemNamespace string
+	// IsolateIdentities allows the Credentials to isolate the ClusterIdentities,
+	// the controller is granted the permissions to create the ClusterIdentities then.
+	IsolateIdentities bool
+
+	controller        controller.Controller
+	cache             cache.Cache
+	shards            *shardFilter
+	identityWatches   map[schema.GroupVersionKind]bool
+	identityWatchesMu sync.Mutex
 }
 
 func (r *CredentialReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	l := ctrl.LoggerFrom(ctx)
 	l.Info("Credential reconcile start")
+	ctx = audit.WithActor(ctx, "credential")
 
 	cred := &hmc.Credential{}
 	if err := r.Client.Get(ctx, req.NamespacedName, cred); err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	if !cred.DeletionTimestamp.IsZero() {
+		return r.delete(ctx, cred)
+	}
+
+	if cred.Spec.IsolateIdentity && controllerutil.AddFinalizer(cred, hmc.CredentialFinalizer) {
+		if err := r.Client.Update(ctx, cred); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to add finalizer to Credential %s/%s: %w", cred.Namespace, cred.Name, err)
+		}
+		return ctrl.Result{}, nil
+	}
+
 	clIdty := &unstructured.Unstructured{}
 	clIdty.SetAPIVersion(cred.Spec.IdentityRef.APIVersion)
 	clIdty.SetKind(cred.Spec.IdentityRef.Kind)
@@ -67,6 +101,31 @@ func (r *CredentialReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
+	identityRef := cred.Spec.IdentityRef
+	if cred.Spec.IsolateIdentity {
+		if !r.IsolateIdentities {
+			l.Info("The isolation of the ClusterIdentities is disabled in the controller")
+			return ctrl.Result{}, r.setState(ctx, cred, hmc.CredentialNotAllowed)
+		}
+		if err := r.watchIdentity(ctx, clIdty.GroupVersionKind()); err != nil {
+			return ctrl.Result{}, err
+		}
+		if !isolationAllowed(clIdty, cred.Namespace) {
+			l.Info("Namespace is not allowed to isolate the ClusterIdentity", "identity", client.ObjectKeyFromObject(clIdty))
+			return ctrl.Result{}, r.setState(ctx, cred, hmc.CredentialNotAllowed)
+		}
+
+		var err error
+		identityRef, err = r.reconcileIsolatedIdentity(ctx, cred, clIdty)
+		if err != nil {
+			l.Error(err, "failed to reconcile isolated ClusterIdentity")
+			return ctrl.Result{}, err
+		}
+	} else if err := r.deleteIsolatedIdentity(ctx, cred); err != nil {
+		return ctrl.Result{}, err
+	}
+	cred.Status.IdentityRef = identityRef
+
 	if err := r.setState(ctx, cred, hmc.CredentialReady); err != nil {
 		l.Error(err, "failed to set Credential state")
 
@@ -86,9 +145,105 @@ func (r *CredentialReconciler) setState(ctx context.Context, cred *hmc.Credentia
 	return nil
 }
 
+func (r *CredentialReconciler) delete(ctx context.Context, cred *hmc.Credential) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(cred, hmc.CredentialFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	// the isolated identity is used by the clusters until they are deleted
+	managedClusters := &hmc.ManagedClusterList{}
+	if err := r.Client.List(ctx, managedClusters,
+		client.InNamespace(cred.Namespace),
+		client.MatchingFields{hmc.CredentialKey: cred.Name}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list ManagedClusters using Credential %s/%s: %w", cred.Namespace, cred.Name, err)
+	}
+	if len(managedClusters.Items) > 0 {
+		ctrl.LoggerFrom(ctx).Info("Waiting for the ManagedClusters using the Credential to be deleted",
+			"clusters", len(managedClusters.Items), "requeue in", DefaultRequeueInterval)
+		return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, nil
+	}
+
+	if err := r.deleteIsolatedIdentity(ctx, cred); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if controllerutil.RemoveFinalizer(cred, hmc.CredentialFinalizer) {
+		if err := r.Client.Update(ctx, cred); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to remove finalizer from Credential %s/%s: %w", cred.Namespace, cred.Name, err)
+		}
+		audit.Record(ctx, audit.ActionRemoveFinalizer, cred, audit.FinalizerRemoved(hmc.CredentialFinalizer))
+	}
+
+	return ctrl.Result{}, nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
+// The source ClusterIdentities of the isolated ones are watched once referenced.
 func (r *CredentialReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&hmc.Credential{}).
-		Complete(r)
+	shards, err := newShardFilter(mgr, r.Shard)
+	if err != nil {
+		return err
+	}
+	r.shards = shards
+	r.cache = mgr.GetCache()
+	r.identityWatches = make(map[schema.GroupVersionKind]bool)
+
+	r.controller, err = ctrl.NewControllerManagedBy(mgr).
+		For(&hmc.Credential{}, builder.WithPredicates(specOrMetadataChanged())).
+		Watches(shards.watchNamespaces(mgr.GetClient(), &hmc.CredentialList{})).
+		WithEventFilter(shards.predicate()).
+		Build(r)
+	return err
+}
+
+// watchIdentity starts the watch of the ClusterIdentities of the given kind
+// requeueing the Credentials isolating them, so that the changes of the source
+// identities are propagated to the isolated copies.
+func (r *CredentialReconciler) watchIdentity(ctx context.Context, gvk schema.GroupVersionKind) error {
+	if r.controller == nil {
+		return nil
+	}
+
+	r.identityWatchesMu.Lock()
+	defer r.identityWatchesMu.Unlock()
+
+	if r.identityWatches[gvk] {
+		return nil
+	}
+
+	identity := &unstructured.Unstructured{}
+	identity.SetGroupVersionKind(gvk)
+	if err := r.controller.Watch(source.Kind[client.Object](r.cache, identity,
+		handler.EnqueueRequestsFromMapFunc(r.mapIdentityToCredentials),
+	)); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", gvk, err)
+	}
+
+	r.identityWatches[gvk] = true
+	ctrl.LoggerFrom(ctx).Info("Watching ClusterIdentities", "kind", gvk.Kind)
+	return nil
+}
+
+// mapIdentityToCredentials maps a ClusterIdentity to the Credentials isolating it.
+func (r *CredentialReconciler) mapIdentityToCredentials(ctx context.Context, o client.Object) []ctrl.Request {
+	credentials := &hmc.CredentialList{}
+	if err := r.Client.List(ctx, credentials); err != nil {
+		return nil
+	}
+
+	gvk := o.GetObjectKind().GroupVersionKind()
+	var requests []ctrl.Request
+	for _, cred := range credentials.Items {
+		ref := cred.Spec.IdentityRef
+		if !cred.Spec.IsolateIdentity || ref == nil ||
+			ref.Kind != gvk.Kind || ref.APIVersion != gvk.GroupVersion().String() ||
+			ref.Name != o.GetName() || ref.Namespace != o.GetNamespace() {
+			continue
+		}
+		if r.shards != nil && !r.shards.inShard(&cred) {
+			continue
+		}
+		requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&cred)})
+	}
+	return requests
 }
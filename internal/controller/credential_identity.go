@@ -0,0 +1,211 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// isolationAllowed returns true if the namespace is allowed to create
+// isolated copies of the given ClusterIdentity.
+func isolationAllowed(identity client.Object, namespace string) bool {
+	namespaces := strings.Split(identity.GetAnnotations()[hmc.IdentityNamespacesAnnotation], ",")
+	return slices.ContainsFunc(namespaces, func(ns string) bool {
+		return strings.TrimSpace(ns) == namespace
+	})
+}
+
+// isolatedAllowedNamespaces returns the allowedNamespaces of the ClusterIdentity
+// of the given kind allowing the identity to be used only from the namespace.
+func isolatedAllowedNamespaces(kind, namespace string) (map[string]any, error) {
+	switch kind {
+	case "AWSClusterStaticIdentity", "AWSClusterRoleIdentity":
+		return map[string]any{"list": []any{namespace}}, nil
+	case "AzureClusterIdentity":
+		return map[string]any{"namespaceList": []any{namespace}}, nil
+	case "VSphereClusterIdentity":
+		return map[string]any{
+			"selector": map[string]any{
+				"matchLabels": map[string]any{corev1.LabelMetadataName: namespace},
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("isolation of %s is not supported", kind)
+	}
+}
+
+func credentialOwnerValue(cred *hmc.Credential) string {
+	return cred.Namespace + "/" + cred.Name
+}
+
+// reconcileIsolatedIdentity ensures the copy of the source ClusterIdentity restricted
+// to the namespace of the Credential and returns the reference to the copy.
+func (r *CredentialReconciler) reconcileIsolatedIdentity(ctx context.Context, cred *hmc.Credential, source *unstructured.Unstructured) (*corev1.ObjectReference, error) {
+	if ref := cred.Status.IdentityRef; ref != nil && (ref.Kind != source.GetKind() || ref.APIVersion != source.GetAPIVersion()) {
+		if err := r.deleteIsolatedIdentity(ctx, cred); err != nil {
+			return nil, err
+		}
+	}
+
+	allowedNamespaces, err := isolatedAllowedNamespaces(source.GetKind(), cred.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, ok := source.Object["spec"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s %s has no spec", source.GetKind(), source.GetName())
+	}
+	spec = runtime.DeepCopyJSON(spec)
+	spec["allowedNamespaces"] = allowedNamespaces
+
+	name := cred.Namespace + "-" + cred.Name
+	if source.GetKind() == "VSphereClusterIdentity" {
+		// the secret of a vSphere identity cannot be shared with other identities
+		secretName, _, _ := unstructured.NestedString(spec, "secretName")
+		if err := r.reconcileIsolatedSecret(ctx, cred, secretName, name); err != nil {
+			return nil, err
+		}
+		spec["secretName"] = name
+	}
+
+	identity := &unstructured.Unstructured{}
+	identity.SetGroupVersionKind(source.GroupVersionKind())
+	identity.SetName(name)
+	identity.SetNamespace(source.GetNamespace())
+
+	if _, err := ctrl.CreateOrUpdate(ctx, r.Client, identity, func() error {
+		if err := checkIsolatedCopyOwner(identity, cred); err != nil {
+			return err
+		}
+		setIsolatedCopyMetadata(identity, cred)
+		identity.Object["spec"] = spec
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to reconcile isolated %s %s: %w", identity.GetKind(), name, err)
+	}
+
+	return &corev1.ObjectReference{
+		APIVersion: identity.GetAPIVersion(),
+		Kind:       identity.GetKind(),
+		Name:       identity.GetName(),
+		Namespace:  identity.GetNamespace(),
+	}, nil
+}
+
+// reconcileIsolatedSecret copies the Secret of the ClusterIdentity in the system namespace.
+func (r *CredentialReconciler) reconcileIsolatedSecret(ctx context.Context, cred *hmc.Credential, sourceName, name string) error {
+	source := &corev1.Secret{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: r.SystemNamespace, Name: sourceName}, source); err != nil {
+		return fmt.Errorf("failed to get Secret %s/%s: %w", r.SystemNamespace, sourceName, err)
+	}
+
+	secret := &corev1.Secret{}
+	secret.SetName(name)
+	secret.SetNamespace(r.SystemNamespace)
+
+	if _, err := ctrl.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if err := checkIsolatedCopyOwner(secret, cred); err != nil {
+			return err
+		}
+		setIsolatedCopyMetadata(secret, cred)
+		secret.Type = source.Type
+		secret.Data = source.Data
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile isolated Secret %s/%s: %w", r.SystemNamespace, name, err)
+	}
+
+	return nil
+}
+
+// deleteIsolatedIdentity removes the isolated copy of the ClusterIdentity
+// referenced in the Credential status (if any).
+func (r *CredentialReconciler) deleteIsolatedIdentity(ctx context.Context, cred *hmc.Credential) error {
+	ref := cred.Status.IdentityRef
+	if ref == nil {
+		return nil
+	}
+
+	identity := &unstructured.Unstructured{}
+	identity.SetGroupVersionKind(schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind))
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, identity); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if identity.GetAnnotations()[hmc.IsolatedIdentityAnnotation] != credentialOwnerValue(cred) {
+		// not an isolated copy of the Credential
+		return nil
+	}
+
+	if ref.Kind == "VSphereClusterIdentity" {
+		secretName, _, _ := unstructured.NestedString(identity.Object, "spec", "secretName")
+		secret := &corev1.Secret{}
+		err := r.Client.Get(ctx, client.ObjectKey{Namespace: r.SystemNamespace, Name: secretName}, secret)
+		if client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to get Secret %s/%s: %w", r.SystemNamespace, secretName, err)
+		}
+		if err == nil && secret.Annotations[hmc.IsolatedIdentityAnnotation] == credentialOwnerValue(cred) {
+			if err := r.Client.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("failed to delete Secret %s/%s: %w", r.SystemNamespace, secretName, err)
+			}
+		}
+	}
+
+	if err := r.Client.Delete(ctx, identity); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete isolated %s %s: %w", ref.Kind, ref.Name, err)
+	}
+
+	ctrl.LoggerFrom(ctx).Info("Deleted isolated ClusterIdentity", "kind", ref.Kind, "name", ref.Name)
+	return nil
+}
+
+func checkIsolatedCopyOwner(obj client.Object, cred *hmc.Credential) error {
+	if obj.GetCreationTimestamp().Time.IsZero() {
+		return nil
+	}
+	if owner := obj.GetAnnotations()[hmc.IsolatedIdentityAnnotation]; owner != credentialOwnerValue(cred) {
+		return fmt.Errorf("%s already exists and is not an isolated copy for the Credential", obj.GetName())
+	}
+	return nil
+}
+
+func setIsolatedCopyMetadata(obj client.Object, cred *hmc.Credential) {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[hmc.HMCManagedLabelKey] = hmc.HMCManagedLabelValue
+	obj.SetLabels(labels)
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[hmc.IsolatedIdentityAnnotation] = credentialOwnerValue(cred)
+	obj.SetAnnotations(annotations)
+}
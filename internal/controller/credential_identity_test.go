@@ -0,0 +1,248 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+const testSystemNamespace = "hmc-system"
+
+func newSourceIdentity(apiVersion, kind, name, namespace string, spec map[string]any, namespaces string) *unstructured.Unstructured {
+	identity := &unstructured.Unstructured{Object: map[string]any{"spec": spec}}
+	identity.SetAPIVersion(apiVersion)
+	identity.SetKind(kind)
+	identity.SetName(name)
+	identity.SetNamespace(namespace)
+	identity.SetAnnotations(map[string]string{hmc.IdentityNamespacesAnnotation: namespaces})
+	return identity
+}
+
+func newIsolatingCredential(identity *unstructured.Unstructured) *hmc.Credential {
+	return &hmc.Credential{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: "cred"},
+		Spec: hmc.CredentialSpec{
+			IdentityRef: &corev1.ObjectReference{
+				APIVersion: identity.GetAPIVersion(),
+				Kind:       identity.GetKind(),
+				Name:       identity.GetName(),
+				Namespace:  identity.GetNamespace(),
+			},
+			IsolateIdentity: true,
+		},
+	}
+}
+
+func newCredentialClient(objs ...client.Object) client.Client {
+	return fake.NewClientBuilder().WithScheme(scheme.Scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&hmc.Credential{}).
+		WithIndex(&hmc.ManagedCluster{}, hmc.CredentialKey, hmc.ExtractCredentialName).
+		Build()
+}
+
+// reconcileCredential reconciles the Credential twice, adding the finalizer first.
+func reconcileCredential(ctx context.Context, g Gomega, r *CredentialReconciler, cred *hmc.Credential) error {
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cred)}
+	_, err := r.Reconcile(ctx, req)
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(r.Client.Get(ctx, req.NamespacedName, cred)).To(Succeed())
+	return err
+}
+
+func TestCredentialIsolatedIdentity(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		identity          *unstructured.Unstructured
+		allowedNamespaces map[string]any
+	}{
+		{
+			name: "aws",
+			identity: newSourceIdentity("infrastructure.cluster.x-k8s.io/v1beta2", "AWSClusterStaticIdentity", "aws", "",
+				map[string]any{"secretRef": "aws-secret"}, "other, tenant"),
+			allowedNamespaces: map[string]any{"list": []any{"tenant"}},
+		},
+		{
+			name: "azure",
+			identity: newSourceIdentity("infrastructure.cluster.x-k8s.io/v1beta1", "AzureClusterIdentity", "azure", testSystemNamespace,
+				map[string]any{"type": "ServicePrincipal", "clientID": "id"}, "tenant"),
+			allowedNamespaces: map[string]any{"namespaceList": []any{"tenant"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			cred := newIsolatingCredential(tc.identity)
+			r := &CredentialReconciler{
+				Client:            newCredentialClient(cred, tc.identity),
+				SystemNamespace:   testSystemNamespace,
+				IsolateIdentities: true,
+			}
+			g.Expect(reconcileCredential(ctx, g, r, cred)).To(Succeed())
+
+			g.Expect(cred.Status.State).To(Equal(hmc.CredentialReady))
+			g.Expect(cred.Status.IdentityRef).To(Equal(&corev1.ObjectReference{
+				APIVersion: tc.identity.GetAPIVersion(),
+				Kind:       tc.identity.GetKind(),
+				Name:       "tenant-cred",
+				Namespace:  tc.identity.GetNamespace(),
+			}))
+
+			isolated := &unstructured.Unstructured{}
+			isolated.SetGroupVersionKind(tc.identity.GroupVersionKind())
+			g.Expect(r.Client.Get(ctx, client.ObjectKey{Namespace: tc.identity.GetNamespace(), Name: "tenant-cred"}, isolated)).To(Succeed())
+			g.Expect(isolated.GetAnnotations()).To(HaveKeyWithValue(hmc.IsolatedIdentityAnnotation, "tenant/cred"))
+			g.Expect(isolated.Object["spec"]).To(HaveKeyWithValue("allowedNamespaces", tc.allowedNamespaces))
+
+			// the changes of the source identity are propagated to the copy
+			spec := tc.identity.Object["spec"].(map[string]any)
+			spec["updated"] = "true"
+			g.Expect(r.Client.Update(ctx, tc.identity)).To(Succeed())
+			g.Expect(reconcileCredential(ctx, g, r, cred)).To(Succeed())
+			g.Expect(r.Client.Get(ctx, client.ObjectKeyFromObject(isolated), isolated)).To(Succeed())
+			g.Expect(isolated.Object["spec"]).To(HaveKeyWithValue("updated", "true"))
+
+			// the copy is removed once the isolation is disabled
+			cred.Spec.IsolateIdentity = false
+			g.Expect(r.Client.Update(ctx, cred)).To(Succeed())
+			g.Expect(reconcileCredential(ctx, g, r, cred)).To(Succeed())
+			g.Expect(cred.Status.IdentityRef.Name).To(Equal(tc.identity.GetName()))
+			g.Expect(r.Client.Get(ctx, client.ObjectKeyFromObject(isolated), isolated)).NotTo(Succeed())
+		})
+	}
+}
+
+func TestCredentialIsolatedVSphereIdentity(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	identity := newSourceIdentity("infrastructure.cluster.x-k8s.io/v1beta1", "VSphereClusterIdentity", "vsphere", "",
+		map[string]any{"secretName": "vsphere-secret"}, "tenant")
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testSystemNamespace, Name: "vsphere-secret"},
+		Data:       map[string][]byte{"username": []byte("user"), "password": []byte("pass")},
+	}
+	cred := newIsolatingCredential(identity)
+	r := &CredentialReconciler{
+		Client:            newCredentialClient(cred, identity, secret),
+		SystemNamespace:   testSystemNamespace,
+		IsolateIdentities: true,
+	}
+	g.Expect(reconcileCredential(ctx, g, r, cred)).To(Succeed())
+	g.Expect(cred.Status.State).To(Equal(hmc.CredentialReady))
+
+	// the identity gets its own copy of the secret
+	isolated := &unstructured.Unstructured{}
+	isolated.SetGroupVersionKind(identity.GroupVersionKind())
+	g.Expect(r.Client.Get(ctx, client.ObjectKey{Name: "tenant-cred"}, isolated)).To(Succeed())
+	g.Expect(isolated.Object["spec"]).To(HaveKeyWithValue("secretName", "tenant-cred"))
+
+	copied := &corev1.Secret{}
+	g.Expect(r.Client.Get(ctx, client.ObjectKey{Namespace: testSystemNamespace, Name: "tenant-cred"}, copied)).To(Succeed())
+	g.Expect(copied.Data).To(Equal(secret.Data))
+	g.Expect(copied.Annotations).To(HaveKeyWithValue(hmc.IsolatedIdentityAnnotation, "tenant/cred"))
+
+	// both copies are removed along with the Credential
+	g.Expect(r.Client.Delete(ctx, cred)).To(Succeed())
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cred)})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(r.Client.Get(ctx, client.ObjectKeyFromObject(isolated), isolated)).NotTo(Succeed())
+	g.Expect(r.Client.Get(ctx, client.ObjectKeyFromObject(copied), copied)).NotTo(Succeed())
+	g.Expect(r.Client.Get(ctx, client.ObjectKeyFromObject(secret), secret)).To(Succeed())
+}
+
+func TestCredentialIsolationNotAllowed(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		enabled   bool
+		allowed   string
+		existing  bool
+		expectErr bool
+	}{
+		{name: "isolation disabled in the controller", allowed: "tenant"},
+		{name: "namespace not allowed", enabled: true, allowed: "other"},
+		{name: "copy not owned by the Credential", enabled: true, allowed: "tenant", existing: true, expectErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			identity := newSourceIdentity("infrastructure.cluster.x-k8s.io/v1beta2", "AWSClusterStaticIdentity", "aws", "",
+				map[string]any{"secretRef": "aws-secret"}, tc.allowed)
+			cred := newIsolatingCredential(identity)
+			objs := []client.Object{cred, identity}
+
+			existing := newSourceIdentity(identity.GetAPIVersion(), identity.GetKind(), "tenant-cred", "",
+				map[string]any{"secretRef": "foreign-secret"}, "")
+			existing.SetCreationTimestamp(metav1.Now())
+			if tc.existing {
+				objs = append(objs, existing)
+			}
+
+			r := &CredentialReconciler{
+				Client:            newCredentialClient(objs...),
+				SystemNamespace:   testSystemNamespace,
+				IsolateIdentities: tc.enabled,
+			}
+			err := reconcileCredential(ctx, g, r, cred)
+			if tc.expectErr {
+				g.Expect(err).To(MatchError(ContainSubstring("is not an isolated copy")))
+				// the foreign identity is left intact
+				g.Expect(r.Client.Get(ctx, client.ObjectKeyFromObject(existing), existing)).To(Succeed())
+				g.Expect(existing.Object["spec"]).To(HaveKeyWithValue("secretRef", "foreign-secret"))
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cred.Status.State).To(Equal(hmc.CredentialNotAllowed))
+			g.Expect(r.Client.Get(ctx, client.ObjectKeyFromObject(existing), existing)).NotTo(Succeed())
+		})
+	}
+}
+
+func TestMapIdentityToCredentials(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	identity := newSourceIdentity("infrastructure.cluster.x-k8s.io/v1beta2", "AWSClusterStaticIdentity", "aws", "", nil, "tenant")
+
+	isolating := newIsolatingCredential(identity)
+	shared := newIsolatingCredential(identity)
+	shared.Name = "shared"
+	shared.Spec.IsolateIdentity = false
+	other := newIsolatingCredential(identity)
+	other.Name = "other"
+	other.Spec.IdentityRef = other.Spec.IdentityRef.DeepCopy()
+	other.Spec.IdentityRef.Name = "other"
+
+	r := &CredentialReconciler{Client: newCredentialClient(isolating, shared, other)}
+	g.Expect(r.mapIdentityToCredentials(ctx, identity)).To(ConsistOf(
+		ctrl.Request{NamespacedName: client.ObjectKeyFromObject(isolating)},
+	))
+}
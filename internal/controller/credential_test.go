@@ -0,0 +1,86 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestCredentialDeleteWaitsForClusters(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	now := metav1.Now()
+	cred := &hmc.Credential{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "tenant",
+			Name:              "aws-cred",
+			Finalizers:        []string{hmc.CredentialFinalizer},
+			DeletionTimestamp: &now,
+		},
+		Spec: hmc.CredentialSpec{IsolateIdentity: true},
+		Status: hmc.CredentialStatus{IdentityRef: &corev1.ObjectReference{
+			APIVersion: "infrastructure.cluster.x-k8s.io/v1beta2",
+			Kind:       "AWSClusterStaticIdentity",
+			Name:       "aws-cred-tenant",
+		}},
+	}
+
+	identity := &unstructured.Unstructured{}
+	identity.SetAPIVersion(cred.Status.IdentityRef.APIVersion)
+	identity.SetKind(cred.Status.IdentityRef.Kind)
+	identity.SetName(cred.Status.IdentityRef.Name)
+	identity.SetAnnotations(map[string]string{hmc.IsolatedIdentityAnnotation: credentialOwnerValue(cred)})
+
+	cluster := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace(cred.Namespace))
+	cluster.Spec.Credential = cred.Name
+	other := managedcluster.NewManagedCluster(managedcluster.WithName("other"), managedcluster.WithNamespace(cred.Namespace))
+	other.Spec.Credential = "other-cred"
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+		WithObjects(cred, identity, cluster, other).
+		WithIndex(&hmc.ManagedCluster{}, hmc.CredentialKey, hmc.ExtractCredentialName).
+		Build()
+	r := &CredentialReconciler{Client: cl}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cred)}
+
+	// the isolated identity is kept while a cluster uses the Credential
+	result, err := r.Reconcile(ctx, req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.RequeueAfter).To(Equal(DefaultRequeueInterval))
+	g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(identity), identity)).To(Succeed())
+	g.Expect(cl.Get(ctx, req.NamespacedName, cred)).To(Succeed())
+
+	// the isolated identity is removed along with the Credential once the clusters are deleted
+	g.Expect(cl.Delete(ctx, cluster)).To(Succeed())
+	result, err = r.Reconcile(ctx, req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(ctrl.Result{}))
+	g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(identity), identity)).NotTo(Succeed())
+	g.Expect(cl.Get(ctx, req.NamespacedName, cred)).NotTo(Succeed())
+}
@@ -0,0 +1,59 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+// Reasons of the events emitted by the controllers.
+const (
+	// EventReasonValidationFailed is used when a template or its configuration is invalid.
+	EventReasonValidationFailed = "ValidationFailed"
+	// EventReasonValidationSucceeded is used when a template becomes valid.
+	EventReasonValidationSucceeded = "ValidationSucceeded"
+	// EventReasonChartDownloadFailed is used when a Helm chart can not be downloaded.
+	EventReasonChartDownloadFailed = "ChartDownloadFailed"
+	// EventReasonChartChecksumMismatch is used when a downloaded Helm chart does not match the digest of the artifact.
+	EventReasonChartChecksumMismatch = "ChartChecksumMismatch"
+	// EventReasonChartVerificationFailed is used when the signature of a Helm chart can not be verified.
+	EventReasonChartVerificationFailed = "ChartVerificationFailed"
+	// EventReasonCredentialNotReady is used when a Credential is missing or not ready.
+	EventReasonCredentialNotReady = "CredentialNotReady"
+	// EventReasonTemplateDeprecated is used when a ManagedCluster uses deprecated templates.
+	EventReasonTemplateDeprecated = "TemplateDeprecated"
+	// EventReasonUpgradeAvailable is used when new upgrades become available for a ManagedCluster.
+	EventReasonUpgradeAvailable = "UpgradeAvailable"
+	// EventReasonDeleting is used when the deletion of the resources of a ManagedCluster is in progress.
+	EventReasonDeleting = "Deleting"
+	// EventReasonDeleted is used when all of the resources of a ManagedCluster are deleted.
+	EventReasonDeleted = "Deleted"
+	// EventReasonLifecycleHooksPending is used when the ManagedCluster waits for the lifecycle hooks to complete.
+	EventReasonLifecycleHooksPending = "LifecycleHooksPending"
+	// EventReasonCleanupIncomplete is used when the provider objects of a deleted ManagedCluster are left behind.
+	EventReasonCleanupIncomplete = "CleanupIncomplete"
+	// EventReasonInstancesRemaining is used when the cloud instances of a deleted ManagedCluster are not removed yet.
+	EventReasonInstancesRemaining = "InstancesRemaining"
+	// EventReasonInstancesUnverified is used when the removal of the cloud instances of a deleted ManagedCluster cannot be verified.
+	EventReasonInstancesUnverified = "InstancesUnverified"
+	// EventReasonServicesDrainTimedOut is used when the services of a deleted ManagedCluster are not withdrawn in time.
+	EventReasonServicesDrainTimedOut = "ServicesDrainTimedOut"
+	// EventReasonPreDeleteCleanupTimedOut is used when the cloud resources of the workloads of a deleted ManagedCluster are not released in time.
+	EventReasonPreDeleteCleanupTimedOut = "PreDeleteCleanupTimedOut"
+	// EventReasonQuotaExceeded is used when the ManagedClusters of a namespace exceed the limits of a ClusterQuota.
+	EventReasonQuotaExceeded = "QuotaExceeded"
+	// EventReasonUpgradeHalted is used when an UpgradeGroup stops upgrading the clusters since too many of them failed.
+	EventReasonUpgradeHalted = "UpgradeHalted"
+	// EventReasonTemplateTestFailed is used when a run of a TemplateTest fails.
+	EventReasonTemplateTestFailed = "TemplateTestFailed"
+	// EventReasonImageNotFound is used when a machine image pinned in the node pools of a ManagedCluster is not available.
+	EventReasonImageNotFound = "ImageNotFound"
+)
@@ -0,0 +1,402 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/credspropagation"
+	"github.com/Mirantis/hmc/internal/errdefs"
+)
+
+// autoscalerService returns the service deploying cluster-autoscaler on the ManagedCluster.
+// The autoscaler discovers the worker pools of the cluster among the CAPI objects in the
+// namespace of the ManagedCluster through the kubeconfig of the management cluster, reached
+// through the proxy of the Management if set.
+func autoscalerService(mc *hmc.ManagedCluster, trust *resolvedTrust) (hmc.ServiceSpec, error) {
+	values := map[string]any{
+		"cloudProvider":              "clusterapi",
+		"clusterAPIMode":             "incluster-kubeconfig",
+		"clusterAPIKubeconfigSecret": hmc.AutoscalerKubeconfigSecretName,
+		"clusterAPICloudConfigPath":  hmc.AutoscalerKubeconfigPath,
+		"autoDiscovery": map[string]any{
+			"clusterName": mc.Name,
+			"namespace":   mc.Namespace,
+		},
+	}
+	if env := trust.env(); len(env) > 0 {
+		extraEnv := make(map[string]string, len(env))
+		for _, v := range env {
+			extraEnv[v.Name] = v.Value
+		}
+		values["extraEnv"] = extraEnv
+	}
+	if volumes, mounts := trust.volumes(); len(volumes) > 0 {
+		values["extraVolumes"] = volumes
+		values["extraVolumeMounts"] = mounts
+	}
+
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return hmc.ServiceSpec{}, fmt.Errorf("failed to marshal cluster-autoscaler values: %w", err)
+	}
+
+	merged, err := mergeServiceValues(&apiextensionsv1.JSON{Raw: raw}, mc.Spec.Autoscaler.Values)
+	if err != nil {
+		return hmc.ServiceSpec{}, fmt.Errorf("failed to merge cluster-autoscaler values: %w", err)
+	}
+
+	return hmc.ServiceSpec{
+		Values:    merged,
+		Template:  mc.Spec.Autoscaler.Template,
+		Name:      hmc.AutoscalerReleaseName,
+		Namespace: hmc.AutoscalerNamespace,
+	}, nil
+}
+
+const (
+	// autoscalerTokenExpiration is the lifetime of the tokens of cluster-autoscaler requested
+	// with the TokenRequest API, the token is rotated once half of its lifetime has passed.
+	autoscalerTokenExpiration = 24 * time.Hour
+	// autoscalerAccessResync is the interval the access of cluster-autoscaler is refreshed at to
+	// include the Machines created since. It is shorter than the time a node has to be unneeded
+	// before the autoscaler removes it, 10 minutes by default.
+	autoscalerAccessResync = 5 * time.Minute
+	// autoscalerTokenExpiresAtAnnotation is the annotation of the ServiceAccount of cluster-autoscaler
+	// recording the expiration time of the token written to the cluster.
+	autoscalerTokenExpiresAtAnnotation = "hmc.mirantis.com/token-expires-at"
+)
+
+// autoscalerScalableKinds are the kinds of the CAPI objects cluster-autoscaler scales, along with
+// the Machines it marks for deletion. The autoscaler of a cluster may only update the objects of the cluster.
+var autoscalerScalableKinds = []struct {
+	kind     string
+	resource string
+}{
+	{kind: "MachineDeployment", resource: "machinedeployments"},
+	{kind: "MachineSet", resource: "machinesets"},
+	{kind: "MachinePool", resource: "machinepools"},
+	{kind: "Machine", resource: "machines"},
+}
+
+// autoscalerAccountName returns the name of the ServiceAccount cluster-autoscaler of the cluster
+// accesses the management cluster with, it also names the RoleBinding to the ClusterRole of the autoscaler.
+func autoscalerAccountName(mc *hmc.ManagedCluster) string {
+	return mc.Name + "-cluster-autoscaler"
+}
+
+// autoscalerScalerName returns the name of the Role and the RoleBinding granting cluster-autoscaler
+// of the cluster the updates of the CAPI objects of the cluster.
+func autoscalerScalerName(mc *hmc.ManagedCluster) string {
+	return autoscalerAccountName(mc) + "-scaler"
+}
+
+// reconcileAutoscaler grants cluster-autoscaler of the cluster the access to the CAPI objects of the
+// cluster and writes the kubeconfig of the management cluster with a short-lived token to the cluster.
+// Returns the time the access has to be refreshed at, to rotate the token and to include the new
+// Machines. The access is revoked and the kubeconfig is removed once the autoscaler is disabled.
+func (r *ManagedClusterReconciler) reconcileAutoscaler(ctx context.Context, mc *hmc.ManagedCluster) (time.Time, error) {
+	if mc.Spec.Autoscaler == nil {
+		return time.Time{}, r.removeAutoscalerAccess(ctx, mc)
+	}
+
+	if r.AutoscalerClusterRole == "" {
+		return time.Time{}, errdefs.Terminal(errors.New("the ClusterRole of cluster-autoscaler is not configured"))
+	}
+	if !slices.Contains(r.AutoscalerNamespaces, mc.Namespace) {
+		return time.Time{}, errdefs.Terminal(fmt.Errorf("cluster-autoscaler may not be enabled in namespace %s, the autoscaler namespaces are %v", mc.Namespace, r.AutoscalerNamespaces))
+	}
+
+	account, err := r.ensureAutoscalerAccess(ctx, mc)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	refreshAt := time.Now().Add(autoscalerAccessResync)
+	if expiresAt, err := time.Parse(time.RFC3339, account.Annotations[autoscalerTokenExpiresAtAnnotation]); err == nil &&
+		time.Until(expiresAt) > autoscalerTokenExpiration/2 {
+		return earliest(refreshAt, expiresAt.Add(-autoscalerTokenExpiration/2)), nil
+	}
+
+	expiresAt, err := r.writeAutoscalerKubeconfig(ctx, mc, account)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// the expiration is recorded once the token is written, the token is requested again otherwise
+	patch := client.MergeFrom(account.DeepCopy())
+	if account.Annotations == nil {
+		account.Annotations = make(map[string]string)
+	}
+	account.Annotations[autoscalerTokenExpiresAtAnnotation] = expiresAt.UTC().Format(time.RFC3339)
+	if err := r.Patch(ctx, account, patch); err != nil {
+		return time.Time{}, fmt.Errorf("failed to record the token expiration of ServiceAccount %s/%s: %w", account.Namespace, account.Name, err)
+	}
+	return earliest(refreshAt, expiresAt.Add(-autoscalerTokenExpiration/2)), nil
+}
+
+// writeAutoscalerKubeconfig requests a token of the ServiceAccount of cluster-autoscaler and writes the
+// kubeconfig of the management cluster with the token to the cluster. Returns the expiration time of the token.
+func (r *ManagedClusterReconciler) writeAutoscalerKubeconfig(ctx context.Context, mc *hmc.ManagedCluster, account *corev1.ServiceAccount) (time.Time, error) {
+	// the CA bundle of the API server is published in every namespace
+	rootCA := &corev1.ConfigMap{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: mc.Namespace, Name: "kube-root-ca.crt"}, rootCA); err != nil {
+		err = fmt.Errorf("failed to get the CA bundle of the management cluster: %w", err)
+		if apierrors.IsNotFound(err) {
+			return time.Time{}, errdefs.Waiting(err)
+		}
+		return time.Time{}, err
+	}
+
+	request := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: ptr.To(int64(autoscalerTokenExpiration.Seconds()))},
+	}
+	if err := r.SubResource("token").Create(ctx, account, request); err != nil {
+		return time.Time{}, fmt.Errorf("failed to request the token of ServiceAccount %s/%s: %w", account.Namespace, account.Name, err)
+	}
+
+	config := clientcmdapi.NewConfig()
+	config.Clusters["management"] = &clientcmdapi.Cluster{
+		Server:                   mc.Spec.Autoscaler.ManagementEndpoint,
+		CertificateAuthorityData: []byte(rootCA.Data[corev1.ServiceAccountRootCAKey]),
+	}
+	config.AuthInfos["cluster-autoscaler"] = &clientcmdapi.AuthInfo{Token: request.Status.Token}
+	config.Contexts["cluster-autoscaler@management"] = &clientcmdapi.Context{Cluster: "management", AuthInfo: "cluster-autoscaler"}
+	config.CurrentContext = "cluster-autoscaler@management"
+	kubeconfig, err := clientcmd.Write(*config)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to write kubeconfig of cluster-autoscaler: %w", err)
+	}
+
+	kubeconfSecret, err := r.getKubeconfigSecret(ctx, mc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := credspropagation.PropagateAutoscalerKubeconfig(ctx, &credspropagation.PropagationCfg{
+		Client:          r.Client,
+		ManagedCluster:  mc,
+		KubeconfSecret:  kubeconfSecret,
+		SystemNamespace: r.SystemNamespace,
+		SecretWriter:    r.secretWriter(),
+	}, kubeconfig); err != nil {
+		return time.Time{}, err
+	}
+	return request.Status.ExpirationTimestamp.Time, nil
+}
+
+// ensureAutoscalerAccess creates the ServiceAccount of cluster-autoscaler bound to the read-only ClusterRole
+// of the autoscaler in the namespace of the ManagedCluster and to the Role granting the updates of the CAPI
+// objects of the cluster only, and returns the ServiceAccount. The objects of the same names not owned by
+// the ManagedCluster are never taken over.
+func (r *ManagedClusterReconciler) ensureAutoscalerAccess(ctx context.Context, mc *hmc.ManagedCluster) (*corev1.ServiceAccount, error) {
+	name, scalerName := autoscalerAccountName(mc), autoscalerScalerName(mc)
+
+	account := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: mc.Namespace}}
+	if err := r.writeAutoscalerObject(ctx, mc, account, func() {}); err != nil {
+		return nil, err
+	}
+
+	subjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: name, Namespace: mc.Namespace}}
+	binding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: mc.Namespace}}
+	if err := r.writeAutoscalerObject(ctx, mc, binding, func() {
+		binding.RoleRef = rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: r.AutoscalerClusterRole}
+		binding.Subjects = subjects
+	}); err != nil {
+		return nil, err
+	}
+
+	rules, err := r.autoscalerScalerRules(ctx, mc)
+	if err != nil {
+		return nil, err
+	}
+	role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: scalerName, Namespace: mc.Namespace}}
+	if err := r.writeAutoscalerObject(ctx, mc, role, func() {
+		role.Rules = rules
+	}); err != nil {
+		return nil, err
+	}
+
+	scalerBinding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: scalerName, Namespace: mc.Namespace}}
+	if err := r.writeAutoscalerObject(ctx, mc, scalerBinding, func() {
+		scalerBinding.RoleRef = rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: scalerName}
+		scalerBinding.Subjects = subjects
+	}); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// autoscalerScalerRules returns the rules granting the updates of the CAPI objects of the cluster
+// by name, the kinds of the CAPI objects not installed or without the objects of the cluster are skipped.
+func (r *ManagedClusterReconciler) autoscalerScalerRules(ctx context.Context, mc *hmc.ManagedCluster) ([]rbacv1.PolicyRule, error) {
+	var rules []rbacv1.PolicyRule
+	for _, scalable := range autoscalerScalableKinds {
+		objects := &metav1.PartialObjectMetadataList{}
+		objects.SetGroupVersionKind(capiClusterGVK.GroupVersion().WithKind(scalable.kind + "List"))
+		err := r.List(ctx, objects, client.InNamespace(mc.Namespace), client.MatchingLabels{hmc.ClusterNameLabelKey: mc.Name})
+		if apimeta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %ss of ManagedCluster %s/%s: %w", scalable.kind, mc.Namespace, mc.Name, err)
+		}
+		if len(objects.Items) == 0 {
+			// the rule without the resource names would grant the access to all of the objects
+			continue
+		}
+
+		names := make([]string, 0, len(objects.Items))
+		for _, obj := range objects.Items {
+			names = append(names, obj.Name)
+		}
+		slices.Sort(names)
+
+		resources := []string{scalable.resource}
+		if scalable.kind != "Machine" {
+			resources = append(resources, scalable.resource+"/scale")
+		}
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups:     []string{capiClusterGVK.Group},
+			Resources:     resources,
+			ResourceNames: names,
+			Verbs:         []string{"patch", "update"},
+		})
+	}
+	return rules, nil
+}
+
+// writeAutoscalerObject creates or updates the object of the access of cluster-autoscaler owned by the
+// ManagedCluster. The existing object not owned by the ManagedCluster is reported and left intact.
+func (r *ManagedClusterReconciler) writeAutoscalerObject(ctx context.Context, mc *hmc.ManagedCluster, obj client.Object, mutate func()) error {
+	_, err := ctrl.CreateOrUpdate(ctx, r.Client, obj, func() error {
+		if obj.GetResourceVersion() != "" && !ownedByCluster(obj, mc) {
+			return errdefs.Terminal(fmt.Errorf("%T %s/%s already exists and is not owned by the ManagedCluster", obj, obj.GetNamespace(), obj.GetName()))
+		}
+		obj.SetOwnerReferences([]metav1.OwnerReference{{
+			APIVersion: hmc.GroupVersion.String(),
+			Kind:       hmc.ManagedClusterKind,
+			Name:       mc.Name,
+			UID:        mc.UID,
+		}})
+		mutate()
+		return nil
+	})
+	if err != nil && !errdefs.IsTerminal(err) {
+		return fmt.Errorf("failed to write %T %s/%s: %w", obj, obj.GetNamespace(), obj.GetName(), err)
+	}
+	return err
+}
+
+// ownedByCluster returns true if the object is owned by the ManagedCluster.
+func ownedByCluster(obj client.Object, mc *hmc.ManagedCluster) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID == mc.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// earliest returns the earliest of the times.
+func earliest(t time.Time, times ...time.Time) time.Time {
+	for _, other := range times {
+		if other.Before(t) {
+			t = other
+		}
+	}
+	return t
+}
+
+// requeueBefore returns the result requeued no later than at the given time, the result is kept if the time is zero.
+func requeueBefore(result ctrl.Result, at time.Time) ctrl.Result {
+	if at.IsZero() {
+		return result
+	}
+	if after := max(time.Until(at), time.Second); result.RequeueAfter == 0 || after < result.RequeueAfter {
+		result.RequeueAfter = after
+	}
+	return result
+}
+
+// removeAutoscalerAccess removes the access of cluster-autoscaler to the management cluster
+// and the kubeconfig written to the cluster if the ServiceAccount of the autoscaler owned by
+// the ManagedCluster exists.
+func (r *ManagedClusterReconciler) removeAutoscalerAccess(ctx context.Context, mc *hmc.ManagedCluster) error {
+	account := &corev1.ServiceAccount{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: mc.Namespace, Name: autoscalerAccountName(mc)}, account); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get ServiceAccount %s/%s: %w", mc.Namespace, autoscalerAccountName(mc), err)
+	}
+	if !ownedByCluster(account, mc) {
+		return nil
+	}
+
+	kubeconfSecret, err := r.getKubeconfigSecret(ctx, mc)
+	if err != nil {
+		return err
+	}
+	if err := credspropagation.RemoveAutoscalerKubeconfig(ctx, &credspropagation.PropagationCfg{
+		Client:          r.Client,
+		ManagedCluster:  mc,
+		KubeconfSecret:  kubeconfSecret,
+		SystemNamespace: r.SystemNamespace,
+		SecretWriter:    r.secretWriter(),
+	}); err != nil {
+		return err
+	}
+
+	scalerName := autoscalerScalerName(mc)
+	for _, obj := range []client.Object{
+		&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: scalerName, Namespace: mc.Namespace}},
+		&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: scalerName, Namespace: mc.Namespace}},
+		&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: account.Name, Namespace: mc.Namespace}},
+		account,
+	} {
+		if err := r.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to get %T %s/%s: %w", obj, mc.Namespace, obj.GetName(), err)
+		}
+		if !ownedByCluster(obj, mc) {
+			continue
+		}
+		if err := r.Client.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete %T %s/%s: %w", obj, mc.Namespace, obj.GetName(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/errdefs"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+)
+
+// newAutoscalerScheme returns the scheme with the CAPI kinds scaled by cluster-autoscaler.
+func newAutoscalerScheme(g Gomega) *runtime.Scheme {
+	autoscalerScheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(autoscalerScheme)).To(Succeed())
+	g.Expect(hmc.AddToScheme(autoscalerScheme)).To(Succeed())
+	for _, scalable := range autoscalerScalableKinds {
+		gvk := capiClusterGVK.GroupVersion().WithKind(scalable.kind)
+		autoscalerScheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+		autoscalerScheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind(scalable.kind+"List"), &unstructured.UnstructuredList{})
+	}
+	return autoscalerScheme
+}
+
+func TestReconcileAutoscaler(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	autoscalerScheme := newAutoscalerScheme(g)
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("team-a"))
+	mc.UID = types.UID("dev-uid")
+	mc.Spec.Autoscaler = &hmc.ClusterAutoscalerSpec{
+		Template:           "cluster-autoscaler-9-43-2",
+		ManagementEndpoint: "https://management.example.com:6443",
+	}
+	newCAPIObject := func(kind, name, cluster string) client.Object {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(capiClusterGVK.GroupVersion().WithKind(kind))
+		obj.SetNamespace(mc.Namespace)
+		obj.SetName(name)
+		obj.SetLabels(map[string]string{hmc.ClusterNameLabelKey: cluster})
+		return obj
+	}
+	ownedAccount := func(expiresAt time.Time) *corev1.ServiceAccount {
+		return &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+			Namespace:       mc.Namespace,
+			Name:            autoscalerAccountName(mc),
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: hmc.GroupVersion.String(), Kind: hmc.ManagedClusterKind, Name: mc.Name, UID: mc.UID}},
+			Annotations:     map[string]string{autoscalerTokenExpiresAtAnnotation: expiresAt.UTC().Format(time.RFC3339)},
+		}}
+	}
+	rootCA := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: mc.Namespace, Name: "kube-root-ca.crt"},
+		Data:       map[string]string{corev1.ServiceAccountRootCAKey: "ca"},
+	}
+	objects := []client.Object{
+		rootCA,
+		newCAPIObject("MachineDeployment", "dev-md", mc.Name),
+		newCAPIObject("MachineDeployment", "prod-md", "prod"),
+		newCAPIObject("MachineSet", "dev-md-abc", mc.Name),
+		newCAPIObject("Machine", "dev-md-abc-1", mc.Name),
+		newCAPIObject("Machine", "dev-md-abc-0", mc.Name),
+	}
+
+	var tokenRequests []*authenticationv1.TokenRequest
+	newClient := func(objects ...client.Object) client.Client {
+		return fake.NewClientBuilder().WithScheme(autoscalerScheme).WithObjects(objects...).WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceCreate: func(_ context.Context, _ client.Client, subResource string, _, subResourceObj client.Object, _ ...client.SubResourceCreateOption) error {
+				g.Expect(subResource).To(Equal("token"))
+				request := subResourceObj.(*authenticationv1.TokenRequest)
+				tokenRequests = append(tokenRequests, request.DeepCopy())
+				request.Status = authenticationv1.TokenRequestStatus{
+					Token:               "token",
+					ExpirationTimestamp: metav1.NewTime(time.Now().Add(time.Duration(*request.Spec.ExpirationSeconds) * time.Second)),
+				}
+				return nil
+			},
+		}).Build()
+	}
+
+	// the token valid for more than half of its lifetime is kept, the access is refreshed to include the new Machines
+	cl := newClient(append(objects, ownedAccount(time.Now().Add(20*time.Hour)))...)
+	r := &ManagedClusterReconciler{Client: cl, AutoscalerClusterRole: "hmc-cluster-autoscaler-role", AutoscalerNamespaces: []string{"team-a"}}
+	refreshAt, err := r.reconcileAutoscaler(ctx, mc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(refreshAt).To(BeTemporally("~", time.Now().Add(autoscalerAccessResync), time.Minute))
+	g.Expect(tokenRequests).To(BeEmpty())
+
+	binding := &rbacv1.RoleBinding{}
+	g.Expect(cl.Get(ctx, client.ObjectKey{Namespace: mc.Namespace, Name: autoscalerAccountName(mc)}, binding)).To(Succeed())
+	g.Expect(binding.RoleRef).To(Equal(rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "hmc-cluster-autoscaler-role"}))
+
+	// the updates are granted for the objects of the cluster only, the kinds without the objects are skipped
+	role := &rbacv1.Role{}
+	g.Expect(cl.Get(ctx, client.ObjectKey{Namespace: mc.Namespace, Name: autoscalerScalerName(mc)}, role)).To(Succeed())
+	g.Expect(role.OwnerReferences).To(ConsistOf(HaveField("UID", mc.UID)))
+	g.Expect(role.Rules).To(Equal([]rbacv1.PolicyRule{
+		{
+			APIGroups:     []string{capiClusterGVK.Group},
+			Resources:     []string{"machinedeployments", "machinedeployments/scale"},
+			ResourceNames: []string{"dev-md"},
+			Verbs:         []string{"patch", "update"},
+		},
+		{
+			APIGroups:     []string{capiClusterGVK.Group},
+			Resources:     []string{"machinesets", "machinesets/scale"},
+			ResourceNames: []string{"dev-md-abc"},
+			Verbs:         []string{"patch", "update"},
+		},
+		{
+			APIGroups:     []string{capiClusterGVK.Group},
+			Resources:     []string{"machines"},
+			ResourceNames: []string{"dev-md-abc-0", "dev-md-abc-1"},
+			Verbs:         []string{"patch", "update"},
+		},
+	}))
+	scalerBinding := &rbacv1.RoleBinding{}
+	g.Expect(cl.Get(ctx, client.ObjectKey{Namespace: mc.Namespace, Name: autoscalerScalerName(mc)}, scalerBinding)).To(Succeed())
+	g.Expect(scalerBinding.RoleRef).To(Equal(rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: autoscalerScalerName(mc)}))
+	g.Expect(scalerBinding.Subjects).To(Equal([]rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: autoscalerAccountName(mc), Namespace: mc.Namespace}}))
+
+	// the token is rotated once half of its lifetime has passed, the expiration is recorded once the token is written
+	expiresAt := time.Now().Add(6 * time.Hour)
+	cl = newClient(append(objects, ownedAccount(expiresAt))...)
+	r.Client = cl
+	_, err = r.reconcileAutoscaler(ctx, mc)
+	g.Expect(err).To(MatchError(ContainSubstring("failed to get kubeconfig secret for cluster team-a/dev")))
+	g.Expect(tokenRequests).To(HaveLen(1))
+	g.Expect(tokenRequests[0].Spec.ExpirationSeconds).To(Equal(ptr.To(int64(24 * 60 * 60))))
+	account := &corev1.ServiceAccount{}
+	g.Expect(cl.Get(ctx, client.ObjectKey{Namespace: mc.Namespace, Name: autoscalerAccountName(mc)}, account)).To(Succeed())
+	g.Expect(account.Annotations).To(HaveKeyWithValue(autoscalerTokenExpiresAtAnnotation, expiresAt.UTC().Format(time.RFC3339)))
+
+	// the token is not requested until the CA bundle of the management cluster is published
+	tokenRequests = nil
+	r.Client = newClient(objects[1:]...)
+	_, err = r.reconcileAutoscaler(ctx, mc)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(errdefs.IsWaiting(err)).To(BeTrue())
+	g.Expect(tokenRequests).To(BeEmpty())
+}
+
+func TestReconcileAutoscalerNotOwned(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("team-a"))
+	mc.UID = types.UID("dev-uid")
+	mc.Spec.Autoscaler = &hmc.ClusterAutoscalerSpec{
+		Template:           "cluster-autoscaler-9-43-2",
+		ManagementEndpoint: "https://management.example.com:6443",
+	}
+
+	// the Role of the same name created by someone else is never taken over
+	foreign := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{Namespace: mc.Namespace, Name: autoscalerScalerName(mc)},
+		Rules:      []rbacv1.PolicyRule{{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}},
+	}
+	cl := fake.NewClientBuilder().WithScheme(newAutoscalerScheme(g)).WithObjects(foreign).Build()
+
+	// nothing is written outside of the autoscaler namespaces the controller is granted the access in
+	r := &ManagedClusterReconciler{Client: cl, AutoscalerClusterRole: "hmc-cluster-autoscaler-role", AutoscalerNamespaces: []string{"team-b"}}
+	_, err := r.reconcileAutoscaler(ctx, mc)
+	g.Expect(err).To(MatchError("cluster-autoscaler may not be enabled in namespace team-a, the autoscaler namespaces are [team-b]"))
+	g.Expect(errdefs.IsTerminal(err)).To(BeTrue())
+	g.Expect(cl.Get(ctx, client.ObjectKey{Namespace: mc.Namespace, Name: autoscalerAccountName(mc)}, &corev1.ServiceAccount{})).
+		To(MatchError(ContainSubstring("not found")))
+
+	r.AutoscalerNamespaces = []string{"team-a"}
+	_, err = r.reconcileAutoscaler(ctx, mc)
+	g.Expect(err).To(MatchError(ContainSubstring("*v1.Role team-a/dev-cluster-autoscaler-scaler already exists and is not owned by the ManagedCluster")))
+	g.Expect(errdefs.IsTerminal(err)).To(BeTrue())
+
+	role := &rbacv1.Role{}
+	g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(foreign), role)).To(Succeed())
+	g.Expect(role.OwnerReferences).To(BeEmpty())
+	g.Expect(role.Rules).To(Equal(foreign.Rules))
+
+	// the ServiceAccount not owned by the cluster is left intact once the autoscaler is disabled
+	account := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: mc.Namespace, Name: autoscalerAccountName(mc)}}
+	cl = fake.NewClientBuilder().WithScheme(newAutoscalerScheme(g)).WithObjects(account).Build()
+	r.Client = cl
+	mc.Spec.Autoscaler = nil
+	_, err = r.reconcileAutoscaler(ctx, mc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(account), &corev1.ServiceAccount{})).To(Succeed())
+}
+
+func TestRequeueBefore(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(requeueBefore(ctrl.Result{RequeueAfter: time.Minute}, time.Time{})).To(Equal(ctrl.Result{RequeueAfter: time.Minute}))
+	g.Expect(requeueBefore(ctrl.Result{RequeueAfter: time.Minute}, time.Now().Add(time.Hour))).To(Equal(ctrl.Result{RequeueAfter: time.Minute}))
+	g.Expect(requeueBefore(ctrl.Result{}, time.Now().Add(time.Hour)).RequeueAfter).To(BeNumerically("~", time.Hour, time.Second))
+	g.Expect(requeueBefore(ctrl.Result{RequeueAfter: time.Hour}, time.Now().Add(time.Minute)).RequeueAfter).To(BeNumerically("~", time.Minute, time.Second))
+	// the refresh due is requeued shortly
+	g.Expect(requeueBefore(ctrl.Result{}, time.Now().Add(-time.Minute))).To(Equal(ctrl.Result{RequeueAfter: time.Second}))
+}
@@ -0,0 +1,125 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/velero"
+)
+
+// backupService returns the service deploying the backup agent configured
+// with the backup schedule of the ManagedCluster. The agent chart is
+// expected to accept the values of the Velero chart.
+func backupService(backup *hmc.ManagedClusterBackupSpec) (hmc.ServiceSpec, error) {
+	values := map[string]any{}
+	if backup.Values != nil && len(backup.Values.Raw) > 0 {
+		if err := json.Unmarshal(backup.Values.Raw, &values); err != nil {
+			return hmc.ServiceSpec{}, fmt.Errorf("failed to unmarshal backup values: %w", err)
+		}
+	}
+
+	template := map[string]any{}
+	if backup.TTL.Duration > 0 {
+		template["ttl"] = backup.TTL.Duration.String()
+	}
+	if len(backup.IncludedNamespaces) > 0 {
+		template["includedNamespaces"] = backup.IncludedNamespaces
+	}
+
+	schedules, _ := values["schedules"].(map[string]any)
+	if schedules == nil {
+		schedules = map[string]any{}
+	}
+	schedules[hmc.ManagedClusterBackupScheduleName] = map[string]any{
+		"disabled": false,
+		"schedule": backup.Schedule,
+		"template": template,
+	}
+	values["schedules"] = schedules
+
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return hmc.ServiceSpec{}, fmt.Errorf("failed to marshal backup values: %w", err)
+	}
+
+	return hmc.ServiceSpec{
+		Values:    &apiextensionsv1.JSON{Raw: raw},
+		Template:  backup.Template,
+		Name:      hmc.ManagedClusterBackupReleaseName,
+		Namespace: backupNamespace(backup),
+	}, nil
+}
+
+func backupNamespace(backup *hmc.ManagedClusterBackupSpec) string {
+	if backup.Namespace == "" {
+		return hmc.ManagedClusterBackupDefaultNamespace
+	}
+	return backup.Namespace
+}
+
+// updateBackupStatus collects the state of the backups performed on the
+// ManagedCluster. The errors are reported in the backup status only since
+// the backups do not affect the readiness of the cluster.
+func (r *ManagedClusterReconciler) updateBackupStatus(ctx context.Context, mc *hmc.ManagedCluster) {
+	if mc.Spec.Backup == nil {
+		mc.Status.Backup = nil
+		return
+	}
+
+	if mc.Status.Backup == nil {
+		mc.Status.Backup = &hmc.ManagedClusterBackupStatus{}
+	}
+
+	backups, err := r.listClusterBackups(ctx, mc)
+	if err != nil {
+		mc.Status.Backup.Error = err.Error()
+		return
+	}
+
+	mc.Status.Backup.Error = ""
+	mc.Status.Backup.LastBackup, mc.Status.Backup.LastSuccessfulBackup = latestBackups(backups)
+	if len(backups) > 0 {
+		mc.Status.Backup.Error = velero.FailureMessage(&backups[0])
+	}
+}
+
+// listClusterBackups returns the Velero Backups created on the ManagedCluster
+// by the backup schedule, the most recent first.
+func (r *ManagedClusterReconciler) listClusterBackups(ctx context.Context, mc *hmc.ManagedCluster) ([]unstructured.Unstructured, error) {
+	cl, err := r.getClusterClient(ctx, mc)
+	if err != nil {
+		return nil, err
+	}
+
+	backups, err := velero.ListBackups(ctx, cl, backupNamespace(mc.Spec.Backup), client.HasLabels{velero.ScheduleNameLabelKey})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Velero Backups on cluster %s/%s: %w", mc.Namespace, mc.Name, err)
+	}
+
+	// the name of the Schedule is prefixed with the release name by the chart
+	return slices.DeleteFunc(backups, func(b unstructured.Unstructured) bool {
+		return !strings.HasSuffix(b.GetLabels()[velero.ScheduleNameLabelKey], hmc.ManagedClusterBackupScheduleName)
+	}), nil
+}
@@ -0,0 +1,167 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// preDeleteCleanup removes the LoadBalancer Services and the PersistentVolumeClaims from
+// the cluster and returns true once the cloud resources are released or the cleanup
+// timed out. The cleanup is skipped unless it is enabled for the ManagedCluster.
+func (r *ManagedClusterReconciler) preDeleteCleanup(ctx context.Context, managedCluster *hmc.ManagedCluster) bool {
+	if managedCluster.Spec.PreDeleteCleanup == nil {
+		return true
+	}
+
+	waitingMessage := ""
+	remaining, err := r.cleanupCloudResources(ctx, managedCluster)
+	switch {
+	case err != nil:
+		ctrl.LoggerFrom(ctx).Info("Failed to clean up the cloud resources of the cluster", "error", err.Error())
+		waitingMessage = "Failed to clean up the cloud resources: " + err.Error()
+	case len(remaining) > 0:
+		waitingMessage = "Waiting for the objects to be removed: " + strings.Join(remaining, ", ")
+	}
+
+	done, timedOut := setTeardownStepCondition(managedCluster, hmc.CloudResourcesCleanedCondition, managedCluster.PreDeleteCleanupTimeout(),
+		"The cloud resources of the workloads are released", waitingMessage, waitingMessage == "")
+	if timedOut {
+		r.Recorder.Eventf(managedCluster, corev1.EventTypeWarning, EventReasonPreDeleteCleanupTimedOut,
+			"The cloud resources of the workloads are not released in %s, tearing the cluster down", managedCluster.PreDeleteCleanupTimeout())
+	}
+	return done
+}
+
+// cleanupCloudResources deletes the LoadBalancer Services and the released PersistentVolumeClaims
+// of the cluster and returns the objects not removed yet. The objects are removed by the
+// cloud controllers and the CSI drivers only once the load balancers and the disks are
+// released, the PersistentVolumes bound to the deleted claims are waited for as well.
+// The claims mounted by the Pods are protected from the removal until the Pods are
+// gone, they are left with the cluster instead of stalling the deletion.
+func (r *ManagedClusterReconciler) cleanupCloudResources(ctx context.Context, managedCluster *hmc.ManagedCluster) ([]string, error) {
+	cl, err := r.getClusterClient(ctx, managedCluster)
+	if err != nil {
+		return nil, err
+	}
+
+	var remaining []string
+
+	if !managedCluster.Spec.PreDeleteCleanup.SkipLoadBalancers {
+		services := &corev1.ServiceList{}
+		if err := cl.List(ctx, services); err != nil {
+			return nil, fmt.Errorf("failed to list Services: %w", err)
+		}
+		for _, service := range services.Items {
+			if service.Spec.Type != corev1.ServiceTypeLoadBalancer {
+				continue
+			}
+			if err := deleteWorkloadObject(ctx, cl, "Service", &service); err != nil {
+				return nil, err
+			}
+			remaining = append(remaining, "Service "+service.Namespace+"/"+service.Name)
+		}
+	}
+
+	if !managedCluster.Spec.PreDeleteCleanup.SkipVolumes {
+		mounted, err := mountedClaims(ctx, cl)
+		if err != nil {
+			return nil, err
+		}
+
+		claims := &corev1.PersistentVolumeClaimList{}
+		if err := cl.List(ctx, claims); err != nil {
+			return nil, fmt.Errorf("failed to list PersistentVolumeClaims: %w", err)
+		}
+		released := make(map[string]bool, len(claims.Items))
+		var inUse []string
+		for _, claim := range claims.Items {
+			key := claim.Namespace + "/" + claim.Name
+			if mounted[key] {
+				inUse = append(inUse, key)
+				continue
+			}
+			if err := deleteWorkloadObject(ctx, cl, "PersistentVolumeClaim", &claim); err != nil {
+				return nil, err
+			}
+			released[key] = true
+			remaining = append(remaining, "PersistentVolumeClaim "+key)
+		}
+		if len(inUse) > 0 {
+			ctrl.LoggerFrom(ctx).Info("Leaving the PersistentVolumeClaims mounted by the Pods", "claims", inUse)
+		}
+
+		volumes := &corev1.PersistentVolumeList{}
+		if err := cl.List(ctx, volumes); err != nil {
+			return nil, fmt.Errorf("failed to list PersistentVolumes: %w", err)
+		}
+		for _, volume := range volumes.Items {
+			// the volumes retained by the policy outlive the cluster on purpose
+			if volume.Spec.PersistentVolumeReclaimPolicy != corev1.PersistentVolumeReclaimDelete || volume.Spec.ClaimRef == nil {
+				continue
+			}
+			claimRef := volume.Spec.ClaimRef
+			if released[claimRef.Namespace+"/"+claimRef.Name] || volume.Status.Phase == corev1.VolumeReleased {
+				remaining = append(remaining, "PersistentVolume "+volume.Name)
+			}
+		}
+	}
+
+	return remaining, nil
+}
+
+// mountedClaims returns the namespace/name of the PersistentVolumeClaims mounted by the not terminated Pods.
+func mountedClaims(ctx context.Context, cl client.Client) (map[string]bool, error) {
+	pods := &corev1.PodList{}
+	if err := cl.List(ctx, pods); err != nil {
+		return nil, fmt.Errorf("failed to list Pods: %w", err)
+	}
+
+	mounted := make(map[string]bool)
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, volume := range pod.Spec.Volumes {
+			switch {
+			case volume.PersistentVolumeClaim != nil:
+				mounted[pod.Namespace+"/"+volume.PersistentVolumeClaim.ClaimName] = true
+			case volume.Ephemeral != nil:
+				// the ephemeral claims are named after the Pod and the volume and go away with the Pod
+				mounted[pod.Namespace+"/"+pod.Name+"-"+volume.Name] = true
+			}
+		}
+	}
+	return mounted, nil
+}
+
+// deleteWorkloadObject deletes the object of the workloads of the cluster unless it is being deleted already.
+func deleteWorkloadObject(ctx context.Context, cl client.Client, kind string, obj client.Object) error {
+	if !obj.GetDeletionTimestamp().IsZero() {
+		return nil
+	}
+	if err := client.IgnoreNotFound(cl.Delete(ctx, obj)); err != nil {
+		return fmt.Errorf("failed to delete %s %s: %w", kind, client.ObjectKeyFromObject(obj), err)
+	}
+	return nil
+}
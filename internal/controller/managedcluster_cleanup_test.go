@@ -0,0 +1,118 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestPreDeleteCleanup(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"))
+	mc.Spec.PreDeleteCleanup = &hmc.PreDeleteCleanupSpec{}
+	kubeconfig := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: mc.Namespace, Name: mc.Name + "-kubeconfig"},
+		Data:       map[string][]byte{"value": testKubeconfig("https://10.0.0.1:6443")},
+	}
+
+	claimVolume := func(name string) corev1.Volume {
+		return corev1.Volume{Name: name, VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: name},
+		}}
+	}
+	pod := func(name string, phase corev1.PodPhase, volumes ...corev1.Volume) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec:       corev1.PodSpec{Volumes: volumes},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	claim := func(name string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}}
+	}
+	volume := func(claimName string) *corev1.PersistentVolume {
+		return &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-" + claimName},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete,
+				ClaimRef:                      &corev1.ObjectReference{Namespace: "default", Name: claimName},
+			},
+		}
+	}
+
+	workloads := fake.NewClientBuilder().WithObjects(
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "lb"}, Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "internal"}, Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP}},
+		pod("db-0", corev1.PodRunning, claimVolume("data")),
+		pod("job", corev1.PodSucceeded, claimVolume("logs")),
+		claim("data"), claim("logs"), claim("cache"),
+		volume("data"), volume("cache"),
+	).Build()
+
+	created := 0
+	r := &ManagedClusterReconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(kubeconfig).Build(),
+		Recorder: record.NewFakeRecorder(10),
+		clusterClients: clusterClients{newClient: func(*rest.Config) (client.Client, error) {
+			created++
+			return workloads, nil
+		}},
+	}
+
+	// the released claims are deleted and their volumes are waited for, the mounted claims are left alone
+	remaining, err := r.cleanupCloudResources(ctx, mc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(remaining).To(ConsistOf(
+		"Service default/lb",
+		"PersistentVolumeClaim default/logs",
+		"PersistentVolumeClaim default/cache",
+		"PersistentVolume pv-cache",
+	))
+	g.Expect(workloads.Get(ctx, client.ObjectKey{Namespace: "default", Name: "data"}, &corev1.PersistentVolumeClaim{})).To(Succeed())
+	g.Expect(workloads.Get(ctx, client.ObjectKey{Namespace: "default", Name: "internal"}, &corev1.Service{})).To(Succeed())
+
+	// the cleanup waits for the volumes of the removed claims to be deleted by the CSI driver
+	pv := &corev1.PersistentVolume{}
+	g.Expect(workloads.Get(ctx, client.ObjectKey{Name: "pv-cache"}, pv)).To(Succeed())
+	pv.Status.Phase = corev1.VolumeReleased
+	g.Expect(workloads.Status().Update(ctx, pv)).To(Succeed())
+	g.Expect(r.preDeleteCleanup(ctx, mc)).To(BeFalse())
+	condition := apimeta.FindStatusCondition(mc.Status.Conditions, hmc.CloudResourcesCleanedCondition)
+	g.Expect(condition).NotTo(BeNil())
+	g.Expect(condition.Message).To(Equal("Waiting for the objects to be removed: PersistentVolume pv-cache"))
+
+	g.Expect(workloads.Delete(ctx, volume("cache"))).To(Succeed())
+	g.Expect(r.preDeleteCleanup(ctx, mc)).To(BeTrue())
+	g.Expect(apimeta.IsStatusConditionTrue(mc.Status.Conditions, hmc.CloudResourcesCleanedCondition)).To(BeTrue())
+
+	// the client of the cluster is reused between the reconciliations
+	g.Expect(created).To(Equal(1))
+}
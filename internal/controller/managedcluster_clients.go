@@ -0,0 +1,92 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// clusterClients caches the clients of the managed clusters to share the
+// discovery and the REST mapping between the reconciliations. The client is
+// recreated once the kubeconfig of the cluster changes.
+type clusterClients struct {
+	// newClient creates the client of a cluster, defaults to client.New.
+	newClient func(*rest.Config) (client.Client, error)
+
+	mu      sync.Mutex
+	clients map[client.ObjectKey]cachedClusterClient
+}
+
+type cachedClusterClient struct {
+	client     client.Client
+	kubeconfig []byte
+}
+
+// get returns the client of the cluster built from the given kubeconfig.
+func (c *clusterClients) get(key client.ObjectKey, kubeconfig []byte) (client.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, ok := c.clients[key]; ok && bytes.Equal(cached.kubeconfig, kubeconfig) {
+		return cached.client, nil
+	}
+
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig of cluster %s: %w", key, err)
+	}
+
+	newClient := c.newClient
+	if newClient == nil {
+		newClient = func(config *rest.Config) (client.Client, error) {
+			return client.New(config, client.Options{})
+		}
+	}
+	cl, err := newClient(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client for cluster %s: %w", key, err)
+	}
+
+	if c.clients == nil {
+		c.clients = make(map[client.ObjectKey]cachedClusterClient)
+	}
+	c.clients[key] = cachedClusterClient{client: cl, kubeconfig: bytes.Clone(kubeconfig)}
+	return cl, nil
+}
+
+// forget drops the client of the deleted cluster.
+func (c *clusterClients) forget(key client.ObjectKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.clients, key)
+}
+
+// getClusterClient returns the client of the ManagedCluster.
+func (r *ManagedClusterReconciler) getClusterClient(ctx context.Context, managedCluster *hmc.ManagedCluster) (client.Client, error) {
+	kubeconfigSecret, err := r.getKubeconfigSecret(ctx, managedCluster)
+	if err != nil {
+		return nil, err
+	}
+	return r.clusterClients.get(client.ObjectKeyFromObject(managedCluster), kubeconfigSecret.Data["value"])
+}
@@ -0,0 +1,128 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/velero"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func testKubeconfig(server string) []byte {
+	return []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: cluster
+  cluster:
+    server: ` + server + `
+contexts:
+- name: cluster
+  context:
+    cluster: cluster
+    user: admin
+current-context: cluster
+users:
+- name: admin
+  user:
+    token: token
+`)
+}
+
+func TestClusterClients(t *testing.T) {
+	g := NewWithT(t)
+
+	var hosts []string
+	clients := &clusterClients{newClient: func(config *rest.Config) (client.Client, error) {
+		hosts = append(hosts, config.Host)
+		return fake.NewClientBuilder().Build(), nil
+	}}
+	key := client.ObjectKey{Namespace: "default", Name: "dev"}
+
+	cl, err := clients.get(key, testKubeconfig("https://10.0.0.1:6443"))
+	g.Expect(err).NotTo(HaveOccurred())
+	cached, err := clients.get(key, testKubeconfig("https://10.0.0.1:6443"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cached).To(BeIdenticalTo(cl))
+	g.Expect(hosts).To(Equal([]string{"https://10.0.0.1:6443"}))
+
+	// the client is recreated once the kubeconfig changes
+	rotated, err := clients.get(key, testKubeconfig("https://10.0.0.2:6443"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rotated).NotTo(BeIdenticalTo(cl))
+	g.Expect(hosts).To(Equal([]string{"https://10.0.0.1:6443", "https://10.0.0.2:6443"}))
+
+	clients.forget(key)
+	_, err = clients.get(key, testKubeconfig("https://10.0.0.2:6443"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(hosts).To(HaveLen(3))
+
+	_, err = clients.get(key, []byte("invalid"))
+	g.Expect(err).To(MatchError(ContainSubstring("failed to parse kubeconfig of cluster default/dev")))
+}
+
+func TestUpdateBackupStatus(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"))
+	mc.Spec.Backup = &hmc.ManagedClusterBackupSpec{Schedule: "@daily"}
+	kubeconfig := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: mc.Namespace, Name: mc.Name + "-kubeconfig"},
+		Data:       map[string][]byte{"value": testKubeconfig("https://10.0.0.1:6443")},
+	}
+
+	now := time.Now().Truncate(time.Second)
+	scheduleName := hmc.ManagedClusterBackupReleaseName + "-" + hmc.ManagedClusterBackupScheduleName
+	backups := []client.Object{
+		newVeleroBackup("b1", scheduleName, velero.PhaseCompleted, now.Add(-time.Hour)),
+		newVeleroBackup("b2", scheduleName, velero.PhaseFailed, now),
+		newVeleroBackup("other", "other", velero.PhaseCompleted, now),
+	}
+	for _, b := range backups {
+		b.SetNamespace(hmc.ManagedClusterBackupDefaultNamespace)
+	}
+
+	created := 0
+	r := &ManagedClusterReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(kubeconfig).Build(),
+		clusterClients: clusterClients{newClient: func(*rest.Config) (client.Client, error) {
+			created++
+			return fake.NewClientBuilder().WithObjects(backups...).Build(), nil
+		}},
+	}
+
+	for range 2 {
+		r.updateBackupStatus(ctx, mc)
+	}
+	g.Expect(created).To(Equal(1))
+	g.Expect(mc.Status.Backup).NotTo(BeNil())
+	g.Expect(mc.Status.Backup.LastBackup).NotTo(BeNil())
+	g.Expect(mc.Status.Backup.LastBackup.Name).To(Equal("b2"))
+	g.Expect(mc.Status.Backup.LastSuccessfulBackup).NotTo(BeNil())
+	g.Expect(mc.Status.Backup.LastSuccessfulBackup.Name).To(Equal("b1"))
+	g.Expect(mc.Status.Backup.Error).To(Equal("Backup Failed with 0 errors"))
+}
@@ -16,10 +16,14 @@ package controller
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	hcv2 "github.com/fluxcd/helm-controller/api/v2"
@@ -28,15 +32,21 @@ import (
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
 	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -47,11 +57,17 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/audit"
 	"github.com/Mirantis/hmc/internal/credspropagation"
+	"github.com/Mirantis/hmc/internal/errdefs"
 	"github.com/Mirantis/hmc/internal/helm"
+	"github.com/Mirantis/hmc/internal/instances"
+	"github.com/Mirantis/hmc/internal/metrics"
+	"github.com/Mirantis/hmc/internal/notifications"
 	"github.com/Mirantis/hmc/internal/sveltos"
 	"github.com/Mirantis/hmc/internal/telemetry"
 	"github.com/Mirantis/hmc/internal/utils/status"
+	"github.com/Mirantis/hmc/pkg/conditions"
 )
 
 const (
@@ -63,14 +79,52 @@ type ManagedClusterReconciler struct {
 	client.Client
 	Config          *rest.Config
 	DynamicClient   *dynamic.DynamicClient
+	Recorder        record.EventRecorder
 	SystemNamespace string
+	// Shard is the name of the shard of the namespaces the controller
+	// reconciles the ManagedClusters in, the default shard is empty.
+	Shard string
+	// SecretWriter writes the CCM credentials propagated to the managed clusters
+	// and the kubeconfig copies registering the clusters in the GitOps tooling,
+	// defaults to credspropagation.ApplySecretWriter.
+	SecretWriter credspropagation.SecretWriter
+	// InstanceVerifiers list the cloud instances of the deleted clusters per
+	// infrastructure provider, the infrastructure cluster is released only
+	// once no instances remain if the verification is enabled in the Management.
+	// Defaults to the AWS verifier.
+	InstanceVerifiers map[string]instances.Verifier
+	// ImageCheckers look up the machine images pinned in the node pools per
+	// infrastructure provider before the machines of the cluster are created.
+	// Defaults to the AWS and Azure checkers.
+	ImageCheckers map[string]instances.ImageChecker
+	// Notifier notifies the receivers configured on the Management about
+	// the deployments and the available upgrades, no notifications are sent if nil.
+	Notifier *notifications.Notifier
+	// AutoscalerClusterRole is the ClusterRole bound to cluster-autoscaler of the clusters
+	// in the namespace of the ManagedCluster, the autoscaler cannot be enabled if not set.
+	AutoscalerClusterRole string
+	// AutoscalerNamespaces are the namespaces cluster-autoscaler may be enabled in, the controller
+	// is granted the permissions to write the ServiceAccounts and the Roles of the autoscaler there only.
+	AutoscalerNamespaces []string
+	// GitOpsNamespaces are the namespaces the clusters may be registered in the GitOps tooling in,
+	// the controller is granted the permissions to write the registration Secrets there only.
+	GitOpsNamespaces []string
+
+	shards         *shardFilter
+	clusterClients clusterClients
+	globalDefaults globalClusterDefaults
 }
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
-func (r *ManagedClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *ManagedClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	l := ctrl.LoggerFrom(ctx)
 	l.Info("Reconciling ManagedCluster")
+	ctx = audit.WithCluster(audit.WithActor(ctx, "managedcluster"), req.NamespacedName)
+
+	defer func(start time.Time) {
+		metrics.ObserveReconcileDuration("managedcluster", start, err)
+	}(time.Now())
 
 	managedCluster := &hmc.ManagedCluster{}
 	if err := r.Get(ctx, req.NamespacedName, managedCluster); err != nil {
@@ -101,15 +155,17 @@ func (r *ManagedClusterReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		}
 	}
 
-	return r.Update(ctx, managedCluster)
+	return errdefs.Result(r.Update(ctx, managedCluster))
 }
 
-func (r *ManagedClusterReconciler) setStatusFromClusterStatus(
-	ctx context.Context, managedCluster *hmc.ManagedCluster,
+// setStatusFromClusterStatus copies the conditions of the CAPI cluster to the ManagedCluster
+// status. It returns true if not all of the conditions are satisfied yet.
+func setStatusFromClusterStatus(
+	ctx context.Context, dynamicClient dynamic.Interface, managedCluster *hmc.ManagedCluster,
 ) (bool, error) {
 	l := ctrl.LoggerFrom(ctx)
 
-	resourceConditions, err := status.GetResourceConditions(ctx, managedCluster.Namespace, r.DynamicClient, schema.GroupVersionResource{
+	resourceConditions, err := status.GetResourceConditions(ctx, managedCluster.Namespace, dynamicClient, schema.GroupVersionResource{
 		Group:    "cluster.x-k8s.io",
 		Version:  "v1beta1",
 		Resource: "clusters",
@@ -125,6 +181,10 @@ func (r *ManagedClusterReconciler) setStatusFromClusterStatus(
 
 	allConditionsComplete := true
 	for _, metaCondition := range resourceConditions.Conditions {
+		if managedCluster.Status.HostedControlPlane && hostedIgnoredConditions[metaCondition.Type] {
+			continue
+		}
+
 		if metaCondition.Status != "True" {
 			allConditionsComplete = false
 		}
@@ -135,7 +195,12 @@ func (r *ManagedClusterReconciler) setStatusFromClusterStatus(
 		apimeta.SetStatusCondition(managedCluster.GetConditions(), metaCondition)
 	}
 
-	return !allConditionsComplete, nil
+	controlPlaneRequeue, err := setStatusFromManagedControlPlane(ctx, dynamicClient, managedCluster)
+	if err != nil {
+		return false, err
+	}
+
+	return !allConditionsComplete || controlPlaneRequeue, nil
 }
 
 func (r *ManagedClusterReconciler) Update(ctx context.Context, managedCluster *hmc.ManagedCluster) (result ctrl.Result, err error) {
@@ -153,9 +218,16 @@ func (r *ManagedClusterReconciler) Update(ctx context.Context, managedCluster *h
 	}
 
 	template := &hmc.ClusterTemplate{}
+	// the notifications are sent once the status recording them is updated
+	var pending []notification
 
 	defer func() {
-		err = errors.Join(err, r.updateStatus(ctx, managedCluster, template))
+		err = errors.Join(err, r.updateStatus(ctx, managedCluster, template, pending...))
+	}()
+	defer func() {
+		if err == nil {
+			result = requeueForMaintenance(result, managedCluster)
+		}
 	}()
 
 	templateRef := client.ObjectKey{Name: managedCluster.Spec.Template, Namespace: managedCluster.Namespace}
@@ -171,6 +243,10 @@ func (r *ManagedClusterReconciler) Update(ctx context.Context, managedCluster *h
 			Reason:  hmc.FailedReason,
 			Message: errMsg,
 		})
+		if apierrors.IsNotFound(err) {
+			// the template is not watched, wait for it to be created
+			return ctrl.Result{}, errdefs.Waiting(err)
+		}
 		return ctrl.Result{}, err
 	}
 
@@ -182,10 +258,11 @@ func (r *ManagedClusterReconciler) Update(ctx context.Context, managedCluster *h
 			Reason:  hmc.FailedReason,
 			Message: errMsg,
 		})
-		return ctrl.Result{}, errors.New(errMsg)
+		return ctrl.Result{}, errdefs.Waiting(errors.New(errMsg))
 	}
 	// template is ok, propagate data from it
 	managedCluster.Status.KubernetesVersion = template.Status.KubernetesVersion
+	managedCluster.Status.HostedControlPlane = template.Status.HostedControlPlane
 
 	apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
 		Type:    hmc.TemplateReadyCondition,
@@ -194,6 +271,42 @@ func (r *ManagedClusterReconciler) Update(ctx context.Context, managedCluster *h
 		Message: "Template is valid",
 	})
 
+	mgmt := &hmc.Management{}
+	if err := r.Get(ctx, client.ObjectKey{Name: hmc.ManagementName}, mgmt); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get Management: %w", err)
+	}
+
+	if missing := mgmt.MissingProviders(template.Status.Providers); len(missing) > 0 {
+		errMsg := fmt.Sprintf("providers required by the template are not enabled in the Management: %s", strings.Join(missing, ", "))
+		apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+			Type:    hmc.ProvidersEnabledCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  hmc.FailedReason,
+			Message: errMsg,
+		})
+		return ctrl.Result{}, errdefs.Waiting(errors.New(errMsg))
+	}
+
+	apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+		Type:    hmc.ProvidersEnabledCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  hmc.SucceededReason,
+		Message: "All of the required providers are enabled",
+	})
+
+	if err := r.checkClusterQuotas(ctx, managedCluster, mgmt); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.checkTemplatesDeprecation(ctx, managedCluster, template); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	servicesCompatible, err := r.checkServicesK8sCompatibility(ctx, managedCluster)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	source, err := r.getSource(ctx, template.Status.ChartRef)
 	if err != nil {
 		apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
@@ -205,12 +318,18 @@ func (r *ManagedClusterReconciler) Update(ctx context.Context, managedCluster *h
 		return ctrl.Result{}, err
 	}
 	l.Info("Downloading Helm chart")
-	hcChart, err := helm.DownloadChartFromArtifact(ctx, source.GetArtifact())
+	hcChart, err := helm.DownloadHelmChart(ctx, r.Client, source)
 	if err != nil {
+		eventReason, reason := EventReasonChartDownloadFailed, hmc.DownloadFailedReason
+		if errors.Is(err, helm.ErrDigestMismatch) {
+			eventReason, reason = EventReasonChartChecksumMismatch, hmc.ChecksumMismatchReason
+		}
+		r.Recorder.Eventf(managedCluster, corev1.EventTypeWarning, eventReason,
+			"Failed to download Helm chart of the template %s: %s", managedCluster.Spec.Template, err)
 		apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
 			Type:    hmc.HelmChartReadyCondition,
 			Status:  metav1.ConditionFalse,
-			Reason:  hmc.FailedReason,
+			Reason:  reason,
 			Message: fmt.Sprintf("failed to download helm chart: %s", err),
 		})
 		return ctrl.Result{}, err
@@ -224,15 +343,51 @@ func (r *ManagedClusterReconciler) Update(ctx context.Context, managedCluster *h
 		return ctrl.Result{}, err
 	}
 
+	globalDefaults, err := r.globalDefaults.get(ctx, r.Client)
+	if errdefs.IsTerminal(err) {
+		apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+			Type:    hmc.HelmChartReadyCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  hmc.FailedReason,
+			Message: err.Error(),
+		})
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	values, err := clusterValues(managedCluster, hcChart, globalDefaults)
+	if err != nil {
+		apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+			Type:    hmc.HelmChartReadyCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  hmc.FailedReason,
+			Message: fmt.Sprintf("failed to merge the configuration with the template defaults: %s", err),
+		})
+		return ctrl.Result{}, errdefs.Terminal(err)
+	}
+
+	setClusterClassValues(values, template.Spec.ClusterClass)
+
+	trust, err := r.resolveTrust(ctx, managedCluster)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	helm.SetTrustValues(values, hcChart.Values, trust.proxy, trust.caBundle)
+
 	l.Info("Validating Helm chart with provided values")
-	if err := validateReleaseWithValues(ctx, actionConfig, managedCluster, hcChart); err != nil {
+	rel, err := validateReleaseWithValues(ctx, actionConfig, managedCluster, hcChart, values)
+	if err != nil {
+		metrics.IncHelmValidationFailures(managedCluster.Namespace, managedCluster.Spec.Template)
+		r.Recorder.Eventf(managedCluster, corev1.EventTypeWarning, EventReasonValidationFailed,
+			"Failed to validate the template %s with provided configuration: %s", managedCluster.Spec.Template, err)
 		apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
 			Type:    hmc.HelmChartReadyCondition,
 			Status:  metav1.ConditionFalse,
 			Reason:  hmc.FailedReason,
 			Message: fmt.Sprintf("failed to validate template with provided configuration: %s", err),
 		})
-		return ctrl.Result{}, err
+		return ctrl.Result{}, errdefs.Terminal(err)
 	}
 
 	apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
@@ -242,28 +397,54 @@ func (r *ManagedClusterReconciler) Update(ctx context.Context, managedCluster *h
 		Message: "Helm chart is valid",
 	})
 
+	if err := r.reconcileDryRun(ctx, actionConfig, managedCluster, rel); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	cred := &hmc.Credential{}
 	err = r.Client.Get(ctx, client.ObjectKey{
 		Name:      managedCluster.Spec.Credential,
 		Namespace: managedCluster.Namespace,
 	}, cred)
 	if err != nil {
+		r.Recorder.Eventf(managedCluster, corev1.EventTypeWarning, EventReasonCredentialNotReady,
+			"Failed to get Credential %s: %s", managedCluster.Spec.Credential, err)
 		apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
 			Type:    hmc.CredentialReadyCondition,
 			Status:  metav1.ConditionFalse,
 			Reason:  hmc.FailedReason,
 			Message: fmt.Sprintf("Failed to get Credential: %s", err),
 		})
+		if apierrors.IsNotFound(err) {
+			// the cluster is reconciled again once the Credential is created
+			return ctrl.Result{}, errdefs.Terminal(err)
+		}
 		return ctrl.Result{}, err
 	}
 
 	if cred.Status.State != hmc.CredentialReady {
+		r.Recorder.Eventf(managedCluster, corev1.EventTypeWarning, EventReasonCredentialNotReady,
+			"Credential %s is not in Ready state", managedCluster.Spec.Credential)
 		apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
 			Type:    hmc.CredentialReadyCondition,
 			Status:  metav1.ConditionFalse,
 			Reason:  hmc.FailedReason,
 			Message: "Credential is not in Ready state",
 		})
+		// the cluster is reconciled again once the Credential becomes ready
+		return ctrl.Result{}, nil
+	}
+
+	identities, err := r.identityRefs(ctx, managedCluster, template, cred)
+	if err != nil {
+		r.Recorder.Eventf(managedCluster, corev1.EventTypeWarning, EventReasonCredentialNotReady, "%s", err)
+		apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+			Type:    hmc.CredentialReadyCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  hmc.FailedReason,
+			Message: err.Error(),
+		})
+		return ctrl.Result{}, err
 	}
 
 	apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
@@ -273,13 +454,60 @@ func (r *ManagedClusterReconciler) Update(ctx context.Context, managedCluster *h
 		Message: "Credential is Ready",
 	})
 
+	if err := r.checkImages(ctx, managedCluster, template, cred, values); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	if !managedCluster.Spec.DryRun {
-		helmValues, err := setIdentityHelmValues(managedCluster.Spec.Config, cred.Spec.IdentityRef)
+		valuesRaw, err := json.Marshal(values)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("error marshalling values: %w", err)
+		}
+
+		helmValues, err := setIdentityHelmValues(&apiextensionsv1.JSON{Raw: valuesRaw}, identities)
 		if err != nil {
 			return ctrl.Result{},
 				fmt.Errorf("error setting identity values: %s", err)
 		}
-		hr, _, err := helm.ReconcileHelmRelease(ctx, r.Client, managedCluster.Name, managedCluster.Namespace, helm.ReconcileHelmReleaseOpts{
+
+		helmValues, err = setNodePoolsHelmValues(helmValues, managedCluster.Spec.NodePools)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("error setting node pools values: %w", err)
+		}
+
+		helmValues, err = setMachineHealthCheckHelmValues(helmValues, managedCluster.Spec.MachineHealthCheck)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("error setting machine health check values: %w", err)
+		}
+
+		imageOverrides, err := getImageOverrides(ctx, r.Client)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		helmValues, err = helm.OverrideImages(helmValues, hcChart.Values, imageOverrides)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("error overriding images: %w", err)
+		}
+
+		currentHR := &hcv2.HelmRelease{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(managedCluster), currentHR); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return ctrl.Result{}, fmt.Errorf("failed to get HelmRelease %s/%s: %w", managedCluster.Namespace, managedCluster.Name, err)
+			}
+			currentHR = nil
+
+			completed, err := r.checkLifecycleHooks(ctx, managedCluster, hmc.LifecycleHookPreProvision)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if !completed {
+				l.Info("Waiting for the PreProvision lifecycle hooks to complete")
+				return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, nil
+			}
+		}
+
+		hrOpts := helm.ReconcileHelmReleaseOpts{
 			Values: helmValues,
 			OwnerReference: &metav1.OwnerReference{
 				APIVersion: hmc.GroupVersion.String(),
@@ -288,17 +516,64 @@ func (r *ManagedClusterReconciler) Update(ctx context.Context, managedCluster *h
 				UID:        managedCluster.UID,
 			},
 			ChartRef: template.Status.ChartRef,
-		})
+		}
+		setHelmReleaseTuning(&hrOpts, managedCluster.Spec.HelmRelease)
+
+		if _, ok := managedCluster.Annotations[hmc.DiffRequestedAnnotation]; ok {
+			if err := r.reportDiff(ctx, actionConfig, managedCluster, rel, currentHR, hrOpts); err != nil {
+				return ctrl.Result{}, err
+			}
+			// nothing is applied while the diff is requested, the removal
+			// of the annotation triggers the next reconciliation
+			return ctrl.Result{}, nil
+		}
+
+		windowOpen, nextWindow, err := maintenanceWindowOpen(managedCluster, mgmt, time.Now())
 		if err != nil {
-			apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
-				Type:    hmc.HelmReleaseReadyCondition,
-				Status:  metav1.ConditionFalse,
-				Reason:  hmc.FailedReason,
-				Message: err.Error(),
-			})
 			return ctrl.Result{}, err
 		}
 
+		hr, operation := currentHR, controllerutil.OperationResultNone
+		if currentHR != nil && !windowOpen && helmReleaseChanged(currentHR, hrOpts) {
+			l.Info("Holding back the HelmRelease update until the maintenance window opens", "nextWindow", nextWindow)
+			setPendingChange(managedCluster, hmc.PendingChangeHelmRelease, nextWindow)
+		} else {
+			clearPendingChange(managedCluster, hmc.PendingChangeHelmRelease)
+			hr, operation, err = helm.ReconcileHelmRelease(ctx, r.Client, managedCluster.Name, managedCluster.Namespace, hrOpts)
+			setApplyConflictCondition(managedCluster.GetConditions(), err)
+			if err != nil {
+				apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+					Type:    hmc.HelmReleaseReadyCondition,
+					Status:  metav1.ConditionFalse,
+					Reason:  hmc.FailedReason,
+					Message: err.Error(),
+				})
+				return ctrl.Result{}, err
+			}
+		}
+
+		if helmReleaseSpecApplied(currentHR, hr, operation) {
+			manifestsConfigMap, err := r.recordManifests(ctx, actionConfig, managedCluster, hcChart, helmValues)
+			if err != nil {
+				l.Error(err, "failed to record the rendered manifests")
+			}
+			managedCluster.AddHistoryEntry(hmc.ManagedClusterHistoryEntry{
+				Timestamp:          metav1.Now(),
+				Template:           managedCluster.Spec.Template,
+				ConfigHash:         valuesHash(helmValues),
+				Generation:         managedCluster.Generation,
+				ManifestsConfigMap: manifestsConfigMap,
+				Outcome:            hmc.ProgressingReason,
+			})
+			pending = append(pending, notification{
+				event:   hmc.NotificationProvisioningStarted,
+				message: "Deploying the template " + managedCluster.Spec.Template,
+			})
+			if err := r.pruneManifests(ctx, managedCluster); err != nil {
+				l.Error(err, "failed to prune the rendered manifests")
+			}
+		}
+
 		hrReadyCondition := fluxconditions.Get(hr, fluxmeta.ReadyCondition)
 		if hrReadyCondition != nil {
 			apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
@@ -307,9 +582,17 @@ func (r *ManagedClusterReconciler) Update(ctx context.Context, managedCluster *h
 				Reason:  hrReadyCondition.Reason,
 				Message: hrReadyCondition.Message,
 			})
+			if helmReleaseObserved(hr, hrReadyCondition) && setHistoryOutcome(managedCluster, hrReadyCondition) {
+				trackTemplateUpgrade(ctx, r.Client, managedCluster)
+			}
+		}
+
+		if err := r.reconcileClusterMetadata(ctx, managedCluster); err != nil {
+			l.Error(err, "failed to propagate labels and annotations to the CAPI Cluster")
+			return ctrl.Result{}, err
 		}
 
-		requeue, err := r.setStatusFromClusterStatus(ctx, managedCluster)
+		requeue, err := setStatusFromClusterStatus(ctx, r.DynamicClient, managedCluster)
 		if err != nil {
 			if requeue {
 				return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, err
@@ -318,6 +601,10 @@ func (r *ManagedClusterReconciler) Update(ctx context.Context, managedCluster *h
 			return ctrl.Result{}, err
 		}
 
+		if err := r.updateEndpointStatus(ctx, managedCluster); err != nil {
+			return ctrl.Result{}, err
+		}
+
 		if requeue {
 			return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, nil
 		}
@@ -331,111 +618,620 @@ func (r *ManagedClusterReconciler) Update(ctx context.Context, managedCluster *h
 			return ctrl.Result{}, err
 		}
 
-		return r.updateServices(ctx, managedCluster)
+		// the DNS config is resolved once for both the written configs and the external-dns service
+		var dns *resolvedDNS
+		if managedCluster.Spec.DNS != nil {
+			if dns, err = r.resolveDNS(ctx, managedCluster); err != nil {
+				l.Error(err, "failed to resolve the DNS config")
+				return ctrl.Result{}, err
+			}
+		}
+
+		if err := r.reconcileDNS(ctx, managedCluster, dns); err != nil {
+			l.Error(err, "failed to reconcile the DNS integration")
+			return ctrl.Result{}, err
+		}
+
+		autoscalerRefreshAt, err := r.reconcileAutoscaler(ctx, managedCluster)
+		if err != nil {
+			l.Error(err, "failed to reconcile the access of cluster-autoscaler")
+			return ctrl.Result{}, err
+		}
+
+		if err := r.reconcileTrustedCA(ctx, managedCluster); err != nil {
+			l.Error(err, "failed to propagate the trusted CA bundle")
+			return ctrl.Result{}, err
+		}
+
+		if err := r.reconcileGitOpsRegistration(ctx, managedCluster); err != nil {
+			l.Error(err, "failed to register the cluster in the GitOps tooling")
+			return ctrl.Result{}, err
+		}
+
+		completed, err := r.checkLifecycleHooks(ctx, managedCluster, hmc.LifecycleHookPostReady)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !completed {
+			l.Info("Waiting for the PostReady lifecycle hooks to complete")
+			return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, nil
+		}
+
+		if !servicesCompatible {
+			l.Info("Skipping the services deployment since the ServiceTemplates are incompatible with the cluster")
+			return requeueBefore(ctrl.Result{}, autoscalerRefreshAt), nil
+		}
+
+		// the services in sync are not requeued, the status of the cluster
+		// is refreshed by the ManagedClusterStatusReconciler
+		if err := r.updateServices(ctx, managedCluster, dns, windowOpen, nextWindow); err != nil {
+			return ctrl.Result{}, err
+		}
+		return requeueBefore(ctrl.Result{}, autoscalerRefreshAt), nil
 	}
 
 	return ctrl.Result{}, nil
 }
 
-// updateServices reconciles services provided in ManagedCluster.Spec.Services.
-// TODO(https://github.com/Mirantis/hmc/issues/361): Set status to ManagedCluster object at appropriate places.
-func (r *ManagedClusterReconciler) updateServices(ctx context.Context, mc *hmc.ManagedCluster) (ctrl.Result, error) {
-	opts, err := helmChartOpts(ctx, r.Client, mc.Namespace, mc.Spec.Services)
+// checkLifecycleHooks returns true if all of the LifecycleHooks of the given
+// phase matching the ManagedCluster have been acknowledged. The result is
+// reflected in the LifecycleHooksCompleted condition.
+func (r *ManagedClusterReconciler) checkLifecycleHooks(ctx context.Context, managedCluster *hmc.ManagedCluster, phase hmc.LifecycleHookPhase) (bool, error) {
+	hooks := &hmc.LifecycleHookList{}
+	if err := r.List(ctx, hooks, client.InNamespace(managedCluster.Namespace)); err != nil {
+		return false, fmt.Errorf("failed to list LifecycleHooks: %w", err)
+	}
+
+	var matched bool
+	var pending []string
+	for _, hook := range hooks.Items {
+		if hook.Spec.Phase != phase || !hook.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		matches, err := hook.Matches(managedCluster)
+		if err != nil {
+			return false, fmt.Errorf("invalid cluster selector of LifecycleHook %s/%s: %w", hook.Namespace, hook.Name, err)
+		}
+		if !matches {
+			continue
+		}
+
+		matched = true
+		if !hook.IsAcknowledged(managedCluster) {
+			pending = append(pending, hook.Name)
+		}
+	}
+
+	if !matched {
+		return true, nil
+	}
+
+	if len(pending) > 0 {
+		apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+			Type:    hmc.LifecycleHooksCompletedCondition,
+			Status:  metav1.ConditionUnknown,
+			Reason:  hmc.ProgressingReason,
+			Message: fmt.Sprintf("Waiting for %s lifecycle hooks: %s", phase, strings.Join(pending, ", ")),
+		})
+		return false, nil
+	}
+
+	apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+		Type:    hmc.LifecycleHooksCompletedCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  hmc.SucceededReason,
+		Message: fmt.Sprintf("All of the %s lifecycle hooks are completed", phase),
+	})
+	return true, nil
+}
+
+// checkServicesK8sCompatibility checks the Kubernetes version of the
+// ManagedCluster against the constraints of the ServiceTemplates of its
+// enabled services and reflects the result in the ServicesK8sCompatible
+// condition. It returns false if any of the ServiceTemplates is incompatible.
+func (r *ManagedClusterReconciler) checkServicesK8sCompatibility(ctx context.Context, managedCluster *hmc.ManagedCluster) (bool, error) {
+	k8sVersion := managedCluster.Status.KubernetesVersion
+	if len(managedCluster.Spec.Services) == 0 || k8sVersion == "" {
+		apimeta.RemoveStatusCondition(managedCluster.GetConditions(), hmc.ServicesK8sCompatibleCondition)
+		return true, nil
+	}
+
+	var incompatible []string
+	for _, svc := range managedCluster.Spec.Services {
+		if svc.Disable {
+			continue
+		}
+
+		svcTpl := &hmc.ServiceTemplate{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: managedCluster.Namespace, Name: svc.Template}, svcTpl); err != nil {
+			if apierrors.IsNotFound(err) {
+				// the missing template is reported on the services deployment
+				continue
+			}
+			return false, fmt.Errorf("failed to get ServiceTemplate %s/%s: %w", managedCluster.Namespace, svc.Template, err)
+		}
+
+		compatible, err := svcTpl.IsCompatibleWith(k8sVersion)
+		if err != nil {
+			return false, err
+		}
+		if !compatible {
+			incompatible = append(incompatible, fmt.Sprintf("%s (%s)", svc.Template, svcTpl.Status.KubernetesConstraint))
+		}
+	}
+
+	if len(incompatible) > 0 {
+		apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+			Type:    hmc.ServicesK8sCompatibleCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  hmc.FailedReason,
+			Message: fmt.Sprintf("k8s version %s does not satisfy constraints of the ServiceTemplates: %s", k8sVersion, strings.Join(incompatible, ", ")),
+		})
+		return false, nil
+	}
+
+	apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+		Type:    hmc.ServicesK8sCompatibleCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  hmc.SucceededReason,
+		Message: fmt.Sprintf("k8s version %s satisfies constraints of the ServiceTemplates", k8sVersion),
+	})
+	return true, nil
+}
+
+// checkTemplatesDeprecation reflects the deprecation of the ClusterTemplate and
+// of the ServiceTemplates of the enabled services in the TemplateDeprecated
+// condition. The condition is informational and does not block the reconciliation,
+// a warning event is emitted once the templates become deprecated.
+func (r *ManagedClusterReconciler) checkTemplatesDeprecation(ctx context.Context, managedCluster *hmc.ManagedCluster, template *hmc.ClusterTemplate) error {
+	now := time.Now()
+	reason := hmc.DeprecatedReason
+
+	var messages []string
+	if msg := template.Spec.DeprecationMessage(hmc.ClusterTemplateKind, template.Name, now); msg != "" {
+		messages = append(messages, msg)
+		if template.Spec.IsSunset(now) {
+			reason = hmc.SunsetReason
+		}
+	}
+
+	for _, svc := range managedCluster.Spec.Services {
+		if svc.Disable {
+			continue
+		}
+
+		svcTpl := &hmc.ServiceTemplate{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: managedCluster.Namespace, Name: svc.Template}, svcTpl); err != nil {
+			if apierrors.IsNotFound(err) {
+				// the missing template is reported on the services deployment
+				continue
+			}
+			return fmt.Errorf("failed to get ServiceTemplate %s/%s: %w", managedCluster.Namespace, svc.Template, err)
+		}
+
+		if msg := svcTpl.Spec.DeprecationMessage(hmc.ServiceTemplateKind, svcTpl.Name, now); msg != "" {
+			messages = append(messages, msg)
+			if svcTpl.Spec.IsSunset(now) {
+				reason = hmc.SunsetReason
+			}
+		}
+	}
+
+	if len(messages) == 0 {
+		apimeta.RemoveStatusCondition(managedCluster.GetConditions(), hmc.TemplateDeprecatedCondition)
+		return nil
+	}
+
+	message := strings.Join(messages, "; ")
+	prev := apimeta.FindStatusCondition(managedCluster.Status.Conditions, hmc.TemplateDeprecatedCondition)
+	if prev == nil || prev.Message != message {
+		r.Recorder.Event(managedCluster, corev1.EventTypeWarning, EventReasonTemplateDeprecated, message)
+	}
+
+	apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+		Type:    hmc.TemplateDeprecatedCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  reason,
+		Message: message,
+	})
+	return nil
+}
+
+// valuesHash returns the SHA-256 hash of the given Helm values.
+func valuesHash(values *apiextensionsv1.JSON) string {
+	var raw []byte
+	if values != nil {
+		raw = values.Raw
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(raw))
+}
+
+// helmReleaseSpecApplied reports whether the spec of the HelmRelease has been created or changed
+// by the apply. The updates of the labels and the owner references only leave the generation of the
+// HelmRelease intact and are not recorded in the history.
+func helmReleaseSpecApplied(current, applied *hcv2.HelmRelease, operation controllerutil.OperationResult) bool {
+	switch operation {
+	case controllerutil.OperationResultCreated:
+		return true
+	case controllerutil.OperationResultUpdated:
+		return current == nil || applied.Generation != current.Generation
+	default:
+		return false
+	}
+}
+
+// helmReleaseObserved reports whether the Ready condition of the HelmRelease is the outcome
+// of its current generation rather than of the previous one.
+func helmReleaseObserved(hr *hcv2.HelmRelease, hrReadyCondition *metav1.Condition) bool {
+	return hrReadyCondition.ObservedGeneration == hr.Generation && hr.Status.ObservedGeneration >= hr.Generation
+}
+
+// setHistoryOutcome sets the outcome of the last ManagedCluster history
+// entry in progress from the given HelmRelease Ready condition.
+// It returns true if the outcome has been set.
+func setHistoryOutcome(managedCluster *hmc.ManagedCluster, hrReadyCondition *metav1.Condition) bool {
+	if len(managedCluster.Status.History) == 0 {
+		return false
+	}
+
+	last := &managedCluster.Status.History[len(managedCluster.Status.History)-1]
+	if last.Outcome != hmc.ProgressingReason {
+		return false
+	}
+
+	switch hrReadyCondition.Status {
+	case metav1.ConditionTrue:
+		last.Outcome = hmc.SucceededReason
+	case metav1.ConditionFalse:
+		last.Outcome = hmc.FailedReason
+	default:
+		return false
+	}
+	last.Message = hrReadyCondition.Message
+	return true
+}
+
+// notificationMetadata returns the metadata the notifications about the ManagedCluster are sent with.
+func notificationMetadata(managedCluster *hmc.ManagedCluster) map[string]string {
+	return map[string]string{
+		"template":   managedCluster.Spec.Template,
+		"generation": strconv.FormatInt(managedCluster.Generation, 10),
+	}
+}
+
+// updateServices reconciles the Profiles deploying the services of the ManagedCluster. The changes
+// of the deployed Profiles are held back until the maintenance window opens if it is closed.
+func (r *ManagedClusterReconciler) updateServices(ctx context.Context, mc *hmc.ManagedCluster, dns *resolvedDNS, windowOpen bool, nextWindow time.Time) error {
+	imageOverrides, err := getImageOverrides(ctx, r.Client)
 	if err != nil {
-		return ctrl.Result{}, err
+		return err
 	}
 
-	if _, err := sveltos.ReconcileProfile(ctx, r.Client, mc.Namespace, mc.Name,
-		sveltos.ReconcileProfileOpts{
-			OwnerReference: &metav1.OwnerReference{
-				APIVersion: hmc.GroupVersion.String(),
-				Kind:       hmc.ManagedClusterKind,
-				Name:       mc.Name,
-				UID:        mc.UID,
-			},
-			LabelSelector: metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					hmc.FluxHelmChartNamespaceKey: mc.Namespace,
-					hmc.FluxHelmChartNameKey:      mc.Name,
-				},
+	trust, err := r.resolveTrust(ctx, mc)
+	if err != nil {
+		return err
+	}
+
+	services := mc.Spec.Services
+	if mc.Spec.Backup != nil {
+		backup, err := backupService(mc.Spec.Backup)
+		if err != nil {
+			return err
+		}
+		services = append(slices.Clone(services), backup)
+	}
+	if dns != nil {
+		service, err := dnsService(mc, dns, trust)
+		if err != nil {
+			return err
+		}
+		services = append(slices.Clone(services), service)
+	}
+	if mc.Spec.Autoscaler != nil {
+		service, err := autoscalerService(mc, trust)
+		if err != nil {
+			return err
+		}
+		services = append(slices.Clone(services), service)
+	}
+
+	opts, err := helmChartOpts(ctx, r.Client, mc.Namespace, services, imageOverrides, trust)
+	if err != nil {
+		return err
+	}
+
+	profileLabels := map[string]string{hmc.ManagedClusterLabelKey: mc.Name}
+	profileOpts := sveltos.ReconcileProfileOpts{
+		OwnerReference: &metav1.OwnerReference{
+			APIVersion: hmc.GroupVersion.String(),
+			Kind:       hmc.ManagedClusterKind,
+			Name:       mc.Name,
+			UID:        mc.UID,
+		},
+		Labels: profileLabels,
+		LabelSelector: metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				hmc.FluxHelmChartNamespaceKey: mc.Namespace,
+				hmc.FluxHelmChartNameKey:      mc.Name,
 			},
-			HelmChartOpts:  opts,
-			Priority:       mc.Spec.ServicesPriority,
-			StopOnConflict: mc.Spec.StopOnConflict,
-		}); err != nil {
-		return ctrl.Result{}, fmt.Errorf("failed to reconcile Profile: %w", err)
+		},
+		HelmChartOpts:  opts,
+		Priority:       mc.Spec.ServicesPriority,
+		StopOnConflict: mc.Spec.StopOnConflict,
+	}
+
+	if !windowOpen {
+		changed, exist, err := sveltos.ProfilesChanged(ctx, r.Client, mc.Namespace, profileLabels, sveltos.ProfilesByPolicy(mc.Name, profileOpts))
+		if err != nil {
+			return err
+		}
+		if changed && exist {
+			ctrl.LoggerFrom(ctx).Info("Holding back the services update until the maintenance window opens", "nextWindow", nextWindow)
+			setPendingChange(mc, hmc.PendingChangeServices, nextWindow)
+			return nil
+		}
+	}
+	clearPendingChange(mc, hmc.PendingChangeServices)
+
+	profiles, operation, err := reconcileProfiles(ctx, r.Client, mc.Namespace, mc.Name, profileOpts)
+	setApplyConflictCondition(mc.GetConditions(), err)
+	if err != nil {
+		metrics.IncServiceDeploymentFailures(hmc.ManagedClusterKind, mc.Namespace, mc.Name)
+		trackServiceDeploys(ctx, r.Client, hmc.ManagedClusterKind, string(mc.UID), services, opts, false)
+		return fmt.Errorf("failed to reconcile Profile: %w", err)
+	}
+	if operation == controllerutil.OperationResultCreated || operation == controllerutil.OperationResultUpdated {
+		trackServiceDeploys(ctx, r.Client, hmc.ManagedClusterKind, string(mc.UID), services, opts, true)
+	}
+
+	if err := sveltos.DeleteProfiles(ctx, r.Client, mc.Namespace, profileLabels, profiles...); err != nil {
+		return err
+	}
+
+	mc.Status.ServiceConflicts, err = profileConflicts(ctx, r.Client, mc.Namespace, mc.Name, profiles)
+	if err != nil {
+		return err
+	}
+	setServiceConflictCondition(mc.GetConditions(), mc.Status.ServiceConflicts)
+
+	r.updateBackupStatus(ctx, mc)
+
+	return nil
+}
+
+// clusterValues returns the values of the release of the ManagedCluster combining its Config
+// with the global cluster defaults and the default values of the template according to the ConfigMergeStrategy.
+// The typed config sections of the ManagedCluster are set over the result.
+func clusterValues(managedCluster *hmc.ManagedCluster, hcChart *chart.Chart, globalDefaults map[string]any) (map[string]any, error) {
+	config, err := managedCluster.HelmValues()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the configuration: %w", err)
+	}
+
+	values, err := helm.MergeValues(managedCluster.Spec.ConfigMergeStrategy, hcChart.Values, globalDefaults, config)
+	if err != nil {
+		return nil, err
+	}
+
+	sections, err := helm.ChartConfigSections(hcChart)
+	if err != nil {
+		return nil, err
+	}
+	helm.SetConfigSections(values, sections, &managedCluster.Spec)
+	return values, nil
+}
+
+// setClusterClassValues passes the name of the ClusterClass of the template to the chart.
+func setClusterClassValues(values map[string]any, clusterClass *hmc.ClusterClassReference) {
+	if clusterClass == nil {
+		return
+	}
+
+	classValues, ok := values["clusterClass"].(map[string]any)
+	if !ok {
+		classValues = make(map[string]any)
+	}
+	classValues["name"] = clusterClass.Name
+	values["clusterClass"] = classValues
+}
+
+// setHelmReleaseTuning sets the options of the HelmRelease tuned in the ManagedCluster spec.
+func setHelmReleaseTuning(opts *helm.ReconcileHelmReleaseOpts, tuning *hmc.HelmReleaseTuning) {
+	if tuning == nil {
+		return
+	}
+
+	if tuning.Timeout != nil {
+		opts.Timeout = &tuning.Timeout.Duration
+	}
+	opts.RemediationRetries = int(tuning.RemediationRetries)
+	opts.DriftDetectionMode = hcv2.DriftDetectionMode(tuning.DriftDetectionMode)
+	opts.Suspend = tuning.Suspend
+	opts.ReuseValues = tuning.ReuseValues
+}
+
+// globalClusterDefaults caches the parsed global cluster defaults of the Management
+// so that they are parsed once per change instead of on every reconciliation.
+type globalClusterDefaults struct {
+	mu              sync.Mutex
+	resourceVersion string
+	values          map[string]any
+	err             error
+}
+
+// get returns a copy of the values merged into the values of every ManagedCluster.
+func (d *globalClusterDefaults) get(ctx context.Context, c client.Client) (map[string]any, error) {
+	mgmt := &hmc.Management{}
+	if err := c.Get(ctx, client.ObjectKey{Name: hmc.ManagementName}, mgmt); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get Management: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.resourceVersion != mgmt.ResourceVersion || d.resourceVersion == "" {
+		d.resourceVersion = mgmt.ResourceVersion
+		d.values, d.err = mgmt.GlobalClusterDefaultsValues()
+		if d.err != nil {
+			d.err = errdefs.Terminal(fmt.Errorf("failed to parse the global cluster defaults: %w", d.err))
+		}
 	}
 
-	// We don't technically need to requeue here, but doing so because golint fails with:
-	// `(*ManagedClusterReconciler).updateServices` - result `res` is always `nil` (unparam)
-	//
-	// This will be automatically resolved once setting status is implemented (https://github.com/Mirantis/hmc/issues/361),
-	// as it is likely that some execution path in the function will have to return with a requeue to fetch latest status.
-	return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, nil
+	if d.err != nil || d.values == nil {
+		return nil, d.err
+	}
+	return runtime.DeepCopyJSON(d.values), nil
 }
 
-func validateReleaseWithValues(ctx context.Context, actionConfig *action.Configuration, managedCluster *hmc.ManagedCluster, hcChart *chart.Chart) error {
+// validateReleaseWithValues renders the chart with the values the way Helm installs it. The rendering
+// failures are reported as helm.RenderError with the chart, the failing template and the value paths.
+func validateReleaseWithValues(ctx context.Context, actionConfig *action.Configuration, managedCluster *hmc.ManagedCluster, hcChart *chart.Chart, values map[string]any) (*release.Release, error) {
 	install := action.NewInstall(actionConfig)
 	install.DryRun = true
 	install.ReleaseName = managedCluster.Name
 	install.Namespace = managedCluster.Namespace
 	install.ClientOnly = true
 
-	vals, err := managedCluster.HelmValues()
+	rel, err := install.RunWithContext(ctx, hcChart, values)
 	if err != nil {
-		return err
+		return rel, helm.NewRenderError(hcChart, err)
 	}
-	_, err = install.RunWithContext(ctx, hcChart, vals)
+	return rel, nil
+}
+
+// notification is a notification about the ManagedCluster sent once its status is updated.
+type notification struct {
+	event   hmc.NotificationEvent
+	message string
+}
+
+// updateStatus updates the status of the ManagedCluster and sends the pending
+// notifications. The notifications are only sent once the status recording
+// them is updated, so they are not repeated if the update fails.
+func (r *ManagedClusterReconciler) updateStatus(ctx context.Context, managedCluster *hmc.ManagedCluster, template *hmc.ClusterTemplate, pending ...notification) error {
+	managedCluster.Status.ObservedGeneration = managedCluster.Generation
+	setReadyCondition(managedCluster)
+	setPhase(managedCluster)
+
+	newUpgrades, err := r.setAvailableUpgrades(ctx, managedCluster, template)
 	if err != nil {
-		return err
+		return errors.New("failed to set available upgrades")
+	}
+	if len(newUpgrades) > 0 {
+		pending = append(pending, notification{
+			event:   hmc.NotificationUpgradeAvailable,
+			message: "New upgrades are available: " + strings.Join(newUpgrades, ", "),
+		})
+	}
+	if err := r.Status().Update(ctx, managedCluster); err != nil {
+		return fmt.Errorf("failed to update status for managedCluster %s/%s: %w", managedCluster.Namespace, managedCluster.Name, err)
+	}
+
+	for _, n := range pending {
+		r.Notifier.Notify(ctx, managedCluster, n.event, n.message, notificationMetadata(managedCluster))
 	}
 	return nil
 }
 
-func (r *ManagedClusterReconciler) updateStatus(ctx context.Context, managedCluster *hmc.ManagedCluster, template *hmc.ClusterTemplate) error {
-	managedCluster.Status.ObservedGeneration = managedCluster.Generation
-	warnings := ""
-	errs := ""
-	for _, condition := range managedCluster.Status.Conditions {
-		if condition.Type == hmc.ReadyCondition {
+// setReadyCondition computes the Ready condition of the ManagedCluster from the rest of its conditions.
+// The readiness gates not reported yet keep the cluster from becoming Ready.
+func setReadyCondition(managedCluster *hmc.ManagedCluster) {
+	conds := managedCluster.Status.Conditions
+	for _, gate := range managedCluster.Spec.ReadinessGates {
+		if apimeta.FindStatusCondition(conds, gate.ConditionType) != nil {
 			continue
 		}
-		if condition.Status == metav1.ConditionUnknown {
-			warnings += condition.Message + ". "
-		}
-		if condition.Status == metav1.ConditionFalse {
-			errs += condition.Message + ". "
-		}
+		conds = append(slices.Clip(conds), metav1.Condition{
+			Type:    gate.ConditionType,
+			Status:  metav1.ConditionUnknown,
+			Reason:  hmc.ProgressingReason,
+			Message: "Waiting for the readiness gate " + gate.ConditionType,
+		})
 	}
-	condition := metav1.Condition{
-		Type:    hmc.ReadyCondition,
-		Status:  metav1.ConditionTrue,
-		Reason:  hmc.SucceededReason,
-		Message: "ManagedCluster is ready",
+
+	apimeta.SetStatusCondition(managedCluster.GetConditions(),
+		conditions.Summary(hmc.ReadyCondition, "ManagedCluster is ready", conds))
+}
+
+// setPhase sets the phase of the ManagedCluster computed from its conditions
+// and exposes it as a metric.
+func setPhase(managedCluster *hmc.ManagedCluster) {
+	managedCluster.Status.Phase = computePhase(managedCluster)
+	metrics.SetManagedClusterPhase(managedCluster.Namespace, managedCluster.Name, string(managedCluster.Status.Phase))
+}
+
+// terminalFailureReasons are the reasons of the conditions set by HMC the ManagedCluster
+// does not recover from without a change of its spec or of the environment.
+var terminalFailureReasons = []string{
+	hcv2.InstallFailedReason,
+	hcv2.UpgradeFailedReason,
+	hmc.DownloadFailedReason,
+	hmc.ChecksumMismatchReason,
+	hmc.ImageNotFoundReason,
+}
+
+// computePhase returns the phase of the ManagedCluster. The phase depends
+// only on the deletion timestamp, the conditions and the deployment history.
+// The cluster not ready is only Failed if its deployment has failed, the CAPI
+// conditions are False while the cluster is being provisioned or updated.
+func computePhase(managedCluster *hmc.ManagedCluster) hmc.ManagedClusterPhase {
+	if !managedCluster.DeletionTimestamp.IsZero() {
+		return hmc.ManagedClusterPhaseDeleting
+	}
+
+	readyCondition := apimeta.FindStatusCondition(managedCluster.Status.Conditions, hmc.ReadyCondition)
+	if readyCondition == nil {
+		return hmc.ManagedClusterPhasePending
 	}
-	if warnings != "" {
-		condition.Status = metav1.ConditionUnknown
-		condition.Reason = hmc.ProgressingReason
-		condition.Message = warnings
+	if readyCondition.Status == metav1.ConditionTrue {
+		return hmc.ManagedClusterPhaseReady
 	}
-	if errs != "" {
-		condition.Status = metav1.ConditionFalse
-		condition.Reason = hmc.FailedReason
-		condition.Message = errs
+	if deploymentFailed(managedCluster) {
+		return hmc.ManagedClusterPhaseFailed
 	}
-	apimeta.SetStatusCondition(managedCluster.GetConditions(), condition)
 
-	err := r.setAvailableUpgrades(ctx, managedCluster, template)
-	if err != nil {
-		return errors.New("failed to set available upgrades")
+	// the cluster is updated once it has been provisioned, the first deployment
+	// succeeds as soon as the HelmRelease is installed, long before the cluster is ready
+	if usage := managedCluster.Status.Usage; usage != nil && usage.ProvisionedAt != nil {
+		return hmc.ManagedClusterPhaseUpdating
 	}
-	if err := r.Status().Update(ctx, managedCluster); err != nil {
-		return fmt.Errorf("failed to update status for managedCluster %s/%s: %w", managedCluster.Namespace, managedCluster.Name, err)
+	if n := len(managedCluster.Status.History); n > 0 {
+		for _, entry := range managedCluster.Status.History[:n-1] {
+			if entry.Outcome == hmc.SucceededReason {
+				return hmc.ManagedClusterPhaseUpdating
+			}
+		}
 	}
-	return nil
+
+	hrReadyCondition := apimeta.FindStatusCondition(managedCluster.Status.Conditions, hmc.HelmReleaseReadyCondition)
+	if len(managedCluster.Status.History) == 0 && (hrReadyCondition == nil || hrReadyCondition.Status == metav1.ConditionUnknown) {
+		return hmc.ManagedClusterPhasePending
+	}
+
+	return hmc.ManagedClusterPhaseProvisioning
+}
+
+// deploymentFailed returns true if the last deployment of the ManagedCluster has
+// failed or any of the conditions set by HMC is False for a terminal reason. The
+// Ready condition is skipped, it summarizes the CAPI conditions as well.
+func deploymentFailed(managedCluster *hmc.ManagedCluster) bool {
+	if n := len(managedCluster.Status.History); n > 0 && managedCluster.Status.History[n-1].Outcome == hmc.FailedReason {
+		return true
+	}
+	for _, c := range managedCluster.Status.Conditions {
+		if c.Status == metav1.ConditionFalse && c.Type != hmc.ReadyCondition && slices.Contains(hmc.ManagedClusterConditionTypes, c.Type) &&
+			slices.Contains(terminalFailureReasons, c.Reason) {
+			return true
+		}
+	}
+	return false
 }
 
-func (r *ManagedClusterReconciler) getSource(ctx context.Context, ref *hcv2.CrossNamespaceSourceReference) (sourcev1.Source, error) {
+func (r *ManagedClusterReconciler) getSource(ctx context.Context, ref *hcv2.CrossNamespaceSourceReference) (*sourcev1.HelmChart, error) {
 	if ref == nil {
 		return nil, errors.New("helm chart source is not provided")
 	}
@@ -457,100 +1253,234 @@ func (r *ManagedClusterReconciler) Delete(ctx context.Context, managedCluster *h
 	}, hr)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
+			// the Profiles outlive the HelmRelease if it is removed before the drain
+			if _, err := r.withdrawServices(ctx, managedCluster); err != nil {
+				return ctrl.Result{}, err
+			}
+
+			lingering, err := r.findLingeringResources(ctx, managedCluster)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if len(lingering) > 0 {
+				l.Info("Waiting for the objects of the cluster to be removed", "objects", lingering)
+				if setCleanupIncompleteCondition(managedCluster, lingering) {
+					r.Recorder.Eventf(managedCluster, corev1.EventTypeWarning, EventReasonCleanupIncomplete,
+						"The objects of the cluster are not removed yet: %s", strings.Join(lingering, ", "))
+				}
+				r.reportDeletionBlockers(ctx, managedCluster, nil)
+				if err := r.releaseCluster(ctx, managedCluster); err != nil {
+					l.Info("Failed to release the cluster", "error", err.Error())
+				}
+				return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, r.updateStatus(ctx, managedCluster, nil)
+			}
+
 			l.Info("Removing Finalizer", "finalizer", hmc.ManagedClusterFinalizer)
 			if controllerutil.RemoveFinalizer(managedCluster, hmc.ManagedClusterFinalizer) {
 				if err := r.Client.Update(ctx, managedCluster); err != nil {
 					return ctrl.Result{}, fmt.Errorf("failed to update managedCluster %s/%s: %w", managedCluster.Namespace, managedCluster.Name, err)
 				}
+				audit.Record(ctx, audit.ActionRemoveFinalizer, managedCluster, audit.FinalizerRemoved(hmc.ManagedClusterFinalizer))
 			}
+			r.clusterClients.forget(client.ObjectKeyFromObject(managedCluster))
+			metrics.DeleteManagedCluster(managedCluster.Namespace, managedCluster.Name)
+			trackManagedClusterDelete(ctx, r.Client, managedCluster, true)
+			r.Recorder.Event(managedCluster, corev1.EventTypeNormal, EventReasonDeleted, "All of the resources of the ManagedCluster are deleted")
 			l.Info("ManagedCluster deleted")
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, err
 	}
 
-	err = helm.DeleteHelmRelease(ctx, r.Client, managedCluster.Name, managedCluster.Namespace)
-	if err != nil {
+	r.reportDeletionBlockers(ctx, managedCluster, hr)
+
+	if hr.DeletionTimestamp.IsZero() {
+		completed, err := r.checkLifecycleHooks(ctx, managedCluster, hmc.LifecycleHookPreDelete)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !completed {
+			l.Info("Waiting for the PreDelete lifecycle hooks to complete")
+			r.Recorder.Event(managedCluster, corev1.EventTypeNormal, EventReasonLifecycleHooksPending, "Waiting for the PreDelete lifecycle hooks to complete")
+			return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, r.updateStatus(ctx, managedCluster, nil)
+		}
+
+		r.Recorder.Event(managedCluster, corev1.EventTypeNormal, EventReasonDeleting, "Deleting the HelmRelease of the ManagedCluster")
+	}
+
+	if managedCluster.Status.Phase != hmc.ManagedClusterPhaseDeleting {
+		if err := r.updateStatus(ctx, managedCluster, nil); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if err := r.deleteGitOpsRegistration(ctx, managedCluster); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if hr.DeletionTimestamp.IsZero() {
+		drained, err := r.drainServices(ctx, managedCluster)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !drained {
+			l.Info("Waiting for the services to be withdrawn from the cluster")
+			r.reportDeletionBlockers(ctx, managedCluster, hr)
+			return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, r.updateStatus(ctx, managedCluster, nil)
+		}
+		cleaned := r.preDeleteCleanup(ctx, managedCluster)
+		// the outcome of the steps is reported along with the rest of the objects
+		r.reportDeletionBlockers(ctx, managedCluster, hr)
+		if !cleaned {
+			l.Info("Waiting for the cloud resources of the workloads to be released")
+			return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, r.updateStatus(ctx, managedCluster, nil)
+		}
+		if err := r.updateStatus(ctx, managedCluster, nil); err != nil {
+			return ctrl.Result{}, err
+		}
+	} else if _, err := r.withdrawServices(ctx, managedCluster); err != nil {
+		// the cluster is torn down already, the services are withdrawn without waiting
 		return ctrl.Result{}, err
 	}
 
-	// Without explicitly deleting the Profile object, we run into a race condition
-	// which prevents Sveltos objects from being removed from the management cluster.
-	// It is detailed in https://github.com/projectsveltos/addon-controller/issues/732.
-	// We may try to remove the explicit call to Delete once a fix for it has been merged.
-	// TODO(https://github.com/Mirantis/hmc/issues/526).
-	err = sveltos.DeleteProfile(ctx, r.Client, managedCluster.Namespace, managedCluster.Name)
+	err = helm.DeleteHelmRelease(ctx, r.Client, managedCluster.Name, managedCluster.Namespace)
 	if err != nil {
+		trackManagedClusterDelete(ctx, r.Client, managedCluster, false)
 		return ctrl.Result{}, err
 	}
 
-	err = r.releaseCluster(ctx, managedCluster.Namespace, managedCluster.Name, managedCluster.Spec.Template)
+	err = r.releaseCluster(ctx, managedCluster)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
 	l.Info("HelmRelease still exists, retrying")
-	return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, nil
+	return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, r.updateStatus(ctx, managedCluster, nil)
 }
 
-func (r *ManagedClusterReconciler) releaseCluster(ctx context.Context, namespace, name, templateName string) error {
-	providers, err := r.getInfraProvidersNames(ctx, namespace, templateName)
+var (
+	// infraClusterGVKs are the kinds of the infrastructure clusters of the providers released
+	// once the Machines are removed. The managed control planes (EKS, AKS) come along with
+	// the managed infrastructure clusters.
+	infraClusterGVKs = map[string][]schema.GroupVersionKind{
+		"aws": {
+			{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta2", Kind: "AWSCluster"},
+			{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta2", Kind: "AWSManagedCluster"},
+		},
+		"azure": {
+			{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta1", Kind: "AzureCluster"},
+			{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta1", Kind: "AzureManagedCluster"},
+		},
+	}
+
+	machineGVK = schema.GroupVersionKind{Group: "cluster.x-k8s.io", Version: "v1beta1", Kind: "Machine"}
+)
+
+func (r *ManagedClusterReconciler) releaseCluster(ctx context.Context, managedCluster *hmc.ManagedCluster) error {
+	providers, err := r.getInfraProvidersNames(ctx, managedCluster.Namespace, managedCluster.Spec.Template)
 	if err != nil {
 		return err
 	}
 
-	var (
-		gvkAWSCluster = schema.GroupVersionKind{
-			Group:   "infrastructure.cluster.x-k8s.io",
-			Version: "v1beta2",
-			Kind:    "AWSCluster",
-		}
+	management := &hmc.Management{}
+	if err := r.Get(ctx, client.ObjectKey{Name: hmc.ManagementName}, management); err != nil {
+		return fmt.Errorf("failed to get Management: %w", err)
+	}
 
-		gvkAzureCluster = schema.GroupVersionKind{
-			Group:   "infrastructure.cluster.x-k8s.io",
-			Version: "v1beta1",
-			Kind:    "AzureCluster",
+	// Associate the provider with it's GVKs
+	for _, provider := range providers {
+		verify := management.DeletionVerified(provider)
+		for _, gvk := range infraClusterGVKs[provider] {
+			if err := r.releaseInfraCluster(ctx, managedCluster, provider, verify, gvk, machineGVK); err != nil {
+				return err
+			}
 		}
+	}
+
+	return nil
+}
 
-		gvkMachine = schema.GroupVersionKind{
-			Group:   "cluster.x-k8s.io",
-			Version: "v1beta1",
-			Kind:    "Machine",
+// releaseInfraCluster removes the blocking finalizer from the infrastructure cluster
+// of the given kind once the Machines of the cluster are removed, and once the cloud
+// instances are removed as well if the removal is verified for the provider.
+func (r *ManagedClusterReconciler) releaseInfraCluster(ctx context.Context, managedCluster *hmc.ManagedCluster, provider string, verify bool, gvk, gvkMachine schema.GroupVersionKind) error {
+	namespace, name := managedCluster.Namespace, managedCluster.Name
+	cluster, err := r.getCluster(ctx, namespace, name, gvk)
+	if err != nil {
+		if apierrors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+			return nil
 		}
-	)
 
-	providerGVKs := map[string]schema.GroupVersionKind{
-		"aws":   gvkAWSCluster,
-		"azure": gvkAzureCluster,
+		return err
 	}
 
-	// Associate the provider with it's GVK
-	for _, provider := range providers {
-		gvk, ok := providerGVKs[provider]
-		if !ok {
-			continue
-		}
-
-		cluster, err := r.getCluster(ctx, namespace, name, gvk)
-		if err != nil {
-			if provider == "aws" && apierrors.IsNotFound(err) {
-				return nil
-			}
+	found, err := r.objectsAvailable(ctx, namespace, cluster.Name, gvkMachine)
+	if err != nil || found {
+		return err
+	}
 
+	// the hosted control plane has no Machines, the infrastructure is
+	// released once the control plane is removed from the management cluster
+	if managedCluster.Status.HostedControlPlane {
+		found, err = r.hostedControlPlaneExists(ctx, namespace, name)
+		if err != nil || found {
 			return err
 		}
+	}
 
-		found, err := r.objectsAvailable(ctx, namespace, cluster.Name, gvkMachine)
-		if err != nil {
+	if verify {
+		remaining, err := r.remainingInstances(ctx, managedCluster, provider, cluster)
+		if err != nil || len(remaining) > 0 {
 			return err
 		}
+	}
 
-		if !found {
-			return r.removeClusterFinalizer(ctx, cluster)
-		}
+	return r.removeClusterFinalizer(ctx, cluster)
+}
+
+// remainingInstances returns the cloud instances of the infrastructure cluster
+// reported by the provider API. The cluster is released unverified if the provider
+// cannot list its instances, e.g. with the not supported kind of the identity.
+func (r *ManagedClusterReconciler) remainingInstances(ctx context.Context, managedCluster *hmc.ManagedCluster, provider string, cluster *metav1.PartialObjectMetadata) ([]string, error) {
+	l := ctrl.LoggerFrom(ctx)
+
+	verifier, ok := r.instanceVerifiers()[provider]
+	if !ok {
+		return nil, nil
 	}
 
-	return nil
+	infraCluster := &unstructured.Unstructured{}
+	infraCluster.SetGroupVersionKind(cluster.GroupVersionKind())
+	if err := r.Get(ctx, client.ObjectKeyFromObject(cluster), infraCluster); err != nil {
+		return nil, fmt.Errorf("failed to get %s %s: %w", cluster.Kind, client.ObjectKeyFromObject(cluster), err)
+	}
+
+	remaining, err := verifier.Remaining(ctx, r.Client, infraCluster)
+	if errors.Is(err, instances.ErrUnsupported) {
+		l.Info("Skipping the verification of the cloud instances", "kind", cluster.Kind, "reason", err.Error())
+		r.Recorder.Eventf(managedCluster, corev1.EventTypeWarning, EventReasonInstancesUnverified,
+			"The removal of the cloud instances of %s %s is not verified: %s", cluster.Kind, cluster.Name, err)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(remaining) > 0 {
+		l.Info("Waiting for the cloud instances of the cluster to be removed", "instances", remaining)
+		r.Recorder.Eventf(managedCluster, corev1.EventTypeWarning, EventReasonInstancesRemaining,
+			"The cloud instances of the cluster are not removed yet: %s", strings.Join(remaining, ", "))
+	}
+	return remaining, nil
+}
+
+func (r *ManagedClusterReconciler) instanceVerifiers() map[string]instances.Verifier {
+	if r.InstanceVerifiers == nil {
+		return map[string]instances.Verifier{
+			"aws": &instances.AWSVerifier{SecretNamespace: r.SystemNamespace},
+		}
+	}
+	return r.InstanceVerifiers
 }
 
 func (r *ManagedClusterReconciler) getInfraProvidersNames(ctx context.Context, templateNamespace, templateName string) ([]string, error) {
@@ -586,7 +1516,7 @@ func (r *ManagedClusterReconciler) getCluster(ctx context.Context, namespace, na
 		return nil, err
 	}
 	if len(itemsList.Items) == 0 {
-		return nil, fmt.Errorf("%s with name %s was not found", gvk.Kind, name)
+		return nil, apierrors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, name)
 	}
 
 	return &itemsList.Items[0], nil
@@ -599,6 +1529,7 @@ func (r *ManagedClusterReconciler) removeClusterFinalizer(ctx context.Context, c
 		if err := r.Client.Patch(ctx, cluster, client.MergeFrom(&originalCluster)); err != nil {
 			return fmt.Errorf("failed to patch cluster %s/%s: %w", cluster.Namespace, cluster.Name, err)
 		}
+		audit.Record(ctx, audit.ActionRemoveFinalizer, cluster, audit.FinalizerRemoved(hmc.BlockingFinalizer))
 	}
 
 	return nil
@@ -618,6 +1549,13 @@ func (r *ManagedClusterReconciler) objectsAvailable(ctx context.Context, namespa
 	return len(itemsList.Items) != 0, nil
 }
 
+func (r *ManagedClusterReconciler) secretWriter() credspropagation.SecretWriter {
+	if r.SecretWriter == nil {
+		return credspropagation.ApplySecretWriter{}
+	}
+	return r.SecretWriter
+}
+
 func (r *ManagedClusterReconciler) reconcileCredentialPropagation(ctx context.Context, managedCluster *hmc.ManagedCluster) error {
 	l := ctrl.LoggerFrom(ctx)
 	l.Info("Reconciling CCM credentials propagation")
@@ -627,12 +1565,25 @@ func (r *ManagedClusterReconciler) reconcileCredentialPropagation(ctx context.Co
 		return fmt.Errorf("failed to get cluster providers for cluster %s/%s: %w", managedCluster.Namespace, managedCluster.Name, err)
 	}
 
-	kubeconfSecret := &corev1.Secret{}
-	if err := r.Client.Get(ctx, client.ObjectKey{
-		Name:      managedCluster.Name + "-kubeconfig",
-		Namespace: managedCluster.Namespace,
-	}, kubeconfSecret); err != nil {
-		return fmt.Errorf("failed to get kubeconfig secret for cluster %s/%s: %w", managedCluster.Namespace, managedCluster.Name, err)
+	managedControlPlane, err := r.getManagedControlPlane(ctx, managedCluster)
+	if err != nil {
+		return fmt.Errorf("failed to get managed control plane of cluster %s/%s: %w", managedCluster.Namespace, managedCluster.Name, err)
+	}
+	if managedControlPlane != nil {
+		// the cloud controller manager of EKS and AKS is run by the cloud provider
+		l.Info("Skipping creds propagation for the managed control plane", "kind", managedControlPlane.gvk.Kind)
+		apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+			Type:    hmc.CredentialsPropagatedCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  hmc.SucceededReason,
+			Message: "CCM credentials are managed by " + managedControlPlane.gvk.Kind,
+		})
+		return nil
+	}
+
+	kubeconfSecret, err := r.getKubeconfigSecret(ctx, managedCluster)
+	if err != nil {
+		return err
 	}
 
 	propnCfg := &credspropagation.PropagationCfg{
@@ -640,6 +1591,7 @@ func (r *ManagedClusterReconciler) reconcileCredentialPropagation(ctx context.Co
 		ManagedCluster:  managedCluster,
 		KubeconfSecret:  kubeconfSecret,
 		SystemNamespace: r.SystemNamespace,
+		SecretWriter:    r.secretWriter(),
 	}
 
 	for _, provider := range providers {
@@ -700,14 +1652,114 @@ func (r *ManagedClusterReconciler) reconcileCredentialPropagation(ctx context.Co
 	return nil
 }
 
-func setIdentityHelmValues(values *apiextensionsv1.JSON, idRef *corev1.ObjectReference) (*apiextensionsv1.JSON, error) {
+// reconcileClusterMetadata ensures the labels and the annotations requested in the
+// ManagedCluster spec are set on the CAPI Cluster as soon as it is created, so that the
+// tooling selecting the clusters picks them up during the provisioning. The keys propagated
+// previously are tracked in the annotations of the Cluster to remove the ones dropped from the spec.
+func (r *ManagedClusterReconciler) reconcileClusterMetadata(ctx context.Context, managedCluster *hmc.ManagedCluster) error {
+	cluster, err := r.getCluster(ctx, managedCluster.Namespace, managedCluster.Name, capiClusterGVK)
+	if apierrors.IsNotFound(err) {
+		// the reconciliation is requeued until the Cluster is provisioned
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get CAPI Cluster of %s/%s: %w", managedCluster.Namespace, managedCluster.Name, err)
+	}
+	original := cluster.DeepCopy()
+
+	labels, annotations := cluster.GetLabels(), cluster.GetAnnotations()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	propagateMetadata(labels, annotations, hmc.PropagatedLabelsAnnotation, managedCluster.Spec.ClusterLabels)
+	propagateMetadata(annotations, annotations, hmc.PropagatedAnnotationsAnnotation, managedCluster.Spec.ClusterAnnotations)
+	cluster.SetLabels(labels)
+	cluster.SetAnnotations(annotations)
+
+	if equality.Semantic.DeepEqual(original.ObjectMeta, cluster.ObjectMeta) {
+		return nil
+	}
+	if err := r.Client.Patch(ctx, cluster, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("failed to patch CAPI Cluster %s/%s: %w", cluster.Namespace, cluster.Name, err)
+	}
+	return nil
+}
+
+// propagateMetadata sets the desired entries on the target removing the ones
+// propagated previously, the propagated keys are tracked in the given annotation.
+func propagateMetadata(target, annotations map[string]string, trackingAnnotation string, desired map[string]string) {
+	for _, key := range strings.Split(annotations[trackingAnnotation], ",") {
+		if _, ok := desired[key]; !ok && key != "" {
+			delete(target, key)
+		}
+	}
+
+	if len(desired) == 0 {
+		delete(annotations, trackingAnnotation)
+		return
+	}
+
+	keys := make([]string, 0, len(desired))
+	for key, value := range desired {
+		target[key] = value
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	annotations[trackingAnnotation] = strings.Join(keys, ",")
+}
+
+// identityRefs returns the references to the ClusterIdentities passed to the template keyed by the values path.
+// The missing Credentials of the identity values and the ones not ready fail terminally, the cluster
+// is reconciled again once the Credentials are created or become ready.
+func (r *ManagedClusterReconciler) identityRefs(ctx context.Context, managedCluster *hmc.ManagedCluster, template *hmc.ClusterTemplate, cred *hmc.Credential) (map[string]*corev1.ObjectReference, error) {
+	credentialsByPath := managedCluster.IdentityCredentialsByPath(template)
+	paths := make([]string, 0, len(credentialsByPath))
+	for path := range credentialsByPath {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	credentials := map[string]*hmc.Credential{cred.Name: cred}
+	refs := make(map[string]*corev1.ObjectReference, len(paths))
+	for _, path := range paths {
+		name := credentialsByPath[path]
+		identityCred, ok := credentials[name]
+		if !ok {
+			identityCred = &hmc.Credential{}
+			if err := r.Client.Get(ctx, client.ObjectKey{Namespace: managedCluster.Namespace, Name: name}, identityCred); err != nil {
+				err = fmt.Errorf("failed to get Credential %s of the identity value %s: %w", name, path, err)
+				if apierrors.IsNotFound(err) {
+					return nil, errdefs.Terminal(err)
+				}
+				return nil, err
+			}
+			credentials[name] = identityCred
+		}
+		if identityCred.Status.State != hmc.CredentialReady {
+			return nil, errdefs.Terminal(fmt.Errorf("the Credential %s of the identity value %s is not in Ready state", name, path))
+		}
+		refs[path] = identityCred.ClusterIdentityRef()
+	}
+	return refs, nil
+}
+
+// setIdentityHelmValues passes the references to the ClusterIdentities to the template at their values paths.
+func setIdentityHelmValues(values *apiextensionsv1.JSON, identities map[string]*corev1.ObjectReference) (*apiextensionsv1.JSON, error) {
 	var valuesJSON map[string]any
 	err := json.Unmarshal(values.Raw, &valuesJSON)
 	if err != nil {
 		return nil, fmt.Errorf("error unmarshalling values: %s", err)
 	}
+	if valuesJSON == nil {
+		valuesJSON = make(map[string]any)
+	}
 
-	valuesJSON["clusterIdentity"] = idRef
+	for path, idRef := range identities {
+		helm.SetValue(valuesJSON, path, idRef)
+	}
 	valuesRaw, err := json.Marshal(valuesJSON)
 	if err != nil {
 		return nil, fmt.Errorf("error marshalling values: %s", err)
@@ -718,9 +1770,87 @@ func setIdentityHelmValues(values *apiextensionsv1.JSON, idRef *corev1.ObjectRef
 	}, nil
 }
 
-func (r *ManagedClusterReconciler) setAvailableUpgrades(ctx context.Context, managedCluster *hmc.ManagedCluster, template *hmc.ClusterTemplate) error {
+// setNodePoolsHelmValues passes the labels, taints and autoscaling of the node pools
+// to the template in the "nodePools" value keyed by the pool name.
+func setNodePoolsHelmValues(values *apiextensionsv1.JSON, nodePools []hmc.NodePoolSpec) (*apiextensionsv1.JSON, error) {
+	if len(nodePools) == 0 {
+		return values, nil
+	}
+
+	var valuesJSON map[string]any
+	if err := json.Unmarshal(values.Raw, &valuesJSON); err != nil {
+		return nil, fmt.Errorf("error unmarshalling values: %w", err)
+	}
+
+	pools := make(map[string]any, len(nodePools))
+	for _, pool := range nodePools {
+		values := map[string]any{
+			"labels": pool.Labels,
+			"taints": pool.Taints,
+		}
+		if pool.Autoscaling != nil {
+			values["autoscaling"] = map[string]any{
+				"minSize": pool.Autoscaling.MinSize,
+				"maxSize": pool.Autoscaling.MaxSize,
+			}
+		}
+		pools[pool.Name] = values
+	}
+	valuesJSON["nodePools"] = pools
+
+	valuesRaw, err := json.Marshal(valuesJSON)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling values: %w", err)
+	}
+
+	return &apiextensionsv1.JSON{Raw: valuesRaw}, nil
+}
+
+// setMachineHealthCheckHelmValues enables the MachineHealthCheck of the worker machines of
+// the template in the "machineHealthCheck" value, the settings not given are left to the template.
+func setMachineHealthCheckHelmValues(values *apiextensionsv1.JSON, mhc *hmc.MachineHealthCheckSpec) (*apiextensionsv1.JSON, error) {
+	if mhc == nil {
+		return values, nil
+	}
+
+	var valuesJSON map[string]any
+	if err := json.Unmarshal(values.Raw, &valuesJSON); err != nil {
+		return nil, fmt.Errorf("error unmarshalling values: %w", err)
+	}
+
+	check := map[string]any{"enabled": true}
+	if len(mhc.UnhealthyConditions) > 0 {
+		conditions := make([]any, 0, len(mhc.UnhealthyConditions))
+		for _, c := range mhc.UnhealthyConditions {
+			conditions = append(conditions, map[string]any{
+				"type":    c.Type,
+				"status":  c.Status,
+				"timeout": c.Timeout.Duration.String(),
+			})
+		}
+		check["unhealthyConditions"] = conditions
+	}
+	if mhc.MaxUnhealthy != nil {
+		check["maxUnhealthy"] = mhc.MaxUnhealthy
+	}
+	if mhc.NodeStartupTimeout != nil {
+		check["nodeStartupTimeout"] = mhc.NodeStartupTimeout.Duration.String()
+	}
+	valuesJSON["machineHealthCheck"] = check
+
+	valuesRaw, err := json.Marshal(valuesJSON)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling values: %w", err)
+	}
+
+	return &apiextensionsv1.JSON{Raw: valuesRaw}, nil
+}
+
+// setAvailableUpgrades sets the upgrades available for the ManagedCluster and
+// returns the ones not available before.
+func (r *ManagedClusterReconciler) setAvailableUpgrades(ctx context.Context, managedCluster *hmc.ManagedCluster, template *hmc.ClusterTemplate) ([]string, error) {
 	if template == nil {
-		return nil
+		return nil, nil
 	}
 	chains := &hmc.ClusterTemplateChainList{}
 	err := r.List(ctx, chains,
@@ -728,11 +1858,14 @@ func (r *ManagedClusterReconciler) setAvailableUpgrades(ctx context.Context, man
 		client.MatchingFields{hmc.SupportedTemplateKey: template.GetName()},
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	availableUpgradesMap := make(map[string]hmc.AvailableUpgrade)
 	for _, chain := range chains.Items {
+		if !hmc.IsTemplateChainValid(&chain.Status) {
+			continue
+		}
 		for _, supportedTemplate := range chain.Spec.SupportedTemplates {
 			if supportedTemplate.Name == template.Name {
 				for _, availableUpgrade := range supportedTemplate.AvailableUpgrades {
@@ -746,63 +1879,177 @@ func (r *ManagedClusterReconciler) setAvailableUpgrades(ctx context.Context, man
 		availableUpgrades = append(availableUpgrades, availableUpgrade.Name)
 	}
 
+	slices.Sort(availableUpgrades)
+	newUpgrades := slices.DeleteFunc(slices.Clone(availableUpgrades), func(name string) bool {
+		return slices.Contains(managedCluster.Status.AvailableUpgrades, name)
+	})
+	if len(newUpgrades) > 0 {
+		r.Recorder.Eventf(managedCluster, corev1.EventTypeNormal, EventReasonUpgradeAvailable,
+			"New upgrades are available: %s", strings.Join(newUpgrades, ", "))
+	}
+
 	managedCluster.Status.AvailableUpgrades = availableUpgrades
+	metrics.SetManagedClusterAvailableUpgrades(managedCluster.Namespace, managedCluster.Name, template.Name, len(availableUpgrades))
+	return newUpgrades, nil
+}
+
+// clusterRequests returns the requests of the ManagedClusters of the shard in the namespace,
+// all namespaces if empty, matching any of the given field selectors, all of the clusters
+// if none is given.
+func (r *ManagedClusterReconciler) clusterRequests(ctx context.Context, namespace string, selectors ...client.MatchingFields) []ctrl.Request {
+	if len(selectors) == 0 {
+		selectors = []client.MatchingFields{nil}
+	}
+
+	var requests []ctrl.Request
+	for _, selector := range selectors {
+		// the clusters are only read to be enqueued
+		opts := []client.ListOption{client.InNamespace(namespace), client.UnsafeDisableDeepCopy}
+		if selector != nil {
+			opts = append(opts, selector)
+		}
+		managedClusters := &hmc.ManagedClusterList{}
+		if err := r.Client.List(ctx, managedClusters, opts...); err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, "failed to list the ManagedClusters to enqueue", "selector", selector)
+			continue
+		}
+		for _, cluster := range managedClusters.Items {
+			req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&cluster)}
+			if r.shards.inShard(&cluster) && !slices.Contains(requests, req) {
+				requests = append(requests, req)
+			}
+		}
+	}
+	return requests
+}
+
+// managementClusterRequests returns the requests of the ManagedClusters affected by the
+// changes of the Management. Only the global cluster defaults, the image overrides, the
+// proxy, the trusted CA and the maintenance window affect all of the clusters, the DNS
+// defaults affect only the clusters managing the DNS records.
+func (r *ManagedClusterReconciler) managementClusterRequests(ctx context.Context, oldMgmt, newMgmt *hmc.Management) []ctrl.Request {
+	switch {
+	case !equality.Semantic.DeepEqual(oldMgmt.Spec.GlobalClusterDefaults, newMgmt.Spec.GlobalClusterDefaults),
+		!equality.Semantic.DeepEqual(oldMgmt.Spec.ImageOverrides, newMgmt.Spec.ImageOverrides),
+		!equality.Semantic.DeepEqual(oldMgmt.Spec.Proxy, newMgmt.Spec.Proxy),
+		!equality.Semantic.DeepEqual(oldMgmt.Spec.TrustedCA, newMgmt.Spec.TrustedCA),
+		!equality.Semantic.DeepEqual(oldMgmt.Spec.MaintenanceWindow, newMgmt.Spec.MaintenanceWindow):
+		return r.clusterRequests(ctx, "")
+	case !equality.Semantic.DeepEqual(oldMgmt.Spec.DNS, newMgmt.Spec.DNS):
+		return r.clusterRequests(ctx, "", client.MatchingFields{hmc.DNSKey: "true"})
+	}
 	return nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ManagedClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	shards, err := newShardFilter(mgr, r.Shard)
+	if err != nil {
+		return err
+	}
+	r.shards = shards
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&hmc.ManagedCluster{}).
-		Watches(&hcv2.HelmRelease{},
+		For(&hmc.ManagedCluster{}, builder.WithPredicates(specOrMetadataChanged())).
+		Watches(&hmc.ClusterTemplateChain{},
 			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, o client.Object) []ctrl.Request {
-				managedCluster := hmc.ManagedCluster{}
-				managedClusterRef := client.ObjectKey{
-					Namespace: o.GetNamespace(),
-					Name:      o.GetName(),
+				chain, ok := o.(*hmc.ClusterTemplateChain)
+				if !ok {
+					return nil
 				}
-				err := r.Client.Get(ctx, managedClusterRef, &managedCluster)
-				if err != nil {
-					return []ctrl.Request{}
+
+				// only the clusters of the templates upgradable within the chain gain or lose the upgrades
+				var selectors []client.MatchingFields
+				for _, template := range chain.Spec.SupportedTemplates {
+					if len(template.AvailableUpgrades) > 0 {
+						selectors = append(selectors, client.MatchingFields{hmc.TemplateKey: template.Name})
+					}
 				}
-				return []reconcile.Request{
-					{
-						NamespacedName: managedClusterRef,
-					},
+				if len(selectors) == 0 {
+					return nil
 				}
+				return r.clusterRequests(ctx, chain.Namespace, selectors...)
+			}),
+			builder.WithPredicates(predicate.Funcs{
+				UpdateFunc:  func(event.UpdateEvent) bool { return false },
+				GenericFunc: func(event.GenericEvent) bool { return false },
 			}),
 		).
-		Watches(&hmc.ClusterTemplateChain{},
+		Watches(&hmc.ClusterTemplate{},
 			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, o client.Object) []ctrl.Request {
-				chain, ok := o.(*hmc.ClusterTemplateChain)
+				return r.clusterRequests(ctx, o.GetNamespace(), client.MatchingFields{hmc.TemplateKey: o.GetName()})
+			}),
+			builder.WithPredicates(templateValidityChanged()),
+		).
+		Watches(&hmc.Credential{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, o client.Object) []ctrl.Request {
+				return r.clusterRequests(ctx, o.GetNamespace(), client.MatchingFields{hmc.CredentialKey: o.GetName()})
+			}),
+			builder.WithPredicates(credentialChanged()),
+		).
+		Watches(&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.dnsCredentialsClusters),
+			builder.WithPredicates(predicate.Funcs{
+				// only the changes of the data of the Secret affect the clusters
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					oldSecret, ok := e.ObjectOld.(*corev1.Secret)
+					if !ok {
+						return false
+					}
+					newSecret, ok := e.ObjectNew.(*corev1.Secret)
+					if !ok {
+						return false
+					}
+					return !equality.Semantic.DeepEqual(oldSecret.Data, newSecret.Data)
+				},
+				GenericFunc: func(event.GenericEvent) bool { return false },
+			}),
+		).
+		Watches(&hmc.Management{},
+			handler.Funcs{
+				UpdateFunc: func(ctx context.Context, e event.UpdateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+					oldMgmt, ok := e.ObjectOld.(*hmc.Management)
+					if !ok {
+						return
+					}
+					newMgmt, ok := e.ObjectNew.(*hmc.Management)
+					if !ok {
+						return
+					}
+					for _, req := range r.managementClusterRequests(ctx, oldMgmt, newMgmt) {
+						q.Add(req)
+					}
+				},
+			},
+		).
+		Watches(&hmc.LifecycleHook{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, o client.Object) []ctrl.Request {
+				hook, ok := o.(*hmc.LifecycleHook)
 				if !ok {
 					return nil
 				}
 
+				managedClusters := &hmc.ManagedClusterList{}
+				if err := r.Client.List(ctx, managedClusters, client.InNamespace(hook.Namespace)); err != nil {
+					return []ctrl.Request{}
+				}
+
 				var req []ctrl.Request
-				for _, template := range getTemplateNamesManagedByChain(chain) {
-					managedClusters := &hmc.ManagedClusterList{}
-					err := r.Client.List(ctx, managedClusters,
-						client.InNamespace(chain.Namespace),
-						client.MatchingFields{hmc.TemplateKey: template})
-					if err != nil {
-						return []ctrl.Request{}
-					}
-					for _, cluster := range managedClusters.Items {
-						req = append(req, ctrl.Request{
-							NamespacedName: client.ObjectKey{
-								Namespace: cluster.Namespace,
-								Name:      cluster.Name,
-							},
-						})
+				for _, cluster := range managedClusters.Items {
+					if matches, err := hook.Matches(&cluster); err != nil || !matches {
+						continue
 					}
+					req = append(req, ctrl.Request{
+						NamespacedName: client.ObjectKey{
+							Namespace: cluster.Namespace,
+							Name:      cluster.Name,
+						},
+					})
 				}
 				return req
 			}),
-			builder.WithPredicates(predicate.Funcs{
-				UpdateFunc:  func(event.UpdateEvent) bool { return false },
-				GenericFunc: func(event.GenericEvent) bool { return false },
-			}),
 		).
+		Watches(shards.watchNamespaces(mgr.GetClient(), &hmc.ManagedClusterList{})).
+		WithEventFilter(shards.predicate()).
 		Complete(r)
 }
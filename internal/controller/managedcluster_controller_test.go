@@ -27,6 +27,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	hmc "github.com/Mirantis/hmc/api/v1alpha1"
@@ -158,7 +159,8 @@ var _ = Describe("ManagedCluster Controller", func() {
 			By("Cleanup")
 
 			controllerReconciler := &ManagedClusterReconciler{
-				Client: k8sClient,
+				Client:   k8sClient,
+				Recorder: record.NewFakeRecorder(100),
 			}
 
 			Expect(k8sClient.Delete(ctx, managedCluster)).To(Succeed())
@@ -175,8 +177,9 @@ var _ = Describe("ManagedCluster Controller", func() {
 		It("should successfully reconcile the resource", func() {
 			By("Reconciling the created resource")
 			controllerReconciler := &ManagedClusterReconciler{
-				Client: k8sClient,
-				Config: &rest.Config{},
+				Client:   k8sClient,
+				Config:   &rest.Config{},
+				Recorder: record.NewFakeRecorder(100),
 			}
 
 			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
@@ -0,0 +1,60 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/Mirantis/hmc/internal/errdefs"
+	"github.com/Mirantis/hmc/test/objects/management"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestGlobalClusterDefaults(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	d := &globalClusterDefaults{}
+
+	// no Management, no defaults
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	values, err := d.get(ctx, cl)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(values).To(BeNil())
+
+	mgmt := management.NewManagement(management.WithGlobalClusterDefaults(`{"proxy":{"httpProxy":"http://proxy:3128"}}`))
+	cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(mgmt).Build()
+	values, err = d.get(ctx, cl)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(values).To(Equal(map[string]any{"proxy": map[string]any{"httpProxy": "http://proxy:3128"}}))
+
+	// the callers get their own copy of the cached values
+	values["proxy"].(map[string]any)["httpProxy"] = "changed"
+	values, err = d.get(ctx, cl)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(values).To(Equal(map[string]any{"proxy": map[string]any{"httpProxy": "http://proxy:3128"}}))
+
+	// the values are parsed again once the Management changes, the invalid defaults are terminal
+	mgmt.Spec.GlobalClusterDefaults = &apiextensionsv1.JSON{Raw: []byte(`["proxy"]`)}
+	g.Expect(cl.Update(ctx, mgmt)).To(Succeed())
+	_, err = d.get(ctx, cl)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(errdefs.IsTerminal(err)).To(BeTrue())
+}
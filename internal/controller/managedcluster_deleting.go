@@ -0,0 +1,136 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	hcv2 "github.com/fluxcd/helm-controller/api/v2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/sveltos"
+)
+
+// deletionBlockers returns the objects and the steps the deletion of the ManagedCluster
+// waits for: the drain of the services and the cleanup of the cloud resources run before
+// the HelmRelease is deleted, the HelmRelease, the Profiles of the services, the
+// infrastructure clusters holding the blocking finalizer and the number of the remaining
+// Machines. The HelmRelease is nil once it is removed.
+func (r *ManagedClusterReconciler) deletionBlockers(ctx context.Context, managedCluster *hmc.ManagedCluster, hr *hcv2.HelmRelease) ([]string, error) {
+	var blockers []string
+	// the steps run until the HelmRelease is being deleted, the details are in the conditions of the steps
+	if hr != nil && hr.DeletionTimestamp.IsZero() {
+		if teardownStepPending(managedCluster, hmc.ServicesDrainedCondition) {
+			blockers = append(blockers, "the services drain ("+hmc.ServicesDrainedCondition+")")
+		}
+		if managedCluster.Spec.PreDeleteCleanup != nil && teardownStepPending(managedCluster, hmc.CloudResourcesCleanedCondition) {
+			blockers = append(blockers, "the cloud resources cleanup ("+hmc.CloudResourcesCleanedCondition+")")
+		}
+	}
+	if hr != nil {
+		blockers = append(blockers, hcv2.HelmReleaseKind+"/"+hr.Name)
+	}
+
+	profiles, err := sveltos.ListProfiles(ctx, r.Client, managedCluster.Namespace, map[string]string{hmc.ManagedClusterLabelKey: managedCluster.Name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the Profiles of the ManagedCluster %s/%s: %w", managedCluster.Namespace, managedCluster.Name, err)
+	}
+	for _, profile := range profiles {
+		blockers = append(blockers, "Profile/"+profile)
+	}
+
+	providers, err := r.getInfraProvidersNames(ctx, managedCluster.Namespace, managedCluster.Spec.Template)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		providers = make([]string, 0, len(infraClusterGVKs))
+		for provider := range infraClusterGVKs {
+			providers = append(providers, provider)
+		}
+	}
+	for _, provider := range providers {
+		for _, gvk := range infraClusterGVKs[provider] {
+			cluster, err := r.getCluster(ctx, managedCluster.Namespace, managedCluster.Name, gvk)
+			if err != nil {
+				if apierrors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+					continue
+				}
+				return nil, fmt.Errorf("failed to get %s of the ManagedCluster %s/%s: %w", gvk.Kind, managedCluster.Namespace, managedCluster.Name, err)
+			}
+			blockers = append(blockers, gvk.Kind+"/"+cluster.Name)
+		}
+	}
+
+	machines := &metav1.PartialObjectMetadataList{}
+	machines.SetGroupVersionKind(machineGVK)
+	if err := r.Client.List(ctx, machines, &client.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{hmc.ClusterNameLabelKey: managedCluster.Name}),
+		Namespace:     managedCluster.Namespace,
+	}); err != nil && !apimeta.IsNoMatchError(err) {
+		return nil, fmt.Errorf("failed to list the Machines of the ManagedCluster %s/%s: %w", managedCluster.Namespace, managedCluster.Name, err)
+	}
+	switch n := len(machines.Items); n {
+	case 0:
+	case 1:
+		blockers = append(blockers, "1 Machine")
+	default:
+		blockers = append(blockers, strconv.Itoa(n)+" Machines")
+	}
+
+	return blockers, nil
+}
+
+// teardownStepPending returns true unless the step of the teardown reported in the
+// condition is completed or timed out, see setTeardownStepCondition.
+func teardownStepPending(managedCluster *hmc.ManagedCluster, conditionType string) bool {
+	condition := apimeta.FindStatusCondition(managedCluster.Status.Conditions, conditionType)
+	return condition == nil || (condition.Status != metav1.ConditionTrue && condition.Reason != hmc.TimedOutReason)
+}
+
+// setDeletingCondition reports the objects the deletion of the ManagedCluster waits for.
+func setDeletingCondition(managedCluster *hmc.ManagedCluster, blockers []string) {
+	if len(blockers) == 0 {
+		apimeta.RemoveStatusCondition(managedCluster.GetConditions(), hmc.DeletingCondition)
+		return
+	}
+
+	apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+		Type:    hmc.DeletingCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  hmc.ProgressingReason,
+		Message: "Waiting for the objects to be removed: " + strings.Join(blockers, ", "),
+	})
+}
+
+// reportDeletionBlockers sets the Deleting condition of the ManagedCluster. The failure
+// to collect the blockers does not hold the deletion back, the condition is kept as is.
+func (r *ManagedClusterReconciler) reportDeletionBlockers(ctx context.Context, managedCluster *hmc.ManagedCluster, hr *hcv2.HelmRelease) {
+	blockers, err := r.deletionBlockers(ctx, managedCluster, hr)
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Info("Failed to collect the objects blocking the deletion", "error", err.Error())
+		return
+	}
+	setDeletingCondition(managedCluster, blockers)
+}
@@ -0,0 +1,142 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	hcv2 "github.com/fluxcd/helm-controller/api/v2"
+	. "github.com/onsi/gomega"
+	sveltosv1beta1 "github.com/projectsveltos/addon-controller/api/v1beta1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+	"github.com/Mirantis/hmc/test/objects/template"
+)
+
+func TestDeletionBlockers(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	kinds := append([]schema.GroupVersionKind{machineGVK}, infraClusterGVKs["aws"]...)
+	s := runtime.NewScheme()
+	utilruntime.Must(hmc.AddToScheme(s))
+	utilruntime.Must(sveltosv1beta1.AddToScheme(s))
+	mapper := apimeta.NewDefaultRESTMapper([]schema.GroupVersion{sveltosv1beta1.GroupVersion})
+	mapper.Add(sveltosv1beta1.GroupVersion.WithKind(sveltosv1beta1.ProfileKind), apimeta.RESTScopeNamespace)
+	for _, gvk := range kinds {
+		s.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+		s.AddKnownTypeWithName(gvk.GroupVersion().WithKind(gvk.Kind+"List"), &unstructured.UnstructuredList{})
+		mapper.Add(gvk, apimeta.RESTScopeNamespace)
+	}
+
+	newObject := func(gvk schema.GroupVersionKind, name string, labels map[string]string) client.Object {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		obj.SetNamespace("default")
+		obj.SetName(name)
+		obj.SetLabels(labels)
+		return obj
+	}
+	cl := fake.NewClientBuilder().WithScheme(s).WithRESTMapper(mapper).WithObjects(
+		template.NewClusterTemplate(template.WithName("aws"), template.WithNamespace("default"),
+			template.WithProvidersStatus(hmc.Providers{"infrastructure-aws"})),
+		&sveltosv1beta1.Profile{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default", Name: "dev-kyverno", Labels: map[string]string{hmc.ManagedClusterLabelKey: "dev"},
+		}},
+		newObject(infraClusterGVKs["aws"][0], "dev", map[string]string{hmc.FluxHelmChartNameKey: "dev"}),
+		newObject(machineGVK, "dev-md-0", map[string]string{hmc.ClusterNameLabelKey: "dev"}),
+		newObject(machineGVK, "dev-md-1", map[string]string{hmc.ClusterNameLabelKey: "dev"}),
+		newObject(machineGVK, "prod-md-0", map[string]string{hmc.ClusterNameLabelKey: "prod"}),
+	).Build()
+	r := &ManagedClusterReconciler{Client: cl}
+
+	newManagedCluster := func() *hmc.ManagedCluster {
+		mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"),
+			managedcluster.WithClusterTemplate("aws"))
+		mc.Spec.PreDeleteCleanup = &hmc.PreDeleteCleanupSpec{}
+		return mc
+	}
+	hr := &hcv2.HelmRelease{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "dev"}}
+	objects := []string{"HelmRelease/dev", "Profile/dev-kyverno", "AWSCluster/dev", "2 Machines"}
+
+	// the drain and the cleanup run before the HelmRelease is deleted
+	mc := newManagedCluster()
+	blockers, err := r.deletionBlockers(ctx, mc, hr)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(blockers).To(Equal(append([]string{
+		"the services drain (ServicesDrained)", "the cloud resources cleanup (CloudResourcesCleaned)",
+	}, objects...)))
+
+	// the steps in progress are reported until they complete or time out
+	apimeta.SetStatusCondition(&mc.Status.Conditions, metav1.Condition{
+		Type: hmc.ServicesDrainedCondition, Status: metav1.ConditionTrue, Reason: hmc.SucceededReason,
+	})
+	apimeta.SetStatusCondition(&mc.Status.Conditions, metav1.Condition{
+		Type: hmc.CloudResourcesCleanedCondition, Status: metav1.ConditionFalse, Reason: hmc.ProgressingReason,
+	})
+	blockers, err = r.deletionBlockers(ctx, mc, hr)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(blockers).To(Equal(append([]string{"the cloud resources cleanup (CloudResourcesCleaned)"}, objects...)))
+
+	apimeta.SetStatusCondition(&mc.Status.Conditions, metav1.Condition{
+		Type: hmc.CloudResourcesCleanedCondition, Status: metav1.ConditionFalse, Reason: hmc.TimedOutReason,
+	})
+	blockers, err = r.deletionBlockers(ctx, mc, hr)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(blockers).To(Equal(objects))
+
+	// the cleanup is not waited for unless it is enabled
+	mc = newManagedCluster()
+	mc.Spec.PreDeleteCleanup = nil
+	blockers, err = r.deletionBlockers(ctx, mc, hr)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(blockers).To(Equal(append([]string{"the services drain (ServicesDrained)"}, objects...)))
+
+	// the steps are over once the HelmRelease is being deleted or removed
+	deleting := hr.DeepCopy()
+	deleting.DeletionTimestamp = ptr.To(metav1.Now())
+	blockers, err = r.deletionBlockers(ctx, newManagedCluster(), deleting)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(blockers).To(Equal(objects))
+
+	blockers, err = r.deletionBlockers(ctx, newManagedCluster(), nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(blockers).To(Equal(objects[1:]))
+}
+
+func TestSetDeletingCondition(t *testing.T) {
+	g := NewWithT(t)
+
+	mc := managedcluster.NewManagedCluster()
+	setDeletingCondition(mc, []string{"the services drain (ServicesDrained)", "HelmRelease/dev"})
+	condition := apimeta.FindStatusCondition(mc.Status.Conditions, hmc.DeletingCondition)
+	g.Expect(condition).NotTo(BeNil())
+	g.Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+	g.Expect(condition.Message).To(Equal("Waiting for the objects to be removed: the services drain (ServicesDrained), HelmRelease/dev"))
+
+	setDeletingCondition(mc, nil)
+	g.Expect(apimeta.FindStatusCondition(mc.Status.Conditions, hmc.DeletingCondition)).To(BeNil())
+}
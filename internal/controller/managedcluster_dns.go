@@ -0,0 +1,211 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/credspropagation"
+)
+
+// resolvedDNS is the DNS config of a ManagedCluster with the Management defaults applied.
+type resolvedDNS struct {
+	config *hmc.DNSConfig
+	// credentials is the Secret with the credentials of the DNS provider.
+	credentials *corev1.Secret
+}
+
+// resolveDNS returns the DNS config of the ManagedCluster with the fields not set taken from
+// the DNS config of the Management. The credentials Secret is looked up in the namespace of
+// the ManagedCluster, the Secrets of the system namespace are never written to the clusters.
+func (r *ManagedClusterReconciler) resolveDNS(ctx context.Context, mc *hmc.ManagedCluster) (*resolvedDNS, error) {
+	mgmt := &hmc.Management{}
+	if err := r.Get(ctx, client.ObjectKey{Name: hmc.ManagementName}, mgmt); client.IgnoreNotFound(err) != nil {
+		return nil, fmt.Errorf("failed to get Management: %w", err)
+	}
+
+	config := mc.Spec.DNS.WithDefaults(mgmt.Spec.DNS)
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid DNS config: %w", err)
+	}
+
+	key := client.ObjectKey{Namespace: mc.Namespace, Name: config.CredentialsSecret}
+	credentials := &corev1.Secret{}
+	if err := r.Get(ctx, key, credentials); err != nil {
+		return nil, fmt.Errorf("failed to get DNS credentials Secret %s: %w", key, err)
+	}
+	return &resolvedDNS{config: config, credentials: credentials}, nil
+}
+
+// dnsService returns the service deploying external-dns on the ManagedCluster. The records
+// managed by external-dns are limited to the cluster subdomain of the zone and the keys of
+// the credentials Secret are passed to external-dns in the environment variables along with
+// the proxy, the trusted CA bundle of the Management is mounted to external-dns.
+func dnsService(mc *hmc.ManagedCluster, dns *resolvedDNS, trust *resolvedTrust) (hmc.ServiceSpec, error) {
+	values := map[string]any{
+		"provider":      map[string]any{"name": dns.config.Provider},
+		"domainFilters": []string{dns.config.Domain(mc.Namespace, mc.Name)},
+		"txtOwnerId":    mc.Namespace + "/" + mc.Name,
+		"sources":       []string{"service", "ingress"},
+		"policy":        "sync",
+	}
+
+	env := trust.env()
+	keys := make([]string, 0, len(dns.credentials.Data))
+	for key := range dns.credentials.Data {
+		// the keys not usable as environment variables are kept in the Secret only
+		if len(validation.IsEnvVarName(key)) == 0 {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		env = append(env, corev1.EnvVar{
+			Name: key,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: hmc.DNSCredentialsSecretName},
+					Key:                  key,
+				},
+			},
+		})
+	}
+	if len(env) > 0 {
+		values["env"] = env
+	}
+	if volumes, mounts := trust.volumes(); len(volumes) > 0 {
+		values["extraVolumes"] = volumes
+		values["extraVolumeMounts"] = mounts
+	}
+
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return hmc.ServiceSpec{}, fmt.Errorf("failed to marshal external-dns values: %w", err)
+	}
+
+	merged, err := mergeServiceValues(&apiextensionsv1.JSON{Raw: raw}, dns.config.Values)
+	if err != nil {
+		return hmc.ServiceSpec{}, fmt.Errorf("failed to merge external-dns values: %w", err)
+	}
+
+	return hmc.ServiceSpec{
+		Values:    merged,
+		Template:  dns.config.Template,
+		Name:      hmc.DNSReleaseName,
+		Namespace: hmc.DNSNamespace,
+	}, nil
+}
+
+// reconcileDNS writes the credentials of the DNS provider and the Service publishing the
+// control plane endpoint to the cluster, and records the assigned names in the status.
+// The objects written previously are removed once the DNS integration is disabled,
+// the dns is nil then.
+func (r *ManagedClusterReconciler) reconcileDNS(ctx context.Context, mc *hmc.ManagedCluster, dns *resolvedDNS) error {
+	if dns == nil {
+		if mc.Status.DNS == nil {
+			return nil
+		}
+
+		kubeconfSecret, err := r.getKubeconfigSecret(ctx, mc)
+		if err != nil {
+			return err
+		}
+		if err := credspropagation.RemoveDNSConfigs(ctx, &credspropagation.PropagationCfg{
+			Client:          r.Client,
+			ManagedCluster:  mc,
+			KubeconfSecret:  kubeconfSecret,
+			SystemNamespace: r.SystemNamespace,
+			SecretWriter:    r.secretWriter(),
+		}); err != nil {
+			return err
+		}
+		mc.Status.DNS = nil
+		return nil
+	}
+
+	domain := dns.config.Domain(mc.Namespace, mc.Name)
+	endpointName := "api." + domain
+
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(capiClusterGVK)
+	if err := r.Get(ctx, client.ObjectKeyFromObject(mc), cluster); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get Cluster %s/%s: %w", mc.Namespace, mc.Name, err)
+	}
+	host, _, _ := unstructured.NestedString(cluster.Object, "spec", "controlPlaneEndpoint", "host")
+
+	kubeconfSecret, err := r.getKubeconfigSecret(ctx, mc)
+	if err != nil {
+		return err
+	}
+	if err := credspropagation.PropagateDNSConfigs(ctx, &credspropagation.PropagationCfg{
+		Client:          r.Client,
+		ManagedCluster:  mc,
+		KubeconfSecret:  kubeconfSecret,
+		SystemNamespace: r.SystemNamespace,
+		SecretWriter:    r.secretWriter(),
+	}, dns.credentials, endpointName, host); err != nil {
+		return err
+	}
+
+	mc.Status.DNS = &hmc.ManagedClusterDNSStatus{Domain: domain}
+	if host != "" {
+		mc.Status.DNS.EndpointName = endpointName
+	} else {
+		ctrl.LoggerFrom(ctx).Info("Waiting for the control plane endpoint to publish its DNS name")
+	}
+	return nil
+}
+
+// dnsCredentialsClusters returns the requests of the ManagedClusters of the namespace
+// of the Secret using the Secret as the credentials of the DNS provider.
+func (r *ManagedClusterReconciler) dnsCredentialsClusters(ctx context.Context, o client.Object) []ctrl.Request {
+	managedClusters := &hmc.ManagedClusterList{}
+	if err := r.Client.List(ctx, managedClusters, client.InNamespace(o.GetNamespace()),
+		client.MatchingFields{hmc.DNSKey: "true"}, client.UnsafeDisableDeepCopy); err != nil {
+		return nil
+	}
+
+	var (
+		req      []ctrl.Request
+		defaults *hmc.DNSConfig
+		mgmt     *hmc.Management
+	)
+	for _, cluster := range managedClusters.Items {
+		if mgmt == nil {
+			mgmt = &hmc.Management{}
+			if err := r.Client.Get(ctx, client.ObjectKey{Name: hmc.ManagementName}, mgmt); client.IgnoreNotFound(err) != nil {
+				return nil
+			}
+			defaults = mgmt.Spec.DNS
+		}
+		if cluster.Spec.DNS.WithDefaults(defaults).CredentialsSecret == o.GetName() {
+			req = append(req, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&cluster)})
+		}
+	}
+	return req
+}
@@ -0,0 +1,88 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestResolveDNS(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	mgmt := &hmc.Management{
+		ObjectMeta: metav1.ObjectMeta{Name: hmc.ManagementName},
+		Spec: hmc.ManagementSpec{DNS: &hmc.DNSConfig{
+			Provider:          "aws",
+			Zone:              "clusters.example.com",
+			CredentialsSecret: "route53",
+			Template:          "external-dns-1-15-0",
+		}},
+	}
+	newSecret := func(namespace, name string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Data:       map[string][]byte{"AWS_ACCESS_KEY_ID": []byte(namespace)},
+		}
+	}
+	defaulted := managedcluster.NewManagedCluster(managedcluster.WithName("defaulted"), managedcluster.WithNamespace("team-a"),
+		managedcluster.WithDNS(&hmc.DNSConfig{}))
+	own := managedcluster.NewManagedCluster(managedcluster.WithName("own"), managedcluster.WithNamespace("team-a"),
+		managedcluster.WithDNS(&hmc.DNSConfig{CredentialsSecret: "team-route53"}))
+	other := managedcluster.NewManagedCluster(managedcluster.WithName("other"), managedcluster.WithNamespace("team-b"),
+		managedcluster.WithDNS(&hmc.DNSConfig{}))
+	noDNS := managedcluster.NewManagedCluster(managedcluster.WithName("no-dns"), managedcluster.WithNamespace("team-a"))
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+		WithObjects(mgmt, defaulted, own, other, noDNS,
+			newSecret("hmc-system", "route53"), newSecret("team-a", "route53"), newSecret("team-a", "team-route53")).
+		WithIndex(&hmc.ManagedCluster{}, hmc.DNSKey, hmc.ExtractDNSEnabled).
+		Build()
+	r := &ManagedClusterReconciler{Client: cl, SystemNamespace: "hmc-system"}
+
+	// the default name of the Secret is looked up in the namespace of the cluster
+	dns, err := r.resolveDNS(ctx, defaulted)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(dns.config.Provider).To(Equal("aws"))
+	g.Expect(client.ObjectKeyFromObject(dns.credentials)).To(Equal(client.ObjectKey{Namespace: "team-a", Name: "route53"}))
+
+	dns, err = r.resolveDNS(ctx, own)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(client.ObjectKeyFromObject(dns.credentials)).To(Equal(client.ObjectKey{Namespace: "team-a", Name: "team-route53"}))
+
+	// the Secret of the system namespace is never used for the clusters
+	_, err = r.resolveDNS(ctx, other)
+	g.Expect(err).To(MatchError(ContainSubstring("failed to get DNS credentials Secret team-b/route53")))
+
+	g.Expect(r.dnsCredentialsClusters(ctx, newSecret("team-a", "route53"))).To(Equal([]ctrl.Request{
+		{NamespacedName: client.ObjectKeyFromObject(defaulted)},
+	}))
+	g.Expect(r.dnsCredentialsClusters(ctx, newSecret("team-a", "team-route53"))).To(Equal([]ctrl.Request{
+		{NamespacedName: client.ObjectKeyFromObject(own)},
+	}))
+	g.Expect(r.dnsCredentialsClusters(ctx, newSecret("hmc-system", "route53"))).To(BeEmpty())
+}
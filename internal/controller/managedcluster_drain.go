@@ -0,0 +1,165 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	sveltosv1beta1 "github.com/projectsveltos/addon-controller/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/sveltos"
+)
+
+// drainServices withdraws the services from the cluster, see withdrawServices, and
+// returns true once the services are withdrawn or the drain timed out. The cluster
+// is torn down only afterwards, so that the services are not left behind on the
+// cloud resources of the cluster, e.g. the load balancers and the volumes created
+// on behalf of the services.
+func (r *ManagedClusterReconciler) drainServices(ctx context.Context, managedCluster *hmc.ManagedCluster) (bool, error) {
+	remaining, err := r.withdrawServices(ctx, managedCluster)
+	if err != nil {
+		return false, err
+	}
+
+	done, timedOut := setTeardownStepCondition(managedCluster, hmc.ServicesDrainedCondition, managedCluster.ServicesDrainTimeout(),
+		"The services are withdrawn from the cluster", "Waiting for the services to be removed: "+strings.Join(remaining, ", "), len(remaining) == 0)
+	if timedOut {
+		r.Recorder.Eventf(managedCluster, corev1.EventTypeWarning, EventReasonServicesDrainTimedOut,
+			"The services are not withdrawn from the cluster in %s, tearing the cluster down", managedCluster.ServicesDrainTimeout())
+	}
+	return done, nil
+}
+
+// withdrawServices deletes the Sveltos Profiles of the ManagedCluster and labels its
+// CAPI Cluster with hmc.ServicesDrainingLabelKey, so that the profiles of the
+// MultiClusterServices stop selecting it. It returns the Profiles of the
+// ManagedCluster still existing and the profiles still deploying services on the
+// cluster. The services are withdrawn at any stage of the deletion, also once the
+// HelmRelease is deleted, so that no profile is left behind.
+func (r *ManagedClusterReconciler) withdrawServices(ctx context.Context, managedCluster *hmc.ManagedCluster) ([]string, error) {
+	// Without explicitly deleting the Profile object, we run into a race condition
+	// which prevents Sveltos objects from being removed from the management cluster.
+	// It is detailed in https://github.com/projectsveltos/addon-controller/issues/732.
+	// We may try to remove the explicit call to Delete once a fix for it has been merged.
+	// TODO(https://github.com/Mirantis/hmc/issues/526).
+	if err := sveltos.DeleteProfile(ctx, r.Client, managedCluster.Namespace, managedCluster.Name); err != nil {
+		return nil, err
+	}
+
+	profileLabels := map[string]string{hmc.ManagedClusterLabelKey: managedCluster.Name}
+	if err := sveltos.DeleteProfiles(ctx, r.Client, managedCluster.Namespace, profileLabels); err != nil {
+		return nil, err
+	}
+
+	if err := r.markServicesDraining(ctx, managedCluster); err != nil {
+		return nil, err
+	}
+
+	profiles, err := sveltos.ListProfiles(ctx, r.Client, managedCluster.Namespace, profileLabels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the Profiles of the ManagedCluster %s/%s: %w", managedCluster.Namespace, managedCluster.Name, err)
+	}
+	deployed, err := sveltos.ClusterProfiles(ctx, r.Client, managedCluster.Namespace, managedCluster.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the profiles deploying the services on the ManagedCluster %s/%s: %w", managedCluster.Namespace, managedCluster.Name, err)
+	}
+
+	remaining := make([]string, 0, len(profiles)+len(deployed))
+	for _, profile := range profiles {
+		if name := sveltosv1beta1.ProfileKind + "/" + profile; !slices.Contains(deployed, name) {
+			remaining = append(remaining, name)
+		}
+	}
+	return append(remaining, deployed...), nil
+}
+
+// markServicesDraining labels the CAPI Cluster of the ManagedCluster to be not selected
+// by the Sveltos profiles anymore, nothing is done if the cluster is gone already.
+func (r *ManagedClusterReconciler) markServicesDraining(ctx context.Context, managedCluster *hmc.ManagedCluster) error {
+	cluster, err := r.getCluster(ctx, managedCluster.Namespace, managedCluster.Name, capiClusterGVK)
+	if apierrors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get the Cluster of the ManagedCluster %s/%s: %w", managedCluster.Namespace, managedCluster.Name, err)
+	}
+	if _, ok := cluster.Labels[hmc.ServicesDrainingLabelKey]; ok {
+		return nil
+	}
+
+	patch := client.MergeFrom(cluster.DeepCopy())
+	if cluster.Labels == nil {
+		cluster.Labels = make(map[string]string)
+	}
+	cluster.Labels[hmc.ServicesDrainingLabelKey] = "true"
+	if err := r.Patch(ctx, cluster, patch); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to label the Cluster %s: %w", client.ObjectKeyFromObject(cluster), err)
+	}
+	return nil
+}
+
+// setTeardownStepCondition sets the condition of a step of the teardown of the ManagedCluster
+// and returns true once the step is completed or it timed out. timedOut is true only
+// when the step times out, the transition time of the condition records the start of the step.
+func setTeardownStepCondition(managedCluster *hmc.ManagedCluster, conditionType string, timeout time.Duration, succeededMessage, waitingMessage string, completed bool) (done, timedOut bool) {
+	if completed {
+		apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+			Type:    conditionType,
+			Status:  metav1.ConditionTrue,
+			Reason:  hmc.SucceededReason,
+			Message: succeededMessage,
+		})
+		return true, false
+	}
+
+	condition := apimeta.FindStatusCondition(managedCluster.Status.Conditions, conditionType)
+	if condition != nil && condition.Reason == hmc.TimedOutReason {
+		return true, false
+	}
+
+	started := time.Now()
+	if condition != nil && condition.Status == metav1.ConditionFalse {
+		started = condition.LastTransitionTime.Time
+	}
+
+	if time.Since(started) >= timeout {
+		apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+			Type:    conditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  hmc.TimedOutReason,
+			Message: fmt.Sprintf("Timed out after %s. %s", timeout, waitingMessage),
+		})
+		return true, true
+	}
+
+	apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+		Type:               conditionType,
+		Status:             metav1.ConditionFalse,
+		Reason:             hmc.ProgressingReason,
+		Message:            waitingMessage,
+		LastTransitionTime: metav1.NewTime(started),
+	})
+	return false, false
+}
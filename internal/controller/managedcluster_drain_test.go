@@ -0,0 +1,123 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	sveltosv1beta1 "github.com/projectsveltos/addon-controller/api/v1beta1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/sveltos"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+)
+
+// drainTestScheme returns the scheme of the Sveltos objects and the CAPI Clusters the drain works with.
+func drainTestScheme() *runtime.Scheme {
+	s := runtime.NewScheme()
+	utilruntime.Must(sveltosv1beta1.AddToScheme(s))
+	s.AddKnownTypeWithName(capiClusterGVK, &unstructured.Unstructured{})
+	s.AddKnownTypeWithName(capiClusterGVK.GroupVersion().WithKind(capiClusterGVK.Kind+"List"), &unstructured.UnstructuredList{})
+	return s
+}
+
+func TestDrainServices(t *testing.T) {
+	g := NewWithT(t)
+
+	mapper := apimeta.NewDefaultRESTMapper([]schema.GroupVersion{sveltosv1beta1.GroupVersion, capiClusterGVK.GroupVersion()})
+	mapper.Add(sveltosv1beta1.GroupVersion.WithKind(sveltosv1beta1.ProfileKind), apimeta.RESTScopeNamespace)
+	mapper.Add(sveltosv1beta1.GroupVersion.WithKind(sveltosv1beta1.ClusterSummaryKind), apimeta.RESTScopeNamespace)
+	mapper.Add(capiClusterGVK, apimeta.RESTScopeNamespace)
+
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"))
+
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(capiClusterGVK)
+	cluster.SetNamespace("default")
+	cluster.SetName("dev")
+	cluster.SetLabels(map[string]string{hmc.FluxHelmChartNameKey: "dev"})
+
+	clusterSummary := func(name string, labels map[string]string) *sveltosv1beta1.ClusterSummary {
+		labels[sveltosv1beta1.ClusterNameLabel] = "dev"
+		return &sveltosv1beta1.ClusterSummary{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: labels}}
+	}
+	mcsSummary := clusterSummary("mcs-summary", map[string]string{sveltos.ClusterProfileLabelKey: "mcs"})
+
+	cl := fake.NewClientBuilder().WithScheme(drainTestScheme()).WithRESTMapper(mapper).WithObjects(
+		cluster,
+		&sveltosv1beta1.Profile{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default", Name: "dev-kyverno", Labels: map[string]string{hmc.ManagedClusterLabelKey: "dev"},
+		}},
+		clusterSummary("dev-summary", map[string]string{sveltos.ProfileLabelKey: "dev-kyverno"}),
+		mcsSummary,
+	).Build()
+	r := &ManagedClusterReconciler{Client: cl, Recorder: record.NewFakeRecorder(10)}
+	ctx := context.Background()
+
+	// the Profiles of the cluster are deleted and the cluster is not selected by the other profiles anymore
+	drained, err := r.drainServices(ctx, mc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(drained).To(BeFalse())
+	g.Expect(sveltos.ListProfiles(ctx, cl, "default", map[string]string{hmc.ManagedClusterLabelKey: "dev"})).To(BeEmpty())
+	g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(cluster), cluster)).To(Succeed())
+	g.Expect(cluster.GetLabels()).To(HaveKeyWithValue(hmc.ServicesDrainingLabelKey, "true"))
+
+	condition := apimeta.FindStatusCondition(mc.Status.Conditions, hmc.ServicesDrainedCondition)
+	g.Expect(condition).NotTo(BeNil())
+	g.Expect(condition.Reason).To(Equal(hmc.ProgressingReason))
+	g.Expect(condition.Message).To(ContainSubstring("ClusterProfile/mcs"))
+	g.Expect(condition.Message).To(ContainSubstring("Profile/dev-kyverno"))
+
+	// the drain completes once Sveltos withdraws the services of all of the profiles
+	g.Expect(cl.DeleteAllOf(ctx, &sveltosv1beta1.ClusterSummary{}, client.InNamespace("default"))).To(Succeed())
+	drained, err = r.drainServices(ctx, mc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(drained).To(BeTrue())
+	g.Expect(apimeta.IsStatusConditionTrue(mc.Status.Conditions, hmc.ServicesDrainedCondition)).To(BeTrue())
+}
+
+func TestWithdrawServicesWithoutCluster(t *testing.T) {
+	g := NewWithT(t)
+
+	mapper := apimeta.NewDefaultRESTMapper([]schema.GroupVersion{sveltosv1beta1.GroupVersion, capiClusterGVK.GroupVersion()})
+	mapper.Add(sveltosv1beta1.GroupVersion.WithKind(sveltosv1beta1.ProfileKind), apimeta.RESTScopeNamespace)
+	mapper.Add(sveltosv1beta1.GroupVersion.WithKind(sveltosv1beta1.ClusterSummaryKind), apimeta.RESTScopeNamespace)
+	mapper.Add(capiClusterGVK, apimeta.RESTScopeNamespace)
+
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"))
+	cl := fake.NewClientBuilder().WithScheme(drainTestScheme()).WithRESTMapper(mapper).WithObjects(
+		&sveltosv1beta1.Profile{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default", Name: "dev-kyverno", Labels: map[string]string{hmc.ManagedClusterLabelKey: "dev"},
+		}},
+	).Build()
+	r := &ManagedClusterReconciler{Client: cl, Recorder: record.NewFakeRecorder(10)}
+
+	// the Profiles are removed also once the cluster is torn down
+	remaining, err := r.withdrawServices(context.Background(), mc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(remaining).To(BeEmpty())
+	g.Expect(sveltos.ListProfiles(context.Background(), cl, "default", map[string]string{hmc.ManagedClusterLabelKey: "dev"})).To(BeEmpty())
+}
@@ -0,0 +1,77 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/utils"
+)
+
+// updateEndpointStatus records the control plane endpoint reported by the CAPI Cluster and
+// the fingerprint of the CA certificate of the kubeconfig Secret in the status. The fields
+// are kept unset until the Cluster reports the endpoint and the Secret is created. The invalid
+// kubeconfig is reported in the KubeconfigValid condition, the last fingerprint is kept then.
+func (r *ManagedClusterReconciler) updateEndpointStatus(ctx context.Context, mc *hmc.ManagedCluster) error {
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(capiClusterGVK)
+	if err := r.Get(ctx, client.ObjectKeyFromObject(mc), cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get Cluster %s/%s: %w", mc.Namespace, mc.Name, err)
+	}
+
+	host, _, _ := unstructured.NestedString(cluster.Object, "spec", "controlPlaneEndpoint", "host")
+	port, _, _ := unstructured.NestedInt64(cluster.Object, "spec", "controlPlaneEndpoint", "port")
+	if host != "" {
+		mc.Status.ControlPlaneEndpoint = &hmc.APIEndpoint{Host: host, Port: int32(port)}
+	}
+
+	secret, err := r.getKubeconfigSecret(ctx, mc)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	fingerprint, err := utils.KubeconfigCAFingerprint(secret.Data["value"])
+	if err != nil {
+		apimeta.SetStatusCondition(&mc.Status.Conditions, metav1.Condition{
+			Type:    hmc.KubeconfigValidCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  hmc.FailedReason,
+			Message: fmt.Sprintf("Failed to get the CA fingerprint of the kubeconfig Secret %s: %v", secret.Name, err),
+		})
+		return nil
+	}
+	mc.Status.CAFingerprint = fingerprint
+	apimeta.SetStatusCondition(&mc.Status.Conditions, metav1.Condition{
+		Type:    hmc.KubeconfigValidCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  hmc.SucceededReason,
+		Message: "The kubeconfig is valid",
+	})
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestUpdateEndpointStatus(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"))
+	mc.Status.CAFingerprint = "sha256:previous"
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "dev-kubeconfig", Namespace: "default"},
+		Data:       map[string][]byte{"value": []byte("invalid")},
+	}
+
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(capiClusterGVK)
+	cluster.SetNamespace("default")
+	cluster.SetName("dev")
+
+	r := &ManagedClusterReconciler{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(secret, cluster).Build()}
+
+	// the invalid kubeconfig is reported in the condition instead of failing the reconciliation
+	g.Expect(r.updateEndpointStatus(ctx, mc)).To(Succeed())
+	condition := apimeta.FindStatusCondition(mc.Status.Conditions, hmc.KubeconfigValidCondition)
+	g.Expect(condition).NotTo(BeNil())
+	g.Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+	g.Expect(condition.Message).To(HavePrefix("Failed to get the CA fingerprint of the kubeconfig Secret dev-kubeconfig: "))
+	g.Expect(mc.Status.CAFingerprint).To(Equal("sha256:previous"))
+
+	// the fields are kept unset until the Cluster and the Secret are created
+	mc = managedcluster.NewManagedCluster(managedcluster.WithName("new"), managedcluster.WithNamespace("default"))
+	g.Expect(r.updateEndpointStatus(ctx, mc)).To(Succeed())
+	g.Expect(mc.Status.Conditions).To(BeEmpty())
+	g.Expect(mc.Status.CAFingerprint).To(BeEmpty())
+}
@@ -0,0 +1,241 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/credspropagation"
+	"github.com/Mirantis/hmc/internal/errdefs"
+)
+
+const (
+	defaultArgoCDNamespace = "argocd"
+
+	argoCDSecretTypeLabel = "argocd.argoproj.io/secret-type"
+	argoCDSecretTypeValue = "cluster"
+)
+
+// argoCDClusterConfig is the connection config of an ArgoCD cluster Secret.
+type argoCDClusterConfig struct {
+	BearerToken     string                `json:"bearerToken,omitempty"`
+	TLSClientConfig argoCDTLSClientConfig `json:"tlsClientConfig"`
+}
+
+type argoCDTLSClientConfig struct {
+	Insecure   bool   `json:"insecure,omitempty"`
+	ServerName string `json:"serverName,omitempty"`
+	CAData     []byte `json:"caData,omitempty"`
+	CertData   []byte `json:"certData,omitempty"`
+	KeyData    []byte `json:"keyData,omitempty"`
+}
+
+// reconcileGitOpsRegistration ensures the Secret registering the ready cluster in the GitOps tooling
+// and removes the previous registration once the registration is changed or disabled.
+func (r *ManagedClusterReconciler) reconcileGitOpsRegistration(ctx context.Context, managedCluster *hmc.ManagedCluster) error {
+	registration := managedCluster.Spec.GitOps
+	if registration == nil {
+		if err := r.deleteGitOpsRegistration(ctx, managedCluster); err != nil {
+			return err
+		}
+		apimeta.RemoveStatusCondition(managedCluster.GetConditions(), hmc.GitOpsRegisteredCondition)
+		return nil
+	}
+
+	kubeconfigSecret, err := r.getKubeconfigSecret(ctx, managedCluster)
+	if err != nil {
+		return err
+	}
+
+	secret, err := gitOpsSecret(managedCluster, registration, kubeconfigSecret.Data["value"])
+	if err != nil {
+		return r.setGitOpsRegisteredFailed(managedCluster, errdefs.Terminal(err))
+	}
+
+	if !slices.Contains(r.GitOpsNamespaces, secret.Namespace) {
+		return r.setGitOpsRegisteredFailed(managedCluster, errdefs.Terminal(fmt.Errorf("the clusters may not be registered in namespace %s, the GitOps namespaces are %v", secret.Namespace, r.GitOpsNamespaces)))
+	}
+
+	if err := r.checkGitOpsSecretOwner(ctx, managedCluster, client.ObjectKeyFromObject(secret)); err != nil {
+		return r.setGitOpsRegisteredFailed(managedCluster, err)
+	}
+
+	if prev := managedCluster.Status.GitOpsSecret; prev != nil && (prev.Namespace != secret.Namespace || prev.Name != secret.Name) {
+		if err := r.deleteGitOpsRegistration(ctx, managedCluster); err != nil {
+			return err
+		}
+	}
+
+	if secret.Namespace == managedCluster.Namespace {
+		if err := controllerutil.SetControllerReference(managedCluster, secret, r.Client.Scheme()); err != nil {
+			return err
+		}
+	}
+	if _, err := r.secretWriter().Write(ctx, r.Client, credspropagation.Scope(managedCluster), secret); err != nil {
+		return r.setGitOpsRegisteredFailed(managedCluster, fmt.Errorf("failed to reconcile GitOps registration Secret %s/%s: %w", secret.Namespace, secret.Name, err))
+	}
+
+	managedCluster.Status.GitOpsSecret = &corev1.SecretReference{Namespace: secret.Namespace, Name: secret.Name}
+	apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+		Type:    hmc.GitOpsRegisteredCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  hmc.SucceededReason,
+		Message: fmt.Sprintf("Registered in %s with Secret %s/%s", registration.Provider, secret.Namespace, secret.Name),
+	})
+	return nil
+}
+
+func (*ManagedClusterReconciler) setGitOpsRegisteredFailed(managedCluster *hmc.ManagedCluster, err error) error {
+	apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+		Type:    hmc.GitOpsRegisteredCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  hmc.FailedReason,
+		Message: err.Error(),
+	})
+	return err
+}
+
+// checkGitOpsSecretOwner returns a terminal error if the Secret with the given key exists
+// and does not register the ManagedCluster, e.g. the Secret is created by the user.
+func (r *ManagedClusterReconciler) checkGitOpsSecretOwner(ctx context.Context, managedCluster *hmc.ManagedCluster, key client.ObjectKey) error {
+	secret := &metav1.PartialObjectMetadata{}
+	secret.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+	if err := r.Get(ctx, key, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get Secret %s: %w", key, err)
+	}
+
+	if owner := secret.GetAnnotations()[hmc.GitOpsRegistrationAnnotation]; owner != client.ObjectKeyFromObject(managedCluster).String() {
+		return errdefs.Terminal(fmt.Errorf("secret %s already exists and does not register the cluster", key))
+	}
+	return nil
+}
+
+// deleteGitOpsRegistration deletes the Secret registering the cluster in the GitOps tooling.
+// The Secrets outside of the namespace of the ManagedCluster are not garbage collected.
+// The Secret is left in place if it does not register the cluster.
+func (r *ManagedClusterReconciler) deleteGitOpsRegistration(ctx context.Context, managedCluster *hmc.ManagedCluster) error {
+	ref := managedCluster.Status.GitOpsSecret
+	if ref == nil {
+		return nil
+	}
+
+	key := client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}
+	if err := r.checkGitOpsSecretOwner(ctx, managedCluster, key); err != nil {
+		if !errdefs.IsTerminal(err) {
+			return err
+		}
+		ctrl.LoggerFrom(ctx).Info("Leaving the GitOps registration Secret not registering the cluster in place", "secret", key)
+		managedCluster.Status.GitOpsSecret = nil
+		return nil
+	}
+
+	if err := r.secretWriter().Delete(ctx, r.Client, credspropagation.Scope(managedCluster), key); err != nil {
+		return fmt.Errorf("failed to delete GitOps registration Secret %s: %w", key, err)
+	}
+
+	managedCluster.Status.GitOpsSecret = nil
+	return nil
+}
+
+// gitOpsSecretName returns the name of the Secret registering the cluster in the GitOps tooling.
+// The name is suffixed with the hash of the UID of the ManagedCluster, so that it does not collide
+// with the Secrets of the other clusters and the ones created by the users.
+func gitOpsSecretName(managedCluster *hmc.ManagedCluster, provider hmc.GitOpsProvider) string {
+	sum := sha256.Sum256([]byte(managedCluster.UID))
+	suffix := fmt.Sprintf("-%s-%x", strings.ToLower(string(provider)), sum[:5])
+	name := managedCluster.Name
+	if maxLen := validation.DNS1123SubdomainMaxLength - len(suffix); len(name) > maxLen {
+		name = strings.TrimRight(name[:maxLen], "-.")
+	}
+	return name + suffix
+}
+
+// gitOpsSecret returns the Secret registering the cluster with the given kubeconfig in the GitOps tooling.
+func gitOpsSecret(managedCluster *hmc.ManagedCluster, registration *hmc.GitOpsRegistration, kubeconfig []byte) (*corev1.Secret, error) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: registration.Namespace,
+			Labels: map[string]string{
+				hmc.HMCManagedLabelKey: hmc.HMCManagedLabelValue,
+			},
+			Annotations: map[string]string{
+				hmc.GitOpsRegistrationAnnotation: client.ObjectKeyFromObject(managedCluster).String(),
+			},
+		},
+	}
+	maps.Copy(secret.Labels, registration.Labels)
+
+	switch registration.Provider {
+	case hmc.GitOpsProviderArgoCD:
+		if secret.Namespace == "" {
+			secret.Namespace = defaultArgoCDNamespace
+		}
+		secret.Name = gitOpsSecretName(managedCluster, registration.Provider)
+		secret.Labels[argoCDSecretTypeLabel] = argoCDSecretTypeValue
+
+		restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the kubeconfig of the cluster: %w", err)
+		}
+		config, err := json.Marshal(argoCDClusterConfig{
+			BearerToken: restConfig.BearerToken,
+			TLSClientConfig: argoCDTLSClientConfig{
+				Insecure:   restConfig.Insecure,
+				ServerName: restConfig.ServerName,
+				CAData:     restConfig.CAData,
+				CertData:   restConfig.CertData,
+				KeyData:    restConfig.KeyData,
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal the ArgoCD cluster config: %w", err)
+		}
+		secret.Data = map[string][]byte{
+			"name":   []byte(managedCluster.Namespace + "-" + managedCluster.Name),
+			"server": []byte(restConfig.Host),
+			"config": config,
+		}
+	case hmc.GitOpsProviderFlux:
+		if secret.Namespace == "" {
+			secret.Namespace = managedCluster.Namespace
+		}
+		secret.Name = gitOpsSecretName(managedCluster, registration.Provider)
+		secret.Data = map[string][]byte{"value": kubeconfig}
+	default:
+		return nil, fmt.Errorf("unsupported GitOps provider %q", registration.Provider)
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/errdefs"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+const gitOpsTestKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com:6443
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: dev
+current-context: dev
+users:
+- name: dev
+  user:
+    token: secret-token
+`
+
+// updateSecretWriter writes the Secrets with the plain updates, since the fake client does not create objects on apply.
+type updateSecretWriter struct{}
+
+func (updateSecretWriter) Write(ctx context.Context, c client.Client, _ string, secret *corev1.Secret) (bool, error) {
+	existing := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(secret), existing); err != nil {
+		return true, c.Create(ctx, secret.DeepCopy())
+	}
+	secret = secret.DeepCopy()
+	secret.ResourceVersion = existing.ResourceVersion
+	if err := c.Update(ctx, secret); err != nil {
+		return false, err
+	}
+	return secret.ResourceVersion != existing.ResourceVersion, nil
+}
+
+func (updateSecretWriter) Delete(ctx context.Context, c client.Client, _ string, key client.ObjectKey) error {
+	return client.IgnoreNotFound(c.Delete(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}}))
+}
+
+func TestGitOpsSecretName(t *testing.T) {
+	g := NewWithT(t)
+
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"))
+	mc.UID = "uid"
+
+	// the names of the clusters named alike in the different namespaces do not collide
+	other := mc.DeepCopy()
+	other.UID = "other-uid"
+	g.Expect(gitOpsSecretName(mc, hmc.GitOpsProviderFlux)).To(HavePrefix("dev-flux-"))
+	g.Expect(gitOpsSecretName(mc, hmc.GitOpsProviderFlux)).NotTo(Equal(gitOpsSecretName(other, hmc.GitOpsProviderFlux)))
+	g.Expect(gitOpsSecretName(mc, hmc.GitOpsProviderFlux)).NotTo(Equal(mc.Name + "-kubeconfig"))
+
+	// the long names are truncated
+	mc.Name = strings.Repeat("a", 300)
+	g.Expect(len(gitOpsSecretName(mc, hmc.GitOpsProviderArgoCD))).To(Equal(253))
+}
+
+func TestReconcileGitOpsRegistration(t *testing.T) {
+	ctx := context.Background()
+
+	newCluster := func(registration *hmc.GitOpsRegistration) *hmc.ManagedCluster {
+		mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"))
+		mc.UID = "uid"
+		mc.Spec.GitOps = registration
+		return mc
+	}
+	kubeconfig := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "dev-kubeconfig"},
+		Data:       map[string][]byte{"value": []byte(gitOpsTestKubeconfig)},
+	}
+
+	t.Run("registers the cluster in ArgoCD", func(t *testing.T) {
+		g := NewWithT(t)
+
+		mc := newCluster(&hmc.GitOpsRegistration{Provider: hmc.GitOpsProviderArgoCD, Labels: map[string]string{"env": "dev"}})
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(kubeconfig).Build()
+		r := &ManagedClusterReconciler{Client: cl, SecretWriter: updateSecretWriter{}, GitOpsNamespaces: []string{"argocd"}}
+
+		g.Expect(r.reconcileGitOpsRegistration(ctx, mc)).To(Succeed())
+		g.Expect(mc.Status.GitOpsSecret).NotTo(BeNil())
+		g.Expect(apimeta.IsStatusConditionTrue(mc.Status.Conditions, hmc.GitOpsRegisteredCondition)).To(BeTrue())
+
+		secret := &corev1.Secret{}
+		g.Expect(cl.Get(ctx, client.ObjectKey{Namespace: "argocd", Name: mc.Status.GitOpsSecret.Name}, secret)).To(Succeed())
+		g.Expect(secret.Labels).To(HaveKeyWithValue(argoCDSecretTypeLabel, argoCDSecretTypeValue))
+		g.Expect(secret.Labels).To(HaveKeyWithValue("env", "dev"))
+		g.Expect(secret.Annotations).To(HaveKeyWithValue(hmc.GitOpsRegistrationAnnotation, "default/dev"))
+		g.Expect(string(secret.Data["server"])).To(Equal("https://dev.example.com:6443"))
+
+		// the registration is removed once disabled
+		mc.Spec.GitOps = nil
+		g.Expect(r.reconcileGitOpsRegistration(ctx, mc)).To(Succeed())
+		g.Expect(mc.Status.GitOpsSecret).To(BeNil())
+		g.Expect(apimeta.FindStatusCondition(mc.Status.Conditions, hmc.GitOpsRegisteredCondition)).To(BeNil())
+		g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(secret), &corev1.Secret{})).NotTo(Succeed())
+	})
+
+	t.Run("refuses the namespaces not allowed", func(t *testing.T) {
+		g := NewWithT(t)
+
+		mc := newCluster(&hmc.GitOpsRegistration{Provider: hmc.GitOpsProviderFlux})
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(kubeconfig).Build()
+		r := &ManagedClusterReconciler{Client: cl, SecretWriter: updateSecretWriter{}, GitOpsNamespaces: []string{"argocd"}}
+
+		err := r.reconcileGitOpsRegistration(ctx, mc)
+		g.Expect(errdefs.IsTerminal(err)).To(BeTrue())
+		g.Expect(mc.Status.GitOpsSecret).To(BeNil())
+		g.Expect(apimeta.IsStatusConditionFalse(mc.Status.Conditions, hmc.GitOpsRegisteredCondition)).To(BeTrue())
+	})
+
+	t.Run("leaves the Secrets of the others in place", func(t *testing.T) {
+		g := NewWithT(t)
+
+		mc := newCluster(&hmc.GitOpsRegistration{Provider: hmc.GitOpsProviderFlux})
+		foreign := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: gitOpsSecretName(mc, hmc.GitOpsProviderFlux)},
+			Data:       map[string][]byte{"value": []byte("user data")},
+		}
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(kubeconfig, foreign).Build()
+		r := &ManagedClusterReconciler{Client: cl, SecretWriter: updateSecretWriter{}, GitOpsNamespaces: []string{"default"}}
+
+		// the existing Secret is not overwritten
+		err := r.reconcileGitOpsRegistration(ctx, mc)
+		g.Expect(errdefs.IsTerminal(err)).To(BeTrue())
+		g.Expect(apimeta.IsStatusConditionFalse(mc.Status.Conditions, hmc.GitOpsRegisteredCondition)).To(BeTrue())
+
+		secret := &corev1.Secret{}
+		g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(foreign), secret)).To(Succeed())
+		g.Expect(secret.Data).To(HaveKeyWithValue("value", []byte("user data")))
+
+		// nor deleted along with the registration
+		mc.Spec.GitOps = nil
+		mc.Status.GitOpsSecret = &corev1.SecretReference{Namespace: foreign.Namespace, Name: foreign.Name}
+		g.Expect(r.reconcileGitOpsRegistration(ctx, mc)).To(Succeed())
+		g.Expect(mc.Status.GitOpsSecret).To(BeNil())
+		g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(foreign), &corev1.Secret{})).To(Succeed())
+	})
+}
@@ -0,0 +1,145 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+func TestSetMachineHealthCheckHelmValues(t *testing.T) {
+	values := &apiextensionsv1.JSON{Raw: []byte(`{"workersNumber":2}`)}
+
+	for _, tc := range []struct {
+		name           string
+		mhc            *hmc.MachineHealthCheckSpec
+		expectedValues string
+	}{
+		{
+			name:           "not set",
+			expectedValues: `{"workersNumber":2}`,
+		},
+		{
+			name:           "defaults of the chart",
+			mhc:            &hmc.MachineHealthCheckSpec{},
+			expectedValues: `{"workersNumber":2,"machineHealthCheck":{"enabled":true}}`,
+		},
+		{
+			name: "conditions and timeouts",
+			mhc: &hmc.MachineHealthCheckSpec{
+				UnhealthyConditions: []hmc.UnhealthyCondition{
+					{Type: corev1.NodeReady, Status: corev1.ConditionFalse, Timeout: metav1.Duration{Duration: 5 * time.Minute}},
+					{Type: corev1.NodeReady, Status: corev1.ConditionUnknown, Timeout: metav1.Duration{Duration: 90 * time.Second}},
+				},
+				NodeStartupTimeout: &metav1.Duration{Duration: 20 * time.Minute},
+			},
+			expectedValues: `{"workersNumber":2,"machineHealthCheck":{"enabled":true,
+				"unhealthyConditions":[
+					{"type":"Ready","status":"False","timeout":"5m0s"},
+					{"type":"Ready","status":"Unknown","timeout":"1m30s"}
+				],
+				"nodeStartupTimeout":"20m0s"}}`,
+		},
+		{
+			name:           "max unhealthy number",
+			mhc:            &hmc.MachineHealthCheckSpec{MaxUnhealthy: ptr.To(intstr.FromInt32(2))},
+			expectedValues: `{"workersNumber":2,"machineHealthCheck":{"enabled":true,"maxUnhealthy":2}}`,
+		},
+		{
+			name:           "max unhealthy percentage",
+			mhc:            &hmc.MachineHealthCheckSpec{MaxUnhealthy: ptr.To(intstr.FromString("40%"))},
+			expectedValues: `{"workersNumber":2,"machineHealthCheck":{"enabled":true,"maxUnhealthy":"40%"}}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			result, err := setMachineHealthCheckHelmValues(values, tc.mhc)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(string(result.Raw)).To(MatchJSON(tc.expectedValues))
+		})
+	}
+}
+
+func TestMachineHealthCheckTemplates(t *testing.T) {
+	g := NewWithT(t)
+
+	templates, err := filepath.Glob(filepath.Join("..", "..", "templates", "cluster", "*", "templates", "machinehealthcheck.yaml"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(templates).NotTo(BeEmpty())
+
+	values, err := setMachineHealthCheckHelmValues(&apiextensionsv1.JSON{Raw: []byte(`{}`)}, &hmc.MachineHealthCheckSpec{
+		MaxUnhealthy:       ptr.To(intstr.FromString("40%")),
+		NodeStartupTimeout: &metav1.Duration{Duration: 10 * time.Minute},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	for _, template := range templates {
+		chartPath := filepath.Dir(filepath.Dir(template))
+		t.Run(filepath.Base(chartPath), func(t *testing.T) {
+			g := NewWithT(t)
+
+			chart, err := loader.Load(chartPath)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			render := func(values map[string]any) string {
+				t.Helper()
+				renderValues, err := chartutil.ToRenderValues(chart, values,
+					chartutil.ReleaseOptions{Name: "dev", Namespace: "default"}, chartutil.DefaultCapabilities)
+				g.Expect(err).NotTo(HaveOccurred())
+				manifests, err := engine.Render(chart, renderValues)
+				g.Expect(err).NotTo(HaveOccurred())
+				return manifests[filepath.Join(chart.Name(), "templates", "machinehealthcheck.yaml")]
+			}
+
+			// the MachineHealthCheck is not rendered by default
+			g.Expect(strings.TrimSpace(render(nil))).To(BeEmpty())
+
+			// and is rendered once enabled with the values of the ManagedCluster
+			var enabled map[string]any
+			g.Expect(json.Unmarshal(values.Raw, &enabled)).To(Succeed())
+
+			var mhc struct {
+				Kind string `json:"kind"`
+				Spec struct {
+					UnhealthyConditions []map[string]any   `json:"unhealthyConditions"`
+					MaxUnhealthy        intstr.IntOrString `json:"maxUnhealthy"`
+					NodeStartupTimeout  string             `json:"nodeStartupTimeout"`
+				} `json:"spec"`
+			}
+			g.Expect(yaml.Unmarshal([]byte(render(enabled)), &mhc)).To(Succeed())
+			g.Expect(mhc.Kind).To(Equal("MachineHealthCheck"))
+			g.Expect(mhc.Spec.UnhealthyConditions).To(HaveLen(2))
+			g.Expect(mhc.Spec.MaxUnhealthy).To(Equal(intstr.FromString("40%")))
+			g.Expect(mhc.Spec.NodeStartupTimeout).To(Equal("10m0s"))
+		})
+	}
+}
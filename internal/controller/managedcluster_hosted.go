@@ -0,0 +1,82 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/errdefs"
+)
+
+// k0smotronControlPlaneGVK is the kind of the control plane hosted within the management cluster.
+var k0smotronControlPlaneGVK = schema.GroupVersionKind{
+	Group:   "controlplane.cluster.x-k8s.io",
+	Version: "v1beta1",
+	Kind:    "K0smotronControlPlane",
+}
+
+// hostedIgnoredConditions are the conditions of the CAPI Cluster computed from
+// the control plane Machines, which are never created for the hosted control planes.
+var hostedIgnoredConditions = map[string]bool{
+	"ControlPlaneInitialized": true,
+}
+
+// hostedControlPlaneExists returns true if the hosted control plane of the cluster is not removed yet.
+func (r *ManagedClusterReconciler) hostedControlPlaneExists(ctx context.Context, namespace, name string) (bool, error) {
+	list := &metav1.PartialObjectMetadataList{}
+	list.SetGroupVersionKind(k0smotronControlPlaneGVK)
+	if err := r.Client.List(ctx, list, &client.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{hmc.FluxHelmChartNameKey: name}),
+		Namespace:     namespace,
+		Limit:         1,
+	}); err != nil {
+		if apimeta.IsNoMatchError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to list %s objects: %w", k0smotronControlPlaneGVK.Kind, err)
+	}
+	return len(list.Items) != 0, nil
+}
+
+// getKubeconfigSecret returns the Secret with the kubeconfig of the cluster. The Secret of the
+// hosted control planes is created once the control plane is running regardless of the Machines,
+// the cluster is waited for if the Secret does not exist yet. The managed control planes (EKS, AKS)
+// additionally provide the "-user-kubeconfig" Secret relying on the exec credential plugins,
+// the Secret used here is maintained by the infrastructure provider for the controllers instead,
+// e.g. with the short-lived token of EKS refreshed by CAPA.
+func (r *ManagedClusterReconciler) getKubeconfigSecret(ctx context.Context, managedCluster *hmc.ManagedCluster) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, client.ObjectKey{
+		Name:      managedCluster.Name + "-kubeconfig",
+		Namespace: managedCluster.Namespace,
+	}, secret); err != nil {
+		err = fmt.Errorf("failed to get kubeconfig secret for cluster %s/%s: %w", managedCluster.Namespace, managedCluster.Name, err)
+		if managedCluster.Status.HostedControlPlane && apierrors.IsNotFound(err) {
+			return nil, errdefs.Waiting(err)
+		}
+		return nil, err
+	}
+	return secret, nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/errdefs"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+// newStatusDynamicClient returns the fake dynamic client serving the objects
+// the status of the ManagedClusters is computed from.
+func newStatusDynamicClient(objs ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	listKinds := map[schema.GroupVersionResource]string{
+		{Group: "cluster.x-k8s.io", Version: "v1beta1", Resource: "clusters"}: "ClusterList",
+	}
+	for _, kind := range managedControlPlaneKinds {
+		listKinds[kind.gvk.GroupVersion().WithResource(kind.resource)] = kind.gvk.Kind + "List"
+	}
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objs...)
+}
+
+// newStatusObject returns the object of the given kind deployed for the ManagedCluster with the given conditions.
+func newStatusObject(gvk schema.GroupVersionKind, mc *hmc.ManagedCluster, conditions ...metav1.Condition) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetNamespace(mc.Namespace)
+	obj.SetName(mc.Name)
+	obj.SetLabels(map[string]string{
+		hmc.FluxHelmChartNameKey:      mc.Name,
+		hmc.FluxHelmChartNamespaceKey: mc.Namespace,
+	})
+
+	unstrConditions := make([]any, 0, len(conditions))
+	for _, c := range conditions {
+		unstrConditions = append(unstrConditions, map[string]any{
+			"type":               c.Type,
+			"status":             string(c.Status),
+			"reason":             c.Reason,
+			"lastTransitionTime": "2024-01-01T00:00:00Z",
+		})
+	}
+	obj.Object["status"] = map[string]any{"conditions": unstrConditions}
+	return obj
+}
+
+func TestSetStatusFromClusterStatusHosted(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"))
+	cluster := newStatusObject(capiClusterGVK, mc,
+		metav1.Condition{Type: "Ready", Status: metav1.ConditionTrue},
+		metav1.Condition{Type: "ControlPlaneInitialized", Status: metav1.ConditionFalse, Reason: "WaitingForControlPlane"},
+	)
+	dynamicClient := newStatusDynamicClient(cluster)
+
+	// the cluster waits for the control plane Machines
+	requeue, err := setStatusFromClusterStatus(ctx, dynamicClient, mc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(requeue).To(BeTrue())
+
+	// the hosted control plane has no Machines, the condition is not awaited
+	mc = managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"))
+	mc.Status.HostedControlPlane = true
+	requeue, err = setStatusFromClusterStatus(ctx, dynamicClient, mc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(requeue).To(BeFalse())
+	g.Expect(apimeta.FindStatusCondition(mc.Status.Conditions, "ControlPlaneInitialized")).To(BeNil())
+	g.Expect(apimeta.IsStatusConditionTrue(mc.Status.Conditions, "Ready")).To(BeTrue())
+}
+
+func TestHostedControlPlaneExists(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	// the k0smotron types are not vendored, the control planes are stored as unstructured
+	cpScheme := runtime.NewScheme()
+	cpScheme.AddKnownTypeWithName(k0smotronControlPlaneGVK, &unstructured.Unstructured{})
+	cpScheme.AddKnownTypeWithName(k0smotronControlPlaneGVK.GroupVersion().WithKind(k0smotronControlPlaneGVK.Kind+"List"), &unstructured.UnstructuredList{})
+
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"))
+	controlPlane := newStatusObject(k0smotronControlPlaneGVK, mc)
+	r := &ManagedClusterReconciler{Client: fake.NewClientBuilder().WithScheme(cpScheme).WithObjects(controlPlane).Build()}
+
+	found, err := r.hostedControlPlaneExists(ctx, mc.Namespace, mc.Name)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(found).To(BeTrue())
+
+	found, err = r.hostedControlPlaneExists(ctx, mc.Namespace, "other")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(found).To(BeFalse())
+
+	found, err = r.hostedControlPlaneExists(ctx, "other", mc.Name)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(found).To(BeFalse())
+}
+
+func TestGetKubeconfigSecretHosted(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"))
+	r := &ManagedClusterReconciler{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()}
+
+	// the missing Secret is an error for the clusters with the Machines
+	_, err := r.getKubeconfigSecret(ctx, mc)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(errdefs.IsWaiting(err)).To(BeFalse())
+
+	// the hosted control plane is waited for to create the Secret
+	mc.Status.HostedControlPlane = true
+	_, err = r.getKubeconfigSecret(ctx, mc)
+	g.Expect(errdefs.IsWaiting(err)).To(BeTrue())
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: mc.Namespace, Name: mc.Name + "-kubeconfig"}}
+	g.Expect(r.Client.Create(ctx, secret)).To(Succeed())
+	found, err := r.getKubeconfigSecret(ctx, mc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(found.Name).To(Equal(secret.Name))
+}
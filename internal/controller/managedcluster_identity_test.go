@@ -0,0 +1,70 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/errdefs"
+	"github.com/Mirantis/hmc/test/objects/credential"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestIdentityHelmValues(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	newCredential := func(name string, state hmc.CredentialState) *hmc.Credential {
+		return credential.NewCredential(credential.WithName(name), credential.WithNamespace("default"), credential.WithState(state),
+			credential.WithIdentityRef(&corev1.ObjectReference{Kind: "AWSClusterRoleIdentity", Name: name + "-identity"}))
+	}
+	cred, cpCred := newCredential("cred", hmc.CredentialReady), newCredential("cp-cred", hmc.CredentialReady)
+	template := &hmc.ClusterTemplate{Status: hmc.ClusterTemplateStatus{IdentityValuesPaths: []string{"cluster.identityRef", "controlPlane.identityRef"}}}
+	mc := managedcluster.NewManagedCluster(managedcluster.WithNamespace("default"), managedcluster.WithCredential(cred.Name),
+		managedcluster.WithIdentityCredential("controlPlane.identityRef", cpCred.Name))
+
+	r := &ManagedClusterReconciler{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(cpCred).Build()}
+	identities, err := r.identityRefs(ctx, mc, template, cred)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	values, err := setIdentityHelmValues(&apiextensionsv1.JSON{Raw: []byte(`{"cluster":{"region":"us-east-2"}}`)}, identities)
+	g.Expect(err).NotTo(HaveOccurred())
+	var valuesJSON map[string]any
+	g.Expect(json.Unmarshal(values.Raw, &valuesJSON)).To(Succeed())
+	g.Expect(valuesJSON).To(Equal(map[string]any{
+		"cluster":      map[string]any{"region": "us-east-2", "identityRef": map[string]any{"kind": "AWSClusterRoleIdentity", "name": "cred-identity"}},
+		"controlPlane": map[string]any{"identityRef": map[string]any{"kind": "AWSClusterRoleIdentity", "name": "cp-cred-identity"}},
+	}))
+
+	// the Credentials of the identity values must be ready
+	cpCred.Status.State = hmc.CredentialNotFound
+	r.Client = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(cpCred).Build()
+	_, err = r.identityRefs(ctx, mc, template, cred)
+	g.Expect(err).To(MatchError("the Credential cp-cred of the identity value controlPlane.identityRef is not in Ready state"))
+	g.Expect(errdefs.IsTerminal(err)).To(BeTrue())
+
+	r.Client = fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	_, err = r.identityRefs(ctx, mc, template, cred)
+	g.Expect(errdefs.IsTerminal(err)).To(BeTrue())
+}
@@ -0,0 +1,140 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/errdefs"
+	"github.com/Mirantis/hmc/internal/helm"
+	"github.com/Mirantis/hmc/internal/instances"
+)
+
+// checkImages looks up the machine images pinned in the node pools in the cloud
+// with the credentials of the cluster and reports the outcome in the ImagesValid
+// condition. The images are checked once per generation of the ManagedCluster, the
+// images not applied by the template are not checked.
+func (r *ManagedClusterReconciler) checkImages(ctx context.Context, managedCluster *hmc.ManagedCluster, template *hmc.ClusterTemplate, cred *hmc.Credential, values map[string]any) error {
+	l := ctrl.LoggerFrom(ctx)
+
+	var pools []hmc.NodePoolSpec
+	for _, pool := range managedCluster.Spec.NodePools {
+		if pool.ImageRef != nil && helm.NodePoolImageApplied(template.Status.ConfigSections, pool) {
+			pools = append(pools, pool)
+		}
+	}
+	if len(pools) == 0 {
+		apimeta.RemoveStatusCondition(managedCluster.GetConditions(), hmc.ImagesValidCondition)
+		return nil
+	}
+
+	cond := apimeta.FindStatusCondition(managedCluster.Status.Conditions, hmc.ImagesValidCondition)
+	if cond != nil && cond.Status == metav1.ConditionTrue && cond.ObservedGeneration == managedCluster.Generation {
+		return nil
+	}
+
+	providers, err := r.getInfraProvidersNames(ctx, managedCluster.Namespace, managedCluster.Spec.Template)
+	if err != nil {
+		return err
+	}
+
+	var checker instances.ImageChecker
+	for _, provider := range providers {
+		if c, ok := r.imageCheckers()[provider]; ok {
+			checker = c
+			break
+		}
+	}
+	if checker == nil {
+		l.Info("Skipping the check of the machine images", "providers", providers)
+		return nil
+	}
+
+	location := instances.ImageLocation{
+		Region:         cmp.Or(stringValue(values, "region"), stringValue(values, "location")),
+		SubscriptionID: stringValue(values, "subscriptionID"),
+	}
+
+	var notFound []string
+	for _, pool := range pools {
+		err := checker.CheckImage(ctx, r.Client, cred.ClusterIdentityRef(), location, *pool.ImageRef)
+		switch {
+		case err == nil:
+		case errors.Is(err, instances.ErrUnsupported):
+			l.Info("Skipping the check of the machine image", "pool", pool.Name, "reason", err.Error())
+		case errors.Is(err, instances.ErrImageNotFound):
+			notFound = append(notFound, fmt.Sprintf("node pool %s: %s", pool.Name, err))
+		default:
+			apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+				Type:               hmc.ImagesValidCondition,
+				Status:             metav1.ConditionUnknown,
+				Reason:             hmc.FailedReason,
+				ObservedGeneration: managedCluster.Generation,
+				Message:            fmt.Sprintf("Failed to check the image of the node pool %s: %s", pool.Name, err),
+			})
+			return err
+		}
+	}
+
+	if len(notFound) > 0 {
+		msg := strings.Join(notFound, "; ")
+		r.Recorder.Eventf(managedCluster, corev1.EventTypeWarning, EventReasonImageNotFound,
+			"The machine images are not available: %s", msg)
+		apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+			Type:               hmc.ImagesValidCondition,
+			Status:             metav1.ConditionFalse,
+			Reason:             hmc.ImageNotFoundReason,
+			ObservedGeneration: managedCluster.Generation,
+			Message:            msg,
+		})
+		// the cluster is reconciled again once the node pools are updated
+		return errdefs.Terminal(errors.New(msg))
+	}
+
+	apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+		Type:               hmc.ImagesValidCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             hmc.SucceededReason,
+		ObservedGeneration: managedCluster.Generation,
+		Message:            "The machine images are available",
+	})
+	return nil
+}
+
+func (r *ManagedClusterReconciler) imageCheckers() map[string]instances.ImageChecker {
+	if r.ImageCheckers == nil {
+		return map[string]instances.ImageChecker{
+			"aws":   &instances.AWSVerifier{SecretNamespace: r.SystemNamespace},
+			"azure": &instances.AzureImageChecker{},
+		}
+	}
+	return r.ImageCheckers
+}
+
+// stringValue returns the string value at the top level of the values.
+func stringValue(values map[string]any, key string) string {
+	s, _ := values[key].(string)
+	return s
+}
@@ -0,0 +1,128 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"slices"
+	"time"
+
+	hcv2 "github.com/fluxcd/helm-controller/api/v2"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/yaml"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/errdefs"
+	"github.com/Mirantis/hmc/internal/helm"
+)
+
+// maintenanceWindowOpen returns true if the changes of the deployed ManagedCluster may be
+// applied now, the window of the Management is used if the cluster does not define its own.
+// Otherwise it returns the time the window opens at.
+func maintenanceWindowOpen(managedCluster *hmc.ManagedCluster, mgmt *hmc.Management, now time.Time) (bool, time.Time, error) {
+	window := managedCluster.Spec.MaintenanceWindow
+	if window == nil {
+		window = mgmt.Spec.MaintenanceWindow
+	}
+	if window == nil {
+		return true, time.Time{}, nil
+	}
+
+	open, err := window.IsOpen(now)
+	if err != nil {
+		return false, time.Time{}, errdefs.Terminal(err)
+	}
+	if open {
+		return true, time.Time{}, nil
+	}
+
+	next, err := window.NextOpening(now)
+	if err != nil {
+		return false, time.Time{}, errdefs.Terminal(err)
+	}
+	return false, next, nil
+}
+
+// helmReleaseChanged returns true if the HelmRelease is to be updated with the chart and the values
+// of the given options. With the values reused the values are compared to the current values merged
+// with the given ones, as they are applied, so that the change is no longer pending once applied.
+func helmReleaseChanged(hr *hcv2.HelmRelease, opts helm.ReconcileHelmReleaseOpts) bool {
+	if !equality.Semantic.DeepEqual(hr.Spec.ChartRef, opts.ChartRef) {
+		return true
+	}
+
+	values := opts.Values
+	if opts.ReuseValues {
+		reused, _, err := helm.ReusedValues(hr, opts.Values)
+		if err != nil {
+			return true
+		}
+		values = reused
+	}
+
+	var current, desired map[string]any
+	if hr.Spec.Values != nil {
+		if err := yaml.Unmarshal(hr.Spec.Values.Raw, &current); err != nil {
+			return true
+		}
+	}
+	if values != nil {
+		if err := yaml.Unmarshal(values.Raw, &desired); err != nil {
+			return true
+		}
+	}
+	return !equality.Semantic.DeepEqual(current, desired)
+}
+
+// setPendingChange records the change of the ManagedCluster held back until the maintenance window opens.
+func setPendingChange(managedCluster *hmc.ManagedCluster, change string, nextWindow time.Time) {
+	pending := managedCluster.Status.PendingMaintenance
+	if pending == nil {
+		pending = &hmc.PendingMaintenance{}
+		managedCluster.Status.PendingMaintenance = pending
+	}
+	if !slices.Contains(pending.Changes, change) {
+		pending.Changes = append(pending.Changes, change)
+		slices.Sort(pending.Changes)
+	}
+	pending.NextWindow = &metav1.Time{Time: nextWindow}
+}
+
+// clearPendingChange removes the applied change of the ManagedCluster from the pending ones.
+func clearPendingChange(managedCluster *hmc.ManagedCluster, change string) {
+	pending := managedCluster.Status.PendingMaintenance
+	if pending == nil {
+		return
+	}
+	pending.Changes = slices.DeleteFunc(pending.Changes, func(c string) bool { return c == change })
+	if len(pending.Changes) == 0 {
+		managedCluster.Status.PendingMaintenance = nil
+	}
+}
+
+// requeueForMaintenance requeues the ManagedCluster with the pending changes once the maintenance window opens.
+func requeueForMaintenance(result ctrl.Result, managedCluster *hmc.ManagedCluster) ctrl.Result {
+	pending := managedCluster.Status.PendingMaintenance
+	if pending == nil || pending.NextWindow == nil || result.Requeue {
+		return result
+	}
+
+	after := max(time.Until(pending.NextWindow.Time), time.Second)
+	if result.RequeueAfter == 0 || after < result.RequeueAfter {
+		result.RequeueAfter = after
+	}
+	return result
+}
@@ -0,0 +1,54 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+
+	hcv2 "github.com/fluxcd/helm-controller/api/v2"
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/helm"
+)
+
+func TestHelmReleaseChanged(t *testing.T) {
+	g := NewWithT(t)
+
+	chartRef := &hcv2.CrossNamespaceSourceReference{Kind: "HelmChart", Name: "chart", Namespace: "default"}
+	hr := &hcv2.HelmRelease{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{hmc.AppliedValuesAnnotation: `[["workersNumber"]]`}},
+		Spec: hcv2.HelmReleaseSpec{
+			ChartRef: chartRef,
+			// the region has been set out of HMC and is kept while the values are reused
+			Values: &apiextensionsv1.JSON{Raw: []byte(`{"region":"us-east-1","workersNumber":2}`)},
+		},
+	}
+
+	opts := helm.ReconcileHelmReleaseOpts{ChartRef: chartRef, Values: &apiextensionsv1.JSON{Raw: []byte(`{"workersNumber":2}`)}}
+	g.Expect(helmReleaseChanged(hr, opts)).To(BeTrue())
+
+	opts.ReuseValues = true
+	g.Expect(helmReleaseChanged(hr, opts)).To(BeFalse())
+
+	opts.Values = &apiextensionsv1.JSON{Raw: []byte(`{"workersNumber":3}`)}
+	g.Expect(helmReleaseChanged(hr, opts)).To(BeTrue())
+
+	opts.Values = &apiextensionsv1.JSON{Raw: []byte(`{"workersNumber":2}`)}
+	opts.ChartRef = &hcv2.CrossNamespaceSourceReference{Kind: "HelmChart", Name: "chart-v2", Namespace: "default"}
+	g.Expect(helmReleaseChanged(hr, opts)).To(BeTrue())
+}
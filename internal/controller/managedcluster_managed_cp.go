@@ -0,0 +1,127 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/utils/status"
+)
+
+// managedControlPlaneKind describes the control plane of a managed Kubernetes service.
+type managedControlPlaneKind struct {
+	gvk      schema.GroupVersionKind
+	resource string
+	// readyCondition is the condition of the control plane reporting the state
+	// of the cluster in the managed Kubernetes service.
+	readyCondition string
+}
+
+// managedControlPlaneKinds are the control planes of the managed Kubernetes
+// services (EKS and AKS) keyed by the infrastructure provider.
+var managedControlPlaneKinds = map[string]managedControlPlaneKind{
+	"aws": {
+		gvk:            schema.GroupVersionKind{Group: "controlplane.cluster.x-k8s.io", Version: "v1beta2", Kind: "AWSManagedControlPlane"},
+		resource:       "awsmanagedcontrolplanes",
+		readyCondition: "EKSControlPlaneReady",
+	},
+	"azure": {
+		gvk:            schema.GroupVersionKind{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta1", Kind: "AzureManagedControlPlane"},
+		resource:       "azuremanagedcontrolplanes",
+		readyCondition: "ManagedClusterRunning",
+	},
+}
+
+// managedControlPlaneSelector selects the objects deployed for the ManagedCluster,
+// the control planes are listed in the namespace of the cluster only.
+func managedControlPlaneSelector(managedCluster *hmc.ManagedCluster) labels.Selector {
+	return labels.SelectorFromSet(map[string]string{hmc.FluxHelmChartNameKey: managedCluster.Name})
+}
+
+// getManagedControlPlane returns the kind of the managed control plane of the cluster
+// or nil if the control plane of the cluster is not provided by a managed Kubernetes service.
+func (r *ManagedClusterReconciler) getManagedControlPlane(ctx context.Context, managedCluster *hmc.ManagedCluster) (*managedControlPlaneKind, error) {
+	providers, err := r.getInfraProvidersNames(ctx, managedCluster.Namespace, managedCluster.Spec.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, provider := range providers {
+		kind, ok := managedControlPlaneKinds[provider]
+		if !ok {
+			continue
+		}
+
+		list := &metav1.PartialObjectMetadataList{}
+		list.SetGroupVersionKind(kind.gvk)
+		if err := r.Client.List(ctx, list, &client.ListOptions{
+			LabelSelector: managedControlPlaneSelector(managedCluster),
+			Namespace:     managedCluster.Namespace,
+			Limit:         1,
+		}); err != nil {
+			if apimeta.IsNoMatchError(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to list %s objects: %w", kind.gvk.Kind, err)
+		}
+		if len(list.Items) != 0 {
+			return &kind, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// setStatusFromManagedControlPlane copies the ready condition of the managed control plane,
+// if any, to the ManagedCluster status. It returns true if the condition is not satisfied yet.
+func setStatusFromManagedControlPlane(
+	ctx context.Context, dynamicClient dynamic.Interface, managedCluster *hmc.ManagedCluster,
+) (bool, error) {
+	for _, kind := range managedControlPlaneKinds {
+		resourceConditions, err := status.GetResourceConditions(ctx, managedCluster.Namespace, dynamicClient, kind.gvk.GroupVersion().WithResource(kind.resource),
+			managedControlPlaneSelector(managedCluster).String())
+		if err != nil {
+			notFoundErr := status.ResourceNotFoundError{}
+			if errors.As(err, &notFoundErr) {
+				continue
+			}
+			return false, fmt.Errorf("failed to get conditions of %s: %w", kind.gvk.Kind, err)
+		}
+
+		condition := apimeta.FindStatusCondition(resourceConditions.Conditions, kind.readyCondition)
+		if condition == nil {
+			ctrl.LoggerFrom(ctx).V(1).Info("Managed control plane reports no ready condition", "kind", kind.gvk.Kind, "condition", kind.readyCondition)
+			return true, nil
+		}
+		if condition.Reason == "" && condition.Status == metav1.ConditionTrue {
+			condition.Reason = hmc.SucceededReason
+		}
+		apimeta.SetStatusCondition(managedCluster.GetConditions(), *condition)
+		return condition.Status != metav1.ConditionTrue, nil
+	}
+
+	return false, nil
+}
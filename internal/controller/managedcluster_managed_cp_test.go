@@ -0,0 +1,98 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+	"github.com/Mirantis/hmc/test/objects/template"
+)
+
+func TestSetStatusFromManagedControlPlane(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	eks := managedControlPlaneKinds["aws"]
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"))
+
+	// the clusters without a managed control plane are not waited for
+	requeue, err := setStatusFromManagedControlPlane(ctx, newStatusDynamicClient(), mc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(requeue).To(BeFalse())
+
+	// the control plane of the cluster with the same name in another namespace is ignored
+	other := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("other"))
+	otherCP := newStatusObject(eks.gvk, other, metav1.Condition{Type: eks.readyCondition, Status: metav1.ConditionFalse, Reason: "Creating"})
+	requeue, err = setStatusFromManagedControlPlane(ctx, newStatusDynamicClient(otherCP), mc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(requeue).To(BeFalse())
+	g.Expect(mc.Status.Conditions).To(BeEmpty())
+
+	// the ready condition of the control plane is copied to the cluster
+	cp := newStatusObject(eks.gvk, mc, metav1.Condition{Type: eks.readyCondition, Status: metav1.ConditionFalse, Reason: "Creating"})
+	requeue, err = setStatusFromManagedControlPlane(ctx, newStatusDynamicClient(cp, otherCP), mc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(requeue).To(BeTrue())
+	g.Expect(apimeta.FindStatusCondition(mc.Status.Conditions, eks.readyCondition)).To(HaveField("Reason", "Creating"))
+
+	cp = newStatusObject(eks.gvk, mc, metav1.Condition{Type: eks.readyCondition, Status: metav1.ConditionTrue})
+	requeue, err = setStatusFromManagedControlPlane(ctx, newStatusDynamicClient(cp, otherCP), mc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(requeue).To(BeFalse())
+	g.Expect(apimeta.FindStatusCondition(mc.Status.Conditions, eks.readyCondition)).To(HaveField("Reason", hmc.SucceededReason))
+}
+
+func TestGetManagedControlPlane(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	eks := managedControlPlaneKinds["aws"]
+
+	// the CAPA types are not vendored, the control planes are stored as unstructured
+	cpScheme := runtime.NewScheme()
+	g.Expect(hmc.AddToScheme(cpScheme)).To(Succeed())
+	cpScheme.AddKnownTypeWithName(eks.gvk, &unstructured.Unstructured{})
+	cpScheme.AddKnownTypeWithName(eks.gvk.GroupVersion().WithKind(eks.gvk.Kind+"List"), &unstructured.UnstructuredList{})
+
+	tpl := template.NewClusterTemplate(template.WithName("aws-eks"), template.WithNamespace("default"))
+	tpl.Status.Providers = hmc.Providers{"infrastructure-aws"}
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"),
+		managedcluster.WithClusterTemplate(tpl.Name))
+	other := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("other"))
+
+	cl := fake.NewClientBuilder().WithScheme(cpScheme).WithObjects(tpl, newStatusObject(eks.gvk, other)).Build()
+	r := &ManagedClusterReconciler{Client: cl}
+
+	// the control plane of the cluster in another namespace is not found
+	kind, err := r.getManagedControlPlane(ctx, mc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(kind).To(BeNil())
+
+	g.Expect(cl.Create(ctx, newStatusObject(eks.gvk, mc))).To(Succeed())
+	kind, err = r.getManagedControlPlane(ctx, mc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(kind).NotTo(BeNil())
+	g.Expect(kind.gvk).To(Equal(eks.gvk))
+}
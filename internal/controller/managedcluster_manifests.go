@@ -0,0 +1,149 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// maxStoredManifestsSize is the maximum total size of the rendered manifests kept for the history
+// of a ManagedCluster, the manifests of the oldest history entries are dropped over the size.
+const maxStoredManifestsSize = 2 * maxConfigMapDataSize
+
+// manifestsConfigMapName returns the name of the ConfigMap with the rendered manifests
+// of the given generation of the ManagedCluster.
+func manifestsConfigMapName(managedCluster *hmc.ManagedCluster, generation int64) string {
+	return fmt.Sprintf("%s-manifests-%d", managedCluster.Name, generation)
+}
+
+// recordManifests renders the manifests of the release deployed with the given values into
+// the ConfigMap of the current generation of the ManagedCluster and returns the name of the
+// ConfigMap. The name is empty if the manifests are too large to be stored in a ConfigMap.
+// The manifests are rendered once per generation, the updates of the release not caused
+// by the changes of the spec reuse the manifests recorded for the generation.
+func (r *ManagedClusterReconciler) recordManifests(
+	ctx context.Context,
+	actionConfig *action.Configuration,
+	managedCluster *hmc.ManagedCluster,
+	hcChart *chart.Chart,
+	helmValues *apiextensionsv1.JSON,
+) (string, error) {
+	if name := generationManifests(managedCluster); name != "" {
+		return name, nil
+	}
+
+	values := make(map[string]any)
+	if helmValues != nil {
+		if err := json.Unmarshal(helmValues.Raw, &values); err != nil {
+			return "", fmt.Errorf("failed to unmarshal the values of the release: %w", err)
+		}
+	}
+
+	rel, err := validateReleaseWithValues(ctx, actionConfig, managedCluster, hcChart, values)
+	if err != nil {
+		return "", fmt.Errorf("failed to render the manifests of the release: %w", err)
+	}
+	if len(rel.Manifest) > maxConfigMapDataSize {
+		ctrl.LoggerFrom(ctx).Info("Rendered manifests are too large to be stored in a ConfigMap", "size", len(rel.Manifest))
+		return "", nil
+	}
+
+	name := manifestsConfigMapName(managedCluster, managedCluster.Generation)
+	if err := r.writePreviewConfigMap(ctx, managedCluster, name, map[string]string{
+		hmc.ManagedClusterLabelKey:           managedCluster.Name,
+		hmc.ManagedClusterGenerationLabelKey: strconv.FormatInt(managedCluster.Generation, 10),
+	}, map[string]string{
+		manifestsConfigMapKey: rel.Manifest,
+	}); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// generationManifests returns the name of the ConfigMap with the manifests recorded for
+// the current generation of the ManagedCluster or an empty string if there is none.
+func generationManifests(managedCluster *hmc.ManagedCluster) string {
+	for i := len(managedCluster.Status.History) - 1; i >= 0; i-- {
+		entry := managedCluster.Status.History[i]
+		if entry.Generation == managedCluster.Generation && entry.ManifestsConfigMap != "" {
+			return entry.ManifestsConfigMap
+		}
+	}
+	return ""
+}
+
+// pruneManifests removes the ConfigMaps with the rendered manifests not referenced by the history
+// of the ManagedCluster anymore. The manifests of the oldest history entries are dropped along
+// with the references to them once the total size of the manifests exceeds maxStoredManifestsSize.
+func (r *ManagedClusterReconciler) pruneManifests(ctx context.Context, managedCluster *hmc.ManagedCluster) error {
+	configMaps := &corev1.ConfigMapList{}
+	if err := r.Client.List(ctx, configMaps, client.InNamespace(managedCluster.Namespace), client.MatchingLabels{
+		hmc.ManagedClusterLabelKey: managedCluster.Name,
+	}, client.HasLabels{hmc.ManagedClusterGenerationLabelKey}); err != nil {
+		return fmt.Errorf("failed to list the manifests ConfigMaps of ManagedCluster %s/%s: %w", managedCluster.Namespace, managedCluster.Name, err)
+	}
+
+	sizes := make(map[string]int, len(configMaps.Items))
+	for _, configMap := range configMaps.Items {
+		sizes[configMap.Name] = len(configMap.Data[manifestsConfigMapKey])
+	}
+
+	// the newest manifests are kept within the size
+	referenced := make(map[string]struct{}, len(managedCluster.Status.History))
+	total := 0
+	for i := len(managedCluster.Status.History) - 1; i >= 0; i-- {
+		entry := &managedCluster.Status.History[i]
+		if entry.ManifestsConfigMap == "" {
+			continue
+		}
+		if _, ok := referenced[entry.ManifestsConfigMap]; ok {
+			continue
+		}
+		if total+sizes[entry.ManifestsConfigMap] > maxStoredManifestsSize {
+			entry.ManifestsConfigMap = ""
+			continue
+		}
+		total += sizes[entry.ManifestsConfigMap]
+		referenced[entry.ManifestsConfigMap] = struct{}{}
+	}
+	// the older entries of the same generation refer to the kept manifests only
+	for i := range managedCluster.Status.History {
+		if _, ok := referenced[managedCluster.Status.History[i].ManifestsConfigMap]; !ok {
+			managedCluster.Status.History[i].ManifestsConfigMap = ""
+		}
+	}
+
+	for _, configMap := range configMaps.Items {
+		if _, ok := referenced[configMap.Name]; ok {
+			continue
+		}
+		if err := r.Client.Delete(ctx, &configMap); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete ConfigMap %s/%s: %w", configMap.Namespace, configMap.Name, err)
+		}
+	}
+	return nil
+}
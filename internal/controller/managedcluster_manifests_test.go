@@ -0,0 +1,99 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestGenerationManifests(t *testing.T) {
+	g := NewWithT(t)
+
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"))
+	mc.Generation = 2
+	g.Expect(generationManifests(mc)).To(BeEmpty())
+
+	// the manifests are reused within the generation only
+	mc.Status.History = []hmc.ManagedClusterHistoryEntry{
+		{Generation: 1, ManifestsConfigMap: "dev-manifests-1"},
+		{Generation: 2, ManifestsConfigMap: "dev-manifests-2"},
+		{Generation: 2},
+	}
+	g.Expect(generationManifests(mc)).To(Equal("dev-manifests-2"))
+	mc.Generation = 3
+	g.Expect(generationManifests(mc)).To(BeEmpty())
+}
+
+func TestPruneManifests(t *testing.T) {
+	g := NewWithT(t)
+
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"))
+	configMap := func(generation, size int) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      manifestsConfigMapName(mc, int64(generation)),
+				Labels: map[string]string{
+					hmc.ManagedClusterLabelKey:           mc.Name,
+					hmc.ManagedClusterGenerationLabelKey: "1",
+				},
+			},
+			Data: map[string]string{manifestsConfigMapKey: strings.Repeat("a", size)},
+		}
+	}
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		configMap(1, 10),
+		configMap(2, maxConfigMapDataSize),
+		configMap(3, maxConfigMapDataSize*3/4),
+		configMap(4, maxConfigMapDataSize*3/4),
+		configMap(5, 10),
+	).Build()
+	mc.Status.History = []hmc.ManagedClusterHistoryEntry{
+		{Generation: 2, ManifestsConfigMap: manifestsConfigMapName(mc, 2)},
+		{Generation: 3, ManifestsConfigMap: manifestsConfigMapName(mc, 3)},
+		{Generation: 3, ManifestsConfigMap: manifestsConfigMapName(mc, 3)},
+		{Generation: 4, ManifestsConfigMap: manifestsConfigMapName(mc, 4)},
+	}
+
+	r := &ManagedClusterReconciler{Client: cl}
+	g.Expect(r.pruneManifests(context.Background(), mc)).To(Succeed())
+
+	// the oldest manifests over the size are dropped along with the ones not referenced
+	g.Expect(mc.Status.History).To(HaveExactElements(
+		HaveField("ManifestsConfigMap", ""),
+		HaveField("ManifestsConfigMap", manifestsConfigMapName(mc, 3)),
+		HaveField("ManifestsConfigMap", manifestsConfigMapName(mc, 3)),
+		HaveField("ManifestsConfigMap", manifestsConfigMapName(mc, 4)),
+	))
+	configMaps := &corev1.ConfigMapList{}
+	g.Expect(cl.List(context.Background(), configMaps, client.InNamespace("default"))).To(Succeed())
+	g.Expect(configMaps.Items).To(ConsistOf(
+		HaveField("Name", manifestsConfigMapName(mc, 3)),
+		HaveField("Name", manifestsConfigMapName(mc, 4)),
+	))
+}
@@ -0,0 +1,70 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+)
+
+func TestReconcileClusterMetadata(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"))
+	mc.Spec.ClusterLabels = map[string]string{"team": "a", "env": "dev"}
+	mc.Spec.ClusterAnnotations = map[string]string{"owner": "team-a"}
+
+	// the CAPI types are not vendored, the Clusters are stored as unstructured
+	capiScheme := runtime.NewScheme()
+	capiScheme.AddKnownTypeWithName(capiClusterGVK, &unstructured.Unstructured{})
+	capiScheme.AddKnownTypeWithName(capiClusterGVK.GroupVersion().WithKind(capiClusterGVK.Kind+"List"), &unstructured.UnstructuredList{})
+	cl := fake.NewClientBuilder().WithScheme(capiScheme).Build()
+	r := &ManagedClusterReconciler{Client: cl}
+
+	// nothing is propagated until the Cluster is created
+	g.Expect(r.reconcileClusterMetadata(ctx, mc)).To(Succeed())
+
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(capiClusterGVK)
+	cluster.SetNamespace(mc.Namespace)
+	cluster.SetName(mc.Name)
+	cluster.SetLabels(map[string]string{hmc.FluxHelmChartNameKey: mc.Name, "team": "b"})
+	g.Expect(cl.Create(ctx, cluster)).To(Succeed())
+
+	// the metadata is propagated as soon as the Cluster exists
+	g.Expect(r.reconcileClusterMetadata(ctx, mc)).To(Succeed())
+	g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(cluster), cluster)).To(Succeed())
+	g.Expect(cluster.GetLabels()).To(Equal(map[string]string{hmc.FluxHelmChartNameKey: mc.Name, "team": "a", "env": "dev"}))
+	g.Expect(cluster.GetAnnotations()).To(HaveKeyWithValue("owner", "team-a"))
+	g.Expect(cluster.GetAnnotations()).To(HaveKeyWithValue(hmc.PropagatedLabelsAnnotation, "env,team"))
+
+	// the keys dropped from the spec are removed
+	mc.Spec.ClusterLabels = map[string]string{"team": "a"}
+	mc.Spec.ClusterAnnotations = nil
+	g.Expect(r.reconcileClusterMetadata(ctx, mc)).To(Succeed())
+	g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(cluster), cluster)).To(Succeed())
+	g.Expect(cluster.GetLabels()).To(Equal(map[string]string{hmc.FluxHelmChartNameKey: mc.Name, "team": "a"}))
+	g.Expect(cluster.GetAnnotations()).To(Equal(map[string]string{hmc.PropagatedLabelsAnnotation: "team"}))
+}
@@ -0,0 +1,122 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// clusterResourceGVKs are the kinds of the CAPI objects of the clusters verified to be removed
+// once the ManagedCluster is deleted, regardless of the provider.
+var clusterResourceGVKs = []schema.GroupVersionKind{
+	{Group: "cluster.x-k8s.io", Version: "v1beta1", Kind: "Cluster"},
+	{Group: "cluster.x-k8s.io", Version: "v1beta1", Kind: "Machine"},
+	{Group: "cluster.x-k8s.io", Version: "v1beta1", Kind: "MachinePool"},
+}
+
+// providerResourceGVKs are the kinds of the infrastructure provider objects of the clusters
+// verified to be removed once the ManagedCluster is deleted. The objects hold the cloud resources,
+// the resources are left orphaned if the objects are removed without being cleaned up by the provider.
+var providerResourceGVKs = map[string][]schema.GroupVersionKind{
+	"aws": {
+		{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta2", Kind: "AWSCluster"},
+		{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta2", Kind: "AWSManagedCluster"},
+		{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta2", Kind: "AWSMachine"},
+		{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta2", Kind: "AWSManagedMachinePool"},
+		{Group: "controlplane.cluster.x-k8s.io", Version: "v1beta2", Kind: "AWSManagedControlPlane"},
+	},
+	"azure": {
+		{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta1", Kind: "AzureCluster"},
+		{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta1", Kind: "AzureManagedCluster"},
+		{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta1", Kind: "AzureMachine"},
+		{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta1", Kind: "AzureManagedMachinePool"},
+		{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta1", Kind: "AzureManagedControlPlane"},
+	},
+	"vsphere": {
+		{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta1", Kind: "VSphereCluster"},
+		{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta1", Kind: "VSphereMachine"},
+		{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta1", Kind: "VSphereVM"},
+	},
+}
+
+// findLingeringResources returns the CAPI and provider objects of the deleted ManagedCluster
+// which are left behind. All of the known providers are checked if the template of the cluster
+// does not exist anymore.
+func (r *ManagedClusterReconciler) findLingeringResources(ctx context.Context, managedCluster *hmc.ManagedCluster) ([]string, error) {
+	providers, err := r.getInfraProvidersNames(ctx, managedCluster.Namespace, managedCluster.Spec.Template)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		providers = make([]string, 0, len(providerResourceGVKs))
+		for provider := range providerResourceGVKs {
+			providers = append(providers, provider)
+		}
+	}
+
+	gvks := slices.Clone(clusterResourceGVKs)
+	for _, provider := range providers {
+		gvks = append(gvks, providerResourceGVKs[provider]...)
+	}
+
+	opts := &client.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{hmc.ClusterNameLabelKey: managedCluster.Name}),
+		Namespace:     managedCluster.Namespace,
+	}
+
+	var lingering []string
+	for _, gvk := range gvks {
+		itemsList := &metav1.PartialObjectMetadataList{}
+		itemsList.SetGroupVersionKind(gvk)
+		if err := r.Client.List(ctx, itemsList, opts); err != nil {
+			if apimeta.IsNoMatchError(err) {
+				continue // the provider is not installed
+			}
+			return nil, fmt.Errorf("failed to list %s objects: %w", gvk.Kind, err)
+		}
+		for _, item := range itemsList.Items {
+			lingering = append(lingering, gvk.Kind+"/"+item.Name)
+		}
+	}
+
+	return lingering, nil
+}
+
+// setCleanupIncompleteCondition reports the lingering objects of the deleted ManagedCluster
+// and returns true if the condition changed.
+func setCleanupIncompleteCondition(managedCluster *hmc.ManagedCluster, lingering []string) bool {
+	if len(lingering) == 0 {
+		return apimeta.RemoveStatusCondition(managedCluster.GetConditions(), hmc.CleanupIncompleteCondition)
+	}
+
+	return apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+		Type:    hmc.CleanupIncompleteCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  hmc.ProgressingReason,
+		Message: "The objects of the cluster are not removed yet: " + strings.Join(lingering, ", "),
+	})
+}
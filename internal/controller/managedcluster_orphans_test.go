@@ -0,0 +1,41 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+)
+
+func TestSetCleanupIncompleteCondition(t *testing.T) {
+	g := NewWithT(t)
+
+	mc := managedcluster.NewManagedCluster()
+
+	// the condition is reported once per change of the lingering objects
+	g.Expect(setCleanupIncompleteCondition(mc, []string{"AWSCluster/dev", "AWSMachine/dev-0"})).To(BeTrue())
+	g.Expect(setCleanupIncompleteCondition(mc, []string{"AWSCluster/dev", "AWSMachine/dev-0"})).To(BeFalse())
+	g.Expect(setCleanupIncompleteCondition(mc, []string{"AWSCluster/dev"})).To(BeTrue())
+	g.Expect(mc.Status.Conditions).To(ContainElement(HaveField("Type", hmc.CleanupIncompleteCondition)))
+
+	// the condition is removed once the objects are gone
+	g.Expect(setCleanupIncompleteCondition(mc, nil)).To(BeTrue())
+	g.Expect(setCleanupIncompleteCondition(mc, nil)).To(BeFalse())
+	g.Expect(mc.Status.Conditions).To(BeEmpty())
+}
@@ -0,0 +1,156 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	hcv2 "github.com/fluxcd/helm-controller/api/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+func TestComputePhase(t *testing.T) {
+	condition := func(conditionType string, status metav1.ConditionStatus, reason string) metav1.Condition {
+		return metav1.Condition{Type: conditionType, Status: status, Reason: reason}
+	}
+	entry := func(outcome string) hmc.ManagedClusterHistoryEntry {
+		return hmc.ManagedClusterHistoryEntry{Template: "aws", Outcome: outcome}
+	}
+	// the CAPI conditions copied to the ManagedCluster while the machines are being created
+	provisioning := []metav1.Condition{
+		condition("InfrastructureReady", metav1.ConditionFalse, "WaitingForInfrastructure"),
+		condition("ControlPlaneInitialized", metav1.ConditionFalse, "WaitingForControlPlaneProviderInitialized"),
+		condition(hmc.ReadyCondition, metav1.ConditionFalse, "WaitingForInfrastructure"),
+	}
+
+	for _, tc := range []struct {
+		name          string
+		deleting      bool
+		conditions    []metav1.Condition
+		history       []hmc.ManagedClusterHistoryEntry
+		provisioned   bool
+		expectedPhase hmc.ManagedClusterPhase
+	}{
+		{
+			name:          "not reconciled yet",
+			expectedPhase: hmc.ManagedClusterPhasePending,
+		},
+		{
+			name: "waiting for the HelmRelease",
+			conditions: []metav1.Condition{
+				condition(hmc.HelmReleaseReadyCondition, metav1.ConditionUnknown, hmc.ProgressingReason),
+				condition(hmc.ReadyCondition, metav1.ConditionUnknown, hmc.ProgressingReason),
+			},
+			expectedPhase: hmc.ManagedClusterPhasePending,
+		},
+		{
+			name:          "CAPI conditions False while provisioning",
+			conditions:    provisioning,
+			history:       []hmc.ManagedClusterHistoryEntry{entry(hmc.ProgressingReason)},
+			expectedPhase: hmc.ManagedClusterPhaseProvisioning,
+		},
+		{
+			name:          "CAPI conditions False once the HelmRelease is installed",
+			conditions:    provisioning,
+			history:       []hmc.ManagedClusterHistoryEntry{entry(hmc.SucceededReason)},
+			expectedPhase: hmc.ManagedClusterPhaseProvisioning,
+		},
+		{
+			name:          "CAPI conditions False once the cluster is provisioned",
+			conditions:    provisioning,
+			history:       []hmc.ManagedClusterHistoryEntry{entry(hmc.SucceededReason)},
+			provisioned:   true,
+			expectedPhase: hmc.ManagedClusterPhaseUpdating,
+		},
+		{
+			name:          "CAPI conditions False while updating",
+			conditions:    provisioning,
+			history:       []hmc.ManagedClusterHistoryEntry{entry(hmc.SucceededReason), entry(hmc.ProgressingReason)},
+			expectedPhase: hmc.ManagedClusterPhaseUpdating,
+		},
+		{
+			name: "ready",
+			conditions: []metav1.Condition{
+				condition(hmc.ReadyCondition, metav1.ConditionTrue, hmc.SucceededReason),
+			},
+			history:       []hmc.ManagedClusterHistoryEntry{entry(hmc.SucceededReason)},
+			expectedPhase: hmc.ManagedClusterPhaseReady,
+		},
+		{
+			name: "HelmRelease install failed",
+			conditions: []metav1.Condition{
+				condition(hmc.HelmReleaseReadyCondition, metav1.ConditionFalse, hcv2.InstallFailedReason),
+				condition(hmc.ReadyCondition, metav1.ConditionFalse, hcv2.InstallFailedReason),
+			},
+			history:       []hmc.ManagedClusterHistoryEntry{entry(hmc.ProgressingReason)},
+			expectedPhase: hmc.ManagedClusterPhaseFailed,
+		},
+		{
+			name:          "last deployment failed",
+			conditions:    provisioning,
+			history:       []hmc.ManagedClusterHistoryEntry{entry(hmc.SucceededReason), entry(hmc.FailedReason)},
+			expectedPhase: hmc.ManagedClusterPhaseFailed,
+		},
+		{
+			name:          "earlier deployment failed",
+			conditions:    provisioning,
+			history:       []hmc.ManagedClusterHistoryEntry{entry(hmc.FailedReason), entry(hmc.ProgressingReason)},
+			expectedPhase: hmc.ManagedClusterPhaseProvisioning,
+		},
+		{
+			name: "chart download failed",
+			conditions: []metav1.Condition{
+				condition(hmc.HelmChartReadyCondition, metav1.ConditionFalse, hmc.DownloadFailedReason),
+				condition(hmc.ReadyCondition, metav1.ConditionFalse, hmc.DownloadFailedReason),
+			},
+			expectedPhase: hmc.ManagedClusterPhaseFailed,
+		},
+		{
+			name: "terminal reason of a CAPI condition",
+			conditions: []metav1.Condition{
+				condition("InfrastructureReady", metav1.ConditionFalse, hmc.ImageNotFoundReason),
+				condition(hmc.ReadyCondition, metav1.ConditionFalse, hmc.ImageNotFoundReason),
+			},
+			history:       []hmc.ManagedClusterHistoryEntry{entry(hmc.ProgressingReason)},
+			expectedPhase: hmc.ManagedClusterPhaseProvisioning,
+		},
+		{
+			name:          "deleting",
+			deleting:      true,
+			conditions:    provisioning,
+			history:       []hmc.ManagedClusterHistoryEntry{entry(hmc.FailedReason)},
+			expectedPhase: hmc.ManagedClusterPhaseDeleting,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			mc := &hmc.ManagedCluster{}
+			if tc.deleting {
+				mc.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+			}
+			mc.Status.Conditions = tc.conditions
+			mc.Status.History = tc.history
+			if tc.provisioned {
+				mc.Status.Usage = &hmc.ManagedClusterUsage{ProvisionedAt: &metav1.Time{Time: time.Now()}}
+			}
+			g.Expect(computePhase(mc)).To(Equal(tc.expectedPhase))
+		})
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+
+	hcv2 "github.com/fluxcd/helm-controller/api/v2"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/helm"
+)
+
+const (
+	// dryRunConfigMapSuffix is the suffix of the name of the ConfigMap containing the dry run results.
+	dryRunConfigMapSuffix = "-dry-run"
+	// diffConfigMapSuffix is the suffix of the name of the ConfigMap containing the requested diff report.
+	diffConfigMapSuffix = "-diff"
+	// manifestsConfigMapKey is the key of the rendered manifests in the preview ConfigMaps.
+	manifestsConfigMapKey = "manifests"
+	// reportConfigMapKey is the key of the diff report in the preview ConfigMaps.
+	reportConfigMapKey = "report"
+	// maxConfigMapDataSize is the maximum size of the data stored in the preview ConfigMaps,
+	// leaving room for the metadata within the 1MiB object size limit.
+	maxConfigMapDataSize = 1000 * 1024
+)
+
+// reconcileDryRun previews the changes the ManagedCluster would apply to the
+// currently deployed release when the dry run is enabled and cleans up the
+// preview otherwise.
+func (r *ManagedClusterReconciler) reconcileDryRun(ctx context.Context, actionConfig *action.Configuration, managedCluster *hmc.ManagedCluster, rel *release.Release) error {
+	configMapName := managedCluster.Name + dryRunConfigMapSuffix
+
+	if !managedCluster.Spec.DryRun {
+		if managedCluster.Status.DryRun == nil {
+			return nil
+		}
+		managedCluster.Status.DryRun = nil
+		return client.IgnoreNotFound(r.Client.Delete(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: configMapName, Namespace: managedCluster.Namespace},
+		}))
+	}
+
+	current, err := getDeployedManifests(actionConfig, managedCluster.Name)
+	if err != nil {
+		return err
+	}
+
+	diff, err := helm.DiffManifests(current, rel.Manifest)
+	if err != nil {
+		return fmt.Errorf("failed to compute the dry run diff: %w", err)
+	}
+
+	status := &hmc.ManagedClusterDryRunStatus{
+		Summary: diff.Summary(),
+		Added:   diff.Added,
+		Changed: diff.Changed,
+		Removed: diff.Removed,
+	}
+
+	if len(rel.Manifest) <= maxConfigMapDataSize {
+		if err := r.writePreviewConfigMap(ctx, managedCluster, configMapName, nil, map[string]string{
+			manifestsConfigMapKey: rel.Manifest,
+		}); err != nil {
+			return err
+		}
+		status.ManifestsConfigMap = configMapName
+	} else {
+		ctrl.LoggerFrom(ctx).Info("Rendered manifests are too large to be stored in a ConfigMap", "size", len(rel.Manifest))
+	}
+
+	managedCluster.Status.DryRun = status
+	return nil
+}
+
+// getDeployedManifests returns the manifests of the currently deployed release
+// or an empty string if the release has not been deployed yet.
+func getDeployedManifests(actionConfig *action.Configuration, releaseName string) (string, error) {
+	deployed, err := action.NewGet(actionConfig).Run(releaseName)
+	if err != nil {
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get the deployed release %s: %w", releaseName, err)
+	}
+	return deployed.Manifest, nil
+}
+
+// writePreviewConfigMap creates or updates the ConfigMap owned by the ManagedCluster with the given labels and data.
+func (r *ManagedClusterReconciler) writePreviewConfigMap(ctx context.Context, managedCluster *hmc.ManagedCluster, name string, labels, data map[string]string) error {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: managedCluster.Namespace},
+	}
+
+	if _, err := ctrl.CreateOrUpdate(ctx, r.Client, configMap, func() error {
+		if configMap.Labels == nil {
+			configMap.Labels = make(map[string]string)
+		}
+		maps.Copy(configMap.Labels, labels)
+		configMap.Labels[hmc.HMCManagedLabelKey] = hmc.HMCManagedLabelValue
+		configMap.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: hmc.GroupVersion.String(),
+			Kind:       hmc.ManagedClusterKind,
+			Name:       managedCluster.Name,
+			UID:        managedCluster.UID,
+		}}
+		configMap.Data = data
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to write ConfigMap %s/%s: %w", managedCluster.Namespace, name, err)
+	}
+	return nil
+}
+
+// diffReport is the report of the changes the reconciliation applies to the ManagedCluster.
+type diffReport struct {
+	Template    diffReportItem[string]                `json:"template"`
+	Values      diffReportItem[any]                   `json:"values"`
+	HelmRelease diffReportItem[*hcv2.HelmReleaseSpec] `json:"helmRelease"`
+	Manifests   *helm.ManifestDiff                    `json:"manifests"`
+}
+
+type diffReportItem[T any] struct {
+	Current T    `json:"current"`
+	Desired T    `json:"desired"`
+	Changed bool `json:"changed"`
+}
+
+// reportDiff writes the report of the changes the reconciliation applies
+// to the ManagedCluster into a ConfigMap and removes the DiffRequestedAnnotation.
+// The HelmRelease and the deployed release are not modified.
+func (r *ManagedClusterReconciler) reportDiff(
+	ctx context.Context,
+	actionConfig *action.Configuration,
+	managedCluster *hmc.ManagedCluster,
+	rel *release.Release,
+	currentHR *hcv2.HelmRelease,
+	opts helm.ReconcileHelmReleaseOpts,
+) error {
+	current, err := getDeployedManifests(actionConfig, managedCluster.Name)
+	if err != nil {
+		return err
+	}
+
+	manifestsDiff, err := helm.DiffManifests(current, rel.Manifest)
+	if err != nil {
+		return fmt.Errorf("failed to compute the manifests diff: %w", err)
+	}
+
+	desiredSpec := helm.NewHelmReleaseSpec(managedCluster.Name, opts)
+	report := diffReport{
+		Template:    diffReportItem[string]{Desired: managedCluster.Spec.Template},
+		HelmRelease: diffReportItem[*hcv2.HelmReleaseSpec]{Desired: &desiredSpec},
+		Manifests:   manifestsDiff,
+	}
+	if n := len(managedCluster.Status.History); n > 0 {
+		report.Template.Current = managedCluster.Status.History[n-1].Template
+	}
+	if currentHR != nil {
+		report.HelmRelease.Current = &currentHR.Spec
+	}
+	report.Template.Changed = report.Template.Current != report.Template.Desired
+	report.HelmRelease.Changed = report.HelmRelease.Current == nil || !equality.Semantic.DeepEqual(*report.HelmRelease.Current, desiredSpec)
+
+	if report.Values.Current, err = unmarshalValues(report.HelmRelease.Current); err != nil {
+		return err
+	}
+	if report.Values.Desired, err = unmarshalValues(&desiredSpec); err != nil {
+		return err
+	}
+	report.Values.Changed = !equality.Semantic.DeepEqual(report.Values.Current, report.Values.Desired)
+
+	raw, err := yaml.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the diff report: %w", err)
+	}
+
+	configMapName := managedCluster.Name + diffConfigMapSuffix
+	data := map[string]string{reportConfigMapKey: string(raw)}
+	if len(raw)+len(rel.Manifest) <= maxConfigMapDataSize {
+		data[manifestsConfigMapKey] = rel.Manifest
+	}
+	if err := r.writePreviewConfigMap(ctx, managedCluster, configMapName, nil, data); err != nil {
+		return err
+	}
+
+	managedCluster.Status.DiffReport = &hmc.ManagedClusterDiffReport{
+		Timestamp: metav1.Now(),
+		ConfigMap: configMapName,
+		Summary:   report.summary(),
+	}
+
+	// patch a copy to keep the status which is not yet persisted
+	patched := managedCluster.DeepCopy()
+	delete(patched.Annotations, hmc.DiffRequestedAnnotation)
+	if err := r.Patch(ctx, patched, client.MergeFrom(managedCluster)); err != nil {
+		return fmt.Errorf("failed to remove %s annotation: %w", hmc.DiffRequestedAnnotation, err)
+	}
+	managedCluster.Annotations = patched.Annotations
+	managedCluster.ResourceVersion = patched.ResourceVersion
+	return nil
+}
+
+func (d *diffReport) summary() string {
+	var changes []string
+	if d.Template.Changed {
+		changes = append(changes, fmt.Sprintf("template %s -> %s", d.Template.Current, d.Template.Desired))
+	}
+	if d.Values.Changed {
+		changes = append(changes, "values changed")
+	}
+	changes = append(changes, "manifests: "+d.Manifests.Summary())
+	return strings.Join(changes, ", ")
+}
+
+func unmarshalValues(spec *hcv2.HelmReleaseSpec) (any, error) {
+	if spec == nil || spec.Values == nil {
+		return nil, nil
+	}
+
+	var values any
+	if err := yaml.Unmarshal(spec.Values.Raw, &values); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal HelmRelease values: %w", err)
+	}
+	return values, nil
+}
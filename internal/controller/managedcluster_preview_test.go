@@ -0,0 +1,106 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	hcv2 "github.com/fluxcd/helm-controller/api/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/action"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/helm"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+const (
+	deployedManifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: default
+data:
+  key: old
+`
+	desiredManifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: default
+data:
+  key: new
+`
+)
+
+func TestReportDiff(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	mc := managedcluster.NewManagedCluster(
+		managedcluster.WithName("cluster"),
+		managedcluster.WithNamespace("default"),
+		managedcluster.WithClusterTemplate("template-0-0-2"),
+		managedcluster.WithAnnotations(map[string]string{hmc.DiffRequestedAnnotation: "true"}),
+	)
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(mc).Build()
+	r := &ManagedClusterReconciler{Client: c}
+
+	actionConfig := &action.Configuration{
+		Releases:   storage.Init(driver.NewMemory()),
+		KubeClient: &kubefake.PrintingKubeClient{},
+	}
+	g.Expect(actionConfig.Releases.Create(&release.Release{
+		Name:      mc.Name,
+		Namespace: mc.Namespace,
+		Version:   1,
+		Manifest:  deployedManifest,
+		Info:      &release.Info{Status: release.StatusDeployed},
+	})).To(Succeed())
+
+	rel := &release.Release{Name: mc.Name, Namespace: mc.Namespace, Manifest: desiredManifest}
+	g.Expect(r.reportDiff(ctx, actionConfig, mc, rel, nil, helm.ReconcileHelmReleaseOpts{})).To(Succeed())
+
+	g.Expect(mc.Annotations).NotTo(HaveKey(hmc.DiffRequestedAnnotation))
+	g.Expect(mc.Status.DiffReport).NotTo(BeNil())
+	g.Expect(mc.Status.DiffReport.ConfigMap).To(Equal(mc.Name + diffConfigMapSuffix))
+	g.Expect(mc.Status.DiffReport.Summary).To(ContainSubstring("manifests:"))
+
+	stored := &hmc.ManagedCluster{}
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(mc), stored)).To(Succeed())
+	g.Expect(stored.Annotations).NotTo(HaveKey(hmc.DiffRequestedAnnotation))
+
+	configMap := &corev1.ConfigMap{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: mc.Namespace, Name: mc.Name + diffConfigMapSuffix}, configMap)).To(Succeed())
+	g.Expect(configMap.Data).To(HaveKey(reportConfigMapKey))
+	g.Expect(configMap.Data[manifestsConfigMapKey]).To(Equal(desiredManifest))
+
+	// neither the HelmRelease nor the deployed release is modified
+	err := c.Get(ctx, client.ObjectKeyFromObject(mc), &hcv2.HelmRelease{})
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	deployed, err := getDeployedManifests(actionConfig, mc.Name)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(deployed).To(Equal(deployedManifest))
+}
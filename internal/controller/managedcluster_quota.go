@@ -0,0 +1,130 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/errdefs"
+)
+
+// checkClusterQuotas re-checks the ClusterQuotas of the namespace before the ManagedCluster
+// is deployed for the first time. The admission webhook checks each ManagedCluster against
+// the clusters existing at the time, so the clusters admitted concurrently may exceed the
+// quota together. The clusters are admitted in the order of their creation: the cluster is
+// counted along with the clusters admitted already and the ones created before it. Once
+// admitted or deployed the cluster is not checked again, so lowering the quota does not
+// block the existing clusters.
+func (r *ManagedClusterReconciler) checkClusterQuotas(ctx context.Context, managedCluster *hmc.ManagedCluster, mgmt *hmc.Management) error {
+	if quotaAdmitted(managedCluster) {
+		return nil
+	}
+
+	quotas := &hmc.ClusterQuotaList{}
+	if err := r.List(ctx, quotas, client.InNamespace(managedCluster.Namespace)); err != nil {
+		return fmt.Errorf("failed to list ClusterQuotas: %w", err)
+	}
+	if len(quotas.Items) == 0 {
+		apimeta.RemoveStatusCondition(managedCluster.GetConditions(), hmc.QuotaAdmittedCondition)
+		return nil
+	}
+
+	clusters := &hmc.ManagedClusterList{}
+	if err := r.List(ctx, clusters, client.InNamespace(managedCluster.Namespace)); err != nil {
+		return fmt.Errorf("failed to list ManagedClusters: %w", err)
+	}
+
+	templates := make(map[string]*hmc.ClusterTemplate)
+	clustersNumber, workersNumber := int32(1), int32(0)
+	workers, err := clusterWorkersNumber(ctx, r.Client, managedCluster, mgmt, templates)
+	if err != nil {
+		return err
+	}
+	workersNumber += workers
+	for _, cluster := range clusters.Items {
+		if cluster.Name == managedCluster.Name || !quotaAdmitted(&cluster) && !createdBefore(&cluster, managedCluster) {
+			continue
+		}
+		workers, err := clusterWorkersNumber(ctx, r.Client, &cluster, mgmt, templates)
+		if err != nil {
+			return err
+		}
+		clustersNumber++
+		workersNumber += workers
+	}
+
+	for _, quota := range quotas.Items {
+		if exceeded := quota.Exceeded(clustersNumber, workersNumber); len(exceeded) > 0 {
+			errMsg := fmt.Sprintf("the ClusterQuota %s is exceeded: %s", quota.Name, strings.Join(exceeded, ", "))
+			apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+				Type:    hmc.QuotaAdmittedCondition,
+				Status:  metav1.ConditionFalse,
+				Reason:  hmc.LimitsExceededReason,
+				Message: errMsg,
+			})
+			return errdefs.Waiting(errors.New(errMsg))
+		}
+	}
+
+	apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+		Type:    hmc.QuotaAdmittedCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  hmc.WithinLimitsReason,
+		Message: "The ManagedCluster fits the ClusterQuotas",
+	})
+	return nil
+}
+
+// quotaAdmitted returns true if the ManagedCluster has been admitted by the ClusterQuotas or deployed.
+func quotaAdmitted(managedCluster *hmc.ManagedCluster) bool {
+	return len(managedCluster.Status.History) > 0 ||
+		apimeta.IsStatusConditionTrue(managedCluster.Status.Conditions, hmc.QuotaAdmittedCondition)
+}
+
+// createdBefore returns true if the ManagedCluster a has been created before b,
+// the clusters created within the same second are ordered by name.
+func createdBefore(a, b *hmc.ManagedCluster) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Name < b.Name
+}
+
+// clusterWorkersNumber returns the number of the worker machines of the ManagedCluster
+// completed with the defaults of its ClusterTemplate and of the Management. The templates
+// are cached in the given map by name, a missing template contributes no defaults.
+func clusterWorkersNumber(ctx context.Context, cl client.Client, managedCluster *hmc.ManagedCluster, mgmt *hmc.Management, templates map[string]*hmc.ClusterTemplate) (int32, error) {
+	template, ok := templates[managedCluster.Spec.Template]
+	if !ok {
+		template = new(hmc.ClusterTemplate)
+		if err := cl.Get(ctx, client.ObjectKey{Namespace: managedCluster.Namespace, Name: managedCluster.Spec.Template}, template); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return 0, fmt.Errorf("failed to get ClusterTemplate %s: %w", managedCluster.Spec.Template, err)
+			}
+			template = nil
+		}
+		templates[managedCluster.Spec.Template] = template
+	}
+	return managedCluster.WorkersNumber(template, mgmt), nil
+}
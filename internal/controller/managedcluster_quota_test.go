@@ -0,0 +1,89 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/errdefs"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestCheckClusterQuotas(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	template := &hmc.ClusterTemplate{ObjectMeta: metav1.ObjectMeta{Name: "template", Namespace: "default"}}
+	template.Status.Config = &apiextensionsv1.JSON{Raw: []byte(`{"workersNumber":2}`)}
+	quota := &hmc.ClusterQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: "default"},
+		Spec:       hmc.ClusterQuotaSpec{MaxWorkers: ptr.To[int32](4)},
+	}
+
+	created := metav1.NewTime(time.Now().Truncate(time.Second))
+	newCluster := func(name string, age time.Duration) *hmc.ManagedCluster {
+		mc := managedcluster.NewManagedCluster(managedcluster.WithName(name), managedcluster.WithNamespace("default"),
+			managedcluster.WithClusterTemplate(template.Name))
+		mc.CreationTimestamp = metav1.NewTime(created.Add(-age))
+		return mc
+	}
+	// the clusters are admitted concurrently, each of them fits the quota alone
+	first, second, third := newCluster("first", 2*time.Minute), newCluster("second", time.Minute), newCluster("third", 0)
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(template, first, second, third).
+		WithStatusSubresource(&hmc.ManagedCluster{}).Build()
+	r := &ManagedClusterReconciler{Client: cl}
+	mgmt := &hmc.Management{}
+
+	// without quotas the clusters are not checked
+	g.Expect(r.checkClusterQuotas(ctx, third, mgmt)).To(Succeed())
+	g.Expect(apimeta.FindStatusCondition(third.Status.Conditions, hmc.QuotaAdmittedCondition)).To(BeNil())
+
+	g.Expect(cl.Create(ctx, quota)).To(Succeed())
+
+	// the clusters created first are admitted first
+	err := r.checkClusterQuotas(ctx, third, mgmt)
+	g.Expect(errdefs.IsWaiting(err)).To(BeTrue())
+	g.Expect(err).To(MatchError(ContainSubstring("6 workers exceed the limit of 4")))
+	g.Expect(apimeta.IsStatusConditionFalse(third.Status.Conditions, hmc.QuotaAdmittedCondition)).To(BeTrue())
+
+	g.Expect(r.checkClusterQuotas(ctx, first, mgmt)).To(Succeed())
+	g.Expect(apimeta.IsStatusConditionTrue(first.Status.Conditions, hmc.QuotaAdmittedCondition)).To(BeTrue())
+	g.Expect(cl.Status().Update(ctx, first)).To(Succeed())
+
+	g.Expect(r.checkClusterQuotas(ctx, second, mgmt)).To(Succeed())
+	g.Expect(cl.Status().Update(ctx, second)).To(Succeed())
+
+	// the cluster is admitted once the clusters before it are deleted
+	g.Expect(cl.Delete(ctx, first)).To(Succeed())
+	g.Expect(r.checkClusterQuotas(ctx, third, mgmt)).To(Succeed())
+	g.Expect(apimeta.IsStatusConditionTrue(third.Status.Conditions, hmc.QuotaAdmittedCondition)).To(BeTrue())
+
+	// the admitted clusters are not checked again once the quota is lowered
+	quota.Spec.MaxWorkers = ptr.To[int32](1)
+	g.Expect(cl.Update(ctx, quota)).To(Succeed())
+	g.Expect(r.checkClusterQuotas(ctx, second, mgmt)).To(Succeed())
+}
@@ -0,0 +1,122 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+	"github.com/Mirantis/hmc/test/objects/template"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestCheckServicesK8sCompatibility(t *testing.T) {
+	ctx := context.Background()
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		template.NewServiceTemplate(template.WithName("ingress"), template.WithNamespace("default"), template.WithServiceK8sConstraint(">=1.30")),
+		template.NewServiceTemplate(template.WithName("legacy"), template.WithNamespace("default"), template.WithServiceK8sConstraint("<1.30")),
+		template.NewServiceTemplate(template.WithName("any"), template.WithNamespace("default")),
+	).Build()
+	r := &ManagedClusterReconciler{Client: cl}
+
+	for _, tc := range []struct {
+		name               string
+		k8sVersion         string
+		services           []hmc.ServiceSpec
+		expectedCompatible bool
+		expectedMessage    string
+		expectedErr        string
+	}{
+		{
+			name:               "no services",
+			k8sVersion:         "v1.31.1",
+			expectedCompatible: true,
+		},
+		{
+			name:               "version not reported yet",
+			services:           []hmc.ServiceSpec{{Name: "legacy", Template: "legacy"}},
+			expectedCompatible: true,
+		},
+		{
+			name:               "compatible",
+			k8sVersion:         "v1.31.1",
+			services:           []hmc.ServiceSpec{{Name: "ingress", Template: "ingress"}, {Name: "any", Template: "any"}},
+			expectedCompatible: true,
+			expectedMessage:    "k8s version v1.31.1 satisfies constraints of the ServiceTemplates",
+		},
+		{
+			name:       "incompatible",
+			k8sVersion: "v1.31.1",
+			services: []hmc.ServiceSpec{
+				{Name: "ingress", Template: "ingress"},
+				{Name: "legacy", Template: "legacy"},
+			},
+			expectedMessage: "k8s version v1.31.1 does not satisfy constraints of the ServiceTemplates: legacy (<1.30)",
+		},
+		{
+			name:       "incompatible services disabled or not found",
+			k8sVersion: "v1.31.1",
+			services: []hmc.ServiceSpec{
+				{Name: "legacy", Template: "legacy", Disable: true},
+				{Name: "missing", Template: "missing"},
+			},
+			expectedCompatible: true,
+			expectedMessage:    "k8s version v1.31.1 satisfies constraints of the ServiceTemplates",
+		},
+		{
+			name:        "invalid version",
+			k8sVersion:  "latest",
+			services:    []hmc.ServiceSpec{{Name: "legacy", Template: "legacy"}},
+			expectedErr: "failed to parse k8s version latest",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"))
+			mc.Spec.Services = tc.services
+			mc.Status.KubernetesVersion = tc.k8sVersion
+			// the condition of the previous check is replaced or removed
+			apimeta.SetStatusCondition(&mc.Status.Conditions, metav1.Condition{
+				Type: hmc.ServicesK8sCompatibleCondition, Status: metav1.ConditionUnknown, Reason: hmc.ProgressingReason,
+			})
+
+			compatible, err := r.checkServicesK8sCompatibility(ctx, mc)
+			if tc.expectedErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.expectedErr)))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(compatible).To(Equal(tc.expectedCompatible))
+
+			condition := apimeta.FindStatusCondition(mc.Status.Conditions, hmc.ServicesK8sCompatibleCondition)
+			if tc.expectedMessage == "" {
+				g.Expect(condition).To(BeNil())
+				return
+			}
+			g.Expect(condition).NotTo(BeNil())
+			g.Expect(condition.Message).To(Equal(tc.expectedMessage))
+			g.Expect(condition.Status == metav1.ConditionTrue).To(Equal(tc.expectedCompatible))
+		})
+	}
+}
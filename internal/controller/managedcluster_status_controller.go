@@ -0,0 +1,260 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	hcv2 "github.com/fluxcd/helm-controller/api/v2"
+	fluxmeta "github.com/fluxcd/pkg/apis/meta"
+	fluxconditions "github.com/fluxcd/pkg/runtime/conditions"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/notifications"
+)
+
+// DefaultStatusSyncConcurrency is the default number of concurrent ManagedCluster status syncs.
+const DefaultStatusSyncConcurrency = 10
+
+// ManagedClusterStatusReconciler keeps the status of a ManagedCluster in sync
+// with the state of the corresponding HelmRelease and CAPI cluster. It never
+// touches the spec nor the generated objects, so it is cheap enough to run
+// on each HelmRelease change, while the ManagedClusterReconciler handles
+// spec and template changes only.
+type ManagedClusterStatusReconciler struct {
+	client.Client
+	DynamicClient           dynamic.Interface
+	MaxConcurrentReconciles int
+	// Shard is the name of the shard of the namespaces the controller
+	// reconciles the ManagedClusters in, the default shard is empty.
+	Shard string
+	// Notifier notifies the receivers configured on the Management about
+	// the outcome of the deployments, no notifications are sent if nil.
+	Notifier *notifications.Notifier
+
+	instanceTypes machineInstanceTypes
+	controller    controller.Controller
+	cache         cache.Cache
+	shards        *shardFilter
+	capiWatches   map[schema.GroupVersionKind]bool
+	capiMu        sync.Mutex
+}
+
+var (
+	capiClusterGVK = schema.GroupVersionKind{Group: "cluster.x-k8s.io", Version: "v1beta1", Kind: "Cluster"}
+	capiMachineGVK = schema.GroupVersionKind{Group: "cluster.x-k8s.io", Version: "v1beta1", Kind: "Machine"}
+
+	// capiWatchedKinds are the kinds of the CAPI objects whose changes are
+	// reflected in the status of the ManagedClusters.
+	capiWatchedKinds = []schema.GroupVersionKind{capiClusterGVK, capiMachineGVK}
+)
+
+// Reconcile syncs the status of a ManagedCluster object.
+func (r *ManagedClusterStatusReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := ctrl.LoggerFrom(ctx)
+	l.V(1).Info("Syncing ManagedCluster status")
+
+	if err := r.watchCAPIObjects(ctx); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	managedCluster := &hmc.ManagedCluster{}
+	if err := r.Get(ctx, req.NamespacedName, managedCluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.instanceTypes.forget(req.NamespacedName)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !managedCluster.DeletionTimestamp.IsZero() || managedCluster.Spec.DryRun {
+		r.instanceTypes.forget(req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+
+	hr := &hcv2.HelmRelease{}
+	if err := r.Get(ctx, req.NamespacedName, hr); err != nil {
+		if apierrors.IsNotFound(err) {
+			// the HelmRelease is yet to be created by the ManagedClusterReconciler
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	original := managedCluster.Status.DeepCopy()
+
+	outcomeSet := false
+	hrReadyCondition := fluxconditions.Get(hr, fluxmeta.ReadyCondition)
+	if hrReadyCondition != nil {
+		apimeta.SetStatusCondition(managedCluster.GetConditions(), metav1.Condition{
+			Type:    hmc.HelmReleaseReadyCondition,
+			Status:  hrReadyCondition.Status,
+			Reason:  hrReadyCondition.Reason,
+			Message: hrReadyCondition.Message,
+		})
+		if helmReleaseObserved(hr, hrReadyCondition) && setHistoryOutcome(managedCluster, hrReadyCondition) {
+			trackTemplateUpgrade(ctx, r.Client, managedCluster)
+			outcomeSet = true
+		}
+	}
+
+	requeue, err := setStatusFromClusterStatus(ctx, r.DynamicClient, managedCluster)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	setReadyCondition(managedCluster)
+	setPhase(managedCluster)
+
+	if err := setUsage(ctx, r.Client, &r.instanceTypes, managedCluster); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !equality.Semantic.DeepEqual(original, &managedCluster.Status) {
+		if err := r.Status().Update(ctx, managedCluster); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if outcomeSet {
+		r.notifyOutcome(ctx, managedCluster)
+	}
+
+	if requeue || !fluxconditions.IsReady(hr) {
+		return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// notifyOutcome notifies the receivers about the outcome of the latest deployment of the ManagedCluster.
+func (r *ManagedClusterStatusReconciler) notifyOutcome(ctx context.Context, managedCluster *hmc.ManagedCluster) {
+	last := managedCluster.Status.History[len(managedCluster.Status.History)-1]
+	event, message := hmc.NotificationProvisioningSucceeded, "The template "+last.Template+" is deployed"
+	if last.Outcome == hmc.FailedReason {
+		event, message = hmc.NotificationProvisioningFailed, "Failed to deploy the template "+last.Template+": "+last.Message
+	}
+	r.Notifier.Notify(ctx, managedCluster, event, message, notificationMetadata(managedCluster))
+}
+
+// watchCAPIObjects starts watching the CAPI objects once their CRDs are installed.
+// The CAPI provider is deployed by the Management after the controller has
+// started, so the watches cannot be set up along with the controller.
+func (r *ManagedClusterStatusReconciler) watchCAPIObjects(ctx context.Context) error {
+	r.capiMu.Lock()
+	defer r.capiMu.Unlock()
+
+	if r.controller == nil {
+		return nil
+	}
+
+	for _, gvk := range capiWatchedKinds {
+		if r.capiWatches[gvk] {
+			continue
+		}
+
+		if _, err := r.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			if apimeta.IsNoMatchError(err) {
+				continue
+			}
+			return fmt.Errorf("failed to get REST mapping of %s: %w", gvk, err)
+		}
+
+		obj := &metav1.PartialObjectMetadata{}
+		obj.SetGroupVersionKind(gvk)
+		if err := r.controller.Watch(source.Kind[client.Object](r.cache, obj,
+			handler.EnqueueRequestsFromMapFunc(mapCAPIObjectToManagedCluster),
+			r.shards.predicate(),
+		)); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", gvk, err)
+		}
+
+		r.capiWatches[gvk] = true
+		ctrl.LoggerFrom(ctx).Info("Watching CAPI objects", "kind", gvk.Kind)
+	}
+
+	return nil
+}
+
+// mapCAPIObjectToManagedCluster maps a CAPI Cluster or Machine to the ManagedCluster it has been
+// deployed for. The Clusters are labeled by Flux while the Machines reference the cluster by name.
+func mapCAPIObjectToManagedCluster(_ context.Context, o client.Object) []ctrl.Request {
+	labels := o.GetLabels()
+	if name, ok := labels[hmc.FluxHelmChartNameKey]; ok {
+		namespace := labels[hmc.FluxHelmChartNamespaceKey]
+		if namespace == "" {
+			namespace = o.GetNamespace()
+		}
+		return []ctrl.Request{{NamespacedName: client.ObjectKey{Namespace: namespace, Name: name}}}
+	}
+	if name, ok := labels[hmc.ClusterNameLabelKey]; ok {
+		return []ctrl.Request{{NamespacedName: client.ObjectKey{Namespace: o.GetNamespace(), Name: name}}}
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ManagedClusterStatusReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.MaxConcurrentReconciles == 0 {
+		r.MaxConcurrentReconciles = DefaultStatusSyncConcurrency
+	}
+
+	shards, err := newShardFilter(mgr, r.Shard)
+	if err != nil {
+		return err
+	}
+	r.shards = shards
+	r.cache = mgr.GetCache()
+	r.capiWatches = make(map[schema.GroupVersionKind]bool, len(capiWatchedKinds))
+
+	c, err := ctrl.NewControllerManagedBy(mgr).
+		Named("managedcluster-status").
+		For(&hmc.ManagedCluster{}, builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, readinessGatesChanged()))).
+		Watches(&hcv2.HelmRelease{},
+			handler.EnqueueRequestsFromMapFunc(func(_ context.Context, o client.Object) []ctrl.Request {
+				if o.GetLabels()[hmc.HMCManagedLabelKey] != hmc.HMCManagedLabelValue {
+					return nil
+				}
+				return []reconcile.Request{{NamespacedName: client.ObjectKeyFromObject(o)}}
+			}),
+			builder.WithPredicates(helmReleaseStatusChanged()),
+		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
+		Watches(shards.watchNamespaces(mgr.GetClient(), &hmc.ManagedClusterList{})).
+		WithEventFilter(shards.predicate()).
+		Build(r)
+	if err != nil {
+		return err
+	}
+
+	r.controller = c
+	return nil
+}
@@ -0,0 +1,308 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	hcv2 "github.com/fluxcd/helm-controller/api/v2"
+	fluxmeta "github.com/fluxcd/pkg/apis/meta"
+	. "github.com/onsi/gomega"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+)
+
+// newStatusScheme returns the scheme of the objects the status of the ManagedClusters is synced from,
+// the CAPI types are not vendored, the Machines are stored as unstructured.
+func newStatusScheme() *runtime.Scheme {
+	s := runtime.NewScheme()
+	utilruntime.Must(hmc.AddToScheme(s))
+	utilruntime.Must(hcv2.AddToScheme(s))
+	s.AddKnownTypeWithName(capiMachineGVK, &unstructured.Unstructured{})
+	s.AddKnownTypeWithName(capiMachineGVK.GroupVersion().WithKind(capiMachineGVK.Kind+"List"), &unstructured.UnstructuredList{})
+	return s
+}
+
+func TestManagedClusterStatusReconcile(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"),
+		managedcluster.WithClusterTemplate("aws"))
+	mc.Generation = 1
+	mc.Status.ObservedGeneration = 1
+	mc.Status.History = []hmc.ManagedClusterHistoryEntry{{Template: "aws", Generation: 1, Outcome: hmc.ProgressingReason}}
+
+	hr := &hcv2.HelmRelease{ObjectMeta: metav1.ObjectMeta{Namespace: mc.Namespace, Name: mc.Name, Generation: 1}}
+	hr.Status.ObservedGeneration = 1
+	hr.Status.Conditions = []metav1.Condition{{
+		Type: fluxmeta.ReadyCondition, Status: metav1.ConditionTrue, Reason: hcv2.InstallSucceededReason, ObservedGeneration: 1,
+	}}
+
+	// the CAPI conditions are False while the machines of the cluster are created
+	capiCluster := newStatusObject(capiClusterGVK, mc,
+		metav1.Condition{Type: "InfrastructureReady", Status: metav1.ConditionFalse, Reason: "WaitingForInfrastructure"},
+		metav1.Condition{Type: hmc.ReadyCondition, Status: metav1.ConditionFalse, Reason: "WaitingForInfrastructure"},
+	)
+	dynamicClient := newStatusDynamicClient(capiCluster)
+
+	statusUpdates := 0
+	cl := fake.NewClientBuilder().WithScheme(newStatusScheme()).WithObjects(mc, hr).
+		WithStatusSubresource(&hmc.ManagedCluster{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceUpdate: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+				statusUpdates++
+				return c.SubResource(subResourceName).Update(ctx, obj, opts...)
+			},
+		}).Build()
+	r := &ManagedClusterStatusReconciler{Client: cl, DynamicClient: dynamicClient}
+
+	sync := func() ctrl.Result {
+		t.Helper()
+		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(mc)})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(mc), mc)).To(Succeed())
+		return result
+	}
+
+	// the HelmRelease is installed while the CAPI cluster is still provisioned
+	result := sync()
+	g.Expect(result.RequeueAfter).To(Equal(DefaultRequeueInterval))
+	g.Expect(statusUpdates).To(Equal(1))
+	g.Expect(mc.Status.History[0].Outcome).To(Equal(hmc.SucceededReason))
+	g.Expect(apimeta.IsStatusConditionTrue(mc.Status.Conditions, hmc.HelmReleaseReadyCondition)).To(BeTrue())
+	g.Expect(apimeta.IsStatusConditionFalse(mc.Status.Conditions, "InfrastructureReady")).To(BeTrue())
+	g.Expect(apimeta.IsStatusConditionFalse(mc.Status.Conditions, hmc.ReadyCondition)).To(BeTrue())
+	g.Expect(mc.Status.Phase).To(Equal(hmc.ManagedClusterPhaseProvisioning))
+
+	// the change of the CAPI conditions makes the cluster Ready
+	capiCluster = newStatusObject(capiClusterGVK, mc,
+		metav1.Condition{Type: "InfrastructureReady", Status: metav1.ConditionTrue},
+		metav1.Condition{Type: hmc.ReadyCondition, Status: metav1.ConditionTrue},
+	)
+	_, err := dynamicClient.Resource(schema.GroupVersionResource{Group: capiClusterGVK.Group, Version: capiClusterGVK.Version, Resource: "clusters"}).
+		Namespace(mc.Namespace).Update(ctx, capiCluster, metav1.UpdateOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	result = sync()
+	g.Expect(result).To(Equal(ctrl.Result{}))
+	g.Expect(statusUpdates).To(Equal(2))
+	g.Expect(apimeta.IsStatusConditionTrue(mc.Status.Conditions, hmc.ReadyCondition)).To(BeTrue())
+	g.Expect(mc.Status.Phase).To(Equal(hmc.ManagedClusterPhaseReady))
+
+	// the main reconciler computes the same Ready condition and phase from the synced conditions
+	mainReconciler := &ManagedClusterReconciler{Client: cl}
+	g.Expect(mainReconciler.updateStatus(ctx, mc, nil)).To(Succeed())
+	g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(mc), mc)).To(Succeed())
+	g.Expect(apimeta.IsStatusConditionTrue(mc.Status.Conditions, hmc.ReadyCondition)).To(BeTrue())
+	g.Expect(mc.Status.Phase).To(Equal(hmc.ManagedClusterPhaseReady))
+
+	// so the status written by the main reconciler is not updated back by the sync
+	updates := statusUpdates
+	sync()
+	g.Expect(statusUpdates).To(Equal(updates))
+	g.Expect(mc.Status.Phase).To(Equal(hmc.ManagedClusterPhaseReady))
+}
+
+func TestManagedClusterStatusReconcileSkipped(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	statusUpdates := 0
+	newReconciler := func(objects ...client.Object) *ManagedClusterStatusReconciler {
+		cl := fake.NewClientBuilder().WithScheme(newStatusScheme()).WithObjects(objects...).
+			WithStatusSubresource(&hmc.ManagedCluster{}).
+			WithInterceptorFuncs(interceptor.Funcs{
+				SubResourceUpdate: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+					statusUpdates++
+					return c.SubResource(subResourceName).Update(ctx, obj, opts...)
+				},
+			}).Build()
+		return &ManagedClusterStatusReconciler{Client: cl, DynamicClient: newStatusDynamicClient()}
+	}
+	request := ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "default", Name: "dev"}}
+
+	// the ManagedCluster is gone
+	_, err := newReconciler().Reconcile(ctx, request)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// the HelmRelease is yet to be created by the main reconciler
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"))
+	result, err := newReconciler(mc).Reconcile(ctx, request)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(ctrl.Result{}))
+
+	// the status of the dry run is left to the main reconciler
+	mc = managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"), managedcluster.WithDryRun(true))
+	hr := &hcv2.HelmRelease{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "dev"}}
+	_, err = newReconciler(mc, hr).Reconcile(ctx, request)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(statusUpdates).To(BeZero())
+}
+
+func TestManagedClusterStatusHistoryOutcome(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"),
+		managedcluster.WithClusterTemplate("aws"))
+	mc.Status.History = []hmc.ManagedClusterHistoryEntry{
+		{Template: "aws", Generation: 1, Outcome: hmc.SucceededReason},
+		{Template: "aws", Generation: 2, Outcome: hmc.ProgressingReason},
+	}
+
+	// the spec of the HelmRelease is updated, the Ready condition is still the one of the previous generation
+	hr := &hcv2.HelmRelease{ObjectMeta: metav1.ObjectMeta{Namespace: mc.Namespace, Name: mc.Name, Generation: 2}}
+	hr.Status.ObservedGeneration = 1
+	hr.Status.Conditions = []metav1.Condition{{
+		Type: fluxmeta.ReadyCondition, Status: metav1.ConditionTrue, Reason: hcv2.UpgradeSucceededReason, ObservedGeneration: 1,
+	}}
+
+	cl := fake.NewClientBuilder().WithScheme(newStatusScheme()).WithObjects(mc, hr).
+		WithStatusSubresource(&hmc.ManagedCluster{}, &hcv2.HelmRelease{}).Build()
+	r := &ManagedClusterStatusReconciler{Client: cl, DynamicClient: newStatusDynamicClient()}
+	sync := func() {
+		t.Helper()
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(mc)})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(mc), mc)).To(Succeed())
+	}
+
+	sync()
+	g.Expect(mc.Status.History[1].Outcome).To(Equal(hmc.ProgressingReason))
+
+	// the condition is set for the new generation before the HelmRelease has observed it
+	hr.Status.Conditions[0].ObservedGeneration = 2
+	g.Expect(cl.Status().Update(ctx, hr)).To(Succeed())
+	sync()
+	g.Expect(mc.Status.History[1].Outcome).To(Equal(hmc.ProgressingReason))
+
+	// the outcome is set once the HelmRelease has observed the generation
+	hr.Status.ObservedGeneration = 2
+	g.Expect(cl.Status().Update(ctx, hr)).To(Succeed())
+	sync()
+	g.Expect(mc.Status.History[1].Outcome).To(Equal(hmc.SucceededReason))
+}
+
+func TestHelmReleaseSpecApplied(t *testing.T) {
+	hrWithGeneration := func(generation int64) *hcv2.HelmRelease {
+		return &hcv2.HelmRelease{ObjectMeta: metav1.ObjectMeta{Generation: generation}}
+	}
+
+	for _, tc := range []struct {
+		name      string
+		current   *hcv2.HelmRelease
+		applied   *hcv2.HelmRelease
+		operation controllerutil.OperationResult
+		expected  bool
+	}{
+		{name: "created", applied: hrWithGeneration(1), operation: controllerutil.OperationResultCreated, expected: true},
+		{name: "spec updated", current: hrWithGeneration(1), applied: hrWithGeneration(2), operation: controllerutil.OperationResultUpdated, expected: true},
+		{name: "metadata updated", current: hrWithGeneration(1), applied: hrWithGeneration(1), operation: controllerutil.OperationResultUpdated},
+		{name: "unchanged", current: hrWithGeneration(1), applied: hrWithGeneration(1), operation: controllerutil.OperationResultNone},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			NewWithT(t).Expect(helmReleaseSpecApplied(tc.current, tc.applied, tc.operation)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestMapCAPIObjectToManagedCluster(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		labels   map[string]string
+		expected []ctrl.Request
+	}{
+		{
+			name:     "Cluster deployed by the HelmRelease",
+			labels:   map[string]string{hmc.FluxHelmChartNameKey: "dev", hmc.FluxHelmChartNamespaceKey: "team-a"},
+			expected: []ctrl.Request{{NamespacedName: client.ObjectKey{Namespace: "team-a", Name: "dev"}}},
+		},
+		{
+			name:     "Cluster without the namespace of the HelmRelease",
+			labels:   map[string]string{hmc.FluxHelmChartNameKey: "dev"},
+			expected: []ctrl.Request{{NamespacedName: client.ObjectKey{Namespace: "default", Name: "dev"}}},
+		},
+		{
+			name:     "Machine of the cluster",
+			labels:   map[string]string{hmc.ClusterNameLabelKey: "dev"},
+			expected: []ctrl.Request{{NamespacedName: client.ObjectKey{Namespace: "default", Name: "dev"}}},
+		},
+		{
+			name: "not deployed by HMC",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "dev-md-0", Labels: tc.labels}}
+			NewWithT(t).Expect(mapCAPIObjectToManagedCluster(context.Background(), obj)).To(Equal(tc.expected))
+		})
+	}
+}
+
+// watchRecorder is the controller recording the watches started on it.
+type watchRecorder struct {
+	controller.Controller
+	watches int
+}
+
+func (c *watchRecorder) Watch(source.Source) error {
+	c.watches++
+	return nil
+}
+
+func TestWatchCAPIObjects(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	mapper := apimeta.NewDefaultRESTMapper(nil)
+	recorder := &watchRecorder{}
+	r := &ManagedClusterStatusReconciler{
+		Client:      fake.NewClientBuilder().WithScheme(newStatusScheme()).WithRESTMapper(mapper).Build(),
+		controller:  recorder,
+		shards:      &shardFilter{},
+		capiWatches: map[schema.GroupVersionKind]bool{},
+	}
+
+	// nothing is watched until the CAPI CRDs are installed
+	g.Expect(r.watchCAPIObjects(ctx)).To(Succeed())
+	g.Expect(recorder.watches).To(BeZero())
+
+	mapper.Add(capiClusterGVK, apimeta.RESTScopeNamespace)
+	g.Expect(r.watchCAPIObjects(ctx)).To(Succeed())
+	g.Expect(recorder.watches).To(Equal(1))
+	g.Expect(r.capiWatches).To(Equal(map[schema.GroupVersionKind]bool{capiClusterGVK: true}))
+
+	// the kinds already watched are not watched again
+	mapper.Add(capiMachineGVK, apimeta.RESTScopeNamespace)
+	g.Expect(r.watchCAPIObjects(ctx)).To(Succeed())
+	g.Expect(r.watchCAPIObjects(ctx)).To(Succeed())
+	g.Expect(recorder.watches).To(Equal(2))
+	g.Expect(r.capiWatches).To(Equal(map[schema.GroupVersionKind]bool{capiClusterGVK: true, capiMachineGVK: true}))
+}
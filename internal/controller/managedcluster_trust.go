@@ -0,0 +1,299 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/credspropagation"
+	"github.com/Mirantis/hmc/internal/helm"
+)
+
+const (
+	// trustedCAVolumeName is the name of the volume of the trusted CA bundle in the services deployed by HMC.
+	trustedCAVolumeName = "hmc-trusted-ca"
+	// trustedCAMountPath is the directory the trusted CA bundle is mounted at in the services deployed by HMC.
+	trustedCAMountPath = "/etc/hmc/trusted-ca"
+)
+
+// resolvedTrust is the HTTP proxy and the trusted CA bundle of the Management.
+type resolvedTrust struct {
+	proxy *hmc.ProxyConfig
+	// caBundle is the PEM-encoded trusted CA bundle, empty if not set.
+	caBundle string
+	// noProxy lists the destinations the services deployed by HMC reach directly,
+	// the networks of the cluster are added once the Cluster is created.
+	noProxy []string
+}
+
+// resolveTrust returns the proxy and the trusted CA bundle configured on the Management,
+// the bundle is read from the ConfigMap in the system namespace.
+func (r *ManagedClusterReconciler) resolveTrust(ctx context.Context, mc *hmc.ManagedCluster) (*resolvedTrust, error) {
+	mgmt := &hmc.Management{}
+	if err := r.Get(ctx, client.ObjectKey{Name: hmc.ManagementName}, mgmt); client.IgnoreNotFound(err) != nil {
+		return nil, fmt.Errorf("failed to get Management: %w", err)
+	}
+
+	trust := &resolvedTrust{proxy: mgmt.Spec.Proxy}
+	if ca := mgmt.Spec.TrustedCA; ca != nil {
+		configMap := &corev1.ConfigMap{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: r.SystemNamespace, Name: ca.ConfigMap}, configMap); err != nil {
+			return nil, fmt.Errorf("failed to get trusted CA ConfigMap %s/%s: %w", r.SystemNamespace, ca.ConfigMap, err)
+		}
+		key := cmp.Or(ca.Key, hmc.TrustedCADefaultKey)
+		trust.caBundle = configMap.Data[key]
+		if trust.caBundle == "" {
+			return nil, fmt.Errorf("the trusted CA ConfigMap %s/%s has no %s key", r.SystemNamespace, ca.ConfigMap, key)
+		}
+	}
+
+	if trust.proxy == nil {
+		return trust, nil
+	}
+
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(capiClusterGVK)
+	if err := r.Get(ctx, client.ObjectKeyFromObject(mc), cluster); err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get Cluster %s/%s: %w", mc.Namespace, mc.Name, err)
+	}
+	pods, _, _ := unstructured.NestedStringSlice(cluster.Object, "spec", "clusterNetwork", "pods", "cidrBlocks")
+	services, _, _ := unstructured.NestedStringSlice(cluster.Object, "spec", "clusterNetwork", "services", "cidrBlocks")
+	var endpoint []string
+	if host, _, _ := unstructured.NestedString(cluster.Object, "spec", "controlPlaneEndpoint", "host"); host != "" {
+		endpoint = append(endpoint, host)
+	}
+	machines, err := r.machineNetworks(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	// the instance metadata service of the cloud is reached directly by the cloud SDKs
+	trust.noProxy = dedup(slices.Concat(trust.proxy.NoProxy, endpoint, machines, pods, services,
+		[]string{"localhost", "127.0.0.1", "169.254.169.254", ".svc", ".cluster.local"}))
+	return trust, nil
+}
+
+// infraClusterNetworkPaths are the paths of the CIDRs of the VPC and of the subnets of the
+// machines in the infrastructure clusters, per the kind of the infrastructure cluster.
+var infraClusterNetworkPaths = map[string][][]string{
+	"AWSCluster": {
+		{"spec", "network", "vpc", "cidrBlock"},
+		{"spec", "network", "subnets", "cidrBlock"},
+	},
+	"AzureCluster": {
+		{"spec", "networkSpec", "vnet", "cidrBlocks"},
+		{"spec", "networkSpec", "subnets", "cidrBlocks"},
+	},
+}
+
+// machineNetworks returns the CIDRs of the VPC and of the subnets of the machines of the cluster
+// set in the infrastructure cluster, the kinds of the infrastructure clusters not known are skipped.
+func (r *ManagedClusterReconciler) machineNetworks(ctx context.Context, cluster *unstructured.Unstructured) ([]string, error) {
+	ref, _, _ := unstructured.NestedStringMap(cluster.Object, "spec", "infrastructureRef")
+	paths, ok := infraClusterNetworkPaths[ref["kind"]]
+	if !ok {
+		return nil, nil
+	}
+
+	infraCluster := &unstructured.Unstructured{}
+	infraCluster.SetAPIVersion(ref["apiVersion"])
+	infraCluster.SetKind(ref["kind"])
+	key := client.ObjectKey{Namespace: cluster.GetNamespace(), Name: ref["name"]}
+	if err := r.Get(ctx, key, infraCluster); err != nil {
+		if apierrors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get %s %s: %w", ref["kind"], key, err)
+	}
+
+	var cidrs []string
+	for _, path := range paths {
+		cidrs = append(cidrs, nestedStrings(infraCluster.Object, path)...)
+	}
+	return cidrs, nil
+}
+
+// nestedStrings returns the strings at the path of the object, the lists on the path are
+// traversed and the string lists at the path are flattened.
+func nestedStrings(obj any, path []string) []string {
+	switch v := obj.(type) {
+	case string:
+		if len(path) == 0 && v != "" {
+			return []string{v}
+		}
+	case []any:
+		var values []string
+		for _, item := range v {
+			values = append(values, nestedStrings(item, path)...)
+		}
+		return values
+	case map[string]any:
+		if len(path) > 0 {
+			return nestedStrings(v[path[0]], path[1:])
+		}
+	}
+	return nil
+}
+
+// dedup returns the values without the duplicates, the order of the first occurrences is kept.
+func dedup(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	result := values[:0]
+	for _, v := range values {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		result = append(result, v)
+	}
+	return result
+}
+
+// env returns the environment variables passing the proxy and the location of the
+// trusted CA bundle to the services deployed by HMC.
+func (t *resolvedTrust) env() []corev1.EnvVar {
+	var env []corev1.EnvVar
+	if t.proxy != nil {
+		for _, v := range []corev1.EnvVar{
+			{Name: "HTTP_PROXY", Value: t.proxy.HTTPProxy},
+			{Name: "HTTPS_PROXY", Value: t.proxy.HTTPSProxy},
+			{Name: "NO_PROXY", Value: strings.Join(t.noProxy, ",")},
+		} {
+			if v.Value != "" {
+				env = append(env, v)
+			}
+		}
+	}
+	if t.caBundle != "" {
+		// the bundle is trusted along with the CAs of the image by the Go programs
+		env = append(env, corev1.EnvVar{Name: "SSL_CERT_DIR", Value: "/etc/ssl/certs:" + trustedCAMountPath})
+	}
+	return env
+}
+
+// volumes returns the volume and the mount of the trusted CA bundle of the services deployed by HMC.
+func (t *resolvedTrust) volumes() ([]corev1.Volume, []corev1.VolumeMount) {
+	if t.caBundle == "" {
+		return nil, nil
+	}
+	volume := corev1.Volume{
+		Name: trustedCAVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: hmc.TrustedCAConfigMapName},
+			},
+		},
+	}
+	mount := corev1.VolumeMount{Name: trustedCAVolumeName, MountPath: trustedCAMountPath, ReadOnly: true}
+	return []corev1.Volume{volume}, []corev1.VolumeMount{mount}
+}
+
+// serviceValues sets the proxy and the trusted CA bundle over the values of a service at the paths
+// defined by the default values of its ServiceTemplate, the same as for the cluster templates. The
+// destinations of the cluster are included in the hosts reached directly.
+func (t *resolvedTrust) serviceValues(values, defaultValues *apiextensionsv1.JSON) (*apiextensionsv1.JSON, error) {
+	if t == nil || (t.proxy == nil && t.caBundle == "") || defaultValues == nil || len(defaultValues.Raw) == 0 {
+		return values, nil
+	}
+
+	chartValues := make(map[string]any)
+	if err := json.Unmarshal(defaultValues.Raw, &chartValues); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the default values: %w", err)
+	}
+	serviceValues := make(map[string]any)
+	if values != nil && len(values.Raw) > 0 {
+		if err := json.Unmarshal(values.Raw, &serviceValues); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the values: %w", err)
+		}
+	}
+
+	var proxy *hmc.ProxyConfig
+	if t.proxy != nil {
+		proxy = t.proxy.DeepCopy()
+		proxy.NoProxy = t.noProxy
+	}
+	helm.SetTrustValues(serviceValues, chartValues, proxy, t.caBundle)
+
+	raw, err := json.Marshal(serviceValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the values: %w", err)
+	}
+	return &apiextensionsv1.JSON{Raw: raw}, nil
+}
+
+// trustedServiceNamespaces returns the namespaces of the services deployed by HMC on the cluster,
+// including the services of the spec and the backup agent.
+func trustedServiceNamespaces(mc *hmc.ManagedCluster) []string {
+	var namespaces []string
+	if mc.Spec.DNS != nil {
+		namespaces = append(namespaces, hmc.DNSNamespace)
+	}
+	if mc.Spec.Autoscaler != nil {
+		namespaces = append(namespaces, hmc.AutoscalerNamespace)
+	}
+	if mc.Spec.Backup != nil {
+		namespaces = append(namespaces, backupNamespace(mc.Spec.Backup))
+	}
+	for _, svc := range mc.Spec.Services {
+		if !svc.Disable {
+			namespaces = append(namespaces, serviceReleaseNamespace(svc))
+		}
+	}
+	slices.Sort(namespaces)
+	return slices.Compact(namespaces)
+}
+
+// reconcileTrustedCA writes the trusted CA bundle of the Management to the namespaces of
+// the services deployed by HMC on the cluster, the bundle is removed once unset.
+func (r *ManagedClusterReconciler) reconcileTrustedCA(ctx context.Context, mc *hmc.ManagedCluster) error {
+	namespaces := trustedServiceNamespaces(mc)
+	if len(namespaces) == 0 {
+		return nil
+	}
+
+	trust, err := r.resolveTrust(ctx, mc)
+	if err != nil {
+		return err
+	}
+
+	kubeconfSecret, err := r.getKubeconfigSecret(ctx, mc)
+	if err != nil {
+		return err
+	}
+	cfg := &credspropagation.PropagationCfg{
+		Client:          r.Client,
+		ManagedCluster:  mc,
+		KubeconfSecret:  kubeconfSecret,
+		SystemNamespace: r.SystemNamespace,
+		SecretWriter:    r.secretWriter(),
+	}
+	if trust.caBundle == "" {
+		return credspropagation.RemoveTrustedCA(ctx, cfg, namespaces)
+	}
+	return credspropagation.PropagateTrustedCA(ctx, cfg, namespaces, trust.caBundle)
+}
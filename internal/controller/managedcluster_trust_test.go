@@ -0,0 +1,148 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+)
+
+func TestResolveTrustNoProxy(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	awsClusterGVK := schema.GroupVersionKind{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta2", Kind: "AWSCluster"}
+	trustScheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(trustScheme)).To(Succeed())
+	g.Expect(hmc.AddToScheme(trustScheme)).To(Succeed())
+	for _, gvk := range []schema.GroupVersionKind{capiClusterGVK, awsClusterGVK} {
+		trustScheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+		trustScheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind(gvk.Kind+"List"), &unstructured.UnstructuredList{})
+	}
+
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("team-a"))
+	mgmt := &hmc.Management{
+		ObjectMeta: metav1.ObjectMeta{Name: hmc.ManagementName},
+		Spec: hmc.ManagementSpec{Proxy: &hmc.ProxyConfig{
+			HTTPSProxy: "http://proxy.example.com:3128",
+			NoProxy:    []string{".corp.example.com", "10.0.0.0/16"},
+		}},
+	}
+
+	cluster := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{
+			"controlPlaneEndpoint": map[string]any{"host": "dev-apiserver.elb.amazonaws.com", "port": int64(6443)},
+			"clusterNetwork": map[string]any{
+				"pods":     map[string]any{"cidrBlocks": []any{"192.168.0.0/16"}},
+				"services": map[string]any{"cidrBlocks": []any{"10.96.0.0/12"}},
+			},
+			"infrastructureRef": map[string]any{"apiVersion": awsClusterGVK.GroupVersion().String(), "kind": awsClusterGVK.Kind, "name": "dev"},
+		},
+	}}
+	cluster.SetGroupVersionKind(capiClusterGVK)
+	cluster.SetNamespace(mc.Namespace)
+	cluster.SetName(mc.Name)
+
+	awsCluster := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{"network": map[string]any{
+			"vpc": map[string]any{"cidrBlock": "10.0.0.0/16"},
+			"subnets": []any{
+				map[string]any{"id": "public", "cidrBlock": "10.0.0.0/24"},
+				map[string]any{"id": "private", "cidrBlock": "10.0.1.0/24"},
+			},
+		}},
+	}}
+	awsCluster.SetGroupVersionKind(awsClusterGVK)
+	awsCluster.SetNamespace(mc.Namespace)
+	awsCluster.SetName("dev")
+
+	r := &ManagedClusterReconciler{
+		Client:          fake.NewClientBuilder().WithScheme(trustScheme).WithObjects(mgmt, cluster, awsCluster).Build(),
+		SystemNamespace: "hmc-system",
+	}
+	trust, err := r.resolveTrust(ctx, mc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(trust.noProxy).To(Equal([]string{
+		".corp.example.com", "10.0.0.0/16", "dev-apiserver.elb.amazonaws.com", "10.0.0.0/24", "10.0.1.0/24",
+		"192.168.0.0/16", "10.96.0.0/12", "localhost", "127.0.0.1", "169.254.169.254", ".svc", ".cluster.local",
+	}))
+
+	// the networks of the cluster are added once the Cluster is created
+	r.Client = fake.NewClientBuilder().WithScheme(trustScheme).WithObjects(mgmt).Build()
+	trust, err = r.resolveTrust(ctx, mc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(trust.noProxy).To(Equal([]string{
+		".corp.example.com", "10.0.0.0/16", "localhost", "127.0.0.1", "169.254.169.254", ".svc", ".cluster.local",
+	}))
+}
+
+func TestTrustServiceValues(t *testing.T) {
+	g := NewWithT(t)
+
+	trust := &resolvedTrust{
+		proxy:    &hmc.ProxyConfig{HTTPSProxy: "http://proxy.example.com:3128", NoProxy: []string{".corp.example.com"}},
+		caBundle: "-----BEGIN CERTIFICATE-----",
+		noProxy:  []string{".corp.example.com", "10.96.0.0/12"},
+	}
+	defaults := &apiextensionsv1.JSON{Raw: []byte(`{"proxy":{"httpsProxy":"","noProxy":[]},"trustedCA":"","replicas":1}`)}
+
+	// the values are set at the paths defined by the default values of the ServiceTemplate
+	values, err := trust.serviceValues(&apiextensionsv1.JSON{Raw: []byte(`{"replicas":3}`)}, defaults)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(values.Raw).To(MatchJSON(`{"replicas":3,"trustedCA":"-----BEGIN CERTIFICATE-----",` +
+		`"proxy":{"httpsProxy":"http://proxy.example.com:3128","noProxy":[".corp.example.com","10.96.0.0/12"]}}`))
+
+	// the values of the charts of the other layouts are kept intact
+	original := &apiextensionsv1.JSON{Raw: []byte(`{"replicas":3}`)}
+	values, err = trust.serviceValues(original, &apiextensionsv1.JSON{Raw: []byte(`{"replicas":1}`)})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(values.Raw).To(MatchJSON(`{"replicas":3}`))
+
+	var noTrust *resolvedTrust
+	values, err = noTrust.serviceValues(original, defaults)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(values).To(BeIdenticalTo(original))
+}
+
+func TestTrustedServiceNamespaces(t *testing.T) {
+	g := NewWithT(t)
+
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("team-a"))
+	mc.Spec.DNS = &hmc.DNSConfig{}
+	mc.Spec.Backup = &hmc.ManagedClusterBackupSpec{Template: "velero"}
+	mc.Spec.Services = []hmc.ServiceSpec{
+		{Name: "ingress", Namespace: "ingress-nginx", Template: "ingress-nginx"},
+		{Name: "cert-manager", Template: "cert-manager"},
+		{Name: "disabled", Template: "kyverno", Disable: true},
+		{Name: "ingress-internal", Namespace: "ingress-nginx", Template: "ingress-nginx"},
+	}
+
+	g.Expect(trustedServiceNamespaces(mc)).To(Equal([]string{
+		"cert-manager", hmc.DNSNamespace, "ingress-nginx", hmc.ManagedClusterBackupDefaultNamespace,
+	}))
+	g.Expect(trustedServiceNamespaces(managedcluster.NewManagedCluster())).To(BeEmpty())
+}
@@ -0,0 +1,190 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/metrics"
+)
+
+const (
+	machineRoleControlPlane = "control-plane"
+	machineRoleWorker       = "worker"
+
+	capiControlPlaneLabelKey = "cluster.x-k8s.io/control-plane"
+)
+
+// instanceTypePaths are the fields of the infrastructure machines of the
+// supported providers holding the instance type of the machine.
+var instanceTypePaths = [][]string{
+	{"spec", "instanceType"}, // AWS
+	{"spec", "vmSize"},       // Azure
+	{"spec", "machineType"},  // GCP
+	{"spec", "flavor"},       // OpenStack
+}
+
+// machineInstanceTypes caches the instance types of the machines of the ManagedClusters
+// by the UID of the CAPI Machine. The instance type of a machine never changes, so the
+// Machine and its infrastructure machine are fetched only once per machine while the
+// Machines themselves are listed from the metadata cache of the watched Machines.
+type machineInstanceTypes struct {
+	mu    sync.Mutex
+	types map[client.ObjectKey]map[types.UID]string
+}
+
+// get returns the instance types of the given Machines of the ManagedCluster, fetching the
+// ones not cached yet. The entries of the Machines no longer listed are dropped.
+func (m *machineInstanceTypes) get(ctx context.Context, c client.Client, key client.ObjectKey, machines []metav1.PartialObjectMetadata) (map[types.UID]string, error) {
+	// the cache is not locked while fetching, the ManagedCluster is never reconciled concurrently
+	m.mu.Lock()
+	cached := m.types[key]
+	m.mu.Unlock()
+
+	instanceTypes := make(map[types.UID]string, len(machines))
+	for _, machine := range machines {
+		if instanceType, ok := cached[machine.UID]; ok {
+			instanceTypes[machine.UID] = instanceType
+			continue
+		}
+
+		instanceType, found, err := machineInstanceType(ctx, c, client.ObjectKeyFromObject(&machine))
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			instanceTypes[machine.UID] = instanceType
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.types == nil {
+		m.types = make(map[client.ObjectKey]map[types.UID]string)
+	}
+	m.types[key] = instanceTypes
+	return instanceTypes, nil
+}
+
+// forget drops the instance types cached for the ManagedCluster.
+func (m *machineInstanceTypes) forget(key client.ObjectKey) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.types, key)
+}
+
+// setUsage records the machines of the ManagedCluster by the role and the instance
+// type in its status and exposes them as metrics. The machines are not reported
+// while the CAPI provider is not installed.
+func setUsage(ctx context.Context, c client.Client, instanceTypes *machineInstanceTypes, managedCluster *hmc.ManagedCluster) error {
+	machines := &metav1.PartialObjectMetadataList{}
+	machines.SetGroupVersionKind(capiMachineGVK.GroupVersion().WithKind(capiMachineGVK.Kind + "List"))
+	err := c.List(ctx, machines, client.InNamespace(managedCluster.Namespace),
+		client.MatchingLabels{hmc.ClusterNameLabelKey: managedCluster.Name})
+	if apimeta.IsNoMatchError(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list Machines of ManagedCluster %s/%s: %w", managedCluster.Namespace, managedCluster.Name, err)
+	}
+
+	machineTypes, err := instanceTypes.get(ctx, c, client.ObjectKeyFromObject(managedCluster), machines.Items)
+	if err != nil {
+		return err
+	}
+
+	usage := &hmc.ManagedClusterUsage{}
+	if managedCluster.Status.Usage != nil {
+		usage.ProvisionedAt = managedCluster.Status.Usage.ProvisionedAt
+	}
+	if usage.ProvisionedAt == nil && apimeta.IsStatusConditionTrue(managedCluster.Status.Conditions, hmc.ReadyCondition) {
+		usage.ProvisionedAt = &metav1.Time{Time: apimeta.FindStatusCondition(managedCluster.Status.Conditions, hmc.ReadyCondition).LastTransitionTime.Time}
+	}
+
+	for _, machine := range machines.Items {
+		role := machineRoleWorker
+		if _, ok := machine.GetLabels()[capiControlPlaneLabelKey]; ok {
+			role = machineRoleControlPlane
+			usage.ControlPlaneMachines++
+		} else {
+			usage.WorkerMachines++
+		}
+
+		instanceType := machineTypes[machine.UID]
+		i := slices.IndexFunc(usage.Machines, func(m hmc.MachineUsage) bool {
+			return m.Role == role && m.InstanceType == instanceType
+		})
+		if i < 0 {
+			usage.Machines = append(usage.Machines, hmc.MachineUsage{Role: role, InstanceType: instanceType})
+			i = len(usage.Machines) - 1
+		}
+		usage.Machines[i].Count++
+	}
+	slices.SortFunc(usage.Machines, func(a, b hmc.MachineUsage) int {
+		return cmp.Or(cmp.Compare(a.Role, b.Role), cmp.Compare(a.InstanceType, b.InstanceType))
+	})
+
+	managedCluster.Status.Usage = usage
+	metrics.SetManagedClusterUsage(managedCluster.Namespace, managedCluster.Name, managedCluster.Annotations[hmc.CostCenterAnnotation], usage)
+	return nil
+}
+
+// machineInstanceType returns the instance type of the infrastructure machine
+// referenced by the CAPI Machine, empty if the provider is not supported. It
+// returns false if the Machine or the infrastructure machine is yet to be found.
+func machineInstanceType(ctx context.Context, c client.Client, key client.ObjectKey) (string, bool, error) {
+	machine := &unstructured.Unstructured{}
+	machine.SetGroupVersionKind(capiMachineGVK)
+	if err := c.Get(ctx, key, machine); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("failed to get Machine %s: %w", key, err)
+	}
+
+	ref, ok, _ := unstructured.NestedStringMap(machine.Object, "spec", "infrastructureRef")
+	if !ok || ref["kind"] == "" || ref["name"] == "" {
+		return "", false, nil
+	}
+
+	infraMachine := &unstructured.Unstructured{}
+	infraMachine.SetAPIVersion(ref["apiVersion"])
+	infraMachine.SetKind(ref["kind"])
+	infraKey := client.ObjectKey{Namespace: cmp.Or(ref["namespace"], machine.GetNamespace()), Name: ref["name"]}
+	if err := c.Get(ctx, infraKey, infraMachine); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("failed to get %s %s: %w", ref["kind"], infraKey, err)
+	}
+
+	for _, path := range instanceTypePaths {
+		if instanceType, ok, _ := unstructured.NestedString(infraMachine.Object, path...); ok && instanceType != "" {
+			return instanceType, true, nil
+		}
+	}
+	return "", true, nil
+}
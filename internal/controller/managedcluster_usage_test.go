@@ -0,0 +1,117 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+)
+
+func TestSetUsage(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	awsMachineGVK := schema.GroupVersionKind{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta2", Kind: "AWSMachine"}
+	usageScheme := runtime.NewScheme()
+	for _, gvk := range []schema.GroupVersionKind{capiMachineGVK, awsMachineGVK} {
+		usageScheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+		usageScheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind(gvk.Kind+"List"), &unstructured.UnstructuredList{})
+	}
+
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"))
+	newMachine := func(name, instanceType string, controlPlane bool) []client.Object {
+		machine := &unstructured.Unstructured{}
+		machine.SetGroupVersionKind(capiMachineGVK)
+		machine.SetNamespace(mc.Namespace)
+		machine.SetName(name)
+		machine.SetUID(types.UID(name))
+		labels := map[string]string{hmc.ClusterNameLabelKey: mc.Name}
+		if controlPlane {
+			labels[capiControlPlaneLabelKey] = ""
+		}
+		machine.SetLabels(labels)
+		g.Expect(unstructured.SetNestedStringMap(machine.Object, map[string]string{
+			"apiVersion": awsMachineGVK.GroupVersion().String(),
+			"kind":       awsMachineGVK.Kind,
+			"name":       name,
+		}, "spec", "infrastructureRef")).To(Succeed())
+
+		infraMachine := &unstructured.Unstructured{}
+		infraMachine.SetGroupVersionKind(awsMachineGVK)
+		infraMachine.SetNamespace(mc.Namespace)
+		infraMachine.SetName(name)
+		g.Expect(unstructured.SetNestedField(infraMachine.Object, instanceType, "spec", "instanceType")).To(Succeed())
+		return []client.Object{machine, infraMachine}
+	}
+
+	var objects []client.Object
+	objects = append(objects, newMachine("cp-0", "t3.large", true)...)
+	objects = append(objects, newMachine("md-0", "t3.small", false)...)
+	objects = append(objects, newMachine("md-1", "t3.small", false)...)
+
+	gets := 0
+	cl := fake.NewClientBuilder().WithScheme(usageScheme).WithObjects(objects...).WithInterceptorFuncs(interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			gets++
+			return c.Get(ctx, key, obj, opts...)
+		},
+	}).Build()
+
+	instanceTypes := &machineInstanceTypes{}
+	g.Expect(setUsage(ctx, cl, instanceTypes, mc)).To(Succeed())
+	g.Expect(mc.Status.Usage).NotTo(BeNil())
+	g.Expect(mc.Status.Usage.ControlPlaneMachines).To(Equal(int32(1)))
+	g.Expect(mc.Status.Usage.WorkerMachines).To(Equal(int32(2)))
+	g.Expect(mc.Status.Usage.Machines).To(Equal([]hmc.MachineUsage{
+		{Role: machineRoleControlPlane, InstanceType: "t3.large", Count: 1},
+		{Role: machineRoleWorker, InstanceType: "t3.small", Count: 2},
+	}))
+	// the Machine and the infrastructure machine are fetched once per machine
+	g.Expect(gets).To(Equal(6))
+
+	// the instance types are cached, the Machines are only listed
+	g.Expect(setUsage(ctx, cl, instanceTypes, mc)).To(Succeed())
+	g.Expect(gets).To(Equal(6))
+
+	// the machines removed are no longer reported nor cached
+	g.Expect(cl.Delete(ctx, objects[4])).To(Succeed())
+	g.Expect(setUsage(ctx, cl, instanceTypes, mc)).To(Succeed())
+	g.Expect(mc.Status.Usage.WorkerMachines).To(Equal(int32(1)))
+	g.Expect(instanceTypes.types[client.ObjectKeyFromObject(mc)]).To(HaveLen(2))
+	g.Expect(gets).To(Equal(6))
+
+	// the usage is not reported while the CAPI provider is not installed
+	mc.Status.Usage = nil
+	noCAPI := fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+		List: func(context.Context, client.WithWatch, client.ObjectList, ...client.ListOption) error {
+			return &apimeta.NoKindMatchError{GroupKind: capiMachineGVK.GroupKind()}
+		},
+	}).Build()
+	g.Expect(setUsage(ctx, noCAPI, instanceTypes, mc)).To(Succeed())
+	g.Expect(mc.Status.Usage).To(BeNil())
+}
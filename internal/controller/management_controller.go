@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"time"
 
 	fluxv2 "github.com/fluxcd/helm-controller/api/v2"
 	"github.com/fluxcd/pkg/apis/meta"
@@ -28,19 +29,27 @@ import (
 	"helm.sh/helm/v3/pkg/chartutil"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/audit"
 	"github.com/Mirantis/hmc/internal/certmanager"
+	"github.com/Mirantis/hmc/internal/errdefs"
 	"github.com/Mirantis/hmc/internal/helm"
+	"github.com/Mirantis/hmc/internal/metrics"
+	"github.com/Mirantis/hmc/internal/telemetry"
 	"github.com/Mirantis/hmc/internal/utils"
 	"github.com/Mirantis/hmc/internal/utils/status"
 )
@@ -64,9 +73,14 @@ type ManagementReconciler struct {
 	CreateTemplateManagement bool
 }
 
-func (r *ManagementReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *ManagementReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	l := ctrl.LoggerFrom(ctx)
 	l.Info("Reconciling Management")
+	ctx = audit.WithActor(ctx, "management")
+
+	defer func(start time.Time) {
+		metrics.ObserveReconcileDuration("management", start, err)
+	}(time.Now())
 
 	management := &hmc.Management{}
 	if err := r.Get(ctx, req.NamespacedName, management); err != nil {
@@ -81,7 +95,7 @@ func (r *ManagementReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 	if !management.DeletionTimestamp.IsZero() {
 		l.Info("Deleting Management")
-		return r.Delete(ctx, management)
+		return errdefs.Result(r.Delete(ctx, management))
 	}
 
 	return r.Update(ctx, management)
@@ -98,6 +112,8 @@ func (r *ManagementReconciler) Update(ctx context.Context, management *hmc.Manag
 		return ctrl.Result{}, nil
 	}
 
+	telemetry.Configure(management.Spec.Telemetry)
+
 	if err := r.ensureTemplateManagement(ctx, management); err != nil {
 		l.Error(err, "Failed to ensure TemplateManagement is created")
 		return ctrl.Result{}, err
@@ -115,6 +131,7 @@ func (r *ManagementReconciler) Update(ctx context.Context, management *hmc.Manag
 		detectedProviders  = hmc.Providers{}
 		detectedComponents = make(map[string]hmc.ComponentStatus)
 		detectedContracts  = make(map[string]hmc.CompatibilityContracts)
+		detectedVersions   = make(map[string]string)
 	)
 
 	err := r.enableAdditionalComponents(ctx, management)
@@ -123,11 +140,25 @@ func (r *ManagementReconciler) Update(ctx context.Context, management *hmc.Manag
 		return ctrl.Result{}, err
 	}
 
+	if err := r.reconcileMonitoring(ctx, management); err != nil {
+		l.Error(err, "failed to reconcile the monitoring agent")
+		errs = errors.Join(errs, err)
+	}
+
 	components, err := wrappedComponents(management, release)
 	if err != nil {
 		l.Error(err, "failed to wrap HMC components")
 		return ctrl.Result{}, err
 	}
+
+	installedHMC := management.Status.Components[hmc.CoreHMCName]
+	if len(components) > 0 && installedHMC.Template != "" && installedHMC.Template != components[0].Template {
+		return r.upgradeHMC(ctx, management, components[0])
+	}
+	// the upgraded HMC has been deployed in the first phase of the self-upgrade
+	// but has not been reconciled successfully since
+	hmcUpgradeInProgress := installedHMC.Template != "" && !installedHMC.Success && installedHMC.Error == ""
+
 	for _, component := range components {
 		template := &hmc.ProviderTemplate{}
 		err := r.Get(ctx, client.ObjectKey{
@@ -146,6 +177,13 @@ func (r *ManagementReconciler) Update(ctx context.Context, management *hmc.Manag
 			continue
 		}
 
+		if err := helm.ValidateValues(template.Status.ConfigSchema, template.Status.Config, component.Config); err != nil {
+			errMsg := fmt.Sprintf("Invalid config of the %s component: %s", component.helmReleaseName, err)
+			updateComponentsStatus(detectedComponents, &detectedProviders, detectedContracts, component.helmReleaseName, component.Template, template.Status.Providers, template.Status.CAPIContracts, errMsg)
+			errs = errors.Join(errs, errors.New(errMsg))
+			continue
+		}
+
 		_, _, err = helm.ReconcileHelmRelease(ctx, r.Client, component.helmReleaseName, r.SystemNamespace, helm.ReconcileHelmReleaseOpts{
 			Values:          component.Config,
 			ChartRef:        template.Status.ChartRef,
@@ -160,6 +198,18 @@ func (r *ManagementReconciler) Update(ctx context.Context, management *hmc.Manag
 			continue
 		}
 
+		health, err := r.getComponentHealth(ctx, component.helmReleaseName, template.Status.Providers)
+		if err != nil {
+			errs = errors.Join(errs, err)
+		}
+		detectedComponents[component.helmReleaseName] = health
+
+		if component.helmReleaseName == hmc.CoreHMCName && hmcUpgradeInProgress && !health.HelmReleaseReady {
+			// the controller being replaced must not reconcile the other components
+			l.Info("Waiting for the upgraded HMC to become ready before reconciling the other components", "template", component.Template)
+			return ctrl.Result{RequeueAfter: defaultRequeueTime}, nil
+		}
+
 		if component.Template != hmc.CoreHMCName {
 			if err := r.checkProviderStatus(ctx, component.Template); err != nil {
 				updateComponentsStatus(detectedComponents, &detectedProviders, detectedContracts, component.helmReleaseName, component.Template, template.Status.Providers, template.Status.CAPIContracts, err.Error())
@@ -169,11 +219,22 @@ func (r *ManagementReconciler) Update(ctx context.Context, management *hmc.Manag
 		}
 
 		updateComponentsStatus(detectedComponents, &detectedProviders, detectedContracts, component.helmReleaseName, component.Template, template.Status.Providers, template.Status.CAPIContracts, "")
+		if template.Status.ProviderVersion != "" {
+			for _, provider := range template.Status.Providers {
+				detectedVersions[provider] = template.Status.ProviderVersion
+			}
+		}
+	}
+
+	if err := r.setAvailableUpgrades(ctx, detectedComponents); err != nil {
+		l.Error(err, "failed to determine the available upgrades of the components")
+		errs = errors.Join(errs, err)
 	}
 
 	management.Status.ObservedGeneration = management.Generation
 	management.Status.AvailableProviders = detectedProviders
 	management.Status.CAPIContracts = detectedContracts
+	management.Status.ProviderVersions = detectedVersions
 	management.Status.Components = detectedComponents
 	management.Status.Release = management.Spec.Release
 	if err := r.Status().Update(ctx, management); err != nil {
@@ -187,6 +248,99 @@ func (r *ManagementReconciler) Update(ctx context.Context, management *hmc.Manag
 	return ctrl.Result{}, nil
 }
 
+// upgradeHMC performs the first phase of the HMC self-upgrade: the HelmRelease of
+// the HMC is updated to the new template and the reconciliation stops, so that the
+// controller being replaced does not upgrade itself in the middle of the
+// reconciliation. The other components are reconciled in the second phase once
+// the upgraded HMC is ready. The upgrade is not started if the new template is not
+// valid or its CustomResourceDefinitions do not serve the versions stored in the cluster.
+func (r *ManagementReconciler) upgradeHMC(ctx context.Context, mgmt *hmc.Management, component component) (ctrl.Result, error) {
+	status := mgmt.Status.Components[hmc.CoreHMCName]
+	l := ctrl.LoggerFrom(ctx).WithValues("from", status.Template, "to", component.Template)
+	l.Info("Upgrading HMC")
+
+	blocked := func(err error) (ctrl.Result, error) {
+		l.Error(err, "HMC upgrade is blocked")
+		status.Error = fmt.Sprintf("Upgrade to %s is blocked: %s", component.Template, err)
+		status.Success = false
+		mgmt.Status.Components[hmc.CoreHMCName] = status
+		if updErr := r.Status().Update(ctx, mgmt); updErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to update status for Management %s: %w", mgmt.Name, updErr))
+		}
+		return ctrl.Result{}, err
+	}
+
+	template := &hmc.ProviderTemplate{}
+	if err := r.Get(ctx, client.ObjectKey{Name: component.Template}, template); err != nil {
+		return blocked(fmt.Errorf("failed to get ProviderTemplate %s: %w", component.Template, err))
+	}
+	if !template.Status.Valid {
+		return blocked(fmt.Errorf("template %s is not marked as valid", component.Template))
+	}
+	if err := r.checkCRDCompatibility(ctx, template); err != nil {
+		return blocked(err)
+	}
+
+	_, _, err := helm.ReconcileHelmRelease(ctx, r.Client, component.helmReleaseName, r.SystemNamespace, helm.ReconcileHelmReleaseOpts{
+		Values:   component.Config,
+		ChartRef: template.Status.ChartRef,
+	})
+	if err != nil {
+		return blocked(fmt.Errorf("error reconciling HelmRelease %s/%s: %w", r.SystemNamespace, component.helmReleaseName, err))
+	}
+
+	// the status of the upgraded HMC is reported by the upgraded controller
+	mgmt.Status.Components[hmc.CoreHMCName] = hmc.ComponentStatus{Template: component.Template}
+	if err := r.Status().Update(ctx, mgmt); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update status for Management %s: %w", mgmt.Name, err)
+	}
+
+	l.Info("HMC HelmRelease has been upgraded, the other components are reconciled once the upgraded HMC is ready")
+	return ctrl.Result{RequeueAfter: defaultRequeueTime}, nil
+}
+
+// checkCRDCompatibility returns an error if the CustomResourceDefinitions
+// installed by the template do not serve the versions stored in the cluster,
+// the stored objects would not be readable after the upgrade. The check fails
+// if the served versions of the template have not been detected.
+func (r *ManagementReconciler) checkCRDCompatibility(ctx context.Context, template *hmc.ProviderTemplate) error {
+	if cond := apimeta.FindStatusCondition(template.Status.Conditions, hmc.CRDVersionsDetectedCondition); cond == nil || cond.Status != metav1.ConditionTrue {
+		msg := "the served versions have not been detected yet"
+		if cond != nil {
+			msg = cond.Message
+		}
+		return fmt.Errorf("the compatibility of the CustomResourceDefinitions of %s can not be checked: %s", template.Name, msg)
+	}
+
+	names := make([]string, 0, len(template.Status.ServedCRDVersions))
+	for name := range template.Status.ServedCRDVersions {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var problems []string
+	for _, name := range names {
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := r.Get(ctx, client.ObjectKey{Name: name}, crd); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to get CustomResourceDefinition %s: %w", name, err)
+		}
+
+		for _, version := range crd.Status.StoredVersions {
+			if !slices.Contains(template.Status.ServedCRDVersions[name], version) {
+				problems = append(problems, fmt.Sprintf("%s does not serve the stored version %s", name, version))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("incompatible CustomResourceDefinitions: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
 func (r *ManagementReconciler) ensureTemplateManagement(ctx context.Context, mgmt *hmc.Management) error {
 	l := ctrl.LoggerFrom(ctx)
 	if !r.CreateTemplateManagement {
@@ -277,6 +431,21 @@ func (r *ManagementReconciler) checkProviderStatus(ctx context.Context, provider
 
 func (r *ManagementReconciler) Delete(ctx context.Context, management *hmc.Management) (ctrl.Result, error) {
 	l := ctrl.LoggerFrom(ctx)
+
+	// the deletion is blocked by the webhook, the finalizer protects the
+	// providers in case the webhook has been bypassed
+	managedClusters := &hmc.ManagedClusterList{}
+	if err := r.Client.List(ctx, managedClusters); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list ManagedClusters: %w", err)
+	}
+	if len(managedClusters.Items) > 0 {
+		clusters := make([]string, 0, len(managedClusters.Items))
+		for _, cluster := range managedClusters.Items {
+			clusters = append(clusters, client.ObjectKeyFromObject(&cluster).String())
+		}
+		l.Info("Waiting for ManagedClusters to be removed", "clusters", clusters)
+		return ctrl.Result{}, errdefs.Waiting(fmt.Errorf("ManagedClusters still exist: %s", strings.Join(clusters, ", ")))
+	}
 	listOpts := &client.ListOptions{
 		LabelSelector: labels.SelectorFromSet(map[string]string{hmc.HMCManagedLabelKey: hmc.HMCManagedLabelValue}),
 	}
@@ -293,7 +462,10 @@ func (r *ManagementReconciler) Delete(ctx context.Context, management *hmc.Manag
 	// Removing finalizer in the end of cleanup
 	l.Info("Removing Management finalizer")
 	if controllerutil.RemoveFinalizer(management, hmc.ManagementFinalizer) {
-		return ctrl.Result{}, r.Client.Update(ctx, management)
+		if err := r.Client.Update(ctx, management); err != nil {
+			return ctrl.Result{}, err
+		}
+		audit.Record(ctx, audit.ActionRemoveFinalizer, management, audit.FinalizerRemoved(hmc.ManagementFinalizer))
 	}
 	return ctrl.Result{}, nil
 }
@@ -481,6 +653,68 @@ func (r *ManagementReconciler) enableAdditionalComponents(ctx context.Context, m
 	return nil
 }
 
+// getComponentHealth returns the readiness and the installed chart version
+// of the HelmRelease of the component along with the availability of the
+// CustomResourceDefinitions installed by the component, either directly or
+// through the CAPI operator for the given providers.
+func (r *ManagementReconciler) getComponentHealth(ctx context.Context, helmReleaseName string, providers hmc.Providers) (hmc.ComponentStatus, error) {
+	health := hmc.ComponentStatus{}
+
+	hr := &fluxv2.HelmRelease{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: r.SystemNamespace, Name: helmReleaseName}, hr); err != nil {
+		return health, client.IgnoreNotFound(fmt.Errorf("failed to get HelmRelease %s/%s: %w", r.SystemNamespace, helmReleaseName, err))
+	}
+	health.HelmReleaseReady = apimeta.IsStatusConditionTrue(hr.Status.Conditions, meta.ReadyCondition)
+	if latest := hr.Status.History.Latest(); latest != nil {
+		health.Version = latest.ChartVersion
+	}
+
+	crds := make(map[string]*apiextensionsv1.CustomResourceDefinition)
+	releaseCRDs := &apiextensionsv1.CustomResourceDefinitionList{}
+	if err := r.List(ctx, releaseCRDs, client.MatchingLabels{
+		hmc.FluxHelmChartNameKey:      helmReleaseName,
+		hmc.FluxHelmChartNamespaceKey: r.SystemNamespace,
+	}); err != nil {
+		return health, fmt.Errorf("failed to list CustomResourceDefinitions of the HelmRelease %s/%s: %w", r.SystemNamespace, helmReleaseName, err)
+	}
+	for i := range releaseCRDs.Items {
+		crds[releaseCRDs.Items[i].Name] = &releaseCRDs.Items[i]
+	}
+
+	if len(providers) > 0 {
+		selector, err := labels.NewRequirement(hmc.ChartAnnotationProviderName, selection.In, providers)
+		if err != nil {
+			return health, fmt.Errorf("failed to construct providers selector: %w", err)
+		}
+		providerCRDs := &apiextensionsv1.CustomResourceDefinitionList{}
+		if err := r.List(ctx, providerCRDs, client.MatchingLabelsSelector{Selector: labels.NewSelector().Add(*selector)}); err != nil {
+			return health, fmt.Errorf("failed to list CustomResourceDefinitions of the providers %s: %w", strings.Join(providers, ", "), err)
+		}
+		for i := range providerCRDs.Items {
+			crds[providerCRDs.Items[i].Name] = &providerCRDs.Items[i]
+		}
+	}
+
+	for name, crd := range crds {
+		if !isCRDEstablished(crd) {
+			health.PendingCRDs = append(health.PendingCRDs, name)
+		}
+	}
+	slices.Sort(health.PendingCRDs)
+	health.CRDsEstablished = len(health.PendingCRDs) == 0
+
+	return health, nil
+}
+
+func isCRDEstablished(crd *apiextensionsv1.CustomResourceDefinition) bool {
+	for _, cond := range crd.Status.Conditions {
+		if cond.Type == apiextensionsv1.Established {
+			return cond.Status == apiextensionsv1.ConditionTrue
+		}
+	}
+	return false
+}
+
 func updateComponentsStatus(
 	components map[string]hmc.ComponentStatus,
 	providers *hmc.Providers,
@@ -491,11 +725,11 @@ func updateComponentsStatus(
 	templateContracts hmc.CompatibilityContracts,
 	err string,
 ) {
-	components[componentName] = hmc.ComponentStatus{
-		Error:    err,
-		Success:  err == "",
-		Template: templateName,
-	}
+	status := components[componentName]
+	status.Error = err
+	status.Success = err == ""
+	status.Template = templateName
+	components[componentName] = status
 
 	if err == "" {
 		*providers = append(*providers, templateProviders...)
@@ -508,9 +742,129 @@ func updateComponentsStatus(
 	}
 }
 
+// setAvailableUpgrades sets the upgrades of the components available in the
+// valid ProviderTemplateChains. The upgrades to the templates which are not
+// valid or whose CAPI contracts are not compatible with the installed core
+// CAPI, or in case of the core CAPI with the installed providers, are omitted.
+func (r *ManagementReconciler) setAvailableUpgrades(ctx context.Context, components map[string]hmc.ComponentStatus) error {
+	chains := &hmc.ProviderTemplateChainList{}
+	if err := r.List(ctx, chains); err != nil {
+		return fmt.Errorf("failed to list ProviderTemplateChains: %w", err)
+	}
+
+	templates := make(map[string]*hmc.ProviderTemplate)
+	getTemplate := func(name string) (*hmc.ProviderTemplate, error) {
+		if tpl, ok := templates[name]; ok {
+			return tpl, nil
+		}
+		tpl := &hmc.ProviderTemplate{}
+		if err := r.Get(ctx, client.ObjectKey{Name: name}, tpl); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, fmt.Errorf("failed to get ProviderTemplate %s: %w", name, err)
+			}
+			tpl = nil
+		}
+		templates[name] = tpl
+		return tpl, nil
+	}
+
+	capiTpl, err := getTemplate(components[hmc.CoreCAPIName].Template)
+	if err != nil {
+		return err
+	}
+
+	var errs error
+	for name, component := range components {
+		var upgrades []string
+		for _, chain := range chains.Items {
+			if !hmc.IsTemplateChainValid(&chain.Status) {
+				continue
+			}
+			_, chainUpgrades := chain.Spec.UpgradesOf(component.Template)
+			for _, upgrade := range chainUpgrades {
+				if slices.Contains(upgrades, upgrade) {
+					continue
+				}
+
+				tpl, err := getTemplate(upgrade)
+				if err != nil {
+					errs = errors.Join(errs, err)
+					continue
+				}
+				if tpl == nil || !tpl.Status.Valid {
+					continue
+				}
+
+				compatible := true
+				switch name {
+				case hmc.CoreHMCName:
+					// HMC itself does not depend on the CAPI contracts
+				case hmc.CoreCAPIName:
+					for providerName, provider := range components {
+						if providerName == hmc.CoreHMCName || providerName == hmc.CoreCAPIName {
+							continue
+						}
+						providerTpl, err := getTemplate(provider.Template)
+						if err != nil {
+							errs = errors.Join(errs, err)
+							continue
+						}
+						if providerTpl != nil && !capiContractsSupported(tpl.Status.CAPIContracts, providerTpl.Status.CAPIContracts) {
+							compatible = false
+						}
+					}
+				default:
+					compatible = capiTpl == nil || capiContractsSupported(capiTpl.Status.CAPIContracts, tpl.Status.CAPIContracts)
+				}
+
+				if compatible {
+					upgrades = append(upgrades, upgrade)
+				}
+			}
+		}
+
+		slices.Sort(upgrades)
+		component.AvailableUpgrades = upgrades
+		components[name] = component
+	}
+
+	return errs
+}
+
+// capiContractsSupported returns true if the core CAPI contracts include all
+// of the CAPI versions the provider is compatible with. The core CAPI
+// without the declared contracts is considered to support any provider.
+func capiContractsSupported(capiContracts, providerContracts hmc.CompatibilityContracts) bool {
+	if len(capiContracts) == 0 {
+		return true
+	}
+	for capiVersion := range providerContracts {
+		if _, ok := capiContracts[capiVersion]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ManagementReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&hmc.Management{}).
+		For(&hmc.Management{}, builder.WithPredicates(specOrMetadataChanged())).
+		Watches(&fluxv2.HelmRelease{},
+			handler.EnqueueRequestsFromMapFunc(func(_ context.Context, o client.Object) []ctrl.Request {
+				// the HelmReleases of the components are not owned, unlike the ones of the ManagedClusters
+				if o.GetNamespace() != r.SystemNamespace || len(o.GetOwnerReferences()) > 0 ||
+					o.GetLabels()[hmc.HMCManagedLabelKey] != hmc.HMCManagedLabelValue {
+					return nil
+				}
+				return []ctrl.Request{{NamespacedName: client.ObjectKey{Name: hmc.ManagementName}}}
+			}),
+			builder.WithPredicates(helmReleaseStatusChanged()),
+		).
+		Watches(&hmc.ProviderTemplateChain{},
+			handler.EnqueueRequestsFromMapFunc(func(context.Context, client.Object) []ctrl.Request {
+				return []ctrl.Request{{NamespacedName: client.ObjectKey{Name: hmc.ManagementName}}}
+			}),
+		).
 		Complete(r)
 }
@@ -0,0 +1,130 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/errdefs"
+)
+
+const (
+	monitoringServiceName      = "monitoring-agent"
+	monitoringServiceNamespace = "monitoring"
+	monitoringAgentValuesKey   = "victoria-metrics-agent"
+
+	// monitoringClusterLabel is instantiated by Sveltos for each of the clusters,
+	// the metrics of the clusters are told apart by the label.
+	monitoringClusterLabel = "cluster={{ .Cluster.metadata.namespace }}/{{ .Cluster.metadata.name }}"
+)
+
+// reconcileMonitoring ensures the MultiClusterService deploying the monitoring agent
+// on the managed clusters, the MultiClusterService is removed once the monitoring is disabled.
+func (r *ManagementReconciler) reconcileMonitoring(ctx context.Context, mgmt *hmc.Management) error {
+	l := ctrl.LoggerFrom(ctx)
+
+	mcs := &hmc.MultiClusterService{
+		ObjectMeta: metav1.ObjectMeta{Name: hmc.MonitoringMultiClusterServiceName},
+	}
+
+	if mgmt.Spec.Monitoring == nil {
+		if err := r.Client.Get(ctx, client.ObjectKeyFromObject(mcs), mcs); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if !monitoringOwnedBy(mcs, mgmt) {
+			return nil
+		}
+		if err := r.Client.Delete(ctx, mcs); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete MultiClusterService %s: %w", mcs.Name, err)
+		}
+		l.Info("Monitoring is disabled, removed the monitoring agent MultiClusterService")
+		return nil
+	}
+
+	values, err := monitoringAgentValues(mgmt.Spec.Monitoring)
+	if err != nil {
+		return err
+	}
+
+	operation, err := ctrl.CreateOrUpdate(ctx, r.Client, mcs, func() error {
+		if mcs.ResourceVersion != "" && !monitoringOwnedBy(mcs, mgmt) {
+			return errdefs.Terminal(fmt.Errorf("MultiClusterService %s already exists and is not managed by Management %s", mcs.Name, mgmt.Name))
+		}
+		if mcs.Labels == nil {
+			mcs.Labels = make(map[string]string)
+		}
+		mcs.Labels[hmc.HMCManagedLabelKey] = hmc.HMCManagedLabelValue
+		mcs.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: hmc.GroupVersion.String(),
+			Kind:       hmc.ManagementKind,
+			Name:       mgmt.Name,
+			UID:        mgmt.UID,
+		}}
+		mcs.Spec.ClusterSelector = mgmt.Spec.Monitoring.ClusterSelector
+		mcs.Spec.Services = []hmc.ServiceSpec{{
+			Name:      monitoringServiceName,
+			Namespace: monitoringServiceNamespace,
+			Template:  mgmt.Spec.Monitoring.Template,
+			Values:    values,
+		}}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reconcile the monitoring agent MultiClusterService %s: %w", mcs.Name, err)
+	}
+	if operation != controllerutil.OperationResultNone {
+		l.Info(fmt.Sprintf("Successfully %s the monitoring agent MultiClusterService", operation))
+	}
+	return nil
+}
+
+// monitoringOwnedBy reports whether the monitoring agent MultiClusterService is created by the Management,
+// the MultiClusterServices created by the users under the same name are left intact.
+func monitoringOwnedBy(mcs *hmc.MultiClusterService, mgmt *hmc.Management) bool {
+	if mcs.Labels[hmc.HMCManagedLabelKey] != hmc.HMCManagedLabelValue {
+		return false
+	}
+	for _, ref := range mcs.OwnerReferences {
+		if ref.Kind == hmc.ManagementKind && ref.UID == mgmt.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// monitoringAgentValues returns the values of the monitoring agent writing the metrics
+// of each of the clusters to the remote write endpoint, the user values take precedence.
+func monitoringAgentValues(monitoring *hmc.Monitoring) (*apiextensionsv1.JSON, error) {
+	raw, err := json.Marshal(map[string]any{
+		monitoringAgentValuesKey: map[string]any{
+			"remoteWrite": []any{map[string]any{"url": monitoring.RemoteWriteURL}},
+			"extraArgs":   map[string]any{"remoteWrite.label": monitoringClusterLabel},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the monitoring agent values: %w", err)
+	}
+
+	return mergeServiceValues(&apiextensionsv1.JSON{Raw: raw}, monitoring.Values)
+}
@@ -0,0 +1,87 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/errdefs"
+	"github.com/Mirantis/hmc/test/objects/management"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestReconcileMonitoring(t *testing.T) {
+	ctx := context.Background()
+
+	newManagement := func() *hmc.Management {
+		mgmt := management.NewManagement()
+		mgmt.UID = "uid"
+		mgmt.Spec.Monitoring = &hmc.Monitoring{Template: "victoria-metrics-agent-0-1-0", RemoteWriteURL: "https://metrics.example.com/api/v1/write"}
+		return mgmt
+	}
+	key := client.ObjectKey{Name: hmc.MonitoringMultiClusterServiceName}
+
+	t.Run("manages its own MultiClusterService", func(t *testing.T) {
+		g := NewWithT(t)
+
+		mgmt := newManagement()
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+		r := &ManagementReconciler{Client: cl}
+
+		g.Expect(r.reconcileMonitoring(ctx, mgmt)).To(Succeed())
+		mcs := &hmc.MultiClusterService{}
+		g.Expect(cl.Get(ctx, key, mcs)).To(Succeed())
+		g.Expect(mcs.Spec.Services).To(ConsistOf(HaveField("Template", "victoria-metrics-agent-0-1-0")))
+
+		// the MultiClusterService is removed once the monitoring is disabled
+		mgmt.Spec.Monitoring = nil
+		g.Expect(r.reconcileMonitoring(ctx, mgmt)).To(Succeed())
+		g.Expect(cl.Get(ctx, key, mcs)).NotTo(Succeed())
+	})
+
+	t.Run("leaves the MultiClusterService of the user intact", func(t *testing.T) {
+		g := NewWithT(t)
+
+		user := &hmc.MultiClusterService{
+			ObjectMeta: metav1.ObjectMeta{Name: hmc.MonitoringMultiClusterServiceName},
+			Spec:       hmc.MultiClusterServiceSpec{Services: []hmc.ServiceSpec{{Name: "user", Template: "user-template"}}},
+		}
+		mgmt := newManagement()
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(user).Build()
+		r := &ManagementReconciler{Client: cl}
+
+		// the MultiClusterService is not taken over
+		err := r.reconcileMonitoring(ctx, mgmt)
+		g.Expect(errdefs.IsTerminal(err)).To(BeTrue())
+		mcs := &hmc.MultiClusterService{}
+		g.Expect(cl.Get(ctx, key, mcs)).To(Succeed())
+		g.Expect(mcs.Spec.Services).To(ConsistOf(HaveField("Template", "user-template")))
+		g.Expect(mcs.OwnerReferences).To(BeEmpty())
+
+		// nor deleted once the monitoring is disabled, even if labeled alike
+		mcs.Labels = map[string]string{hmc.HMCManagedLabelKey: hmc.HMCManagedLabelValue}
+		g.Expect(cl.Update(ctx, mcs)).To(Succeed())
+		mgmt.Spec.Monitoring = nil
+		g.Expect(r.reconcileMonitoring(ctx, mgmt)).To(Succeed())
+		g.Expect(cl.Get(ctx, key, mcs)).To(Succeed())
+	})
+}
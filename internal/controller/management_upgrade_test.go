@@ -0,0 +1,153 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/template"
+)
+
+const widgetsCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: {{ required "name is required" .Values.name }}
+spec:
+  versions:
+  - name: v1beta1
+    served: true
+`
+
+func TestFillServedCRDVersions(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   map[string]any
+		status   metav1.ConditionStatus
+		reason   string
+		versions map[string][]string
+	}{
+		{
+			name:     "should detect the served versions",
+			values:   map[string]any{"name": "widgets.example.com"},
+			status:   metav1.ConditionTrue,
+			reason:   hmc.SucceededReason,
+			versions: map[string][]string{"widgets.example.com": {"v1beta1"}},
+		},
+		{
+			name:   "should report the skipped detection if the chart can not be rendered",
+			status: metav1.ConditionFalse,
+			reason: hmc.RenderFailedReason,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			helmChart := &chart.Chart{
+				Metadata:  &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "hmc", Version: "0.1.0"},
+				Values:    tt.values,
+				Templates: []*chart.File{{Name: "templates/crd.yaml", Data: []byte(widgetsCRD)}},
+			}
+
+			pt := template.NewProviderTemplate(template.WithName("hmc-0-1-0"))
+			// the versions detected before are dropped if the detection fails
+			pt.Status.ServedCRDVersions = map[string][]string{"widgets.example.com": {"v1alpha1"}}
+			fillServedCRDVersions(context.Background(), pt, helmChart, "hmc-system")
+
+			g.Expect(pt.Status.ServedCRDVersions).To(Equal(tt.versions))
+			cond := apimeta.FindStatusCondition(pt.Status.Conditions, hmc.CRDVersionsDetectedCondition)
+			g.Expect(cond).NotTo(BeNil())
+			g.Expect(cond.Status).To(Equal(tt.status))
+			g.Expect(cond.Reason).To(Equal(tt.reason))
+		})
+	}
+}
+
+func TestCheckCRDCompatibility(t *testing.T) {
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets.example.com"},
+		Status:     apiextensionsv1.CustomResourceDefinitionStatus{StoredVersions: []string{"v1alpha1", "v1beta1"}},
+	}
+	detected := metav1.Condition{Type: hmc.CRDVersionsDetectedCondition, Status: metav1.ConditionTrue, Reason: hmc.SucceededReason}
+
+	tests := []struct {
+		name       string
+		conditions []metav1.Condition
+		versions   map[string][]string
+		err        string
+	}{
+		{
+			name:       "should succeed if the stored versions are served",
+			conditions: []metav1.Condition{detected},
+			versions: map[string][]string{
+				"widgets.example.com": {"v1alpha1", "v1beta1"},
+				// the CustomResourceDefinitions not installed yet are not checked
+				"gadgets.example.com": {"v1"},
+			},
+		},
+		{
+			name:       "should fail if a stored version is not served",
+			conditions: []metav1.Condition{detected},
+			versions:   map[string][]string{"widgets.example.com": {"v1beta1"}},
+			err:        "widgets.example.com does not serve the stored version v1alpha1",
+		},
+		{
+			name: "should fail if the served versions have not been detected",
+			conditions: []metav1.Condition{{
+				Type:    hmc.CRDVersionsDetectedCondition,
+				Status:  metav1.ConditionFalse,
+				Reason:  hmc.RenderFailedReason,
+				Message: "the chart can not be rendered",
+			}},
+			err: "the compatibility of the CustomResourceDefinitions of hmc-0-1-0 can not be checked: the chart can not be rendered",
+		},
+		{
+			name: "should fail if the template has not been reconciled yet",
+			err:  "the served versions have not been detected yet",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			s := runtime.NewScheme()
+			g.Expect(apiextensionsv1.AddToScheme(s)).To(Succeed())
+			r := &ManagementReconciler{Client: fake.NewClientBuilder().WithScheme(s).WithObjects(crd).Build()}
+
+			pt := template.NewProviderTemplate(template.WithName("hmc-0-1-0"))
+			pt.Status.Conditions = tt.conditions
+			pt.Status.ServedCRDVersions = tt.versions
+
+			err := r.checkCRDCompatibility(context.Background(), pt)
+			if tt.err == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+			} else {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.err)))
+			}
+		})
+	}
+}
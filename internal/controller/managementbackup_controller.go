@@ -0,0 +1,172 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/velero"
+)
+
+// ManagementBackupReconciler reconciles a ManagementBackup object
+type ManagementBackupReconciler struct {
+	client.Client
+	DiscoveryClient discovery.DiscoveryInterface
+	VeleroNamespace string
+
+	veleroWatches veleroWatches
+}
+
+func (r *ManagementBackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	l := ctrl.LoggerFrom(ctx)
+	l.Info("Reconciling ManagementBackup")
+
+	mgmtBackup := &hmc.ManagementBackup{}
+	if err := r.Get(ctx, req.NamespacedName, mgmtBackup); err != nil {
+		if apierrors.IsNotFound(err) {
+			l.Info("ManagementBackup not found, ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+
+		l.Error(err, "Failed to get ManagementBackup")
+		return ctrl.Result{}, err
+	}
+
+	mgmtBackup.Status.Error = ""
+	defer func() {
+		if err != nil {
+			mgmtBackup.Status.Error = err.Error()
+		}
+		mgmtBackup.Status.ObservedGeneration = mgmtBackup.Generation
+		if updateErr := r.Status().Update(ctx, mgmtBackup); updateErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to update status of ManagementBackup %s: %w", mgmtBackup.Name, updateErr))
+		}
+	}()
+
+	installed, err := r.veleroWatches.ensure(r.DiscoveryClient)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !installed {
+		l.Info("Velero is not installed", "requeue in", veleroCheckInterval)
+		mgmtBackup.Status.Error = veleroNotInstalledMessage
+		return ctrl.Result{RequeueAfter: veleroCheckInterval}, nil
+	}
+
+	resources, err := velero.ManagementResources(r.DiscoveryClient)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := velero.ReconcileSchedule(ctx, r.Client, r.VeleroNamespace, mgmtBackup.Name, velero.ScheduleOpts{
+		OwnerReference: &metav1.OwnerReference{
+			APIVersion: hmc.GroupVersion.String(),
+			Kind:       hmc.ManagementBackupKind,
+			Name:       mgmtBackup.Name,
+			UID:        mgmtBackup.UID,
+			Controller: ptr.To(true),
+		},
+		Schedule:          mgmtBackup.Spec.Schedule,
+		StorageLocation:   mgmtBackup.Spec.StorageLocation,
+		TTL:               mgmtBackup.Spec.TTL,
+		IncludedResources: resources,
+		Paused:            mgmtBackup.Spec.Paused,
+	}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	backups, err := velero.ListScheduleBackups(ctx, r.Client, r.VeleroNamespace, mgmtBackup.Name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	mgmtBackup.Status.LastBackup, mgmtBackup.Status.LastSuccessfulBackup = latestBackups(backups)
+
+	if last := mgmtBackup.Status.LastBackup; last != nil {
+		if msg := velero.FailureMessage(&backups[0]); msg != "" {
+			l.Info("Last management backup has failed", "backup", last.Name, "reason", msg)
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// latestBackups returns the references to the most recent and to the most
+// recent successful backups of the given ones sorted by the creation time.
+func latestBackups(backups []unstructured.Unstructured) (last, lastSuccessful *hmc.ManagementBackupRef) {
+	for i := range backups {
+		ref := backupRef(&backups[i])
+		if last == nil {
+			last = ref
+		}
+		if ref.Phase == velero.PhaseCompleted {
+			return last, ref
+		}
+	}
+	return last, nil
+}
+
+func backupRef(backup *unstructured.Unstructured) *hmc.ManagementBackupRef {
+	created := backup.GetCreationTimestamp()
+	return &hmc.ManagementBackupRef{
+		Time:  &created,
+		Name:  backup.GetName(),
+		Phase: velero.Phase(backup),
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+// The Velero objects are watched once Velero is installed.
+func (r *ManagementBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	c, err := ctrl.NewControllerManagedBy(mgr).
+		For(&hmc.ManagementBackup{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Build(r)
+	if err != nil {
+		return err
+	}
+
+	r.veleroWatches.start = func() error {
+		if err := c.Watch(source.Kind[client.Object](mgr.GetCache(), velero.New(velero.ScheduleGVK, "", ""),
+			handler.EnqueueRequestForOwner(mgr.GetScheme(), mgr.GetRESTMapper(), &hmc.ManagementBackup{}, handler.OnlyControllerOwner()),
+		)); err != nil {
+			return err
+		}
+		return c.Watch(source.Kind[client.Object](mgr.GetCache(), velero.New(velero.BackupGVK, "", ""),
+			handler.EnqueueRequestsFromMapFunc(func(_ context.Context, o client.Object) []ctrl.Request {
+				schedule, ok := o.GetLabels()[velero.ScheduleNameLabelKey]
+				if !ok || o.GetNamespace() != r.VeleroNamespace {
+					return nil
+				}
+				return []ctrl.Request{{NamespacedName: client.ObjectKey{Name: schedule}}}
+			}),
+		))
+	}
+	return nil
+}
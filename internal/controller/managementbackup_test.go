@@ -0,0 +1,177 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/velero"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func newVeleroDiscovery(installed bool) *fakediscovery.FakeDiscovery {
+	dc := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+	if installed {
+		dc.Resources = []*metav1.APIResourceList{{
+			GroupVersion: velero.ScheduleGVK.GroupVersion().String(),
+			APIResources: []metav1.APIResource{{Name: "schedules", Kind: velero.ScheduleGVK.Kind}},
+		}}
+	}
+	return dc
+}
+
+func newVeleroBackup(name, schedule, phase string, created time.Time) *unstructured.Unstructured {
+	backup := velero.New(velero.BackupGVK, velero.DefaultNamespace, name)
+	backup.SetCreationTimestamp(metav1.NewTime(created))
+	backup.SetLabels(map[string]string{velero.ScheduleNameLabelKey: schedule})
+	_ = unstructured.SetNestedField(backup.Object, phase, "status", "phase")
+	return backup
+}
+
+func TestManagementBackupReconcile(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	mgmtBackup := &hmc.ManagementBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "daily", UID: "uid", Generation: 1},
+		Spec:       hmc.ManagementBackupSpec{Schedule: "@daily"},
+	}
+	now := time.Now().Truncate(time.Second)
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+		WithObjects(mgmtBackup,
+			newVeleroBackup("daily-1", "daily", velero.PhaseCompleted, now.Add(-time.Hour)),
+			newVeleroBackup("daily-2", "daily", velero.PhaseFailed, now),
+		).
+		WithStatusSubresource(mgmtBackup).
+		Build()
+
+	watchesStarted := 0
+	dc := newVeleroDiscovery(false)
+	r := &ManagementBackupReconciler{
+		Client:          cl,
+		DiscoveryClient: dc,
+		VeleroNamespace: velero.DefaultNamespace,
+	}
+	r.veleroWatches.start = func() error {
+		watchesStarted++
+		return nil
+	}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(mgmtBackup)}
+
+	// reporting Velero is not installed
+	result, err := r.Reconcile(ctx, req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.RequeueAfter).To(Equal(veleroCheckInterval))
+	g.Expect(watchesStarted).To(BeZero())
+	g.Expect(cl.Get(ctx, req.NamespacedName, mgmtBackup)).To(Succeed())
+	g.Expect(mgmtBackup.Status.Error).To(Equal(veleroNotInstalledMessage))
+
+	schedule := velero.New(velero.ScheduleGVK, velero.DefaultNamespace, mgmtBackup.Name)
+	g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(schedule), schedule)).NotTo(Succeed())
+
+	// scheduling the backups once Velero is installed
+	dc.Resources = newVeleroDiscovery(true).Resources
+	for range 2 {
+		result, err = r.Reconcile(ctx, req)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(result).To(Equal(ctrl.Result{}))
+	}
+	g.Expect(watchesStarted).To(Equal(1))
+
+	g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(schedule), schedule)).To(Succeed())
+	g.Expect(schedule.GetOwnerReferences()).To(ConsistOf(HaveField("UID", mgmtBackup.UID)))
+
+	g.Expect(cl.Get(ctx, req.NamespacedName, mgmtBackup)).To(Succeed())
+	g.Expect(mgmtBackup.Status.Error).To(BeEmpty())
+	g.Expect(mgmtBackup.Status.ObservedGeneration).To(Equal(mgmtBackup.Generation))
+	g.Expect(mgmtBackup.Status.LastBackup).NotTo(BeNil())
+	g.Expect(mgmtBackup.Status.LastBackup.Name).To(Equal("daily-2"))
+	g.Expect(mgmtBackup.Status.LastSuccessfulBackup).NotTo(BeNil())
+	g.Expect(mgmtBackup.Status.LastSuccessfulBackup.Name).To(Equal("daily-1"))
+}
+
+func TestLatestBackups(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	tests := []struct {
+		name                 string
+		backups              []unstructured.Unstructured
+		lastBackup           string
+		lastSuccessfulBackup string
+	}{
+		{
+			name: "no backups",
+		},
+		{
+			name: "most recent backup is successful",
+			backups: []unstructured.Unstructured{
+				*newVeleroBackup("b2", "daily", velero.PhaseCompleted, now),
+				*newVeleroBackup("b1", "daily", velero.PhaseCompleted, now.Add(-time.Hour)),
+			},
+			lastBackup:           "b2",
+			lastSuccessfulBackup: "b2",
+		},
+		{
+			name: "most recent backups are not successful",
+			backups: []unstructured.Unstructured{
+				*newVeleroBackup("b3", "daily", "InProgress", now),
+				*newVeleroBackup("b2", "daily", velero.PhasePartiallyFailed, now.Add(-time.Hour)),
+				*newVeleroBackup("b1", "daily", velero.PhaseCompleted, now.Add(-2*time.Hour)),
+			},
+			lastBackup:           "b3",
+			lastSuccessfulBackup: "b1",
+		},
+		{
+			name: "no successful backups",
+			backups: []unstructured.Unstructured{
+				*newVeleroBackup("b1", "daily", velero.PhaseFailed, now),
+			},
+			lastBackup: "b1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			last, lastSuccessful := latestBackups(tt.backups)
+			if tt.lastBackup == "" {
+				g.Expect(last).To(BeNil())
+			} else {
+				g.Expect(last).NotTo(BeNil())
+				g.Expect(last.Name).To(Equal(tt.lastBackup))
+			}
+			if tt.lastSuccessfulBackup == "" {
+				g.Expect(lastSuccessful).To(BeNil())
+			} else {
+				g.Expect(lastSuccessful).NotTo(BeNil())
+				g.Expect(lastSuccessful.Name).To(Equal(tt.lastSuccessfulBackup))
+				g.Expect(lastSuccessful.Phase).To(Equal(velero.PhaseCompleted))
+			}
+		})
+	}
+}
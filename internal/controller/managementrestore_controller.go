@@ -0,0 +1,135 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/velero"
+)
+
+// ManagementRestoreReconciler reconciles a ManagementRestore object
+type ManagementRestoreReconciler struct {
+	client.Client
+	DiscoveryClient discovery.DiscoveryInterface
+	VeleroNamespace string
+
+	veleroWatches veleroWatches
+}
+
+func (r *ManagementRestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	l := ctrl.LoggerFrom(ctx)
+	l.Info("Reconciling ManagementRestore")
+
+	mgmtRestore := &hmc.ManagementRestore{}
+	if err := r.Get(ctx, req.NamespacedName, mgmtRestore); err != nil {
+		if apierrors.IsNotFound(err) {
+			l.Info("ManagementRestore not found, ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+
+		l.Error(err, "Failed to get ManagementRestore")
+		return ctrl.Result{}, err
+	}
+
+	if mgmtRestore.Status.CompletionTime != nil {
+		return ctrl.Result{}, nil
+	}
+
+	defer func() {
+		if err != nil {
+			mgmtRestore.Status.Error = err.Error()
+		}
+		if updateErr := r.Status().Update(ctx, mgmtRestore); updateErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to update status of ManagementRestore %s: %w", mgmtRestore.Name, updateErr))
+		}
+	}()
+
+	installed, err := r.veleroWatches.ensure(r.DiscoveryClient)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !installed {
+		l.Info("Velero is not installed", "requeue in", veleroCheckInterval)
+		mgmtRestore.Status.Error = veleroNotInstalledMessage
+		return ctrl.Result{RequeueAfter: veleroCheckInterval}, nil
+	}
+
+	backup := velero.New(velero.BackupGVK, r.VeleroNamespace, mgmtRestore.Spec.BackupName)
+	if err := r.Get(ctx, client.ObjectKeyFromObject(backup), backup); err != nil {
+		if apierrors.IsNotFound(err) {
+			// the backups are synced from the storage location by Velero periodically
+			l.Info("Velero Backup is not found yet", "backup", mgmtRestore.Spec.BackupName, "requeue in", DefaultRequeueInterval)
+			mgmtRestore.Status.Error = fmt.Sprintf("Velero Backup %s/%s is not found", r.VeleroNamespace, mgmtRestore.Spec.BackupName)
+			return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("failed to get Velero Backup %s/%s: %w", r.VeleroNamespace, mgmtRestore.Spec.BackupName, err)
+	}
+	if phase := velero.Phase(backup); phase != velero.PhaseCompleted {
+		return ctrl.Result{}, fmt.Errorf("the Velero Backup %s/%s can not be restored in the %s phase", r.VeleroNamespace, backup.GetName(), phase)
+	}
+
+	restore, err := velero.ReconcileRestore(ctx, r.Client, r.VeleroNamespace, mgmtRestore.Name, mgmtRestore.Spec.BackupName, &metav1.OwnerReference{
+		APIVersion: hmc.GroupVersion.String(),
+		Kind:       hmc.ManagementRestoreKind,
+		Name:       mgmtRestore.Name,
+		UID:        mgmtRestore.UID,
+		Controller: ptr.To(true),
+	})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	mgmtRestore.Status.RestoreName = restore.GetName()
+	mgmtRestore.Status.Phase = velero.Phase(restore)
+	mgmtRestore.Status.Error = velero.FailureMessage(restore)
+	if velero.IsTerminal(mgmtRestore.Status.Phase) {
+		l.Info("Management restore is finished", "phase", mgmtRestore.Status.Phase)
+		now := metav1.Now()
+		mgmtRestore.Status.CompletionTime = &now
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+// The Velero Restores are watched once Velero is installed.
+func (r *ManagementRestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	c, err := ctrl.NewControllerManagedBy(mgr).
+		For(&hmc.ManagementRestore{}).
+		Build(r)
+	if err != nil {
+		return err
+	}
+
+	r.veleroWatches.start = func() error {
+		return c.Watch(source.Kind[client.Object](mgr.GetCache(), velero.New(velero.RestoreGVK, "", ""),
+			handler.EnqueueRequestForOwner(mgr.GetScheme(), mgr.GetRESTMapper(), &hmc.ManagementRestore{}, handler.OnlyControllerOwner()),
+		))
+	}
+	return nil
+}
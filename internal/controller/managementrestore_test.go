@@ -0,0 +1,101 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/velero"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestManagementRestoreReconcile(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	mgmtRestore := &hmc.ManagementRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", UID: "uid"},
+		Spec:       hmc.ManagementRestoreSpec{BackupName: "daily-1"},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+		WithObjects(mgmtRestore).
+		WithStatusSubresource(mgmtRestore).
+		Build()
+
+	dc := newVeleroDiscovery(false)
+	r := &ManagementRestoreReconciler{
+		Client:          cl,
+		DiscoveryClient: dc,
+		VeleroNamespace: velero.DefaultNamespace,
+	}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(mgmtRestore)}
+	restore := velero.New(velero.RestoreGVK, velero.DefaultNamespace, mgmtRestore.Name)
+
+	// reporting Velero is not installed
+	result, err := r.Reconcile(ctx, req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.RequeueAfter).To(Equal(veleroCheckInterval))
+	g.Expect(cl.Get(ctx, req.NamespacedName, mgmtRestore)).To(Succeed())
+	g.Expect(mgmtRestore.Status.Error).To(Equal(veleroNotInstalledMessage))
+
+	// waiting for the backup to be synced from the storage location
+	dc.Resources = newVeleroDiscovery(true).Resources
+	result, err = r.Reconcile(ctx, req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.RequeueAfter).To(Equal(DefaultRequeueInterval))
+	g.Expect(cl.Get(ctx, req.NamespacedName, mgmtRestore)).To(Succeed())
+	g.Expect(mgmtRestore.Status.Error).To(ContainSubstring("is not found"))
+
+	// refusing to restore the backup which is not completed
+	backup := newVeleroBackup("daily-1", "daily", velero.PhaseFailed, time.Now())
+	g.Expect(cl.Create(ctx, backup)).To(Succeed())
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).To(MatchError(ContainSubstring("can not be restored in the Failed phase")))
+	g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(restore), restore)).NotTo(Succeed())
+
+	// restoring the completed backup
+	g.Expect(unstructured.SetNestedField(backup.Object, velero.PhaseCompleted, "status", "phase")).To(Succeed())
+	g.Expect(cl.Update(ctx, backup)).To(Succeed())
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(restore), restore)).To(Succeed())
+	g.Expect(restore.GetOwnerReferences()).To(ConsistOf(HaveField("UID", mgmtRestore.UID)))
+	g.Expect(cl.Get(ctx, req.NamespacedName, mgmtRestore)).To(Succeed())
+	g.Expect(mgmtRestore.Status.RestoreName).To(Equal(restore.GetName()))
+	g.Expect(mgmtRestore.Status.Error).To(BeEmpty())
+	g.Expect(mgmtRestore.Status.CompletionTime).To(BeNil())
+
+	// completing the restore once Velero finishes it
+	g.Expect(unstructured.SetNestedMap(restore.Object, map[string]any{
+		"phase": velero.PhasePartiallyFailed, "errors": int64(2),
+	}, "status")).To(Succeed())
+	g.Expect(cl.Update(ctx, restore)).To(Succeed())
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cl.Get(ctx, req.NamespacedName, mgmtRestore)).To(Succeed())
+	g.Expect(mgmtRestore.Status.Phase).To(Equal(velero.PhasePartiallyFailed))
+	g.Expect(mgmtRestore.Status.Error).To(Equal("Restore PartiallyFailed with 2 errors"))
+	g.Expect(mgmtRestore.Status.CompletionTime).NotTo(BeNil())
+}
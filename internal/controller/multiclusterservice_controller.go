@@ -15,17 +15,25 @@
 package controller
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/audit"
+	"github.com/Mirantis/hmc/internal/errdefs"
+	"github.com/Mirantis/hmc/internal/helm"
+	"github.com/Mirantis/hmc/internal/metrics"
 	"github.com/Mirantis/hmc/internal/sveltos"
 	"github.com/Mirantis/hmc/internal/utils"
 )
@@ -33,15 +41,25 @@ import (
 // MultiClusterServiceReconciler reconciles a MultiClusterService object
 type MultiClusterServiceReconciler struct {
 	client.Client
+
+	summaries clusterSummaryWatch
 }
 
 // Reconcile reconciles a MultiClusterService object.
-func (r *MultiClusterServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *MultiClusterServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	l := ctrl.LoggerFrom(ctx)
 	l.Info("Reconciling MultiClusterService")
+	ctx = audit.WithActor(ctx, "multiclusterservice")
+
+	defer func(start time.Time) {
+		metrics.ObserveReconcileDuration("multiclusterservice", start, err)
+	}(time.Now())
+	defer func() {
+		result, err = errdefs.Result(result, err)
+	}()
 
 	mcsvc := &hmc.MultiClusterService{}
-	err := r.Get(ctx, req.NamespacedName, mcsvc)
+	err = r.Get(ctx, req.NamespacedName, mcsvc)
 	if apierrors.IsNotFound(err) {
 		l.Info("MultiClusterService not found, ignoring since object must be deleted")
 		return ctrl.Result{}, nil
@@ -63,35 +81,160 @@ func (r *MultiClusterServiceReconciler) Reconcile(ctx context.Context, req ctrl.
 		return ctrl.Result{}, nil
 	}
 
+	profiles, err := reconcileServiceProfiles(ctx, r.Client, mcsvc, hmc.MultiClusterServiceKind, "",
+		map[string]string{hmc.MultiClusterServiceLabelKey: mcsvc.Name}, &mcsvc.Spec, &mcsvc.Status)
+	if err != nil {
+		if isApplyConflict(err) {
+			// the retries do not resolve the conflict, so it is reported in the status
+			err = errors.Join(err, r.Status().Update(ctx, mcsvc))
+		}
+		return ctrl.Result{}, err
+	}
+
+	if err := r.summaries.start(ctx, r.Client); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return r.updateStatus(ctx, mcsvc, profiles)
+}
+
+// reconcileServiceProfiles reconciles the Sveltos profiles deploying the services of the spec
+// of the MultiClusterService or the NamespacedMultiClusterService, deletes the stale profiles
+// with the labels and returns the names of the profiles. The ClusterProfiles deploying the
+// ServiceTemplates of the system namespace are used if the namespace is empty, the Profiles
+// deploying the ServiceTemplates of the namespace on the clusters of the namespace otherwise.
+// The state of the rollout of the services is reported in the status.
+func reconcileServiceProfiles(ctx context.Context, c client.Client, owner client.Object, kind, namespace string, labels map[string]string, spec *hmc.MultiClusterServiceSpec, status *hmc.MultiClusterServiceStatus) ([]string, error) {
+	imageOverrides, err := getImageOverrides(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	// By using DefaultSystemNamespace we are enforcing that MultiClusterService
 	// may only use ServiceTemplates that are present in the hmc-system namespace.
-	opts, err := helmChartOpts(ctx, r.Client, utils.DefaultSystemNamespace, mcsvc.Spec.Services)
+	templatesNamespace := cmp.Or(namespace, utils.DefaultSystemNamespace)
+	opts, err := helmChartOpts(ctx, c, templatesNamespace, spec.Services, imageOverrides, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ownerReference := &metav1.OwnerReference{
+		APIVersion: hmc.GroupVersion.String(),
+		Kind:       kind,
+		Name:       owner.GetName(),
+		UID:        owner.GetUID(),
+	}
+	profileOpts := sveltos.ReconcileProfileOpts{
+		OwnerReference: ownerReference,
+		Labels:         labels,
+		LabelSelector:  spec.ClusterSelector,
+		HelmChartOpts:  opts,
+		Priority:       spec.ServicesPriority,
+		StopOnConflict: spec.StopOnConflict,
+	}
+
+	var (
+		profiles  []string
+		operation controllerutil.OperationResult
+	)
+	if spec.Rollout != nil {
+		profileOpts.HealthChecks = spec.Rollout.HealthChecks
+		profiles, operation, err = reconcileRollout(ctx, c, namespace, serviceProfileName(kind, owner.GetName()), profileOpts, spec.Rollout, status)
+	} else {
+		status.Rollout = nil
+		profiles, operation, err = reconcileProfiles(ctx, c, namespace, serviceProfileName(kind, owner.GetName()), profileOpts)
+	}
+	setApplyConflictCondition(&status.Conditions, err)
+	if err != nil {
+		metrics.IncServiceDeploymentFailures(kind, owner.GetNamespace(), owner.GetName())
+		trackServiceDeploys(ctx, c, kind, string(owner.GetUID()), spec.Services, opts, false)
+		return nil, fmt.Errorf("failed to reconcile the profile of %s %s: %w", kind, client.ObjectKeyFromObject(owner), err)
+	}
+	if operation == controllerutil.OperationResultCreated || operation == controllerutil.OperationResultUpdated {
+		trackServiceDeploys(ctx, c, kind, string(owner.GetUID()), spec.Services, opts, true)
+	}
+
+	matcherProfiles, err := reconcileMatchers(ctx, c, ownerReference, namespace, templatesNamespace, labels, spec, imageOverrides)
+	if err != nil {
+		metrics.IncServiceDeploymentFailures(kind, owner.GetNamespace(), owner.GetName())
+		return nil, err
+	}
+
+	profiles = append(profiles, matcherProfiles...)
+	if namespace == "" {
+		err = sveltos.DeleteClusterProfiles(ctx, c, labels, profiles...)
+	} else {
+		err = sveltos.DeleteProfiles(ctx, c, namespace, labels, profiles...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// deleteServiceProfiles deletes the Sveltos profiles of the MultiClusterService or
+// the NamespacedMultiClusterService, the ClusterProfiles if the namespace is empty.
+func deleteServiceProfiles(ctx context.Context, c client.Client, namespace, name string, labels map[string]string) error {
+	if namespace == "" {
+		if err := sveltos.DeleteClusterProfile(ctx, c, name); err != nil {
+			return err
+		}
+		return sveltos.DeleteClusterProfiles(ctx, c, labels)
+	}
+
+	if err := sveltos.DeleteProfile(ctx, c, namespace, serviceProfileName(hmc.NamespacedMultiClusterServiceKind, name)); err != nil {
+		return err
+	}
+	return sveltos.DeleteProfiles(ctx, c, namespace, labels)
+}
+
+// serviceProfileName returns the name of the Sveltos profile deploying the services of the object.
+// The Profiles of the NamespacedMultiClusterServices are prefixed not to collide with the Profiles
+// of the ManagedClusters in the same namespace, the stale Profiles of the previous prefix are
+// deleted along with the other stale Profiles labeled with the NamespacedMultiClusterService.
+func serviceProfileName(kind, name string) string {
+	if kind == hmc.NamespacedMultiClusterServiceKind {
+		return hmc.NamespacedMultiClusterServiceProfilePrefix + name
+	}
+	return name
+}
+
+// updateStatus reports the services of the MultiClusterService conflicting with the other profiles.
+// The conflicts appear once Sveltos deploys the services, so the status is refreshed on the changes
+// of the ClusterSummaries of the profiles.
+func (r *MultiClusterServiceReconciler) updateStatus(ctx context.Context, mcsvc *hmc.MultiClusterService, profiles []string) (ctrl.Result, error) {
+	conflicts, err := profileConflicts(ctx, r.Client, "", "", profiles)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	if _, err := sveltos.ReconcileClusterProfile(ctx, r.Client, mcsvc.Name,
-		sveltos.ReconcileProfileOpts{
-			OwnerReference: &metav1.OwnerReference{
-				APIVersion: hmc.GroupVersion.String(),
-				Kind:       hmc.MultiClusterServiceKind,
-				Name:       mcsvc.Name,
-				UID:        mcsvc.UID,
-			},
-			LabelSelector:  mcsvc.Spec.ClusterSelector,
-			HelmChartOpts:  opts,
-			Priority:       mcsvc.Spec.ServicesPriority,
-			StopOnConflict: mcsvc.Spec.StopOnConflict,
-		}); err != nil {
-		return ctrl.Result{}, fmt.Errorf("failed to reconcile ClusterProfile: %w", err)
+	mcsvc.Status.ServiceConflicts = conflicts
+	setServiceConflictCondition(mcsvc.GetConditions(), conflicts)
+	if err := r.Status().Update(ctx, mcsvc); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update status of MultiClusterService %s: %w", mcsvc.Name, err)
 	}
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: soakRequeueAfter(mcsvc.Spec.Rollout, mcsvc.Status.Rollout)}, nil
+}
+
+// getImageOverrides returns the registry rewrite rules defined in the Management object.
+func getImageOverrides(ctx context.Context, c client.Client) ([]hmc.ImageOverride, error) {
+	mgmt := &hmc.Management{}
+	if err := c.Get(ctx, client.ObjectKey{Name: hmc.ManagementName}, mgmt); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get Management: %w", err)
+	}
+
+	return mgmt.Spec.ImageOverrides, nil
 }
 
 // helmChartOpts returns slice of helm chart options to use with Sveltos.
 // Namespace is the namespace of the referred templates in services slice.
-func helmChartOpts(ctx context.Context, c client.Client, namespace string, services []hmc.ServiceSpec) ([]sveltos.HelmChartOpts, error) {
+// Image overrides, if any, are applied to the values of each service, the default values of
+// the charts are downloaded only once per artifact.
+func helmChartOpts(ctx context.Context, c client.Client, namespace string, services []hmc.ServiceSpec, imageOverrides []hmc.ImageOverride, trust *resolvedTrust) ([]sveltos.HelmChartOpts, error) {
 	l := ctrl.LoggerFrom(ctx)
 	opts := []sveltos.HelmChartOpts{}
 
@@ -144,8 +287,34 @@ func helmChartOpts(ctx context.Context, c client.Client, namespace string, servi
 			chartName = tmpl.Spec.Helm.ChartRef.Name
 		}
 
+		values := svc.Values
+		if tmpl.Status.DefaultValues != nil {
+			var err error
+			values, err = mergeServiceValues(tmpl.Status.DefaultValues, svc.Values)
+			if err != nil {
+				return nil, fmt.Errorf("failed to merge the default values of ServiceTemplate %s for service %s: %w", tmplRef.String(), svc.Name, err)
+			}
+		}
+
+		values, err := trust.serviceValues(values, tmpl.Status.DefaultValues)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set the proxy and the trusted CA of service %s: %w", svc.Name, err)
+		}
+
+		if len(imageOverrides) > 0 {
+			chartValues, err := serviceChartValues.get(ctx, c, chart)
+			if err != nil {
+				return nil, fmt.Errorf("failed to download HelmChart %s referenced by ServiceTemplate %s: %w", chartRef.String(), tmplRef.String(), err)
+			}
+
+			values, err = helm.OverrideImages(values, chartValues, imageOverrides)
+			if err != nil {
+				return nil, fmt.Errorf("failed to override images for service %s: %w", svc.Name, err)
+			}
+		}
+
 		opts = append(opts, sveltos.HelmChartOpts{
-			Values:        svc.Values,
+			Values:        values,
 			RepositoryURL: repo.Spec.URL,
 			// We don't have repository name so chart name becomes repository name.
 			RepositoryName: chartName,
@@ -158,27 +327,29 @@ func helmChartOpts(ctx context.Context, c client.Client, namespace string, servi
 				// See: https://projectsveltos.github.io/sveltos/addons/helm_charts/.
 				return fmt.Sprintf("%s/%s", chartName, chartName)
 			}(),
-			ChartVersion: tmpl.Spec.Helm.ChartVersion,
-			ReleaseName:  svc.Name,
-			ReleaseNamespace: func() string {
-				if svc.Namespace != "" {
-					return svc.Namespace
-				}
-				return svc.Name
-			}(),
+			ChartVersion:     tmpl.Spec.Helm.ChartVersion,
+			ReleaseName:      svc.Name,
+			ReleaseNamespace: serviceReleaseNamespace(svc),
 			// The reason it is passed to PlainHTTP instead of InsecureSkipTLSVerify is because
 			// the source.Spec.Insecure field is meant to be used for connecting to repositories
 			// over plain HTTP, which is different than what InsecureSkipTLSVerify is meant for.
 			// See: https://github.com/fluxcd/source-controller/pull/1288
-			PlainHTTP: repo.Spec.Insecure,
+			PlainHTTP:      repo.Spec.Insecure,
+			ConflictPolicy: svc.ConflictPolicy,
+			DeletionPolicy: svc.DeletionPolicy,
 		})
 	}
 
 	return opts, nil
 }
 
+// serviceReleaseNamespace returns the namespace of the release of the service, the name of the service by default.
+func serviceReleaseNamespace(svc hmc.ServiceSpec) string {
+	return cmp.Or(svc.Namespace, svc.Name)
+}
+
 func (r *MultiClusterServiceReconciler) reconcileDelete(ctx context.Context, mcsvc *hmc.MultiClusterService) (ctrl.Result, error) {
-	if err := sveltos.DeleteClusterProfile(ctx, r.Client, mcsvc.Name); err != nil {
+	if err := deleteServiceProfiles(ctx, r.Client, "", mcsvc.Name, map[string]string{hmc.MultiClusterServiceLabelKey: mcsvc.Name}); err != nil {
 		return ctrl.Result{}, err
 	}
 
@@ -186,14 +357,17 @@ func (r *MultiClusterServiceReconciler) reconcileDelete(ctx context.Context, mcs
 		if err := r.Client.Update(ctx, mcsvc); err != nil {
 			return ctrl.Result{}, fmt.Errorf("failed to remove finalizer %s from MultiClusterService %s: %w", hmc.MultiClusterServiceFinalizer, mcsvc.Name, err)
 		}
+		audit.Record(ctx, audit.ActionRemoveFinalizer, mcsvc, audit.FinalizerRemoved(hmc.MultiClusterServiceFinalizer))
 	}
 
 	return ctrl.Result{}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
-func (r *MultiClusterServiceReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&hmc.MultiClusterService{}).
-		Complete(r)
+func (r *MultiClusterServiceReconciler) SetupWithManager(mgr ctrl.Manager) (err error) {
+	r.summaries = clusterSummaryWatch{cache: mgr.GetCache(), kind: hmc.MultiClusterServiceKind}
+	r.summaries.controller, err = ctrl.NewControllerManagedBy(mgr).
+		For(&hmc.MultiClusterService{}, builder.WithPredicates(specOrMetadataChanged())).
+		Build(r)
+	return err
 }
@@ -0,0 +1,147 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/errdefs"
+	"github.com/Mirantis/hmc/internal/helm"
+	"github.com/Mirantis/hmc/internal/sveltos"
+)
+
+// matcherProfileName returns the name of the profile deploying the services of the matcher.
+func matcherProfileName(ownerReference *metav1.OwnerReference, matcher *hmc.ServiceMatcher) string {
+	return sveltos.DerivedProfileName(serviceProfileName(ownerReference.Kind, ownerReference.Name), ownerReference.UID, "matcher/"+matcher.Name)
+}
+
+// reconcileMatchers reconciles the profiles of the matchers of the MultiClusterService
+// and returns their names. The profiles of the matchers are given a higher priority than
+// the profile of the MultiClusterService, so that the services are deployed on the matching
+// clusters with the overridden values, the earlier matchers get the higher priority.
+func reconcileMatchers(ctx context.Context, c client.Client, ownerReference *metav1.OwnerReference, namespace, templatesNamespace string,
+	labels map[string]string, spec *hmc.MultiClusterServiceSpec, imageOverrides []hmc.ImageOverride,
+) ([]string, error) {
+	profiles := make([]string, 0, len(spec.Matchers))
+	for i := range spec.Matchers {
+		matcher := &spec.Matchers[i]
+
+		services, err := matcherServices(spec.Services, matcher)
+		if err != nil {
+			return nil, errdefs.Terminal(fmt.Errorf("failed to override the values of the matcher %s: %w", matcher.Name, err))
+		}
+
+		opts, err := helmChartOpts(ctx, c, templatesNamespace, services, imageOverrides, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		name := matcherProfileName(ownerReference, matcher)
+		names, _, err := reconcileProfiles(ctx, c, namespace, name,
+			sveltos.ReconcileProfileOpts{
+				OwnerReference: ownerReference,
+				Labels:         labels,
+				LabelSelector:  intersectSelectors(spec.ClusterSelector, matcher.ClusterSelector),
+				HelmChartOpts:  opts,
+				Priority:       spec.ServicesPriority + int32(len(spec.Matchers)-i),
+				StopOnConflict: spec.StopOnConflict,
+			})
+		if err != nil {
+			return nil, fmt.Errorf("failed to reconcile the profile %s: %w", name, err)
+		}
+		profiles = append(profiles, names...)
+	}
+
+	return profiles, nil
+}
+
+// matcherServices returns the services with the values overridden by the matcher.
+func matcherServices(services []hmc.ServiceSpec, matcher *hmc.ServiceMatcher) ([]hmc.ServiceSpec, error) {
+	result := make([]hmc.ServiceSpec, len(services))
+	for i, svc := range services {
+		svc.DeepCopyInto(&result[i])
+
+		idx := slices.IndexFunc(matcher.Services, func(o hmc.ServiceValuesOverride) bool { return o.Name == svc.Name })
+		if idx < 0 || matcher.Services[idx].Values == nil {
+			continue
+		}
+
+		values, err := mergeServiceValues(svc.Values, matcher.Services[idx].Values)
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", svc.Name, err)
+		}
+		result[i].Values = values
+	}
+	return result, nil
+}
+
+// mergeServiceValues merges the overrides onto the values of a service.
+func mergeServiceValues(values, overrides *apiextensionsv1.JSON) (*apiextensionsv1.JSON, error) {
+	if overrides == nil || len(overrides.Raw) == 0 {
+		return values, nil
+	}
+
+	var base, layer map[string]any
+	if values != nil {
+		if err := json.Unmarshal(values.Raw, &base); err != nil {
+			return nil, fmt.Errorf("failed to parse the values: %w", err)
+		}
+	}
+	if err := json.Unmarshal(overrides.Raw, &layer); err != nil {
+		return nil, fmt.Errorf("failed to parse the overridden values: %w", err)
+	}
+
+	merged, err := helm.MergeValues(hmc.ConfigMergeStrategyMerge, nil, base, layer)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the values: %w", err)
+	}
+	return &apiextensionsv1.JSON{Raw: raw}, nil
+}
+
+// intersectSelectors returns the selector matching the objects matched by both of the selectors.
+// The labels of the second selector are turned into the expressions to keep the conflicting
+// values of the same label in both selectors.
+func intersectSelectors(a, b metav1.LabelSelector) metav1.LabelSelector {
+	result := *a.DeepCopy()
+	keys := make([]string, 0, len(b.MatchLabels))
+	for key := range b.MatchLabels {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		result.MatchExpressions = append(result.MatchExpressions, metav1.LabelSelectorRequirement{
+			Key:      key,
+			Operator: metav1.LabelSelectorOpIn,
+			Values:   []string{b.MatchLabels[key]},
+		})
+	}
+	for _, req := range b.MatchExpressions {
+		result.MatchExpressions = append(result.MatchExpressions, *req.DeepCopy())
+	}
+	return result
+}
@@ -0,0 +1,135 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/audit"
+	"github.com/Mirantis/hmc/internal/errdefs"
+	"github.com/Mirantis/hmc/internal/metrics"
+)
+
+// NamespacedMultiClusterServiceReconciler reconciles a NamespacedMultiClusterService object
+type NamespacedMultiClusterServiceReconciler struct {
+	client.Client
+	Shard string
+
+	summaries clusterSummaryWatch
+}
+
+// Reconcile reconciles a NamespacedMultiClusterService object. The services are deployed
+// with a Sveltos Profile, which only matches the clusters in the namespace of the Profile.
+func (r *NamespacedMultiClusterServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	l := ctrl.LoggerFrom(ctx)
+	l.Info("Reconciling NamespacedMultiClusterService")
+	ctx = audit.WithActor(ctx, "namespacedmulticlusterservice")
+
+	defer func(start time.Time) {
+		metrics.ObserveReconcileDuration("namespacedmulticlusterservice", start, err)
+	}(time.Now())
+	defer func() {
+		result, err = errdefs.Result(result, err)
+	}()
+
+	nmcs := &hmc.NamespacedMultiClusterService{}
+	err = r.Get(ctx, req.NamespacedName, nmcs)
+	if apierrors.IsNotFound(err) {
+		l.Info("NamespacedMultiClusterService not found, ignoring since object must be deleted")
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		l.Error(err, "Failed to get NamespacedMultiClusterService")
+		return ctrl.Result{}, err
+	}
+
+	labels := map[string]string{hmc.NamespacedMultiClusterServiceLabelKey: nmcs.Name}
+
+	if !nmcs.DeletionTimestamp.IsZero() {
+		l.Info("Deleting NamespacedMultiClusterService")
+		if err := deleteServiceProfiles(ctx, r.Client, nmcs.Namespace, nmcs.Name, labels); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if controllerutil.RemoveFinalizer(nmcs, hmc.NamespacedMultiClusterServiceFinalizer) {
+			if err := r.Client.Update(ctx, nmcs); err != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to remove finalizer %s from NamespacedMultiClusterService %s: %w", hmc.NamespacedMultiClusterServiceFinalizer, req.NamespacedName, err)
+			}
+			audit.Record(ctx, audit.ActionRemoveFinalizer, nmcs, audit.FinalizerRemoved(hmc.NamespacedMultiClusterServiceFinalizer))
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if controllerutil.AddFinalizer(nmcs, hmc.NamespacedMultiClusterServiceFinalizer) {
+		if err := r.Client.Update(ctx, nmcs); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to update NamespacedMultiClusterService %s with finalizer %s: %w", req.NamespacedName, hmc.NamespacedMultiClusterServiceFinalizer, err)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	profiles, err := reconcileServiceProfiles(ctx, r.Client, nmcs, hmc.NamespacedMultiClusterServiceKind, nmcs.Namespace, labels, &nmcs.Spec, &nmcs.Status)
+	if err != nil {
+		if isApplyConflict(err) {
+			// the retries do not resolve the conflict, so it is reported in the status
+			err = errors.Join(err, r.Status().Update(ctx, nmcs))
+		}
+		return ctrl.Result{}, err
+	}
+
+	if err := r.summaries.start(ctx, r.Client); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// The conflicts appear once Sveltos deploys the services, so the status is refreshed
+	// on the changes of the ClusterSummaries of the profiles.
+	conflicts, err := profileConflicts(ctx, r.Client, nmcs.Namespace, "", profiles)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	nmcs.Status.ServiceConflicts = conflicts
+	setServiceConflictCondition(nmcs.GetConditions(), conflicts)
+	if err := r.Status().Update(ctx, nmcs); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update status of NamespacedMultiClusterService %s: %w", req.NamespacedName, err)
+	}
+
+	return ctrl.Result{RequeueAfter: soakRequeueAfter(nmcs.Spec.Rollout, nmcs.Status.Rollout)}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *NamespacedMultiClusterServiceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	shards, err := newShardFilter(mgr, r.Shard)
+	if err != nil {
+		return err
+	}
+
+	r.summaries = clusterSummaryWatch{cache: mgr.GetCache(), kind: hmc.NamespacedMultiClusterServiceKind}
+	r.summaries.controller, err = ctrl.NewControllerManagedBy(mgr).
+		For(&hmc.NamespacedMultiClusterService{}, builder.WithPredicates(specOrMetadataChanged())).
+		Watches(shards.watchNamespaces(mgr.GetClient(), &hmc.NamespacedMultiClusterServiceList{})).
+		WithEventFilter(shards.predicate()).
+		Build(r)
+	return err
+}
@@ -0,0 +1,99 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	sveltosv1beta1 "github.com/projectsveltos/addon-controller/api/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/fakeclient"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestNamespacedMultiClusterServiceReconcile(t *testing.T) {
+	g := NewWithT(t)
+
+	mapper := apimeta.NewDefaultRESTMapper([]schema.GroupVersion{sveltosv1beta1.GroupVersion})
+	mapper.Add(sveltosv1beta1.GroupVersion.WithKind(sveltosv1beta1.ProfileKind), apimeta.RESTScopeNamespace)
+	mapper.Add(sveltosv1beta1.GroupVersion.WithKind(sveltosv1beta1.ClusterProfileKind), apimeta.RESTScopeRoot)
+	mapper.Add(sveltosv1beta1.GroupVersion.WithKind(sveltosv1beta1.ClusterSummaryKind), apimeta.RESTScopeNamespace)
+
+	nmcs := &hmc.NamespacedMultiClusterService{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "ingress"},
+		Spec: hmc.MultiClusterServiceSpec{
+			ClusterSelector:  metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+			ServicesPriority: 100,
+		},
+	}
+	// the Profile of the ManagedCluster named like the Profile of the previous prefix
+	clusterProfile := &sveltosv1beta1.Profile{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "team-a", Name: "mcs-ingress", Labels: map[string]string{hmc.ManagedClusterLabelKey: "mcs-ingress"},
+	}}
+	// the stale Profile of the NamespacedMultiClusterService
+	staleProfile := &sveltosv1beta1.Profile{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "team-a", Name: "stale", Labels: map[string]string{hmc.NamespacedMultiClusterServiceLabelKey: "ingress"},
+	}}
+
+	cl := fakeclient.WithApply(fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper)).
+		WithObjects(nmcs, clusterProfile, staleProfile).
+		WithStatusSubresource(&hmc.NamespacedMultiClusterService{}).
+		Build()
+	r := &NamespacedMultiClusterServiceReconciler{Client: cl}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(nmcs)}
+
+	// the finalizer is added first
+	_, err := r.Reconcile(ctx, req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cl.Get(ctx, req.NamespacedName, nmcs)).To(Succeed())
+	g.Expect(nmcs.Finalizers).To(ContainElement(hmc.NamespacedMultiClusterServiceFinalizer))
+
+	// the services are deployed with the Profile named apart from the Profiles of the ManagedClusters,
+	// the status is refreshed on the changes of the ClusterSummaries rather than periodically
+	result, err := r.Reconcile(ctx, req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(ctrl.Result{}))
+
+	profile := &sveltosv1beta1.Profile{}
+	g.Expect(cl.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "nmcs.ingress"}, profile)).To(Succeed())
+	g.Expect(profile.Labels).To(HaveKeyWithValue(hmc.NamespacedMultiClusterServiceLabelKey, "ingress"))
+	g.Expect(profile.OwnerReferences).To(ConsistOf(HaveField("Kind", hmc.NamespacedMultiClusterServiceKind)))
+
+	g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(clusterProfile), clusterProfile)).To(Succeed())
+	g.Expect(clusterProfile.Labels).NotTo(HaveKey(hmc.NamespacedMultiClusterServiceLabelKey))
+	g.Expect(clusterProfile.OwnerReferences).To(BeEmpty())
+	g.Expect(apierrors.IsNotFound(cl.Get(ctx, client.ObjectKeyFromObject(staleProfile), staleProfile))).To(BeTrue())
+
+	g.Expect(cl.Get(ctx, req.NamespacedName, nmcs)).To(Succeed())
+
+	// the Profiles are deleted along with the NamespacedMultiClusterService
+	g.Expect(cl.Delete(ctx, nmcs)).To(Succeed())
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(apierrors.IsNotFound(cl.Get(ctx, req.NamespacedName, nmcs))).To(BeTrue())
+	g.Expect(apierrors.IsNotFound(cl.Get(ctx, client.ObjectKeyFromObject(profile), profile))).To(BeTrue())
+	g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(clusterProfile), clusterProfile)).To(Succeed())
+}
@@ -0,0 +1,184 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	hcv2 "github.com/fluxcd/helm-controller/api/v2"
+	fluxmeta "github.com/fluxcd/pkg/apis/meta"
+	fluxconditions "github.com/fluxcd/pkg/runtime/conditions"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// specOrMetadataChanged passes the updates of the spec, the labels or the
+// annotations of the object, the status-only updates are skipped.
+func specOrMetadataChanged() predicate.Predicate {
+	return predicate.Or(
+		predicate.GenerationChangedPredicate{},
+		predicate.LabelChangedPredicate{},
+		predicate.AnnotationChangedPredicate{},
+	)
+}
+
+// readinessGatesChanged passes the updates of the conditions of the ManagedCluster
+// referred to by its readiness gates, the conditions are set by the external controllers.
+func readinessGatesChanged() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(event.CreateEvent) bool { return false },
+		DeleteFunc: func(event.DeleteEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldMC, ok := e.ObjectOld.(*hmc.ManagedCluster)
+			if !ok {
+				return false
+			}
+			newMC, ok := e.ObjectNew.(*hmc.ManagedCluster)
+			if !ok {
+				return false
+			}
+			for _, gate := range newMC.Spec.ReadinessGates {
+				oldCond := apimeta.FindStatusCondition(oldMC.Status.Conditions, gate.ConditionType)
+				newCond := apimeta.FindStatusCondition(newMC.Status.Conditions, gate.ConditionType)
+				if oldCond == nil || newCond == nil {
+					if oldCond != newCond {
+						return true
+					}
+					continue
+				}
+				if oldCond.Status != newCond.Status || oldCond.Message != newCond.Message {
+					return true
+				}
+			}
+			return false
+		},
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+}
+
+// templateValidityChanged passes the updates of the validity of the ClusterTemplate
+// affecting the ManagedClusters deployed from it, the clusters waiting for a missing
+// template are reconciled once it is created.
+func templateValidityChanged() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldTemplate, ok := e.ObjectOld.(*hmc.ClusterTemplate)
+			if !ok {
+				return false
+			}
+			newTemplate, ok := e.ObjectNew.(*hmc.ClusterTemplate)
+			if !ok {
+				return false
+			}
+			return oldTemplate.Status.Valid != newTemplate.Status.Valid ||
+				oldTemplate.Status.ValidationError != newTemplate.Status.ValidationError
+		},
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+}
+
+// credentialChanged passes the updates of the Credential affecting the ManagedClusters
+// using it: the changes of its state and of its cluster identity.
+func credentialChanged() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldCred, ok := e.ObjectOld.(*hmc.Credential)
+			if !ok {
+				return false
+			}
+			newCred, ok := e.ObjectNew.(*hmc.Credential)
+			if !ok {
+				return false
+			}
+			return oldCred.Status.State != newCred.Status.State ||
+				!equality.Semantic.DeepEqual(oldCred.ClusterIdentityRef(), newCred.ClusterIdentityRef())
+		},
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+}
+
+// helmReleaseStatusChanged passes the updates of the HelmRelease affecting
+// its owners: the changes of the spec, the labels, the Ready condition or the
+// installed chart version. The other status updates made by Flux on every
+// reconciliation of the release are skipped.
+func helmReleaseStatusChanged() predicate.Predicate {
+	return predicate.Or(
+		specOrMetadataChanged(),
+		predicate.Funcs{
+			CreateFunc: func(event.CreateEvent) bool { return false },
+			DeleteFunc: func(event.DeleteEvent) bool { return false },
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldHR, ok := e.ObjectOld.(*hcv2.HelmRelease)
+				if !ok {
+					return false
+				}
+				newHR, ok := e.ObjectNew.(*hcv2.HelmRelease)
+				if !ok {
+					return false
+				}
+				return readyConditionChanged(oldHR, newHR) ||
+					latestChartVersion(oldHR) != latestChartVersion(newHR)
+			},
+			GenericFunc: func(event.GenericEvent) bool { return false },
+		},
+	)
+}
+
+// readyConditionChanged returns true if the Ready condition of the HelmRelease changed,
+// the transition time is ignored.
+func readyConditionChanged(oldHR, newHR *hcv2.HelmRelease) bool {
+	oldCond := fluxconditions.Get(oldHR, fluxmeta.ReadyCondition)
+	newCond := fluxconditions.Get(newHR, fluxmeta.ReadyCondition)
+	if oldCond == nil || newCond == nil {
+		return oldCond != newCond
+	}
+	return oldCond.Status != newCond.Status ||
+		oldCond.Reason != newCond.Reason ||
+		oldCond.Message != newCond.Message ||
+		oldCond.ObservedGeneration != newCond.ObservedGeneration
+}
+
+// StripHelmRelease is the cache transform of the HelmReleases keeping only
+// the metadata and the status fields used by helmReleaseStatusChanged.
+// The HelmReleases are read from the API server, the cached objects are only
+// used to filter the watch events.
+func StripHelmRelease(obj any) (any, error) {
+	hr, ok := obj.(*hcv2.HelmRelease)
+	if !ok {
+		return obj, nil
+	}
+
+	stripped := &hcv2.HelmRelease{
+		TypeMeta:   hr.TypeMeta,
+		ObjectMeta: hr.ObjectMeta,
+		Status: hcv2.HelmReleaseStatus{
+			Conditions: hr.Status.Conditions,
+		},
+	}
+	stripped.SetManagedFields(nil)
+	if latest := hr.Status.History.Latest(); latest != nil {
+		stripped.Status.History = hcv2.Snapshots{latest}
+	}
+	return stripped, nil
+}
+
+func latestChartVersion(hr *hcv2.HelmRelease) string {
+	if latest := hr.Status.History.Latest(); latest != nil {
+		return latest.ChartVersion
+	}
+	return ""
+}
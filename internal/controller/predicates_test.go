@@ -0,0 +1,195 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+
+	hcv2 "github.com/fluxcd/helm-controller/api/v2"
+	fluxmeta "github.com/fluxcd/pkg/apis/meta"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+func TestSpecOrMetadataChanged(t *testing.T) {
+	g := NewWithT(t)
+
+	old := &hmc.ClusterTemplate{ObjectMeta: metav1.ObjectMeta{Name: "template", Generation: 1}}
+	update := func(mutate func(*hmc.ClusterTemplate)) bool {
+		updated := old.DeepCopy()
+		mutate(updated)
+		return specOrMetadataChanged().Update(event.UpdateEvent{ObjectOld: old, ObjectNew: updated})
+	}
+
+	g.Expect(specOrMetadataChanged().Create(event.CreateEvent{Object: old})).To(BeTrue())
+	g.Expect(specOrMetadataChanged().Delete(event.DeleteEvent{Object: old})).To(BeTrue())
+
+	g.Expect(update(func(t *hmc.ClusterTemplate) { t.Generation++ })).To(BeTrue())
+	g.Expect(update(func(t *hmc.ClusterTemplate) { t.Labels = map[string]string{"a": "b"} })).To(BeTrue())
+	g.Expect(update(func(t *hmc.ClusterTemplate) { t.Annotations = map[string]string{"a": "b"} })).To(BeTrue())
+
+	// the status-only updates are skipped
+	g.Expect(update(func(t *hmc.ClusterTemplate) {
+		t.ResourceVersion = "2"
+		t.Status.Valid = true
+	})).To(BeFalse())
+}
+
+func TestHelmReleaseStatusChanged(t *testing.T) {
+	g := NewWithT(t)
+
+	old := &hcv2.HelmRelease{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "dev", Generation: 1},
+		Status: hcv2.HelmReleaseStatus{
+			Conditions: []metav1.Condition{{
+				Type: fluxmeta.ReadyCondition, Status: metav1.ConditionFalse, Reason: "Progressing", Message: "installing", ObservedGeneration: 1,
+			}},
+			History: hcv2.Snapshots{{ChartVersion: "0.0.1"}},
+		},
+	}
+	update := func(mutate func(*hcv2.HelmRelease)) bool {
+		updated := old.DeepCopy()
+		mutate(updated)
+		return helmReleaseStatusChanged().Update(event.UpdateEvent{ObjectOld: old, ObjectNew: updated})
+	}
+
+	g.Expect(update(func(hr *hcv2.HelmRelease) { hr.Generation++ })).To(BeTrue())
+	g.Expect(update(func(hr *hcv2.HelmRelease) { hr.Labels = map[string]string{"a": "b"} })).To(BeTrue())
+	g.Expect(update(func(hr *hcv2.HelmRelease) { hr.Status.Conditions[0].Status = metav1.ConditionTrue })).To(BeTrue())
+	g.Expect(update(func(hr *hcv2.HelmRelease) { hr.Status.Conditions[0].Message = "upgrading" })).To(BeTrue())
+	g.Expect(update(func(hr *hcv2.HelmRelease) { hr.Status.Conditions = nil })).To(BeTrue())
+	g.Expect(update(func(hr *hcv2.HelmRelease) {
+		hr.Status.History = hcv2.Snapshots{{ChartVersion: "0.0.2"}, {ChartVersion: "0.0.1"}}
+	})).To(BeTrue())
+
+	// the status updates made by Flux on every reconciliation are skipped
+	g.Expect(update(func(hr *hcv2.HelmRelease) {
+		hr.ResourceVersion = "2"
+		hr.Status.LastAttemptedRevision = "0.0.1"
+		hr.Status.Conditions[0].LastTransitionTime = metav1.Now()
+		hr.Status.Conditions = append(hr.Status.Conditions, metav1.Condition{Type: "Reconciling", Status: metav1.ConditionTrue})
+	})).To(BeFalse())
+}
+
+func TestStripHelmRelease(t *testing.T) {
+	g := NewWithT(t)
+
+	hr := &hcv2.HelmRelease{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default", Name: "dev", Generation: 2,
+			Labels:        map[string]string{hmc.HMCManagedLabelKey: hmc.HMCManagedLabelValue},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "hmc", Operation: metav1.ManagedFieldsOperationApply}},
+		},
+		Spec: hcv2.HelmReleaseSpec{Values: &apiextensionsv1.JSON{Raw: []byte(`{"workersNumber":2}`)}},
+		Status: hcv2.HelmReleaseStatus{
+			ObservedGeneration: 2,
+			Conditions: []metav1.Condition{{
+				Type: fluxmeta.ReadyCondition, Status: metav1.ConditionTrue, Reason: hcv2.UpgradeSucceededReason, ObservedGeneration: 2,
+			}},
+			History: hcv2.Snapshots{{ChartVersion: "0.0.2"}, {ChartVersion: "0.0.1"}},
+		},
+	}
+
+	obj, err := StripHelmRelease(hr.DeepCopy())
+	g.Expect(err).NotTo(HaveOccurred())
+	stripped, ok := obj.(*hcv2.HelmRelease)
+	g.Expect(ok).To(BeTrue())
+
+	// the cached HelmRelease keeps only what the watches of the HelmReleases compare
+	g.Expect(stripped.Labels).To(Equal(hr.Labels))
+	g.Expect(stripped.Generation).To(Equal(hr.Generation))
+	g.Expect(stripped.ManagedFields).To(BeEmpty())
+	g.Expect(stripped.Spec.Values).To(BeNil())
+	g.Expect(stripped.Status.Conditions).To(Equal(hr.Status.Conditions))
+	g.Expect(stripped.Status.History).To(Equal(hcv2.Snapshots{{ChartVersion: "0.0.2"}}))
+	g.Expect(latestChartVersion(stripped)).To(Equal("0.0.2"))
+
+	// so the status changes are still passed between the stripped HelmReleases
+	updated := hr.DeepCopy()
+	updated.Status.Conditions[0].Status = metav1.ConditionFalse
+	strippedUpdate, err := StripHelmRelease(updated)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(helmReleaseStatusChanged().Update(event.UpdateEvent{
+		ObjectOld: stripped, ObjectNew: strippedUpdate.(*hcv2.HelmRelease),
+	})).To(BeTrue())
+
+	// the other objects are cached intact
+	mc := &hmc.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "dev"}}
+	obj, err = StripHelmRelease(mc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(obj).To(BeIdenticalTo(mc))
+}
+
+func TestCredentialChanged(t *testing.T) {
+	g := NewWithT(t)
+
+	old := &hmc.Credential{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "aws-cred", Generation: 1},
+		Spec: hmc.CredentialSpec{IdentityRef: &corev1.ObjectReference{
+			APIVersion: "infrastructure.cluster.x-k8s.io/v1beta2", Kind: "AWSClusterStaticIdentity", Name: "aws-identity",
+		}},
+		Status: hmc.CredentialStatus{State: hmc.CredentialReady},
+	}
+	update := func(mutate func(*hmc.Credential)) bool {
+		updated := old.DeepCopy()
+		mutate(updated)
+		return credentialChanged().Update(event.UpdateEvent{ObjectOld: old, ObjectNew: updated})
+	}
+
+	g.Expect(credentialChanged().Create(event.CreateEvent{Object: old})).To(BeTrue())
+	g.Expect(credentialChanged().Delete(event.DeleteEvent{Object: old})).To(BeTrue())
+
+	g.Expect(update(func(c *hmc.Credential) { c.Status.State = hmc.CredentialNotFound })).To(BeTrue())
+	g.Expect(update(func(c *hmc.Credential) { c.Spec.IdentityRef.Name = "other-identity" })).To(BeTrue())
+	// the isolated copy of the identity replaces the referenced one
+	g.Expect(update(func(c *hmc.Credential) {
+		c.Status.IdentityRef = &corev1.ObjectReference{Kind: "AWSClusterStaticIdentity", Name: "aws-identity-default"}
+	})).To(BeTrue())
+
+	// the other changes do not affect the clusters
+	g.Expect(update(func(c *hmc.Credential) {
+		c.Generation++
+		c.Spec.Description = "AWS credentials"
+		c.Labels = map[string]string{"a": "b"}
+	})).To(BeFalse())
+}
+
+func TestTemplateValidityChanged(t *testing.T) {
+	g := NewWithT(t)
+
+	old := &hmc.ClusterTemplate{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "aws-0-0-1", Generation: 1}}
+	update := func(mutate func(*hmc.ClusterTemplate)) bool {
+		updated := old.DeepCopy()
+		mutate(updated)
+		return templateValidityChanged().Update(event.UpdateEvent{ObjectOld: old, ObjectNew: updated})
+	}
+
+	// the clusters waiting for the template are reconciled once it is created
+	g.Expect(templateValidityChanged().Create(event.CreateEvent{Object: old})).To(BeTrue())
+
+	g.Expect(update(func(t *hmc.ClusterTemplate) { t.Status.Valid = true })).To(BeTrue())
+	g.Expect(update(func(t *hmc.ClusterTemplate) { t.Status.ValidationError = "chart not found" })).To(BeTrue())
+
+	// the other changes of the template do not affect the clusters
+	g.Expect(update(func(t *hmc.ClusterTemplate) {
+		t.Generation++
+		t.Status.KubernetesVersion = "v1.31.1"
+	})).To(BeFalse())
+}
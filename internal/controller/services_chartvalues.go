@@ -0,0 +1,80 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	"helm.sh/helm/v3/pkg/chart"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/Mirantis/hmc/internal/helm"
+)
+
+// serviceChartValues caches the default values of the service charts the image overrides are applied to.
+var serviceChartValues = &chartValuesCache{}
+
+// chartValuesCache caches the default values of the charts of the HelmCharts, so the charts
+// are downloaded once per artifact rather than on every reconciliation. The values are
+// downloaded again once the digest of the artifact changes.
+type chartValuesCache struct {
+	// download downloads the chart of the HelmChart, defaults to helm.DownloadHelmChart.
+	download func(context.Context, client.Client, *sourcev1.HelmChart) (*chart.Chart, error)
+
+	mu     sync.Mutex
+	values map[client.ObjectKey]cachedChartValues
+}
+
+type cachedChartValues struct {
+	digest string
+	values map[string]any
+}
+
+// get returns the default values of the chart of the HelmChart. The returned values
+// are shared between the callers and must not be modified.
+func (c *chartValuesCache) get(ctx context.Context, cl client.Client, hc *sourcev1.HelmChart) (map[string]any, error) {
+	if hc.Status.Artifact == nil {
+		return nil, fmt.Errorf("artifact of HelmChart %s/%s is not ready yet", hc.Namespace, hc.Name)
+	}
+
+	key := client.ObjectKeyFromObject(hc)
+	c.mu.Lock()
+	cached, ok := c.values[key]
+	c.mu.Unlock()
+	if ok && cached.digest == hc.Status.Artifact.Digest {
+		return cached.values, nil
+	}
+
+	download := c.download
+	if download == nil {
+		download = helm.DownloadHelmChart
+	}
+	hcChart, err := download(ctx, cl, hc)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.values == nil {
+		c.values = make(map[client.ObjectKey]cachedChartValues)
+	}
+	// the HelmChart is read again on the digest mismatch, so its artifact is the downloaded one
+	c.values[key] = cachedChartValues{digest: hc.Status.Artifact.Digest, values: hcChart.Values}
+	return hcChart.Values, nil
+}
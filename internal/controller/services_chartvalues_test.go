@@ -0,0 +1,128 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	hcv2 "github.com/fluxcd/helm-controller/api/v2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/template"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+// countingChartDownload returns the download of the charts whose default values hold
+// the digest of the artifact, the downloads are counted per digest.
+func countingChartDownload(downloads map[string]int) func(context.Context, client.Client, *sourcev1.HelmChart) (*chart.Chart, error) {
+	return func(_ context.Context, _ client.Client, hc *sourcev1.HelmChart) (*chart.Chart, error) {
+		downloads[hc.Status.Artifact.Digest]++
+		if hc.Status.Artifact.Digest == "" {
+			return nil, errors.New("artifact digest mismatch")
+		}
+		return &chart.Chart{Values: map[string]any{"image": "docker.io/ingress:" + hc.Status.Artifact.Digest}}, nil
+	}
+}
+
+func TestChartValuesCache(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	downloads := map[string]int{}
+	cache := &chartValuesCache{download: countingChartDownload(downloads)}
+	newHelmChart := func(name, digest string) *sourcev1.HelmChart {
+		hc := &sourcev1.HelmChart{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}}
+		hc.Status.Artifact = &sourcev1.Artifact{Digest: digest}
+		return hc
+	}
+
+	// the chart is downloaded once per artifact
+	for range 3 {
+		values, err := cache.get(ctx, nil, newHelmChart("ingress", "v1"))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(values).To(HaveKeyWithValue("image", "docker.io/ingress:v1"))
+	}
+	g.Expect(downloads).To(Equal(map[string]int{"v1": 1}))
+
+	// and again once the artifact changes
+	values, err := cache.get(ctx, nil, newHelmChart("ingress", "v2"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(values).To(HaveKeyWithValue("image", "docker.io/ingress:v2"))
+	_, err = cache.get(ctx, nil, newHelmChart("ingress", "v2"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(downloads).To(Equal(map[string]int{"v1": 1, "v2": 1}))
+
+	// the values are cached per HelmChart
+	_, err = cache.get(ctx, nil, newHelmChart("monitoring", "v1"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(downloads).To(Equal(map[string]int{"v1": 2, "v2": 1}))
+
+	// the failed downloads and the artifacts not ready yet are not cached
+	for range 2 {
+		_, err = cache.get(ctx, nil, newHelmChart("ingress", ""))
+		g.Expect(err).To(HaveOccurred())
+	}
+	g.Expect(downloads).To(HaveKeyWithValue("", 2))
+	_, err = cache.get(ctx, nil, &sourcev1.HelmChart{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "ingress"}})
+	g.Expect(err).To(MatchError(ContainSubstring("is not ready yet")))
+}
+
+func TestHelmChartOptsImageOverrides(t *testing.T) {
+	g := NewWithT(t)
+
+	downloads := map[string]int{}
+	cache := serviceChartValues
+	serviceChartValues = &chartValuesCache{download: countingChartDownload(downloads)}
+	t.Cleanup(func() { serviceChartValues = cache })
+
+	tmpl := template.NewServiceTemplate(template.WithName("ingress"), template.WithNamespace("default"),
+		template.WithHelmSpec(hmc.HelmSpec{ChartName: "ingress-nginx", ChartVersion: "4.11.0"}))
+	tmpl.Status.ChartRef = &hcv2.CrossNamespaceSourceReference{Kind: sourcev1.HelmChartKind, Namespace: "default", Name: "ingress"}
+	helmChart := &sourcev1.HelmChart{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "ingress"},
+		Spec:       sourcev1.HelmChartSpec{SourceRef: sourcev1.LocalHelmChartSourceReference{Kind: sourcev1.HelmRepositoryKind, Name: "repo"}},
+	}
+	helmChart.Status.Artifact = &sourcev1.Artifact{Digest: "v1"}
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		tmpl,
+		helmChart,
+		&sourcev1.HelmRepository{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "repo"},
+			Spec:       sourcev1.HelmRepositorySpec{URL: "https://example.com/charts"},
+		},
+	).Build()
+	imageOverrides := []hmc.ImageOverride{{Source: "docker.io", Target: "registry.local/mirror"}}
+
+	// the images of the chart are overridden on every reconciliation, the chart is downloaded once
+	for range 3 {
+		opts, err := helmChartOpts(context.Background(), cl, "default", []hmc.ServiceSpec{
+			{Name: "ingress", Template: "ingress", Values: &apiextensionsv1.JSON{Raw: []byte(`{"replicaCount":3}`)}},
+		}, imageOverrides, nil)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(opts).To(HaveLen(1))
+		g.Expect(opts[0].Values.Raw).To(MatchJSON(`{"replicaCount":3,"image":"registry.local/mirror/ingress:v1"}`))
+	}
+	g.Expect(downloads).To(Equal(map[string]int{"v1": 1}))
+}
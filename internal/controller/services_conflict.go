@@ -0,0 +1,239 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	sveltosv1beta1 "github.com/projectsveltos/addon-controller/api/v1beta1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/sveltos"
+	"github.com/Mirantis/hmc/internal/utils"
+)
+
+// reconcileProfiles reconciles the Sveltos Profiles, or the ClusterProfiles if the namespace is empty,
+// deploying the services of the profile with the given name split by their conflict and deletion policies.
+// It returns the names of the reconciled profiles and the operation performed on any of them.
+func reconcileProfiles(ctx context.Context, c client.Client, namespace, name string, opts sveltos.ReconcileProfileOpts) ([]string, controllerutil.OperationResult, error) {
+	profiles := sveltos.ProfilesByPolicy(name, opts)
+	names := make([]string, 0, len(profiles))
+	for profileName := range profiles {
+		names = append(names, profileName)
+	}
+	slices.Sort(names)
+
+	operation := controllerutil.OperationResultNone
+	for _, profileName := range names {
+		var (
+			op  controllerutil.OperationResult
+			err error
+		)
+		if namespace == "" {
+			_, op, err = sveltos.ReconcileClusterProfile(ctx, c, profileName, profiles[profileName])
+		} else {
+			_, op, err = sveltos.ReconcileProfile(ctx, c, namespace, profileName, profiles[profileName])
+		}
+		if err != nil {
+			return nil, op, err
+		}
+		if op != controllerutil.OperationResultNone {
+			operation = op
+		}
+	}
+
+	return names, operation, nil
+}
+
+// profileConflicts returns the services of the Profiles, or the ClusterProfiles if the namespace
+// is empty, not deployed on the clusters since another profile manages them. The conflicts
+// are only looked up on the cluster of the given name if the Profiles are namespaced. The conflicts
+// between the given profiles are omitted, e.g. with the profiles rolling a change of the services out.
+func profileConflicts(ctx context.Context, c client.Client, namespace, clusterName string, profiles []string) ([]hmc.ServiceConflict, error) {
+	var result []hmc.ServiceConflict
+	for _, profile := range profiles {
+		var (
+			conflicts []hmc.ServiceConflict
+			err       error
+		)
+		if namespace == "" {
+			conflicts, err = sveltos.ClusterProfileConflicts(ctx, c, profile)
+		} else {
+			conflicts, err = sveltos.ProfileConflicts(ctx, c, namespace, profile, clusterName)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, conflict := range conflicts {
+			_, managedBy, _ := strings.Cut(conflict.ManagedBy, "/")
+			if slices.Contains(profiles, managedBy) {
+				continue
+			}
+			result = append(result, conflict)
+		}
+	}
+
+	slices.SortFunc(result, func(a, b hmc.ServiceConflict) int {
+		return cmp.Or(cmp.Compare(a.Cluster, b.Cluster), cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+	})
+	return result, nil
+}
+
+// setServiceConflictCondition reports the services not deployed due to the conflicts with the other profiles.
+func setServiceConflictCondition(conditions *[]metav1.Condition, conflicts []hmc.ServiceConflict) {
+	if len(conflicts) == 0 {
+		apimeta.RemoveStatusCondition(conditions, hmc.ServiceConflictCondition)
+		return
+	}
+
+	messages := make([]string, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		msg := fmt.Sprintf("service %s/%s", conflict.Namespace, conflict.Name)
+		if conflict.Cluster != "" {
+			msg += " on cluster " + conflict.Cluster
+		}
+		if conflict.ManagedBy != "" {
+			msg += " is managed by " + conflict.ManagedBy
+		} else {
+			msg += " is managed by another profile"
+		}
+		messages = append(messages, msg)
+	}
+
+	apimeta.SetStatusCondition(conditions, metav1.Condition{
+		Type:    hmc.ServiceConflictCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  hmc.ConflictReason,
+		Message: strings.Join(messages, "; "),
+	})
+}
+
+// clusterSummaryWatch watches the Sveltos ClusterSummaries reporting the deployment of the services,
+// the conflicts and the state of the rollouts included. The watch is started once the Sveltos
+// API is served, since Sveltos is installed by the Management after the controller is started.
+type clusterSummaryWatch struct {
+	controller controller.Controller
+	cache      cache.Cache
+	// kind is the kind of the objects owning the profiles of the ClusterSummaries to enqueue.
+	kind string
+
+	mu      sync.Mutex
+	started bool
+}
+
+// start starts the watch unless it is started already.
+func (w *clusterSummaryWatch) start(ctx context.Context, c client.Client) error {
+	if w == nil || w.controller == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.started {
+		return nil
+	}
+
+	version, err := sveltos.ServedVersion(c)
+	if err != nil {
+		return err
+	}
+
+	gv := schema.GroupVersion{Group: sveltosv1beta1.GroupVersion.Group, Version: version}
+	clusterSummary := &metav1.PartialObjectMetadata{}
+	clusterSummary.SetGroupVersionKind(gv.WithKind(sveltosv1beta1.ClusterSummaryKind))
+	if err := w.controller.Watch(source.Kind[client.Object](w.cache, clusterSummary,
+		handler.EnqueueRequestsFromMapFunc(w.enqueueProfileOwners(c, gv)),
+	)); err != nil {
+		return fmt.Errorf("failed to watch ClusterSummaries: %w", err)
+	}
+
+	w.started = true
+	ctrl.LoggerFrom(ctx).Info("Watching ClusterSummaries")
+	return nil
+}
+
+// enqueueProfileOwners maps the ClusterSummary to the objects of the kind of the watch
+// owning its Profile or ClusterProfile of the given version.
+func (w *clusterSummaryWatch) enqueueProfileOwners(c client.Client, gv schema.GroupVersion) handler.MapFunc {
+	return func(ctx context.Context, o client.Object) []ctrl.Request {
+		profile := &metav1.PartialObjectMetadata{}
+		if name, ok := o.GetLabels()[sveltos.ClusterProfileLabelKey]; ok {
+			profile.SetGroupVersionKind(gv.WithKind(sveltosv1beta1.ClusterProfileKind))
+			profile.SetName(name)
+		} else if name, ok := o.GetLabels()[sveltos.ProfileLabelKey]; ok {
+			profile.SetGroupVersionKind(gv.WithKind(sveltosv1beta1.ProfileKind))
+			profile.SetNamespace(o.GetNamespace())
+			profile.SetName(name)
+		} else {
+			return nil
+		}
+
+		if err := c.Get(ctx, client.ObjectKeyFromObject(profile), profile); err != nil {
+			return nil
+		}
+
+		var requests []ctrl.Request
+		for _, ref := range profile.GetOwnerReferences() {
+			if ref.APIVersion == hmc.GroupVersion.String() && ref.Kind == w.kind {
+				requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKey{Namespace: profile.GetNamespace(), Name: ref.Name}})
+			}
+		}
+		return requests
+	}
+}
+
+// setApplyConflictCondition reports the fields of the objects applied by HMC managed by
+// other field managers. The condition is removed once the objects are applied and left
+// as is if the apply failed for another reason.
+func setApplyConflictCondition(conditions *[]metav1.Condition, err error) {
+	if err == nil {
+		apimeta.RemoveStatusCondition(conditions, hmc.ApplyConflictCondition)
+		return
+	}
+
+	conflict := &utils.ConflictError{}
+	if !errors.As(err, &conflict) {
+		return
+	}
+	apimeta.SetStatusCondition(conditions, metav1.Condition{
+		Type:    hmc.ApplyConflictCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  hmc.ConflictReason,
+		Message: conflict.Error(),
+	})
+}
+
+// isApplyConflict returns true if the error is caused by the fields of the applied object managed by other field managers.
+func isApplyConflict(err error) bool {
+	conflict := &utils.ConflictError{}
+	return errors.As(err, &conflict)
+}
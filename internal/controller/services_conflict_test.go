@@ -0,0 +1,109 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	sveltosv1beta1 "github.com/projectsveltos/addon-controller/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/sveltos"
+	"github.com/Mirantis/hmc/internal/utils"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestEnqueueProfileOwners(t *testing.T) {
+	g := NewWithT(t)
+
+	owner := func(kind, name string) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{APIVersion: hmc.GroupVersion.String(), Kind: kind, Name: name}}
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		&sveltosv1beta1.ClusterProfile{ObjectMeta: metav1.ObjectMeta{Name: "mcs-profile", OwnerReferences: owner(hmc.MultiClusterServiceKind, "mcs")}},
+		&sveltosv1beta1.Profile{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nmcs-profile", OwnerReferences: owner(hmc.NamespacedMultiClusterServiceKind, "nmcs")}},
+	).Build()
+
+	clusterSummary := func(labels map[string]string) client.Object {
+		return &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "summary", Labels: labels}}
+	}
+
+	mcs := (&clusterSummaryWatch{kind: hmc.MultiClusterServiceKind}).enqueueProfileOwners(cl, sveltosv1beta1.GroupVersion)
+	nmcs := (&clusterSummaryWatch{kind: hmc.NamespacedMultiClusterServiceKind}).enqueueProfileOwners(cl, sveltosv1beta1.GroupVersion)
+
+	// the ClusterSummaries are mapped to the owners of their profiles
+	g.Expect(mcs(context.Background(), clusterSummary(map[string]string{sveltos.ClusterProfileLabelKey: "mcs-profile"}))).
+		To(ConsistOf(HaveField("NamespacedName", client.ObjectKey{Name: "mcs"})))
+	g.Expect(nmcs(context.Background(), clusterSummary(map[string]string{sveltos.ProfileLabelKey: "nmcs-profile"}))).
+		To(ConsistOf(HaveField("NamespacedName", client.ObjectKey{Namespace: "default", Name: "nmcs"})))
+
+	// the profiles owned by the other kinds, the missing and the unknown ones are ignored
+	g.Expect(nmcs(context.Background(), clusterSummary(map[string]string{sveltos.ClusterProfileLabelKey: "mcs-profile"}))).To(BeEmpty())
+	g.Expect(mcs(context.Background(), clusterSummary(map[string]string{sveltos.ClusterProfileLabelKey: "missing"}))).To(BeEmpty())
+	g.Expect(mcs(context.Background(), clusterSummary(nil))).To(BeEmpty())
+}
+
+func TestSoakRequeueAfter(t *testing.T) {
+	g := NewWithT(t)
+
+	rollout := &hmc.ServiceRollout{SoakTime: metav1.Duration{Duration: time.Hour}}
+
+	// the rollout is only requeued while soaking
+	g.Expect(soakRequeueAfter(nil, nil)).To(BeZero())
+	g.Expect(soakRequeueAfter(rollout, &hmc.ServiceRolloutStatus{Phase: hmc.ServiceRolloutPhaseProgressing})).To(BeZero())
+
+	started := metav1.NewTime(time.Now().Add(-30 * time.Minute))
+	g.Expect(soakRequeueAfter(rollout, &hmc.ServiceRolloutStatus{Phase: hmc.ServiceRolloutPhaseSoaking, SoakStartedAt: &started})).
+		To(BeNumerically("~", 30*time.Minute, time.Minute))
+
+	// the soaked rollout is requeued right away
+	started = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	g.Expect(soakRequeueAfter(rollout, &hmc.ServiceRolloutStatus{Phase: hmc.ServiceRolloutPhaseSoaking, SoakStartedAt: &started})).
+		To(Equal(time.Second))
+}
+
+func TestSetApplyConflictCondition(t *testing.T) {
+	g := NewWithT(t)
+
+	var conditions []metav1.Condition
+	conflict := fmt.Errorf("failed to reconcile Profile: %w", &utils.ConflictError{
+		Object:   "Profile default/dev",
+		Managers: []string{"kubectl"},
+		Fields:   []string{".spec.syncMode"},
+	})
+
+	setApplyConflictCondition(&conditions, conflict)
+	g.Expect(conditions).To(ConsistOf(And(
+		HaveField("Type", hmc.ApplyConflictCondition),
+		HaveField("Status", metav1.ConditionTrue),
+		HaveField("Reason", hmc.ConflictReason),
+		HaveField("Message", "fields .spec.syncMode of Profile default/dev are managed by kubectl"),
+	)))
+
+	// the other errors leave the condition as is
+	setApplyConflictCondition(&conditions, errors.New("connection refused"))
+	g.Expect(conditions).To(HaveLen(1))
+
+	setApplyConflictCondition(&conditions, nil)
+	g.Expect(conditions).To(BeEmpty())
+}
@@ -0,0 +1,230 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	sveltosv1beta1 "github.com/projectsveltos/addon-controller/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/sveltos"
+)
+
+// reconcileRollout reconciles the Sveltos profiles deploying the services of the profile with the
+// given name step by step. The profiles built from the opts are applied once nothing is deployed yet
+// or the change is rolled out to all of the selected clusters. Meanwhile the applied profiles keep
+// the previous revision of the services, and the rollout profiles with a higher priority take the
+// releases over on the clusters of the completed steps and the current one. Deleting the rollout
+// profiles hands the releases back to the applied profiles, which rolls the clusters back if the
+// rollout is aborted. It returns the names of the profiles to keep and the operation performed.
+func reconcileRollout(ctx context.Context, c client.Client, namespace, name string, opts sveltos.ReconcileProfileOpts, rollout *hmc.ServiceRollout, status *hmc.MultiClusterServiceStatus) ([]string, controllerutil.OperationResult, error) {
+	revision, err := sveltos.Revision(sveltos.ProfilesByPolicy(name, opts))
+	if err != nil {
+		return nil, controllerutil.OperationResultNone, err
+	}
+	opts.Revision = revision
+
+	applied, exists, err := sveltos.AppliedRevision(ctx, c, namespace, name)
+	if err != nil {
+		return nil, controllerutil.OperationResultNone, err
+	}
+
+	state := status.Rollout
+	if !exists || applied == revision {
+		if state == nil || state.Revision != revision {
+			state = &hmc.ServiceRolloutStatus{Revision: revision}
+		}
+		state.Phase = hmc.ServiceRolloutPhaseCompleted
+		state.SoakStartedAt = nil
+		state.Message = ""
+		status.Rollout = state
+		return reconcileProfiles(ctx, c, namespace, name, opts)
+	}
+
+	// the profiles applied before the change keep deploying the previous revision
+	var current []string
+	if namespace == "" {
+		current, err = sveltos.ListClusterProfiles(ctx, c, opts.Labels)
+	} else {
+		current, err = sveltos.ListProfiles(ctx, c, namespace, opts.Labels)
+	}
+	if err != nil {
+		return nil, controllerutil.OperationResultNone, err
+	}
+	// the profiles derived from the rollout profile are prefixed with its name
+	rolloutName := sveltos.RolloutProfileName(name, opts.OwnerReference.UID)
+	current = slices.DeleteFunc(current, func(profile string) bool { return strings.HasPrefix(profile, rolloutName) })
+
+	// the changes of the selected clusters are not rolled out: the clusters selected
+	// anew get the previous revision and join the rollout, the ones left are dropped
+	for profile := range sveltos.ProfilesByPolicy(name, opts) {
+		if !slices.Contains(current, profile) {
+			continue
+		}
+		if _, err := sveltos.SetClusterSelector(ctx, c, namespace, profile, opts.LabelSelector); err != nil {
+			return nil, controllerutil.OperationResultNone, err
+		}
+	}
+
+	if state == nil || state.Revision != revision {
+		// a new change is rolled out from the first step, the rollout profiles
+		// of the previous change are updated to the new one
+		state = &hmc.ServiceRolloutStatus{Revision: revision, Phase: hmc.ServiceRolloutPhaseProgressing}
+		status.Rollout = state
+	}
+	if state.Phase == hmc.ServiceRolloutPhaseAborted {
+		return current, controllerutil.OperationResultNone, nil
+	}
+
+	matching, err := sveltos.MatchingClusters(ctx, c, namespace, name)
+	if err != nil {
+		return nil, controllerutil.OperationResultNone, err
+	}
+	refs := make(map[string]corev1.ObjectReference, len(matching))
+	keys := make([]string, 0, len(matching))
+	for _, ref := range matching {
+		key := ref.Namespace + "/" + ref.Name
+		refs[key] = ref
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	// the clusters not selected anymore are not tracked
+	state.Clusters = slices.DeleteFunc(state.Clusters, func(key string) bool {
+		_, ok := refs[key]
+		return !ok
+	})
+
+	rolloutOpts := opts
+	rolloutOpts.LabelSelector = metav1.LabelSelector{}
+	rolloutOpts.Priority = opts.Priority + 1
+	rolloutOpts.Revision = ""
+	rolloutProfiles := sveltos.ProfilesByPolicy(rolloutName, rolloutOpts)
+	names := make([]string, 0, len(rolloutProfiles))
+	for profile := range rolloutProfiles {
+		names = append(names, profile)
+	}
+
+	statuses, err := sveltos.ClusterStatuses(ctx, c, namespace, names)
+	if err != nil {
+		return nil, controllerutil.OperationResultNone, err
+	}
+
+	done := true
+	state.FailedClusters = nil
+	var messages []string
+	for _, key := range state.Clusters {
+		switch statuses[key].Status {
+		case sveltosv1beta1.FeatureStatusProvisioned:
+		case sveltosv1beta1.FeatureStatusFailed:
+			state.FailedClusters = append(state.FailedClusters, key)
+			messages = append(messages, fmt.Sprintf("%s: %s", key, statuses[key].Message))
+		default:
+			done = false
+		}
+	}
+
+	if len(state.FailedClusters) > 0 && rollout.AbortOnFailure {
+		state.Phase = hmc.ServiceRolloutPhaseAborted
+		state.SoakStartedAt = nil
+		state.Message = "the change failed on the clusters " + strings.Join(messages, "; ")
+		return current, controllerutil.OperationResultNone, nil
+	}
+
+	switch {
+	case !done:
+		// the soak restarts once all of the clusters of the step are provisioned again
+		state.Phase = hmc.ServiceRolloutPhaseProgressing
+		state.SoakStartedAt = nil
+	case len(state.Clusters) > 0 && rollout.SoakTime.Duration > 0:
+		if state.SoakStartedAt == nil {
+			state.SoakStartedAt = &metav1.Time{Time: time.Now()}
+		}
+		if time.Since(state.SoakStartedAt.Time) < rollout.SoakTime.Duration {
+			state.Phase = hmc.ServiceRolloutPhaseSoaking
+			done = false
+		}
+	}
+
+	if done {
+		step := rolloutStep(rollout.MaxClusters, len(keys))
+		var next []string
+		for _, key := range keys {
+			if len(next) == step {
+				break
+			}
+			if !slices.Contains(state.Clusters, key) {
+				next = append(next, key)
+			}
+		}
+
+		if len(next) == 0 {
+			state.Phase = hmc.ServiceRolloutPhaseCompleted
+			state.SoakStartedAt = nil
+			state.Message = ""
+			return reconcileProfiles(ctx, c, namespace, name, opts)
+		}
+
+		state.Clusters = append(state.Clusters, next...)
+		state.Phase = hmc.ServiceRolloutPhaseProgressing
+		state.SoakStartedAt = nil
+	}
+	state.Message = fmt.Sprintf("the change is rolled out to %d of %d clusters", len(state.Clusters), len(keys))
+	if len(messages) > 0 {
+		state.Message += ", failed on the clusters " + strings.Join(messages, "; ")
+	}
+
+	for _, key := range state.Clusters {
+		rolloutOpts.ClusterRefs = append(rolloutOpts.ClusterRefs, refs[key])
+	}
+	rolled, operation, err := reconcileProfiles(ctx, c, namespace, rolloutName, rolloutOpts)
+	if err != nil {
+		return nil, operation, err
+	}
+	return append(current, rolled...), operation, nil
+}
+
+// rolloutStep returns the number of the clusters the change is rolled out to at each step.
+func rolloutStep(maxClusters *intstr.IntOrString, total int) int {
+	if maxClusters == nil {
+		return 1
+	}
+	step, err := intstr.GetScaledValueFromIntOrPercent(maxClusters, total, true)
+	if err != nil || step < 1 {
+		return 1
+	}
+	return step
+}
+
+// soakRequeueAfter returns the time left until the clusters of the current step of the rollout
+// are soaked, or zero if the rollout is not soaking. The soak is reset as soon as any of the
+// clusters is no longer provisioned, so the rollout is not polled while the clusters are being
+// deployed: the rest of the rollout progresses on the changes of the ClusterSummaries.
+func soakRequeueAfter(rollout *hmc.ServiceRollout, state *hmc.ServiceRolloutStatus) time.Duration {
+	if rollout == nil || state == nil || state.Phase != hmc.ServiceRolloutPhaseSoaking || state.SoakStartedAt == nil {
+		return 0
+	}
+	return max(time.Until(state.SoakStartedAt.Add(rollout.SoakTime.Duration)), time.Second)
+}
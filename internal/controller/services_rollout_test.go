@@ -0,0 +1,224 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	sveltosv1beta1 "github.com/projectsveltos/addon-controller/api/v1beta1"
+	libsveltosv1beta1 "github.com/projectsveltos/libsveltos/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/sveltos"
+	"github.com/Mirantis/hmc/test/fakeclient"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+// rolloutTest drives the rollout of the services of a MultiClusterService
+// deployed with the ClusterProfiles on the clusters a, b and c.
+type rolloutTest struct {
+	g      *WithT
+	ctx    context.Context
+	cl     client.Client
+	opts   sveltos.ReconcileProfileOpts
+	status *hmc.MultiClusterServiceStatus
+}
+
+func newRolloutTest(t *testing.T) *rolloutTest {
+	t.Helper()
+
+	mapper := apimeta.NewDefaultRESTMapper([]schema.GroupVersion{sveltosv1beta1.GroupVersion})
+	mapper.Add(sveltosv1beta1.GroupVersion.WithKind(sveltosv1beta1.ProfileKind), apimeta.RESTScopeNamespace)
+	mapper.Add(sveltosv1beta1.GroupVersion.WithKind(sveltosv1beta1.ClusterProfileKind), apimeta.RESTScopeRoot)
+	mapper.Add(sveltosv1beta1.GroupVersion.WithKind(sveltosv1beta1.ClusterSummaryKind), apimeta.RESTScopeNamespace)
+
+	test := &rolloutTest{
+		g:   NewWithT(t),
+		ctx: context.Background(),
+		cl: fakeclient.WithApply(fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper)).
+			WithStatusSubresource(&sveltosv1beta1.ClusterProfile{}).
+			Build(),
+		opts: sveltos.ReconcileProfileOpts{
+			OwnerReference: &metav1.OwnerReference{APIVersion: hmc.GroupVersion.String(), Kind: hmc.MultiClusterServiceKind, Name: "mcs", UID: "uid"},
+			Labels:         map[string]string{hmc.MultiClusterServiceLabelKey: "mcs"},
+			LabelSelector:  metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+			HelmChartOpts:  []sveltos.HelmChartOpts{{ChartName: "ingress", ChartVersion: "1.0.0", ReleaseName: "ingress", ReleaseNamespace: "ingress"}},
+			Priority:       100,
+		},
+		status: &hmc.MultiClusterServiceStatus{},
+	}
+
+	// the services are applied at once while nothing is deployed yet
+	test.reconcile(&hmc.ServiceRollout{})
+	test.g.Expect(test.status.Rollout.Phase).To(Equal(hmc.ServiceRolloutPhaseCompleted))
+
+	profile := &sveltosv1beta1.ClusterProfile{}
+	test.g.Expect(test.cl.Get(test.ctx, client.ObjectKey{Name: "mcs"}, profile)).To(Succeed())
+	for _, cluster := range []string{"a", "b", "c"} {
+		profile.Status.MatchingClusterRefs = append(profile.Status.MatchingClusterRefs, corev1.ObjectReference{Namespace: "default", Name: cluster})
+	}
+	test.g.Expect(test.cl.Status().Update(test.ctx, profile)).To(Succeed())
+
+	// the change of the services is rolled out
+	test.opts.HelmChartOpts[0].ChartVersion = "2.0.0"
+	return test
+}
+
+// reconcile reconciles the rollout and returns the names of the rollout profiles kept.
+func (test *rolloutTest) reconcile(rollout *hmc.ServiceRollout) []string {
+	profiles, _, err := reconcileRollout(test.ctx, test.cl, "", "mcs", test.opts, rollout, test.status)
+	test.g.Expect(err).NotTo(HaveOccurred())
+
+	var rolloutProfiles []string
+	for _, profile := range profiles {
+		if strings.HasPrefix(profile, sveltos.RolloutProfileName("mcs", "uid")) {
+			rolloutProfiles = append(rolloutProfiles, profile)
+		}
+	}
+	return rolloutProfiles
+}
+
+// setStatus sets the status of the services of the rollout profiles on the cluster.
+func (test *rolloutTest) setStatus(profiles []string, cluster string, status sveltosv1beta1.FeatureStatus) {
+	for _, profile := range profiles {
+		summary := &sveltosv1beta1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      profile + "-" + cluster,
+				Labels:    map[string]string{sveltos.ClusterProfileLabelKey: profile},
+			},
+			Spec: sveltosv1beta1.ClusterSummarySpec{ClusterNamespace: "default", ClusterName: cluster},
+		}
+		err := test.cl.Get(test.ctx, client.ObjectKeyFromObject(summary), summary)
+		if client.IgnoreNotFound(err) != nil {
+			test.g.Expect(err).NotTo(HaveOccurred())
+		}
+		summary.Status.FeatureSummaries = []sveltosv1beta1.FeatureSummary{{FeatureID: sveltosv1beta1.FeatureHelm, Status: status}}
+		if err != nil {
+			test.g.Expect(test.cl.Create(test.ctx, summary)).To(Succeed())
+		} else {
+			test.g.Expect(test.cl.Update(test.ctx, summary)).To(Succeed())
+		}
+	}
+}
+
+func TestReconcileRolloutSteps(t *testing.T) {
+	test := newRolloutTest(t)
+	g := test.g
+	rollout := &hmc.ServiceRollout{MaxClusters: ptr.To(intstr.FromInt32(1)), SoakTime: metav1.Duration{Duration: time.Hour}}
+
+	// the first step rolls the change out to the first cluster
+	profiles := test.reconcile(rollout)
+	g.Expect(profiles).NotTo(BeEmpty())
+	g.Expect(test.status.Rollout.Phase).To(Equal(hmc.ServiceRolloutPhaseProgressing))
+	g.Expect(test.status.Rollout.Clusters).To(Equal([]string{"default/a"}))
+	g.Expect(soakRequeueAfter(rollout, test.status.Rollout)).To(BeZero())
+
+	// the cluster is soaked once the change is provisioned on it
+	test.setStatus(profiles, "a", sveltosv1beta1.FeatureStatusProvisioned)
+	test.reconcile(rollout)
+	g.Expect(test.status.Rollout.Phase).To(Equal(hmc.ServiceRolloutPhaseSoaking))
+	g.Expect(test.status.Rollout.SoakStartedAt).NotTo(BeNil())
+	g.Expect(soakRequeueAfter(rollout, test.status.Rollout)).To(BeNumerically(">", 59*time.Minute))
+
+	// the soak restarts once the cluster is no longer provisioned, the rollout is not polled meanwhile
+	test.setStatus(profiles, "a", sveltosv1beta1.FeatureStatusProvisioning)
+	test.reconcile(rollout)
+	g.Expect(test.status.Rollout.Phase).To(Equal(hmc.ServiceRolloutPhaseProgressing))
+	g.Expect(test.status.Rollout.SoakStartedAt).To(BeNil())
+	g.Expect(soakRequeueAfter(rollout, test.status.Rollout)).To(BeZero())
+
+	// the next step starts once the cluster is soaked
+	test.setStatus(profiles, "a", sveltosv1beta1.FeatureStatusProvisioned)
+	test.reconcile(rollout)
+	test.status.Rollout.SoakStartedAt = &metav1.Time{Time: time.Now().Add(-2 * time.Hour)}
+	test.reconcile(rollout)
+	g.Expect(test.status.Rollout.Phase).To(Equal(hmc.ServiceRolloutPhaseProgressing))
+	g.Expect(test.status.Rollout.Clusters).To(Equal([]string{"default/a", "default/b"}))
+
+	// the changes of the selector are applied to the profiles of the previous revision at once
+	revision := test.status.Rollout.Revision
+	test.opts.LabelSelector = metav1.LabelSelector{MatchLabels: map[string]string{"env": "staging"}}
+	test.reconcile(rollout)
+	g.Expect(test.status.Rollout.Revision).To(Equal(revision))
+	g.Expect(test.status.Rollout.Clusters).To(Equal([]string{"default/a", "default/b"}))
+	profile := &sveltosv1beta1.ClusterProfile{}
+	g.Expect(test.cl.Get(test.ctx, client.ObjectKey{Name: "mcs"}, profile)).To(Succeed())
+	g.Expect(profile.Spec.ClusterSelector.MatchLabels).To(Equal(map[string]string{"env": "staging"}))
+	g.Expect(profile.Annotations).NotTo(HaveKeyWithValue(sveltos.RevisionAnnotation, revision))
+}
+
+func TestReconcileRolloutAbort(t *testing.T) {
+	test := newRolloutTest(t)
+	g := test.g
+	rollout := &hmc.ServiceRollout{MaxClusters: ptr.To(intstr.FromString("50%")), AbortOnFailure: true}
+
+	profiles := test.reconcile(rollout)
+	g.Expect(test.status.Rollout.Clusters).To(Equal([]string{"default/a", "default/b"}))
+
+	// the rollout profiles are dropped once the change fails, which rolls the clusters back
+	test.setStatus(profiles, "a", sveltosv1beta1.FeatureStatusProvisioned)
+	test.setStatus(profiles, "b", sveltosv1beta1.FeatureStatusFailed)
+	g.Expect(test.reconcile(rollout)).To(BeEmpty())
+	g.Expect(test.status.Rollout.Phase).To(Equal(hmc.ServiceRolloutPhaseAborted))
+	g.Expect(test.status.Rollout.FailedClusters).To(Equal([]string{"default/b"}))
+
+	// the aborted rollout is not resumed until the services change again
+	test.setStatus(profiles, "b", sveltosv1beta1.FeatureStatusProvisioned)
+	g.Expect(test.reconcile(rollout)).To(BeEmpty())
+	g.Expect(test.status.Rollout.Phase).To(Equal(hmc.ServiceRolloutPhaseAborted))
+
+	test.opts.HelmChartOpts[0].ChartVersion = "2.0.1"
+	g.Expect(test.reconcile(rollout)).NotTo(BeEmpty())
+	g.Expect(test.status.Rollout.Phase).To(Equal(hmc.ServiceRolloutPhaseProgressing))
+}
+
+func TestReconcileRolloutComplete(t *testing.T) {
+	test := newRolloutTest(t)
+	g := test.g
+	rollout := &hmc.ServiceRollout{MaxClusters: ptr.To(intstr.FromString("100%"))}
+
+	profiles := test.reconcile(rollout)
+	g.Expect(test.status.Rollout.Clusters).To(Equal([]string{"default/a", "default/b", "default/c"}))
+
+	// the change is applied to the profiles of the services once rolled out to all of the clusters
+	for _, cluster := range []string{"a", "b", "c"} {
+		test.setStatus(profiles, cluster, sveltosv1beta1.FeatureStatusProvisioned)
+	}
+	g.Expect(test.reconcile(rollout)).To(BeEmpty())
+	g.Expect(test.status.Rollout.Phase).To(Equal(hmc.ServiceRolloutPhaseCompleted))
+
+	profile := &sveltosv1beta1.ClusterProfile{}
+	g.Expect(test.cl.Get(test.ctx, client.ObjectKey{Name: "mcs"}, profile)).To(Succeed())
+	g.Expect(profile.Annotations).To(HaveKeyWithValue(sveltos.RevisionAnnotation, test.status.Rollout.Revision))
+	g.Expect(profile.Spec.ClusterSelector).To(Equal(libsveltosv1beta1.Selector{LabelSelector: metav1.LabelSelector{
+		MatchLabels: map[string]string{"env": "prod"},
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: hmc.ServicesDrainingLabelKey, Operator: metav1.LabelSelectorOpDoesNotExist},
+		},
+	}}))
+}
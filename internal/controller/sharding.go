@@ -0,0 +1,163 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	toolscache "k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// shardFilter filters the namespaced objects by the shard their namespace is
+// assigned to with the hmc.ShardLabelKey label, so that several controller
+// replicas can reconcile the objects of distinct namespaces. The shards of the
+// namespaces are tracked from the events of the Namespace informer.
+type shardFilter struct {
+	reader client.Reader
+	shard  string
+
+	mu         sync.RWMutex
+	namespaces map[string]string
+}
+
+// newShardFilter returns the filter of the objects of the given shard
+// tracking the shards of the namespaces with the informer of the manager.
+func newShardFilter(mgr ctrl.Manager, shard string) (*shardFilter, error) {
+	f := &shardFilter{
+		reader:     mgr.GetClient(),
+		shard:      shard,
+		namespaces: make(map[string]string),
+	}
+
+	informer, err := mgr.GetCache().GetInformer(context.Background(), &corev1.Namespace{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the Namespace informer: %w", err)
+	}
+	if _, err := informer.AddEventHandler(f); err != nil {
+		return nil, fmt.Errorf("failed to track the shards of the namespaces: %w", err)
+	}
+	return f, nil
+}
+
+// OnAdd implements toolscache.ResourceEventHandler.
+func (f *shardFilter) OnAdd(obj any, _ bool) {
+	if ns, ok := obj.(*corev1.Namespace); ok {
+		f.mu.Lock()
+		f.namespaces[ns.Name] = ns.Labels[hmc.ShardLabelKey]
+		f.mu.Unlock()
+	}
+}
+
+// OnUpdate implements toolscache.ResourceEventHandler.
+func (f *shardFilter) OnUpdate(_, newObj any) {
+	f.OnAdd(newObj, false)
+}
+
+// OnDelete implements toolscache.ResourceEventHandler.
+func (f *shardFilter) OnDelete(obj any) {
+	if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if ns, ok := obj.(*corev1.Namespace); ok {
+		f.mu.Lock()
+		delete(f.namespaces, ns.Name)
+		f.mu.Unlock()
+	}
+}
+
+// inShard returns true if the object is reconciled by the shard of the filter.
+// The cluster-scoped objects are always accepted.
+func (f *shardFilter) inShard(o client.Object) bool {
+	if o.GetNamespace() == "" {
+		return true
+	}
+
+	f.mu.RLock()
+	shard, ok := f.namespaces[o.GetNamespace()]
+	f.mu.RUnlock()
+	if ok {
+		return shard == f.shard
+	}
+
+	// the informer has not delivered the namespace yet
+	ns := &corev1.Namespace{}
+	if err := f.reader.Get(context.TODO(), client.ObjectKey{Name: o.GetNamespace()}, ns); err != nil {
+		// the object is reconciled by the default shard if the namespace cannot be fetched
+		return f.shard == ""
+	}
+	return ns.Labels[hmc.ShardLabelKey] == f.shard
+}
+
+// predicate filters the events of the objects by the shard.
+func (f *shardFilter) predicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(f.inShard)
+}
+
+// watchNamespaces returns the watch of the Namespaces enqueueing the objects of
+// the given list type in the namespaces assigned to the shard of the filter, so
+// that the objects are picked up once their namespace is relabelled.
+func (f *shardFilter) watchNamespaces(cl client.Client, list client.ObjectList) (client.Object, handler.EventHandler, builder.WatchesOption) {
+	return &corev1.Namespace{},
+		handler.EnqueueRequestsFromMapFunc(f.mapNamespaceObjects(cl, list)),
+		builder.WithPredicates(predicate.Funcs{
+			CreateFunc: func(event.CreateEvent) bool { return false },
+			DeleteFunc: func(event.DeleteEvent) bool { return false },
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				return e.ObjectOld.GetLabels()[hmc.ShardLabelKey] != e.ObjectNew.GetLabels()[hmc.ShardLabelKey]
+			},
+			GenericFunc: func(event.GenericEvent) bool { return false },
+		})
+}
+
+// mapNamespaceObjects maps a Namespace assigned to the shard of the filter to
+// the objects of the given list type in the namespace.
+func (f *shardFilter) mapNamespaceObjects(cl client.Client, list client.ObjectList) handler.MapFunc {
+	return func(ctx context.Context, o client.Object) []ctrl.Request {
+		if o.GetLabels()[hmc.ShardLabelKey] != f.shard {
+			return nil
+		}
+
+		objects, ok := list.DeepCopyObject().(client.ObjectList)
+		if !ok {
+			return nil
+		}
+		if err := cl.List(ctx, objects, client.InNamespace(o.GetName())); err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, "failed to list the objects of the relabelled namespace", "namespace", o.GetName())
+			return nil
+		}
+
+		var requests []ctrl.Request
+		_ = meta.EachListItem(objects, func(obj runtime.Object) error {
+			if item, ok := obj.(client.Object); ok {
+				requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(item)})
+			}
+			return nil
+		})
+		return requests
+	}
+}
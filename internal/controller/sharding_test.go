@@ -0,0 +1,212 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func shardNamespace(name, shard string) *corev1.Namespace {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if shard != "" {
+		ns.Labels = map[string]string{hmc.ShardLabelKey: shard}
+	}
+	return ns
+}
+
+func TestShardFilter(t *testing.T) {
+	g := NewWithT(t)
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+		WithObjects(shardNamespace("unseen", "a")).
+		Build()
+	f := &shardFilter{reader: cl, shard: "a", namespaces: make(map[string]string)}
+
+	inNamespace := func(namespace string) client.Object {
+		return managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace(namespace))
+	}
+
+	f.OnAdd(shardNamespace("default", ""), true)
+	f.OnAdd(shardNamespace("tenant", "a"), true)
+
+	g.Expect(f.inShard(inNamespace("default"))).To(BeFalse())
+	g.Expect(f.inShard(inNamespace("tenant"))).To(BeTrue())
+	g.Expect(f.inShard(&hmc.Management{ObjectMeta: metav1.ObjectMeta{Name: hmc.ManagementName}})).To(BeTrue())
+
+	// the namespaces not delivered by the informer yet are fetched
+	g.Expect(f.inShard(inNamespace("unseen"))).To(BeTrue())
+	g.Expect(f.inShard(inNamespace("missing"))).To(BeFalse())
+
+	f.OnUpdate(shardNamespace("default", ""), shardNamespace("default", "a"))
+	f.OnUpdate(shardNamespace("tenant", "a"), shardNamespace("tenant", "b"))
+	g.Expect(f.inShard(inNamespace("default"))).To(BeTrue())
+	g.Expect(f.inShard(inNamespace("tenant"))).To(BeFalse())
+
+	f.OnDelete(toolscache.DeletedFinalStateUnknown{Key: "default", Obj: shardNamespace("default", "a")})
+	f.mu.RLock()
+	g.Expect(f.namespaces).NotTo(HaveKey("default"))
+	f.mu.RUnlock()
+
+	// the default shard reconciles the objects of the namespaces which cannot be fetched
+	defaultShard := &shardFilter{reader: cl, namespaces: make(map[string]string)}
+	g.Expect(defaultShard.inShard(inNamespace("missing"))).To(BeTrue())
+}
+
+func TestShardFilterMapNamespaceObjects(t *testing.T) {
+	g := NewWithT(t)
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+		WithObjects(
+			managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("tenant")),
+			managedcluster.NewManagedCluster(managedcluster.WithName("prod"), managedcluster.WithNamespace("tenant")),
+			managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("other")),
+		).
+		Build()
+	f := &shardFilter{reader: cl, shard: "a", namespaces: make(map[string]string)}
+	mapFunc := f.mapNamespaceObjects(cl, &hmc.ManagedClusterList{})
+
+	g.Expect(mapFunc(context.Background(), shardNamespace("tenant", "a"))).To(ConsistOf(
+		ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "tenant", Name: "dev"}},
+		ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "tenant", Name: "prod"}},
+	))
+	g.Expect(mapFunc(context.Background(), shardNamespace("tenant", "b"))).To(BeEmpty())
+}
+
+func TestClusterRequests(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+		WithObjects(
+			shardNamespace("tenant", ""), shardNamespace("other", "b"),
+			managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("tenant"),
+				managedcluster.WithClusterTemplate("aws-0-0-1"), managedcluster.WithDNS(&hmc.DNSConfig{})),
+			managedcluster.NewManagedCluster(managedcluster.WithName("prod"), managedcluster.WithNamespace("tenant"),
+				managedcluster.WithClusterTemplate("aws-0-0-2"), managedcluster.WithCredential("aws-cred")),
+			// the clusters of the other shards are never enqueued
+			managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("other"),
+				managedcluster.WithClusterTemplate("aws-0-0-1"), managedcluster.WithDNS(&hmc.DNSConfig{})),
+		).
+		WithIndex(&hmc.ManagedCluster{}, hmc.TemplateKey, hmc.ExtractTemplateName).
+		WithIndex(&hmc.ManagedCluster{}, hmc.DNSKey, hmc.ExtractDNSEnabled).
+		WithIndex(&hmc.ManagedCluster{}, hmc.CredentialKey, hmc.ExtractCredentialName).
+		Build()
+	r := &ManagedClusterReconciler{Client: cl, shards: &shardFilter{reader: cl, namespaces: make(map[string]string)}}
+
+	dev := ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "tenant", Name: "dev"}}
+	prod := ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "tenant", Name: "prod"}}
+
+	g.Expect(r.clusterRequests(ctx, "")).To(ConsistOf(dev, prod))
+	g.Expect(r.clusterRequests(ctx, "", client.MatchingFields{hmc.DNSKey: "true"})).To(ConsistOf(dev))
+	g.Expect(r.clusterRequests(ctx, "tenant", client.MatchingFields{hmc.TemplateKey: "aws-0-0-2"})).To(ConsistOf(prod))
+	// the clusters using the Credential are enqueued on its changes
+	g.Expect(r.clusterRequests(ctx, "tenant", client.MatchingFields{hmc.CredentialKey: "aws-cred"})).To(ConsistOf(prod))
+	g.Expect(r.clusterRequests(ctx, "tenant",
+		client.MatchingFields{hmc.TemplateKey: "aws-0-0-1"},
+		client.MatchingFields{hmc.TemplateKey: "aws-0-0-2"},
+		client.MatchingFields{hmc.TemplateKey: "aws-0-0-1"},
+	)).To(Equal([]ctrl.Request{dev, prod}))
+}
+
+func TestManagementClusterRequests(t *testing.T) {
+	ctx := context.Background()
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+		WithObjects(
+			managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("tenant"),
+				managedcluster.WithDNS(&hmc.DNSConfig{})),
+			managedcluster.NewManagedCluster(managedcluster.WithName("prod"), managedcluster.WithNamespace("tenant")),
+		).
+		WithIndex(&hmc.ManagedCluster{}, hmc.DNSKey, hmc.ExtractDNSEnabled).
+		Build()
+	r := &ManagedClusterReconciler{Client: cl, shards: &shardFilter{reader: cl, namespaces: make(map[string]string)}}
+
+	dev := ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "tenant", Name: "dev"}}
+	prod := ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "tenant", Name: "prod"}}
+
+	for _, tc := range []struct {
+		name     string
+		mutate   func(*hmc.ManagementSpec)
+		expected []ctrl.Request
+	}{
+		{
+			name: "global cluster defaults",
+			mutate: func(s *hmc.ManagementSpec) {
+				s.GlobalClusterDefaults = &apiextensionsv1.JSON{Raw: []byte(`{"region":"us-east-1"}`)}
+			},
+			expected: []ctrl.Request{dev, prod},
+		},
+		{
+			name: "image overrides",
+			mutate: func(s *hmc.ManagementSpec) {
+				s.ImageOverrides = []hmc.ImageOverride{{Source: "docker.io", Target: "registry.local"}}
+			},
+			expected: []ctrl.Request{dev, prod},
+		},
+		{
+			name:     "proxy",
+			mutate:   func(s *hmc.ManagementSpec) { s.Proxy = &hmc.ProxyConfig{HTTPSProxy: "http://proxy.local:3128"} },
+			expected: []ctrl.Request{dev, prod},
+		},
+		{
+			name:     "trusted CA",
+			mutate:   func(s *hmc.ManagementSpec) { s.TrustedCA = &hmc.TrustedCA{ConfigMap: "ca-bundle"} },
+			expected: []ctrl.Request{dev, prod},
+		},
+		{
+			name:     "maintenance window",
+			mutate:   func(s *hmc.ManagementSpec) { s.MaintenanceWindow = &hmc.MaintenanceWindow{TimeZone: "Europe/Berlin"} },
+			expected: []ctrl.Request{dev, prod},
+		},
+		{
+			name:     "DNS defaults",
+			mutate:   func(s *hmc.ManagementSpec) { s.DNS = &hmc.DNSConfig{} },
+			expected: []ctrl.Request{dev},
+		},
+		{
+			name:   "telemetry",
+			mutate: func(s *hmc.ManagementSpec) { s.Telemetry = &hmc.Telemetry{Mode: hmc.TelemetryModeDisabled} },
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			oldMgmt := &hmc.Management{ObjectMeta: metav1.ObjectMeta{Name: hmc.ManagementName}}
+			newMgmt := oldMgmt.DeepCopy()
+			tc.mutate(&newMgmt.Spec)
+
+			requests := r.managementClusterRequests(ctx, oldMgmt, newMgmt)
+			if tc.expected == nil {
+				g.Expect(requests).To(BeEmpty())
+				return
+			}
+			g.Expect(requests).To(ConsistOf(tc.expected))
+		})
+	}
+}
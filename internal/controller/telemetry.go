@@ -0,0 +1,105 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/sveltos"
+	"github.com/Mirantis/hmc/internal/telemetry"
+)
+
+// trackTelemetry calls the given tracking function with the UID of the
+// Management object. The failures are only logged, so the telemetry never
+// affects the reconciliation.
+func trackTelemetry(ctx context.Context, c client.Client, event string, track func(mgmtID string) error) {
+	l := ctrl.LoggerFrom(ctx)
+
+	mgmt := &hmc.Management{}
+	if err := c.Get(ctx, client.ObjectKey{Name: hmc.ManagementName}, mgmt); err != nil {
+		l.Error(err, "Failed to get Management object", "event", event)
+		return
+	}
+
+	if err := track(string(mgmt.UID)); err != nil {
+		l.Error(err, "Failed to track "+event)
+	}
+}
+
+// trackServiceDeploys tracks the deployment of every enabled service of the
+// object with the given kind and UID. The opts are expected to be produced
+// by helmChartOpts for the same services.
+func trackServiceDeploys(ctx context.Context, c client.Client, kind, uid string, services []hmc.ServiceSpec, opts []sveltos.HelmChartOpts, success bool) {
+	trackTelemetry(ctx, c, "service deployment", func(mgmtID string) error {
+		i := 0
+		for _, svc := range services {
+			if svc.Disable {
+				continue
+			}
+
+			var version string
+			if i < len(opts) {
+				version = opts[i].ChartVersion
+			}
+			i++
+
+			if err := telemetry.TrackServiceDeploy(mgmtID, kind, uid, svc.Template, version, success); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// trackTemplateUpgrade tracks the outcome of the last deployment of the
+// ManagedCluster if it has changed the template of the cluster.
+func trackTemplateUpgrade(ctx context.Context, c client.Client, managedCluster *hmc.ManagedCluster) {
+	history := managedCluster.Status.History
+	if len(history) < 2 {
+		return
+	}
+
+	last, previous := history[len(history)-1], history[len(history)-2]
+	if last.Template == previous.Template {
+		return
+	}
+
+	template := &hmc.ClusterTemplate{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: managedCluster.Namespace, Name: last.Template}, template); client.IgnoreNotFound(err) != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Failed to get ClusterTemplate", "template", last.Template)
+	}
+
+	trackTelemetry(ctx, c, "template upgrade", func(mgmtID string) error {
+		return telemetry.TrackTemplateUpgrade(mgmtID, string(managedCluster.UID), previous.Template, last.Template,
+			template.Spec.Helm.ChartVersion, template.Status.Providers, last.Outcome == hmc.SucceededReason)
+	})
+}
+
+// trackManagedClusterDelete tracks the deletion of the ManagedCluster with the given outcome.
+func trackManagedClusterDelete(ctx context.Context, c client.Client, managedCluster *hmc.ManagedCluster, success bool) {
+	template := &hmc.ClusterTemplate{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: managedCluster.Namespace, Name: managedCluster.Spec.Template}, template); client.IgnoreNotFound(err) != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Failed to get ClusterTemplate", "template", managedCluster.Spec.Template)
+	}
+
+	trackTelemetry(ctx, c, "ManagedCluster deletion", func(mgmtID string) error {
+		return telemetry.TrackManagedClusterDelete(mgmtID, string(managedCluster.UID), managedCluster.Spec.Template,
+			template.Status.Providers, success)
+	})
+}
@@ -0,0 +1,134 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/sveltos"
+	"github.com/Mirantis/hmc/internal/telemetry"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+	"github.com/Mirantis/hmc/test/objects/template"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+// recordingSink records the telemetry events written in the local mode.
+type recordingSink struct {
+	mu     sync.Mutex
+	events []telemetry.Event
+}
+
+func (s *recordingSink) Write(_ context.Context, event telemetry.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+// take returns the events recorded since the last call.
+func (s *recordingSink) take() []telemetry.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := s.events
+	s.events = nil
+	return events
+}
+
+func TestTrackTelemetry(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	sink := &recordingSink{}
+	telemetry.RegisterSink("recording", sink)
+	telemetry.Configure(&hmc.Telemetry{Mode: hmc.TelemetryModeLocal, LocalSink: "recording"})
+	t.Cleanup(func() { telemetry.Configure(nil) })
+
+	mc := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("default"),
+		managedcluster.WithClusterTemplate("aws-0-0-2"))
+	mc.UID = "cluster-uid"
+	mgmt := &hmc.Management{ObjectMeta: metav1.ObjectMeta{Name: hmc.ManagementName, UID: "mgmt-uid"}}
+	newTemplate := template.NewClusterTemplate(template.WithName("aws-0-0-2"), template.WithNamespace("default"),
+		template.WithHelmSpec(hmc.HelmSpec{ChartName: "aws", ChartVersion: "0.0.2"}),
+		template.WithProvidersStatus(hmc.Providers{"infrastructure-aws"}))
+
+	// nothing is tracked without the Management object
+	trackManagedClusterDelete(ctx, fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(), mc, true)
+	g.Expect(sink.take()).To(BeEmpty())
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(mgmt, newTemplate).Build()
+
+	// the deployments of the same template are not upgrades
+	mc.Status.History = []hmc.ManagedClusterHistoryEntry{
+		{Template: "aws-0-0-2", Outcome: hmc.SucceededReason},
+		{Template: "aws-0-0-2", Outcome: hmc.SucceededReason},
+	}
+	trackTemplateUpgrade(ctx, cl, mc)
+	g.Expect(sink.take()).To(BeEmpty())
+
+	mc.Status.History = []hmc.ManagedClusterHistoryEntry{
+		{Template: "aws-0-0-1", Outcome: hmc.SucceededReason},
+		{Template: "aws-0-0-2", Outcome: hmc.FailedReason},
+	}
+	trackTemplateUpgrade(ctx, cl, mc)
+	events := sink.take()
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0].Name).To(Equal("template-upgrade"))
+	g.Expect(events[0].ID).To(Equal("mgmt-uid"))
+	g.Expect(events[0].Properties).To(And(
+		HaveKeyWithValue("managedClusterID", "cluster-uid"),
+		HaveKeyWithValue("fromTemplate", "aws-0-0-1"),
+		HaveKeyWithValue("template", "aws-0-0-2"),
+		HaveKeyWithValue("templateHelmChartVersion", "0.0.2"),
+		HaveKeyWithValue("providers", []string{"infrastructure-aws"}),
+		HaveKeyWithValue("success", false),
+	))
+
+	// every enabled service is tracked with the version of its chart
+	trackServiceDeploys(ctx, cl, hmc.ManagedClusterKind, "cluster-uid", []hmc.ServiceSpec{
+		{Name: "ingress", Template: "ingress-4-11-0"},
+		{Name: "disabled", Template: "kyverno-3-2-6", Disable: true},
+		{Name: "monitoring", Template: "prometheus-25-0-0"},
+	}, []sveltos.HelmChartOpts{{ChartVersion: "4.11.0"}, {ChartVersion: "25.0.0"}}, true)
+	events = sink.take()
+	g.Expect(events).To(HaveLen(2))
+	for i, expected := range [][2]string{{"ingress-4-11-0", "4.11.0"}, {"prometheus-25-0-0", "25.0.0"}} {
+		g.Expect(events[i].Name).To(Equal("service-deploy"))
+		g.Expect(events[i].Properties).To(And(
+			HaveKeyWithValue("ownerKind", hmc.ManagedClusterKind),
+			HaveKeyWithValue("ownerID", "cluster-uid"),
+			HaveKeyWithValue("serviceTemplate", expected[0]),
+			HaveKeyWithValue("serviceTemplateHelmChartVersion", expected[1]),
+			HaveKeyWithValue("success", true),
+		))
+	}
+
+	trackManagedClusterDelete(ctx, cl, mc, true)
+	events = sink.take()
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0].Name).To(Equal("managed-cluster-delete"))
+	g.Expect(events[0].Properties).To(And(
+		HaveKeyWithValue("managedClusterID", "cluster-uid"),
+		HaveKeyWithValue("template", "aws-0-0-2"),
+		HaveKeyWithValue("providers", []string{"infrastructure-aws"}),
+		HaveKeyWithValue("success", true),
+	))
+}
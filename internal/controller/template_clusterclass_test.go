@@ -0,0 +1,89 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/errdefs"
+)
+
+func TestSetClusterClassValues(t *testing.T) {
+	g := NewWithT(t)
+
+	// nothing is set for the templates without a ClusterClass
+	values := map[string]any{"workersNumber": 2}
+	setClusterClassValues(values, nil)
+	g.Expect(values).To(Equal(map[string]any{"workersNumber": 2}))
+
+	// the name of the ClusterClass overrides the configured one, the rest of the values are kept
+	values["clusterClass"] = map[string]any{"name": "other", "variables": map[string]any{"region": "us-east-1"}}
+	setClusterClassValues(values, &hmc.ClusterClassReference{Name: "aws-class"})
+	g.Expect(values).To(Equal(map[string]any{
+		"workersNumber": 2,
+		"clusterClass":  map[string]any{"name": "aws-class", "variables": map[string]any{"region": "us-east-1"}},
+	}))
+
+	// the values are created if not set
+	values = map[string]any{}
+	setClusterClassValues(values, &hmc.ClusterClassReference{Name: "aws-class"})
+	g.Expect(values).To(Equal(map[string]any{"clusterClass": map[string]any{"name": "aws-class"}}))
+}
+
+func TestValidateClusterClass(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	// the CAPI types are not vendored, the ClusterClasses are stored as unstructured
+	classScheme := runtime.NewScheme()
+	g.Expect(hmc.AddToScheme(classScheme)).To(Succeed())
+	classScheme.AddKnownTypeWithName(clusterClassGVK, &unstructured.Unstructured{})
+	classScheme.AddKnownTypeWithName(clusterClassGVK.GroupVersion().WithKind(clusterClassGVK.Kind+"List"), &unstructured.UnstructuredList{})
+
+	template := &hmc.ClusterTemplate{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "aws-clusterclass"},
+		Spec:       hmc.ClusterTemplateSpec{ClusterClass: &hmc.ClusterClassReference{Name: "aws-class"}},
+	}
+	cl := fake.NewClientBuilder().WithScheme(classScheme).
+		WithObjects(template).
+		WithStatusSubresource(template).
+		Build()
+	r := &ClusterTemplateReconciler{TemplateReconciler{Client: cl, Recorder: record.NewFakeRecorder(10)}}
+
+	// the template waits for the ClusterClass to be created
+	err := r.validateClusterClass(ctx, template)
+	g.Expect(errdefs.IsWaiting(err)).To(BeTrue())
+	g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(template), template)).To(Succeed())
+	g.Expect(template.Status.Valid).To(BeFalse())
+	g.Expect(template.Status.ValidationError).To(Equal("ClusterClass default/aws-class is not found"))
+
+	clusterClass := &unstructured.Unstructured{}
+	clusterClass.SetGroupVersionKind(clusterClassGVK)
+	clusterClass.SetNamespace(template.Namespace)
+	clusterClass.SetName("aws-class")
+	g.Expect(cl.Create(ctx, clusterClass)).To(Succeed())
+
+	g.Expect(r.validateClusterClass(ctx, template)).To(Succeed())
+}
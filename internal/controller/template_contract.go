@@ -0,0 +1,133 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/helm"
+)
+
+const (
+	capiInfrastructureGroup = "infrastructure.cluster.x-k8s.io"
+	capiControlPlaneGroup   = "controlplane.cluster.x-k8s.io"
+	capiBootstrapGroup      = "bootstrap.cluster.x-k8s.io"
+)
+
+// providerContractKinds holds the kinds a ClusterTemplate declaring the
+// provider is expected to render. Each entry lists the alternatives, at least
+// one of which must be rendered. The providers missing here are not validated.
+var providerContractKinds = map[string][][]schema.GroupKind{
+	"infrastructure-aws": {
+		groupKinds(capiInfrastructureGroup, "AWSCluster", "AWSManagedCluster"),
+		groupKinds(capiInfrastructureGroup, "AWSMachineTemplate", "AWSManagedMachinePool", "AWSMachinePool"),
+	},
+	"infrastructure-azure": {
+		groupKinds(capiInfrastructureGroup, "AzureCluster", "AzureManagedCluster", "AzureASOManagedCluster"),
+		groupKinds(capiInfrastructureGroup, "AzureMachineTemplate", "AzureManagedMachinePool", "AzureASOManagedMachinePool", "AzureMachinePool"),
+	},
+	"infrastructure-vsphere": {
+		groupKinds(capiInfrastructureGroup, "VSphereCluster"),
+		groupKinds(capiInfrastructureGroup, "VSphereMachineTemplate"),
+	},
+	"control-plane-k0smotron": {
+		groupKinds(capiControlPlaneGroup, "K0sControlPlane", "K0smotronControlPlane"),
+	},
+	"bootstrap-k0smotron": {
+		groupKinds(capiBootstrapGroup, "K0sWorkerConfigTemplate", "K0sWorkerConfig"),
+	},
+}
+
+func groupKinds(group string, kinds ...string) []schema.GroupKind {
+	res := make([]schema.GroupKind, 0, len(kinds))
+	for _, kind := range kinds {
+		res = append(res, schema.GroupKind{Group: group, Kind: kind})
+	}
+	return res
+}
+
+// validateProviderContract checks the ClusterTemplate chart renders the CAPI
+// kinds implied by the providers declared by the template. The validation is
+// skipped if the chart can not be rendered with its default values, the outcome
+// is reported in the ProviderContractValidated condition of the template.
+func validateProviderContract(ctx context.Context, template templateCommon, helmChart *chart.Chart) error {
+	clusterTemplate, ok := template.(*hmc.ClusterTemplate)
+	if !ok {
+		return nil
+	}
+	if len(clusterTemplate.Status.Providers) == 0 {
+		apimeta.RemoveStatusCondition(&clusterTemplate.Status.Conditions, hmc.ProviderContractValidatedCondition)
+		return nil
+	}
+
+	rendered, err := helm.RenderedKinds(helmChart, clusterTemplate.Name, clusterTemplate.Namespace)
+	if err != nil {
+		ctrl.LoggerFrom(ctx).V(1).Info("Skipping provider contract validation, the chart can not be rendered with the default values", "error", err.Error())
+		apimeta.SetStatusCondition(&clusterTemplate.Status.Conditions, metav1.Condition{
+			Type:               hmc.ProviderContractValidatedCondition,
+			Status:             metav1.ConditionFalse,
+			Reason:             hmc.RenderFailedReason,
+			Message:            fmt.Sprintf("Validation is skipped, the chart can not be rendered with the default values: %s", err),
+			ObservedGeneration: clusterTemplate.Generation,
+		})
+		return nil
+	}
+
+	var violations []string
+	for _, provider := range clusterTemplate.Status.Providers {
+		for _, alternatives := range providerContractKinds[provider] {
+			if slices.ContainsFunc(alternatives, func(gk schema.GroupKind) bool { return slices.Contains(rendered, gk) }) {
+				continue
+			}
+
+			kinds := make([]string, 0, len(alternatives))
+			for _, gk := range alternatives {
+				kinds = append(kinds, gk.Kind)
+			}
+			violations = append(violations, fmt.Sprintf("%s (one of %s)", provider, strings.Join(kinds, ", ")))
+		}
+	}
+
+	if len(violations) > 0 {
+		err := fmt.Errorf("the chart does not render the kinds required by the declared providers: %s", strings.Join(violations, "; "))
+		apimeta.SetStatusCondition(&clusterTemplate.Status.Conditions, metav1.Condition{
+			Type:               hmc.ProviderContractValidatedCondition,
+			Status:             metav1.ConditionFalse,
+			Reason:             hmc.FailedReason,
+			Message:            err.Error(),
+			ObservedGeneration: clusterTemplate.Generation,
+		})
+		return err
+	}
+
+	apimeta.SetStatusCondition(&clusterTemplate.Status.Conditions, metav1.Condition{
+		Type:               hmc.ProviderContractValidatedCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             hmc.SucceededReason,
+		Message:            "The chart renders the kinds required by the declared providers",
+		ObservedGeneration: clusterTemplate.Generation,
+	})
+	return nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/template"
+)
+
+func TestValidateProviderContract(t *testing.T) {
+	const awsCluster = `apiVersion: infrastructure.cluster.x-k8s.io/v1beta2
+kind: AWSCluster
+metadata:
+  name: {{ required "name is required" .Values.name }}
+`
+	const awsMachineTemplate = `apiVersion: infrastructure.cluster.x-k8s.io/v1beta2
+kind: AWSMachineTemplate
+metadata:
+  name: md
+`
+
+	tests := []struct {
+		name      string
+		values    map[string]any
+		templates []string
+		status    metav1.ConditionStatus
+		reason    string
+		err       string
+	}{
+		{
+			name:      "should succeed if the chart renders the kinds of the providers",
+			values:    map[string]any{"name": "cluster"},
+			templates: []string{awsCluster, awsMachineTemplate},
+			status:    metav1.ConditionTrue,
+			reason:    hmc.SucceededReason,
+		},
+		{
+			name:      "should fail if the chart does not render the kinds of the providers",
+			values:    map[string]any{"name": "cluster"},
+			templates: []string{awsCluster},
+			status:    metav1.ConditionFalse,
+			reason:    hmc.FailedReason,
+			err:       "AWSMachineTemplate",
+		},
+		{
+			name:      "should report the skipped validation if the chart can not be rendered",
+			templates: []string{awsCluster, awsMachineTemplate},
+			status:    metav1.ConditionFalse,
+			reason:    hmc.RenderFailedReason,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			helmChart := &chart.Chart{
+				Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "aws", Version: "0.1.0"},
+				Values:   tt.values,
+			}
+			for i, data := range tt.templates {
+				helmChart.Templates = append(helmChart.Templates, &chart.File{Name: "templates/" + string(rune('a'+i)) + ".yaml", Data: []byte(data)})
+			}
+
+			ct := template.NewClusterTemplate(template.WithName("aws"))
+			ct.Status.Providers = hmc.Providers{"infrastructure-aws"}
+
+			err := validateProviderContract(context.Background(), ct, helmChart)
+			if tt.err == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+			} else {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.err)))
+			}
+
+			cond := apimeta.FindStatusCondition(ct.Status.Conditions, hmc.ProviderContractValidatedCondition)
+			g.Expect(cond).NotTo(BeNil())
+			g.Expect(cond.Status).To(Equal(tt.status))
+			g.Expect(cond.Reason).To(Equal(tt.reason))
+		})
+	}
+}
@@ -23,13 +23,22 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
 	helmcontrollerv2 "github.com/fluxcd/helm-controller/api/v2"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	"github.com/xeipuuv/gojsonschema"
 	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -38,6 +47,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/errdefs"
 	"github.com/Mirantis/hmc/internal/helm"
 	"github.com/Mirantis/hmc/internal/utils"
 )
@@ -48,11 +58,15 @@ const (
 	defaultRequeueTime = 1 * time.Minute
 )
 
+var clusterClassGVK = schema.GroupVersionKind{Group: "cluster.x-k8s.io", Version: "v1beta1", Kind: "ClusterClass"}
+
 // TemplateReconciler reconciles a *Template object
 type TemplateReconciler struct {
 	client.Client
 
-	downloadHelmChartFunc func(context.Context, *sourcev1.Artifact) (*chart.Chart, error)
+	downloadHelmChartFunc func(context.Context, client.Client, *sourcev1.HelmChart) (*chart.Chart, error)
+
+	Recorder record.EventRecorder
 
 	SystemNamespace       string
 	DefaultRegistryConfig helm.DefaultRegistryConfig
@@ -85,10 +99,25 @@ func (r *ClusterTemplateReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return ctrl.Result{}, err
 	}
 
+	// the template controller is the only writer of the status, the usage is
+	// recorded along with the rest of the status
+	var err error
+	clusterTemplate.Status.UsedByClusters, err = templateUsers(ctx, r.Client, clusterTemplate.Namespace, hmc.TemplateKey, clusterTemplate.Name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	result, err := r.ReconcileTemplate(ctx, clusterTemplate)
 	if err != nil {
 		l.Error(err, "failed to reconcile template")
-		return result, err
+		return errdefs.Result(result, err)
+	}
+
+	if clusterTemplate.Spec.ClusterClass != nil {
+		l.Info("Validating the ClusterClass of the template")
+		if err := r.validateClusterClass(ctx, clusterTemplate); err != nil {
+			return errdefs.Result(ctrl.Result{}, err)
+		}
 	}
 
 	l.Info("Validating template compatibility attributes")
@@ -118,7 +147,13 @@ func (r *ServiceTemplateReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		l.Error(err, "Failed to get ServiceTemplate")
 		return ctrl.Result{}, err
 	}
-	return r.ReconcileTemplate(ctx, serviceTemplate)
+
+	var err error
+	serviceTemplate.Status.UsedByClusters, err = templateUsers(ctx, r.Client, serviceTemplate.Namespace, hmc.ServicesTemplateKey, serviceTemplate.Name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	return errdefs.Result(r.ReconcileTemplate(ctx, serviceTemplate))
 }
 
 func (r *ProviderTemplateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -145,7 +180,7 @@ func (r *ProviderTemplateReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		return ctrl.Result{}, r.Update(ctx, providerTemplate)
 	}
 
-	return r.ReconcileTemplate(ctx, providerTemplate)
+	return errdefs.Result(r.ReconcileTemplate(ctx, providerTemplate))
 }
 
 func (r *ProviderTemplateReconciler) setReleaseOwnership(ctx context.Context, providerTemplate *hmc.ProviderTemplate) (changed bool, err error) {
@@ -186,15 +221,24 @@ func (r *TemplateReconciler) ReconcileTemplate(ctx context.Context, template tem
 		}
 	} else {
 		if helmSpec.ChartName == "" {
-			err := errors.New("neither chartName nor chartRef is set")
+			err := errdefs.Terminal(errors.New("neither chartName nor chartRef is set"))
 			l.Error(err, "invalid helm chart reference")
 			return ctrl.Result{}, err
 		}
-		if template.GetNamespace() == r.SystemNamespace || !templateManagedByHMC(template) {
-			namespace := template.GetNamespace()
-			if namespace == "" {
-				namespace = r.SystemNamespace
+		namespace := template.GetNamespace()
+		if namespace == "" {
+			namespace = r.SystemNamespace
+		}
+		if helmSpec.Repository != "" {
+			if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: helmSpec.Repository}, &sourcev1.HelmRepository{}); err != nil {
+				if !apierrors.IsNotFound(err) {
+					return ctrl.Result{}, fmt.Errorf("failed to get HelmRepository %s/%s: %w", namespace, helmSpec.Repository, err)
+				}
+				l.Info("HelmRepository is not found", "requeue in", defaultRequeueTime)
+				_ = r.updateStatus(ctx, template, fmt.Sprintf("HelmRepository %s/%s is not found", namespace, helmSpec.Repository))
+				return ctrl.Result{RequeueAfter: defaultRequeueTime}, nil
 			}
+		} else if template.GetNamespace() == r.SystemNamespace || !templateManagedByHMC(template) {
 			err := helm.ReconcileHelmRepository(ctx, r.Client, defaultRepoName, namespace, r.DefaultRegistryConfig.HelmRepositorySpec())
 			if err != nil {
 				l.Error(err, "Failed to reconcile default HelmRepository")
@@ -214,6 +258,12 @@ func (r *TemplateReconciler) ReconcileTemplate(ctx context.Context, template tem
 		return ctrl.Result{}, err
 	}
 
+	policy, err := r.chartVerificationPolicy(ctx, helmChartRepository(hcChart))
+	if err != nil {
+		l.Error(err, "Failed to get chart verification policy")
+		return ctrl.Result{}, err
+	}
+
 	status.ChartRef = &helmcontrollerv2.CrossNamespaceSourceReference{
 		Kind:      sourcev1.HelmChartKind,
 		Name:      hcChart.Name,
@@ -227,17 +277,30 @@ func (r *TemplateReconciler) ReconcileTemplate(ctx context.Context, template tem
 		return ctrl.Result{}, err
 	}
 
-	artifact := hcChart.Status.Artifact
+	if policy != nil {
+		l.Info("Verifying Helm chart signature")
+		if err := r.verifyChartSignature(ctx, hcChart, policy); err != nil {
+			l.Error(err, "Helm chart signature verification failed")
+			r.Recorder.Event(template, corev1.EventTypeWarning, EventReasonChartVerificationFailed, err.Error())
+			_ = r.updateStatus(ctx, template, err.Error())
+			return ctrl.Result{}, errdefs.Terminal(err)
+		}
+	}
 
 	if r.downloadHelmChartFunc == nil {
-		r.downloadHelmChartFunc = helm.DownloadChartFromArtifact
+		r.downloadHelmChartFunc = helm.DownloadChartFromHelmChart
 	}
 
 	l.Info("Downloading Helm chart")
-	helmChart, err := r.downloadHelmChartFunc(ctx, artifact)
+	helmChart, err := r.downloadHelmChartFunc(ctx, r.Client, hcChart)
 	if err != nil {
 		l.Error(err, "Failed to download Helm chart")
-		err = fmt.Errorf("failed to download chart: %s", err)
+		eventReason := EventReasonChartDownloadFailed
+		if errors.Is(err, helm.ErrDigestMismatch) {
+			eventReason = EventReasonChartChecksumMismatch
+		}
+		err = fmt.Errorf("failed to download chart: %w", err)
+		r.Recorder.Event(template, corev1.EventTypeWarning, eventReason, err.Error())
 		_ = r.updateStatus(ctx, template, err.Error())
 		return ctrl.Result{}, err
 	}
@@ -249,9 +312,52 @@ func (r *TemplateReconciler) ReconcileTemplate(ctx context.Context, template tem
 		return ctrl.Result{}, err
 	}
 
+	l.Info("Validating Helm chart values schema")
+	if err := validateValuesSchema(helmChart); err != nil {
+		l.Error(err, "Helm chart values schema validation failed")
+		_ = r.updateStatus(ctx, template, err.Error())
+		return ctrl.Result{}, err
+	}
+
 	l.Info("Parsing Helm chart metadata")
 	if err := fillStatusWithProviders(template, helmChart); err != nil {
 		l.Error(err, "Failed to fill status with providers")
+		r.Recorder.Event(template, corev1.EventTypeWarning, EventReasonValidationFailed, err.Error())
+		_ = r.updateStatus(ctx, template, err.Error())
+		// the chart metadata does not change until the template is updated
+		return ctrl.Result{}, errdefs.Terminal(err)
+	}
+
+	if err := validateCustomTemplateProviders(template); err != nil {
+		l.Error(err, "Helm chart metadata validation failed")
+		_ = r.updateStatus(ctx, template, err.Error())
+		return ctrl.Result{}, err
+	}
+
+	l.Info("Validating provider contract")
+	if err := validateProviderContract(ctx, template, helmChart); err != nil {
+		l.Error(err, "Provider contract validation failed")
+		_ = r.updateStatus(ctx, template, err.Error())
+		return ctrl.Result{}, err
+	}
+
+	l.Info("Validating Helm chart with the sample values")
+	if err := r.validateSampleValues(ctx, template, helmChart); err != nil {
+		l.Error(err, "Sample values validation failed")
+		_ = r.updateStatus(ctx, template, err.Error())
+		return ctrl.Result{}, err
+	}
+
+	fillServedCRDVersions(ctx, template, helmChart, r.SystemNamespace)
+
+	if err := fillServiceDefaultValues(template, helmChart); err != nil {
+		l.Error(err, "Failed to resolve the default values of the services")
+		_ = r.updateStatus(ctx, template, err.Error())
+		return ctrl.Result{}, err
+	}
+
+	if err := fillTemplateParameters(template, helmChart); err != nil {
+		l.Error(err, "Failed to collect the parameters of the template")
 		_ = r.updateStatus(ctx, template, err.Error())
 		return ctrl.Result{}, err
 	}
@@ -281,11 +387,158 @@ func fillStatusWithProviders(template templateCommon, helmChart *chart.Chart) er
 		return errors.New("chart metadata is empty")
 	}
 
+	var configSchema *apiextensionsv1.JSON
+	if len(helmChart.Schema) > 0 {
+		configSchema = &apiextensionsv1.JSON{Raw: helmChart.Schema}
+	}
+
+	switch t := template.(type) {
+	case *hmc.ProviderTemplate:
+		t.Status.ProviderVersion = helmChart.Metadata.AppVersion
+		t.Status.ConfigSchema = configSchema
+	case *hmc.ClusterTemplate:
+		t.Status.ConfigSchema = configSchema
+	}
+
 	return template.FillStatusWithProviders(helmChart.Metadata.Annotations)
 }
 
+// fillServedCRDVersions sets the versions served by the CustomResourceDefinitions
+// of the ProviderTemplate. The versions are not set if the chart can not be
+// rendered with its default values, the outcome is reported in the
+// CRDVersionsDetected condition of the template.
+func fillServedCRDVersions(ctx context.Context, template templateCommon, helmChart *chart.Chart, releaseNamespace string) {
+	providerTemplate, ok := template.(*hmc.ProviderTemplate)
+	if !ok {
+		return
+	}
+
+	versions, err := helm.RenderedCRDVersions(helmChart, providerTemplate.Name, releaseNamespace)
+	providerTemplate.Status.ServedCRDVersions = versions
+	if err != nil {
+		ctrl.LoggerFrom(ctx).V(1).Info("Skipping served CRD versions detection, the chart can not be rendered with the default values", "error", err.Error())
+		apimeta.SetStatusCondition(&providerTemplate.Status.Conditions, metav1.Condition{
+			Type:               hmc.CRDVersionsDetectedCondition,
+			Status:             metav1.ConditionFalse,
+			Reason:             hmc.RenderFailedReason,
+			Message:            fmt.Sprintf("Detection is skipped, the chart can not be rendered with the default values: %s", err),
+			ObservedGeneration: providerTemplate.Generation,
+		})
+		return
+	}
+
+	apimeta.SetStatusCondition(&providerTemplate.Status.Conditions, metav1.Condition{
+		Type:               hmc.CRDVersionsDetectedCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             hmc.SucceededReason,
+		Message:            fmt.Sprintf("Detected the served versions of %d CustomResourceDefinitions", len(versions)),
+		ObservedGeneration: providerTemplate.Generation,
+	})
+}
+
+// validateValuesSchema checks the values JSON schema shipped with the chart is a valid JSON schema.
+func validateValuesSchema(helmChart *chart.Chart) error {
+	if len(helmChart.Schema) == 0 {
+		return nil
+	}
+
+	if _, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(helmChart.Schema)); err != nil {
+		return fmt.Errorf("invalid values schema: %w", err)
+	}
+	return nil
+}
+
+// validateCustomTemplateProviders checks the ClusterTemplates pulled from
+// the user-defined HelmRepositories declare the providers they require,
+// the HMC templates are guaranteed to declare them.
+func validateCustomTemplateProviders(template templateCommon) error {
+	clusterTemplate, ok := template.(*hmc.ClusterTemplate)
+	if !ok || clusterTemplate.Spec.Helm.Repository == "" {
+		return nil
+	}
+
+	if len(clusterTemplate.Status.Providers) == 0 {
+		return fmt.Errorf("the chart declares no providers: set either the %s chart annotation or .spec.providers", hmc.ChartAnnotationProviderName)
+	}
+	return nil
+}
+
+// fillTemplateParameters sets the summary of the values of the chart of the ClusterTemplate,
+// so that the clients may build the forms of the cluster configuration without the chart.
+func fillTemplateParameters(template templateCommon, helmChart *chart.Chart) error {
+	clusterTemplate, ok := template.(*hmc.ClusterTemplate)
+	if !ok {
+		return nil
+	}
+
+	params, err := helm.ChartParameters(helmChart)
+	if err != nil {
+		return fmt.Errorf("failed to collect the parameters of the chart: %w", err)
+	}
+	clusterTemplate.Status.Parameters = params
+
+	sections, err := helm.ChartConfigSections(helmChart)
+	if err != nil {
+		return fmt.Errorf("failed to collect the config sections of the chart: %w", err)
+	}
+	clusterTemplate.Status.ConfigSections = sections
+	return nil
+}
+
+// fillServiceDefaultValues resolves the default values of the services using the ServiceTemplate,
+// the DefaultValues of the template take precedence over the values file of the chart.
+func fillServiceDefaultValues(template templateCommon, helmChart *chart.Chart) error {
+	serviceTemplate, ok := template.(*hmc.ServiceTemplate)
+	if !ok {
+		return nil
+	}
+
+	var layers []map[string]any
+	if file := serviceTemplate.Spec.DefaultValuesFile; file != "" {
+		idx := slices.IndexFunc(helmChart.Files, func(f *chart.File) bool { return f.Name == file })
+		if idx < 0 {
+			return fmt.Errorf("values file %s is not found in the chart", file)
+		}
+		values, err := chartutil.ReadValues(helmChart.Files[idx].Data)
+		if err != nil {
+			return fmt.Errorf("failed to parse values file %s: %w", file, err)
+		}
+		layers = append(layers, values)
+	}
+	if serviceTemplate.Spec.DefaultValues != nil && len(serviceTemplate.Spec.DefaultValues.Raw) > 0 {
+		values := make(map[string]any)
+		if err := json.Unmarshal(serviceTemplate.Spec.DefaultValues.Raw, &values); err != nil {
+			return fmt.Errorf("failed to parse default values: %w", err)
+		}
+		layers = append(layers, values)
+	}
+
+	serviceTemplate.Status.DefaultValues = nil
+	if len(layers) == 0 {
+		return nil
+	}
+
+	merged, err := helm.MergeValues(hmc.ConfigMergeStrategyMerge, nil, layers...)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return fmt.Errorf("failed to marshal default values: %w", err)
+	}
+	serviceTemplate.Status.DefaultValues = &apiextensionsv1.JSON{Raw: raw}
+	return nil
+}
+
 func (r *TemplateReconciler) updateStatus(ctx context.Context, template templateCommon, validationError string) error {
 	status := template.GetCommonStatus()
+	switch {
+	case validationError != "":
+		r.Recorder.Event(template, corev1.EventTypeWarning, EventReasonValidationFailed, validationError)
+	case !status.Valid:
+		r.Recorder.Event(template, corev1.EventTypeNormal, EventReasonValidationSucceeded, "Template is valid")
+	}
+
 	status.ObservedGeneration = template.GetGeneration()
 	status.ValidationError = validationError
 	status.Valid = validationError == ""
@@ -297,6 +550,17 @@ func (r *TemplateReconciler) updateStatus(ctx context.Context, template template
 }
 
 func (r *TemplateReconciler) reconcileHelmChart(ctx context.Context, template templateCommon) (*sourcev1.HelmChart, error) {
+	helmSpec := template.GetHelmSpec()
+	repoName := defaultRepoName
+	if helmSpec.Repository != "" {
+		repoName = helmSpec.Repository
+	}
+
+	policy, err := r.chartVerificationPolicy(ctx, repoName)
+	if err != nil {
+		return nil, err
+	}
+
 	namespace := template.GetNamespace()
 	if namespace == "" {
 		namespace = r.SystemNamespace
@@ -308,8 +572,7 @@ func (r *TemplateReconciler) reconcileHelmChart(ctx context.Context, template te
 		},
 	}
 
-	helmSpec := template.GetHelmSpec()
-	_, err := ctrl.CreateOrUpdate(ctx, r.Client, helmChart, func() error {
+	_, err = ctrl.CreateOrUpdate(ctx, r.Client, helmChart, func() error {
 		if helmChart.Labels == nil {
 			helmChart.Labels = make(map[string]string)
 		}
@@ -329,10 +592,13 @@ func (r *TemplateReconciler) reconcileHelmChart(ctx context.Context, template te
 			Version: helmSpec.ChartVersion,
 			SourceRef: sourcev1.LocalHelmChartSourceReference{
 				Kind: sourcev1.HelmRepositoryKind,
-				Name: defaultRepoName,
+				Name: repoName,
 			},
 			Interval: metav1.Duration{Duration: helm.DefaultReconcileInterval},
 		}
+		if policy != nil {
+			helmChart.Spec.Verify = policy.Verify.DeepCopy()
+		}
 
 		return nil
 	})
@@ -355,6 +621,23 @@ func (r *TemplateReconciler) getHelmChartFromChartRef(ctx context.Context, chart
 	return helmChart, nil
 }
 
+// validateClusterClass checks the ClusterClass referenced by the template exists.
+func (r *ClusterTemplateReconciler) validateClusterClass(ctx context.Context, template *hmc.ClusterTemplate) error {
+	clusterClass := &metav1.PartialObjectMetadata{}
+	clusterClass.SetGroupVersionKind(clusterClassGVK)
+	key := client.ObjectKey{Namespace: template.Namespace, Name: template.Spec.ClusterClass.Name}
+	if err := r.Get(ctx, key, clusterClass); err != nil {
+		if !apierrors.IsNotFound(err) && !apimeta.IsNoMatchError(err) {
+			return fmt.Errorf("failed to get ClusterClass %s: %w", key, err)
+		}
+		errMsg := fmt.Sprintf("ClusterClass %s is not found", key)
+		_ = r.updateStatus(ctx, template, errMsg)
+		// the ClusterClass is not watched, wait for it to be created
+		return errdefs.Waiting(errors.New(errMsg))
+	}
+	return nil
+}
+
 func (r *ClusterTemplateReconciler) validateCompatibilityAttrs(ctx context.Context, template *hmc.ClusterTemplate) error {
 	management := new(hmc.Management)
 	if err := r.Client.Get(ctx, client.ObjectKey{Name: hmc.ManagementName}, management); err != nil {
@@ -375,9 +658,10 @@ func (r *ClusterTemplateReconciler) validateCompatibilityAttrs(ctx context.Conte
 		"exposed_capi_contract_versions", management.Status.CAPIContracts, "required_provider_contract_versions", template.Status.ProviderContracts)
 
 	var (
-		merr          error
-		missing       []string
-		nonSatisfying []string
+		merr                  error
+		missing               []string
+		nonSatisfying         []string
+		nonSatisfyingVersions []string
 	)
 	for _, v := range requiredProviders {
 		if !slices.Contains(exposedProviders, v) {
@@ -406,6 +690,22 @@ func (r *ClusterTemplateReconciler) validateCompatibilityAttrs(ctx context.Conte
 		}
 	}
 
+	for providerName, constraint := range template.Status.ProviderVersions {
+		if !slices.Contains(exposedProviders, providerName) {
+			continue // reported as missing
+		}
+
+		version, ok := management.Status.ProviderVersions[providerName]
+		if !ok {
+			nonSatisfyingVersions = append(nonSatisfyingVersions, "version of provider "+providerName+" is unknown")
+			continue
+		}
+
+		if !providerVersionSatisfies(version, constraint) {
+			nonSatisfyingVersions = append(nonSatisfyingVersions, "provider "+providerName+" version "+version+" does not satisfy "+constraint)
+		}
+	}
+
 	if len(missing) > 0 {
 		slices.Sort(missing)
 		merr = errors.Join(merr, fmt.Errorf("one or more required providers are not deployed yet: %v", missing))
@@ -416,6 +716,11 @@ func (r *ClusterTemplateReconciler) validateCompatibilityAttrs(ctx context.Conte
 		merr = errors.Join(merr, fmt.Errorf("one or more required provider contract versions does not satisfy deployed: %v", nonSatisfying))
 	}
 
+	if len(nonSatisfyingVersions) > 0 {
+		slices.Sort(nonSatisfyingVersions)
+		merr = errors.Join(merr, fmt.Errorf("one or more deployed provider versions do not satisfy the required ones: %v", nonSatisfyingVersions))
+	}
+
 	if merr != nil {
 		_ = r.updateStatus(ctx, template, merr.Error())
 		return merr
@@ -424,24 +729,94 @@ func (r *ClusterTemplateReconciler) validateCompatibilityAttrs(ctx context.Conte
 	return r.updateStatus(ctx, template, "")
 }
 
+// providerVersionSatisfies returns true if the provider version satisfies
+// the already validated SemVer constraint.
+func providerVersionSatisfies(version, constraint string) bool {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return false
+	}
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return false
+	}
+	return c.Check(v)
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ClusterTemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&hmc.ClusterTemplate{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		For(&hmc.ClusterTemplate{}, builder.WithPredicates(specOrMetadataChanged())).
+		Watches(&hmc.Management{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, _ client.Object) []ctrl.Request {
+				templates := &hmc.ClusterTemplateList{}
+				if err := r.List(ctx, templates); err != nil {
+					return nil
+				}
+				requests := make([]ctrl.Request, 0, len(templates.Items))
+				for _, template := range templates.Items {
+					requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&template)})
+				}
+				return requests
+			}),
+			builder.WithPredicates(predicate.Funcs{
+				// the compatibility of the templates is revalidated once the installed providers change
+				// and the signatures of the charts once the verification policies change
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					oldMgmt, ok := e.ObjectOld.(*hmc.Management)
+					if !ok {
+						return false
+					}
+					newMgmt, ok := e.ObjectNew.(*hmc.Management)
+					if !ok {
+						return false
+					}
+					return !equality.Semantic.DeepEqual(oldMgmt.Status.AvailableProviders, newMgmt.Status.AvailableProviders) ||
+						!equality.Semantic.DeepEqual(oldMgmt.Status.CAPIContracts, newMgmt.Status.CAPIContracts) ||
+						!equality.Semantic.DeepEqual(oldMgmt.Status.ProviderVersions, newMgmt.Status.ProviderVersions) ||
+						chartVerificationChanged(e)
+				},
+				DeleteFunc:  func(event.DeleteEvent) bool { return false },
+				GenericFunc: func(event.GenericEvent) bool { return false },
+			}),
+		).
+		Watches(&hmc.ManagedCluster{}, enqueueUsedTemplates(hmc.ExtractTemplateName), builder.WithPredicates(specOrMetadataChanged())).
+		Watches(&sourcev1.HelmChart{}, enqueueChartTemplates(r.Client, hmc.ClusterTemplateKind, &hmc.ClusterTemplateList{}), builder.OnlyMetadata).
 		Complete(r)
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ServiceTemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&hmc.ServiceTemplate{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		For(&hmc.ServiceTemplate{}, builder.WithPredicates(specOrMetadataChanged())).
+		Watches(&hmc.Management{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, _ client.Object) []ctrl.Request {
+				templates := &hmc.ServiceTemplateList{}
+				if err := r.List(ctx, templates); err != nil {
+					return nil
+				}
+				requests := make([]ctrl.Request, 0, len(templates.Items))
+				for _, template := range templates.Items {
+					requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&template)})
+				}
+				return requests
+			}),
+			builder.WithPredicates(predicate.Funcs{
+				// the signatures of the charts are verified again once the policies change
+				UpdateFunc:  chartVerificationChanged,
+				DeleteFunc:  func(event.DeleteEvent) bool { return false },
+				GenericFunc: func(event.GenericEvent) bool { return false },
+			}),
+		).
+		Watches(&hmc.ManagedCluster{}, enqueueUsedTemplates(hmc.ExtractServiceTemplateName), builder.WithPredicates(specOrMetadataChanged())).
+		Watches(&sourcev1.HelmChart{}, enqueueChartTemplates(r.Client, hmc.ServiceTemplateKind, &hmc.ServiceTemplateList{}), builder.OnlyMetadata).
 		Complete(r)
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ProviderTemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&hmc.ProviderTemplate{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		For(&hmc.ProviderTemplate{}, builder.WithPredicates(specOrMetadataChanged())).
 		Watches(&hmc.Release{},
 			handler.EnqueueRequestsFromMapFunc(func(_ context.Context, o client.Object) []ctrl.Request {
 				release, ok := o.(*hmc.Release)
@@ -463,5 +838,62 @@ func (r *ProviderTemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				DeleteFunc:  func(event.DeleteEvent) bool { return false },
 			}),
 		).
+		Watches(&hmc.Management{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, _ client.Object) []ctrl.Request {
+				templates := &hmc.ProviderTemplateList{}
+				if err := r.List(ctx, templates); err != nil {
+					return nil
+				}
+				requests := make([]ctrl.Request, 0, len(templates.Items))
+				for _, template := range templates.Items {
+					requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&template)})
+				}
+				return requests
+			}),
+			builder.WithPredicates(predicate.Funcs{
+				// the signatures of the charts are verified again once the policies change
+				UpdateFunc:  chartVerificationChanged,
+				DeleteFunc:  func(event.DeleteEvent) bool { return false },
+				GenericFunc: func(event.GenericEvent) bool { return false },
+			}),
+		).
+		Watches(&hmc.ManagedCluster{}, enqueueUsedTemplates(hmc.ExtractServiceTemplateName), builder.WithPredicates(specOrMetadataChanged())).
+		Watches(&sourcev1.HelmChart{}, enqueueChartTemplates(r.Client, hmc.ProviderTemplateKind, &hmc.ProviderTemplateList{}), builder.OnlyMetadata).
 		Complete(r)
 }
+
+// enqueueChartTemplates returns the handler enqueueing the templates of the given kind using
+// the HelmChart, i.e. owning it or referencing it with the chartRef, so that the templates are
+// validated once the artifact of the chart is ready.
+func enqueueChartTemplates(cl client.Client, kind string, list client.ObjectList) handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, o client.Object) []ctrl.Request {
+		// the ProviderTemplates are cluster-scoped
+		namespace := o.GetNamespace()
+		if kind == hmc.ProviderTemplateKind {
+			namespace = ""
+		}
+
+		var requests []ctrl.Request
+		for _, owner := range o.GetOwnerReferences() {
+			if owner.APIVersion == hmc.GroupVersion.String() && owner.Kind == kind {
+				requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKey{Namespace: namespace, Name: owner.Name}})
+			}
+		}
+
+		templates, ok := list.DeepCopyObject().(client.ObjectList)
+		if !ok {
+			return requests
+		}
+		if err := cl.List(ctx, templates, client.MatchingFields{hmc.ChartRefKey: client.ObjectKeyFromObject(o).String()}); err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, "failed to list the templates referencing the HelmChart", "kind", kind, "chart", client.ObjectKeyFromObject(o))
+			return requests
+		}
+		_ = apimeta.EachListItem(templates, func(obj runtime.Object) error {
+			if template, ok := obj.(client.Object); ok {
+				requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(template)})
+			}
+			return nil
+		})
+		return requests
+	})
+}
@@ -27,6 +27,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
@@ -43,7 +44,7 @@ var _ = Describe("Template Controller", func() {
 			helmChartURL      = "http://source-controller.hmc-system.svc.cluster.local./helmchart/hmc-system/test-chart/0.1.0.tar.gz"
 		)
 
-		fakeDownloadHelmChartFunc := func(context.Context, *sourcev1.Artifact) (*chart.Chart, error) {
+		fakeDownloadHelmChartFunc := func(context.Context, client.Client, *sourcev1.HelmChart) (*chart.Chart, error) {
 			return &chart.Chart{
 				Metadata: &chart.Metadata{
 					APIVersion: "v2",
@@ -179,6 +180,7 @@ var _ = Describe("Template Controller", func() {
 			templateReconciler := TemplateReconciler{
 				Client:                mgrClient,
 				downloadHelmChartFunc: fakeDownloadHelmChartFunc,
+				Recorder:              record.NewFakeRecorder(100),
 			}
 			By("Reconciling the ClusterTemplate resource")
 			clusterTemplateReconciler := &ClusterTemplateReconciler{TemplateReconciler: templateReconciler}
@@ -303,6 +305,7 @@ var _ = Describe("Template Controller", func() {
 			clusterTemplateReconciler := &ClusterTemplateReconciler{TemplateReconciler: TemplateReconciler{
 				Client:                k8sClient,
 				downloadHelmChartFunc: fakeDownloadHelmChartFunc,
+				Recorder:              record.NewFakeRecorder(100),
 			}}
 			_, err := clusterTemplateReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{
 				Name:      clusterTemplateName,
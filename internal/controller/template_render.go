@@ -0,0 +1,103 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/errdefs"
+	"github.com/Mirantis/hmc/internal/helm"
+)
+
+// errTemplateRender is returned if the chart of the ClusterTemplate fails to render
+// with its sample values or the rendered objects are invalid.
+var errTemplateRender = errors.New("the chart is invalid with the sample values")
+
+// validateSampleValues renders the ClusterTemplate chart with each of the sample values
+// shipped in the ci directory of the chart against the Kubernetes version of the template
+// and validates the rendered objects against the API of the management cluster.
+// Charts without sample values are not validated. The validation waits for the kinds
+// not yet served by the management cluster, e.g. the kinds of the providers being installed.
+func (r *TemplateReconciler) validateSampleValues(ctx context.Context, template templateCommon, helmChart *chart.Chart) error {
+	clusterTemplate, ok := template.(*hmc.ClusterTemplate)
+	if !ok {
+		return nil
+	}
+
+	samples, err := helm.SampleValues(helmChart)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errTemplateRender, err)
+	}
+	if len(samples) == 0 {
+		return nil
+	}
+
+	validator := &helm.ManifestValidator{
+		GetCRD: func(name string) (*apiextensionsv1.CustomResourceDefinition, error) {
+			crd := new(apiextensionsv1.CustomResourceDefinition)
+			if err := r.Get(ctx, client.ObjectKey{Name: name}, crd); err != nil {
+				return nil, client.IgnoreNotFound(err)
+			}
+			return crd, nil
+		},
+		Mapper: r.RESTMapper(),
+	}
+
+	names := make([]string, 0, len(samples))
+	for name := range samples {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var problems, pending []string
+	for _, name := range names {
+		objects, err := helm.RenderObjects(helmChart, samples[name], helm.RenderOptions{
+			ReleaseName:      clusterTemplate.Name,
+			ReleaseNamespace: clusterTemplate.Namespace,
+			KubeVersion:      clusterTemplate.Status.KubernetesVersion,
+		})
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		objProblems, objPending := validator.Validate(objects)
+		for _, problem := range objProblems {
+			problems = append(problems, fmt.Sprintf("%s: %s", name, problem))
+		}
+		for _, kind := range objPending {
+			pending = append(pending, fmt.Sprintf("%s: %s", name, kind))
+		}
+	}
+
+	if len(problems) > 0 {
+		ctrl.LoggerFrom(ctx).V(1).Info("The chart is invalid with the sample values", "problems", problems)
+		return fmt.Errorf("%w: %s", errTemplateRender, strings.Join(problems, "; "))
+	}
+	if len(pending) > 0 {
+		// the kinds are served once their providers are installed, the discovery is not watched
+		return errdefs.Waiting(fmt.Errorf("waiting for the kinds of the rendered objects to be served: %s", strings.Join(pending, "; ")))
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	hcv2 "github.com/fluxcd/helm-controller/api/v2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/template"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestFillServiceDefaultValues(t *testing.T) {
+	g := NewWithT(t)
+
+	helmChart := &chart.Chart{Files: []*chart.File{{
+		Name: "values-hardened.yaml",
+		Data: []byte("securityContext:\n  runAsNonRoot: true\n  readOnlyRootFilesystem: true\nreplicas: 2\n"),
+	}}}
+
+	// the other templates are not affected
+	g.Expect(fillServiceDefaultValues(template.NewClusterTemplate(), helmChart)).To(Succeed())
+
+	// nothing is resolved without the defaults
+	tmpl := template.NewServiceTemplate()
+	tmpl.Status.DefaultValues = &apiextensionsv1.JSON{Raw: []byte(`{"stale":true}`)}
+	g.Expect(fillServiceDefaultValues(tmpl, helmChart)).To(Succeed())
+	g.Expect(tmpl.Status.DefaultValues).To(BeNil())
+
+	// the default values take precedence over the values file of the chart
+	tmpl.Spec.DefaultValuesFile = "values-hardened.yaml"
+	tmpl.Spec.DefaultValues = &apiextensionsv1.JSON{Raw: []byte(`{"securityContext":{"readOnlyRootFilesystem":false},"replicas":3}`)}
+	g.Expect(fillServiceDefaultValues(tmpl, helmChart)).To(Succeed())
+	g.Expect(tmpl.Status.DefaultValues).NotTo(BeNil())
+	g.Expect(tmpl.Status.DefaultValues.Raw).To(MatchJSON(`{"securityContext":{"runAsNonRoot":true,"readOnlyRootFilesystem":false},"replicas":3}`))
+
+	// the missing and the invalid values are reported
+	tmpl.Spec.DefaultValuesFile = "values-missing.yaml"
+	g.Expect(fillServiceDefaultValues(tmpl, helmChart)).To(MatchError(ContainSubstring("values file values-missing.yaml is not found")))
+	tmpl.Spec.DefaultValuesFile = ""
+	tmpl.Spec.DefaultValues = &apiextensionsv1.JSON{Raw: []byte(`[]`)}
+	g.Expect(fillServiceDefaultValues(tmpl, helmChart)).To(MatchError(ContainSubstring("failed to parse default values")))
+}
+
+func TestMergeServiceValues(t *testing.T) {
+	g := NewWithT(t)
+
+	defaults := &apiextensionsv1.JSON{Raw: []byte(`{"securityContext":{"runAsNonRoot":true},"replicas":2}`)}
+
+	// the defaults are kept without the values of the service
+	merged, err := mergeServiceValues(defaults, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(merged).To(Equal(defaults))
+
+	// the values of the service are merged over the defaults
+	merged, err = mergeServiceValues(defaults, &apiextensionsv1.JSON{Raw: []byte(`{"replicas":1,"image":{"tag":"v1"}}`)})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(merged.Raw).To(MatchJSON(`{"securityContext":{"runAsNonRoot":true},"replicas":1,"image":{"tag":"v1"}}`))
+
+	_, err = mergeServiceValues(defaults, &apiextensionsv1.JSON{Raw: []byte(`"invalid"`)})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestHelmChartOptsDefaultValues(t *testing.T) {
+	g := NewWithT(t)
+
+	tmpl := template.NewServiceTemplate(template.WithName("ingress"), template.WithNamespace("default"),
+		template.WithHelmSpec(hmc.HelmSpec{ChartName: "ingress-nginx", ChartVersion: "4.11.0"}))
+	tmpl.Status.ChartRef = &hcv2.CrossNamespaceSourceReference{Kind: sourcev1.HelmChartKind, Namespace: "default", Name: "ingress"}
+	tmpl.Status.DefaultValues = &apiextensionsv1.JSON{Raw: []byte(`{"controller":{"replicaCount":2,"metrics":{"enabled":true}}}`)}
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		tmpl,
+		&sourcev1.HelmChart{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "ingress"},
+			Spec:       sourcev1.HelmChartSpec{SourceRef: sourcev1.LocalHelmChartSourceReference{Kind: sourcev1.HelmRepositoryKind, Name: "repo"}},
+		},
+		&sourcev1.HelmRepository{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "repo"},
+			Spec:       sourcev1.HelmRepositorySpec{URL: "https://example.com/charts"},
+		},
+	).Build()
+
+	// the values of the service are merged over the default values of the template
+	opts, err := helmChartOpts(context.Background(), cl, "default", []hmc.ServiceSpec{
+		{Name: "ingress", Template: "ingress", Values: &apiextensionsv1.JSON{Raw: []byte(`{"controller":{"replicaCount":3}}`)}},
+		{Name: "defaults", Template: "ingress"},
+	}, nil, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(opts).To(HaveLen(2))
+	g.Expect(opts[0].Values.Raw).To(MatchJSON(`{"controller":{"replicaCount":3,"metrics":{"enabled":true}}}`))
+	g.Expect(opts[1].Values.Raw).To(MatchJSON(`{"controller":{"replicaCount":2,"metrics":{"enabled":true}}}`))
+}
@@ -0,0 +1,133 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	helmcontrollerv2 "github.com/fluxcd/helm-controller/api/v2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+	"github.com/Mirantis/hmc/test/objects/template"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestTemplateUsers(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+		WithObjects(
+			managedcluster.NewManagedCluster(managedcluster.WithName("b"), managedcluster.WithNamespace("tenant"), managedcluster.WithClusterTemplate("aws")),
+			managedcluster.NewManagedCluster(managedcluster.WithName("a"), managedcluster.WithNamespace("tenant"), managedcluster.WithClusterTemplate("aws")),
+			managedcluster.NewManagedCluster(managedcluster.WithName("c"), managedcluster.WithNamespace("tenant"), managedcluster.WithClusterTemplate("azure")),
+			// the templates are namespaced, the clusters of the other namespaces do not use the template
+			managedcluster.NewManagedCluster(managedcluster.WithName("d"), managedcluster.WithNamespace("other"), managedcluster.WithClusterTemplate("aws")),
+		).
+		WithIndex(&hmc.ManagedCluster{}, hmc.TemplateKey, hmc.ExtractTemplateName).
+		Build()
+
+	users, err := templateUsers(ctx, cl, "tenant", hmc.TemplateKey, "aws")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(users).To(Equal([]string{"a", "b"}))
+
+	users, err = templateUsers(ctx, cl, "tenant", hmc.TemplateKey, "vsphere")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(users).To(BeEmpty())
+}
+
+func TestEnqueueUsedTemplates(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	h := enqueueUsedTemplates(hmc.ExtractTemplateName)
+	q := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	defer q.ShutDown()
+
+	oldCluster := managedcluster.NewManagedCluster(managedcluster.WithName("dev"), managedcluster.WithNamespace("tenant"), managedcluster.WithClusterTemplate("aws-0-0-1"))
+	newCluster := oldCluster.DeepCopy()
+
+	// the updates not changing the template do not change the usage
+	h.Update(ctx, event.UpdateEvent{ObjectOld: oldCluster, ObjectNew: newCluster}, q)
+	g.Expect(q.Len()).To(BeZero())
+
+	// both the previous and the new template are enqueued on the upgrade
+	newCluster.Spec.Template = "aws-0-0-2"
+	h.Update(ctx, event.UpdateEvent{ObjectOld: oldCluster, ObjectNew: newCluster}, q)
+	g.Expect(q.Len()).To(Equal(2))
+
+	var names []string
+	for q.Len() > 0 {
+		req, _ := q.Get()
+		names = append(names, req.String())
+		q.Done(req)
+	}
+	g.Expect(names).To(ConsistOf(
+		client.ObjectKey{Namespace: "tenant", Name: "aws-0-0-1"}.String(),
+		client.ObjectKey{Namespace: "tenant", Name: "aws-0-0-2"}.String(),
+	))
+}
+
+func TestEnqueueChartTemplates(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+		WithObjects(
+			template.NewServiceTemplate(template.WithName("ingress"), template.WithNamespace("tenant"),
+				template.WithHelmSpec(hmc.HelmSpec{ChartRef: &helmcontrollerv2.CrossNamespaceSourceReference{Kind: sourcev1.HelmChartKind, Namespace: "tenant", Name: "shared"}})),
+			template.NewServiceTemplate(template.WithName("other"), template.WithNamespace("tenant"), template.WithHelmSpec(hmc.HelmSpec{ChartName: "other"})),
+		).
+		WithIndex(&hmc.ServiceTemplate{}, hmc.ChartRefKey, hmc.ExtractChartRef).
+		Build()
+
+	h := enqueueChartTemplates(cl, hmc.ServiceTemplateKind, &hmc.ServiceTemplateList{})
+	q := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	defer q.ShutDown()
+
+	chart := func(name string, owners ...metav1.OwnerReference) *metav1.PartialObjectMetadata {
+		return &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: name, OwnerReferences: owners}}
+	}
+	owner := func(kind, name string) metav1.OwnerReference {
+		return metav1.OwnerReference{APIVersion: hmc.GroupVersion.String(), Kind: kind, Name: name}
+	}
+
+	// the charts owned by the templates and the ones referenced with the chartRef are enqueued
+	h.Update(ctx, event.UpdateEvent{ObjectOld: chart("other"), ObjectNew: chart("other", owner(hmc.ServiceTemplateKind, "other"))}, q)
+	h.Update(ctx, event.UpdateEvent{ObjectOld: chart("shared"), ObjectNew: chart("shared")}, q)
+	// the charts of the templates of other kinds are not
+	h.Update(ctx, event.UpdateEvent{ObjectOld: chart("aws"), ObjectNew: chart("aws", owner(hmc.ClusterTemplateKind, "aws"))}, q)
+
+	var names []string
+	for q.Len() > 0 {
+		req, _ := q.Get()
+		names = append(names, req.String())
+		q.Done(req)
+	}
+	g.Expect(names).To(ConsistOf(
+		client.ObjectKey{Namespace: "tenant", Name: "other"}.String(),
+		client.ObjectKey{Namespace: "tenant", Name: "ingress"}.String(),
+	))
+}
@@ -0,0 +1,122 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/helm"
+	"github.com/Mirantis/hmc/internal/utils"
+)
+
+// provenanceKeyringKey is the key of the keyring in the Secret of the provenance verification policy.
+const provenanceKeyringKey = "keyring.gpg"
+
+// chartVerificationPolicy returns the signature verification policy of the
+// charts from the given HelmRepository. It returns nil if there is no policy
+// or the Management object does not exist yet.
+func (r *TemplateReconciler) chartVerificationPolicy(ctx context.Context, repository string) (*hmc.ChartVerificationPolicy, error) {
+	management := new(hmc.Management)
+	if err := r.Get(ctx, client.ObjectKey{Name: hmc.ManagementName}, management); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get Management: %w", err)
+	}
+
+	return management.ChartVerificationPolicy(repository), nil
+}
+
+// helmChartRepository returns the name of the HelmRepository the HelmChart is
+// pulled from or an empty string if the chart comes from another source.
+func helmChartRepository(hcChart *sourcev1.HelmChart) string {
+	if hcChart.Spec.SourceRef.Kind != sourcev1.HelmRepositoryKind {
+		return ""
+	}
+	return hcChart.Spec.SourceRef.Name
+}
+
+// verifyChartSignature returns an error unless the signature of the HelmChart
+// artifact has been verified according to the given policy.
+func (r *TemplateReconciler) verifyChartSignature(ctx context.Context, hcChart *sourcev1.HelmChart, policy *hmc.ChartVerificationPolicy) error {
+	if policy.Provenance != nil {
+		return r.verifyChartProvenance(ctx, hcChart, policy)
+	}
+
+	repo := &sourcev1.HelmRepository{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: hcChart.Namespace, Name: policy.Repository}, repo); err != nil {
+		return fmt.Errorf("failed to get HelmRepository %s/%s: %w", hcChart.Namespace, policy.Repository, err)
+	}
+	if repo.Spec.Type != utils.RegistryTypeOCI {
+		return fmt.Errorf("signature verification is required for the HelmRepository %s, but it is supported only for OCI repositories, use the provenance verification instead", policy.Repository)
+	}
+
+	if hcChart.Spec.Verify == nil {
+		return fmt.Errorf("signature verification is required for the HelmRepository %s, but it is not enabled in the HelmChart %s/%s",
+			policy.Repository, hcChart.Namespace, hcChart.Name)
+	}
+
+	cond := apimeta.FindStatusCondition(hcChart.Status.Conditions, sourcev1.SourceVerifiedCondition)
+	switch {
+	case cond == nil || cond.ObservedGeneration != hcChart.Generation:
+		// the verification of the previous generation does not apply to the current policy
+		return fmt.Errorf("signature of the HelmChart %s/%s is not verified yet", hcChart.Namespace, hcChart.Name)
+	case cond.Status != metav1.ConditionTrue:
+		return fmt.Errorf("failed to verify the signature of the HelmChart %s/%s: %s", hcChart.Namespace, hcChart.Name, cond.Message)
+	}
+
+	return nil
+}
+
+// verifyChartProvenance verifies the HelmChart artifact against its provenance
+// file with the keyring of the given policy.
+func (r *TemplateReconciler) verifyChartProvenance(ctx context.Context, hcChart *sourcev1.HelmChart, policy *hmc.ChartVerificationPolicy) error {
+	secret := &corev1.Secret{}
+	secretKey := client.ObjectKey{Namespace: hcChart.Namespace, Name: policy.Provenance.KeyringSecretRef}
+	if err := r.Get(ctx, secretKey, secret); err != nil {
+		return fmt.Errorf("failed to get the keyring Secret %s: %w", secretKey, err)
+	}
+	keyring, ok := secret.Data[provenanceKeyringKey]
+	if !ok {
+		return fmt.Errorf("keyring Secret %s has no %s key", secretKey, provenanceKeyringKey)
+	}
+
+	return helm.VerifyProvenance(ctx, r.Client, hcChart, keyring)
+}
+
+// chartVerificationChanged returns true if the chart verification policies
+// of the Management have been changed by the update.
+func chartVerificationChanged(e event.UpdateEvent) bool {
+	oldMgmt, ok := e.ObjectOld.(*hmc.Management)
+	if !ok {
+		return false
+	}
+	newMgmt, ok := e.ObjectNew.(*hmc.Management)
+	if !ok {
+		return false
+	}
+	return !equality.Semantic.DeepEqual(oldMgmt.Spec.ChartVerification, newMgmt.Spec.ChartVerification)
+}
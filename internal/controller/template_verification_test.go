@@ -0,0 +1,133 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/utils"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestChartVerificationChanged(t *testing.T) {
+	g := NewWithT(t)
+
+	policy := hmc.ChartVerificationPolicy{
+		Repository: "hmc-templates",
+		Verify:     &sourcev1.OCIRepositoryVerification{Provider: "cosign"},
+	}
+	oldMgmt := &hmc.Management{}
+	newMgmt := oldMgmt.DeepCopy()
+	newMgmt.Spec.ChartVerification = []hmc.ChartVerificationPolicy{policy}
+	g.Expect(chartVerificationChanged(event.UpdateEvent{ObjectOld: oldMgmt, ObjectNew: newMgmt})).To(BeTrue())
+
+	updated := newMgmt.DeepCopy()
+	updated.Spec.Release = "hmc-0-0-5"
+	g.Expect(chartVerificationChanged(event.UpdateEvent{ObjectOld: newMgmt, ObjectNew: updated})).To(BeFalse())
+}
+
+func TestVerifyChartSignature(t *testing.T) {
+	policy := &hmc.ChartVerificationPolicy{
+		Repository: "hmc-templates",
+		Verify:     &sourcev1.OCIRepositoryVerification{Provider: "cosign"},
+	}
+	repo := &sourcev1.HelmRepository{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "hmc-system", Name: "hmc-templates"},
+		Spec:       sourcev1.HelmRepositorySpec{Type: utils.RegistryTypeOCI},
+	}
+	verified := func(status metav1.ConditionStatus, generation int64) []metav1.Condition {
+		return []metav1.Condition{{
+			Type:               sourcev1.SourceVerifiedCondition,
+			Status:             status,
+			ObservedGeneration: generation,
+			Message:            "verification message",
+		}}
+	}
+
+	tests := []struct {
+		name       string
+		verify     *sourcev1.OCIRepositoryVerification
+		conditions []metav1.Condition
+		err        string
+	}{
+		{
+			name: "should fail if the verification is not enabled in the HelmChart",
+			err:  "it is not enabled in the HelmChart",
+		},
+		{
+			name:   "should fail if the signature is not verified yet",
+			verify: policy.Verify,
+			err:    "is not verified yet",
+		},
+		{
+			name:       "should fail if the signature is verified for the previous policy",
+			verify:     policy.Verify,
+			conditions: verified(metav1.ConditionTrue, 1),
+			err:        "is not verified yet",
+		},
+		{
+			name:       "should fail if the verification failed",
+			verify:     policy.Verify,
+			conditions: verified(metav1.ConditionFalse, 2),
+			err:        "verification message",
+		},
+		{
+			name:       "should succeed if the signature is verified",
+			verify:     policy.Verify,
+			conditions: verified(metav1.ConditionTrue, 2),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			hcChart := &sourcev1.HelmChart{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "hmc-system", Name: "aws", Generation: 2},
+				Spec:       sourcev1.HelmChartSpec{Verify: tt.verify},
+				Status:     sourcev1.HelmChartStatus{Conditions: tt.conditions},
+			}
+			r := &TemplateReconciler{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(repo).Build()}
+
+			err := r.verifyChartSignature(context.Background(), hcChart, policy)
+			if tt.err == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+			} else {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.err)))
+			}
+		})
+	}
+}
+
+func TestVerifyChartProvenanceMissingKeyring(t *testing.T) {
+	g := NewWithT(t)
+
+	policy := &hmc.ChartVerificationPolicy{
+		Repository: "hmc-templates",
+		Provenance: &hmc.ProvenanceVerification{KeyringSecretRef: "keyring"},
+	}
+	hcChart := &sourcev1.HelmChart{ObjectMeta: metav1.ObjectMeta{Namespace: "hmc-system", Name: "aws"}}
+	r := &TemplateReconciler{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()}
+
+	g.Expect(r.verifyChartSignature(context.Background(), hcChart, policy)).To(MatchError(ContainSubstring("failed to get the keyring Secret")))
+}
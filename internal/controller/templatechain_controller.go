@@ -18,10 +18,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	hmc "github.com/Mirantis/hmc/api/v1alpha1"
@@ -43,12 +47,19 @@ type ServiceTemplateChainReconciler struct {
 	TemplateChainReconciler
 }
 
+// ProviderTemplateChainReconciler only validates the ProviderTemplateChain,
+// the ProviderTemplates are cluster-scoped and are not created from the chain.
+type ProviderTemplateChainReconciler struct {
+	TemplateChainReconciler
+}
+
 // templateChain is the interface defining a list of methods to interact with *templatechains
 type templateChain interface {
 	client.Object
 	Kind() string
 	TemplateKind() string
 	GetSpec() *hmc.TemplateChainSpec
+	GetStatus() *hmc.TemplateChainStatus
 }
 
 func (r *ClusterTemplateChainReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -85,9 +96,37 @@ func (r *ServiceTemplateChainReconciler) Reconcile(ctx context.Context, req ctrl
 	return r.ReconcileTemplateChain(ctx, serviceTemplateChain)
 }
 
+func (r *ProviderTemplateChainReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := ctrl.LoggerFrom(ctx)
+	l.Info("Reconciling ProviderTemplateChain")
+
+	providerTemplateChain := &hmc.ProviderTemplateChain{}
+	err := r.Get(ctx, req.NamespacedName, providerTemplateChain)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			l.Info("ProviderTemplateChain not found, ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+		l.Error(err, "Failed to get ProviderTemplateChain")
+		return ctrl.Result{}, err
+	}
+
+	_, err = r.validateTemplateChain(ctx, providerTemplateChain)
+	return ctrl.Result{}, err
+}
+
 func (r *TemplateChainReconciler) ReconcileTemplateChain(ctx context.Context, templateChain templateChain) (ctrl.Result, error) {
 	l := ctrl.LoggerFrom(ctx)
 
+	valid, err := r.validateTemplateChain(ctx, templateChain)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !valid {
+		l.Info("Skipping the invalid " + templateChain.Kind())
+		return ctrl.Result{}, nil
+	}
+
 	systemTemplates, managedTemplates, err := getCurrentTemplates(ctx, r.Client, templateChain.TemplateKind(), r.SystemNamespace, templateChain.GetNamespace(), templateChain.GetName())
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to get current templates: %v", err)
@@ -166,6 +205,36 @@ func (r *TemplateChainReconciler) ReconcileTemplateChain(ctx context.Context, te
 	return ctrl.Result{}, errs
 }
 
+// validateTemplateChain reflects the validity of the TemplateChain spec in
+// the Valid condition and returns true if the spec is valid.
+func (r *TemplateChainReconciler) validateTemplateChain(ctx context.Context, templateChain templateChain) (bool, error) {
+	status := templateChain.GetStatus()
+	original := status.DeepCopy()
+
+	condition := metav1.Condition{
+		Type:    hmc.TemplateChainValidCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  hmc.SucceededReason,
+		Message: templateChain.Kind() + " is valid",
+	}
+	problems := templateChain.GetSpec().Validate()
+	if len(problems) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = hmc.FailedReason
+		condition.Message = strings.Join(problems, "; ")
+	}
+	apimeta.SetStatusCondition(&status.Conditions, condition)
+	status.ObservedGeneration = templateChain.GetGeneration()
+
+	if !equality.Semantic.DeepEqual(original, status) {
+		if err := r.Status().Update(ctx, templateChain); err != nil {
+			return false, fmt.Errorf("failed to update status of %s %s/%s: %w", templateChain.Kind(), templateChain.GetNamespace(), templateChain.GetName(), err)
+		}
+	}
+
+	return len(problems) == 0, nil
+}
+
 func getCurrentTemplates(ctx context.Context, cl client.Client, templateKind, systemNamespace, targetNamespace, templateChainName string) (systemTemplates map[string]templateCommon, managedTemplates []templateCommon, _ error) {
 	var templates []templateCommon
 
@@ -222,13 +291,20 @@ func getTemplateNamesManagedByChain(chain templateChain) []string {
 // SetupWithManager sets up the controller with the Manager.
 func (r *ClusterTemplateChainReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&hmc.ClusterTemplateChain{}).
+		For(&hmc.ClusterTemplateChain{}, builder.WithPredicates(specOrMetadataChanged())).
 		Complete(r)
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ServiceTemplateChainReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&hmc.ServiceTemplateChain{}).
+		For(&hmc.ServiceTemplateChain{}, builder.WithPredicates(specOrMetadataChanged())).
+		Complete(r)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ProviderTemplateChainReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&hmc.ProviderTemplateChain{}, builder.WithPredicates(specOrMetadataChanged())).
 		Complete(r)
 }
@@ -20,16 +20,22 @@ import (
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	hmc "github.com/Mirantis/hmc/api/v1alpha1"
 )
 
+// HMCManagedByTemplateManagementLabelKey is the label set on the Templates
+// distributed by the TemplateManagement access rules.
+const HMCManagedByTemplateManagementLabelKey = "hmc.mirantis.com/managed-by-template-management"
+
 // TemplateManagementReconciler reconciles a TemplateManagement object
 type TemplateManagementReconciler struct {
 	client.Client
@@ -71,8 +77,19 @@ func (r *TemplateManagementReconciler) Reconcile(ctx context.Context, req ctrl.R
 		return ctrl.Result{}, err
 	}
 
+	systemCts, managedCts, err := r.getCurrentTemplates(ctx, hmc.ClusterTemplateKind)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	systemSts, managedSts, err := r.getCurrentTemplates(ctx, hmc.ServiceTemplateKind)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	keepCtChains := make(map[string]bool)
 	keepStChains := make(map[string]bool)
+	keepCts := make(map[string]bool)
+	keepSts := make(map[string]bool)
 
 	var errs error
 	for _, rule := range templateMgmt.Spec.AccessRules {
@@ -105,6 +122,33 @@ func (r *TemplateManagementReconciler) Reconcile(ctx context.Context, req ctrl.R
 					continue
 				}
 			}
+			for _, ct := range rule.ClusterTemplates {
+				keepCts[getNamespacedName(namespace, ct)] = true
+				if systemCts[ct] == nil {
+					errs = errors.Join(errs, fmt.Errorf("ClusterTemplate %s/%s is not found", r.SystemNamespace, ct))
+					continue
+				}
+				errs = errors.Join(errs, r.createTemplate(ctx, hmc.ClusterTemplateKind, systemCts[ct], namespace))
+			}
+			for _, st := range rule.ServiceTemplates {
+				keepSts[getNamespacedName(namespace, st)] = true
+				if systemSts[st] == nil {
+					errs = errors.Join(errs, fmt.Errorf("ServiceTemplate %s/%s is not found", r.SystemNamespace, st))
+					continue
+				}
+				errs = errors.Join(errs, r.createTemplate(ctx, hmc.ServiceTemplateKind, systemSts[st], namespace))
+			}
+		}
+	}
+
+	for _, managedTemplate := range managedCts {
+		if !keepCts[getNamespacedName(managedTemplate.GetNamespace(), managedTemplate.GetName())] {
+			errs = errors.Join(errs, r.deleteTemplate(ctx, hmc.ClusterTemplateKind, managedTemplate))
+		}
+	}
+	for _, managedTemplate := range managedSts {
+		if !keepSts[getNamespacedName(managedTemplate.GetNamespace(), managedTemplate.GetName())] {
+			errs = errors.Join(errs, r.deleteTemplate(ctx, hmc.ServiceTemplateKind, managedTemplate))
 		}
 	}
 
@@ -184,6 +228,40 @@ func (r *TemplateManagementReconciler) getCurrentTemplateChains(ctx context.Cont
 	return systemTemplateChains, managedTemplateChains, nil
 }
 
+// getCurrentTemplates returns the Templates of the given kind from the system
+// namespace by name and the Templates distributed by the access rules.
+func (r *TemplateManagementReconciler) getCurrentTemplates(ctx context.Context, templateKind string) (map[string]templateCommon, []templateCommon, error) {
+	systemTemplates, _, err := getCurrentTemplates(ctx, r.Client, templateKind, r.SystemNamespace, "", "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		managedTemplates []templateCommon
+		listOpts         = client.MatchingLabels{HMCManagedByTemplateManagementLabelKey: hmc.TemplateManagementName}
+	)
+	switch templateKind {
+	case hmc.ClusterTemplateKind:
+		ctList := &hmc.ClusterTemplateList{}
+		if err := r.List(ctx, ctList, listOpts); err != nil {
+			return nil, nil, err
+		}
+		for _, template := range ctList.Items {
+			managedTemplates = append(managedTemplates, &template)
+		}
+	case hmc.ServiceTemplateKind:
+		stList := &hmc.ServiceTemplateList{}
+		if err := r.List(ctx, stList, listOpts); err != nil {
+			return nil, nil, err
+		}
+		for _, template := range stList.Items {
+			managedTemplates = append(managedTemplates, &template)
+		}
+	}
+
+	return systemTemplates, managedTemplates, nil
+}
+
 func getTargetNamespaces(ctx context.Context, cl client.Client, targetNamespaces hmc.TargetNamespaces) ([]string, error) {
 	if len(targetNamespaces.List) > 0 {
 		return targetNamespaces.List, nil
@@ -222,6 +300,9 @@ func getTargetNamespaces(ctx context.Context, cl client.Client, targetNamespaces
 	return result, nil
 }
 
+// createTemplateChain creates the copy of the TemplateChain in the target namespace or
+// updates the existing copy. The existing TemplateChains not distributed by the
+// TemplateManagement are never taken over.
 func (r *TemplateManagementReconciler) createTemplateChain(ctx context.Context, source templateChain, targetNamespace string) error {
 	l := ctrl.LoggerFrom(ctx)
 
@@ -232,22 +313,134 @@ func (r *TemplateManagementReconciler) createTemplateChain(ctx context.Context,
 			hmc.HMCManagedLabelKey: hmc.HMCManagedLabelValue,
 		},
 	}
-	var target templateChain
+	var target, existing templateChain
 	switch source.Kind() {
 	case hmc.ClusterTemplateChainKind:
-		target = &hmc.ClusterTemplateChain{ObjectMeta: meta, Spec: *source.GetSpec()}
+		target, existing = &hmc.ClusterTemplateChain{ObjectMeta: meta, Spec: *source.GetSpec()}, &hmc.ClusterTemplateChain{}
 	case hmc.ServiceTemplateChainKind:
-		target = &hmc.ServiceTemplateChain{ObjectMeta: meta, Spec: *source.GetSpec()}
+		target, existing = &hmc.ServiceTemplateChain{ObjectMeta: meta, Spec: *source.GetSpec()}, &hmc.ServiceTemplateChain{}
+	default:
+		return fmt.Errorf("invalid TemplateChain kind. Supported kinds are %s and %s", hmc.ClusterTemplateChainKind, hmc.ServiceTemplateChainKind)
+	}
+
+	err := r.Get(ctx, client.ObjectKeyFromObject(target), existing)
+	switch {
+	case apierrors.IsNotFound(err):
+	case err != nil:
+		return fmt.Errorf("failed to get %s %s/%s: %w", source.Kind(), targetNamespace, source.GetName(), err)
+	case existing.GetLabels()[hmc.HMCManagedLabelKey] != hmc.HMCManagedLabelValue:
+		return fmt.Errorf("%s %s/%s already exists and is not managed by HMC", source.Kind(), targetNamespace, source.GetName())
+	case equality.Semantic.DeepEqual(existing.GetSpec(), target.GetSpec()):
+		return nil
+	default:
+		// the spec of the TemplateChains is immutable, the outdated copy is replaced
+		if err := r.deleteTemplateChain(ctx, existing); err != nil {
+			return err
+		}
+	}
+
+	if err := r.Create(ctx, target); err != nil {
+		return err
+	}
+	l.Info(source.Kind()+" was successfully created", "target namespace", targetNamespace, "source name", source.GetName())
+	return nil
+}
+
+// createTemplate creates the copy of the Template in the target namespace or updates the
+// existing copy. The existing Templates not distributed by the TemplateManagement are never
+// taken over.
+func (r *TemplateManagementReconciler) createTemplate(ctx context.Context, templateKind string, source templateCommon, targetNamespace string) error {
+	l := ctrl.LoggerFrom(ctx)
+
+	if source.GetCommonStatus().ChartRef == nil {
+		return fmt.Errorf("source %s %s/%s does not have chart reference yet", templateKind, r.SystemNamespace, source.GetName())
+	}
+
+	meta := metav1.ObjectMeta{
+		Name:      source.GetName(),
+		Namespace: targetNamespace,
+		Labels: map[string]string{
+			hmc.HMCManagedLabelKey:                 hmc.HMCManagedLabelValue,
+			HMCManagedByTemplateManagementLabelKey: hmc.TemplateManagementName,
+		},
+	}
+	helmSpec := hmc.HelmSpec{
+		ChartRef: source.GetCommonStatus().ChartRef,
+	}
+
+	var (
+		target, existing templateCommon
+		deprecation      hmc.TemplateDeprecation
+	)
+	switch src := source.(type) {
+	case *hmc.ClusterTemplate:
+		deprecation = src.Spec.TemplateDeprecation
+		target = &hmc.ClusterTemplate{ObjectMeta: meta, Spec: hmc.ClusterTemplateSpec{Helm: helmSpec, TemplateDeprecation: deprecation}}
+		existing = &hmc.ClusterTemplate{}
+	case *hmc.ServiceTemplate:
+		deprecation = src.Spec.TemplateDeprecation
+		target = &hmc.ServiceTemplate{ObjectMeta: meta, Spec: hmc.ServiceTemplateSpec{Helm: helmSpec, TemplateDeprecation: deprecation}}
+		existing = &hmc.ServiceTemplate{}
+	default:
+		return fmt.Errorf("invalid Template kind. Supported kinds are %s and %s", hmc.ClusterTemplateKind, hmc.ServiceTemplateKind)
 	}
 
-	err := r.Create(ctx, target)
+	err := r.Get(ctx, client.ObjectKeyFromObject(target), existing)
+	switch {
+	case apierrors.IsNotFound(err):
+	case err != nil:
+		return fmt.Errorf("failed to get %s %s/%s: %w", templateKind, targetNamespace, source.GetName(), err)
+	case existing.GetLabels()[HMCManagedByTemplateManagementLabelKey] != hmc.TemplateManagementName:
+		return fmt.Errorf("%s %s/%s already exists and is not managed by the TemplateManagement", templateKind, targetNamespace, source.GetName())
+	case !equality.Semantic.DeepEqual(existing.GetHelmSpec(), target.GetHelmSpec()):
+		// the helm spec of the templates is immutable, the outdated copy is replaced
+		if err := r.deleteTemplate(ctx, templateKind, existing); err != nil {
+			return err
+		}
+	default:
+		return r.updateTemplateDeprecation(ctx, existing, deprecation)
+	}
+
+	if err := r.Create(ctx, target); err != nil {
+		return err
+	}
+	l.Info(templateKind+" was successfully created", "target namespace", targetNamespace, "source name", source.GetName())
+	return nil
+}
+
+// updateTemplateDeprecation updates the deprecation fields of the existing
+// copy of a Template, the only mutable fields of the spec.
+func (r *TemplateManagementReconciler) updateTemplateDeprecation(ctx context.Context, existing templateCommon, deprecation hmc.TemplateDeprecation) error {
+	var current *hmc.TemplateDeprecation
+	switch t := existing.(type) {
+	case *hmc.ClusterTemplate:
+		current = &t.Spec.TemplateDeprecation
+	case *hmc.ServiceTemplate:
+		current = &t.Spec.TemplateDeprecation
+	}
+	if current == nil || equality.Semantic.DeepEqual(*current, deprecation) {
+		return nil
+	}
+
+	*current = deprecation
+	if err := r.Update(ctx, existing); err != nil {
+		return fmt.Errorf("failed to update %s/%s: %w", existing.GetNamespace(), existing.GetName(), err)
+	}
+	ctrl.LoggerFrom(ctx).Info("Template was successfully updated", "template namespace", existing.GetNamespace(), "template name", existing.GetName())
+	return nil
+}
+
+func (r *TemplateManagementReconciler) deleteTemplate(ctx context.Context, templateKind string, template templateCommon) error {
+	l := ctrl.LoggerFrom(ctx)
+
+	err := r.Delete(ctx, template)
 	if err != nil {
-		if apierrors.IsAlreadyExists(err) {
+		if apierrors.IsNotFound(err) {
 			return nil
 		}
 		return err
 	}
-	l.Info(source.Kind()+" was successfully created", "target namespace", targetNamespace, "source name", source.GetName())
+	l.Info(templateKind+" was successfully deleted", "template namespace", template.GetNamespace(), "template name", template.GetName())
 	return nil
 }
 
@@ -275,6 +468,6 @@ func (r *TemplateManagementReconciler) updateStatus(ctx context.Context, templat
 // SetupWithManager sets up the controller with the Manager.
 func (r *TemplateManagementReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&hmc.TemplateManagement{}).
+		For(&hmc.TemplateManagement{}, builder.WithPredicates(specOrMetadataChanged())).
 		Complete(r)
 }
@@ -0,0 +1,150 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	hcv2 "github.com/fluxcd/helm-controller/api/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/template"
+	tc "github.com/Mirantis/hmc/test/objects/templatechain"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestTemplateManagementCreateTemplate(t *testing.T) {
+	const (
+		systemNamespace = "hmc-system"
+		targetNamespace = "tenant"
+		name            = "aws-standalone-cp-0-0-7"
+	)
+
+	chartRef := &hcv2.CrossNamespaceSourceReference{Kind: "HelmChart", Name: name, Namespace: systemNamespace}
+	source := template.NewClusterTemplate(template.WithName(name), template.WithNamespace(systemNamespace),
+		template.WithDeprecation(hmc.TemplateDeprecation{Deprecated: true}))
+	source.Status.ChartRef = chartRef
+
+	distributed := template.WithLabels(map[string]string{
+		hmc.HMCManagedLabelKey:                 hmc.HMCManagedLabelValue,
+		HMCManagedByTemplateManagementLabelKey: hmc.TemplateManagementName,
+	})
+
+	tests := []struct {
+		name     string
+		existing []client.Object
+		err      string
+	}{
+		{
+			name: "should create the missing template",
+		},
+		{
+			name: "should refuse to take over the template not distributed by the TemplateManagement",
+			existing: []client.Object{
+				template.NewClusterTemplate(template.WithName(name), template.WithNamespace(targetNamespace),
+					template.WithHelmSpec(hmc.HelmSpec{ChartName: "custom"})),
+			},
+			err: "already exists and is not managed by the TemplateManagement",
+		},
+		{
+			name: "should update the deprecation of the distributed template",
+			existing: []client.Object{
+				template.NewClusterTemplate(template.WithName(name), template.WithNamespace(targetNamespace), distributed,
+					template.WithHelmSpec(hmc.HelmSpec{ChartRef: chartRef})),
+			},
+		},
+		{
+			name: "should replace the distributed template referring to another chart",
+			existing: []client.Object{
+				template.NewClusterTemplate(template.WithName(name), template.WithNamespace(targetNamespace), distributed,
+					template.WithHelmSpec(hmc.HelmSpec{ChartRef: &hcv2.CrossNamespaceSourceReference{Kind: "HelmChart", Name: "old", Namespace: systemNamespace}})),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(tt.existing...).Build()
+			r := &TemplateManagementReconciler{Client: c, SystemNamespace: systemNamespace}
+
+			err := r.createTemplate(ctx, hmc.ClusterTemplateKind, source, targetNamespace)
+			if tt.err != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.err)))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			ct := &hmc.ClusterTemplate{}
+			g.Expect(c.Get(ctx, client.ObjectKey{Namespace: targetNamespace, Name: name}, ct)).To(Succeed())
+			g.Expect(ct.Labels).To(HaveKeyWithValue(HMCManagedByTemplateManagementLabelKey, hmc.TemplateManagementName))
+			g.Expect(ct.Spec.Helm.ChartRef).To(Equal(chartRef))
+			g.Expect(ct.Spec.Deprecated).To(BeTrue())
+		})
+	}
+}
+
+func TestTemplateManagementCreateTemplateChain(t *testing.T) {
+	const targetNamespace = "tenant"
+
+	source := tc.NewClusterTemplateChain(tc.WithName("aws"), tc.WithNamespace("hmc-system"),
+		tc.WithSupportedTemplates([]hmc.SupportedTemplate{{Name: "aws-standalone-cp-0-0-7"}}))
+
+	tests := []struct {
+		name     string
+		existing []client.Object
+		err      string
+	}{
+		{
+			name: "should create the missing chain",
+		},
+		{
+			name:     "should refuse to take over the chain not managed by HMC",
+			existing: []client.Object{tc.NewClusterTemplateChain(tc.WithName("aws"), tc.WithNamespace(targetNamespace))},
+			err:      "already exists and is not managed by HMC",
+		},
+		{
+			name:     "should replace the outdated chain",
+			existing: []client.Object{tc.NewClusterTemplateChain(tc.WithName("aws"), tc.WithNamespace(targetNamespace), tc.ManagedByHMC())},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(tt.existing...).Build()
+			r := &TemplateManagementReconciler{Client: c, SystemNamespace: "hmc-system"}
+
+			err := r.createTemplateChain(ctx, source, targetNamespace)
+			if tt.err != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.err)))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			chain := &hmc.ClusterTemplateChain{}
+			g.Expect(c.Get(ctx, client.ObjectKey{Namespace: targetNamespace, Name: "aws"}, chain)).To(Succeed())
+			g.Expect(chain.Spec).To(Equal(source.Spec))
+		})
+	}
+}
@@ -0,0 +1,348 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/helm"
+)
+
+// HMCManagedByTemplatesSourceLabelKey is the label set on the Templates
+// applied from the TemplateManagement templates source.
+const HMCManagedByTemplatesSourceLabelKey = "hmc.mirantis.com/managed-by-templates-source"
+
+// TemplatesSourceReconciler syncs the ClusterTemplates and ServiceTemplates
+// from the GitRepository referenced by the TemplateManagement into the system namespace.
+type TemplatesSourceReconciler struct {
+	client.Client
+	SystemNamespace string
+}
+
+func (r *TemplatesSourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := ctrl.LoggerFrom(ctx)
+
+	templateMgmt := &hmc.TemplateManagement{}
+	if err := r.Get(ctx, req.NamespacedName, templateMgmt); err != nil {
+		if apierrors.IsNotFound(err) {
+			l.Info("TemplateManagement not found, ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+
+		l.Error(err, "Failed to get TemplateManagement")
+		return ctrl.Result{}, err
+	}
+
+	source := templateMgmt.Spec.TemplatesSource
+	if source == nil {
+		return ctrl.Result{}, nil
+	}
+
+	l.Info("Syncing templates from the GitRepository", "gitRepository", source.GitRepository, "path", source.Path)
+	revision, err := r.sync(ctx, source)
+
+	patch := client.MergeFrom(templateMgmt.DeepCopy())
+	templateMgmt.Status.TemplatesSourceError = ""
+	if err != nil {
+		templateMgmt.Status.TemplatesSourceError = err.Error()
+	} else if revision != "" {
+		templateMgmt.Status.TemplatesSourceRevision = revision
+	}
+	if patchErr := r.Status().Patch(ctx, templateMgmt, patch); patchErr != nil {
+		return ctrl.Result{}, errors.Join(err, fmt.Errorf("failed to update status of TemplateManagement %s: %w", templateMgmt.Name, patchErr))
+	}
+
+	if err == nil && revision == "" {
+		l.Info("GitRepository artifact is not ready yet", "requeue in", DefaultRequeueInterval)
+		return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, nil
+	}
+	return ctrl.Result{}, err
+}
+
+// sync applies the templates found in the artifact of the GitRepository and
+// prunes the ones removed from it if requested. The artifact with no templates
+// is never pruned from, it fails the sync instead. It returns the revision of
+// the applied artifact or an empty string if the artifact is not ready yet.
+func (r *TemplatesSourceReconciler) sync(ctx context.Context, source *hmc.TemplatesSource) (string, error) {
+	repo := &sourcev1.GitRepository{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: r.SystemNamespace, Name: source.GitRepository}, repo); err != nil {
+		return "", fmt.Errorf("failed to get GitRepository %s/%s: %w", r.SystemNamespace, source.GitRepository, err)
+	}
+	artifact := repo.Status.Artifact
+	if artifact == nil {
+		return "", nil
+	}
+
+	buf, err := helm.DownloadArtifact(ctx, artifact.URL, artifact.Digest)
+	if err != nil {
+		return "", fmt.Errorf("failed to download artifact of GitRepository %s/%s: %w", r.SystemNamespace, source.GitRepository, err)
+	}
+
+	templates, err := templatesFromArtifact(buf, source.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read templates from GitRepository %s/%s revision %s: %w", r.SystemNamespace, source.GitRepository, artifact.Revision, err)
+	}
+
+	if len(templates) == 0 && source.Prune {
+		// an emptied or mistyped path would delete every template applied from the source
+		return "", fmt.Errorf("no templates found in GitRepository %s/%s revision %s under path %q, refusing to prune the templates applied from the source",
+			r.SystemNamespace, source.GitRepository, artifact.Revision, source.Path)
+	}
+
+	var errs error
+	keep := make(map[string]bool, len(templates))
+	for _, template := range templates {
+		keep[template.GetObjectKind().GroupVersionKind().Kind+"/"+template.GetName()] = true
+		errs = errors.Join(errs, r.applyTemplate(ctx, template))
+	}
+
+	if source.Prune {
+		errs = errors.Join(errs, r.prune(ctx, keep))
+	}
+
+	if errs != nil {
+		return "", errs
+	}
+	return artifact.Revision, nil
+}
+
+// applyTemplate creates or updates the given template in the system namespace.
+// The existing templates not applied from the source are never adopted.
+func (r *TemplatesSourceReconciler) applyTemplate(ctx context.Context, desired templateCommon) error {
+	var (
+		current templateCommon
+		mutate  func()
+		kind    = desired.GetObjectKind().GroupVersionKind().Kind
+	)
+	switch d := desired.(type) {
+	case *hmc.ClusterTemplate:
+		ct := &hmc.ClusterTemplate{ObjectMeta: metav1.ObjectMeta{Name: d.Name, Namespace: r.SystemNamespace}}
+		current, mutate = ct, func() { ct.Spec = d.Spec }
+	case *hmc.ServiceTemplate:
+		st := &hmc.ServiceTemplate{ObjectMeta: metav1.ObjectMeta{Name: d.Name, Namespace: r.SystemNamespace}}
+		current, mutate = st, func() { st.Spec = d.Spec }
+	default:
+		return fmt.Errorf("unsupported template kind %s", kind)
+	}
+
+	_, err := ctrl.CreateOrUpdate(ctx, r.Client, current, func() error {
+		if ts := current.GetCreationTimestamp(); !ts.IsZero() && current.GetLabels()[HMCManagedByTemplatesSourceLabelKey] != hmc.TemplateManagementName {
+			return fmt.Errorf("%s %s/%s already exists and is not managed by the templates source", kind, r.SystemNamespace, current.GetName())
+		}
+
+		labels := current.GetLabels()
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		for k, v := range desired.GetLabels() {
+			labels[k] = v
+		}
+		labels[HMCManagedByTemplatesSourceLabelKey] = hmc.TemplateManagementName
+		current.SetLabels(labels)
+
+		annotations := current.GetAnnotations()
+		for k, v := range desired.GetAnnotations() {
+			if annotations == nil {
+				annotations = make(map[string]string)
+			}
+			annotations[k] = v
+		}
+		current.SetAnnotations(annotations)
+
+		mutate()
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply %s %s/%s: %w", kind, r.SystemNamespace, desired.GetName(), err)
+	}
+	return nil
+}
+
+// prune deletes the templates previously applied from the source which are not kept.
+func (r *TemplatesSourceReconciler) prune(ctx context.Context, keep map[string]bool) error {
+	listOpts := []client.ListOption{
+		client.InNamespace(r.SystemNamespace),
+		client.MatchingLabels{HMCManagedByTemplatesSourceLabelKey: hmc.TemplateManagementName},
+	}
+
+	var applied []templateCommon
+	clusterTemplates := &hmc.ClusterTemplateList{}
+	if err := r.List(ctx, clusterTemplates, listOpts...); err != nil {
+		return fmt.Errorf("failed to list ClusterTemplates: %w", err)
+	}
+	for i := range clusterTemplates.Items {
+		applied = append(applied, &clusterTemplates.Items[i])
+	}
+	serviceTemplates := &hmc.ServiceTemplateList{}
+	if err := r.List(ctx, serviceTemplates, listOpts...); err != nil {
+		return fmt.Errorf("failed to list ServiceTemplates: %w", err)
+	}
+	for i := range serviceTemplates.Items {
+		applied = append(applied, &serviceTemplates.Items[i])
+	}
+
+	var errs error
+	for _, template := range applied {
+		kind := hmc.ClusterTemplateKind
+		if _, ok := template.(*hmc.ServiceTemplate); ok {
+			kind = hmc.ServiceTemplateKind
+		}
+		if keep[kind+"/"+template.GetName()] {
+			continue
+		}
+
+		ctrl.LoggerFrom(ctx).Info("Pruning template removed from the templates source", "kind", kind, "name", template.GetName())
+		if err := r.Delete(ctx, template); client.IgnoreNotFound(err) != nil {
+			errs = errors.Join(errs, fmt.Errorf("failed to delete %s %s/%s: %w", kind, r.SystemNamespace, template.GetName(), err))
+		}
+	}
+	return errs
+}
+
+// templatesFromArtifact reads the ClusterTemplates and ServiceTemplates from
+// the YAML and JSON manifests found under the given directory of the artifact
+// tarball. The other objects found in the manifests are ignored.
+func templatesFromArtifact(artifact io.Reader, dir string) ([]templateCommon, error) {
+	dir = strings.Trim(path.Clean("/"+dir), "/")
+
+	gzr, err := gzip.NewReader(artifact)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact: %w", err)
+	}
+	defer gzr.Close()
+
+	var (
+		templates []templateCommon
+		seen      = make(map[string]string)
+		tr        = tar.NewReader(gzr)
+	)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read artifact: %w", err)
+		}
+
+		name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		if hdr.Typeflag != tar.TypeReg || (dir != "" && !strings.HasPrefix(name, dir+"/")) {
+			continue
+		}
+		switch path.Ext(name) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+
+		fileTemplates, err := decodeTemplates(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", name, err)
+		}
+		for _, template := range fileTemplates {
+			key := template.GetObjectKind().GroupVersionKind().Kind + "/" + template.GetName()
+			if prev, ok := seen[key]; ok {
+				return nil, fmt.Errorf("%s %s is defined in both %s and %s", template.GetObjectKind().GroupVersionKind().Kind, template.GetName(), prev, name)
+			}
+			seen[key] = name
+			templates = append(templates, template)
+		}
+	}
+
+	return templates, nil
+}
+
+// decodeTemplates decodes the ClusterTemplates and ServiceTemplates from the multi-document manifest.
+func decodeTemplates(r io.Reader) ([]templateCommon, error) {
+	var templates []templateCommon
+
+	decoder := yamlutil.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+
+		gvk := obj.GroupVersionKind()
+		if gvk.Group != hmc.GroupVersion.Group {
+			continue
+		}
+
+		var template templateCommon
+		switch gvk.Kind {
+		case hmc.ClusterTemplateKind:
+			template = &hmc.ClusterTemplate{}
+		case hmc.ServiceTemplateKind:
+			template = &hmc.ServiceTemplate{}
+		default:
+			continue
+		}
+
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, template); err != nil {
+			return nil, fmt.Errorf("failed to convert %s %s: %w", gvk.Kind, obj.GetName(), err)
+		}
+		if template.GetName() == "" {
+			return nil, fmt.Errorf("%s has no name", gvk.Kind)
+		}
+		template.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{Group: hmc.GroupVersion.Group, Version: hmc.GroupVersion.Version, Kind: gvk.Kind})
+		templates = append(templates, template)
+	}
+
+	return templates, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *TemplatesSourceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("templatessource").
+		For(&hmc.TemplateManagement{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&sourcev1.GitRepository{},
+			handler.EnqueueRequestsFromMapFunc(func(_ context.Context, o client.Object) []ctrl.Request {
+				if o.GetNamespace() != r.SystemNamespace {
+					return nil
+				}
+				return []ctrl.Request{{NamespacedName: types.NamespacedName{Name: hmc.TemplateManagementName}}}
+			}),
+		).
+		Complete(r)
+}
@@ -0,0 +1,167 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	godigest "github.com/opencontainers/go-digest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmcmirantiscomv1alpha1 "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+var _ = Describe("Templates Source Controller", func() {
+	Context("When reading templates from an artifact", func() {
+		const templates = `apiVersion: hmc.mirantis.com/v1alpha1
+kind: ClusterTemplate
+metadata:
+  name: ct
+spec:
+  helm:
+    chartName: ct
+    chartVersion: 0.0.1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+---
+apiVersion: hmc.mirantis.com/v1alpha1
+kind: ServiceTemplate
+metadata:
+  name: st
+spec:
+  helm:
+    chartName: st
+    chartVersion: 0.0.1
+`
+
+		newArtifact := func(files map[string]string) *bytes.Buffer {
+			buf := new(bytes.Buffer)
+			gzw := gzip.NewWriter(buf)
+			tw := tar.NewWriter(gzw)
+			for name, content := range files {
+				Expect(tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg})).To(Succeed())
+				_, err := tw.Write([]byte(content))
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(tw.Close()).To(Succeed())
+			Expect(gzw.Close()).To(Succeed())
+			return buf
+		}
+
+		It("should read the templates under the given path only", func() {
+			artifact := newArtifact(map[string]string{
+				"catalog/templates.yaml": templates,
+				"catalog/README.md":      "not a manifest",
+				"other/templates.yaml":   templates,
+			})
+
+			result, err := templatesFromArtifact(artifact, "./catalog/")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(HaveLen(2))
+
+			ct, ok := result[0].(*hmcmirantiscomv1alpha1.ClusterTemplate)
+			Expect(ok).To(BeTrue())
+			Expect(ct.Name).To(Equal("ct"))
+			Expect(ct.Spec.Helm.ChartName).To(Equal("ct"))
+
+			st, ok := result[1].(*hmcmirantiscomv1alpha1.ServiceTemplate)
+			Expect(ok).To(BeTrue())
+			Expect(st.Name).To(Equal("st"))
+		})
+
+		It("should fail on the templates defined twice", func() {
+			artifact := newArtifact(map[string]string{
+				"a.yaml": templates,
+				"b.yaml": templates,
+			})
+
+			_, err := templatesFromArtifact(artifact, "")
+			Expect(err).To(MatchError(ContainSubstring("is defined in both")))
+		})
+
+		It("should not prune the templates once the artifact has no templates", func() {
+			ctx := context.Background()
+
+			var artifact []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write(artifact)
+			}))
+			DeferCleanup(server.Close)
+
+			repo := &sourcev1.GitRepository{ObjectMeta: metav1.ObjectMeta{Namespace: "hmc-system", Name: "catalog"}}
+			cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(repo).WithStatusSubresource(repo).Build()
+			r := &TemplatesSourceReconciler{Client: cl, SystemNamespace: "hmc-system"}
+			source := &hmcmirantiscomv1alpha1.TemplatesSource{GitRepository: "catalog", Path: "catalog", Prune: true}
+
+			publish := func(revision string, files map[string]string) {
+				artifact = newArtifact(files).Bytes()
+				repo.Status.Artifact = &sourcev1.Artifact{
+					URL:      server.URL,
+					Revision: revision,
+					Digest:   godigest.FromBytes(artifact).String(),
+				}
+				Expect(cl.Status().Update(ctx, repo)).To(Succeed())
+			}
+			appliedTemplates := func() []string {
+				var names []string
+				clusterTemplates := &hmcmirantiscomv1alpha1.ClusterTemplateList{}
+				Expect(cl.List(ctx, clusterTemplates, client.InNamespace("hmc-system"))).To(Succeed())
+				for _, ct := range clusterTemplates.Items {
+					names = append(names, ct.Name)
+				}
+				serviceTemplates := &hmcmirantiscomv1alpha1.ServiceTemplateList{}
+				Expect(cl.List(ctx, serviceTemplates, client.InNamespace("hmc-system"))).To(Succeed())
+				for _, st := range serviceTemplates.Items {
+					names = append(names, st.Name)
+				}
+				return names
+			}
+
+			publish("main@sha1:1", map[string]string{"catalog/templates.yaml": templates})
+			revision, err := r.sync(ctx, source)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revision).To(Equal("main@sha1:1"))
+			Expect(appliedTemplates()).To(ConsistOf("ct", "st"))
+
+			// the templates moved to another path are not pruned
+			publish("main@sha1:2", map[string]string{"templates/templates.yaml": templates})
+			_, err = r.sync(ctx, source)
+			Expect(err).To(MatchError(ContainSubstring("refusing to prune")))
+			Expect(appliedTemplates()).To(ConsistOf("ct", "st"))
+
+			// the templates removed from the artifact are pruned once templates are left in it
+			publish("main@sha1:3", map[string]string{"catalog/templates.yaml": templates[:strings.Index(templates, "---")]})
+			revision, err = r.sync(ctx, source)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revision).To(Equal("main@sha1:3"))
+			Expect(appliedTemplates()).To(ConsistOf("ct"))
+		})
+	})
+})
@@ -0,0 +1,234 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/audit"
+	"github.com/Mirantis/hmc/pkg/conditions"
+)
+
+// TemplateTestReconciler runs the TemplateTests: it periodically provisions a short-lived
+// ManagedCluster from the template of the test, waits for the cluster to become ready,
+// deletes it and records the result of the run in the history of the test.
+type TemplateTestReconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+	Shard    string
+}
+
+func (r *TemplateTestReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx = audit.WithActor(ctx, "templatetest")
+
+	test := &hmc.TemplateTest{}
+	if err := r.Get(ctx, req.NamespacedName, test); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// the run is recorded before its test cluster is created, the stale TemplateTest
+	// must not record the run again
+	patch := client.MergeFromWithOptions(test.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	result, err := r.reconcileRun(ctx, test)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.Status().Patch(ctx, test, patch); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update the status of TemplateTest %s: %w", req.NamespacedName, err)
+	}
+	return result, nil
+}
+
+func (r *TemplateTestReconciler) reconcileRun(ctx context.Context, test *hmc.TemplateTest) (ctrl.Result, error) {
+	if test.Status.CurrentRun != nil {
+		return r.reconcileCurrentRun(ctx, test)
+	}
+	if test.Spec.Suspend {
+		return ctrl.Result{}, nil
+	}
+	if next := test.NextRunTime(); time.Now().Before(next) {
+		return ctrl.Result{RequeueAfter: time.Until(next)}, nil
+	}
+	return r.startRun(test)
+}
+
+// startRun records the start of a new run. The test cluster of the run is named after the
+// number of the run and created once the run is recorded, a stale TemplateTest fails to record
+// the same run again on the conflict instead of creating another test cluster.
+func (r *TemplateTestReconciler) startRun(test *hmc.TemplateTest) (ctrl.Result, error) {
+	test.Status.ObservedGeneration = test.Generation
+	test.Status.RunNumber++
+	test.Status.CurrentRun = &hmc.TemplateTestRun{StartedAt: metav1.Now(), Template: test.Spec.Template}
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// createRunCluster creates the test cluster of the current run. The cluster created
+// by the previous reconcile of the run, whose creation failed to be recorded, is adopted.
+func (r *TemplateTestReconciler) createRunCluster(ctx context.Context, test *hmc.TemplateTest) (ctrl.Result, error) {
+	run := test.Status.CurrentRun
+
+	cluster := &hmc.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      test.RunClusterName(),
+			Namespace: test.Namespace,
+			Labels:    map[string]string{hmc.TemplateTestLabelKey: test.Name},
+		},
+		Spec: hmc.ManagedClusterSpec{
+			Config:     test.Spec.Config,
+			Template:   run.Template,
+			Credential: test.Spec.Credential,
+		},
+	}
+	if err := controllerutil.SetControllerReference(test, cluster, r.Scheme()); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to set the owner of the test cluster: %w", err)
+	}
+
+	err := r.Create(ctx, cluster)
+	switch {
+	case apierrors.IsAlreadyExists(err):
+		if err := r.Get(ctx, client.ObjectKeyFromObject(cluster), cluster); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to get the test cluster %s/%s: %w", cluster.Namespace, cluster.Name, err)
+		}
+		if !metav1.IsControlledBy(cluster, test) {
+			run.Result = hmc.TemplateTestResultFailed
+			run.Message = fmt.Sprintf("failed to create the test cluster: the ManagedCluster %s is not owned by the test", cluster.Name)
+			r.finishRun(test, *run)
+			return ctrl.Result{RequeueAfter: test.Spec.Interval.Duration}, nil
+		}
+	case apierrors.IsForbidden(err), apierrors.IsInvalid(err):
+		run.Result = hmc.TemplateTestResultFailed
+		run.Message = "failed to create the test cluster: " + err.Error()
+		r.finishRun(test, *run)
+		return ctrl.Result{RequeueAfter: test.Spec.Interval.Duration}, nil
+	case err != nil:
+		return ctrl.Result{}, fmt.Errorf("failed to create the test cluster of TemplateTest %s/%s: %w", test.Namespace, test.Name, err)
+	default:
+		ctrl.LoggerFrom(ctx).Info("Provisioning the test cluster", "cluster", cluster.Name, "template", run.Template)
+	}
+
+	run.Cluster = cluster.Name
+	return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, nil
+}
+
+// reconcileCurrentRun verifies the readiness of the test cluster of the current run within
+// the timeout of the test, then deletes the cluster and completes the run once the cluster is gone.
+func (r *TemplateTestReconciler) reconcileCurrentRun(ctx context.Context, test *hmc.TemplateTest) (ctrl.Result, error) {
+	run := test.Status.CurrentRun
+	if run.Cluster == "" {
+		return r.createRunCluster(ctx, test)
+	}
+
+	cluster := &hmc.ManagedCluster{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: test.Namespace, Name: run.Cluster}, cluster)
+	if client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get the test cluster %s/%s: %w", test.Namespace, run.Cluster, err)
+	}
+	deleted := apierrors.IsNotFound(err)
+
+	if run.Result == "" {
+		switch {
+		case deleted:
+			run.Result = hmc.TemplateTestResultFailed
+			run.Message = "the test cluster was deleted before it became ready"
+		case cluster.Status.Phase == hmc.ManagedClusterPhaseReady:
+			run.Result = hmc.TemplateTestResultPassed
+			run.Message = fmt.Sprintf("the test cluster became ready in %s", time.Since(run.StartedAt.Time).Round(time.Second))
+		case deploymentFailed(cluster):
+			// the cluster is not failed while the CAPI conditions are False during the provisioning
+			run.Result = hmc.TemplateTestResultFailed
+			run.Message = "the test cluster failed"
+			if issues := conditions.Issues(cluster.Status.Conditions, hmc.ReadyCondition); len(issues) > 0 {
+				run.Message += ": " + issues[0].Type + ": " + issues[0].Message
+			}
+		case time.Since(run.StartedAt.Time) > test.Spec.Timeout.Duration:
+			run.Result = hmc.TemplateTestResultFailed
+			run.Message = fmt.Sprintf("the test cluster is not ready within %s", test.Spec.Timeout.Duration)
+		default:
+			return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, nil
+		}
+		run.VerifiedAt = &metav1.Time{Time: time.Now()}
+	}
+
+	if !deleted {
+		if cluster.DeletionTimestamp.IsZero() {
+			ctrl.LoggerFrom(ctx).Info("Deleting the test cluster", "cluster", cluster.Name, "result", run.Result)
+			if err := r.Delete(ctx, cluster); client.IgnoreNotFound(err) != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to delete the test cluster %s/%s: %w", cluster.Namespace, cluster.Name, err)
+			}
+		}
+		if time.Since(run.VerifiedAt.Time) <= test.Spec.Timeout.Duration {
+			return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, nil
+		}
+		run.Result = hmc.TemplateTestResultFailed
+		run.Message += fmt.Sprintf("; the test cluster is not deleted within %s", test.Spec.Timeout.Duration)
+	}
+
+	r.finishRun(test, *run)
+	if test.Spec.Suspend {
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{RequeueAfter: max(time.Until(test.NextRunTime()), 0)}, nil
+}
+
+// finishRun records the completed run in the history of the test and reports its result in the Passed condition.
+func (r *TemplateTestReconciler) finishRun(test *hmc.TemplateTest, run hmc.TemplateTestRun) {
+	run.FinishedAt = &metav1.Time{Time: time.Now()}
+	test.AddHistoryEntry(run)
+	test.Status.CurrentRun = nil
+
+	condition := metav1.Condition{
+		Type:               hmc.TemplateTestPassedCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             hmc.SucceededReason,
+		Message:            run.Message,
+		ObservedGeneration: test.Generation,
+	}
+	if run.Result == hmc.TemplateTestResultFailed {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = hmc.FailedReason
+		r.Recorder.Eventf(test, corev1.EventTypeWarning, EventReasonTemplateTestFailed,
+			"The test of the ClusterTemplate %s failed: %s", run.Template, run.Message)
+	}
+	apimeta.SetStatusCondition(&test.Status.Conditions, condition)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *TemplateTestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	shards, err := newShardFilter(mgr, r.Shard)
+	if err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&hmc.TemplateTest{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&hmc.ManagedCluster{}).
+		Watches(shards.watchNamespaces(mgr.GetClient(), &hmc.TemplateTestList{})).
+		WithEventFilter(shards.predicate()).
+		Complete(r)
+}
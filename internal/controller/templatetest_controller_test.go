@@ -0,0 +1,190 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestTemplateTestStartRun(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	test := &hmc.TemplateTest{
+		ObjectMeta: metav1.ObjectMeta{Name: "smoke", Namespace: "default", UID: "smoke-uid", Generation: 1},
+		Spec: hmc.TemplateTestSpec{
+			Template:     "template-0-0-1",
+			Interval:     metav1.Duration{Duration: 24 * time.Hour},
+			Timeout:      metav1.Duration{Duration: time.Hour},
+			HistoryLimit: 10,
+		},
+	}
+	foreign := &hmc.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "smoke-2", Namespace: "default"}}
+
+	// stale is returned instead of the TemplateTest if set, the way the cache lags behind the status updates
+	var stale *hmc.TemplateTest
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(test, foreign).
+		WithStatusSubresource(&hmc.TemplateTest{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if tt, ok := obj.(*hmc.TemplateTest); ok && stale != nil {
+					stale.DeepCopyInto(tt)
+					return nil
+				}
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).Build()
+	r := &TemplateTestReconciler{Client: cl, Recorder: record.NewFakeRecorder(10)}
+
+	reconcile := func() (ctrl.Result, error) {
+		t.Helper()
+		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(test)})
+		g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(test), test)).To(Succeed())
+		return result, err
+	}
+	clusterNames := func() []string {
+		t.Helper()
+		clusters := &hmc.ManagedClusterList{}
+		g.Expect(cl.List(ctx, clusters, client.MatchingLabels{hmc.TemplateTestLabelKey: test.Name})).To(Succeed())
+		var names []string
+		for _, cluster := range clusters.Items {
+			names = append(names, cluster.Name)
+		}
+		return names
+	}
+
+	// the run is recorded before the test cluster is created
+	initial := test.DeepCopy()
+	result, err := reconcile()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.Requeue).To(BeTrue())
+	g.Expect(test.Status.RunNumber).To(BeEquivalentTo(1))
+	g.Expect(test.Status.CurrentRun).NotTo(BeNil())
+	g.Expect(test.Status.CurrentRun.Cluster).To(BeEmpty())
+	g.Expect(clusterNames()).To(BeEmpty())
+
+	// the stale TemplateTest fails to record the same run again
+	stale = initial
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(test)})
+	g.Expect(apierrors.IsConflict(err)).To(BeTrue(), "expected a conflict, got %v", err)
+	stale = nil
+
+	_, err = reconcile()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(test.Status.RunNumber).To(BeEquivalentTo(1))
+	g.Expect(test.Status.CurrentRun.Cluster).To(Equal("smoke-1"))
+	g.Expect(clusterNames()).To(Equal([]string{"smoke-1"}))
+
+	cluster := &hmc.ManagedCluster{}
+	g.Expect(cl.Get(ctx, client.ObjectKey{Namespace: "default", Name: "smoke-1"}, cluster)).To(Succeed())
+	g.Expect(metav1.IsControlledBy(cluster, test)).To(BeTrue())
+	g.Expect(cluster.Spec.Template).To(Equal("template-0-0-1"))
+
+	// the test cluster, whose creation failed to be recorded, is adopted
+	test.Status.CurrentRun.Cluster = ""
+	g.Expect(cl.Status().Update(ctx, test)).To(Succeed())
+	_, err = reconcile()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(test.Status.CurrentRun.Cluster).To(Equal("smoke-1"))
+	g.Expect(clusterNames()).To(Equal([]string{"smoke-1"}))
+
+	// the run fails if the name of its test cluster is taken
+	test.Status.CurrentRun = nil
+	test.Status.ObservedGeneration = 0
+	g.Expect(cl.Status().Update(ctx, test)).To(Succeed())
+	_, err = reconcile()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(test.Status.RunNumber).To(BeEquivalentTo(2))
+	_, err = reconcile()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(test.Status.CurrentRun).To(BeNil())
+	g.Expect(test.Status.History).To(HaveLen(1))
+	g.Expect(test.Status.History[0].Result).To(Equal(hmc.TemplateTestResultFailed))
+	g.Expect(test.Status.History[0].Message).To(Equal("failed to create the test cluster: the ManagedCluster smoke-2 is not owned by the test"))
+}
+
+func TestTemplateTestVerifyRun(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	test := &hmc.TemplateTest{
+		ObjectMeta: metav1.ObjectMeta{Name: "smoke", Namespace: "default", UID: "smoke-uid", Generation: 1},
+		Spec: hmc.TemplateTestSpec{
+			Template:     "template-0-0-1",
+			Interval:     metav1.Duration{Duration: 24 * time.Hour},
+			Timeout:      metav1.Duration{Duration: time.Hour},
+			HistoryLimit: 10,
+		},
+		Status: hmc.TemplateTestStatus{
+			ObservedGeneration: 1,
+			RunNumber:          1,
+			CurrentRun:         &hmc.TemplateTestRun{StartedAt: metav1.Now(), Template: "template-0-0-1", Cluster: "smoke-1"},
+		},
+	}
+	// the CAPI conditions are False while the machines of the test cluster are created
+	cluster := &hmc.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "smoke-1", Namespace: "default", Finalizers: []string{hmc.ManagedClusterFinalizer}},
+		Status: hmc.ManagedClusterStatus{
+			Conditions: []metav1.Condition{
+				{Type: "InfrastructureReady", Status: metav1.ConditionFalse, Reason: "WaitingForInfrastructure"},
+				{Type: hmc.ReadyCondition, Status: metav1.ConditionFalse, Reason: "WaitingForInfrastructure"},
+			},
+			History: []hmc.ManagedClusterHistoryEntry{{Template: "template-0-0-1", Outcome: hmc.ProgressingReason}},
+		},
+	}
+	cluster.Status.Phase = computePhase(cluster)
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(test, cluster).
+		WithStatusSubresource(&hmc.TemplateTest{}, &hmc.ManagedCluster{}).Build()
+	r := &TemplateTestReconciler{Client: cl, Recorder: record.NewFakeRecorder(10)}
+
+	reconcile := func() {
+		t.Helper()
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(test)})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(test), test)).To(Succeed())
+		g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(cluster), cluster)).To(Succeed())
+	}
+
+	// the run goes on while the cluster is being provisioned
+	reconcile()
+	g.Expect(test.Status.CurrentRun).NotTo(BeNil())
+	g.Expect(test.Status.CurrentRun.Result).To(BeEmpty())
+	g.Expect(cluster.DeletionTimestamp.IsZero()).To(BeTrue())
+
+	// the failed deployment fails the run and the test cluster is deleted
+	cluster.Status.History[0].Outcome = hmc.FailedReason
+	cluster.Status.Phase = computePhase(cluster)
+	g.Expect(cl.Status().Update(ctx, cluster)).To(Succeed())
+	reconcile()
+	g.Expect(test.Status.CurrentRun).NotTo(BeNil())
+	g.Expect(test.Status.CurrentRun.Result).To(Equal(hmc.TemplateTestResultFailed))
+	g.Expect(test.Status.CurrentRun.Message).To(HavePrefix("the test cluster failed"))
+	g.Expect(cluster.DeletionTimestamp.IsZero()).To(BeFalse())
+}
@@ -0,0 +1,310 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/audit"
+)
+
+// UpgradeGroupReconciler upgrades the ManagedClusters selected by an UpgradeGroup to its template
+// batch by batch. The next batch starts once the clusters of the current one are deployed with the
+// template and ready, the upgrade is halted once more clusters failed than the UpgradeGroup allows.
+type UpgradeGroupReconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+	Shard    string
+}
+
+func (r *UpgradeGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := ctrl.LoggerFrom(ctx)
+	ctx = audit.WithActor(ctx, "upgradegroup")
+
+	group := &hmc.UpgradeGroup{}
+	if err := r.Get(ctx, req.NamespacedName, group); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&group.Spec.ClusterSelector)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("invalid cluster selector of UpgradeGroup %s: %w", req.NamespacedName, err)
+	}
+	clusters := &hmc.ManagedClusterList{}
+	if err := r.List(ctx, clusters, client.InNamespace(group.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list ManagedClusters: %w", err)
+	}
+	slices.SortFunc(clusters.Items, func(a, b hmc.ManagedCluster) int { return strings.Compare(a.Name, b.Name) })
+
+	patch := client.MergeFrom(group.DeepCopy())
+	if group.Status.ObservedGeneration != group.Generation {
+		// the upgrade starts over once the spec changes, e.g. to resume the halted upgrade
+		group.Status.Phase = hmc.UpgradeGroupPhaseProgressing
+		group.Status.Batch = 0
+		group.Status.BatchStartedAt = nil
+		group.Status.Clusters = nil
+		group.Status.ObservedGeneration = group.Generation
+	}
+
+	previous := make(map[string]hmc.ClusterUpgradeStatus, len(group.Status.Clusters))
+	for _, cluster := range group.Status.Clusters {
+		previous[cluster.Name] = cluster
+	}
+
+	claimed, err := r.claimedClusters(ctx, group)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	statuses := make([]hmc.ClusterUpgradeStatus, len(clusters.Items))
+	for i, cluster := range clusters.Items {
+		if owner, ok := claimed[cluster.Name]; ok {
+			statuses[i] = hmc.ClusterUpgradeStatus{
+				Name:    cluster.Name,
+				Phase:   hmc.ClusterUpgradePhaseSkipped,
+				Message: "the cluster is upgraded by the UpgradeGroup " + owner,
+			}
+			continue
+		}
+		statuses[i] = r.clusterUpgradeStatus(group, &cluster, previous[cluster.Name])
+	}
+
+	upgrading := slices.ContainsFunc(statuses, func(s hmc.ClusterUpgradeStatus) bool {
+		return s.Phase == hmc.ClusterUpgradePhaseUpgrading
+	})
+	if upgrading && group.Status.BatchStartedAt == nil {
+		// the clusters already using the template once the upgrade starts make up the first batch
+		group.Status.Batch++
+		group.Status.BatchStartedAt = &metav1.Time{Time: time.Now()}
+	}
+
+	if !r.halted(group, statuses) && !upgrading {
+		// the clusters of the current batch are done, the next batch is started
+		batch := group.BatchClusters(len(statuses))
+		started := 0
+		for i := range statuses {
+			if started == batch {
+				break
+			}
+			if statuses[i].Phase != hmc.ClusterUpgradePhasePending {
+				continue
+			}
+			started++
+
+			cluster := &clusters.Items[i]
+			cluster.Spec.Template = group.Spec.Template
+			if err := r.Update(ctx, cluster); err != nil {
+				if !apierrors.IsForbidden(err) && !apierrors.IsInvalid(err) {
+					return ctrl.Result{}, fmt.Errorf("failed to upgrade ManagedCluster %s/%s to ClusterTemplate %s: %w", cluster.Namespace, cluster.Name, group.Spec.Template, err)
+				}
+				statuses[i].Phase = hmc.ClusterUpgradePhaseFailed
+				statuses[i].Message = err.Error()
+				continue
+			}
+			l.Info("Upgrading ManagedCluster", "cluster", cluster.Name, "template", group.Spec.Template)
+			statuses[i].Phase = hmc.ClusterUpgradePhaseUpgrading
+		}
+
+		if started > 0 {
+			group.Status.Phase = hmc.UpgradeGroupPhaseProgressing
+			group.Status.Batch++
+			group.Status.BatchStartedAt = &metav1.Time{Time: time.Now()}
+		} else {
+			group.Status.Phase = hmc.UpgradeGroupPhaseCompleted
+			group.Status.BatchStartedAt = nil
+		}
+	}
+	group.Status.Clusters = statuses
+	r.halted(group, statuses)
+
+	if err := r.Status().Patch(ctx, group, patch); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update the status of UpgradeGroup %s: %w", req.NamespacedName, err)
+	}
+
+	if group.Status.Phase != hmc.UpgradeGroupPhaseProgressing {
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, nil
+}
+
+// clusterUpgradeStatus returns the state of the upgrade of the cluster to the template of the UpgradeGroup.
+// The cluster is upgraded once the last deployment of the template succeeded and the health gates
+// of the cluster are met. The failed clusters remain failed until the spec of the UpgradeGroup changes.
+func (*UpgradeGroupReconciler) clusterUpgradeStatus(group *hmc.UpgradeGroup, cluster *hmc.ManagedCluster, previous hmc.ClusterUpgradeStatus) hmc.ClusterUpgradeStatus {
+	status := hmc.ClusterUpgradeStatus{Name: cluster.Name, Phase: hmc.ClusterUpgradePhasePending}
+	switch {
+	case previous.Phase == hmc.ClusterUpgradePhaseFailed:
+		return previous
+	case cluster.Spec.Template != group.Spec.Template:
+		return status
+	}
+
+	status.Phase = hmc.ClusterUpgradePhaseUpgrading
+	if len(cluster.Status.History) > 0 && cluster.Status.ObservedGeneration >= cluster.Generation {
+		last := cluster.Status.History[len(cluster.Status.History)-1]
+		switch {
+		case last.Template != group.Spec.Template:
+		case last.Outcome == hmc.FailedReason:
+			status.Phase = hmc.ClusterUpgradePhaseFailed
+			status.Message = last.Message
+			return status
+		case last.Outcome == hmc.SucceededReason:
+			unhealthy := unmetHealthGates(cluster)
+			if len(unhealthy) == 0 {
+				status.Phase = hmc.ClusterUpgradePhaseUpgraded
+				return status
+			}
+			status.Message = "waiting for the conditions " + strings.Join(unhealthy, ", ")
+		}
+	}
+
+	if group.Status.BatchStartedAt != nil &&
+		time.Since(group.Status.BatchStartedAt.Time) > group.Spec.BatchTimeout.Duration {
+		status.Phase = hmc.ClusterUpgradePhaseFailed
+		status.Message = fmt.Sprintf("the cluster is not ready on the ClusterTemplate %s within %s", group.Spec.Template, group.Spec.BatchTimeout.Duration)
+	}
+	return status
+}
+
+// unmetHealthGates returns the types of the conditions of the ManagedCluster gating the upgrade
+// which are not True: the Ready and the HelmReleaseReady conditions and the readiness gates
+// of the cluster. The Ready condition alone may be stale until the status of the cluster is synced.
+func unmetHealthGates(cluster *hmc.ManagedCluster) []string {
+	gates := []string{hmc.ReadyCondition, hmc.HelmReleaseReadyCondition}
+	for _, gate := range cluster.Spec.ReadinessGates {
+		gates = append(gates, gate.ConditionType)
+	}
+
+	var unmet []string
+	for _, gate := range gates {
+		if !apimeta.IsStatusConditionTrue(cluster.Status.Conditions, gate) && !slices.Contains(unmet, gate) {
+			unmet = append(unmet, gate)
+		}
+	}
+	return unmet
+}
+
+// claimedClusters returns the names of the ManagedClusters selected by the UpgradeGroup which
+// are selected by the UpgradeGroups preceding it as well, mapped to the name of the group
+// upgrading them. The admission webhook rejects the overlapping groups, yet the clusters
+// may be labeled to match several groups afterwards.
+func (r *UpgradeGroupReconciler) claimedClusters(ctx context.Context, group *hmc.UpgradeGroup) (map[string]string, error) {
+	groups := &hmc.UpgradeGroupList{}
+	if err := r.List(ctx, groups, client.InNamespace(group.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list UpgradeGroups: %w", err)
+	}
+	slices.SortFunc(groups.Items, func(a, b hmc.UpgradeGroup) int {
+		if a.Precedes(&b) {
+			return -1
+		}
+		return 1
+	})
+
+	claimed := make(map[string]string)
+	for _, other := range groups.Items {
+		if other.Name == group.Name || !other.Precedes(group) || !other.DeletionTimestamp.IsZero() {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&other.Spec.ClusterSelector)
+		if err != nil {
+			continue
+		}
+		clusters := &hmc.ManagedClusterList{}
+		if err := r.List(ctx, clusters, client.InNamespace(group.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return nil, fmt.Errorf("failed to list ManagedClusters: %w", err)
+		}
+		for _, cluster := range clusters.Items {
+			if _, ok := claimed[cluster.Name]; !ok {
+				claimed[cluster.Name] = other.Name
+			}
+		}
+	}
+	return claimed, nil
+}
+
+// halted sets the UpgradeHalted condition of the UpgradeGroup and returns true
+// if more clusters failed to upgrade than the UpgradeGroup allows.
+func (r *UpgradeGroupReconciler) halted(group *hmc.UpgradeGroup, statuses []hmc.ClusterUpgradeStatus) bool {
+	var failed []string
+	for _, status := range statuses {
+		if status.Phase == hmc.ClusterUpgradePhaseFailed {
+			failed = append(failed, status.Name)
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:               hmc.UpgradeHaltedCondition,
+		Status:             metav1.ConditionFalse,
+		Reason:             hmc.WithinFailureBudgetReason,
+		ObservedGeneration: group.Generation,
+	}
+	if len(failed) > int(group.Spec.MaxFailures) {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = hmc.FailuresExceededReason
+		condition.Message = fmt.Sprintf("%d clusters failed to upgrade, %d allowed: %s", len(failed), group.Spec.MaxFailures, strings.Join(failed, ", "))
+		group.Status.Phase = hmc.UpgradeGroupPhaseHalted
+	}
+	if apimeta.SetStatusCondition(&group.Status.Conditions, condition) && condition.Status == metav1.ConditionTrue {
+		r.Recorder.Eventf(group, corev1.EventTypeWarning, EventReasonUpgradeHalted,
+			"The upgrade to the ClusterTemplate %s is halted: %s", group.Spec.Template, condition.Message)
+	}
+	return condition.Status == metav1.ConditionTrue
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *UpgradeGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	shards, err := newShardFilter(mgr, r.Shard)
+	if err != nil {
+		return err
+	}
+
+	namespaceGroups := handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, o client.Object) []ctrl.Request {
+		groups := &hmc.UpgradeGroupList{}
+		if err := mgr.GetClient().List(ctx, groups, client.InNamespace(o.GetNamespace())); err != nil {
+			return nil
+		}
+
+		requests := make([]ctrl.Request, 0, len(groups.Items))
+		for _, group := range groups.Items {
+			requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&group)})
+		}
+		return requests
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&hmc.UpgradeGroup{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&hmc.ManagedCluster{}, namespaceGroups).
+		// the clusters skipped by a group are upgraded by it once the preceding group no longer selects them
+		Watches(&hmc.UpgradeGroup{}, namespaceGroups, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(shards.watchNamespaces(mgr.GetClient(), &hmc.UpgradeGroupList{})).
+		WithEventFilter(shards.predicate()).
+		Complete(r)
+}
@@ -0,0 +1,120 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestUpgradeGroupReconcile(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	const target = "template-0-0-2"
+
+	created := metav1.NewTime(time.Now().Truncate(time.Second))
+	newGroup := func(name string, age time.Duration, labels map[string]string) *hmc.UpgradeGroup {
+		return &hmc.UpgradeGroup{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", CreationTimestamp: metav1.NewTime(created.Add(-age))},
+			Spec: hmc.UpgradeGroupSpec{
+				ClusterSelector: metav1.LabelSelector{MatchLabels: labels},
+				Template:        target,
+				BatchTimeout:    metav1.Duration{Duration: time.Hour},
+			},
+		}
+	}
+	newCluster := func(name string, labels map[string]string) *hmc.ManagedCluster {
+		mc := managedcluster.NewManagedCluster(managedcluster.WithName(name), managedcluster.WithNamespace("default"),
+			managedcluster.WithClusterTemplate("template-0-0-1"), managedcluster.WithReadinessGates("ServicesReady"))
+		mc.Labels = labels
+		return mc
+	}
+
+	// the cluster b is selected by both of the groups, the group created first upgrades it
+	first, second := newGroup("first", time.Minute, map[string]string{"env": "prod"}), newGroup("second", 0, map[string]string{"tier": "web"})
+	a, b, c := newCluster("a", map[string]string{"env": "prod"}),
+		newCluster("b", map[string]string{"env": "prod", "tier": "web"}),
+		newCluster("c", map[string]string{"tier": "web"})
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(first, second, a, b, c).
+		WithStatusSubresource(&hmc.UpgradeGroup{}, &hmc.ManagedCluster{}).Build()
+	r := &UpgradeGroupReconciler{Client: cl, Recorder: record.NewFakeRecorder(10)}
+
+	reconcile := func(group *hmc.UpgradeGroup) {
+		t.Helper()
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(group)})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(group), group)).To(Succeed())
+	}
+
+	reconcile(second)
+	g.Expect(second.Status.Clusters).To(Equal([]hmc.ClusterUpgradeStatus{
+		{Name: "b", Phase: hmc.ClusterUpgradePhaseSkipped, Message: "the cluster is upgraded by the UpgradeGroup first"},
+		{Name: "c", Phase: hmc.ClusterUpgradePhaseUpgrading},
+	}))
+	g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(b), b)).To(Succeed())
+	g.Expect(b.Spec.Template).To(Equal("template-0-0-1"))
+
+	// the cluster is not upgraded until all of its health gates are met
+	g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(c), c)).To(Succeed())
+	c.Status.ObservedGeneration = c.Generation
+	c.Status.Phase = hmc.ManagedClusterPhaseReady
+	c.Status.History = []hmc.ManagedClusterHistoryEntry{{Template: target, Outcome: hmc.SucceededReason}}
+	for _, condition := range []string{hmc.ReadyCondition, hmc.HelmReleaseReadyCondition} {
+		apimeta.SetStatusCondition(&c.Status.Conditions, metav1.Condition{Type: condition, Status: metav1.ConditionTrue, Reason: hmc.SucceededReason})
+	}
+	g.Expect(cl.Status().Update(ctx, c)).To(Succeed())
+
+	reconcile(second)
+	g.Expect(second.Status.Phase).To(Equal(hmc.UpgradeGroupPhaseProgressing))
+	g.Expect(second.Status.Clusters[1]).To(Equal(hmc.ClusterUpgradeStatus{
+		Name: "c", Phase: hmc.ClusterUpgradePhaseUpgrading, Message: "waiting for the conditions ServicesReady",
+	}))
+
+	apimeta.SetStatusCondition(&c.Status.Conditions, metav1.Condition{Type: "ServicesReady", Status: metav1.ConditionTrue, Reason: hmc.SucceededReason})
+	g.Expect(cl.Status().Update(ctx, c)).To(Succeed())
+
+	reconcile(second)
+	g.Expect(second.Status.Phase).To(Equal(hmc.UpgradeGroupPhaseCompleted))
+	g.Expect(second.Status.Clusters[1].Phase).To(Equal(hmc.ClusterUpgradePhaseUpgraded))
+
+	// the group created first upgrades the clusters it selects
+	reconcile(first)
+	g.Expect(first.Status.Clusters).To(Equal([]hmc.ClusterUpgradeStatus{
+		{Name: "a", Phase: hmc.ClusterUpgradePhaseUpgrading},
+		{Name: "b", Phase: hmc.ClusterUpgradePhasePending},
+	}))
+
+	// the cluster is upgraded by the next group once the preceding one is deleted
+	g.Expect(cl.Delete(ctx, first)).To(Succeed())
+	reconcile(second)
+	g.Expect(second.Status.Clusters[0]).To(Equal(hmc.ClusterUpgradeStatus{Name: "b", Phase: hmc.ClusterUpgradePhaseUpgrading}))
+	g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(b), b)).To(Succeed())
+	g.Expect(b.Spec.Template).To(Equal(target))
+}
@@ -0,0 +1,67 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/discovery"
+
+	"github.com/Mirantis/hmc/internal/velero"
+)
+
+// veleroCheckInterval is the interval between the checks of the Velero API
+// availability while Velero is not installed.
+const veleroCheckInterval = time.Minute
+
+// veleroNotInstalledMessage is reported in the status of the management
+// backups and restores while Velero is not installed.
+var veleroNotInstalledMessage = fmt.Sprintf("Velero is not installed, the %s API is not served", velero.ScheduleGVK.GroupVersion())
+
+// veleroWatches starts the watches of the Velero objects once the Velero API
+// is served. Velero may be installed after HMC, so the API is discovered on
+// the reconciliations until the watches are started.
+type veleroWatches struct {
+	// start starts the watches, nil if there is nothing to watch.
+	start func() error
+
+	mu      sync.Mutex
+	started bool
+}
+
+// ensure starts the watches if the Velero API is served and returns whether it is.
+func (w *veleroWatches) ensure(dc discovery.DiscoveryInterface) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.started {
+		return true, nil
+	}
+
+	installed, err := velero.IsInstalled(dc)
+	if err != nil || !installed {
+		return false, err
+	}
+
+	if w.start != nil {
+		if err := w.start(); err != nil {
+			return false, fmt.Errorf("failed to watch the Velero objects: %w", err)
+		}
+	}
+	w.started = true
+	return true, nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credspropagation
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// PropagateAutoscalerKubeconfig writes the kubeconfig of the management cluster
+// cluster-autoscaler deployed on the managed cluster scales the worker pools with.
+func PropagateAutoscalerKubeconfig(ctx context.Context, cfg *PropagationCfg, kubeconfig []byte) error {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: hmc.AutoscalerNamespace}}
+	namespace.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Namespace"))
+
+	secret := makeSecret(hmc.AutoscalerKubeconfigSecretName, hmc.AutoscalerNamespace, map[string][]byte{"value": kubeconfig})
+	if err := applyCCMConfigs(ctx, cfg, namespace, secret); err != nil {
+		return fmt.Errorf("failed to apply cluster-autoscaler configs: %w", err)
+	}
+	return nil
+}
+
+// RemoveAutoscalerKubeconfig removes the Secret written by PropagateAutoscalerKubeconfig.
+func RemoveAutoscalerKubeconfig(ctx context.Context, cfg *PropagationCfg) error {
+	clnt, err := makeClientFromSecret(cfg.KubeconfSecret)
+	if err != nil {
+		return fmt.Errorf("failed to create k8s client: %w", err)
+	}
+
+	key := client.ObjectKey{Namespace: hmc.AutoscalerNamespace, Name: hmc.AutoscalerKubeconfigSecretName}
+	if err := deleteSecret(ctx, cfg, clnt, key); err != nil {
+		return fmt.Errorf("failed to delete cluster-autoscaler kubeconfig Secret: %w", err)
+	}
+	return nil
+}
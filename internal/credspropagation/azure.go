@@ -55,7 +55,7 @@ func PropagateAzureSecrets(ctx context.Context, cfg *PropagationCfg) error {
 		return fmt.Errorf("failed to generate Azure CCM secret: %s", err)
 	}
 
-	if err := applyCCMConfigs(ctx, cfg.KubeconfSecret, ccmSecret); err != nil {
+	if err := applyCCMConfigs(ctx, cfg, ccmSecret); err != nil {
 		return fmt.Errorf("failed to apply Azure CCM secret: %s", err)
 	}
 
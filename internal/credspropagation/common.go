@@ -26,6 +26,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/audit"
 )
 
 type PropagationCfg struct {
@@ -33,26 +34,58 @@ type PropagationCfg struct {
 	ManagedCluster  *hmc.ManagedCluster
 	KubeconfSecret  *corev1.Secret
 	SystemNamespace string
+	// SecretWriter writes the Secrets to the managed cluster, defaults to ApplySecretWriter.
+	SecretWriter SecretWriter
 }
 
-func applyCCMConfigs(ctx context.Context, kubeconfSecret *corev1.Secret, objects ...client.Object) error {
-	clnt, err := makeClientFromSecret(kubeconfSecret)
+// Scope returns the scope of the Secrets written for the ManagedCluster.
+func Scope(managedCluster *hmc.ManagedCluster) string {
+	return managedCluster.Namespace + "/" + managedCluster.Name
+}
+
+func applyCCMConfigs(ctx context.Context, cfg *PropagationCfg, objects ...client.Object) error {
+	clnt, err := makeClientFromSecret(cfg.KubeconfSecret)
 	if err != nil {
 		return fmt.Errorf("failed to create k8s client: %w", err)
 	}
+
+	writer := cfg.SecretWriter
+	if writer == nil {
+		writer = ApplySecretWriter{}
+	}
+
+	// the propagation is only recorded once the object changes, not on every reconciliation
 	for _, object := range objects {
-		if err := clnt.Patch(
-			ctx,
-			object,
-			client.Apply,
-			client.FieldOwner("hmc-controller"),
-		); err != nil {
+		if secret, ok := object.(*corev1.Secret); ok {
+			changed, err := writer.Write(ctx, clnt, Scope(cfg.ManagedCluster), secret)
+			if err != nil {
+				return fmt.Errorf("failed to write CCM secret %s: %w", secret.GetName(), err)
+			}
+			if changed {
+				audit.Record(ctx, audit.ActionPropagate, secret, "")
+			}
+			continue
+		}
+		changed, err := applyObject(ctx, clnt, object, client.FieldOwner(fieldOwner))
+		if err != nil {
 			return fmt.Errorf("failed to apply CCM config object %s: %w", object.GetName(), err)
 		}
+		if changed {
+			audit.Record(ctx, audit.ActionPropagate, object, "")
+		}
 	}
 	return nil
 }
 
+// deleteSecret deletes the Secret written to the managed cluster with the writer of the config.
+func deleteSecret(ctx context.Context, cfg *PropagationCfg, clnt client.Client, key client.ObjectKey) error {
+	writer := cfg.SecretWriter
+	if writer == nil {
+		writer = ApplySecretWriter{}
+	}
+	return writer.Delete(ctx, clnt, Scope(cfg.ManagedCluster), key)
+}
+
 func makeSecret(name, namespace string, data map[string][]byte) *corev1.Secret {
 	s := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,87 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credspropagation
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// externalDNSHostnameAnnotation tells external-dns the DNS name of the Service.
+const externalDNSHostnameAnnotation = "external-dns.alpha.kubernetes.io/hostname"
+
+// PropagateDNSConfigs writes the objects external-dns deployed on the managed cluster relies on:
+// the copy of the Secret with the credentials of the DNS provider, if given, and the ExternalName
+// Service publishing the endpointName of the control plane endpoint host, if the host is known.
+func PropagateDNSConfigs(ctx context.Context, cfg *PropagationCfg, credentials *corev1.Secret, endpointName, endpointHost string) error {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: hmc.DNSNamespace}}
+	namespace.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Namespace"))
+
+	objects := []client.Object{namespace}
+	if credentials != nil {
+		objects = append(objects, makeSecret(hmc.DNSCredentialsSecretName, hmc.DNSNamespace, credentials.Data))
+	}
+	if endpointHost != "" {
+		objects = append(objects, makeEndpointService(endpointName, endpointHost))
+	}
+
+	if err := applyCCMConfigs(ctx, cfg, objects...); err != nil {
+		return fmt.Errorf("failed to apply external-dns configs: %w", err)
+	}
+	return nil
+}
+
+// RemoveDNSConfigs removes the objects written by PropagateDNSConfigs, the namespace is kept.
+func RemoveDNSConfigs(ctx context.Context, cfg *PropagationCfg) error {
+	clnt, err := makeClientFromSecret(cfg.KubeconfSecret)
+	if err != nil {
+		return fmt.Errorf("failed to create k8s client: %w", err)
+	}
+
+	key := client.ObjectKey{Namespace: hmc.DNSNamespace, Name: hmc.DNSCredentialsSecretName}
+	if err := deleteSecret(ctx, cfg, clnt, key); err != nil {
+		return fmt.Errorf("failed to delete external-dns credentials Secret: %w", err)
+	}
+
+	service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: hmc.DNSNamespace, Name: hmc.DNSEndpointServiceName}}
+	if err := clnt.Delete(ctx, service); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete external-dns endpoint Service: %w", err)
+	}
+	return nil
+}
+
+// makeEndpointService returns the ExternalName Service external-dns creates the record of
+// the control plane endpoint from, the A record for an IP and the CNAME record otherwise.
+func makeEndpointService(endpointName, endpointHost string) *corev1.Service {
+	s := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        hmc.DNSEndpointServiceName,
+			Namespace:   hmc.DNSNamespace,
+			Annotations: map[string]string{externalDNSHostnameAnnotation: endpointName},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:         corev1.ServiceTypeExternalName,
+			ExternalName: endpointHost,
+		},
+	}
+	s.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Service"))
+	return s
+}
@@ -0,0 +1,283 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credspropagation
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"github.com/Mirantis/hmc/internal/utils"
+)
+
+const fieldOwner = "hmc-controller"
+
+// SecretWriter writes the Secrets propagated by HMC, e.g. the CCM credentials
+// and the kubeconfig copies. The scope identifies the owner of the Secret,
+// e.g. the ManagedCluster, and keeps apart the Secrets of different owners
+// with the same name in the external stores.
+type SecretWriter interface {
+	// Write ensures the given Secret with the client and returns true
+	// if the Secret, or the objects it is written with, changed.
+	Write(ctx context.Context, c client.Client, scope string, secret *corev1.Secret) (bool, error)
+	// Delete removes the Secret with the given key.
+	Delete(ctx context.Context, c client.Client, scope string, key client.ObjectKey) error
+}
+
+// ApplySecretWriter writes the Secrets as is with the server-side apply.
+type ApplySecretWriter struct{}
+
+var _ SecretWriter = ApplySecretWriter{}
+
+// Write implements SecretWriter. The ExternalSecret written for the Secret before
+// the secret store is switched is removed first along with the Secret created from it.
+// The fields of the Secret owned by other field managers are not taken over, the
+// conflicting Secret fails the write.
+func (ApplySecretWriter) Write(ctx context.Context, c client.Client, _ string, secret *corev1.Secret) (bool, error) {
+	if err := removeExternalSecret(ctx, c, client.ObjectKeyFromObject(secret)); err != nil {
+		return false, err
+	}
+
+	secret = secret.DeepCopy()
+	secret.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+	secret.ManagedFields = nil
+	secret.ResourceVersion = ""
+	return applyObject(ctx, c, secret, client.FieldOwner(fieldOwner))
+}
+
+// Delete implements SecretWriter.
+func (ApplySecretWriter) Delete(ctx context.Context, c client.Client, _ string, key client.ObjectKey) error {
+	secret := &corev1.Secret{}
+	secret.Namespace, secret.Name = key.Namespace, key.Name
+	return client.IgnoreNotFound(c.Delete(ctx, secret))
+}
+
+// SecretStore is an external store of the secret data.
+type SecretStore interface {
+	// Put stores the data under the given key and returns true if the stored data changed.
+	Put(ctx context.Context, key string, data map[string]string) (bool, error)
+	// Remove removes the data with the given key.
+	Remove(ctx context.Context, key string) error
+}
+
+// ExternalSecretGVK is the GroupVersionKind of the ExternalSecrets of the External Secrets Operator.
+var ExternalSecretGVK = schema.GroupVersionKind{Group: "external-secrets.io", Version: "v1beta1", Kind: "ExternalSecret"}
+
+// ExternalSecretWriter writes the data of the Secrets to the external store
+// and creates the ExternalSecrets of the External Secrets Operator instead
+// of the Secrets. The operator reads the data from the store with the given
+// ClusterSecretStore and creates the Secrets, so the data is not kept in the
+// objects created by HMC.
+type ExternalSecretWriter struct {
+	// Store is the store the data is written to.
+	Store SecretStore
+	// ClusterSecretStore is the name of the ClusterSecretStore reading the Store.
+	ClusterSecretStore string
+	// KeyPrefix is prepended to the keys of the data in the store.
+	KeyPrefix string
+	// RefreshInterval is the refresh interval of the ExternalSecrets, defaults to 1h.
+	RefreshInterval string
+}
+
+var _ SecretWriter = (*ExternalSecretWriter)(nil)
+
+// Write implements SecretWriter. The Secret written as is before the secret
+// store is switched is removed, so it is created from the ExternalSecret.
+func (w *ExternalSecretWriter) Write(ctx context.Context, c client.Client, scope string, secret *corev1.Secret) (bool, error) {
+	key := w.key(scope, client.ObjectKeyFromObject(secret))
+
+	// the values are encoded since the store keeps the strings only
+	data := make(map[string]string, len(secret.Data)+len(secret.StringData))
+	for k, v := range secret.Data {
+		data[k] = base64.StdEncoding.EncodeToString(v)
+	}
+	for k, v := range secret.StringData {
+		data[k] = base64.StdEncoding.EncodeToString([]byte(v))
+	}
+	dataChanged, err := w.Store.Put(ctx, key, data)
+	if err != nil {
+		return false, fmt.Errorf("failed to write the data of Secret %s/%s to the store: %w", secret.Namespace, secret.Name, err)
+	}
+
+	refreshInterval := w.RefreshInterval
+	if refreshInterval == "" {
+		refreshInterval = "1h"
+	}
+
+	target := map[string]any{
+		"name":           secret.Name,
+		"creationPolicy": "Owner",
+		"deletionPolicy": "Delete",
+	}
+	templateMeta := make(map[string]any)
+	if len(secret.Labels) > 0 {
+		templateMeta["labels"] = toAnyMap(secret.Labels)
+	}
+	if len(secret.Annotations) > 0 {
+		templateMeta["annotations"] = toAnyMap(secret.Annotations)
+	}
+	template := map[string]any{"metadata": templateMeta}
+	if secret.Type != "" {
+		template["type"] = string(secret.Type)
+	}
+	target["template"] = template
+
+	externalSecret := &unstructured.Unstructured{}
+	externalSecret.SetGroupVersionKind(ExternalSecretGVK)
+	externalSecret.SetNamespace(secret.Namespace)
+	externalSecret.SetName(secret.Name)
+	externalSecret.SetLabels(secret.Labels)
+	externalSecret.SetOwnerReferences(secret.OwnerReferences)
+	externalSecret.Object["spec"] = map[string]any{
+		"refreshInterval": refreshInterval,
+		"secretStoreRef": map[string]any{
+			"kind": "ClusterSecretStore",
+			"name": w.ClusterSecretStore,
+		},
+		"target": target,
+		"dataFrom": []any{
+			map[string]any{
+				"extract": map[string]any{
+					"key":              key,
+					"decodingStrategy": "Base64",
+				},
+			},
+		},
+	}
+
+	if err := removeAppliedSecret(ctx, c, client.ObjectKeyFromObject(secret)); err != nil {
+		return false, err
+	}
+	changed, err := applyObject(ctx, c, externalSecret, client.FieldOwner(fieldOwner), client.ForceOwnership)
+	if err != nil {
+		return false, fmt.Errorf("failed to apply ExternalSecret %s/%s: %w", secret.Namespace, secret.Name, err)
+	}
+	return dataChanged || changed, nil
+}
+
+// Delete implements SecretWriter.
+func (w *ExternalSecretWriter) Delete(ctx context.Context, c client.Client, scope string, key client.ObjectKey) error {
+	externalSecret := &unstructured.Unstructured{}
+	externalSecret.SetGroupVersionKind(ExternalSecretGVK)
+	externalSecret.SetNamespace(key.Namespace)
+	externalSecret.SetName(key.Name)
+	if err := c.Delete(ctx, externalSecret); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete ExternalSecret %s: %w", key, err)
+	}
+
+	if err := w.Store.Remove(ctx, w.key(scope, key)); err != nil {
+		return fmt.Errorf("failed to remove the data of Secret %s from the store: %w", key, err)
+	}
+	return nil
+}
+
+func (w *ExternalSecretWriter) key(scope string, key client.ObjectKey) string {
+	return path.Join(w.KeyPrefix, scope, key.Namespace, key.Name)
+}
+
+// applyObject applies the object with the server-side apply and returns true if the object is created or changed.
+func applyObject(ctx context.Context, c client.Client, obj client.Object, opts ...client.PatchOption) (bool, error) {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return false, err
+	}
+	current := &metav1.PartialObjectMetadata{}
+	current.SetGroupVersionKind(gvk)
+	if err := c.Get(ctx, client.ObjectKeyFromObject(obj), current); client.IgnoreNotFound(err) != nil {
+		return false, fmt.Errorf("failed to get %s %s: %w", gvk.Kind, client.ObjectKeyFromObject(obj), err)
+	}
+
+	if err := c.Patch(ctx, obj, client.Apply, opts...); err != nil {
+		return false, err
+	}
+	return obj.GetResourceVersion() != current.GetResourceVersion(), nil
+}
+
+// removeExternalSecret removes the ExternalSecret with the given key written by HMC
+// and the Secret created from it. The ExternalSecret is removed orphaning the Secret,
+// so the Secret written later is not garbage collected.
+func removeExternalSecret(ctx context.Context, c client.Client, key client.ObjectKey) error {
+	externalSecret := &metav1.PartialObjectMetadata{}
+	externalSecret.SetGroupVersionKind(ExternalSecretGVK)
+	if err := utils.APIReader(ctx, c).Get(ctx, key, externalSecret); err != nil {
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get ExternalSecret %s: %w", key, err)
+	}
+	if !writtenByHMC(externalSecret) {
+		return nil
+	}
+
+	if err := c.Delete(ctx, externalSecret, client.PropagationPolicy(metav1.DeletePropagationOrphan)); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete ExternalSecret %s: %w", key, err)
+	}
+	secret := &corev1.Secret{}
+	secret.Namespace, secret.Name = key.Namespace, key.Name
+	if err := c.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete Secret %s created from the ExternalSecret: %w", key, err)
+	}
+	return nil
+}
+
+// removeAppliedSecret removes the Secret with the given key written as is by HMC,
+// the External Secrets Operator does not take over the existing Secrets.
+func removeAppliedSecret(ctx context.Context, c client.Client, key client.ObjectKey) error {
+	secret := &metav1.PartialObjectMetadata{}
+	secret.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+	if err := utils.APIReader(ctx, c).Get(ctx, key, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get Secret %s: %w", key, err)
+	}
+	if metav1.GetControllerOf(secret) != nil || !writtenByHMC(secret) {
+		return nil
+	}
+
+	if err := c.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete Secret %s written before the ExternalSecret: %w", key, err)
+	}
+	return nil
+}
+
+// writtenByHMC returns true if the fields of the object are managed by HMC. The object must be
+// read with the APIReader of the context, the cache of the manager strips the managed fields.
+func writtenByHMC(obj client.Object) bool {
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Manager == fieldOwner {
+			return true
+		}
+	}
+	return false
+}
+
+func toAnyMap(m map[string]string) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
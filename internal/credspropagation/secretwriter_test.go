@@ -0,0 +1,265 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credspropagation
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/Mirantis/hmc/internal/utils"
+)
+
+type memoryStore map[string]map[string]string
+
+func (s memoryStore) Put(_ context.Context, key string, data map[string]string) (bool, error) {
+	changed := !maps.Equal(s[key], data)
+	s[key] = data
+	return changed, nil
+}
+
+func (s memoryStore) Remove(_ context.Context, key string) error {
+	delete(s, key)
+	return nil
+}
+
+func TestExternalSecretWriter(t *testing.T) {
+	var applied []client.Object
+	c := fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+		Patch: func(_ context.Context, _ client.WithWatch, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+			applied = append(applied, obj)
+			return nil
+		},
+	}).Build()
+
+	store := memoryStore{}
+	writer := &ExternalSecretWriter{Store: store, ClusterSecretStore: "vault", KeyPrefix: "hmc"}
+
+	secret := makeSecret("azure-cloud-provider", metav1.NamespaceSystem, map[string][]byte{"cloud-config": []byte("{}")})
+	secret.Labels = map[string]string{"app": "ccm"}
+	changed, err := writer.Write(context.Background(), c, "default/dev", secret)
+	require.NoError(t, err)
+	require.True(t, changed)
+
+	require.Equal(t, memoryStore{
+		"hmc/default/dev/kube-system/azure-cloud-provider": {"cloud-config": "e30="},
+	}, store)
+
+	require.Len(t, applied, 1)
+	externalSecret, ok := applied[0].(*unstructured.Unstructured)
+	require.True(t, ok)
+	require.Equal(t, ExternalSecretGVK, externalSecret.GroupVersionKind())
+	require.Equal(t, "kube-system", externalSecret.GetNamespace())
+	require.Equal(t, "azure-cloud-provider", externalSecret.GetName())
+
+	storeName, _, _ := unstructured.NestedString(externalSecret.Object, "spec", "secretStoreRef", "name")
+	require.Equal(t, "vault", storeName)
+	labels, _, _ := unstructured.NestedStringMap(externalSecret.Object, "spec", "target", "template", "metadata", "labels")
+	require.Equal(t, map[string]string{"app": "ccm"}, labels)
+	dataFrom, _, _ := unstructured.NestedSlice(externalSecret.Object, "spec", "dataFrom")
+	require.Equal(t, []any{map[string]any{"extract": map[string]any{
+		"key":              "hmc/default/dev/kube-system/azure-cloud-provider",
+		"decodingStrategy": "Base64",
+	}}}, dataFrom)
+
+	require.NoError(t, writer.Delete(context.Background(), c, "default/dev", client.ObjectKeyFromObject(secret)))
+	require.Empty(t, store)
+}
+
+func TestApplySecretWriterMigration(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	scheme.AddKnownTypeWithName(ExternalSecretGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(ExternalSecretGVK.GroupVersion().WithKind("ExternalSecretList"), &unstructured.UnstructuredList{})
+
+	hmcManaged := []metav1.ManagedFieldsEntry{{Manager: fieldOwner, Operation: metav1.ManagedFieldsOperationApply}}
+
+	externalSecret := &unstructured.Unstructured{}
+	externalSecret.SetGroupVersionKind(ExternalSecretGVK)
+	externalSecret.SetNamespace(metav1.NamespaceSystem)
+	externalSecret.SetName("azure-cloud-provider")
+	externalSecret.SetManagedFields(hmcManaged)
+
+	created := makeSecret("azure-cloud-provider", metav1.NamespaceSystem, map[string][]byte{"cloud-config": []byte("{}")})
+	created.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: ExternalSecretGVK.GroupVersion().String(), Kind: ExternalSecretGVK.Kind,
+		Name: "azure-cloud-provider", Controller: ptr.To(true),
+	}}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(externalSecret, created).WithInterceptorFuncs(interceptor.Funcs{
+		Patch: func(context.Context, client.WithWatch, client.Object, client.Patch, ...client.PatchOption) error {
+			return nil
+		},
+	}).Build()
+
+	ctx := utils.WithAPIReader(context.Background(), c)
+	secret := makeSecret("azure-cloud-provider", metav1.NamespaceSystem, map[string][]byte{"cloud-config": []byte("{}")})
+	_, err := ApplySecretWriter{}.Write(ctx, withStrippingCache(c), "default/dev", secret)
+	require.NoError(t, err)
+	require.True(t, apierrors.IsNotFound(c.Get(context.Background(), client.ObjectKeyFromObject(externalSecret), externalSecret.DeepCopy())))
+	require.True(t, apierrors.IsNotFound(c.Get(context.Background(), client.ObjectKeyFromObject(created), &corev1.Secret{})))
+
+	// the ExternalSecrets not written by HMC are left untouched
+	externalSecret.SetResourceVersion("")
+	externalSecret.SetManagedFields(nil)
+	require.NoError(t, c.Create(context.Background(), externalSecret))
+	_, err = ApplySecretWriter{}.Write(ctx, withStrippingCache(c), "default/dev", secret)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(externalSecret), externalSecret.DeepCopy()))
+}
+
+func TestExternalSecretWriterMigration(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		managedFields []metav1.ManagedFieldsEntry
+		owners        []metav1.OwnerReference
+		deleted       bool
+	}{
+		{
+			name:          "secret written by hmc",
+			managedFields: []metav1.ManagedFieldsEntry{{Manager: fieldOwner, Operation: metav1.ManagedFieldsOperationApply}},
+			deleted:       true,
+		},
+		{
+			name:          "secret created from the external secret",
+			managedFields: []metav1.ManagedFieldsEntry{{Manager: fieldOwner, Operation: metav1.ManagedFieldsOperationApply}},
+			owners: []metav1.OwnerReference{{
+				APIVersion: ExternalSecretGVK.GroupVersion().String(), Kind: ExternalSecretGVK.Kind,
+				Name: "azure-cloud-provider", Controller: ptr.To(true),
+			}},
+		},
+		{
+			name:          "secret written by another manager",
+			managedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationUpdate}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			existing := makeSecret("azure-cloud-provider", metav1.NamespaceSystem, map[string][]byte{"cloud-config": []byte("{}")})
+			existing.ManagedFields = tc.managedFields
+			existing.OwnerReferences = tc.owners
+
+			c := fake.NewClientBuilder().WithObjects(existing).WithInterceptorFuncs(interceptor.Funcs{
+				Patch: func(context.Context, client.WithWatch, client.Object, client.Patch, ...client.PatchOption) error {
+					return nil
+				},
+			}).Build()
+
+			writer := &ExternalSecretWriter{Store: memoryStore{}, ClusterSecretStore: "vault"}
+			secret := makeSecret("azure-cloud-provider", metav1.NamespaceSystem, map[string][]byte{"cloud-config": []byte("{}")})
+			_, err := writer.Write(utils.WithAPIReader(context.Background(), c), withStrippingCache(c), "default/dev", secret)
+			require.NoError(t, err)
+
+			err = c.Get(context.Background(), client.ObjectKeyFromObject(secret), &corev1.Secret{})
+			require.Equal(t, tc.deleted, apierrors.IsNotFound(err))
+		})
+	}
+}
+
+// withStrippingCache returns the client reading the objects without the managed fields
+// as the cache of the manager does.
+func withStrippingCache(c client.WithWatch) client.WithWatch {
+	strip := cache.TransformStripManagedFields()
+	return interceptor.NewClient(c, interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if err := c.Get(ctx, key, obj, opts...); err != nil {
+				return err
+			}
+			_, err := strip(obj)
+			return err
+		},
+	})
+}
+
+func TestVaultStore(t *testing.T) {
+	type request struct {
+		method, path, token string
+		body                map[string]any
+	}
+	var requests []request
+	kv := map[string]map[string]any{"/v1/kv/data/hmc/unchanged": {"key": "value"}}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := request{method: r.Method, path: r.URL.Path, token: r.Header.Get("X-Vault-Token")}
+		if r.Method == http.MethodPost {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req.body))
+		}
+		requests = append(requests, req)
+
+		switch {
+		case r.URL.Path == "/v1/auth/token/renew-self":
+			_, _ = w.Write([]byte(`{"auth":{"lease_duration":3600,"renewable":true}}`))
+		case r.URL.Path == "/v1/kv/data/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+		case r.Method == http.MethodGet:
+			data, ok := kv[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": data}}))
+		case r.Method == http.MethodPost:
+			kv[r.URL.Path], _ = req.body["data"].(map[string]any)
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path == "/v1/kv/metadata/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	store := &VaultStore{Address: server.URL + "/", Mount: "kv", Token: "token"}
+	ctx := context.Background()
+
+	changed, err := store.Put(ctx, "hmc/a", map[string]string{"key": "value"})
+	require.NoError(t, err)
+	require.True(t, changed)
+	changed, err = store.Put(ctx, "hmc/unchanged", map[string]string{"key": "value"})
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.NoError(t, store.Remove(ctx, "hmc/a"))
+	require.NoError(t, store.Remove(ctx, "missing"))
+	_, err = store.Put(ctx, "forbidden", nil)
+	require.EqualError(t, err, `failed to read the current data: unexpected status 403 Forbidden from Vault: {"errors":["permission denied"]}`)
+
+	require.Equal(t, []request{
+		{method: http.MethodPost, path: "/v1/auth/token/renew-self", token: "token", body: map[string]any{}},
+		{method: http.MethodGet, path: "/v1/kv/data/hmc/a", token: "token"},
+		{method: http.MethodPost, path: "/v1/kv/data/hmc/a", token: "token", body: map[string]any{"data": map[string]any{"key": "value"}}},
+		{method: http.MethodGet, path: "/v1/kv/data/hmc/unchanged", token: "token"},
+		{method: http.MethodDelete, path: "/v1/kv/metadata/hmc/a", token: "token"},
+		{method: http.MethodDelete, path: "/v1/kv/metadata/missing", token: "token"},
+		{method: http.MethodGet, path: "/v1/kv/data/forbidden", token: "token"},
+	}, requests)
+	require.WithinDuration(t, time.Now().Add(30*time.Minute), store.renewAt, time.Minute)
+}
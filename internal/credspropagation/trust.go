@@ -0,0 +1,59 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credspropagation
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// PropagateTrustedCA writes the ConfigMap with the trusted CA bundle to the namespaces
+// of the services deployed by HMC on the managed cluster, the services mount the bundle.
+func PropagateTrustedCA(ctx context.Context, cfg *PropagationCfg, namespaces []string, caBundle string) error {
+	objects := make([]client.Object, 0, 2*len(namespaces))
+	for _, name := range namespaces {
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		namespace.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Namespace"))
+		objects = append(objects, namespace,
+			makeConfigMap(hmc.TrustedCAConfigMapName, name, map[string]string{hmc.TrustedCADefaultKey: caBundle}))
+	}
+
+	if err := applyCCMConfigs(ctx, cfg, objects...); err != nil {
+		return fmt.Errorf("failed to apply trusted CA configs: %w", err)
+	}
+	return nil
+}
+
+// RemoveTrustedCA removes the ConfigMaps written by PropagateTrustedCA, the namespaces are kept.
+func RemoveTrustedCA(ctx context.Context, cfg *PropagationCfg, namespaces []string) error {
+	clnt, err := makeClientFromSecret(cfg.KubeconfSecret)
+	if err != nil {
+		return fmt.Errorf("failed to create k8s client: %w", err)
+	}
+
+	for _, namespace := range namespaces {
+		configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: hmc.TrustedCAConfigMapName}}
+		if err := clnt.Delete(ctx, configMap); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete trusted CA ConfigMap %s/%s: %w", namespace, hmc.TrustedCAConfigMapName, err)
+		}
+	}
+	return nil
+}
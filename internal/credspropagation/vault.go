@@ -0,0 +1,201 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credspropagation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// vaultRequestTimeout is the timeout of the requests to Vault made with the default client.
+	vaultRequestTimeout = 30 * time.Second
+	// vaultRenewRetryInterval is the interval the failed renewals of the token are retried after.
+	vaultRenewRetryInterval = time.Minute
+)
+
+var defaultVaultClient = &http.Client{Timeout: vaultRequestTimeout}
+
+// VaultStore is a SecretStore writing the data to the KV version 2 secrets engine of Vault.
+type VaultStore struct {
+	// HTTPClient is the client used for the requests, defaults to the client with a 30s timeout.
+	HTTPClient *http.Client
+	// Address is the address of Vault, e.g. https://vault.example.com:8200.
+	Address string
+	// Mount is the path the KV secrets engine is mounted at, defaults to "secret".
+	Mount string
+	// Token is the token used to authenticate to Vault. The renewable token is
+	// renewed once half of its TTL passes, so it does not expire while in use.
+	Token string
+
+	mu sync.Mutex
+	// renewAt is the time the token is renewed at, zero until the first request.
+	renewAt time.Time
+}
+
+var _ SecretStore = (*VaultStore)(nil)
+
+// vaultError is the error of the request the Vault responded with the given status to.
+type vaultError struct {
+	status  string
+	message string
+	code    int
+}
+
+func (e *vaultError) Error() string {
+	return fmt.Sprintf("unexpected status %s from Vault: %s", e.status, e.message)
+}
+
+// Put implements SecretStore. The data is written only if it differs from the
+// current version, so the unchanged data does not produce the new versions.
+func (s *VaultStore) Put(ctx context.Context, key string, data map[string]string) (bool, error) {
+	s.renewToken(ctx)
+
+	current := struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}{}
+	err := s.do(ctx, http.MethodGet, s.url("data", key), nil, &current)
+	switch {
+	case err == nil:
+		if current.Data.Data != nil && maps.Equal(current.Data.Data, data) {
+			return false, nil
+		}
+	case isVaultNotFound(err):
+	default:
+		return false, fmt.Errorf("failed to read the current data: %w", err)
+	}
+
+	body, err := json.Marshal(map[string]any{"data": data})
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal the data: %w", err)
+	}
+	if err := s.do(ctx, http.MethodPost, s.url("data", key), body, nil); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Remove implements SecretStore. All of the versions of the data are removed.
+func (s *VaultStore) Remove(ctx context.Context, key string) error {
+	s.renewToken(ctx)
+
+	if err := s.do(ctx, http.MethodDelete, s.url("metadata", key), nil, nil); err != nil && !isVaultNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// renewToken renews the token once half of its TTL passes. The failed renewal
+// is logged and retried later, the request is then made with the current token.
+func (s *VaultStore) renewToken(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(s.renewAt) {
+		return
+	}
+
+	renewal := struct {
+		Auth struct {
+			LeaseDuration int64 `json:"lease_duration"`
+			Renewable     bool  `json:"renewable"`
+		} `json:"auth"`
+	}{}
+	if err := s.do(ctx, http.MethodPost, s.url("", "auth/token/renew-self"), []byte("{}"), &renewal); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to renew the Vault token")
+		s.renewAt = now.Add(vaultRenewRetryInterval)
+		return
+	}
+
+	ttl := time.Duration(renewal.Auth.LeaseDuration) * time.Second
+	if !renewal.Auth.Renewable || ttl <= 0 {
+		// the token does not expire or can not be renewed anymore, the latter
+		// is still checked since the token may be replaced with the new one
+		ttl = 2 * vaultRenewRetryInterval
+	}
+	s.renewAt = now.Add(ttl / 2)
+}
+
+// url returns the URL of the endpoint of the KV secrets engine for the key,
+// or of the given path if the endpoint is empty.
+func (s *VaultStore) url(endpoint, key string) string {
+	address := strings.TrimSuffix(s.Address, "/")
+	if endpoint == "" {
+		return fmt.Sprintf("%s/v1/%s", address, key)
+	}
+
+	mount := s.Mount
+	if mount == "" {
+		mount = "secret"
+	}
+	return fmt.Sprintf("%s/v1/%s/%s/%s", address, strings.Trim(mount, "/"), endpoint, strings.TrimPrefix(key, "/"))
+}
+
+// do makes the request to Vault and decodes the response into out if the out is not nil.
+func (s *VaultStore) do(ctx context.Context, method, url string, body []byte, out any) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", s.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := s.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultVaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &vaultError{status: resp.Status, message: strings.TrimSpace(string(msg)), code: resp.StatusCode}
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode the response from Vault: %w", err)
+	}
+	return nil
+}
+
+func isVaultNotFound(err error) bool {
+	var vaultErr *vaultError
+	return errors.As(err, &vaultErr) && vaultErr.code == http.StatusNotFound
+}
@@ -77,7 +77,7 @@ func PropagateVSphereSecrets(ctx context.Context, cfg *PropagationCfg) error {
 		return fmt.Errorf("failed to generate VSphere CSI secret: %s", err)
 	}
 
-	if err := applyCCMConfigs(ctx, cfg.KubeconfSecret, ccmSecret, ccmConfig, csiSecret); err != nil {
+	if err := applyCCMConfigs(ctx, cfg, ccmSecret, ccmConfig, csiSecret); err != nil {
 		return fmt.Errorf("failed to apply VSphere CCM/CSI secrets: %s", err)
 	}
 
@@ -0,0 +1,157 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package errdefs classifies the errors of the reconciliation so the controllers
+// can decide whether and when the reconciliation is retried.
+package errdefs
+
+import (
+	"errors"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// DefaultWaitInterval is the default interval the reconciliation waiting for a dependency is retried with.
+const DefaultWaitInterval = 10 * time.Second
+
+// TerminalError is an error which cannot be resolved by retrying, e.g. a misconfiguration.
+// The reconciliation is retried only once the watched objects change.
+type TerminalError struct {
+	Err error
+}
+
+func (e *TerminalError) Error() string { return e.Err.Error() }
+
+func (e *TerminalError) Unwrap() error { return e.Err }
+
+// TransientError is an error which is expected to be resolved by retrying,
+// e.g. a failed API request. The reconciliation is retried with a backoff.
+type TransientError struct {
+	Err error
+}
+
+func (e *TransientError) Error() string { return e.Err.Error() }
+
+func (e *TransientError) Unwrap() error { return e.Err }
+
+// WaitingError reports the reconciliation is waiting for a dependency to become ready,
+// e.g. a HelmChart to be reconciled. The reconciliation is retried after RequeueAfter.
+type WaitingError struct {
+	Err          error
+	RequeueAfter time.Duration
+}
+
+func (e *WaitingError) Error() string { return e.Err.Error() }
+
+func (e *WaitingError) Unwrap() error { return e.Err }
+
+// Terminal wraps the error into a TerminalError.
+func Terminal(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &TerminalError{Err: err}
+}
+
+// Transient wraps the error into a TransientError.
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &TransientError{Err: err}
+}
+
+// Waiting wraps the error into a WaitingError retried after DefaultWaitInterval.
+func Waiting(err error) error {
+	return WaitingAfter(err, DefaultWaitInterval)
+}
+
+// WaitingAfter wraps the error into a WaitingError retried after the given interval.
+func WaitingAfter(err error, requeueAfter time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &WaitingError{Err: err, RequeueAfter: requeueAfter}
+}
+
+// IsTerminal returns true if the error is a TerminalError.
+func IsTerminal(err error) bool {
+	var terminalErr *TerminalError
+	return errors.As(err, &terminalErr)
+}
+
+// IsTransient returns true if the error is a TransientError.
+func IsTransient(err error) bool {
+	var transientErr *TransientError
+	return errors.As(err, &transientErr)
+}
+
+// IsWaiting returns true if the error is a WaitingError.
+func IsWaiting(err error) bool {
+	var waitingErr *WaitingError
+	return errors.As(err, &waitingErr)
+}
+
+// Result returns the result of the reconciliation failed with the given error.
+// The terminal errors are not retried, the waiting errors are retried after their interval
+// without being reported as errors, the transient and the unclassified errors are retried
+// with a backoff. The transient errors are retried even if they wrap the terminal ones.
+// The joined errors are retried with a backoff if any of them is neither terminal nor waiting.
+func Result(result ctrl.Result, err error) (ctrl.Result, error) {
+	if err == nil {
+		return result, nil
+	}
+
+	terminal, requeueAfter, other := classify(err)
+	switch {
+	case other:
+		return ctrl.Result{}, err
+	case terminal:
+		return ctrl.Result{}, reconcile.TerminalError(err)
+	default:
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
+	}
+}
+
+// classify walks the joined errors and reports whether any of them is terminal,
+// the shortest interval of the waiting errors and whether any of them is transient or unclassified.
+func classify(err error) (terminal bool, requeueAfter time.Duration, other bool) {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			t, r, o := classify(e)
+			terminal = terminal || t
+			other = other || o
+			if r > 0 && (requeueAfter == 0 || r < requeueAfter) {
+				requeueAfter = r
+			}
+		}
+		return terminal, requeueAfter, other
+	}
+
+	if IsTransient(err) {
+		return false, 0, true
+	}
+	if IsTerminal(err) {
+		return true, 0, false
+	}
+
+	var waitingErr *WaitingError
+	if errors.As(err, &waitingErr) {
+		return false, waitingErr.RequeueAfter, false
+	}
+
+	return false, 0, true
+}
@@ -0,0 +1,135 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errdefs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestResult(t *testing.T) {
+	errFoo := errors.New("foo")
+
+	for _, tc := range []struct {
+		name           string
+		err            error
+		expectedResult ctrl.Result
+		expectedErr    error
+		terminal       bool
+	}{
+		{
+			name:           "no error",
+			expectedResult: ctrl.Result{Requeue: true},
+		},
+		{
+			name:        "unclassified error",
+			err:         errFoo,
+			expectedErr: errFoo,
+		},
+		{
+			name:        "transient error",
+			err:         Transient(errFoo),
+			expectedErr: errFoo,
+		},
+		{
+			name:        "transient error wrapped into terminal error",
+			err:         Terminal(fmt.Errorf("failed to verify: %w", Transient(errFoo))),
+			expectedErr: errFoo,
+		},
+		{
+			name:        "terminal error",
+			err:         Terminal(errFoo),
+			expectedErr: errFoo,
+			terminal:    true,
+		},
+		{
+			name:           "waiting error",
+			err:            WaitingAfter(errFoo, time.Minute),
+			expectedResult: ctrl.Result{RequeueAfter: time.Minute},
+		},
+		{
+			name:           "joined waiting errors",
+			err:            errors.Join(WaitingAfter(errFoo, time.Minute), Waiting(errFoo)),
+			expectedResult: ctrl.Result{RequeueAfter: DefaultWaitInterval},
+		},
+		{
+			name:        "joined terminal and waiting errors",
+			err:         errors.Join(Waiting(errFoo), Terminal(errFoo)),
+			expectedErr: errFoo,
+			terminal:    true,
+		},
+		{
+			name:        "joined waiting and transient errors",
+			err:         errors.Join(Waiting(errFoo), Transient(errFoo)),
+			expectedErr: errFoo,
+		},
+		{
+			name:        "joined terminal and unclassified errors",
+			err:         errors.Join(Terminal(errFoo), errors.New("bar")),
+			expectedErr: errFoo,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Result(ctrl.Result{Requeue: true}, tc.err)
+			if result != tc.expectedResult {
+				t.Errorf("unexpected result: got %+v, expected %+v", result, tc.expectedResult)
+			}
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("unexpected error: got %v, expected %v", err, tc.expectedErr)
+			}
+			if errors.Is(err, reconcile.TerminalError(nil)) != tc.terminal {
+				t.Errorf("unexpected terminal error: got %v, expected terminal %t", err, tc.terminal)
+			}
+		})
+	}
+}
+
+func TestClassification(t *testing.T) {
+	errFoo := errors.New("foo")
+
+	for _, tc := range []struct {
+		name      string
+		err       error
+		terminal  bool
+		transient bool
+		waiting   bool
+	}{
+		{name: "unclassified error", err: errFoo},
+		{name: "terminal error", err: fmt.Errorf("failed: %w", Terminal(errFoo)), terminal: true},
+		{name: "transient error", err: fmt.Errorf("failed: %w", Transient(errFoo)), transient: true},
+		{name: "waiting error", err: fmt.Errorf("failed: %w", Waiting(errFoo)), waiting: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if IsTerminal(tc.err) != tc.terminal {
+				t.Errorf("IsTerminal(%v) = %t, expected %t", tc.err, !tc.terminal, tc.terminal)
+			}
+			if IsTransient(tc.err) != tc.transient {
+				t.Errorf("IsTransient(%v) = %t, expected %t", tc.err, !tc.transient, tc.transient)
+			}
+			if IsWaiting(tc.err) != tc.waiting {
+				t.Errorf("IsWaiting(%v) = %t, expected %t", tc.err, !tc.waiting, tc.waiting)
+			}
+		})
+	}
+
+	if Terminal(nil) != nil || Transient(nil) != nil || Waiting(nil) != nil {
+		t.Error("expected the nil errors not to be wrapped")
+	}
+}
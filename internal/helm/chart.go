@@ -20,13 +20,15 @@ import (
 
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Mirantis/hmc/internal/errdefs"
 )
 
 func ArtifactReady(chart *sourcev1.HelmChart) (reportStatus bool, _ error) {
 	for _, c := range chart.Status.Conditions {
 		if c.Type == "Ready" {
 			if chart.Generation != c.ObservedGeneration {
-				return false, errors.New("HelmChart was not reconciled yet, retrying")
+				return false, errdefs.Waiting(errors.New("HelmChart was not reconciled yet, retrying"))
 			}
 			if c.Status != metav1.ConditionTrue {
 				return true, fmt.Errorf("failed to download helm chart artifact: %s", c.Message)
@@ -35,7 +37,7 @@ func ArtifactReady(chart *sourcev1.HelmChart) (reportStatus bool, _ error) {
 	}
 
 	if chart.Status.Artifact == nil || chart.Status.URL == "" {
-		return false, errors.New("helm chart artifact is not ready yet")
+		return false, errdefs.Waiting(errors.New("helm chart artifact is not ready yet"))
 	}
 
 	return false, nil
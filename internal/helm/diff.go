@@ -0,0 +1,100 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"sigs.k8s.io/yaml"
+)
+
+// ManifestDiff is a summary of the differences between two sets of rendered manifests.
+// The objects are identified as Kind/namespace/name.
+type ManifestDiff struct {
+	Added   []string `json:"added,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// Empty returns true if there are no differences.
+func (d *ManifestDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Changed) == 0 && len(d.Removed) == 0
+}
+
+// Summary returns a short human-readable summary of the differences.
+func (d *ManifestDiff) Summary() string {
+	return fmt.Sprintf("%d to add, %d to change, %d to remove", len(d.Added), len(d.Changed), len(d.Removed))
+}
+
+// DiffManifests compares the current manifests of a release with the desired ones.
+func DiffManifests(current, desired string) (*ManifestDiff, error) {
+	currentObjects, err := parseManifests(current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse current manifests: %w", err)
+	}
+	desiredObjects, err := parseManifests(desired)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse desired manifests: %w", err)
+	}
+
+	diff := &ManifestDiff{}
+	for key, desiredObj := range desiredObjects {
+		currentObj, ok := currentObjects[key]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, key)
+		case !reflect.DeepEqual(currentObj, desiredObj):
+			diff.Changed = append(diff.Changed, key)
+		}
+	}
+	for key := range currentObjects {
+		if _, ok := desiredObjects[key]; !ok {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+
+	slices.Sort(diff.Added)
+	slices.Sort(diff.Changed)
+	slices.Sort(diff.Removed)
+	return diff, nil
+}
+
+func parseManifests(manifests string) (map[string]map[string]any, error) {
+	objects := make(map[string]map[string]any)
+	for _, manifest := range releaseutil.SplitManifests(manifests) {
+		obj := make(map[string]any)
+		if err := yaml.Unmarshal([]byte(manifest), &obj); err != nil {
+			return nil, err
+		}
+		if len(obj) == 0 {
+			continue
+		}
+
+		kind, _ := obj["kind"].(string)
+		metadata, _ := obj["metadata"].(map[string]any)
+		name, _ := metadata["name"].(string)
+		namespace, _ := metadata["namespace"].(string)
+
+		key := kind + "/" + name
+		if namespace != "" {
+			key = kind + "/" + namespace + "/" + name
+		}
+		objects[key] = obj
+	}
+	return objects, nil
+}
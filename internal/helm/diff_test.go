@@ -0,0 +1,101 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffManifests(t *testing.T) {
+	const (
+		cluster = `---
+# Source: chart/templates/cluster.yaml
+apiVersion: cluster.x-k8s.io/v1beta1
+kind: Cluster
+metadata:
+  name: test
+  namespace: default
+spec:
+  paused: false
+`
+		clusterChanged = `---
+# Source: chart/templates/cluster.yaml
+apiVersion: cluster.x-k8s.io/v1beta1
+kind: Cluster
+metadata:
+  name: test
+  namespace: default
+spec:
+  paused: true
+`
+		machineDeployment = `---
+# Source: chart/templates/md.yaml
+apiVersion: cluster.x-k8s.io/v1beta1
+kind: MachineDeployment
+metadata:
+  name: test-md
+  namespace: default
+`
+		clusterRole = `---
+# Source: chart/templates/role.yaml
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: test-role
+`
+	)
+
+	for _, tc := range []struct {
+		name     string
+		current  string
+		desired  string
+		expected *ManifestDiff
+	}{
+		{
+			name:     "no changes",
+			current:  cluster + machineDeployment,
+			desired:  cluster + machineDeployment,
+			expected: &ManifestDiff{},
+		},
+		{
+			name:    "new release",
+			desired: cluster + clusterRole,
+			expected: &ManifestDiff{
+				Added: []string{"Cluster/default/test", "ClusterRole/test-role"},
+			},
+		},
+		{
+			name:    "objects added, changed and removed",
+			current: cluster + machineDeployment,
+			desired: clusterChanged + clusterRole,
+			expected: &ManifestDiff{
+				Added:   []string{"ClusterRole/test-role"},
+				Changed: []string{"Cluster/default/test"},
+				Removed: []string{"MachineDeployment/default/test-md"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			diff, err := DiffManifests(tc.current, tc.desired)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(diff, tc.expected) {
+				t.Errorf("expected diff %+v, got %+v", tc.expected, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// imageValuesKeys holds the values keys conventionally used by charts
+// to expose image references or image registries.
+var imageValuesKeys = map[string]struct{}{
+	"image":         {},
+	"repository":    {},
+	"registry":      {},
+	"imageRegistry": {},
+}
+
+// OverrideImages rewrites the image settings according to the given rules.
+// Chart default values are used to discover image settings which are not
+// explicitly set in the given values. Only the rewritten settings are added
+// to the values, the rest of them are returned intact.
+func OverrideImages(values *apiextensionsv1.JSON, chartValues map[string]any, rules []hmc.ImageOverride) (*apiextensionsv1.JSON, error) {
+	if len(rules) == 0 {
+		return values, nil
+	}
+
+	userValues := make(map[string]any)
+	if values != nil && len(values.Raw) > 0 {
+		if err := json.Unmarshal(values.Raw, &userValues); err != nil {
+			return nil, fmt.Errorf("error unmarshalling values: %w", err)
+		}
+	}
+
+	merged, err := copyValues(chartValues)
+	if err != nil {
+		return nil, err
+	}
+	userCopy, err := copyValues(userValues)
+	if err != nil {
+		return nil, err
+	}
+	merged = chartutil.CoalesceTables(userCopy, merged)
+
+	overrides := make(map[string]any)
+	collectImageOverrides(merged, overrides, rules)
+	if len(overrides) == 0 {
+		return values, nil
+	}
+
+	raw, err := json.Marshal(chartutil.CoalesceTables(overrides, userValues))
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling values: %w", err)
+	}
+
+	return &apiextensionsv1.JSON{Raw: raw}, nil
+}
+
+// collectImageOverrides walks the values and puts rewritten
+// image settings into the overrides under the same path.
+func collectImageOverrides(values, overrides map[string]any, rules []hmc.ImageOverride) {
+	for k, v := range values {
+		switch vv := v.(type) {
+		case map[string]any:
+			nested := make(map[string]any)
+			collectImageOverrides(vv, nested, rules)
+			if len(nested) > 0 {
+				overrides[k] = nested
+			}
+		case string:
+			if _, ok := imageValuesKeys[k]; !ok {
+				continue
+			}
+			if rewritten, ok := rewriteImage(vv, rules); ok {
+				overrides[k] = rewritten
+			}
+		}
+	}
+}
+
+// rewriteImage replaces the registry of the given image reference
+// with the target of the first matching rule.
+func rewriteImage(image string, rules []hmc.ImageOverride) (string, bool) {
+	for _, rule := range rules {
+		source := strings.TrimSuffix(rule.Source, "/")
+		if image != source && !strings.HasPrefix(image, source+"/") {
+			continue
+		}
+
+		rewritten := strings.TrimSuffix(rule.Target, "/") + strings.TrimPrefix(image, source)
+		return rewritten, rewritten != image
+	}
+
+	return image, false
+}
+
+func copyValues(values map[string]any) (map[string]any, error) {
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling values: %w", err)
+	}
+
+	result := make(map[string]any)
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("error unmarshalling values: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+func TestRewriteImage(t *testing.T) {
+	rules := []hmc.ImageOverride{
+		{Source: "registry.k8s.io/capi", Target: "mirror.example.com/capi"},
+		{Source: "docker.io/", Target: "mirror.example.com/docker/"},
+	}
+
+	for _, tc := range []struct {
+		image        string
+		expected     string
+		expectedSwap bool
+	}{
+		{image: "registry.k8s.io/capi/manager:v1.8.0", expected: "mirror.example.com/capi/manager:v1.8.0", expectedSwap: true},
+		{image: "docker.io/library/nginx", expected: "mirror.example.com/docker/library/nginx", expectedSwap: true},
+		{image: "docker.io", expected: "mirror.example.com/docker", expectedSwap: true},
+		{image: "docker.iox/library/nginx", expected: "docker.iox/library/nginx"},
+		{image: "quay.io/foo/bar", expected: "quay.io/foo/bar"},
+	} {
+		t.Run(tc.image, func(t *testing.T) {
+			actual, ok := rewriteImage(tc.image, rules)
+			if ok != tc.expectedSwap {
+				t.Errorf("expected rewrite %t, got %t", tc.expectedSwap, ok)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected image %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestOverrideImages(t *testing.T) {
+	rules := []hmc.ImageOverride{{Source: "docker.io", Target: "mirror.example.com"}}
+	chartValues := map[string]any{
+		"controller": map[string]any{
+			"image": map[string]any{"repository": "docker.io/foo/controller", "tag": "v1"},
+		},
+		"webhook":  map[string]any{"image": "quay.io/foo/webhook:v1"},
+		"replicas": 1,
+	}
+
+	for _, tc := range []struct {
+		name     string
+		values   string
+		rules    []hmc.ImageOverride
+		expected string
+	}{
+		{
+			name:     "no rules",
+			values:   `{"replicas":2}`,
+			expected: `{"replicas":2}`,
+		},
+		{
+			name:     "chart defaults are rewritten",
+			values:   `{"replicas":2}`,
+			rules:    rules,
+			expected: `{"controller":{"image":{"repository":"mirror.example.com/foo/controller"}},"replicas":2}`,
+		},
+		{
+			name:     "user values are rewritten",
+			values:   `{"webhook":{"image":"docker.io/bar/webhook:v2"}}`,
+			rules:    rules,
+			expected: `{"controller":{"image":{"repository":"mirror.example.com/foo/controller"}},"webhook":{"image":"mirror.example.com/bar/webhook:v2"}}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := OverrideImages(&apiextensionsv1.JSON{Raw: []byte(tc.values)}, chartValues, tc.rules)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var actualValues, expectedValues map[string]any
+			if err := json.Unmarshal(actual.Raw, &actualValues); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tc.expected), &expectedValues); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actualValues, expectedValues) {
+				t.Errorf("expected values %s, got %s", tc.expected, actual.Raw)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// sampleValuesDir is the directory of the chart holding the sample values, following
+// the chart-testing convention, e.g. ci/aws-values.yaml.
+const sampleValuesDir = "ci"
+
+// RenderOptions are the options of the rendering of a chart.
+type RenderOptions struct {
+	ReleaseName      string
+	ReleaseNamespace string
+	// KubeVersion is the version of Kubernetes reported to the templates, e.g. v1.31.0.
+	// The default version of Helm is reported if empty.
+	KubeVersion string
+}
+
+// RenderedObject is an object rendered by a chart.
+type RenderedObject struct {
+	// Template is the name of the template of the chart rendering the object.
+	Template string
+	Object   *unstructured.Unstructured
+}
+
+// String returns the template and the kind and the name of the object, e.g. "aws-standalone-cp/templates/cluster.yaml: AWSCluster/foo".
+func (o RenderedObject) String() string {
+	return fmt.Sprintf("%s: %s/%s", o.Template, o.Object.GetKind(), o.Object.GetName())
+}
+
+// SampleValues returns the sample values shipped with the chart in the ci directory
+// keyed by the name of the file, e.g. ci/aws-values.yaml.
+func SampleValues(helmChart *chart.Chart) (map[string]map[string]any, error) {
+	var samples map[string]map[string]any
+	for _, file := range helmChart.Files {
+		if path.Dir(file.Name) != sampleValuesDir || !strings.HasSuffix(file.Name, "values.yaml") {
+			continue
+		}
+
+		values, err := chartutil.ReadValues(file.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the sample values %s: %w", file.Name, err)
+		}
+		if samples == nil {
+			samples = make(map[string]map[string]any)
+		}
+		samples[file.Name] = values
+	}
+	return samples, nil
+}
+
+// RenderObjects renders the chart with the values coalesced with its default values and returns the
+// rendered objects. Unlike the other render functions the values must satisfy the values schema of the chart.
+func RenderObjects(helmChart *chart.Chart, values map[string]any, opts RenderOptions) ([]RenderedObject, error) {
+	var objects []RenderedObject
+	err := renderManifestsWithValues(helmChart, values, opts, false, func(name, manifest string) error {
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal([]byte(manifest), &obj.Object); err != nil {
+			return fmt.Errorf("failed to parse rendered manifest %s: %w", name, err)
+		}
+		if len(obj.Object) == 0 {
+			return nil
+		}
+		if obj.GetKind() == "" || obj.GetAPIVersion() == "" {
+			return fmt.Errorf("rendered manifest %s: the object has no apiVersion or kind", name)
+		}
+		objects = append(objects, RenderedObject{Template: name, Object: obj})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(objects, func(i, j int) bool { return objects[i].Template < objects[j].Template })
+	return objects, nil
+}
+
+// ManifestValidator validates the rendered objects against the API of the cluster, the way kubeconform
+// validates the manifests against the schemas of a Kubernetes version. The custom resources are
+// validated against the OpenAPI schemas of the versions of their CustomResourceDefinitions the way the
+// API server validates them, including the unknown fields, the objects of the Kubernetes kinds are
+// decoded strictly into the Kubernetes types.
+type ManifestValidator struct {
+	// GetCRD returns the CustomResourceDefinition by name or nil if it is not installed.
+	// The custom resources are not validated if nil.
+	GetCRD func(name string) (*apiextensionsv1.CustomResourceDefinition, error)
+	// Mapper reports the kinds and the versions not served by the cluster and resolves the names
+	// of the CustomResourceDefinitions. The served versions are not verified if nil.
+	Mapper meta.RESTMapper
+}
+
+// Validate returns the problems of the rendered objects and the objects of the kinds not yet served
+// by the cluster, each prefixed with the template and the object. The objects of the kinds neither
+// known to the Kubernetes types nor to the CustomResourceDefinitions are not validated.
+func (v *ManifestValidator) Validate(objects []RenderedObject) (problems, pending []string) {
+	decoder := serializer.NewCodecFactory(clientgoscheme.Scheme, serializer.EnableStrict).UniversalDeserializer()
+	schemas := make(map[schema.GroupVersionKind]*apiextensionsv1.JSONSchemaProps)
+
+	for _, obj := range objects {
+		gvk := obj.Object.GroupVersionKind()
+		var mapping *meta.RESTMapping
+		if v.Mapper != nil {
+			var err error
+			if mapping, err = v.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+				if meta.IsNoMatchError(err) {
+					pending = append(pending, fmt.Sprintf("%s: %s is not served by the cluster", obj, gvk.GroupVersion().WithKind(gvk.Kind)))
+				} else {
+					problems = append(problems, fmt.Sprintf("%s: %v", obj, err))
+				}
+				continue
+			}
+		}
+
+		if clientgoscheme.Scheme.Recognizes(gvk) {
+			raw, err := json.Marshal(obj.Object.Object)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", obj, err))
+				continue
+			}
+			if _, _, err := decoder.Decode(raw, nil, nil); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", obj, err))
+			}
+			continue
+		}
+		if mapping == nil || v.GetCRD == nil {
+			continue
+		}
+
+		crdSchema, cached := schemas[gvk]
+		if !cached {
+			var err error
+			if crdSchema, err = v.schema(mapping); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", obj, err))
+				continue
+			}
+			schemas[gvk] = crdSchema
+		}
+		if crdSchema == nil {
+			continue
+		}
+		for _, problem := range validateAgainstSchema(obj.Object.Object, crdSchema) {
+			problems = append(problems, fmt.Sprintf("%s: %s", obj, problem))
+		}
+	}
+	return problems, pending
+}
+
+// schema returns the OpenAPI schema of the version of the custom resource of the mapping
+// or nil if the kind is not defined by a CustomResourceDefinition, e.g. aggregated APIs.
+func (v *ManifestValidator) schema(mapping *meta.RESTMapping) (*apiextensionsv1.JSONSchemaProps, error) {
+	gvk := mapping.GroupVersionKind
+	name := mapping.Resource.Resource + "." + mapping.Resource.Group
+	crd, err := v.GetCRD(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the CustomResourceDefinition %s: %w", name, err)
+	}
+	if crd == nil || crd.Spec.Names.Kind != gvk.Kind {
+		return nil, nil
+	}
+
+	for _, version := range crd.Spec.Versions {
+		if version.Name != gvk.Version {
+			continue
+		}
+		if !version.Served {
+			return nil, fmt.Errorf("the version %s of the CustomResourceDefinition %s is not served", gvk.Version, crd.Name)
+		}
+		if version.Schema == nil || version.Schema.OpenAPIV3Schema == nil {
+			return nil, nil
+		}
+		return version.Schema.OpenAPIV3Schema, nil
+	}
+	return nil, fmt.Errorf("the CustomResourceDefinition %s has no version %s", crd.Name, gvk.Version)
+}
+
+// validateAgainstSchema returns the fields of the object unknown to the schema
+// and the violations of the schema reported by the API server.
+func validateAgainstSchema(obj map[string]any, crdSchema *apiextensionsv1.JSONSchemaProps) []string {
+	var problems []string
+	for key, value := range obj {
+		// the metadata is validated by the API server regardless of the schema
+		if key == "apiVersion" || key == "kind" || key == "metadata" {
+			continue
+		}
+		if prop, ok := crdSchema.Properties[key]; ok {
+			problems = append(problems, unknownFields(key, value, &prop)...)
+			continue
+		}
+		if crdSchema.XPreserveUnknownFields == nil || !*crdSchema.XPreserveUnknownFields {
+			problems = append(problems, key+": unknown field")
+		}
+	}
+	slices.Sort(problems)
+
+	internalSchema := new(apiextensions.JSONSchemaProps)
+	if err := apiextensionsv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(crdSchema, internalSchema, nil); err != nil {
+		return append(problems, fmt.Sprintf("failed to convert the schema: %v", err))
+	}
+	validator, _, err := validation.NewSchemaValidator(internalSchema)
+	if err != nil {
+		return append(problems, fmt.Sprintf("invalid schema: %v", err))
+	}
+	for _, fieldErr := range validation.ValidateCustomResource(nil, obj, validator) {
+		problems = append(problems, fieldErr.Error())
+	}
+	return problems
+}
+
+// unknownFields returns the fields of the value at the path not defined by the schema,
+// the same fields the API server prunes from the custom resources.
+func unknownFields(fieldPath string, value any, props *apiextensionsv1.JSONSchemaProps) []string {
+	if props.XPreserveUnknownFields != nil && *props.XPreserveUnknownFields || props.XEmbeddedResource {
+		return nil
+	}
+
+	var problems []string
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			itemPath := fieldPath + "." + key
+			if prop, ok := props.Properties[key]; ok {
+				problems = append(problems, unknownFields(itemPath, item, &prop)...)
+				continue
+			}
+			if props.AdditionalProperties != nil {
+				if props.AdditionalProperties.Schema != nil {
+					problems = append(problems, unknownFields(itemPath, item, props.AdditionalProperties.Schema)...)
+				}
+				continue
+			}
+			problems = append(problems, itemPath+": unknown field")
+		}
+	case []any:
+		if props.Items == nil || props.Items.Schema == nil {
+			return nil
+		}
+		for i, item := range v {
+			problems = append(problems, unknownFields(fmt.Sprintf("%s[%d]", fieldPath, i), item, props.Items.Schema)...)
+		}
+	}
+	return problems
+}
@@ -0,0 +1,203 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestSampleValues(t *testing.T) {
+	helmChart := &chart.Chart{
+		Files: []*chart.File{
+			{Name: "ci/aws-values.yaml", Data: []byte("region: us-east-2\n")},
+			{Name: "ci/README.md", Data: []byte("# samples\n")},
+			{Name: "files/values.yaml", Data: []byte("ignored: true\n")},
+		},
+	}
+
+	samples, err := SampleValues(helmChart)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]map[string]any{"ci/aws-values.yaml": {"region": "us-east-2"}}
+	if !reflect.DeepEqual(samples, expected) {
+		t.Errorf("expected samples %v, got %v", expected, samples)
+	}
+}
+
+func TestRenderObjects(t *testing.T) {
+	helmChart := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "test", Version: "0.1.0"},
+		Values:   map[string]any{"replicas": 1},
+		Schema:   []byte(`{"type": "object", "properties": {"replicas": {"type": "integer"}}}`),
+		Templates: []*chart.File{
+			{Name: "templates/widget.yaml", Data: []byte(`apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: {{ .Release.Name }}
+spec:
+  replicas: {{ .Values.replicas }}
+  kubeVersion: {{ .Capabilities.KubeVersion.Version }}
+`)},
+		},
+	}
+
+	objects, err := RenderObjects(helmChart, map[string]any{"replicas": 3}, RenderOptions{
+		ReleaseName:      "release",
+		ReleaseNamespace: "default",
+		KubeVersion:      "v1.31.0",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(objects) != 1 {
+		t.Fatalf("expected 1 object, got %d", len(objects))
+	}
+	if s := objects[0].String(); s != "test/templates/widget.yaml: Widget/release" {
+		t.Errorf("unexpected object %s", s)
+	}
+	spec := objects[0].Object.Object["spec"].(map[string]any)
+	if spec["replicas"] != float64(3) || spec["kubeVersion"] != "v1.31.0" {
+		t.Errorf("unexpected spec %v", spec)
+	}
+
+	if _, err := RenderObjects(helmChart, map[string]any{"replicas": "three"}, RenderOptions{}); err == nil {
+		t.Error("expected the values violating the schema to fail the rendering")
+	}
+}
+
+func TestManifestValidatorValidate(t *testing.T) {
+	helmChart := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "test", Version: "0.1.0"},
+		Templates: []*chart.File{
+			{Name: "templates/widget.yaml", Data: []byte(`apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: valid
+spec:
+  replicas: 1
+  labels:
+    foo: bar
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: invalid
+spec:
+  replicas: one
+  replicaz: 1
+---
+apiVersion: example.com/v1beta1
+kind: Widget
+metadata:
+  name: legacy
+spec:
+  replicas: one
+---
+apiVersion: example.com/v1
+kind: Gadget
+metadata:
+  name: pending
+`)},
+			{Name: "templates/configmap.yaml", Data: []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+dataa:
+  foo: bar
+`)},
+		},
+	}
+
+	objects, err := RenderObjects(helmChart, nil, RenderOptions{ReleaseName: "release"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	specSchema := func(replicasType string) *apiextensionsv1.CustomResourceValidation {
+		return &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]apiextensionsv1.JSONSchemaProps{
+				"spec": {
+					Type: "object",
+					Properties: map[string]apiextensionsv1.JSONSchemaProps{
+						"replicas": {Type: replicasType},
+						"labels": {
+							Type:                 "object",
+							AdditionalProperties: &apiextensionsv1.JSONSchemaPropsOrBool{Allows: true, Schema: &apiextensionsv1.JSONSchemaProps{Type: "string"}},
+						},
+					},
+				},
+			},
+		}}
+	}
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets.example.com"},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "example.com",
+			Names: apiextensionsv1.CustomResourceDefinitionNames{Kind: "Widget", Plural: "widgets"},
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{Name: "v1", Served: true, Schema: specSchema("integer")},
+				{Name: "v1beta1", Served: true, Schema: specSchema("string")},
+			},
+		},
+	}
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "example.com", Version: "v1beta1", Kind: "Widget"}, meta.RESTScopeNamespace)
+
+	var gets []string
+	validator := &ManifestValidator{
+		GetCRD: func(name string) (*apiextensionsv1.CustomResourceDefinition, error) {
+			gets = append(gets, name)
+			if name == crd.Name {
+				return crd, nil
+			}
+			return nil, nil
+		},
+		Mapper: mapper,
+	}
+
+	problems, pending := validator.Validate(objects)
+	if !reflect.DeepEqual(pending, []string{"test/templates/widget.yaml: Gadget/pending: example.com/v1, Kind=Gadget is not served by the cluster"}) {
+		t.Errorf("unexpected pending kinds %v", pending)
+	}
+	if !reflect.DeepEqual(gets, []string{"widgets.example.com", "widgets.example.com"}) {
+		t.Errorf("expected the CustomResourceDefinition to be got once per version, got %v", gets)
+	}
+	if len(problems) != 3 {
+		t.Fatalf("expected 3 problems, got %v", problems)
+	}
+	if !strings.HasPrefix(problems[0], "test/templates/configmap.yaml: ConfigMap/test: ") || !strings.Contains(problems[0], `unknown field "dataa"`) {
+		t.Errorf("unexpected problem %s", problems[0])
+	}
+	if problems[1] != "test/templates/widget.yaml: Widget/invalid: spec.replicaz: unknown field" {
+		t.Errorf("unexpected problem %s", problems[1])
+	}
+	if !strings.HasPrefix(problems[2], "test/templates/widget.yaml: Widget/invalid: spec.replicas: Invalid value") {
+		t.Errorf("unexpected problem %s", problems[2])
+	}
+}
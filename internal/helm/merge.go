@@ -0,0 +1,157 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"fmt"
+	"slices"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// MergeValues combines the layers of the values according to the strategy and returns
+// the values of the release, the later layers take precedence. Helm merges the release
+// values with the chart default values itself, so the chart defaults are added to the
+// result only where the strategy requires them, e.g. the lists merged by StrategicMerge.
+func MergeValues(strategy hmc.ConfigMergeStrategy, chartValues map[string]any, layers ...map[string]any) (map[string]any, error) {
+	defaults, err := copyValues(chartValues)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]any)
+	for _, layer := range layers {
+		layer, err := copyValues(layer)
+		if err != nil {
+			return nil, err
+		}
+
+		switch strategy {
+		case hmc.ConfigMergeStrategyMerge, "":
+			result = mergeMaps(result, layer, defaults, false)
+		case hmc.ConfigMergeStrategyStrategicMerge:
+			result = mergeMaps(result, layer, defaults, true)
+		case hmc.ConfigMergeStrategyReplace:
+			for k, v := range layer {
+				current, ok := result[k]
+				if !ok {
+					current = defaults[k]
+				}
+				result[k] = replaceValue(current, v)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported config merge strategy %q", strategy)
+		}
+	}
+
+	return result, nil
+}
+
+// mergeMaps deep merges src into dst. The lists of objects are merged by the "name" field
+// of the objects if strategic is set, otherwise the lists of src replace the lists of dst.
+// The defaults are the chart default values under the same path as dst.
+func mergeMaps(dst, src, defaults map[string]any, strategic bool) map[string]any {
+	for k, sv := range src {
+		dv, exists := dst[k]
+		switch svt := sv.(type) {
+		case map[string]any:
+			base, ok := dv.(map[string]any)
+			if !ok {
+				base = make(map[string]any)
+			}
+			nested, _ := defaults[k].(map[string]any)
+			dst[k] = mergeMaps(base, svt, nested, strategic)
+			continue
+		case []any:
+			if !strategic {
+				break
+			}
+			if !exists {
+				dv = defaults[k]
+			}
+			if base, ok := dv.([]any); ok {
+				if merged, ok := mergeNamedLists(base, svt); ok {
+					dst[k] = merged
+					continue
+				}
+			}
+		}
+		dst[k] = sv
+	}
+
+	return dst
+}
+
+// mergeNamedLists merges the lists of objects by the "name" field of the objects.
+// It returns false if some of the items are not objects with a name.
+func mergeNamedLists(dst, src []any) ([]any, bool) {
+	index := make(map[string]int, len(dst))
+	for i, item := range dst {
+		name, ok := itemName(item)
+		if !ok {
+			return nil, false
+		}
+		index[name] = i
+	}
+
+	result := slices.Clone(dst)
+	for _, item := range src {
+		name, ok := itemName(item)
+		if !ok {
+			return nil, false
+		}
+
+		if i, found := index[name]; found {
+			result[i] = mergeMaps(result[i].(map[string]any), item.(map[string]any), nil, true)
+			continue
+		}
+		index[name] = len(result)
+		result = append(result, item)
+	}
+
+	return result, true
+}
+
+func itemName(item any) (string, bool) {
+	m, ok := item.(map[string]any)
+	if !ok {
+		return "", false
+	}
+	name, ok := m["name"].(string)
+	return name, ok
+}
+
+// replaceValue returns src with the keys of the dst maps missing in src set to null,
+// so that Helm does not merge the replaced defaults into the value.
+func replaceValue(dst, src any) any {
+	dm, ok := dst.(map[string]any)
+	if !ok {
+		return src
+	}
+	sm, ok := src.(map[string]any)
+	if !ok {
+		return src
+	}
+
+	for k, dv := range dm {
+		if sv, ok := sm[k]; ok {
+			sm[k] = replaceValue(dv, sv)
+		} else {
+			sm[k] = nil
+		}
+	}
+
+	return sm
+}
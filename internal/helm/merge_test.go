@@ -0,0 +1,94 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+func TestMergeValues(t *testing.T) {
+	chartValues := map[string]any{
+		"worker": map[string]any{"instanceType": "t3.small", "rootVolumeSize": 8},
+		"files": []any{
+			map[string]any{"name": "a", "content": "a", "permissions": "0600"},
+			map[string]any{"name": "b", "content": "b"},
+		},
+		"args": []any{"--foo"},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		strategy hmc.ConfigMergeStrategy
+		layers   []string
+		expected string
+	}{
+		{
+			name:     "merge keeps the values as is",
+			layers:   []string{`{"worker":{"instanceType":"t3.large"},"files":[{"name":"a","content":"c"}],"args":null}`},
+			expected: `{"worker":{"instanceType":"t3.large"},"files":[{"name":"a","content":"c"}],"args":null}`,
+		},
+		{
+			name:     "merge deep merges the layers",
+			strategy: hmc.ConfigMergeStrategyMerge,
+			layers:   []string{`{"worker":{"instanceType":"t3.large","amiID":"ami-1"}}`, `{"worker":{"instanceType":"t3.xlarge"}}`},
+			expected: `{"worker":{"instanceType":"t3.xlarge","amiID":"ami-1"}}`,
+		},
+		{
+			name:     "strategic merge merges the lists by name",
+			strategy: hmc.ConfigMergeStrategyStrategicMerge,
+			layers:   []string{`{"files":[{"name":"a","content":"c"},{"name":"d","content":"d"}],"args":["--bar"]}`},
+			expected: `{"files":[{"name":"a","content":"c","permissions":"0600"},{"name":"b","content":"b"},{"name":"d","content":"d"}],"args":["--bar"]}`,
+		},
+		{
+			name:     "replace removes the missing defaults",
+			strategy: hmc.ConfigMergeStrategyReplace,
+			layers:   []string{`{"worker":{"instanceType":"t3.large"}}`},
+			expected: `{"worker":{"instanceType":"t3.large","rootVolumeSize":null}}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			layers := make([]map[string]any, 0, len(tc.layers))
+			for _, l := range tc.layers {
+				var layer map[string]any
+				if err := json.Unmarshal([]byte(l), &layer); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				layers = append(layers, layer)
+			}
+
+			actual, err := MergeValues(tc.strategy, chartValues, layers...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var expected map[string]any
+			if err := json.Unmarshal([]byte(tc.expected), &expected); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, expected) {
+				raw, _ := json.Marshal(actual)
+				t.Errorf("expected values %s, got %s", tc.expected, raw)
+			}
+		})
+	}
+
+	if _, err := MergeValues("Unknown", chartValues, map[string]any{}); err == nil {
+		t.Error("expected error for the unknown strategy")
+	}
+}
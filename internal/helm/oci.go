@@ -0,0 +1,254 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/registry"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/Mirantis/hmc/internal/errdefs"
+)
+
+var (
+	// ErrNotOCI is returned when the chart of the HelmChart is not served by an OCI HelmRepository.
+	ErrNotOCI = errors.New("the chart is not served by an OCI repository")
+	// ErrUnsupportedProvider is returned when the OCI HelmRepository authenticates with
+	// the credentials of a cloud provider, only the source-controller may pull its charts.
+	ErrUnsupportedProvider = errors.New("the authentication provider of the repository is not supported")
+)
+
+// genericProvider is the provider of the OCI HelmRepositories authenticating with the static credentials.
+const genericProvider = "generic"
+
+// DownloadHelmChart downloads the chart of the HelmChart from its artifact. The chart is
+// pulled directly from the OCI repository only if the artifact cannot be downloaded from
+// the source-controller after all of the retries, keeping the reconciliation alive during
+// the outages of the source-controller. The pulled chart must match the digest of the
+// artifact, the charts not matching the digest of the artifact are never pulled instead.
+func DownloadHelmChart(ctx context.Context, cl client.Client, hc *sourcev1.HelmChart) (*chart.Chart, error) {
+	helmChart, err := DownloadChartFromHelmChart(ctx, cl, hc)
+	if err == nil || hc.Status.Artifact == nil || errors.Is(err, ErrDigestMismatch) {
+		return helmChart, err
+	}
+
+	l := ctrl.LoggerFrom(ctx).WithValues("helmchart", client.ObjectKeyFromObject(hc))
+	l.Info("Pulling the Helm chart from the OCI repository, the artifact cannot be downloaded", "error", err.Error())
+
+	pulled, pullErr := PullOCIChart(ctx, cl, hc)
+	switch {
+	case pullErr == nil:
+		return pulled, nil
+	case errors.Is(pullErr, ErrNotOCI), errors.Is(pullErr, ErrUnsupportedProvider):
+		return nil, err
+	default:
+		return nil, errors.Join(err, pullErr)
+	}
+}
+
+// PullOCIChart pulls the chart of the artifact of the HelmChart directly from the OCI
+// HelmRepository it refers to, authenticating with the credentials and the certificates
+// of the repository. The pulled chart is verified against the digest of the artifact, so
+// it is the very chart the source-controller has verified the signature of.
+func PullOCIChart(ctx context.Context, cl client.Client, hc *sourcev1.HelmChart) (*chart.Chart, error) {
+	if hc.Spec.SourceRef.Kind != sourcev1.HelmRepositoryKind {
+		return nil, ErrNotOCI
+	}
+	if hc.Status.Artifact == nil {
+		return nil, fmt.Errorf("artifact of HelmChart %s/%s is not ready yet", hc.Namespace, hc.Name)
+	}
+
+	repo := &sourcev1.HelmRepository{}
+	repoKey := client.ObjectKey{Namespace: hc.Namespace, Name: hc.Spec.SourceRef.Name}
+	if err := cl.Get(ctx, repoKey, repo); err != nil {
+		return nil, fmt.Errorf("failed to get HelmRepository %s: %w", repoKey, err)
+	}
+	if repo.Spec.Type != sourcev1.HelmRepositoryTypeOCI {
+		return nil, ErrNotOCI
+	}
+	if repo.Spec.Provider != "" && repo.Spec.Provider != genericProvider {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedProvider, repo.Spec.Provider)
+	}
+
+	host, repoPath, _ := strings.Cut(strings.TrimPrefix(repo.Spec.URL, "oci://"), "/")
+	opts := []registry.ClientOption{registry.ClientOptWriter(io.Discard)}
+	if repo.Spec.Insecure {
+		opts = append(opts, registry.ClientOptPlainHTTP())
+	}
+
+	if repo.Spec.CertSecretRef != nil {
+		secret := &corev1.Secret{}
+		secretKey := client.ObjectKey{Namespace: repo.Namespace, Name: repo.Spec.CertSecretRef.Name}
+		if err := cl.Get(ctx, secretKey, secret); err != nil {
+			return nil, fmt.Errorf("failed to get the certificates Secret %s: %w", secretKey, err)
+		}
+		tlsConfig, err := registryTLSConfig(secret)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificates Secret %s: %w", secretKey, err)
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		opts = append(opts, registry.ClientOptHTTPClient(&http.Client{Transport: transport}))
+	}
+
+	if repo.Spec.SecretRef != nil {
+		secret := &corev1.Secret{}
+		secretKey := client.ObjectKey{Namespace: repo.Namespace, Name: repo.Spec.SecretRef.Name}
+		if err := cl.Get(ctx, secretKey, secret); err != nil {
+			return nil, fmt.Errorf("failed to get the credentials Secret %s: %w", secretKey, err)
+		}
+
+		dir, err := os.MkdirTemp("", "hmc-registry-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+
+		credentialsFile := filepath.Join(dir, "config.json")
+		if err := writeRegistryCredentials(credentialsFile, host, secret); err != nil {
+			return nil, err
+		}
+		opts = append(opts, registry.ClientOptCredentialsFile(credentialsFile))
+	}
+
+	registryClient, err := registry.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the registry client: %w", err)
+	}
+
+	// the exact version of the artifact is pulled, the revision may be suffixed with the digest of the manifest
+	version, _, _ := strings.Cut(hc.Status.Artifact.Revision, "@")
+	ref := strings.TrimSuffix(host+"/"+repoPath, "/") + "/" + hc.Spec.Chart
+	tag, err := resolveTag(registryClient, ref, version)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := registryClient.Pull(ref+":"+tag, registry.PullOptWithChart(true))
+	if err != nil {
+		return nil, errdefs.Transient(fmt.Errorf("failed to pull chart %s:%s: %w", ref, tag, err))
+	}
+	if err := copyChart(bytes.NewReader(result.Chart.Data), io.Discard, hc.Status.Artifact.Digest); err != nil {
+		return nil, fmt.Errorf("failed to verify chart %s:%s: %w", ref, tag, err)
+	}
+
+	helmChart, err := loader.LoadArchive(bytes.NewReader(result.Chart.Data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load archive for chart %s:%s, %w", ref, tag, err)
+	}
+	return helmChart, nil
+}
+
+// registryTLSConfig returns the TLS configuration from the certificates Secret
+// of the HelmRepository in the format expected by the source-controller.
+func registryTLSConfig(secret *corev1.Secret) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if ca := secret.Data["ca.crt"]; len(ca) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("failed to parse ca.crt")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	certPEM, keyPEM := secret.Data["tls.crt"], secret.Data["tls.key"]
+	if len(certPEM) > 0 || len(keyPEM) > 0 {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// resolveTag returns the tag of the chart version matching the version of the HelmChart,
+// the version may be a semver constraint resolved to the latest matching tag.
+func resolveTag(registryClient *registry.Client, ref, version string) (string, error) {
+	if version == "" {
+		version = "*"
+	}
+	if v, err := semver.StrictNewVersion(version); err == nil {
+		// the OCI tags do not allow the "+" of the build metadata
+		return strings.ReplaceAll(v.Original(), "+", "_"), nil
+	}
+
+	constraint, err := semver.NewConstraint(version)
+	if err != nil {
+		return "", fmt.Errorf("invalid chart version %s: %w", version, err)
+	}
+	tags, err := registryClient.Tags(ref)
+	if err != nil {
+		return "", errdefs.Transient(fmt.Errorf("failed to list the tags of chart %s: %w", ref, err))
+	}
+	// the tags are sorted by the version in descending order
+	for _, tag := range tags {
+		v, err := semver.NewVersion(tag)
+		if err == nil && constraint.Check(v) {
+			return strings.ReplaceAll(tag, "+", "_"), nil
+		}
+	}
+	return "", fmt.Errorf("no version of chart %s matches %s", ref, version)
+}
+
+// writeRegistryCredentials writes the credentials of the registry in the format of the
+// Docker config file. The Secrets of the kubernetes.io/dockerconfigjson type are
+// written as is, the other ones are expected to have the username and the password.
+func writeRegistryCredentials(path, host string, secret *corev1.Secret) error {
+	if secret.Type == corev1.SecretTypeDockerConfigJson {
+		data := secret.Data[corev1.DockerConfigJsonKey]
+		if len(data) == 0 {
+			return fmt.Errorf("secret %s/%s has no %s key", secret.Namespace, secret.Name, corev1.DockerConfigJsonKey)
+		}
+		return os.WriteFile(path, data, 0o600)
+	}
+
+	username, password := string(secret.Data["username"]), string(secret.Data["password"])
+	config := map[string]any{
+		"auths": map[string]any{
+			host: map[string]string{
+				"auth": base64.StdEncoding.EncodeToString([]byte(username + ":" + password)),
+			},
+		},
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
@@ -0,0 +1,254 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	godigest "github.com/opencontainers/go-digest"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestResolveTag(t *testing.T) {
+	// the exact versions are resolved without listing the tags
+	tag, err := resolveTag(nil, "example.com/charts/aws", "1.2.3+build.1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tag != "1.2.3_build.1" {
+		t.Errorf("expected tag 1.2.3_build.1, got %s", tag)
+	}
+
+	if _, err := resolveTag(nil, "example.com/charts/aws", "not a version"); err == nil {
+		t.Error("expected an error for the invalid version")
+	}
+}
+
+func TestDownloadHelmChartNotOCI(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := sourcev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &sourcev1.HelmRepository{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "hmc-system", Name: "hmc-templates"},
+		Spec:       sourcev1.HelmRepositorySpec{URL: "https://example.com/charts"},
+	}
+	hc := &sourcev1.HelmChart{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "hmc-system", Name: "aws"},
+		Spec: sourcev1.HelmChartSpec{
+			Chart:     "aws",
+			Version:   "1.0.0",
+			SourceRef: sourcev1.LocalHelmChartSourceReference{Kind: sourcev1.HelmRepositoryKind, Name: "hmc-templates"},
+		},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(repo).Build()
+
+	// the chart cannot be pulled from the HTTP repositories, the missing artifact is reported
+	_, err := DownloadHelmChart(context.Background(), cl, hc)
+	if err == nil || !strings.Contains(err.Error(), "is not ready yet") {
+		t.Errorf("expected the artifact not ready error, got %v", err)
+	}
+}
+
+// registryHandler serves the chart archive as the only version of the chart in a minimal OCI registry.
+func registryHandler(t *testing.T, repository, version string, archive []byte, username, password string) http.Handler {
+	t.Helper()
+
+	config := []byte("{}")
+	manifest, err := json.Marshal(map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+		"config": map[string]any{
+			"mediaType": "application/vnd.cncf.helm.config.v1+json",
+			"digest":    godigest.FromBytes(config).String(),
+			"size":      len(config),
+		},
+		"layers": []map[string]any{{
+			"mediaType": "application/vnd.cncf.helm.chart.content.v1.tar+gzip",
+			"digest":    godigest.FromBytes(archive).String(),
+			"size":      len(archive),
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blobs := map[string][]byte{
+		"/v2/" + repository + "/manifests/" + version:                               manifest,
+		"/v2/" + repository + "/manifests/" + godigest.FromBytes(manifest).String(): manifest,
+		"/v2/" + repository + "/blobs/" + godigest.FromBytes(config).String():       config,
+		"/v2/" + repository + "/blobs/" + godigest.FromBytes(archive).String():      archive,
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); username != "" && (!ok || user != username || pass != password) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		data, ok := blobs[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if strings.Contains(r.URL.Path, "/manifests/") {
+			w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+		}
+		w.Header().Set("Docker-Content-Digest", godigest.FromBytes(data).String())
+		if r.Method == http.MethodHead {
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			return
+		}
+		_, _ = w.Write(data)
+	})
+}
+
+func TestDownloadHelmChartFromOCI(t *testing.T) {
+	ctx := WithDownloadBackoff(context.Background(), wait.Backoff{Steps: 1, Duration: time.Millisecond})
+
+	archivePath, err := chartutil.Save(&chart.Chart{Metadata: &chart.Metadata{
+		APIVersion: chart.APIVersionV2,
+		Name:       "aws",
+		Version:    "1.0.0",
+	}}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := os.ReadFile(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the registries on the loopback addresses are always served over plain HTTP by the registry client
+	server := httptest.NewServer(registryHandler(t, "charts/aws", "1.0.0", archive, "user", "secret"))
+	defer server.Close()
+	// the artifact server of the source-controller is down
+	artifactServer := httptest.NewServer(http.NotFoundHandler())
+	artifactURL := artifactServer.URL + "/aws-1.0.0.tgz"
+	artifactServer.Close()
+
+	scheme := runtime.NewScheme()
+	if err := sourcev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	dockerConfig, err := json.Marshal(map[string]any{"auths": map[string]any{
+		host: map[string]string{"username": "user", "password": "secret"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &sourcev1.HelmRepository{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "hmc-system", Name: "hmc-templates"},
+		Spec: sourcev1.HelmRepositorySpec{
+			URL:       "oci://" + host + "/charts",
+			Type:      sourcev1.HelmRepositoryTypeOCI,
+			SecretRef: &meta.LocalObjectReference{Name: "registry-credentials"},
+		},
+	}
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "hmc-system", Name: "registry-credentials"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{corev1.DockerConfigJsonKey: dockerConfig},
+	}
+	hc := &sourcev1.HelmChart{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "hmc-system", Name: "aws"},
+		Spec: sourcev1.HelmChartSpec{
+			Chart:     "aws",
+			Version:   "1.0.x",
+			SourceRef: sourcev1.LocalHelmChartSourceReference{Kind: sourcev1.HelmRepositoryKind, Name: "hmc-templates"},
+		},
+		Status: sourcev1.HelmChartStatus{Artifact: &sourcev1.Artifact{
+			URL: artifactURL, Revision: "1.0.0", Digest: godigest.FromBytes(archive).String(),
+		}},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(repo, credentials, hc).Build()
+
+	helmChart, err := DownloadHelmChart(ctx, cl, hc.DeepCopy())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if helmChart.Metadata.Version != "1.0.0" {
+		t.Errorf("expected chart version 1.0.0, got %s", helmChart.Metadata.Version)
+	}
+
+	// the pulled chart must match the digest of the artifact
+	mismatched := hc.DeepCopy()
+	mismatched.Status.Artifact.Digest = godigest.FromString("other").String()
+	if _, err := PullOCIChart(ctx, cl, mismatched); !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("expected digest mismatch, got %v", err)
+	}
+
+	// the repositories authenticating with the cloud providers are not pulled from
+	repo.Spec.Provider = "aws"
+	cl = fake.NewClientBuilder().WithScheme(scheme).WithObjects(repo, credentials, hc).Build()
+	if _, err := PullOCIChart(ctx, cl, hc.DeepCopy()); !errors.Is(err, ErrUnsupportedProvider) {
+		t.Errorf("expected unsupported provider, got %v", err)
+	}
+	if _, err := DownloadHelmChart(ctx, cl, hc.DeepCopy()); err == nil || errors.Is(err, ErrUnsupportedProvider) {
+		t.Errorf("expected the artifact download error, got %v", err)
+	}
+}
+
+func TestRegistryTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	secret := &corev1.Secret{Data: map[string][]byte{
+		"ca.crt": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}),
+	}}
+	tlsConfig, err := registryTLSConfig(secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected the registry certificate to be trusted: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	secret.Data = map[string][]byte{"ca.crt": []byte("invalid")}
+	if _, err := registryTLSConfig(secret); err == nil {
+		t.Error("expected an error for the invalid CA")
+	}
+	secret.Data = map[string][]byte{"tls.crt": []byte("invalid")}
+	if _, err := registryTLSConfig(secret); err == nil {
+		t.Error("expected an error for the invalid client certificate")
+	}
+}
@@ -0,0 +1,319 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// chartReadmeName is the name of the README of the chart.
+const chartReadmeName = "README.md"
+
+// ChartParameters returns the summary of the values of the chart sorted by name. The parameters
+// are collected from the values JSON schema and the default values of the chart, the types and the
+// descriptions missing in the schema are taken from the parameters table of the README of the chart,
+// e.g. the one generated by helm-docs. The nested values are described by their leaves.
+func ChartParameters(helmChart *chart.Chart) ([]hmc.TemplateParameter, error) {
+	params := make(map[string]*hmc.TemplateParameter)
+
+	if len(helmChart.Schema) > 0 {
+		schema := make(map[string]any)
+		if err := json.Unmarshal(helmChart.Schema, &schema); err != nil {
+			return nil, fmt.Errorf("failed to parse values schema: %w", err)
+		}
+		collectSchemaParameters("", schema, params)
+	}
+	collectValuesParameters("", helmChart.Values, params)
+
+	for name, row := range readmeParameters(chartReadme(helmChart)) {
+		param, ok := params[name]
+		if !ok {
+			continue
+		}
+		if param.Type == "" {
+			param.Type = row.Type
+		}
+		if param.Description == "" {
+			param.Description = row.Description
+		}
+	}
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]hmc.TemplateParameter, 0, len(names))
+	for _, name := range names {
+		param := params[name]
+		if value, ok := lookupValue(helmChart.Values, name); ok && value != nil {
+			param.Default = rawJSON(value)
+		}
+		result = append(result, *param)
+	}
+	return result, nil
+}
+
+// collectSchemaParameters collects the leaves of the properties of the given schema. The properties
+// of the object type without the nested properties, e.g. the maps, are considered leaves.
+func collectSchemaParameters(prefix string, schema map[string]any, params map[string]*hmc.TemplateParameter) {
+	properties, _ := schema["properties"].(map[string]any)
+	required := make(map[string]bool)
+	if list, ok := schema["required"].([]any); ok {
+		for _, key := range list {
+			if key, ok := key.(string); ok {
+				required[key] = true
+			}
+		}
+	}
+
+	for key, v := range properties {
+		prop, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		name := joinValuesPath(prefix, key)
+		if nested, ok := prop["properties"].(map[string]any); ok && len(nested) > 0 {
+			collectSchemaParameters(name, prop, params)
+			continue
+		}
+
+		param := &hmc.TemplateParameter{
+			Name:     name,
+			Type:     schemaType(prop["type"]),
+			Required: required[key],
+		}
+		param.Description, _ = prop["description"].(string)
+		if value, ok := prop["default"]; ok && value != nil {
+			param.Default = rawJSON(value)
+		}
+		params[name] = param
+	}
+}
+
+// collectValuesParameters collects the leaves of the default values not described by the schema.
+// The lists and the empty maps are considered leaves.
+func collectValuesParameters(prefix string, values map[string]any, params map[string]*hmc.TemplateParameter) {
+	for key, value := range values {
+		name := joinValuesPath(prefix, key)
+		if param, ok := params[name]; ok {
+			if param.Type == "" {
+				param.Type = valueType(value)
+			}
+			continue
+		}
+		if nested, ok := value.(map[string]any); ok && len(nested) > 0 {
+			collectValuesParameters(name, nested, params)
+			continue
+		}
+		if hasNestedParameter(params, name) {
+			continue // the empty map is described by the schema
+		}
+		params[name] = &hmc.TemplateParameter{Name: name, Type: valueType(value)}
+	}
+}
+
+// hasNestedParameter returns true if some of the parameters are nested under the given path.
+func hasNestedParameter(params map[string]*hmc.TemplateParameter, path string) bool {
+	for name := range params {
+		if strings.HasPrefix(name, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaType returns the type of the JSON schema property, several types are separated with "|".
+func schemaType(t any) string {
+	switch t := t.(type) {
+	case string:
+		return t
+	case []any:
+		types := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return strings.Join(types, "|")
+	default:
+		return ""
+	}
+}
+
+// valueType returns the JSON schema type of the value.
+func valueType(value any) string {
+	switch v := value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int64:
+		return "integer"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return ""
+	}
+}
+
+// readmeParameter is a row of the parameters table of the README of the chart.
+type readmeParameter struct {
+	Type        string
+	Description string
+}
+
+// chartReadme returns the README of the chart if it is packaged with the chart.
+func chartReadme(helmChart *chart.Chart) []byte {
+	for _, f := range helmChart.Files {
+		if f != nil && f.Name == chartReadmeName {
+			return f.Data
+		}
+	}
+	return nil
+}
+
+// readmeParameters parses the Markdown tables of the README having the "Key" (or "Parameter")
+// and the "Description" columns, the "Type" column is optional. The rows are keyed by the
+// dot-separated path of the value.
+func readmeParameters(readme []byte) map[string]readmeParameter {
+	params := make(map[string]readmeParameter)
+
+	var columns map[string]int
+	scanner := bufio.NewScanner(bytes.NewReader(readme))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "|") {
+			columns = nil
+			continue
+		}
+
+		cells := tableCells(line)
+		if columns == nil {
+			columns = tableColumns(cells)
+			continue
+		}
+		if isTableSeparator(cells) {
+			continue
+		}
+
+		key := strings.Trim(tableCell(cells, columns, "key"), "`")
+		if key == "" {
+			continue
+		}
+		params[key] = readmeParameter{
+			Type:        strings.Trim(tableCell(cells, columns, "type"), "`"),
+			Description: tableCell(cells, columns, "description"),
+		}
+	}
+	return params
+}
+
+// tableColumns returns the indexes of the known columns of the table with the given header,
+// the header of the table with no key or description columns is not considered a parameters table.
+func tableColumns(header []string) map[string]int {
+	columns := make(map[string]int)
+	for i, cell := range header {
+		switch strings.ToLower(cell) {
+		case "key", "parameter", "name":
+			columns["key"] = i
+		case "type":
+			columns["type"] = i
+		case "description":
+			columns["description"] = i
+		}
+	}
+	if _, ok := columns["key"]; !ok {
+		return map[string]int{}
+	}
+	if _, ok := columns["description"]; !ok {
+		return map[string]int{}
+	}
+	return columns
+}
+
+func tableCells(line string) []string {
+	cells := strings.Split(strings.TrimSuffix(strings.TrimPrefix(line, "|"), "|"), "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+func tableCell(cells []string, columns map[string]int, column string) string {
+	i, ok := columns[column]
+	if !ok || i >= len(cells) {
+		return ""
+	}
+	return cells[i]
+}
+
+func isTableSeparator(cells []string) bool {
+	for _, cell := range cells {
+		if strings.Trim(cell, ":-") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func joinValuesPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// lookupValue returns the value at the given dot-separated path.
+func lookupValue(values map[string]any, path string) (any, bool) {
+	var cur any = values
+	for _, key := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func rawJSON(value any) *apiextensionsv1.JSON {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	return &apiextensionsv1.JSON{Raw: raw}
+}
@@ -0,0 +1,98 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"reflect"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+func TestChartParameters(t *testing.T) {
+	helmChart := &chart.Chart{
+		Schema: []byte(`{
+  "type": "object",
+  "required": ["region"],
+  "properties": {
+    "region": {"description": "AWS region", "type": "string"},
+    "sshKeyName": {"type": ["string", "null"]},
+    "controlPlane": {
+      "type": "object",
+      "properties": {
+        "instanceType": {"description": "Instance type", "type": "string", "default": "t3.small"},
+        "rootVolumeSize": {"type": "integer"}
+      }
+    },
+    "clusterIdentity": {"type": "object"},
+    "nodePools": {
+      "type": "object",
+      "properties": {
+        "worker": {"type": "object", "properties": {"labels": {"type": "object"}}}
+      }
+    }
+  }
+}`),
+		Values: map[string]any{
+			"region":          "",
+			"sshKeyName":      nil,
+			"controlPlane":    map[string]any{"rootVolumeSize": float64(8)},
+			"clusterIdentity": map[string]any{"name": "aws-cluster-identity", "kind": "AWSClusterStaticIdentity"},
+			"nodePools":       map[string]any{},
+			"workersNumber":   float64(2),
+			"k0s":             map[string]any{"version": "v1.31.1+k0s.1"},
+		},
+		Files: []*chart.File{{Name: chartReadmeName, Data: []byte(`# Chart
+
+| Key | Type | Default | Description |
+|-----|------|---------|-------------|
+| ` + "`workersNumber`" + ` | int | ` + "`2`" + ` | The number of the worker machines |
+| controlPlane.rootVolumeSize | int | 8 | Root volume size in GiB |
+| region | string | "" | Ignored, the schema describes the region |
+
+| Name | Value |
+|------|-------|
+| k0s.version | Not a parameters table |
+`)}},
+	}
+
+	expected := []hmc.TemplateParameter{
+		{Name: "clusterIdentity", Type: "object", Default: &apiextensionsv1.JSON{Raw: []byte(`{"kind":"AWSClusterStaticIdentity","name":"aws-cluster-identity"}`)}},
+		{Name: "controlPlane.instanceType", Type: "string", Description: "Instance type", Default: &apiextensionsv1.JSON{Raw: []byte(`"t3.small"`)}},
+		{Name: "controlPlane.rootVolumeSize", Type: "integer", Description: "Root volume size in GiB", Default: &apiextensionsv1.JSON{Raw: []byte(`8`)}},
+		{Name: "k0s.version", Type: "string", Default: &apiextensionsv1.JSON{Raw: []byte(`"v1.31.1+k0s.1"`)}},
+		{Name: "nodePools.worker.labels", Type: "object"},
+		{Name: "region", Type: "string", Description: "AWS region", Required: true, Default: &apiextensionsv1.JSON{Raw: []byte(`""`)}},
+		{Name: "sshKeyName", Type: "string|null"},
+		{Name: "workersNumber", Type: "integer", Description: "The number of the worker machines", Default: &apiextensionsv1.JSON{Raw: []byte(`2`)}},
+	}
+
+	actual, err := ChartParameters(helmChart)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected parameters %+v, got %+v", expected, actual)
+	}
+}
+
+func TestChartParametersInvalidSchema(t *testing.T) {
+	if _, err := ChartParameters(&chart.Chart{Schema: []byte(`{`)}); err == nil {
+		t.Error("expected error on the invalid schema")
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	"helm.sh/helm/v3/pkg/provenance"
+	helmrepo "helm.sh/helm/v3/pkg/repo"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/Mirantis/hmc/internal/errdefs"
+)
+
+// provenanceSuffix is the suffix of the provenance file of a chart archive.
+const provenanceSuffix = ".prov"
+
+// provenanceClient downloads the provenance files from the HelmRepositories.
+var provenanceClient = &http.Client{Timeout: 30 * time.Second}
+
+// VerifyProvenance verifies the chart of the HelmChart artifact against the
+// provenance file published next to the chart archive in the HTTP HelmRepository.
+// The provenance file must be signed by one of the keys of the given keyring and
+// list the digest of the chart archive.
+func VerifyProvenance(ctx context.Context, cl client.Client, hc *sourcev1.HelmChart, keyring []byte) error {
+	if hc.Spec.SourceRef.Kind != sourcev1.HelmRepositoryKind {
+		return fmt.Errorf("provenance files are supported only for the charts from a HelmRepository, HelmChart %s/%s refers to a %s",
+			hc.Namespace, hc.Name, hc.Spec.SourceRef.Kind)
+	}
+	if hc.Status.Artifact == nil {
+		return fmt.Errorf("artifact of HelmChart %s/%s is not ready yet", hc.Namespace, hc.Name)
+	}
+
+	repo := &sourcev1.HelmRepository{}
+	repoKey := client.ObjectKey{Namespace: hc.Namespace, Name: hc.Spec.SourceRef.Name}
+	if err := cl.Get(ctx, repoKey, repo); err != nil {
+		return fmt.Errorf("failed to get HelmRepository %s: %w", repoKey, err)
+	}
+	if repo.Spec.Type == sourcev1.HelmRepositoryTypeOCI {
+		return fmt.Errorf("provenance files are supported only for HTTP HelmRepositories, %s is an OCI HelmRepository", repoKey)
+	}
+	if repo.Status.Artifact == nil {
+		return fmt.Errorf("index of HelmRepository %s is not ready yet", repoKey)
+	}
+
+	chartURL, err := chartArchiveURL(ctx, repo, hc.Spec.Chart, hc.Status.Artifact.Revision)
+	if err != nil {
+		return err
+	}
+
+	var username, password string
+	if repo.Spec.SecretRef != nil {
+		secret := &corev1.Secret{}
+		secretKey := client.ObjectKey{Namespace: repo.Namespace, Name: repo.Spec.SecretRef.Name}
+		if err := cl.Get(ctx, secretKey, secret); err != nil {
+			return fmt.Errorf("failed to get the credentials Secret %s: %w", secretKey, err)
+		}
+		username, password = string(secret.Data["username"]), string(secret.Data["password"])
+	}
+
+	prov, err := fetch(ctx, chartURL+provenanceSuffix, username, password)
+	if err != nil {
+		return fmt.Errorf("failed to download the provenance file of chart %s: %w", chartURL, err)
+	}
+	archive, err := DownloadArtifact(ctx, hc.Status.Artifact.URL, hc.Status.Artifact.Digest)
+	if err != nil {
+		return fmt.Errorf("failed to download the artifact of HelmChart %s/%s: %w", hc.Namespace, hc.Name, err)
+	}
+
+	// the provenance is verified against the files named as the chart archive in the repository
+	dir, err := os.MkdirTemp("", "hmc-provenance-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	chartPath := filepath.Join(dir, path.Base(chartURL))
+	keyringPath := filepath.Join(dir, "keyring.gpg")
+	for name, data := range map[string][]byte{
+		chartPath:                    archive.Bytes(),
+		chartPath + provenanceSuffix: prov,
+		keyringPath:                  keyring,
+	} {
+		if err := os.WriteFile(name, data, 0o600); err != nil {
+			return err
+		}
+	}
+
+	signatory, err := provenance.NewFromKeyring(keyringPath, "")
+	if err != nil {
+		return fmt.Errorf("failed to load the keyring: %w", err)
+	}
+	if _, err := signatory.Verify(chartPath, chartPath+provenanceSuffix); err != nil {
+		return fmt.Errorf("failed to verify the provenance of chart %s: %w", chartURL, err)
+	}
+	return nil
+}
+
+// chartArchiveURL returns the URL of the archive of the given chart version
+// listed in the index of the HelmRepository.
+func chartArchiveURL(ctx context.Context, repo *sourcev1.HelmRepository, name, version string) (string, error) {
+	buf, err := DownloadArtifact(ctx, repo.Status.Artifact.URL, repo.Status.Artifact.Digest)
+	if err != nil {
+		return "", fmt.Errorf("failed to download the index of HelmRepository %s/%s: %w", repo.Namespace, repo.Name, err)
+	}
+
+	index := &helmrepo.IndexFile{}
+	if err := yaml.Unmarshal(buf.Bytes(), index); err != nil {
+		return "", fmt.Errorf("failed to parse the index of HelmRepository %s/%s: %w", repo.Namespace, repo.Name, err)
+	}
+
+	chartVersion, err := index.Get(name, version)
+	if err != nil {
+		return "", fmt.Errorf("chart %s %s is not found in HelmRepository %s/%s: %w", name, version, repo.Namespace, repo.Name, err)
+	}
+	if len(chartVersion.URLs) == 0 {
+		return "", fmt.Errorf("chart %s %s has no URLs in HelmRepository %s/%s", name, version, repo.Namespace, repo.Name)
+	}
+	return helmrepo.ResolveReferenceURL(repo.Spec.URL, chartVersion.URLs[0])
+}
+
+// fetch downloads the given URL, authenticating with the basic auth credentials if set.
+func fetch(ctx context.Context, url, username, password string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if username != "" || password != "" {
+		req.SetBasicAuth(username, password)
+	}
+
+	resp, err := provenanceClient.Do(req)
+	if err != nil {
+		return nil, errdefs.Transient(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, errdefs.Transient(errors.New(resp.Status))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,154 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	"golang.org/x/crypto/openpgp" //nolint:staticcheck // the provenance files are signed with the deprecated package by Helm
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/provenance"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/Mirantis/hmc/internal/errdefs"
+)
+
+func TestVerifyProvenance(t *testing.T) {
+	dir := t.TempDir()
+	archivePath, err := chartutil.Save(&chart.Chart{Metadata: &chart.Metadata{
+		APIVersion: chart.APIVersionV2,
+		Name:       "aws",
+		Version:    "1.0.0",
+	}}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := os.ReadFile(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := openpgp.NewEntity("hmc", "", "hmc@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prov, err := (&provenance.Signatory{Entity: signer, KeyRing: openpgp.EntityList{signer}}).ClearSign(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring := new(bytes.Buffer)
+	if err := signer.Serialize(keyring); err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := openpgp.NewEntity("other", "", "other@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKeyring := new(bytes.Buffer)
+	if err := other.Serialize(otherKeyring); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string][]byte{
+		"/index.yaml": []byte(`apiVersion: v1
+entries:
+  aws:
+  - name: aws
+    version: 1.0.0
+    urls:
+    - charts/aws-1.0.0.tgz
+`),
+		"/charts/aws-1.0.0.tgz":      archive,
+		"/charts/aws-1.0.0.tgz.prov": []byte(prov),
+		"/artifact/aws-1.0.0.tgz":    archive,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if data == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	scheme := runtime.NewScheme()
+	if err := sourcev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	repo := &sourcev1.HelmRepository{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "hmc-system", Name: "hmc-templates"},
+		Spec:       sourcev1.HelmRepositorySpec{URL: server.URL + "/"},
+		Status:     sourcev1.HelmRepositoryStatus{Artifact: &sourcev1.Artifact{URL: server.URL + "/index.yaml"}},
+	}
+	hc := &sourcev1.HelmChart{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "hmc-system", Name: "aws"},
+		Spec: sourcev1.HelmChartSpec{
+			Chart:     "aws",
+			Version:   "1.0.0",
+			SourceRef: sourcev1.LocalHelmChartSourceReference{Kind: sourcev1.HelmRepositoryKind, Name: "hmc-templates"},
+		},
+		Status: sourcev1.HelmChartStatus{Artifact: &sourcev1.Artifact{URL: server.URL + "/artifact/aws-1.0.0.tgz", Revision: "1.0.0"}},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(repo).Build()
+
+	if err := VerifyProvenance(context.Background(), cl, hc, keyring.Bytes()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err = VerifyProvenance(context.Background(), cl, hc, otherKeyring.Bytes())
+	if err == nil {
+		t.Error("expected an error for the chart signed by a key missing from the keyring")
+	}
+
+	files["/artifact/aws-1.0.0.tgz"] = append(bytes.Clone(archive), 0)
+	err = VerifyProvenance(context.Background(), cl, hc, keyring.Bytes())
+	if err == nil || !strings.Contains(err.Error(), "sha256 sum does not match") {
+		t.Errorf("expected a digest mismatch for the modified artifact, got %v", err)
+	}
+
+	delete(files, "/charts/aws-1.0.0.tgz.prov")
+	err = VerifyProvenance(context.Background(), cl, hc, keyring.Bytes())
+	if err == nil || errdefs.IsTransient(err) {
+		t.Errorf("expected a non-transient error for the missing provenance file, got %v", err)
+	}
+
+	// the nil files are served as unavailable
+	files["/charts/aws-1.0.0.tgz.prov"] = nil
+	if err := VerifyProvenance(context.Background(), cl, hc, keyring.Bytes()); !errdefs.IsTransient(err) {
+		t.Errorf("expected a transient error for the unavailable repository, got %v", err)
+	}
+
+	repo.Spec.Type = sourcev1.HelmRepositoryTypeOCI
+	cl = fake.NewClientBuilder().WithScheme(scheme).WithObjects(repo).Build()
+	if err := VerifyProvenance(context.Background(), cl, hc, keyring.Bytes()); err == nil {
+		t.Error("expected an error for the OCI HelmRepository")
+	}
+}
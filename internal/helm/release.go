@@ -16,17 +16,21 @@ package helm
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	hcv2 "github.com/fluxcd/helm-controller/api/v2"
 	"github.com/fluxcd/pkg/apis/meta"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/utils"
 )
 
 const (
@@ -41,8 +45,23 @@ type ReconcileHelmReleaseOpts struct {
 	TargetNamespace   string
 	DependsOn         []meta.NamespacedObjectReference
 	CreateNamespace   bool
+	// Timeout is the time to wait for the install and upgrade of the release,
+	// the Flux default is used if not set.
+	Timeout *time.Duration
+	// RemediationRetries is the number of retries of the failed install and upgrade of the release.
+	RemediationRetries int
+	// DriftDetectionMode is the mode of the detection of the drift of the deployed resources,
+	// the drift detection is disabled if not set.
+	DriftDetectionMode hcv2.DriftDetectionMode
+	// Suspend suspends the reconciliation of the HelmRelease.
+	Suspend bool
+	// ReuseValues merges the values onto the values of the existing HelmRelease
+	// instead of replacing them, the equivalent of the helm upgrade --reuse-values.
+	ReuseValues bool
 }
 
+// ReconcileHelmRelease applies the HelmRelease built from the options with
+// the server-side apply and returns the performed operation.
 func ReconcileHelmRelease(ctx context.Context,
 	cl client.Client,
 	name string,
@@ -53,35 +72,31 @@ func ReconcileHelmRelease(ctx context.Context,
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: namespace,
+			Labels: map[string]string{
+				hmc.HMCManagedLabelKey: hmc.HMCManagedLabelValue,
+			},
 		},
+		Spec: NewHelmReleaseSpec(name, opts),
+	}
+	if opts.OwnerReference != nil {
+		hr.OwnerReferences = []metav1.OwnerReference{*opts.OwnerReference}
 	}
 
-	operation, err := ctrl.CreateOrUpdate(ctx, cl, hr, func() error {
-		if hr.Labels == nil {
-			hr.Labels = make(map[string]string)
+	if opts.ReuseValues {
+		current := &hcv2.HelmRelease{}
+		err := cl.Get(ctx, client.ObjectKeyFromObject(hr), current)
+		if client.IgnoreNotFound(err) != nil {
+			return nil, controllerutil.OperationResultNone, fmt.Errorf("failed to get HelmRelease %s: %w", client.ObjectKeyFromObject(hr), err)
 		}
-		hr.Labels[hmc.HMCManagedLabelKey] = hmc.HMCManagedLabelValue
-		if opts.OwnerReference != nil {
-			hr.OwnerReferences = []metav1.OwnerReference{*opts.OwnerReference}
-		}
-		hr.Spec = hcv2.HelmReleaseSpec{
-			ChartRef: opts.ChartRef,
-			Interval: metav1.Duration{Duration: func() time.Duration {
-				if opts.ReconcileInterval != nil {
-					return *opts.ReconcileInterval
-				}
-				return DefaultReconcileInterval
-			}()},
-			ReleaseName:     name,
-			Values:          opts.Values,
-			DependsOn:       opts.DependsOn,
-			TargetNamespace: opts.TargetNamespace,
-			Install: &hcv2.Install{
-				CreateNamespace: opts.CreateNamespace,
-			},
+		values, applied, err := ReusedValues(current, opts.Values)
+		if err != nil {
+			return nil, controllerutil.OperationResultNone, err
 		}
-		return nil
-	})
+		hr.Spec.Values = values
+		hr.Annotations = map[string]string{hmc.AppliedValuesAnnotation: applied}
+	}
+
+	operation, err := utils.Apply(ctx, cl, hr)
 	if err != nil {
 		return nil, operation, err
 	}
@@ -89,6 +104,136 @@ func ReconcileHelmRelease(ctx context.Context,
 	return hr, operation, nil
 }
 
+// NewHelmReleaseSpec returns the spec of the HelmRelease with the given name built from the options.
+func NewHelmReleaseSpec(name string, opts ReconcileHelmReleaseOpts) hcv2.HelmReleaseSpec {
+	spec := hcv2.HelmReleaseSpec{
+		ChartRef: opts.ChartRef,
+		Interval: metav1.Duration{Duration: func() time.Duration {
+			if opts.ReconcileInterval != nil {
+				return *opts.ReconcileInterval
+			}
+			return DefaultReconcileInterval
+		}()},
+		ReleaseName:     name,
+		Suspend:         opts.Suspend,
+		Values:          opts.Values,
+		DependsOn:       opts.DependsOn,
+		TargetNamespace: opts.TargetNamespace,
+		Install: &hcv2.Install{
+			CreateNamespace: opts.CreateNamespace,
+		},
+	}
+
+	if opts.Timeout != nil {
+		spec.Timeout = &metav1.Duration{Duration: *opts.Timeout}
+	}
+	if opts.RemediationRetries > 0 {
+		spec.Install.Remediation = &hcv2.InstallRemediation{Retries: opts.RemediationRetries}
+		spec.Upgrade = &hcv2.Upgrade{Remediation: &hcv2.UpgradeRemediation{Retries: opts.RemediationRetries}}
+	}
+	if opts.DriftDetectionMode != "" {
+		spec.DriftDetection = &hcv2.DriftDetection{Mode: opts.DriftDetectionMode}
+	}
+
+	return spec
+}
+
+// ReusedValues returns the values of the HelmRelease reusing its current values and the
+// paths of the applied values to be recorded in the hmc.AppliedValuesAnnotation of the HelmRelease.
+// The values applied previously and missing in the given values are removed from the current values.
+func ReusedValues(current *hcv2.HelmRelease, values *apiextensionsv1.JSON) (*apiextensionsv1.JSON, string, error) {
+	var previous [][]string
+	if raw := current.Annotations[hmc.AppliedValuesAnnotation]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &previous); err != nil {
+			return nil, "", fmt.Errorf("failed to parse the applied values paths of the HelmRelease: %w", err)
+		}
+	}
+	return reuseValues(current.Spec.Values, previous, values)
+}
+
+// reuseValues merges the values onto the current values of the HelmRelease
+// with the previously applied values missing in the values removed.
+func reuseValues(current *apiextensionsv1.JSON, previous [][]string, values *apiextensionsv1.JSON) (*apiextensionsv1.JSON, string, error) {
+	var currentValues, newValues map[string]any
+	if current != nil {
+		if err := json.Unmarshal(current.Raw, &currentValues); err != nil {
+			return nil, "", fmt.Errorf("failed to parse the current values of the HelmRelease: %w", err)
+		}
+	}
+	if values != nil {
+		if err := json.Unmarshal(values.Raw, &newValues); err != nil {
+			return nil, "", fmt.Errorf("failed to parse the values of the HelmRelease: %w", err)
+		}
+	}
+
+	applied := valuesPaths(nil, newValues)
+	appliedRaw, err := json.Marshal(applied)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal the applied values paths: %w", err)
+	}
+
+	if current == nil {
+		return values, string(appliedRaw), nil
+	}
+
+	appliedSet := make(map[string]struct{}, len(applied))
+	for _, path := range applied {
+		appliedSet[strings.Join(path, "\x00")] = struct{}{}
+	}
+	for _, path := range previous {
+		if _, ok := appliedSet[strings.Join(path, "\x00")]; !ok {
+			removeValue(currentValues, path)
+		}
+	}
+
+	merged, err := MergeValues(hmc.ConfigMergeStrategyMerge, nil, currentValues, newValues)
+	if err != nil {
+		return nil, "", err
+	}
+
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal the values of the HelmRelease: %w", err)
+	}
+	return &apiextensionsv1.JSON{Raw: raw}, string(appliedRaw), nil
+}
+
+// valuesPaths returns the paths of the leaf values, the lists are the leaves.
+func valuesPaths(prefix []string, values map[string]any) [][]string {
+	var paths [][]string
+	for k, v := range values {
+		path := append(slices.Clone(prefix), k)
+		if m, ok := v.(map[string]any); ok && len(m) > 0 {
+			paths = append(paths, valuesPaths(path, m)...)
+			continue
+		}
+		paths = append(paths, path)
+	}
+	slices.SortFunc(paths, func(a, b []string) int {
+		return slices.Compare(a, b)
+	})
+	return paths
+}
+
+// removeValue removes the value at the path along with the parents left empty.
+func removeValue(values map[string]any, path []string) {
+	if len(path) == 0 {
+		return
+	}
+	if len(path) == 1 {
+		delete(values, path[0])
+		return
+	}
+	child, ok := values[path[0]].(map[string]any)
+	if !ok {
+		return
+	}
+	removeValue(child, path[1:])
+	if len(child) == 0 {
+		delete(values, path[0])
+	}
+}
+
 func DeleteHelmRelease(ctx context.Context, cl client.Client, name, namespace string) error {
 	err := cl.Delete(ctx, &hcv2.HelmRelease{
 		ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,138 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	hcv2 "github.com/fluxcd/helm-controller/api/v2"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+func TestNewHelmReleaseSpecTuning(t *testing.T) {
+	timeout := 30 * time.Minute
+
+	for _, tc := range []struct {
+		name     string
+		opts     ReconcileHelmReleaseOpts
+		expected hcv2.HelmReleaseSpec
+	}{
+		{
+			name: "defaults",
+			expected: hcv2.HelmReleaseSpec{
+				ReleaseName: "release",
+				Interval:    metav1.Duration{Duration: DefaultReconcileInterval},
+				Install:     &hcv2.Install{},
+			},
+		},
+		{
+			name: "tuned",
+			opts: ReconcileHelmReleaseOpts{
+				Timeout:            &timeout,
+				RemediationRetries: 3,
+				DriftDetectionMode: hcv2.DriftDetectionWarn,
+			},
+			expected: hcv2.HelmReleaseSpec{
+				ReleaseName:    "release",
+				Interval:       metav1.Duration{Duration: DefaultReconcileInterval},
+				Timeout:        &metav1.Duration{Duration: timeout},
+				Install:        &hcv2.Install{Remediation: &hcv2.InstallRemediation{Retries: 3}},
+				Upgrade:        &hcv2.Upgrade{Remediation: &hcv2.UpgradeRemediation{Retries: 3}},
+				DriftDetection: &hcv2.DriftDetection{Mode: hcv2.DriftDetectionWarn},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := NewHelmReleaseSpec("release", tc.opts)
+			if !reflect.DeepEqual(spec, tc.expected) {
+				t.Errorf("unexpected spec: got %+v, expected %+v", spec, tc.expected)
+			}
+		})
+	}
+}
+
+func TestReuseValues(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		current         *apiextensionsv1.JSON
+		previous        [][]string
+		values          *apiextensionsv1.JSON
+		expected        string
+		expectedApplied string
+	}{
+		{
+			name:            "no current values",
+			values:          &apiextensionsv1.JSON{Raw: []byte(`{"a":1}`)},
+			expected:        `{"a":1}`,
+			expectedApplied: `[["a"]]`,
+		},
+		{
+			name:            "values merged onto the current values",
+			current:         &apiextensionsv1.JSON{Raw: []byte(`{"a":1,"b":{"c":2,"d":3}}`)},
+			values:          &apiextensionsv1.JSON{Raw: []byte(`{"b":{"c":4}}`)},
+			expected:        `{"a":1,"b":{"c":4,"d":3}}`,
+			expectedApplied: `[["b","c"]]`,
+		},
+		{
+			name:            "previously applied values removed from the values",
+			current:         &apiextensionsv1.JSON{Raw: []byte(`{"a":1,"b":{"c":2,"d":3},"e":{"f":[1]}}`)},
+			previous:        [][]string{{"b", "c"}, {"b", "d"}, {"e", "f"}},
+			values:          &apiextensionsv1.JSON{Raw: []byte(`{"b":{"c":4}}`)},
+			expected:        `{"a":1,"b":{"c":4}}`,
+			expectedApplied: `[["b","c"]]`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			values, applied, err := reuseValues(tc.current, tc.previous, tc.values)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(values.Raw) != tc.expected {
+				t.Errorf("unexpected values: got %s, expected %s", values.Raw, tc.expected)
+			}
+			if applied != tc.expectedApplied {
+				t.Errorf("unexpected applied values: got %s, expected %s", applied, tc.expectedApplied)
+			}
+		})
+	}
+}
+
+func TestReusedValues(t *testing.T) {
+	// the values are applied with ReuseValues first, the applied paths are recorded
+	hr := &hcv2.HelmRelease{Spec: hcv2.HelmReleaseSpec{Values: &apiextensionsv1.JSON{Raw: []byte(`{"manual":true}`)}}}
+	values, applied, err := ReusedValues(hr, &apiextensionsv1.JSON{Raw: []byte(`{"a":1,"b":2}`)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(values.Raw) != `{"a":1,"b":2,"manual":true}` {
+		t.Errorf("unexpected values: %s", values.Raw)
+	}
+
+	// the value removed from the config is removed from the release, the rest of the values are reused
+	hr.Spec.Values = values
+	hr.Annotations = map[string]string{hmc.AppliedValuesAnnotation: applied}
+	values, _, err = ReusedValues(hr, &apiextensionsv1.JSON{Raw: []byte(`{"a":1}`)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(values.Raw) != `{"a":1,"manual":true}` {
+		t.Errorf("unexpected values: %s", values.Raw)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// RenderedKinds renders the chart with its default values and returns the
+// sorted list of the kinds of the rendered objects. The values schema is not
+// enforced, so that the charts requiring user input can still be rendered.
+func RenderedKinds(helmChart *chart.Chart, releaseName, releaseNamespace string) ([]schema.GroupKind, error) {
+	var kinds []schema.GroupKind
+	err := renderManifests(helmChart, releaseName, releaseNamespace, func(name, manifest string) error {
+		var typeMeta struct {
+			APIVersion string `json:"apiVersion"`
+			Kind       string `json:"kind"`
+		}
+		if err := yaml.Unmarshal([]byte(manifest), &typeMeta); err != nil {
+			return fmt.Errorf("failed to parse rendered manifest %s: %w", name, err)
+		}
+		if typeMeta.Kind == "" {
+			return nil
+		}
+
+		gv, err := schema.ParseGroupVersion(typeMeta.APIVersion)
+		if err != nil {
+			return fmt.Errorf("invalid apiVersion of %s in rendered manifest %s: %w", typeMeta.Kind, name, err)
+		}
+		kinds = append(kinds, gv.WithKind(typeMeta.Kind).GroupKind())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(kinds, func(a, b schema.GroupKind) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	return slices.Compact(kinds), nil
+}
+
+// RenderedCRDVersions renders the chart with its default values and returns
+// the sorted lists of the served versions of the rendered CustomResourceDefinitions,
+// where the key is the name of the CustomResourceDefinition.
+func RenderedCRDVersions(helmChart *chart.Chart, releaseName, releaseNamespace string) (map[string][]string, error) {
+	var crdVersions map[string][]string
+	err := renderManifests(helmChart, releaseName, releaseNamespace, func(name, manifest string) error {
+		var crd struct {
+			APIVersion string `json:"apiVersion"`
+			Kind       string `json:"kind"`
+			Metadata   struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Spec struct {
+				Versions []struct {
+					Name   string `json:"name"`
+					Served bool   `json:"served"`
+				} `json:"versions"`
+			} `json:"spec"`
+		}
+		if err := yaml.Unmarshal([]byte(manifest), &crd); err != nil {
+			return fmt.Errorf("failed to parse rendered manifest %s: %w", name, err)
+		}
+		if crd.Kind != "CustomResourceDefinition" || crd.APIVersion != "apiextensions.k8s.io/v1" {
+			return nil
+		}
+
+		var versions []string
+		for _, v := range crd.Spec.Versions {
+			if v.Served {
+				versions = append(versions, v.Name)
+			}
+		}
+		slices.Sort(versions)
+
+		if crdVersions == nil {
+			crdVersions = make(map[string][]string)
+		}
+		crdVersions[crd.Metadata.Name] = versions
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return crdVersions, nil
+}
+
+// renderManifests renders the chart with its default values and calls fn
+// for each of the rendered YAML and JSON manifests.
+func renderManifests(helmChart *chart.Chart, releaseName, releaseNamespace string, fn func(name, manifest string) error) error {
+	return renderManifestsWithValues(helmChart, nil, RenderOptions{ReleaseName: releaseName, ReleaseNamespace: releaseNamespace}, true, fn)
+}
+
+// renderManifestsWithValues renders the chart with the values coalesced with its default values
+// and calls fn for each of the rendered YAML and JSON manifests. The values are validated
+// against the values schema of the chart unless skipSchemaValidation is set.
+func renderManifestsWithValues(helmChart *chart.Chart, vals map[string]any, opts RenderOptions, skipSchemaValidation bool, fn func(name, manifest string) error) error {
+	var caps *chartutil.Capabilities
+	if opts.KubeVersion != "" {
+		kubeVersion, err := chartutil.ParseKubeVersion(opts.KubeVersion)
+		if err != nil {
+			return fmt.Errorf("invalid Kubernetes version %s: %w", opts.KubeVersion, err)
+		}
+		caps = chartutil.DefaultCapabilities.Copy()
+		caps.KubeVersion = *kubeVersion
+	}
+
+	values, err := chartutil.ToRenderValuesWithSchemaValidation(helmChart, vals, chartutil.ReleaseOptions{
+		Name:      opts.ReleaseName,
+		Namespace: opts.ReleaseNamespace,
+		Revision:  1,
+		IsInstall: true,
+	}, caps, skipSchemaValidation)
+	if err != nil {
+		return fmt.Errorf("failed to compose render values: %w", err)
+	}
+
+	files, err := engine.Render(helmChart, values)
+	if err != nil {
+		return fmt.Errorf("failed to render chart: %w", err)
+	}
+
+	for name, content := range files {
+		if ext := path.Ext(name); ext != ".yaml" && ext != ".yml" && ext != ".json" {
+			continue
+		}
+
+		for _, manifest := range releaseutil.SplitManifests(content) {
+			if err := fn(name, manifest); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+const schemaErrorPrefix = "values don't meet the specifications of the schema(s)"
+
+var (
+	// template: chart/templates/cluster.yaml:12:20: executing "chart/templates/cluster.yaml" at <.Values.region>: message
+	templateExecErrorRe = regexp.MustCompile(`template: ([^:\s]+):(\d+)(?::\d+)?: executing "[^"]*" at <(.*?)>: (.*)`)
+	// execution error at (chart/templates/cluster.yaml:12:20): message, e.g. of the required and fail functions
+	// parse error at (chart/templates/cluster.yaml:12): message
+	templateLocationErrorRe = regexp.MustCompile(`(?:execution|parse) error at \(([^:\s]+):(\d+)(?::\d+)?\): (.*)`)
+	// YAML parse error on chart/templates/cluster.yaml: message
+	yamlParseErrorRe = regexp.MustCompile(`YAML parse error on ([^:\s]+): (.*)`)
+
+	valuesPathRe = regexp.MustCompile(`\.Values((?:\.[A-Za-z0-9_-]+)+)`)
+)
+
+// RenderError is a failure to render a chart with the details extracted from the Helm error:
+// the failing template and the paths of the values involved, if any.
+type RenderError struct {
+	// Err is the original error.
+	Err error
+	// Chart is the name and the version of the chart, e.g. aws-standalone-cp-0.0.3.
+	Chart string
+	// Template is the path of the failing template in the chart, e.g. templates/awscluster.yaml.
+	Template string
+	// Line is the line of the template the rendering failed at, zero if not known.
+	Line int
+	// ValuePaths are the paths of the values involved in the failure, e.g. controlPlane.instanceType.
+	ValuePaths []string
+	// Message is the cause of the failure without the location.
+	Message string
+}
+
+// NewRenderError returns the RenderError of the failure to render the chart.
+// The details of the error are only extracted from the well-known Helm errors.
+func NewRenderError(helmChart *chart.Chart, err error) *RenderError {
+	renderErr := &RenderError{Err: err, Message: err.Error()}
+	if helmChart != nil && helmChart.Metadata != nil {
+		renderErr.Chart = helmChart.Name() + "-" + helmChart.Metadata.Version
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, schemaErrorPrefix):
+		var problems []string
+		for _, line := range strings.Split(msg, "\n") {
+			line = strings.TrimSpace(line)
+			problem, ok := strings.CutPrefix(line, "- ")
+			if !ok {
+				continue
+			}
+			if path, _, ok := strings.Cut(problem, ": "); ok && path != "(root)" {
+				renderErr.ValuePaths = append(renderErr.ValuePaths, path)
+			}
+			problems = append(problems, problem)
+		}
+		if len(problems) > 0 {
+			renderErr.Message = "the values do not match the schema: " + strings.Join(problems, "; ")
+		}
+	case templateExecErrorRe.MatchString(msg):
+		m := templateExecErrorRe.FindStringSubmatch(msg)
+		renderErr.setLocation(helmChart, m[1], m[2])
+		renderErr.ValuePaths = valuePaths(m[3])
+		renderErr.Message = m[4]
+	case templateLocationErrorRe.MatchString(msg):
+		m := templateLocationErrorRe.FindStringSubmatch(msg)
+		renderErr.setLocation(helmChart, m[1], m[2])
+		renderErr.Message = m[3]
+	case yamlParseErrorRe.MatchString(msg):
+		m := yamlParseErrorRe.FindStringSubmatch(msg)
+		renderErr.setLocation(helmChart, m[1], "")
+		renderErr.Message = "invalid YAML rendered: " + m[2]
+	}
+
+	slices.Sort(renderErr.ValuePaths)
+	renderErr.ValuePaths = slices.Compact(renderErr.ValuePaths)
+	return renderErr
+}
+
+// setLocation sets the template relative to the chart and the line of the failure.
+func (e *RenderError) setLocation(helmChart *chart.Chart, template, line string) {
+	if helmChart != nil && helmChart.Metadata != nil {
+		template = strings.TrimPrefix(template, helmChart.Name()+"/")
+	}
+	e.Template = template
+	e.Line, _ = strconv.Atoi(line)
+}
+
+// valuePaths returns the paths of the values referenced by the template action, e.g. ".Values.region".
+func valuePaths(action string) []string {
+	var paths []string
+	for _, m := range valuesPathRe.FindAllStringSubmatch(action, -1) {
+		paths = append(paths, strings.TrimPrefix(m[1], "."))
+	}
+	return paths
+}
+
+// Error returns the location of the failure followed by its cause, e.g.
+// "chart aws-standalone-cp-0.0.3, template templates/awscluster.yaml:12, values region: region is required".
+func (e *RenderError) Error() string {
+	var location []string
+	if e.Chart != "" {
+		location = append(location, "chart "+e.Chart)
+	}
+	if e.Template != "" {
+		template := "template " + e.Template
+		if e.Line > 0 {
+			template += ":" + strconv.Itoa(e.Line)
+		}
+		location = append(location, template)
+	}
+	if len(e.ValuePaths) > 0 {
+		location = append(location, "values "+strings.Join(e.ValuePaths, ", "))
+	}
+
+	if len(location) == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", strings.Join(location, ", "), e.Message)
+}
+
+func (e *RenderError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,102 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func TestNewRenderError(t *testing.T) {
+	tests := []struct {
+		name       string
+		template   string
+		schema     string
+		values     map[string]any
+		expected   string
+		valuePaths []string
+	}{
+		{
+			name:       "nil pointer",
+			template:   "region: {{ .Values.cluster.region.name }}\n",
+			expected:   "chart test-0.1.0, template templates/cluster.yaml:1, values cluster.region.name: nil pointer evaluating interface {}.region",
+			valuePaths: []string{"cluster.region.name"},
+		},
+		{
+			name:     "required",
+			template: "kind: ConfigMap\n\nregion: {{ required \"the region is required\" .Values.region }}\n",
+			expected: "chart test-0.1.0, template templates/cluster.yaml:3: the region is required",
+		},
+		{
+			name:       "schema",
+			template:   "kind: ConfigMap\n",
+			schema:     `{"type": "object", "properties": {"workersNumber": {"type": "integer"}, "region": {"type": "string"}}}`,
+			values:     map[string]any{"workersNumber": "two", "region": 1},
+			expected:   "chart test-0.1.0, values region, workersNumber: the values do not match the schema: ",
+			valuePaths: []string{"region", "workersNumber"},
+		},
+		{
+			name:     "yaml",
+			template: "kind: ConfigMap\ndata: [\n",
+			expected: "chart test-0.1.0, template templates/cluster.yaml: invalid YAML rendered: ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			helmChart := &chart.Chart{
+				Metadata:  &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "test", Version: "0.1.0"},
+				Values:    map[string]any{},
+				Templates: []*chart.File{{Name: "templates/cluster.yaml", Data: []byte(tt.template)}},
+			}
+			if tt.schema != "" {
+				helmChart.Schema = []byte(tt.schema)
+			}
+
+			install := action.NewInstall(&action.Configuration{Log: func(string, ...any) {}})
+			install.DryRun = true
+			install.ClientOnly = true
+			install.ReleaseName = "release"
+			install.Namespace = "default"
+			_, err := install.RunWithContext(context.Background(), helmChart, tt.values)
+			if err == nil {
+				t.Fatal("expected the rendering to fail")
+			}
+
+			renderErr := NewRenderError(helmChart, err)
+			if msg := renderErr.Error(); len(msg) < len(tt.expected) || msg[:len(tt.expected)] != tt.expected {
+				t.Errorf("expected the error to start with %q, got %q", tt.expected, msg)
+			}
+			if !reflect.DeepEqual(renderErr.ValuePaths, tt.valuePaths) {
+				t.Errorf("expected the value paths %v, got %v", tt.valuePaths, renderErr.ValuePaths)
+			}
+			if !errors.Is(renderErr, err) {
+				t.Error("expected the original error to be wrapped")
+			}
+		})
+	}
+}
+
+func TestNewRenderErrorUnknown(t *testing.T) {
+	err := NewRenderError(nil, errors.New("something went wrong"))
+	if err.Error() != "something went wrong" {
+		t.Errorf("expected the original message, got %q", err.Error())
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"reflect"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestRenderedKinds(t *testing.T) {
+	helmChart := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "test", Version: "0.1.0"},
+		Values:   map[string]any{"name": "test"},
+		Templates: []*chart.File{
+			{Name: "templates/cluster.yaml", Data: []byte(`apiVersion: infrastructure.cluster.x-k8s.io/v1beta2
+kind: AWSCluster
+metadata:
+  name: {{ .Values.name }}
+---
+apiVersion: infrastructure.cluster.x-k8s.io/v1beta2
+kind: AWSMachineTemplate
+metadata:
+  name: {{ .Values.name }}-md
+`)},
+			{Name: "templates/configmap.yaml", Data: []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Release.Name }}
+`)},
+			{Name: "templates/_helpers.tpl", Data: []byte(`{{- define "test.name" -}}test{{- end -}}`)},
+			{Name: "templates/NOTES.txt", Data: []byte(`kind: Ignored`)},
+		},
+	}
+
+	kinds, err := RenderedKinds(helmChart, "release", "default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []schema.GroupKind{
+		{Group: "infrastructure.cluster.x-k8s.io", Kind: "AWSCluster"},
+		{Group: "infrastructure.cluster.x-k8s.io", Kind: "AWSMachineTemplate"},
+		{Kind: "ConfigMap"},
+	}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("expected kinds %v, got %v", expected, kinds)
+	}
+}
+
+func TestRenderedCRDVersions(t *testing.T) {
+	helmChart := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "test", Version: "0.1.0"},
+		Templates: []*chart.File{
+			{Name: "templates/crds/widgets.yaml", Data: []byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  versions:
+  - name: v1beta1
+    served: true
+  - name: v1alpha1
+    served: true
+  - name: v1alpha0
+    served: false
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Release.Name }}
+`)},
+		},
+	}
+
+	versions, err := RenderedCRDVersions(helmChart, "release", "default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string][]string{"widgets.example.com": {"v1alpha1", "v1beta1"}}
+	if !reflect.DeepEqual(versions, expected) {
+		t.Errorf("expected versions %v, got %v", expected, versions)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// ValidateValues validates the values coalesced with the chart default values
+// against the JSON schema of the chart values, the same way Helm does on install.
+// Nothing is validated if the schema is not set.
+func ValidateValues(schema, defaults, values *apiextensionsv1.JSON) error {
+	if schema == nil || len(schema.Raw) == 0 {
+		return nil
+	}
+
+	vals, err := unmarshalValues(values)
+	if err != nil {
+		return fmt.Errorf("failed to parse values: %w", err)
+	}
+	defaultVals, err := unmarshalValues(defaults)
+	if err != nil {
+		return fmt.Errorf("failed to parse default values: %w", err)
+	}
+
+	if err := chartutil.ValidateAgainstSingleSchema(chartutil.CoalesceTables(vals, defaultVals), schema.Raw); err != nil {
+		return errors.New(strings.Join(schemaProblems(err), "; "))
+	}
+	return nil
+}
+
+// PruneValues removes the values the JSON schema of the chart values disallows, i.e. the properties
+// of the objects with "additionalProperties: false" not listed in their "properties", and returns the
+// sorted dot-separated paths of the removed values. The objects with "patternProperties" are left intact.
+func PruneValues(schema []byte, values map[string]any) ([]string, error) {
+	if len(schema) == 0 {
+		return nil, nil
+	}
+
+	parsed := make(map[string]any)
+	if err := json.Unmarshal(schema, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse values schema: %w", err)
+	}
+
+	var pruned []string
+	pruneValues("", parsed, values, &pruned)
+	sort.Strings(pruned)
+	return pruned, nil
+}
+
+func pruneValues(prefix string, schema, values map[string]any, pruned *[]string) {
+	properties, _ := schema["properties"].(map[string]any)
+	_, hasPatterns := schema["patternProperties"]
+	for key, value := range values {
+		path := joinValuesPath(prefix, key)
+		prop, known := properties[key].(map[string]any)
+		if !known {
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional && !hasPatterns {
+					delete(values, key)
+					*pruned = append(*pruned, path)
+				}
+			case map[string]any:
+				if nested, ok := value.(map[string]any); ok {
+					pruneValues(path, additional, nested, pruned)
+				}
+			}
+			continue
+		}
+		if nested, ok := value.(map[string]any); ok {
+			pruneValues(path, prop, nested, pruned)
+		}
+	}
+}
+
+// schemaProblems splits the error of the schema validation into the problems,
+// reported by Helm one per line in the "- problem" form.
+func schemaProblems(err error) []string {
+	var problems []string
+	for _, line := range strings.Split(strings.TrimSpace(err.Error()), "\n") {
+		problems = append(problems, strings.TrimPrefix(strings.TrimSpace(line), "- "))
+	}
+	return problems
+}
+
+func unmarshalValues(values *apiextensionsv1.JSON) (map[string]any, error) {
+	result := make(map[string]any)
+	if values == nil || len(values.Raw) == 0 {
+		return result, nil
+	}
+	if err := json.Unmarshal(values.Raw, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
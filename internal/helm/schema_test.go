@@ -0,0 +1,149 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestValidateValues(t *testing.T) {
+	schema := &apiextensionsv1.JSON{Raw: []byte(`{
+		"type": "object",
+		"required": ["manager"],
+		"properties": {
+			"manager": {
+				"type": "object",
+				"properties": {
+					"featureGates": {"type": "object", "additionalProperties": {"type": "boolean"}},
+					"maxConcurrentReconciles": {"type": "integer", "minimum": 1}
+				}
+			}
+		}
+	}`)}
+	defaults := &apiextensionsv1.JSON{Raw: []byte(`{"manager":{"featureGates":{"ExternalResourceGC":true}}}`)}
+
+	for _, tc := range []struct {
+		name     string
+		schema   *apiextensionsv1.JSON
+		defaults *apiextensionsv1.JSON
+		values   string
+		err      string
+	}{
+		{
+			name:   "no schema",
+			values: `{"manager":{"maxConcurrentReconciles":0}}`,
+		},
+		{
+			name:     "valid values",
+			schema:   schema,
+			defaults: defaults,
+			values:   `{"manager":{"maxConcurrentReconciles":5,"featureGates":{"MachinePool":true}}}`,
+		},
+		{
+			name:     "required values are taken from the defaults",
+			schema:   schema,
+			defaults: defaults,
+		},
+		{
+			name:   "missing required values",
+			schema: schema,
+			err:    "manager is required",
+		},
+		{
+			name:     "invalid values",
+			schema:   schema,
+			defaults: defaults,
+			values:   `{"manager":{"maxConcurrentReconciles":0,"featureGates":{"MachinePool":"yes"}}}`,
+			err:      "manager.maxConcurrentReconciles: Must be greater than or equal to 1",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var values *apiextensionsv1.JSON
+			if tc.values != "" {
+				values = &apiextensionsv1.JSON{Raw: []byte(tc.values)}
+			}
+
+			err := ValidateValues(tc.schema, tc.defaults, values)
+			if tc.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestPruneValues(t *testing.T) {
+	schema := []byte(`{
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"region": {"type": "string"},
+			"controlPlane": {
+				"type": "object",
+				"additionalProperties": false,
+				"properties": {"instanceType": {"type": "string"}}
+			},
+			"k0s": {"type": "object", "properties": {"version": {"type": "string"}}},
+			"nodePools": {
+				"type": "object",
+				"additionalProperties": {
+					"type": "object",
+					"additionalProperties": false,
+					"properties": {"instanceType": {"type": "string"}}
+				}
+			},
+			"labels": {"type": "object", "additionalProperties": false, "patternProperties": {"^x-": {"type": "string"}}}
+		}
+	}`)
+	values := map[string]any{
+		"region":       "us-east-2",
+		"regoin":       "us-west-2",
+		"controlPlane": map[string]any{"instanceType": "t3.small", "amiId": "ami-1"},
+		"k0s":          map[string]any{"version": "v1.31.1+k0s.1", "api": map[string]any{}},
+		"nodePools":    map[string]any{"worker": map[string]any{"instanceType": "t3.large", "size": 3}},
+		"labels":       map[string]any{"x-team": "a", "env": "prod"},
+	}
+
+	pruned, err := PruneValues(schema, values)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"controlPlane.amiId", "nodePools.worker.size", "regoin"}; !reflect.DeepEqual(expected, pruned) {
+		t.Errorf("expected pruned values %v, got %v", expected, pruned)
+	}
+	expected := map[string]any{
+		"region":       "us-east-2",
+		"controlPlane": map[string]any{"instanceType": "t3.small"},
+		"k0s":          map[string]any{"version": "v1.31.1+k0s.1", "api": map[string]any{}},
+		"nodePools":    map[string]any{"worker": map[string]any{"instanceType": "t3.large"}},
+		"labels":       map[string]any{"x-team": "a", "env": "prod"},
+	}
+	if !reflect.DeepEqual(expected, values) {
+		t.Errorf("expected values %v, got %v", expected, values)
+	}
+
+	if pruned, err := PruneValues(nil, values); err != nil || pruned != nil {
+		t.Errorf("expected nothing pruned without the schema, got %v, %v", pruned, err)
+	}
+}
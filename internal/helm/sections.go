@@ -0,0 +1,262 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// ConfigSectionKeyword is the keyword of the properties of the values schema of the
+// ClusterTemplate charts declaring the typed config section of the ManagedCluster the
+// value is set from, e.g. "x-hmc-config-section": "spec.sshKey". The typed config
+// sections not declared by the chart are not supported by the template.
+const ConfigSectionKeyword = "x-hmc-config-section"
+
+// The paths of the values the proxy and the trusted CA bundle of the Management
+// are set at, the paths follow the layout of the HMC cluster templates.
+var (
+	httpProxyPaths  = [][]string{{"proxy", "httpProxy"}}
+	httpsProxyPaths = [][]string{{"proxy", "httpsProxy"}}
+	noProxyPaths    = [][]string{{"proxy", "noProxy"}}
+	trustedCAPaths  = [][]string{{"trustedCA"}}
+)
+
+// configSection is a value of a typed config section of the ManagedCluster,
+// or of the Management settings passed to the cluster templates.
+type configSection struct {
+	// field is the path of the section field in the ManagedCluster.
+	field string
+	paths [][]string
+	value any
+}
+
+// configSections returns the values of the typed config sections set in the spec.
+func configSections(spec *hmc.ManagedClusterSpec) []configSection {
+	var sections []configSection
+	if spec.ControlPlane != nil && spec.ControlPlane.Replicas != nil {
+		sections = append(sections, configSection{field: "spec.controlPlane.replicas", value: int64(*spec.ControlPlane.Replicas)})
+	}
+	for _, pool := range spec.Workers {
+		if pool.Replicas != nil {
+			field := fmt.Sprintf("spec.workers[%s].replicas", pool.Name)
+			sections = append(sections, configSection{field: field, value: int64(*pool.Replicas)})
+		}
+	}
+	if network := spec.Network; network != nil {
+		if network.VPCID != "" {
+			sections = append(sections, configSection{field: "spec.network.vpcID", value: network.VPCID})
+		}
+		if network.Name != "" {
+			sections = append(sections, configSection{field: "spec.network.name", value: network.Name})
+		}
+		if network.Subnet != "" {
+			sections = append(sections, configSection{field: "spec.network.subnet", value: network.Subnet})
+		}
+	}
+	if spec.SSHKey != "" {
+		sections = append(sections, configSection{field: "spec.sshKey", value: spec.SSHKey})
+	}
+	for _, pool := range spec.NodePools {
+		sections = append(sections, imageSections(pool)...)
+	}
+	return sections
+}
+
+// imageSections returns the values of the image pinned in the node pool.
+func imageSections(pool hmc.NodePoolSpec) []configSection {
+	image := pool.ImageRef
+	if image == nil {
+		return nil
+	}
+
+	field := fmt.Sprintf("spec.nodePools[%s].imageRef", pool.Name)
+	if image.ID != "" {
+		return []configSection{{field: field + ".id", value: image.ID}}
+	}
+	if image.Marketplace == nil {
+		return nil
+	}
+
+	var sections []configSection
+	for _, v := range []struct{ key, value string }{
+		{"publisher", image.Marketplace.Publisher},
+		{"offer", image.Marketplace.Offer},
+		{"sku", image.Marketplace.SKU},
+		{"version", image.Marketplace.Version},
+	} {
+		sections = append(sections, configSection{field: field + ".marketplace." + v.key, value: v.value})
+	}
+	return sections
+}
+
+// SetConfigSections sets the values of the typed config sections of the ManagedCluster
+// spec over the given values at the paths the sections are declared at by the chart,
+// see ChartConfigSections.
+func SetConfigSections(values map[string]any, sections map[string][]string, spec *hmc.ManagedClusterSpec) {
+	for _, section := range configSections(spec) {
+		for _, path := range sections[section.field] {
+			SetValue(values, path, section.value)
+		}
+	}
+}
+
+// SetTrustValues sets the HTTP proxy and the trusted CA bundle configured on the
+// Management over the given values. Like the typed config sections, the values are
+// set only at the paths defined by the chart default values as a non-map value.
+func SetTrustValues(values, chartValues map[string]any, proxy *hmc.ProxyConfig, caBundle string) {
+	var sections []configSection
+	if proxy != nil {
+		noProxy := make([]any, 0, len(proxy.NoProxy))
+		for _, v := range proxy.NoProxy {
+			noProxy = append(noProxy, v)
+		}
+		sections = append(sections,
+			configSection{"spec.proxy.httpProxy", httpProxyPaths, proxy.HTTPProxy},
+			configSection{"spec.proxy.httpsProxy", httpsProxyPaths, proxy.HTTPSProxy},
+			configSection{"spec.proxy.noProxy", noProxyPaths, noProxy},
+		)
+	}
+	if caBundle != "" {
+		sections = append(sections, configSection{"spec.trustedCA", trustedCAPaths, caBundle})
+	}
+
+	setSections(values, chartValues, sections)
+}
+
+// setSections sets the values of the sections at the paths defined by the chart default values.
+func setSections(values, chartValues map[string]any, sections []configSection) {
+	for _, section := range sections {
+		for _, path := range section.paths {
+			if definedValue(chartValues, path) {
+				setValue(values, path, section.value)
+			}
+		}
+	}
+}
+
+// UnsupportedConfigSections returns the fields of the typed config sections set in
+// the spec which are not declared by the chart, see ChartConfigSections.
+func UnsupportedConfigSections(sections map[string][]string, spec *hmc.ManagedClusterSpec) []string {
+	var unsupported []string
+	for _, section := range configSections(spec) {
+		if len(sections[section.field]) == 0 {
+			unsupported = append(unsupported, section.field)
+		}
+	}
+	return unsupported
+}
+
+// NodePoolImageApplied returns true if the chart declares the config sections of the
+// image pinned in the node pool, see ChartConfigSections.
+func NodePoolImageApplied(sections map[string][]string, pool hmc.NodePoolSpec) bool {
+	for _, section := range imageSections(pool) {
+		if len(sections[section.field]) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ChartConfigSections returns the typed config sections of the ManagedCluster declared
+// in the values schema of the chart with the ConfigSectionKeyword, mapped to the sorted
+// dot-separated paths of the values the sections are set at.
+func ChartConfigSections(helmChart *chart.Chart) (map[string][]string, error) {
+	if len(helmChart.Schema) == 0 {
+		return nil, nil
+	}
+
+	schema := make(map[string]any)
+	if err := json.Unmarshal(helmChart.Schema, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse values schema: %w", err)
+	}
+
+	sections := make(map[string][]string)
+	if err := collectConfigSections("", schema, sections); err != nil {
+		return nil, err
+	}
+	if len(sections) == 0 {
+		return nil, nil
+	}
+	for _, paths := range sections {
+		slices.Sort(paths)
+	}
+	return sections, nil
+}
+
+// collectConfigSections collects the paths of the properties of the schema declaring the config sections.
+func collectConfigSections(prefix string, schema map[string]any, sections map[string][]string) error {
+	properties, _ := schema["properties"].(map[string]any)
+	for key, v := range properties {
+		prop, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		path := joinValuesPath(prefix, key)
+		if section, ok := prop[ConfigSectionKeyword]; ok {
+			field, ok := section.(string)
+			if !ok || !strings.HasPrefix(field, "spec.") {
+				return fmt.Errorf("invalid %s of the value %s: expected the path of the ManagedCluster field, e.g. spec.sshKey", ConfigSectionKeyword, path)
+			}
+			sections[field] = append(sections[field], path)
+		}
+		if err := collectConfigSections(path, prop, sections); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// definedValue returns true if the values define a non-map value at the path.
+func definedValue(values map[string]any, path []string) bool {
+	for i, key := range path {
+		v, ok := values[key]
+		if !ok {
+			return false
+		}
+		if i == len(path)-1 {
+			_, isMap := v.(map[string]any)
+			return !isMap
+		}
+		if values, ok = v.(map[string]any); !ok {
+			return false
+		}
+	}
+	return false
+}
+
+// SetValue sets the value at the dot-separated path, e.g. "cluster.identityRef", creating the missing maps.
+func SetValue(values map[string]any, path string, value any) {
+	setValue(values, strings.Split(path, "."), value)
+}
+
+// setValue sets the value at the path creating the missing maps.
+func setValue(values map[string]any, path []string, value any) {
+	for _, key := range path[:len(path)-1] {
+		nested, ok := values[key].(map[string]any)
+		if !ok {
+			nested = make(map[string]any)
+			values[key] = nested
+		}
+		values = nested
+	}
+	values[path[len(path)-1]] = value
+}
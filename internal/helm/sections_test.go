@@ -0,0 +1,280 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"k8s.io/utils/ptr"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+func TestSetConfigSections(t *testing.T) {
+	spec := &hmc.ManagedClusterSpec{
+		ControlPlane: &hmc.ControlPlaneConfig{Replicas: ptr.To[int32](1)},
+		Workers: []hmc.WorkerPoolConfig{
+			{Name: "worker", Replicas: ptr.To[int32](5)},
+			{Name: "gpu", Replicas: ptr.To[int32](2)},
+		},
+		Network: &hmc.NetworkConfig{VPCID: "vpc-1", Name: "net", Subnet: "subnet"},
+		SSHKey:  "key",
+	}
+
+	for _, tc := range []struct {
+		name        string
+		sections    map[string][]string
+		values      string
+		expected    string
+		unsupported []string
+	}{
+		{
+			name: "aws standalone",
+			sections: map[string][]string{
+				"spec.controlPlane.replicas":    {"controlPlaneNumber"},
+				"spec.workers[worker].replicas": {"workersNumber"},
+				"spec.sshKey":                   {"sshKeyName"},
+			},
+			values:      `{"workersNumber":3,"worker":{"instanceType":"t3.small"}}`,
+			expected:    `{"controlPlaneNumber":1,"workersNumber":5,"sshKeyName":"key","worker":{"instanceType":"t3.small"}}`,
+			unsupported: []string{"spec.workers[gpu].replicas", "spec.network.vpcID", "spec.network.name", "spec.network.subnet"},
+		},
+		{
+			name: "azure hosted",
+			sections: map[string][]string{
+				"spec.workers[worker].replicas": {"workersNumber"},
+				"spec.network.name":             {"network.vnetName"},
+				"spec.network.subnet":           {"network.nodeSubnetName"},
+				"spec.sshKey":                   {"sshPublicKey"},
+			},
+			values:      `{"network":{"routeTableName":"rt"}}`,
+			expected:    `{"workersNumber":5,"network":{"vnetName":"net","nodeSubnetName":"subnet","routeTableName":"rt"},"sshPublicKey":"key"}`,
+			unsupported: []string{"spec.controlPlane.replicas", "spec.workers[gpu].replicas", "spec.network.vpcID"},
+		},
+		{
+			name: "vsphere standalone",
+			sections: map[string][]string{
+				"spec.controlPlane.replicas": {"controlPlaneNumber"},
+				"spec.network.name":          {"controlPlane.network", "worker.network"},
+				"spec.sshKey":                {"controlPlane.ssh.publicKey", "worker.ssh.publicKey"},
+			},
+			values:      `{}`,
+			expected:    `{"controlPlaneNumber":1,"controlPlane":{"network":"net","ssh":{"publicKey":"key"}},"worker":{"network":"net","ssh":{"publicKey":"key"}}}`,
+			unsupported: []string{"spec.workers[worker].replicas", "spec.workers[gpu].replicas", "spec.network.vpcID", "spec.network.subnet"},
+		},
+		{
+			name:     "no sections declared",
+			values:   `{"replicas":1}`,
+			expected: `{"replicas":1}`,
+			unsupported: []string{
+				"spec.controlPlane.replicas", "spec.workers[worker].replicas", "spec.workers[gpu].replicas",
+				"spec.network.vpcID", "spec.network.name", "spec.network.subnet", "spec.sshKey",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var values map[string]any
+			if err := json.Unmarshal([]byte(tc.values), &values); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			SetConfigSections(values, tc.sections, spec)
+
+			// normalize the numbers the same way the values are passed to the chart
+			raw, err := json.Marshal(values)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var actual, expected map[string]any
+			if err := json.Unmarshal(raw, &actual); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tc.expected), &expected); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("expected values %s, got %s", tc.expected, raw)
+			}
+
+			if unsupported := UnsupportedConfigSections(tc.sections, spec); !reflect.DeepEqual(unsupported, tc.unsupported) {
+				t.Errorf("expected unsupported sections %v, got %v", tc.unsupported, unsupported)
+			}
+		})
+	}
+}
+
+func TestSetImageConfigSections(t *testing.T) {
+	marketplaceSections := map[string][]string{
+		"spec.nodePools[worker].imageRef.marketplace.publisher": {"image.marketplace.publisher"},
+		"spec.nodePools[worker].imageRef.marketplace.offer":     {"image.marketplace.offer"},
+		"spec.nodePools[worker].imageRef.marketplace.sku":       {"image.marketplace.sku"},
+		"spec.nodePools[worker].imageRef.marketplace.version":   {"image.marketplace.version"},
+	}
+
+	for _, tc := range []struct {
+		name        string
+		image       *hmc.ImageRef
+		sections    map[string][]string
+		expected    string
+		unsupported []string
+	}{
+		{
+			name:     "aws standalone",
+			image:    &hmc.ImageRef{ID: "ami-1"},
+			sections: map[string][]string{"spec.nodePools[worker].imageRef.id": {"worker.amiID"}},
+			expected: `{"worker":{"amiID":"ami-1"}}`,
+		},
+		{
+			name:     "azure hosted",
+			image:    &hmc.ImageRef{Marketplace: &hmc.MarketplaceImage{Publisher: "pub", Offer: "capi", SKU: "sku", Version: "1.0.0"}},
+			sections: marketplaceSections,
+			expected: `{"image":{"marketplace":{"publisher":"pub","offer":"capi","sku":"sku","version":"1.0.0"}}}`,
+		},
+		{
+			name:     "marketplace image on vsphere",
+			image:    &hmc.ImageRef{Marketplace: &hmc.MarketplaceImage{Publisher: "pub", Offer: "capi", SKU: "sku", Version: "1.0.0"}},
+			sections: map[string][]string{"spec.nodePools[worker].imageRef.id": {"vmTemplate"}},
+			expected: `{}`,
+			unsupported: []string{
+				"spec.nodePools[worker].imageRef.marketplace.publisher", "spec.nodePools[worker].imageRef.marketplace.offer",
+				"spec.nodePools[worker].imageRef.marketplace.sku", "spec.nodePools[worker].imageRef.marketplace.version",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := &hmc.ManagedClusterSpec{NodePools: []hmc.NodePoolSpec{{Name: "worker", ImageRef: tc.image}}}
+
+			values := map[string]any{}
+			SetConfigSections(values, tc.sections, spec)
+
+			var expected map[string]any
+			if err := json.Unmarshal([]byte(tc.expected), &expected); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(values, expected) {
+				t.Errorf("expected values %s, got %v", tc.expected, values)
+			}
+
+			if unsupported := UnsupportedConfigSections(tc.sections, spec); !reflect.DeepEqual(unsupported, tc.unsupported) {
+				t.Errorf("expected unsupported sections %v, got %v", tc.unsupported, unsupported)
+			}
+			if applied := NodePoolImageApplied(tc.sections, spec.NodePools[0]); applied != (len(tc.unsupported) == 0) {
+				t.Errorf("expected the image applied to be %t, got %t", len(tc.unsupported) == 0, applied)
+			}
+			if applied := NodePoolImageApplied(tc.sections, spec.NodePools[0]); applied != (len(tc.unsupported) == 0) {
+				t.Errorf("expected the image applied to be %t, got %t", len(tc.unsupported) == 0, applied)
+			}
+		})
+	}
+}
+
+func TestChartConfigSections(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		schema   string
+		expected map[string][]string
+		err      string
+	}{
+		{
+			name: "declared sections",
+			schema: `{"properties":{
+				"workersNumber":{"x-hmc-config-section":"spec.workers[worker].replicas","type":"number"},
+				"worker":{"type":"object","properties":{
+					"sshPublicKey":{"x-hmc-config-section":"spec.sshKey","type":"string"},
+					"image":{"properties":{"id":{"x-hmc-config-section":"spec.nodePools[worker].imageRef.id"}}}
+				}},
+				"controlPlane":{"type":"object","properties":{"sshPublicKey":{"x-hmc-config-section":"spec.sshKey"}}},
+				"region":{"type":"string"}
+			}}`,
+			expected: map[string][]string{
+				"spec.workers[worker].replicas":      {"workersNumber"},
+				"spec.sshKey":                        {"controlPlane.sshPublicKey", "worker.sshPublicKey"},
+				"spec.nodePools[worker].imageRef.id": {"worker.image.id"},
+			},
+		},
+		{
+			name:   "no sections declared",
+			schema: `{"properties":{"region":{"type":"string"}}}`,
+		},
+		{
+			name: "no schema",
+		},
+		{
+			name:   "invalid section",
+			schema: `{"properties":{"worker":{"properties":{"sshPublicKey":{"x-hmc-config-section":"sshKey"}}}}}`,
+			err:    "invalid x-hmc-config-section of the value worker.sshPublicKey: expected the path of the ManagedCluster field, e.g. spec.sshKey",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sections, err := ChartConfigSections(&chart.Chart{Schema: []byte(tc.schema)})
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(sections, tc.expected) {
+				t.Errorf("expected sections %v, got %v", tc.expected, sections)
+			}
+		})
+	}
+}
+
+func TestSetValue(t *testing.T) {
+	values := map[string]any{"cluster": map[string]any{"region": "us-east-2"}, "controlPlane": "invalid"}
+	SetValue(values, "cluster.identityRef", "identity")
+	SetValue(values, "controlPlane.identityRef", "identity")
+	SetValue(values, "clusterIdentity", "identity")
+
+	expected := map[string]any{
+		"cluster":         map[string]any{"region": "us-east-2", "identityRef": "identity"},
+		"controlPlane":    map[string]any{"identityRef": "identity"},
+		"clusterIdentity": "identity",
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected values %v, got %v", expected, values)
+	}
+}
+
+func TestSetTrustValues(t *testing.T) {
+	proxy := &hmc.ProxyConfig{HTTPSProxy: "http://proxy.example.com:3128", NoProxy: []string{".example.com"}}
+
+	chartValues := map[string]any{
+		"proxy":     map[string]any{"httpProxy": "", "httpsProxy": "", "noProxy": []any{}},
+		"trustedCA": "",
+	}
+	values := map[string]any{"proxy": map[string]any{"httpProxy": "http://other.example.com:3128"}}
+	SetTrustValues(values, chartValues, proxy, "-----BEGIN CERTIFICATE-----")
+
+	expected := map[string]any{
+		"proxy":     map[string]any{"httpProxy": "", "httpsProxy": "http://proxy.example.com:3128", "noProxy": []any{".example.com"}},
+		"trustedCA": "-----BEGIN CERTIFICATE-----",
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected values %v, got %v", expected, values)
+	}
+
+	values = map[string]any{}
+	SetTrustValues(values, map[string]any{"replicas": 3}, proxy, "-----BEGIN CERTIFICATE-----")
+	if len(values) != 0 {
+		t.Errorf("expected no values set for the unknown layout, got %v", values)
+	}
+}
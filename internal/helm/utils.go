@@ -17,53 +17,133 @@ package helm
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
 	"github.com/hashicorp/go-retryablehttp"
 	godigest "github.com/opencontainers/go-digest"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/Mirantis/hmc/internal/errdefs"
 )
 
+// ErrDigestMismatch is returned when the downloaded artifact does not match the digest of the Flux Artifact.
+var ErrDigestMismatch = errors.New("artifact digest mismatch")
+
+// DefaultDownloadBackoff returns the backoff the failed artifact downloads are
+// retried with unless another one is carried by the context.
+func DefaultDownloadBackoff() wait.Backoff {
+	return wait.Backoff{
+		Steps:    5,
+		Duration: time.Second,
+		Factor:   2,
+		Jitter:   0.1,
+		Cap:      30 * time.Second,
+	}
+}
+
+type downloadBackoffKey struct{}
+
+// WithDownloadBackoff returns the context retrying the failed artifact downloads with the given backoff.
+// The network errors and the server errors are retried by the HTTP client, the HelmCharts
+// whose artifacts do not match the digest are read and downloaded again.
+func WithDownloadBackoff(ctx context.Context, backoff wait.Backoff) context.Context {
+	return context.WithValue(ctx, downloadBackoffKey{}, backoff)
+}
+
+func downloadBackoff(ctx context.Context) wait.Backoff {
+	if backoff, ok := ctx.Value(downloadBackoffKey{}).(wait.Backoff); ok {
+		return backoff
+	}
+	return DefaultDownloadBackoff()
+}
+
 func DownloadChartFromArtifact(ctx context.Context, artifact *sourcev1.Artifact) (*chart.Chart, error) {
 	return DownloadChart(ctx, artifact.URL, artifact.Digest)
 }
 
-func DownloadChart(ctx context.Context, chartURL, digest string) (*chart.Chart, error) {
-	l := log.FromContext(ctx, "chart", chartURL)
+// DownloadChartFromHelmChart downloads the chart from the artifact of the HelmChart.
+// The artifact not matching its digest is usually replaced by the source-controller
+// while being downloaded, so the HelmChart is read again before every retry.
+func DownloadChartFromHelmChart(ctx context.Context, cl client.Client, hc *sourcev1.HelmChart) (*chart.Chart, error) {
+	var (
+		helmChart *chart.Chart
+		attempt   int
+	)
+	err := retry.OnError(downloadBackoff(ctx), func(err error) bool {
+		return errors.Is(err, ErrDigestMismatch)
+	}, func() error {
+		if attempt++; attempt > 1 {
+			if err := cl.Get(ctx, client.ObjectKeyFromObject(hc), hc); err != nil {
+				return fmt.Errorf("failed to get HelmChart %s/%s: %w", hc.Namespace, hc.Name, err)
+			}
+		}
+		if hc.Status.Artifact == nil {
+			return fmt.Errorf("artifact of HelmChart %s/%s is not ready yet", hc.Namespace, hc.Name)
+		}
+
+		var err error
+		helmChart, err = DownloadChartFromArtifact(ctx, hc.Status.Artifact)
+		return err
+	})
+	return helmChart, err
+}
 
-	client := retryablehttp.NewClient()
-	req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodGet, chartURL, nil)
+func DownloadChart(ctx context.Context, chartURL, digest string) (*chart.Chart, error) {
+	buf, err := DownloadArtifact(ctx, chartURL, digest)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := client.Do(req)
+
+	helmChart, err := loader.LoadArchive(buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load archive for chart %s, %w", chartURL, err)
+	}
+	return helmChart, nil
+}
+
+// DownloadArtifact downloads the source controller artifact from the given URL.
+// The integrity of the artifact is verified if the digest is provided, the
+// artifacts not matching the digest are not downloaded again.
+func DownloadArtifact(ctx context.Context, artifactURL, digest string) (*bytes.Buffer, error) {
+	l := log.FromContext(ctx, "artifact", artifactURL)
+
+	backoff := downloadBackoff(ctx)
+	httpClient := retryablehttp.NewClient()
+	httpClient.RetryMax = max(backoff.Steps-1, 0)
+	httpClient.RetryWaitMin = backoff.Duration
+	httpClient.RetryWaitMax = backoff.Cap
+	req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodGet, artifactURL, nil)
 	if err != nil {
 		return nil, err
 	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errdefs.Transient(err)
+	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
-			l.Error(err, "Error closing response body after chart download")
+			l.Error(err, "Error closing response body after artifact download")
 		}
 	}()
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("chart download request failed: %s", resp.Status)
+		return nil, fmt.Errorf("artifact download request failed: %s", resp.Status)
 	}
 
 	var buf bytes.Buffer
 	if err := copyChart(resp.Body, &buf, digest); err != nil {
 		return nil, err
 	}
-
-	helmChart, err := loader.LoadArchive(&buf)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load archive for chart %s, %w", chartURL, err)
-	}
-	return helmChart, nil
+	return &buf, nil
 }
 
 func copyChart(reader io.Reader, writer io.Writer, digest string) error {
@@ -85,7 +165,7 @@ func copyChart(reader io.Reader, writer io.Writer, digest string) error {
 	}
 
 	if digest != "" && !verifier.Verified() {
-		return fmt.Errorf("verification for digest %s failed", digest)
+		return fmt.Errorf("%w: verification for digest %s failed", ErrDigestMismatch, digest)
 	}
 	return nil
 }
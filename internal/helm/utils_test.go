@@ -0,0 +1,132 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	godigest "github.com/opencontainers/go-digest"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDownloadArtifact(t *testing.T) {
+	ctx := WithDownloadBackoff(context.Background(), wait.Backoff{Steps: 3, Duration: time.Millisecond, Factor: 1, Cap: time.Millisecond})
+
+	content := "chart"
+	var (
+		requests atomic.Int32
+		failed   atomic.Bool
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		// the first response is a server error retried by the HTTP client
+		if failed.CompareAndSwap(false, true) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	buf, err := DownloadArtifact(ctx, server.URL, godigest.FromString(content).String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.String() != content {
+		t.Errorf("expected %q, got %q", content, buf.String())
+	}
+	if requests.Load() != 2 {
+		t.Errorf("expected 2 requests, got %d", requests.Load())
+	}
+
+	// the artifact not matching the digest is not downloaded again
+	requests.Store(0)
+	_, err = DownloadArtifact(ctx, server.URL, godigest.FromString("other").String())
+	if !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("expected digest mismatch, got %v", err)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("expected 1 request, got %d", requests.Load())
+	}
+}
+
+func TestDownloadChartFromHelmChart(t *testing.T) {
+	ctx := WithDownloadBackoff(context.Background(), wait.Backoff{Steps: 3, Duration: time.Millisecond, Factor: 1, Cap: time.Millisecond})
+
+	archivePath, err := chartutil.Save(&chart.Chart{Metadata: &chart.Metadata{
+		APIVersion: chart.APIVersionV2,
+		Name:       "aws",
+		Version:    "1.0.1",
+	}}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := os.ReadFile(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(archive)
+	}))
+	defer server.Close()
+
+	scheme := runtime.NewScheme()
+	if err := sourcev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	// the artifact has been replaced by a newer revision, the cached HelmChart
+	// still refers to the digest of the previous one
+	stored := &sourcev1.HelmChart{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "hmc-system", Name: "aws"},
+		Status: sourcev1.HelmChartStatus{Artifact: &sourcev1.Artifact{
+			URL: server.URL, Revision: "1.0.1", Digest: godigest.FromBytes(archive).String(),
+		}},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(stored).Build()
+	hc := stored.DeepCopy()
+	hc.Status.Artifact.Revision = "1.0.0"
+	hc.Status.Artifact.Digest = godigest.FromString("1.0.0").String()
+
+	helmChart, err := DownloadChartFromHelmChart(ctx, cl, hc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if helmChart.Metadata.Version != "1.0.1" {
+		t.Errorf("expected chart version 1.0.1, got %s", helmChart.Metadata.Version)
+	}
+	if hc.Status.Artifact.Revision != "1.0.1" {
+		t.Errorf("expected the HelmChart to be read again, got revision %s", hc.Status.Artifact.Revision)
+	}
+
+	// the mismatch persisting after the retries is reported
+	stored.Status.Artifact.Digest = godigest.FromString("other").String()
+	cl = fake.NewClientBuilder().WithScheme(scheme).WithObjects(stored).Build()
+	if _, err := DownloadChartFromHelmChart(ctx, cl, stored.DeepCopy()); !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("expected digest mismatch, got %v", err)
+	}
+}
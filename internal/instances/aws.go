@@ -0,0 +1,147 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instances
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	awsClusterKind        = "AWSCluster"
+	awsStaticIdentityKind = "AWSClusterStaticIdentity"
+
+	// defaultAWSTimeout is the timeout of the EC2 API requests of the AWSVerifier without the HTTP client set.
+	defaultAWSTimeout = 30 * time.Second
+)
+
+var defaultAWSHTTPClient = &http.Client{Timeout: defaultAWSTimeout}
+
+// AWSVerifier lists the EC2 instances of the AWSClusters and looks up the AMIs
+// with the credentials of the AWSClusterStaticIdentity of the cluster. The
+// EC2 endpoint is resolved from the region, including the China and GovCloud
+// partitions.
+type AWSVerifier struct {
+	// HTTPClient sends the EC2 API requests, defaults to the client with the 30s timeout.
+	HTTPClient *http.Client
+	// SecretNamespace is the namespace of the Secrets of the identities, the namespace of the CAPA controller.
+	SecretNamespace string
+	// BaseEndpoint overrides the EC2 endpoint resolved from the region, e.g. with a VPC endpoint.
+	BaseEndpoint string
+}
+
+func (v *AWSVerifier) Remaining(ctx context.Context, c client.Client, cluster *unstructured.Unstructured) ([]string, error) {
+	if cluster.GetKind() != awsClusterKind {
+		return nil, fmt.Errorf("%w for %s", ErrUnsupported, cluster.GetKind())
+	}
+
+	region, _, _ := unstructured.NestedString(cluster.Object, "spec", "region")
+	if region == "" {
+		return nil, fmt.Errorf("%s %s has no region", cluster.GetKind(), client.ObjectKeyFromObject(cluster))
+	}
+
+	ec2Client, err := v.client(ctx, c, v.identityRef(cluster), region)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &ec2.DescribeInstancesInput{
+		Filters: []ec2types.Filter{
+			{Name: aws.String("tag:sigs.k8s.io/cluster-api-provider-aws/cluster/" + cluster.GetName()), Values: []string{"owned"}},
+			{Name: aws.String("instance-state-name"), Values: []string{"pending", "running", "shutting-down", "stopping", "stopped"}},
+		},
+	}
+
+	var ids []string
+	for pages := ec2.NewDescribeInstancesPaginator(ec2Client, input); pages.HasMorePages(); {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe the instances of the cluster %s: %w", cluster.GetName(), err)
+		}
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				ids = append(ids, aws.ToString(instance.InstanceId))
+			}
+		}
+	}
+	return ids, nil
+}
+
+// identityRef returns the reference to the identity of the cluster.
+func (*AWSVerifier) identityRef(cluster *unstructured.Unstructured) *corev1.ObjectReference {
+	kind, _, _ := unstructured.NestedString(cluster.Object, "spec", "identityRef", "kind")
+	name, _, _ := unstructured.NestedString(cluster.Object, "spec", "identityRef", "name")
+	return &corev1.ObjectReference{APIVersion: cluster.GetAPIVersion(), Kind: kind, Name: name}
+}
+
+// client returns the EC2 client of the region with the credentials of the identity.
+func (v *AWSVerifier) client(ctx context.Context, c client.Client, identityRef *corev1.ObjectReference, region string) (*ec2.Client, error) {
+	creds, err := v.identityCredentials(ctx, c, identityRef)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := v.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultAWSHTTPClient
+	}
+	return ec2.New(ec2.Options{
+		Region:      region,
+		Credentials: creds,
+		HTTPClient:  httpClient,
+	}, func(o *ec2.Options) {
+		if v.BaseEndpoint != "" {
+			o.BaseEndpoint = aws.String(v.BaseEndpoint)
+		}
+	}), nil
+}
+
+// identityCredentials returns the credentials of the AWSClusterStaticIdentity, the
+// other kinds of the identities are not supported.
+func (v *AWSVerifier) identityCredentials(ctx context.Context, c client.Client, identityRef *corev1.ObjectReference) (aws.CredentialsProvider, error) {
+	kind, name := identityRef.Kind, identityRef.Name
+	if kind != awsStaticIdentityKind {
+		return nil, fmt.Errorf("%w for the identity %s %s", ErrUnsupported, kind, name)
+	}
+
+	identity := &unstructured.Unstructured{}
+	identity.SetAPIVersion(identityRef.APIVersion)
+	identity.SetKind(kind)
+	if err := c.Get(ctx, client.ObjectKey{Name: name}, identity); err != nil {
+		return nil, fmt.Errorf("failed to get %s %s: %w", kind, name, err)
+	}
+
+	secretName, _, _ := unstructured.NestedString(identity.Object, "spec", "secretRef")
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: v.SecretNamespace, Name: secretName}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get Secret %s/%s of %s %s: %w", v.SecretNamespace, secretName, kind, name, err)
+	}
+
+	return credentials.NewStaticCredentialsProvider(
+		string(secret.Data["AccessKeyID"]),
+		string(secret.Data["SecretAccessKey"]),
+		string(secret.Data["SessionToken"]),
+	), nil
+}
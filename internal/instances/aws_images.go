@@ -0,0 +1,64 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instances
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// CheckImage looks up the AMI by its ID in the region.
+func (v *AWSVerifier) CheckImage(ctx context.Context, c client.Client, identityRef *corev1.ObjectReference, location ImageLocation, image hmc.ImageRef) error {
+	if image.ID == "" {
+		return fmt.Errorf("%w for the images other than AMI", ErrUnsupported)
+	}
+	if location.Region == "" {
+		return fmt.Errorf("the region of the AMI %s is not set", image.ID)
+	}
+
+	ec2Client, err := v.client(ctx, c, identityRef, location.Region)
+	if err != nil {
+		return err
+	}
+
+	result, err := ec2Client.DescribeImages(ctx, &ec2.DescribeImagesInput{ImageIds: []string{image.ID}})
+	if apiErr := smithy.APIError(nil); errors.As(err, &apiErr) && strings.HasPrefix(apiErr.ErrorCode(), "InvalidAMIID.") {
+		return fmt.Errorf("%w: the AMI %s in the region %s: %s", ErrImageNotFound, image.ID, location.Region, apiErr.ErrorMessage())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to describe the AMI %s: %w", image.ID, err)
+	}
+
+	for _, img := range result.Images {
+		if img.ImageId == nil || *img.ImageId != image.ID {
+			continue
+		}
+		if img.State != ec2types.ImageStateAvailable {
+			return fmt.Errorf("%w: the AMI %s in the region %s is %s", ErrImageNotFound, image.ID, location.Region, img.State)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: the AMI %s in the region %s", ErrImageNotFound, image.ID, location.Region)
+}
@@ -0,0 +1,157 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instances
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+func TestAWSEndpointPartitions(t *testing.T) {
+	// the endpoint is resolved from the region, not assumed to be in the public partition
+	for region, expected := range map[string]string{
+		"us-east-2":     "https://ec2.us-east-2.amazonaws.com",
+		"cn-north-1":    "https://ec2.cn-north-1.amazonaws.com.cn",
+		"us-gov-west-1": "https://ec2.us-gov-west-1.amazonaws.com",
+	} {
+		endpoint, err := ec2.NewDefaultEndpointResolverV2().ResolveEndpoint(context.Background(), ec2.EndpointParameters{Region: aws.String(region)})
+		require.NoError(t, err)
+		require.Equal(t, expected, endpoint.URI.String(), region)
+	}
+}
+
+func TestAWSVerifierRemaining(t *testing.T) {
+	identity := &unstructured.Unstructured{}
+	identity.SetAPIVersion("infrastructure.cluster.x-k8s.io/v1beta2")
+	identity.SetKind(awsStaticIdentityKind)
+	identity.SetName("aws-identity")
+	require.NoError(t, unstructured.SetNestedField(identity.Object, "aws-credentials", "spec", "secretRef"))
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "hmc-system", Name: "aws-credentials"},
+		Data:       map[string][]byte{"AccessKeyID": []byte("AKID"), "SecretAccessKey": []byte("secret")},
+	}
+	cl := fake.NewClientBuilder().WithObjects(identity, secret).Build()
+
+	var queries []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") || r.ParseForm() != nil {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		queries = append(queries, r.PostForm)
+		if r.PostForm.Get("NextToken") == "" {
+			_, _ = w.Write([]byte(`<DescribeInstancesResponse><reservationSet><item><instancesSet>` +
+				`<item><instanceId>i-1</instanceId></item><item><instanceId>i-2</instanceId></item>` +
+				`</instancesSet></item></reservationSet><nextToken>next</nextToken></DescribeInstancesResponse>`))
+			return
+		}
+		_, _ = w.Write([]byte(`<DescribeInstancesResponse><reservationSet><item><instancesSet>` +
+			`<item><instanceId>i-3</instanceId></item></instancesSet></item></reservationSet></DescribeInstancesResponse>`))
+	}))
+	defer server.Close()
+
+	verifier := &AWSVerifier{
+		SecretNamespace: "hmc-system",
+		BaseEndpoint:    server.URL,
+	}
+
+	cluster := &unstructured.Unstructured{}
+	cluster.SetAPIVersion("infrastructure.cluster.x-k8s.io/v1beta2")
+	cluster.SetKind(awsClusterKind)
+	cluster.SetNamespace("default")
+	cluster.SetName("dev")
+	require.NoError(t, unstructured.SetNestedField(cluster.Object, "us-east-2", "spec", "region"))
+	require.NoError(t, unstructured.SetNestedMap(cluster.Object, map[string]any{"kind": awsStaticIdentityKind, "name": "aws-identity"}, "spec", "identityRef"))
+
+	ids, err := verifier.Remaining(context.Background(), cl, cluster)
+	require.NoError(t, err)
+	require.Equal(t, []string{"i-1", "i-2", "i-3"}, ids)
+	require.Len(t, queries, 2)
+	require.Equal(t, "DescribeInstances", queries[0].Get("Action"))
+	require.Equal(t, "tag:sigs.k8s.io/cluster-api-provider-aws/cluster/dev", queries[0].Get("Filter.1.Name"))
+	require.Equal(t, "next", queries[1].Get("NextToken"))
+
+	require.NoError(t, unstructured.SetNestedField(cluster.Object, "AWSClusterRoleIdentity", "spec", "identityRef", "kind"))
+	_, err = verifier.Remaining(context.Background(), cl, cluster)
+	require.True(t, errors.Is(err, ErrUnsupported))
+}
+
+func TestAWSVerifierCheckImage(t *testing.T) {
+	identity := &unstructured.Unstructured{}
+	identity.SetAPIVersion("infrastructure.cluster.x-k8s.io/v1beta2")
+	identity.SetKind(awsStaticIdentityKind)
+	identity.SetName("aws-identity")
+	require.NoError(t, unstructured.SetNestedField(identity.Object, "aws-credentials", "spec", "secretRef"))
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "hmc-system", Name: "aws-credentials"},
+		Data:       map[string][]byte{"AccessKeyID": []byte("AKID"), "SecretAccessKey": []byte("secret")},
+	}
+	cl := fake.NewClientBuilder().WithObjects(identity, secret).Build()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.PostForm.Get("ImageId.1") {
+		case "ami-available":
+			_, _ = w.Write([]byte(`<DescribeImagesResponse><imagesSet><item><imageId>ami-available</imageId>` +
+				`<imageState>available</imageState></item></imagesSet></DescribeImagesResponse>`))
+		case "ami-missing":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`<Response><Errors><Error><Code>InvalidAMIID.NotFound</Code>` +
+				`<Message>The image id '[ami-missing]' does not exist</Message></Error></Errors></Response>`))
+		default:
+			_, _ = w.Write([]byte(`<DescribeImagesResponse><imagesSet/></DescribeImagesResponse>`))
+		}
+	}))
+	defer server.Close()
+
+	verifier := &AWSVerifier{
+		SecretNamespace: "hmc-system",
+		BaseEndpoint:    server.URL,
+	}
+	identityRef := &corev1.ObjectReference{APIVersion: "infrastructure.cluster.x-k8s.io/v1beta2", Kind: awsStaticIdentityKind, Name: "aws-identity"}
+	location := ImageLocation{Region: "us-east-2"}
+
+	require.NoError(t, verifier.CheckImage(context.Background(), cl, identityRef, location, hmc.ImageRef{ID: "ami-available"}))
+
+	err := verifier.CheckImage(context.Background(), cl, identityRef, location, hmc.ImageRef{ID: "ami-missing"})
+	require.ErrorIs(t, err, ErrImageNotFound)
+	require.ErrorContains(t, err, "does not exist")
+
+	err = verifier.CheckImage(context.Background(), cl, identityRef, location, hmc.ImageRef{ID: "ami-private"})
+	require.ErrorIs(t, err, ErrImageNotFound)
+
+	err = verifier.CheckImage(context.Background(), cl, identityRef, location, hmc.ImageRef{Marketplace: &hmc.MarketplaceImage{}})
+	require.ErrorIs(t, err, ErrUnsupported)
+}
@@ -0,0 +1,148 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instances
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v5"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+const (
+	azureClusterIdentityKind = "AzureClusterIdentity"
+	azureServicePrincipal    = "ServicePrincipal"
+
+	// defaultAzureTimeout is the timeout of the Azure API requests of the AzureImageChecker without the HTTP client set.
+	defaultAzureTimeout = 30 * time.Second
+)
+
+var defaultAzureHTTPClient = &http.Client{Timeout: defaultAzureTimeout}
+
+// AzureImageChecker looks up the Azure Marketplace images with the credentials
+// of the service principal of the AzureClusterIdentity of the cluster.
+type AzureImageChecker struct {
+	// HTTPClient sends the Microsoft Entra ID and the Azure Resource Manager requests,
+	// defaults to the client with the 30s timeout.
+	HTTPClient *http.Client
+	// Cloud is the Azure cloud the images are looked up in, defaults to the Azure public cloud.
+	Cloud cloud.Configuration
+}
+
+// CheckImage looks up the version of the Marketplace image in the location.
+func (v *AzureImageChecker) CheckImage(ctx context.Context, c client.Client, identityRef *corev1.ObjectReference, location ImageLocation, image hmc.ImageRef) error {
+	img := image.Marketplace
+	if img == nil {
+		return fmt.Errorf("%w for the images other than Marketplace images", ErrUnsupported)
+	}
+	if location.Region == "" || location.SubscriptionID == "" {
+		return fmt.Errorf("the location and the subscription of the image %s are not set", marketplaceURN(img))
+	}
+
+	cred, err := v.credential(ctx, c, identityRef)
+	if err != nil {
+		return err
+	}
+	images, err := armcompute.NewVirtualMachineImagesClient(location.SubscriptionID, cred, &arm.ClientOptions{ClientOptions: v.clientOptions()})
+	if err != nil {
+		return fmt.Errorf("failed to create the client of the virtual machine images: %w", err)
+	}
+
+	_, err = images.Get(ctx, location.Region, img.Publisher, img.Offer, img.SKU, img.Version, nil)
+	if respErr := (*azcore.ResponseError)(nil); errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: the image %s in the location %s", ErrImageNotFound, marketplaceURN(img), location.Region)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get the image %s: %w", marketplaceURN(img), err)
+	}
+	return nil
+}
+
+// credential returns the credential of the service principal of the AzureClusterIdentity,
+// the other types of the identities are not supported.
+func (v *AzureImageChecker) credential(ctx context.Context, c client.Client, identityRef *corev1.ObjectReference) (azcore.TokenCredential, error) {
+	kind, name := identityRef.Kind, identityRef.Name
+	if kind != azureClusterIdentityKind {
+		return nil, fmt.Errorf("%w for the identity %q", ErrUnsupported, kind)
+	}
+
+	identity := &unstructured.Unstructured{}
+	identity.SetAPIVersion(identityRef.APIVersion)
+	identity.SetKind(kind)
+	if err := c.Get(ctx, client.ObjectKey{Namespace: identityRef.Namespace, Name: name}, identity); err != nil {
+		return nil, fmt.Errorf("failed to get %s %s/%s: %w", kind, identityRef.Namespace, name, err)
+	}
+
+	if identityType, _, _ := unstructured.NestedString(identity.Object, "spec", "type"); identityType != azureServicePrincipal {
+		return nil, fmt.Errorf("%w for the identity of the type %q", ErrUnsupported, identityType)
+	}
+	clientID, _, _ := unstructured.NestedString(identity.Object, "spec", "clientID")
+	tenantID, _, _ := unstructured.NestedString(identity.Object, "spec", "tenantID")
+	secretName, _, _ := unstructured.NestedString(identity.Object, "spec", "clientSecret", "name")
+	secretNamespace, _, _ := unstructured.NestedString(identity.Object, "spec", "clientSecret", "namespace")
+
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: secretNamespace, Name: secretName}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get Secret %s/%s of %s %s: %w", secretNamespace, secretName, kind, name, err)
+	}
+
+	options := v.clientOptions()
+	cred, err := azidentity.NewClientSecretCredential(tenantID, clientID, string(secret.Data["clientSecret"]),
+		&azidentity.ClientSecretCredentialOptions{
+			ClientOptions: options,
+			// the authority of the private clouds, e.g. Azure Stack, is not known to the instance discovery
+			DisableInstanceDiscovery: !slices.ContainsFunc([]cloud.Configuration{cloud.AzurePublic, cloud.AzureChina, cloud.AzureGovernment},
+				func(known cloud.Configuration) bool {
+					return known.ActiveDirectoryAuthorityHost == options.Cloud.ActiveDirectoryAuthorityHost
+				}),
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the credential of %s %s: %w", kind, name, err)
+	}
+	return cred, nil
+}
+
+// clientOptions returns the options of the Azure clients of the cloud.
+func (v *AzureImageChecker) clientOptions() policy.ClientOptions {
+	httpClient := v.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultAzureHTTPClient
+	}
+	cloudConfig := v.Cloud
+	if cloudConfig.ActiveDirectoryAuthorityHost == "" {
+		cloudConfig = cloud.AzurePublic
+	}
+	return policy.ClientOptions{Cloud: cloudConfig, Transport: httpClient}
+}
+
+// marketplaceURN returns the URN of the Marketplace image, e.g. "cncf-upstream:capi:ubuntu-2204-gen1:130.3.20240717".
+func marketplaceURN(img *hmc.MarketplaceImage) string {
+	return strings.Join([]string{img.Publisher, img.Offer, img.SKU, img.Version}, ":")
+}
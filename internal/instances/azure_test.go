@@ -0,0 +1,109 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instances
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+func TestAzureImageCheckerCheckImage(t *testing.T) {
+	identity := &unstructured.Unstructured{}
+	identity.SetAPIVersion("infrastructure.cluster.x-k8s.io/v1beta1")
+	identity.SetKind(azureClusterIdentityKind)
+	identity.SetNamespace("default")
+	identity.SetName("azure-identity")
+	require.NoError(t, unstructured.SetNestedMap(identity.Object, map[string]any{
+		"type":         azureServicePrincipal,
+		"clientID":     "client",
+		"tenantID":     "tenant",
+		"clientSecret": map[string]any{"name": "azure-secret", "namespace": "default"},
+	}, "spec"))
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "azure-secret"},
+		Data:       map[string][]byte{"clientSecret": []byte("secret")},
+	}
+	cl := fake.NewClientBuilder().WithObjects(identity, secret).Build()
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tenant/v2.0/.well-known/openid-configuration":
+			_, _ = w.Write([]byte(`{"token_endpoint":"` + server.URL + `/tenant/oauth2/v2.0/token",` +
+				`"authorization_endpoint":"` + server.URL + `/tenant/oauth2/v2.0/authorize","issuer":"` + server.URL + `/tenant/v2.0"}`))
+			return
+		case "/tenant/oauth2/v2.0/token":
+			require.NoError(t, r.ParseForm())
+			if r.PostForm.Get("client_id") != "client" || r.PostForm.Get("client_secret") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/subscriptions/sub/providers/Microsoft.Compute/locations/westus/publishers/cncf-upstream"+
+			"/artifacttypes/vmimage/offers/capi/skus/ubuntu-2204-gen1/versions/130.3.20240717" {
+			_, _ = w.Write([]byte(`{"name":"130.3.20240717"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":{"code":"NotFound","message":"Artifact: VMImage was not found."}}`))
+	}))
+	defer server.Close()
+
+	checker := &AzureImageChecker{
+		HTTPClient: server.Client(),
+		Cloud: cloud.Configuration{
+			ActiveDirectoryAuthorityHost: server.URL + "/",
+			Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+				cloud.ResourceManager: {Endpoint: server.URL, Audience: server.URL},
+			},
+		},
+	}
+	identityRef := &corev1.ObjectReference{
+		APIVersion: "infrastructure.cluster.x-k8s.io/v1beta1", Kind: azureClusterIdentityKind, Namespace: "default", Name: "azure-identity",
+	}
+	location := ImageLocation{Region: "westus", SubscriptionID: "sub"}
+	image := hmc.ImageRef{Marketplace: &hmc.MarketplaceImage{
+		Publisher: "cncf-upstream", Offer: "capi", SKU: "ubuntu-2204-gen1", Version: "130.3.20240717",
+	}}
+
+	require.NoError(t, checker.CheckImage(context.Background(), cl, identityRef, location, image))
+
+	image.Marketplace.Version = "1.0.0"
+	err := checker.CheckImage(context.Background(), cl, identityRef, location, image)
+	require.ErrorIs(t, err, ErrImageNotFound)
+	require.ErrorContains(t, err, "cncf-upstream:capi:ubuntu-2204-gen1:1.0.0")
+
+	err = checker.CheckImage(context.Background(), cl, identityRef, location, hmc.ImageRef{ID: "ami-1"})
+	require.ErrorIs(t, err, ErrUnsupported)
+}
@@ -0,0 +1,58 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package instances verifies the removal of the cloud instances of the
+// deleted clusters and the availability of the machine images by the provider API.
+package instances
+
+import (
+	"context"
+	"errors"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// ErrUnsupported is returned if the instances of the infrastructure cluster cannot be listed,
+// e.g. the cluster kind or the kind of its identity is not supported by the Verifier.
+var ErrUnsupported = errors.New("the verification of the instances is not supported")
+
+// ErrImageNotFound is returned if the machine image is not available in the cloud.
+var ErrImageNotFound = errors.New("the image is not found")
+
+// Verifier lists the cloud instances of the infrastructure cluster.
+type Verifier interface {
+	// Remaining returns the IDs of the instances of the infrastructure cluster
+	// existing in the cloud, the instances being terminated are included.
+	Remaining(ctx context.Context, c client.Client, cluster *unstructured.Unstructured) ([]string, error)
+}
+
+// ImageLocation is the location of the cluster the machine images are looked up in.
+type ImageLocation struct {
+	// Region is the AWS region or the Azure location of the cluster.
+	Region string
+	// SubscriptionID is the Azure subscription of the cluster.
+	SubscriptionID string
+}
+
+// ImageChecker looks up the machine images in the cloud.
+type ImageChecker interface {
+	// CheckImage returns ErrImageNotFound if the image is not available in the location
+	// with the credentials of the cluster identity, or ErrUnsupported if the image or
+	// the kind of the identity is not supported by the ImageChecker.
+	CheckImage(ctx context.Context, c client.Client, identityRef *corev1.ObjectReference, location ImageLocation, image hmc.ImageRef) error
+}
@@ -0,0 +1,227 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+const (
+	metricsNamespace = "hmc"
+
+	resultSuccess = "success"
+	resultError   = "error"
+)
+
+var (
+	managedClusterPhase = &phaseCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "managed_cluster", "phase"),
+			"The current phase of the ManagedCluster, set to 1 for the current phase.",
+			[]string{"namespace", "name", "phase"}, nil,
+		),
+		phases: make(map[types.NamespacedName]string),
+	}
+
+	managedClusterAvailableUpgrades = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "managed_cluster",
+			Name:      "available_upgrades",
+			Help:      "The number of ClusterTemplates the ManagedCluster can be upgraded to.",
+		},
+		[]string{"namespace", "name", "template"},
+	)
+
+	managedClusterMachines = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "managed_cluster",
+			Name:      "machines",
+			Help:      "The number of the machines of the ManagedCluster by the role and the instance type.",
+		},
+		[]string{"namespace", "name", "cost_center", "role", "instance_type"},
+	)
+
+	managedClusterProvisionedTime = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "managed_cluster",
+			Name:      "provisioned_timestamp_seconds",
+			Help:      "The Unix time the ManagedCluster has become ready for the first time.",
+		},
+		[]string{"namespace", "name", "cost_center"},
+	)
+
+	reconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "reconcile_duration_seconds",
+			Help:      "The duration of the reconciliation in seconds.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+		},
+		[]string{"controller", "result"},
+	)
+
+	helmValidationFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "helm_validation_failures_total",
+			Help:      "The number of failed validations of a template with the provided configuration.",
+		},
+		[]string{"namespace", "template"},
+	)
+
+	serviceDeploymentFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "service_deployment_failures_total",
+			Help:      "The number of failed deployments of services.",
+		},
+		[]string{"kind", "namespace", "name"},
+	)
+
+	telemetryEvents = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "telemetry_events_total",
+			Help:      "The number of telemetry events by the delivery result.",
+		},
+		[]string{"result"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		managedClusterPhase,
+		managedClusterAvailableUpgrades,
+		managedClusterMachines,
+		managedClusterProvisionedTime,
+		reconcileDuration,
+		helmValidationFailures,
+		serviceDeploymentFailures,
+		telemetryEvents,
+	)
+}
+
+// phaseCollector exposes the phases of the ManagedClusters. The phase of a cluster is
+// replaced at once, so the cluster is never seen in no phase or in several phases,
+// even if the phase is set by several controllers concurrently.
+type phaseCollector struct {
+	desc   *prometheus.Desc
+	phases map[types.NamespacedName]string
+	mu     sync.RWMutex
+}
+
+// Describe implements prometheus.Collector.
+func (c *phaseCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *phaseCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for key, phase := range c.phases {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, 1, key.Namespace, key.Name, phase)
+	}
+}
+
+func (c *phaseCollector) set(key types.NamespacedName, phase string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.phases[key] = phase
+}
+
+func (c *phaseCollector) delete(key types.NamespacedName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.phases, key)
+}
+
+// SetManagedClusterPhase sets the current phase of the ManagedCluster.
+func SetManagedClusterPhase(namespace, name, phase string) {
+	managedClusterPhase.set(types.NamespacedName{Namespace: namespace, Name: name}, phase)
+}
+
+// SetManagedClusterAvailableUpgrades sets the number of upgrades available for the ManagedCluster.
+func SetManagedClusterAvailableUpgrades(namespace, name, template string, count int) {
+	managedClusterAvailableUpgrades.DeletePartialMatch(prometheus.Labels{"namespace": namespace, "name": name})
+	managedClusterAvailableUpgrades.WithLabelValues(namespace, name, template).Set(float64(count))
+}
+
+// DeleteManagedCluster removes all of the metrics of the ManagedCluster.
+func DeleteManagedCluster(namespace, name string) {
+	labels := prometheus.Labels{"namespace": namespace, "name": name}
+	managedClusterPhase.delete(types.NamespacedName{Namespace: namespace, Name: name})
+	managedClusterAvailableUpgrades.DeletePartialMatch(labels)
+	managedClusterMachines.DeletePartialMatch(labels)
+	managedClusterProvisionedTime.DeletePartialMatch(labels)
+}
+
+// SetManagedClusterUsage sets the number of the machines of the ManagedCluster
+// and the time it has been provisioned at, the uptime of the cluster is
+// computed as time() - hmc_managed_cluster_provisioned_timestamp_seconds.
+func SetManagedClusterUsage(namespace, name, costCenter string, usage *hmc.ManagedClusterUsage) {
+	labels := prometheus.Labels{"namespace": namespace, "name": name}
+	managedClusterMachines.DeletePartialMatch(labels)
+	managedClusterProvisionedTime.DeletePartialMatch(labels)
+
+	for _, machines := range usage.Machines {
+		managedClusterMachines.WithLabelValues(namespace, name, costCenter, machines.Role, machines.InstanceType).Set(float64(machines.Count))
+	}
+	if usage.ProvisionedAt != nil {
+		managedClusterProvisionedTime.WithLabelValues(namespace, name, costCenter).Set(float64(usage.ProvisionedAt.Unix()))
+	}
+}
+
+// ObserveReconcileDuration records the duration of the reconciliation started at the given time.
+func ObserveReconcileDuration(controller string, start time.Time, err error) {
+	result := resultSuccess
+	if err != nil {
+		result = resultError
+	}
+	reconcileDuration.WithLabelValues(controller, result).Observe(time.Since(start).Seconds())
+}
+
+// IncHelmValidationFailures increments the number of failed validations of the template.
+func IncHelmValidationFailures(namespace, template string) {
+	helmValidationFailures.WithLabelValues(namespace, template).Inc()
+}
+
+// IncServiceDeploymentFailures increments the number of failed deployments
+// of services of the object of the given kind.
+func IncServiceDeploymentFailures(kind, namespace, name string) {
+	serviceDeploymentFailures.WithLabelValues(kind, namespace, name).Inc()
+}
+
+// IncTelemetryEvents increments the number of telemetry events with the given
+// delivery result, one of "delivered", "failed" or "dropped".
+func IncTelemetryEvents(result string) {
+	telemetryEvents.WithLabelValues(result).Inc()
+}
+
+// AddTelemetryEvents adds the given number of telemetry events with the given delivery result.
+func AddTelemetryEvents(result string, count int) {
+	telemetryEvents.WithLabelValues(result).Add(float64(count))
+}
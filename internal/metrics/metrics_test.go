@@ -0,0 +1,131 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+func TestManagedClusterPhase(t *testing.T) {
+	t.Cleanup(func() {
+		DeleteManagedCluster("default", "dev")
+		DeleteManagedCluster("default", "prod")
+	})
+
+	SetManagedClusterPhase("default", "dev", "Provisioning")
+	SetManagedClusterPhase("default", "prod", "Ready")
+	SetManagedClusterPhase("default", "dev", "Ready")
+	require.NoError(t, testutil.CollectAndCompare(managedClusterPhase, strings.NewReader(`
+# HELP hmc_managed_cluster_phase The current phase of the ManagedCluster, set to 1 for the current phase.
+# TYPE hmc_managed_cluster_phase gauge
+hmc_managed_cluster_phase{name="dev",namespace="default",phase="Ready"} 1
+hmc_managed_cluster_phase{name="prod",namespace="default",phase="Ready"} 1
+`)))
+	problems, err := testutil.CollectAndLint(managedClusterPhase)
+	require.NoError(t, err)
+	require.Empty(t, problems)
+
+	// every scrape sees the cluster in exactly one phase while the phase is set concurrently
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for _, phase := range []string{"Ready", "Updating"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					SetManagedClusterPhase("default", "dev", phase)
+				}
+			}
+		}()
+	}
+	for range 100 {
+		require.Equal(t, 2, testutil.CollectAndCount(managedClusterPhase))
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestObserveReconcileDuration(t *testing.T) {
+	t.Cleanup(reconcileDuration.Reset)
+
+	ObserveReconcileDuration("managedcluster", time.Now().Add(-time.Second), nil)
+	ObserveReconcileDuration("managedcluster", time.Now(), nil)
+	ObserveReconcileDuration("managedcluster", time.Now(), errors.New("failed"))
+
+	// the durations are observed per controller and result
+	require.Equal(t, 2, testutil.CollectAndCount(reconcileDuration))
+	require.Equal(t, uint64(2), histogramSampleCount(t, "managedcluster", resultSuccess))
+	require.Equal(t, uint64(1), histogramSampleCount(t, "managedcluster", resultError))
+}
+
+// histogramSampleCount returns the number of the reconcile durations observed for the controller with the result.
+func histogramSampleCount(t *testing.T, controller, result string) uint64 {
+	t.Helper()
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(reconcileDuration))
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["controller"] == controller && labels["result"] == result {
+				return metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
+
+func TestDeleteManagedCluster(t *testing.T) {
+	t.Cleanup(func() { DeleteManagedCluster("default", "prod") })
+
+	for _, name := range []string{"dev", "prod"} {
+		SetManagedClusterPhase("default", name, "Ready")
+		SetManagedClusterAvailableUpgrades("default", name, "aws-0-0-1", 2)
+		SetManagedClusterUsage("default", name, "team-a", &hmc.ManagedClusterUsage{
+			Machines:      []hmc.MachineUsage{{Role: "worker", InstanceType: "t3.small", Count: 2}},
+			ProvisionedAt: &metav1.Time{Time: time.Unix(1700000000, 0)},
+		})
+	}
+
+	// the metrics of the other clusters are kept
+	DeleteManagedCluster("default", "dev")
+	require.Equal(t, 1, testutil.CollectAndCount(managedClusterPhase))
+	require.Equal(t, 1, testutil.CollectAndCount(managedClusterAvailableUpgrades))
+	require.Equal(t, 1, testutil.CollectAndCount(managedClusterMachines))
+	require.NoError(t, testutil.CollectAndCompare(managedClusterProvisionedTime, strings.NewReader(`
+# HELP hmc_managed_cluster_provisioned_timestamp_seconds The Unix time the ManagedCluster has become ready for the first time.
+# TYPE hmc_managed_cluster_provisioned_timestamp_seconds gauge
+hmc_managed_cluster_provisioned_timestamp_seconds{cost_center="team-a",name="prod",namespace="default"} 1.7e+09
+`)))
+}
@@ -0,0 +1,308 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package notifications notifies the receivers configured on the Management
+// about the provisioning of the ManagedClusters and the upgrades available for them.
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	hcv2 "github.com/fluxcd/helm-controller/api/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+const (
+	// DefaultFluxAddress is the default address of the event receiver of the Flux notification-controller.
+	DefaultFluxAddress = "http://notification-controller.flux-system.svc.cluster.local./"
+	// AddressSecretKey is the key of the address in the Secrets referenced by the receivers.
+	AddressSecretKey = "address"
+
+	reportingController = "hmc-controller"
+	defaultQueueSize    = 100
+	defaultTimeout      = 10 * time.Second
+)
+
+// Notification is the payload the webhook receivers are notified with.
+type Notification struct {
+	Event     hmc.NotificationEvent `json:"event"`
+	Namespace string                `json:"namespace"`
+	Name      string                `json:"name"`
+	Message   string                `json:"message"`
+	Metadata  map[string]string     `json:"metadata,omitempty"`
+	Timestamp metav1.Time           `json:"timestamp"`
+}
+
+// Notifier sends the notifications to the receivers configured on the Management.
+// The notifications are delivered asynchronously by the running Notifier, so
+// the reconciliation is not delayed by the latency and the failures of the
+// receivers. The notifications are buffered in a bounded queue, the ones not
+// fitting into the queue are dropped. The failures to notify a receiver are
+// logged, the notifications are never retried.
+type Notifier struct {
+	client client.Client
+	// systemNamespace is the namespace of the Secrets referenced by the receivers.
+	systemNamespace string
+	queue           chan Notification
+	dropped         atomic.Int64
+
+	// HTTPClient is the client the notifications are sent with, the default client if nil.
+	HTTPClient *http.Client
+	// Timeout is the maximum time a notification is sent to a single receiver for.
+	Timeout time.Duration
+}
+
+// NewNotifier returns the Notifier reading the receivers with the given client.
+func NewNotifier(cl client.Client, systemNamespace string) *Notifier {
+	return &Notifier{
+		client:          cl,
+		systemNamespace: systemNamespace,
+		queue:           make(chan Notification, defaultQueueSize),
+		Timeout:         defaultTimeout,
+	}
+}
+
+// Notify enqueues the notification of the receivers subscribed to the event
+// about the ManagedCluster, the notification is dropped if the queue is full.
+// The callers are expected to record the notified state before calling Notify,
+// so the notification is not repeated once the recording fails.
+// It is a no-op on a nil Notifier.
+func (n *Notifier) Notify(ctx context.Context, cluster *hmc.ManagedCluster, event hmc.NotificationEvent, message string, metadata map[string]string) {
+	if n == nil {
+		return
+	}
+
+	notification := Notification{
+		Event:     event,
+		Namespace: cluster.Namespace,
+		Name:      cluster.Name,
+		Message:   message,
+		Metadata:  metadata,
+		Timestamp: metav1.Now(),
+	}
+	select {
+	case n.queue <- notification:
+	default:
+		n.dropped.Add(1)
+		ctrl.LoggerFrom(ctx).Info("Dropping the notification, the queue is full", "event", event)
+	}
+}
+
+// Dropped returns the number of the notifications dropped because the queue was full.
+func (n *Notifier) Dropped() int64 {
+	return n.dropped.Load()
+}
+
+// NeedLeaderElection implements the LeaderElectionRunnable interface, the
+// Notifier delivers the notifications of any replica.
+func (*Notifier) NeedLeaderElection() bool {
+	return false
+}
+
+// Start delivers the queued notifications until the context is done, the
+// remaining notifications are delivered on exit.
+func (n *Notifier) Start(ctx context.Context) error {
+	l := ctrl.LoggerFrom(ctx).WithName("notifications")
+	ctx = ctrl.LoggerInto(ctx, l)
+
+	var batch []Notification
+	for {
+		select {
+		case notification := <-n.queue:
+			batch = append(batch, notification)
+		case <-ctx.Done():
+			for {
+				select {
+				case notification := <-n.queue:
+					batch = append(batch, notification)
+				default:
+					n.deliver(context.WithoutCancel(ctx), batch)
+					return nil
+				}
+			}
+		}
+
+		// the receivers are read once for whatever is queued already
+	collect:
+		for {
+			select {
+			case notification := <-n.queue:
+				batch = append(batch, notification)
+			default:
+				break collect
+			}
+		}
+
+		// the notifications are sent with their own timeouts, the ones collected
+		// before the manager shuts down are sent regardless
+		n.deliver(context.WithoutCancel(ctx), batch)
+		batch = batch[:0]
+	}
+}
+
+func (n *Notifier) deliver(ctx context.Context, batch []Notification) {
+	if len(batch) == 0 {
+		return
+	}
+	l := ctrl.LoggerFrom(ctx)
+
+	mgmt := &hmc.Management{}
+	if err := n.client.Get(ctx, client.ObjectKey{Name: hmc.ManagementName}, mgmt); err != nil {
+		l.Error(err, "failed to get Management", "dropped", len(batch))
+		return
+	}
+	if mgmt.Spec.Notifications == nil {
+		return
+	}
+
+	timeout := n.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	for _, notification := range batch {
+		for _, receiver := range mgmt.Spec.Notifications.Receivers {
+			if len(receiver.Events) > 0 && !slices.Contains(receiver.Events, notification.Event) {
+				continue
+			}
+			sendCtx, cancel := context.WithTimeout(ctx, timeout)
+			if err := n.send(sendCtx, receiver, notification); err != nil {
+				l.Error(err, "failed to notify receiver", "receiver", receiver.Name, "event", notification.Event)
+			}
+			cancel()
+		}
+	}
+}
+
+func (n *Notifier) send(ctx context.Context, receiver hmc.NotificationReceiver, notification Notification) error {
+	address, err := n.address(ctx, receiver)
+	if err != nil {
+		return err
+	}
+
+	var payload any
+	switch receiver.Type {
+	case hmc.NotificationReceiverSlack:
+		payload = slackPayload(notification)
+	case hmc.NotificationReceiverFlux:
+		payload = fluxPayload(notification)
+	default:
+		payload = notification
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, address, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	httpClient := n.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post the notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("failed to post the notification: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// address returns the address of the receiver, the address from the Secret takes precedence.
+func (n *Notifier) address(ctx context.Context, receiver hmc.NotificationReceiver) (string, error) {
+	if receiver.SecretRef != "" {
+		secret := &corev1.Secret{}
+		key := client.ObjectKey{Namespace: n.systemNamespace, Name: receiver.SecretRef}
+		if err := n.client.Get(ctx, key, secret); err != nil {
+			return "", fmt.Errorf("failed to get Secret %s: %w", key, err)
+		}
+		address := string(secret.Data[AddressSecretKey])
+		if address == "" {
+			return "", fmt.Errorf("secret %s has no %s key", key, AddressSecretKey)
+		}
+		return address, nil
+	}
+
+	if receiver.Address == "" && receiver.Type == hmc.NotificationReceiverFlux {
+		return DefaultFluxAddress, nil
+	}
+	if receiver.Address == "" {
+		return "", fmt.Errorf("receiver %s has no address", receiver.Name)
+	}
+	return receiver.Address, nil
+}
+
+// slackPayload returns the message of an incoming webhook of Slack.
+func slackPayload(notification Notification) map[string]string {
+	return map[string]string{
+		"text": fmt.Sprintf("*%s* ManagedCluster %s/%s: %s",
+			notification.Event, notification.Namespace, notification.Name, notification.Message),
+	}
+}
+
+// fluxEvent follows the layout of the events accepted by the Flux notification-controller.
+type fluxEvent struct {
+	InvolvedObject      corev1.ObjectReference `json:"involvedObject"`
+	Severity            string                 `json:"severity"`
+	Timestamp           metav1.Time            `json:"timestamp"`
+	Message             string                 `json:"message"`
+	Reason              string                 `json:"reason"`
+	Metadata            map[string]string      `json:"metadata,omitempty"`
+	ReportingController string                 `json:"reportingController"`
+}
+
+// fluxPayload returns the Flux event of the notification. The event is reported
+// against the HelmRelease of the cluster, the kinds of the event sources of the
+// Flux Alerts are limited to the Flux objects.
+func fluxPayload(notification Notification) fluxEvent {
+	severity := "info"
+	if notification.Event == hmc.NotificationProvisioningFailed {
+		severity = "error"
+	}
+
+	return fluxEvent{
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: hcv2.GroupVersion.String(),
+			Kind:       hcv2.HelmReleaseKind,
+			Namespace:  notification.Namespace,
+			Name:       notification.Name,
+		},
+		Severity:            severity,
+		Timestamp:           notification.Timestamp,
+		Message:             notification.Message,
+		Reason:              string(notification.Event),
+		Metadata:            notification.Metadata,
+		ReportingController: reportingController,
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+func TestNotify(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received = map[string]map[string]any{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := map[string]any{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		received[r.URL.Path] = payload
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, hmc.AddToScheme(scheme))
+
+	mgmt := &hmc.Management{
+		ObjectMeta: metav1.ObjectMeta{Name: hmc.ManagementName},
+		Spec: hmc.ManagementSpec{Notifications: &hmc.Notifications{Receivers: []hmc.NotificationReceiver{
+			{Name: "webhook", Type: hmc.NotificationReceiverWebhook, Address: server.URL + "/webhook"},
+			{Name: "slack", Type: hmc.NotificationReceiverSlack, SecretRef: "slack"},
+			{Name: "flux", Type: hmc.NotificationReceiverFlux, Address: server.URL + "/flux"},
+			{
+				Name: "upgrades", Type: hmc.NotificationReceiverWebhook, Address: server.URL + "/upgrades",
+				Events: []hmc.NotificationEvent{hmc.NotificationUpgradeAvailable},
+			},
+		}}},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "hmc-system", Name: "slack"},
+		Data:       map[string][]byte{AddressSecretKey: []byte(server.URL + "/slack")},
+	}
+	n := NewNotifier(fake.NewClientBuilder().WithScheme(scheme).WithObjects(mgmt, secret).Build(), "hmc-system")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = n.Start(ctx) }()
+
+	cluster := &hmc.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "dev"}}
+	n.Notify(ctx, cluster, hmc.NotificationProvisioningFailed, "boom", map[string]string{"template": "aws"})
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Equal(t, "ProvisioningFailed", received["/webhook"]["event"])
+	require.Equal(t, "default", received["/webhook"]["namespace"])
+	require.Equal(t, "dev", received["/webhook"]["name"])
+	require.Equal(t, map[string]any{"template": "aws"}, received["/webhook"]["metadata"])
+	require.Equal(t, "*ProvisioningFailed* ManagedCluster default/dev: boom", received["/slack"]["text"])
+	require.Equal(t, "error", received["/flux"]["severity"])
+	require.Equal(t, "ProvisioningFailed", received["/flux"]["reason"])
+	require.Equal(t, map[string]any{
+		"apiVersion": "helm.toolkit.fluxcd.io/v2",
+		"kind":       "HelmRelease",
+		"namespace":  "default",
+		"name":       "dev",
+	}, received["/flux"]["involvedObject"])
+
+	// a nil Notifier is a no-op
+	var nilNotifier *Notifier
+	nilNotifier.Notify(context.Background(), cluster, hmc.NotificationProvisioningFailed, "boom", nil)
+}
+
+func TestNotifierQueue(t *testing.T) {
+	received := make(chan string, defaultQueueSize+1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		<-release
+		payload := Notification{}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload.Message
+	}))
+	defer server.Close()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, hmc.AddToScheme(scheme))
+	mgmt := &hmc.Management{
+		ObjectMeta: metav1.ObjectMeta{Name: hmc.ManagementName},
+		Spec: hmc.ManagementSpec{Notifications: &hmc.Notifications{Receivers: []hmc.NotificationReceiver{
+			{Name: "webhook", Type: hmc.NotificationReceiverWebhook, Address: server.URL},
+		}}},
+	}
+	n := NewNotifier(fake.NewClientBuilder().WithScheme(scheme).WithObjects(mgmt).Build(), "hmc-system")
+	cluster := &hmc.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "dev"}}
+
+	// Notify never blocks, the notifications not fitting into the queue are dropped
+	for i := 0; i < defaultQueueSize+1; i++ {
+		n.Notify(context.Background(), cluster, hmc.NotificationUpgradeAvailable, "upgrade", nil)
+	}
+	require.Equal(t, int64(1), n.Dropped())
+
+	// the queued notifications are delivered on exit
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	close(release)
+	require.NoError(t, n.Start(ctx))
+	require.Len(t, received, defaultQueueSize)
+}
+
+func TestNotifierTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, hmc.AddToScheme(scheme))
+	mgmt := &hmc.Management{
+		ObjectMeta: metav1.ObjectMeta{Name: hmc.ManagementName},
+		Spec: hmc.ManagementSpec{Notifications: &hmc.Notifications{Receivers: []hmc.NotificationReceiver{
+			{Name: "first", Type: hmc.NotificationReceiverWebhook, Address: server.URL},
+			{Name: "second", Type: hmc.NotificationReceiverWebhook, Address: server.URL},
+		}}},
+	}
+	n := NewNotifier(fake.NewClientBuilder().WithScheme(scheme).WithObjects(mgmt).Build(), "hmc-system")
+	n.Timeout = 50 * time.Millisecond
+
+	// a hanging receiver delays the delivery by the timeout only
+	start := time.Now()
+	n.deliver(context.Background(), []Notification{{Event: hmc.NotificationProvisioningStarted}})
+	require.Less(t, time.Since(start), 2*time.Second)
+}
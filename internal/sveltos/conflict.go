@@ -0,0 +1,265 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sveltos
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	sveltosv1beta1 "github.com/projectsveltos/addon-controller/api/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+const (
+	// ProfileLabelKey is set by Sveltos on the ClusterSummaries to the name of the Profile.
+	ProfileLabelKey = "projectsveltos.io/profile-name"
+	// ClusterProfileLabelKey is set by Sveltos on the ClusterSummaries to the name of the ClusterProfile.
+	ClusterProfileLabelKey = "projectsveltos.io/cluster-profile-name"
+)
+
+// ProfilesByPolicy splits the helm charts of the profile by their conflict and deletion policies,
+// since Sveltos applies the policies to the whole profile. Each chart is deployed by its own profile,
+// see ReleaseProfileName, derived from the profile of the given name if the chart follows the
+// policy implied by StopOnConflict, or from the profile derived per policy otherwise, see
+// PolicyProfileName. The charts with the Force policy are deployed with the highest priority.
+// The profile of the given name deploys no charts and is always returned.
+//
+// The profile of the chart with the Orphan deletion policy leaves the chart in place once deleted
+// along with the service, since Sveltos uninstalls the charts removed from a profile. Switching the
+// deletion policy only changes the stop matching behavior of the profile, the release stays deployed.
+func ProfilesByPolicy(name string, opts ReconcileProfileOpts) map[string]ReconcileProfileOpts {
+	var uid types.UID
+	if opts.OwnerReference != nil {
+		uid = opts.OwnerReference.UID
+	}
+
+	defaultPolicy := hmc.ServiceConflictPolicySkip
+	if opts.StopOnConflict {
+		defaultPolicy = hmc.ServiceConflictPolicyStop
+	}
+
+	profiles := map[string]ReconcileProfileOpts{}
+	for _, hc := range opts.HelmChartOpts {
+		policy := hc.ConflictPolicy
+		if policy == "" {
+			policy = defaultPolicy
+		}
+
+		profileName := name
+		if policy != defaultPolicy {
+			profileName = PolicyProfileName(name, uid, policy)
+		}
+		profileName = ReleaseProfileName(profileName, uid, hc.ReleaseName)
+
+		profile, ok := profiles[profileName]
+		if !ok {
+			profile = opts
+			profile.HelmChartOpts = nil
+			profile.StopOnConflict = policy == hmc.ServiceConflictPolicyStop
+			if policy == hmc.ServiceConflictPolicyForce {
+				profile.Priority = math.MaxInt32 - 1
+			}
+			profile.LeavePolicies = hc.DeletionPolicy == hmc.ServiceDeletionPolicyOrphan
+		}
+		profile.HelmChartOpts = append(profile.HelmChartOpts, hc)
+		profiles[profileName] = profile
+	}
+
+	if _, ok := profiles[name]; !ok {
+		opts.HelmChartOpts = nil
+		profiles[name] = opts
+	}
+
+	return profiles
+}
+
+// PolicyProfileName returns the name of the profile deploying the charts with the given conflict
+// policy derived from the profile of the given name owned by the object of the given UID.
+func PolicyProfileName(name string, uid types.UID, policy hmc.ServiceConflictPolicy) string {
+	if policy == "" {
+		return name
+	}
+	return DerivedProfileName(name, uid, "policy/"+string(policy))
+}
+
+// ReleaseProfileName returns the name of the profile deploying the chart of the release derived
+// from the profile of the given name owned by the object of the given UID.
+func ReleaseProfileName(name string, uid types.UID, releaseName string) string {
+	return DerivedProfileName(name, uid, "release/"+releaseName)
+}
+
+// ProfileConflicts returns the helm charts of the Profile not deployed on the cluster
+// since another profile manages them. The cluster is not set in the returned conflicts.
+// The conflicts on all of the selected clusters are returned if the clusterName is empty.
+func ProfileConflicts(ctx context.Context, cl client.Client, namespace, profile, clusterName string) ([]hmc.ServiceConflict, error) {
+	labels := client.MatchingLabels{ProfileLabelKey: profile}
+	if clusterName == "" {
+		return conflicts(ctx, cl, client.InNamespace(namespace), labels)
+	}
+
+	labels[sveltosv1beta1.ClusterNameLabel] = clusterName
+	result, err := conflicts(ctx, cl, client.InNamespace(namespace), labels)
+	for i := range result {
+		result[i].Cluster = ""
+	}
+	return result, err
+}
+
+// ClusterProfileConflicts returns the helm charts of the ClusterProfile not deployed
+// on the selected clusters since another profile manages them.
+func ClusterProfileConflicts(ctx context.Context, cl client.Client, profile string) ([]hmc.ServiceConflict, error) {
+	return conflicts(ctx, cl, client.MatchingLabels{ClusterProfileLabelKey: profile})
+}
+
+// conflicts returns the conflicting helm charts of the ClusterSummaries matching the options
+// with the profiles managing the charts.
+func conflicts(ctx context.Context, cl client.Client, opts ...client.ListOption) ([]hmc.ServiceConflict, error) {
+	_, list, err := listClusterSummaries(ctx, cl, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	managers := make(map[string]map[string]string)
+	var result []hmc.ServiceConflict
+	for _, item := range list.Items {
+		summaries, err := helmChartSummaries(&item)
+		if err != nil {
+			return nil, err
+		}
+
+		clusterNamespace, _, _ := unstructured.NestedString(item.Object, "spec", "clusterNamespace")
+		clusterName, _, _ := unstructured.NestedString(item.Object, "spec", "clusterName")
+		clusterType, _, _ := unstructured.NestedString(item.Object, "spec", "clusterType")
+		cluster := clusterNamespace + "/" + clusterName
+		for _, summary := range summaries {
+			if summary.Status != sveltosv1beta1.HelmChartStatusConflict {
+				continue
+			}
+
+			clusterKey := clusterType + ":" + cluster
+			if _, ok := managers[clusterKey]; !ok {
+				managers[clusterKey], err = releaseManagers(ctx, cl, clusterNamespace, clusterName, clusterType)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			result = append(result, hmc.ServiceConflict{
+				Cluster:   cluster,
+				Name:      summary.ReleaseName,
+				Namespace: summary.ReleaseNamespace,
+				ManagedBy: managers[clusterKey][summary.ReleaseNamespace+"/"+summary.ReleaseName],
+				Message:   summary.ConflictMessage,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// releaseManagers returns the Kind/name of the profiles managing the helm releases on the cluster
+// by the namespace/name of the releases. The profiles are the ones of the ClusterSummaries
+// of the cluster reporting the releases as managed.
+func releaseManagers(ctx context.Context, cl client.Client, clusterNamespace, clusterName, clusterType string) (map[string]string, error) {
+	_, list, err := listClusterSummaries(ctx, cl, client.InNamespace(clusterNamespace), client.MatchingLabels{
+		sveltosv1beta1.ClusterNameLabel: clusterName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	managers := make(map[string]string)
+	for _, item := range list.Items {
+		name, _, _ := unstructured.NestedString(item.Object, "spec", "clusterName")
+		typ, _, _ := unstructured.NestedString(item.Object, "spec", "clusterType")
+		if name != clusterName || typ != clusterType {
+			continue
+		}
+
+		profile := profileOf(&item)
+		if profile == "" {
+			continue
+		}
+
+		summaries, err := helmChartSummaries(&item)
+		if err != nil {
+			return nil, err
+		}
+		for _, summary := range summaries {
+			if summary.Status == sveltosv1beta1.HelmChartStatusManaging {
+				managers[summary.ReleaseNamespace+"/"+summary.ReleaseName] = profile
+			}
+		}
+	}
+
+	return managers, nil
+}
+
+// profileOf returns the Kind/name of the profile of the ClusterSummary or an empty string if it is unknown.
+func profileOf(clusterSummary *unstructured.Unstructured) string {
+	if name, ok := clusterSummary.GetLabels()[ClusterProfileLabelKey]; ok {
+		return sveltosv1beta1.ClusterProfileKind + "/" + name
+	}
+	if name, ok := clusterSummary.GetLabels()[ProfileLabelKey]; ok {
+		return sveltosv1beta1.ProfileKind + "/" + name
+	}
+	return ""
+}
+
+// listClusterSummaries lists the ClusterSummaries of the served version of the Sveltos API.
+func listClusterSummaries(ctx context.Context, cl client.Client, opts ...client.ListOption) (string, *unstructured.UnstructuredList, error) {
+	version, err := ServedVersion(cl)
+	if err != nil {
+		return "", nil, err
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(sveltosv1beta1.GroupVersion.WithKind(sveltosv1beta1.ClusterSummaryKind + "List"))
+	list.SetAPIVersion(sveltosv1beta1.GroupVersion.Group + "/" + version)
+	if err := cl.List(ctx, list, opts...); err != nil {
+		return "", nil, fmt.Errorf("failed to list ClusterSummaries: %w", err)
+	}
+	return version, list, nil
+}
+
+// helmChartSummaries returns the summaries of the helm charts of the ClusterSummary.
+func helmChartSummaries(clusterSummary *unstructured.Unstructured) ([]sveltosv1beta1.HelmChartSummary, error) {
+	// the summaries of the helm charts are the same in all of the served versions
+	raw, _, err := unstructured.NestedSlice(clusterSummary.Object, "status", "helmReleaseSummaries")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the helm chart summaries of ClusterSummary %s: %w", client.ObjectKeyFromObject(clusterSummary), err)
+	}
+
+	summaries := make([]sveltosv1beta1.HelmChartSummary, 0, len(raw))
+	for _, entry := range raw {
+		entryMap, ok := entry.(map[string]any)
+		if !ok {
+			continue
+		}
+		summary := sveltosv1beta1.HelmChartSummary{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(entryMap, &summary); err != nil {
+			return nil, fmt.Errorf("failed to parse the helm chart summaries of ClusterSummary %s: %w", client.ObjectKeyFromObject(clusterSummary), err)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
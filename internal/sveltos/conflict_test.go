@@ -0,0 +1,181 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sveltos
+
+import (
+	"context"
+	"testing"
+
+	sveltosv1beta1 "github.com/projectsveltos/addon-controller/api/v1beta1"
+	libsveltosv1beta1 "github.com/projectsveltos/libsveltos/api/v1beta1"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestProfilesByPolicy(t *testing.T) {
+	charts := []HelmChartOpts{
+		{ReleaseName: "default"},
+		{ReleaseName: "stop", ConflictPolicy: hmc.ServiceConflictPolicyStop},
+		{ReleaseName: "skip", ConflictPolicy: hmc.ServiceConflictPolicySkip},
+		{ReleaseName: "force", ConflictPolicy: hmc.ServiceConflictPolicyForce},
+	}
+	owner := &metav1.OwnerReference{UID: "uid"}
+
+	releases := func(opts ReconcileProfileOpts) []string {
+		names := make([]string, 0, len(opts.HelmChartOpts))
+		for _, hc := range opts.HelmChartOpts {
+			names = append(names, hc.ReleaseName)
+		}
+		return names
+	}
+	release := func(policy hmc.ServiceConflictPolicy, releaseName string) string {
+		return ReleaseProfileName(PolicyProfileName("test", owner.UID, policy), owner.UID, releaseName)
+	}
+
+	t.Run("continue on conflict", func(t *testing.T) {
+		profiles := ProfilesByPolicy("test", ReconcileProfileOpts{OwnerReference: owner, Priority: 100, HelmChartOpts: charts})
+		require.Len(t, profiles, 5)
+		require.Empty(t, profiles["test"].HelmChartOpts)
+		require.Equal(t, []string{"default"}, releases(profiles[release("", "default")]))
+		require.False(t, profiles[release("", "default")].StopOnConflict)
+		require.Equal(t, []string{"skip"}, releases(profiles[release("", "skip")]))
+		require.Equal(t, []string{"stop"}, releases(profiles[release(hmc.ServiceConflictPolicyStop, "stop")]))
+		require.True(t, profiles[release(hmc.ServiceConflictPolicyStop, "stop")].StopOnConflict)
+		require.Equal(t, int32(100), profiles[release(hmc.ServiceConflictPolicyStop, "stop")].Priority)
+		require.Equal(t, []string{"force"}, releases(profiles[release(hmc.ServiceConflictPolicyForce, "force")]))
+		require.Equal(t, int32(2147483646), profiles[release(hmc.ServiceConflictPolicyForce, "force")].Priority)
+	})
+
+	t.Run("stop on conflict", func(t *testing.T) {
+		profiles := ProfilesByPolicy("test", ReconcileProfileOpts{OwnerReference: owner, StopOnConflict: true, HelmChartOpts: charts})
+		require.Len(t, profiles, 5)
+		require.True(t, profiles[release("", "default")].StopOnConflict)
+		require.True(t, profiles[release("", "stop")].StopOnConflict)
+		require.Equal(t, []string{"skip"}, releases(profiles[release(hmc.ServiceConflictPolicySkip, "skip")]))
+		require.False(t, profiles[release(hmc.ServiceConflictPolicySkip, "skip")].StopOnConflict)
+	})
+
+	t.Run("deletion policy", func(t *testing.T) {
+		profiles := ProfilesByPolicy("test", ReconcileProfileOpts{OwnerReference: owner, HelmChartOpts: []HelmChartOpts{
+			{ReleaseName: "default"},
+			{ReleaseName: "orphan", DeletionPolicy: hmc.ServiceDeletionPolicyOrphan},
+			{ReleaseName: "forced", DeletionPolicy: hmc.ServiceDeletionPolicyOrphan, ConflictPolicy: hmc.ServiceConflictPolicyForce},
+		}})
+		require.Len(t, profiles, 4)
+		require.False(t, profiles[release("", "default")].LeavePolicies)
+		require.True(t, profiles[release("", "orphan")].LeavePolicies)
+		require.True(t, profiles[release(hmc.ServiceConflictPolicyForce, "forced")].LeavePolicies)
+		require.Equal(t, int32(2147483646), profiles[release(hmc.ServiceConflictPolicyForce, "forced")].Priority)
+
+		// switching the deletion policy keeps the chart in its profile
+		switched := ProfilesByPolicy("test", ReconcileProfileOpts{OwnerReference: owner, HelmChartOpts: []HelmChartOpts{
+			{ReleaseName: "default", DeletionPolicy: hmc.ServiceDeletionPolicyOrphan},
+			{ReleaseName: "orphan", DeletionPolicy: hmc.ServiceDeletionPolicyDelete},
+			{ReleaseName: "forced", ConflictPolicy: hmc.ServiceConflictPolicyForce},
+		}})
+		require.Len(t, switched, 4)
+		for profileName := range profiles {
+			require.Contains(t, switched, profileName)
+		}
+		require.True(t, switched[release("", "default")].LeavePolicies)
+		require.False(t, switched[release("", "orphan")].LeavePolicies)
+		require.False(t, switched[release(hmc.ServiceConflictPolicyForce, "forced")].LeavePolicies)
+	})
+
+	t.Run("no charts", func(t *testing.T) {
+		profiles := ProfilesByPolicy("test", ReconcileProfileOpts{})
+		require.Len(t, profiles, 1)
+		require.Empty(t, profiles["test"].HelmChartOpts)
+	})
+
+	t.Run("names not colliding with the user objects", func(t *testing.T) {
+		// the profile of an object named like the suffixed profile of another one is not taken over
+		profiles := ProfilesByPolicy("test", ReconcileProfileOpts{OwnerReference: owner, HelmChartOpts: charts})
+		require.NotContains(t, profiles, "test--force")
+		require.NotEqual(t, release(hmc.ServiceConflictPolicyForce, "force"),
+			ReleaseProfileName(PolicyProfileName("test", "other-uid", hmc.ServiceConflictPolicyForce), "other-uid", "force"))
+	})
+}
+
+func TestClusterProfileConflicts(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{sveltosv1beta1.GroupVersion})
+	mapper.Add(sveltosv1beta1.GroupVersion.WithKind(sveltosv1beta1.ProfileKind), meta.RESTScopeNamespace)
+	mapper.Add(sveltosv1beta1.GroupVersion.WithKind(sveltosv1beta1.ClusterSummaryKind), meta.RESTScopeNamespace)
+
+	clusterSummary := func(name, profile string, summaries ...sveltosv1beta1.HelmChartSummary) *sveltosv1beta1.ClusterSummary {
+		return &sveltosv1beta1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      name,
+				Labels: map[string]string{
+					ClusterProfileLabelKey:          profile,
+					sveltosv1beta1.ClusterNameLabel: "cluster",
+				},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: sveltosv1beta1.GroupVersion.String(),
+					Kind:       sveltosv1beta1.ClusterProfileKind,
+					Name:       profile,
+				}},
+			},
+			Spec: sveltosv1beta1.ClusterSummarySpec{
+				ClusterNamespace: "default",
+				ClusterName:      "cluster",
+				ClusterType:      libsveltosv1beta1.ClusterTypeCapi,
+			},
+			Status: sveltosv1beta1.ClusterSummaryStatus{HelmReleaseSummaries: summaries},
+		}
+	}
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper).WithObjects(
+		clusterSummary("test-summary", "test",
+			sveltosv1beta1.HelmChartSummary{ReleaseName: "ingress", ReleaseNamespace: "ingress", Status: sveltosv1beta1.HelmChartStatusManaging},
+			sveltosv1beta1.HelmChartSummary{
+				ReleaseName:      "kyverno",
+				ReleaseNamespace: "kyverno",
+				Status:           sveltosv1beta1.HelmChartStatusConflict,
+				ConflictMessage:  "managed by another profile",
+			},
+			sveltosv1beta1.HelmChartSummary{ReleaseName: "cert-manager", ReleaseNamespace: "cert-manager", Status: sveltosv1beta1.HelmChartStatusConflict},
+		),
+		clusterSummary("other-summary", "other",
+			sveltosv1beta1.HelmChartSummary{ReleaseName: "kyverno", ReleaseNamespace: "kyverno", Status: sveltosv1beta1.HelmChartStatusManaging},
+		),
+	).Build()
+
+	conflicts, err := ClusterProfileConflicts(context.Background(), cl, "test")
+	require.NoError(t, err)
+	require.Equal(t, []hmc.ServiceConflict{{
+		Cluster:   "default/cluster",
+		Name:      "kyverno",
+		Namespace: "kyverno",
+		ManagedBy: "ClusterProfile/other",
+		Message:   "managed by another profile",
+	}, {
+		// the manager of the release is not reported yet
+		Cluster:   "default/cluster",
+		Name:      "cert-manager",
+		Namespace: "cert-manager",
+	}}, conflicts)
+
+	conflicts, err = ClusterProfileConflicts(context.Background(), cl, "other")
+	require.NoError(t, err)
+	require.Empty(t, conflicts)
+}
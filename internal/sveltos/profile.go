@@ -16,28 +16,74 @@ package sveltos
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"maps"
 	"math"
+	"slices"
 	"unsafe"
 
+	sveltosv1alpha1 "github.com/projectsveltos/addon-controller/api/v1alpha1"
 	sveltosv1beta1 "github.com/projectsveltos/addon-controller/api/v1beta1"
 	libsveltosv1beta1 "github.com/projectsveltos/libsveltos/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/yaml"
 
 	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/errdefs"
+	"github.com/Mirantis/hmc/internal/utils"
 )
 
+// SpecHashAnnotation is set on the Profiles and the ClusterProfiles to the hash of
+// their spec, so that the pending changes are detected regardless of the fields
+// defaulted by Sveltos and the served version of the Sveltos API.
+const SpecHashAnnotation = "hmc.mirantis.com/spec-hash"
+
+// RevisionAnnotation is set on the Profiles and the ClusterProfiles to the revision
+// of the services they deploy, see Revision.
+const RevisionAnnotation = "hmc.mirantis.com/revision"
+
+// maxProfileNameLength is the maximum length of the names of the profiles.
+const maxProfileNameLength = 253
+
+// DerivedProfileName returns the name of a profile derived from the profile of the given name
+// owned by the object of the given UID. The name is suffixed with the hash of the UID and the
+// discriminator rather than with the discriminator itself, so that the derived profiles collide
+// neither with each other nor with the profiles of the objects named like the derived ones.
+func DerivedProfileName(name string, uid types.UID, discriminator string) string {
+	sum := sha256.Sum256([]byte(string(uid) + "/" + discriminator))
+	suffix := fmt.Sprintf("-%x", sum[:5])
+	if len(name)+len(suffix) > maxProfileNameLength {
+		name = name[:maxProfileNameLength-len(suffix)]
+	}
+	return name + suffix
+}
+
 type ReconcileProfileOpts struct {
 	OwnerReference *metav1.OwnerReference
+	// Labels are set on the Profile along with the HMC managed label.
+	Labels         map[string]string
 	LabelSelector  metav1.LabelSelector
 	HelmChartOpts  []HelmChartOpts
 	Priority       int32
 	StopOnConflict bool
+	// LeavePolicies leaves the charts deployed on the clusters once the profile
+	// is deleted or the cluster stops matching the profile.
+	LeavePolicies bool
+	// ClusterRefs are the clusters selected along with the clusters matching the LabelSelector.
+	ClusterRefs []corev1.ObjectReference
+	// HealthChecks are evaluated on the clusters before the charts count as deployed.
+	HealthChecks []hmc.ServiceHealthCheck
+	// Revision is set on the profile in the RevisionAnnotation if not empty.
+	Revision string
 }
 
 type HelmChartOpts struct {
@@ -50,53 +96,81 @@ type HelmChartOpts struct {
 	ReleaseNamespace      string
 	PlainHTTP             bool
 	InsecureSkipTLSVerify bool
+	// ConflictPolicy overrides the StopOnConflict of the profile for the chart.
+	ConflictPolicy hmc.ServiceConflictPolicy
+	// DeletionPolicy defines whether the chart is uninstalled once removed from the profile.
+	DeletionPolicy hmc.ServiceDeletionPolicy
 }
 
-// ReconcileClusterProfile reconciles a Sveltos ClusterProfile object.
+// ReconcileClusterProfile applies a Sveltos ClusterProfile object with the server-side apply
+// and returns the performed operation.
 func ReconcileClusterProfile(
 	ctx context.Context,
 	cl client.Client,
 	name string,
 	opts ReconcileProfileOpts,
-) (*sveltosv1beta1.ClusterProfile, error) {
+) (*sveltosv1beta1.ClusterProfile, controllerutil.OperationResult, error) {
 	l := ctrl.LoggerFrom(ctx)
-	obj := objectMeta(opts.OwnerReference)
+	obj := objectMeta(opts.OwnerReference, opts.Labels)
 	obj.SetName(name)
 
 	cp := &sveltosv1beta1.ClusterProfile{
 		ObjectMeta: obj,
 	}
 
-	operation, err := ctrl.CreateOrUpdate(ctx, cl, cp, func() error {
-		spec, err := Spec(&opts)
-		if err != nil {
-			return err
-		}
-		cp.Spec = *spec
+	version, err := ServedVersion(cl)
+	if err != nil {
+		return nil, controllerutil.OperationResultNone, err
+	}
 
-		return nil
-	})
+	spec, err := Spec(&opts)
 	if err != nil {
-		return nil, err
+		return nil, controllerutil.OperationResultNone, err
+	}
+	cp.Spec = *spec
+	cp.Annotations = map[string]string{SpecHashAnnotation: specHash(spec)}
+	if opts.Revision != "" {
+		cp.Annotations[RevisionAnnotation] = opts.Revision
+	}
+
+	var operation controllerutil.OperationResult
+	switch version {
+	case sveltosv1beta1.GroupVersion.Version:
+		operation, err = utils.Apply(ctx, cl, cp)
+	case sveltosv1alpha1.GroupVersion.Version:
+		legacy := &sveltosv1alpha1.ClusterProfile{}
+		if err = legacy.ConvertFrom(cp); err != nil {
+			break
+		}
+		if operation, err = utils.Apply(ctx, cl, legacy); err != nil {
+			break
+		}
+		legacy.ObjectMeta.DeepCopyInto(&cp.ObjectMeta)
+	default:
+		err = errdefs.Terminal(fmt.Errorf("unsupported version %s of the Sveltos API", version))
+	}
+	if err != nil {
+		return nil, operation, err
 	}
 
 	if operation == controllerutil.OperationResultCreated || operation == controllerutil.OperationResultUpdated {
 		l.Info(fmt.Sprintf("Successfully %s ClusterProfile %s", string(operation), cp.Name))
 	}
 
-	return cp, nil
+	return cp, operation, nil
 }
 
-// ReconcileProfile reconciles a Sveltos Profile object.
+// ReconcileProfile applies a Sveltos Profile object with the server-side apply
+// and returns the performed operation.
 func ReconcileProfile(
 	ctx context.Context,
 	cl client.Client,
 	namespace string,
 	name string,
 	opts ReconcileProfileOpts,
-) (*sveltosv1beta1.Profile, error) {
+) (*sveltosv1beta1.Profile, controllerutil.OperationResult, error) {
 	l := ctrl.LoggerFrom(ctx)
-	obj := objectMeta(opts.OwnerReference)
+	obj := objectMeta(opts.OwnerReference, opts.Labels)
 	obj.SetNamespace(namespace)
 	obj.SetName(name)
 
@@ -104,24 +178,46 @@ func ReconcileProfile(
 		ObjectMeta: obj,
 	}
 
-	operation, err := ctrl.CreateOrUpdate(ctx, cl, p, func() error {
-		spec, err := Spec(&opts)
-		if err != nil {
-			return err
-		}
-		p.Spec = *spec
+	version, err := ServedVersion(cl)
+	if err != nil {
+		return nil, controllerutil.OperationResultNone, err
+	}
 
-		return nil
-	})
+	spec, err := Spec(&opts)
 	if err != nil {
-		return nil, err
+		return nil, controllerutil.OperationResultNone, err
+	}
+	p.Spec = *spec
+	p.Annotations = map[string]string{SpecHashAnnotation: specHash(spec)}
+	if opts.Revision != "" {
+		p.Annotations[RevisionAnnotation] = opts.Revision
+	}
+
+	var operation controllerutil.OperationResult
+	switch version {
+	case sveltosv1beta1.GroupVersion.Version:
+		operation, err = utils.Apply(ctx, cl, p)
+	case sveltosv1alpha1.GroupVersion.Version:
+		legacy := &sveltosv1alpha1.Profile{}
+		if err = legacy.ConvertFrom(p); err != nil {
+			break
+		}
+		if operation, err = utils.Apply(ctx, cl, legacy); err != nil {
+			break
+		}
+		legacy.ObjectMeta.DeepCopyInto(&p.ObjectMeta)
+	default:
+		err = errdefs.Terminal(fmt.Errorf("unsupported version %s of the Sveltos API", version))
+	}
+	if err != nil {
+		return nil, operation, err
 	}
 
 	if operation == controllerutil.OperationResultCreated || operation == controllerutil.OperationResultUpdated {
 		l.Info(fmt.Sprintf("Successfully %s Profile %s", string(operation), p.Name))
 	}
 
-	return p, nil
+	return p, operation, nil
 }
 
 // Spec returns a spec object to be used with
@@ -134,12 +230,28 @@ func Spec(opts *ReconcileProfileOpts) (*sveltosv1beta1.Spec, error) {
 
 	spec := &sveltosv1beta1.Spec{
 		ClusterSelector: libsveltosv1beta1.Selector{
-			LabelSelector: opts.LabelSelector,
+			LabelSelector: withoutDrainingClusters(opts.LabelSelector),
 		},
+		ClusterRefs:        opts.ClusterRefs,
 		Tier:               tier,
 		ContinueOnConflict: !opts.StopOnConflict,
 		HelmCharts:         make([]sveltosv1beta1.HelmChart, 0, len(opts.HelmChartOpts)),
 	}
+	for _, check := range opts.HealthChecks {
+		spec.ValidateHealths = append(spec.ValidateHealths, sveltosv1beta1.ValidateHealth{
+			Name:      check.Name,
+			FeatureID: sveltosv1beta1.FeatureHelm,
+			Group:     check.Group,
+			Version:   check.Version,
+			Kind:      check.Kind,
+			Namespace: check.Namespace,
+			Script:    check.Script,
+		})
+	}
+	spec.StopMatchingBehavior = sveltosv1beta1.WithdrawPolicies
+	if opts.LeavePolicies {
+		spec.StopMatchingBehavior = sveltosv1beta1.LeavePolicies
+	}
 
 	for _, hc := range opts.HelmChartOpts {
 		helmChart := sveltosv1beta1.HelmChart{
@@ -176,12 +288,64 @@ func Spec(opts *ReconcileProfileOpts) (*sveltosv1beta1.Spec, error) {
 	return spec, nil
 }
 
-func objectMeta(owner *metav1.OwnerReference) metav1.ObjectMeta {
+// withoutDrainingClusters returns the selector not selecting the clusters the services
+// are withdrawn from, the empty selector selecting no clusters is returned as is.
+func withoutDrainingClusters(selector metav1.LabelSelector) metav1.LabelSelector {
+	if len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0 {
+		return selector
+	}
+	selector = *selector.DeepCopy()
+	selector.MatchExpressions = append(selector.MatchExpressions, metav1.LabelSelectorRequirement{
+		Key:      hmc.ServicesDrainingLabelKey,
+		Operator: metav1.LabelSelectorOpDoesNotExist,
+	})
+	return selector
+}
+
+// specHash returns the hash of the spec of a Profile or a ClusterProfile.
+func specHash(spec *sveltosv1beta1.Spec) string {
+	raw, _ := json.Marshal(spec)
+	return fmt.Sprintf("%x", sha256.Sum256(raw))
+}
+
+// ProfilesChanged returns true if the Profiles in the namespace matching the labels
+// differ from the Profiles built from the given options keyed by the Profile name,
+// and whether any of the Profiles matching the labels exists.
+func ProfilesChanged(ctx context.Context, cl client.Client, namespace string, labels map[string]string, profiles map[string]ReconcileProfileOpts) (changed, exist bool, err error) {
+	_, list, err := listObjects(ctx, cl, sveltosv1beta1.ProfileKind, namespace, labels)
+	if err != nil {
+		return false, false, err
+	}
+	if len(list.Items) == 0 {
+		return len(profiles) > 0, false, nil
+	}
+	if len(list.Items) != len(profiles) {
+		return true, true, nil
+	}
+
+	for _, profile := range list.Items {
+		opts, ok := profiles[profile.Name]
+		if !ok {
+			return true, true, nil
+		}
+		spec, err := Spec(&opts)
+		if err != nil {
+			return false, true, err
+		}
+		if profile.Annotations[SpecHashAnnotation] != specHash(spec) {
+			return true, true, nil
+		}
+	}
+	return false, true, nil
+}
+
+func objectMeta(owner *metav1.OwnerReference, labels map[string]string) metav1.ObjectMeta {
 	obj := metav1.ObjectMeta{
 		Labels: map[string]string{
 			hmc.HMCManagedLabelKey: hmc.HMCManagedLabelValue,
 		},
 	}
+	maps.Copy(obj.Labels, labels)
 
 	if owner != nil {
 		obj.OwnerReferences = []metav1.OwnerReference{*owner}
@@ -192,25 +356,114 @@ func objectMeta(owner *metav1.OwnerReference) metav1.ObjectMeta {
 
 // DeleteProfile deletes a Sveltos Profile object.
 func DeleteProfile(ctx context.Context, cl client.Client, namespace, name string) error {
-	err := cl.Delete(ctx, &sveltosv1beta1.Profile{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: namespace,
-			Name:      name,
-		},
-	})
-
-	return client.IgnoreNotFound(err)
+	return deleteObject(ctx, cl, sveltosv1beta1.ProfileKind, namespace, name)
 }
 
 // DeleteClusterProfile deletes a Sveltos ClusterProfile object.
 func DeleteClusterProfile(ctx context.Context, cl client.Client, name string) error {
-	err := cl.Delete(ctx, &sveltosv1beta1.ClusterProfile{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: name,
-		},
+	return deleteObject(ctx, cl, sveltosv1beta1.ClusterProfileKind, "", name)
+}
+
+// DeleteProfiles deletes the Sveltos Profile objects in the namespace matching
+// the labels except for the ones with the names to keep.
+func DeleteProfiles(ctx context.Context, cl client.Client, namespace string, labels map[string]string, keep ...string) error {
+	return deleteObjects(ctx, cl, sveltosv1beta1.ProfileKind, namespace, labels, keep)
+}
+
+// DeleteClusterProfiles deletes the Sveltos ClusterProfile objects matching
+// the labels except for the ones with the names to keep.
+func DeleteClusterProfiles(ctx context.Context, cl client.Client, labels map[string]string, keep ...string) error {
+	return deleteObjects(ctx, cl, sveltosv1beta1.ClusterProfileKind, "", labels, keep)
+}
+
+// ListProfiles returns the names of the Sveltos Profile objects in the namespace matching the labels.
+func ListProfiles(ctx context.Context, cl client.Client, namespace string, labels map[string]string) ([]string, error) {
+	return listNames(ctx, cl, sveltosv1beta1.ProfileKind, namespace, labels)
+}
+
+// ListClusterProfiles returns the names of the Sveltos ClusterProfile objects matching the labels.
+func ListClusterProfiles(ctx context.Context, cl client.Client, labels map[string]string) ([]string, error) {
+	return listNames(ctx, cl, sveltosv1beta1.ClusterProfileKind, "", labels)
+}
+
+// listNames returns the names of the Profiles or the ClusterProfiles matching the labels.
+func listNames(ctx context.Context, cl client.Client, kind, namespace string, labels map[string]string) ([]string, error) {
+	_, list, err := listObjects(ctx, cl, kind, namespace, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(list.Items))
+	for _, profile := range list.Items {
+		names = append(names, profile.Name)
+	}
+	return names, nil
+}
+
+// listObjects lists the metadata of the Profiles or the ClusterProfiles matching the labels.
+func listObjects(ctx context.Context, cl client.Client, kind, namespace string, labels map[string]string) (schema.GroupVersionKind, *metav1.PartialObjectMetadataList, error) {
+	version, err := ServedVersion(cl)
+	if err != nil {
+		return schema.GroupVersionKind{}, nil, err
+	}
+
+	gvk := schema.GroupVersionKind{Group: sveltosv1beta1.GroupVersion.Group, Version: version, Kind: kind}
+	list := &metav1.PartialObjectMetadataList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := cl.List(ctx, list, client.InNamespace(namespace), client.MatchingLabels(labels)); err != nil {
+		return gvk, nil, fmt.Errorf("failed to list %ss: %w", kind, err)
+	}
+	return gvk, list, nil
+}
+
+// deleteObjects deletes the Profiles or the ClusterProfiles matching the labels except for the ones to keep.
+func deleteObjects(ctx context.Context, cl client.Client, kind, namespace string, labels map[string]string, keep []string) error {
+	gvk, list, err := listObjects(ctx, cl, kind, namespace, labels)
+	if err != nil {
+		return err
+	}
+
+	for _, profile := range list.Items {
+		if slices.Contains(keep, profile.Name) {
+			continue
+		}
+		profile.SetGroupVersionKind(gvk)
+		if err := client.IgnoreNotFound(cl.Delete(ctx, &profile)); err != nil {
+			return fmt.Errorf("failed to delete %s %s: %w", kind, client.ObjectKeyFromObject(&profile), err)
+		}
+	}
+
+	return nil
+}
+
+// deleteObject deletes the Profile or the ClusterProfile in the version of the Sveltos API served by the cluster.
+func deleteObject(ctx context.Context, cl client.Client, kind, namespace, name string) error {
+	version, err := ServedVersion(cl)
+	if err != nil {
+		return err
+	}
+
+	obj := &metav1.PartialObjectMetadata{}
+	obj.SetGroupVersionKind(schema.GroupVersionKind{Group: sveltosv1beta1.GroupVersion.Group, Version: version, Kind: kind})
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+
+	return client.IgnoreNotFound(cl.Delete(ctx, obj))
+}
+
+// ServedVersion returns the preferred version of the Sveltos Profile API served by the cluster.
+// The Profiles and the ClusterProfiles are built in the v1beta1 version and converted
+// to the served version, so that HMC keeps working with the older Sveltos releases.
+func ServedVersion(cl client.Client) (string, error) {
+	mapping, err := cl.RESTMapper().RESTMapping(schema.GroupKind{
+		Group: sveltosv1beta1.GroupVersion.Group,
+		Kind:  sveltosv1beta1.ProfileKind,
 	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get the served version of the Sveltos API: %w", err)
+	}
 
-	return client.IgnoreNotFound(err)
+	return mapping.GroupVersionKind.Version, nil
 }
 
 // priorityToTier converts priority value to Sveltos tier value.
@@ -223,5 +476,5 @@ func priorityToTier(priority int32) (int32, error) {
 		return math.MaxInt32 - priority, nil
 	}
 
-	return 0, fmt.Errorf("invalid value %d, priority has to be between %d and %d", priority, mini, maxi)
+	return 0, errdefs.Terminal(fmt.Errorf("invalid value %d, priority has to be between %d and %d", priority, mini, maxi))
 }
@@ -15,11 +15,24 @@
 package sveltos
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
+	sveltosv1alpha1 "github.com/projectsveltos/addon-controller/api/v1alpha1"
+	sveltosv1beta1 "github.com/projectsveltos/addon-controller/api/v1beta1"
 	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/fakeclient"
+	"github.com/Mirantis/hmc/test/scheme"
 )
 
 func Test_priorityToTier(t *testing.T) {
@@ -44,3 +57,142 @@ func Test_priorityToTier(t *testing.T) {
 		})
 	}
 }
+
+func TestReconcileProfileServedVersion(t *testing.T) {
+	opts := ReconcileProfileOpts{
+		LabelSelector: metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+		Priority:      100,
+	}
+
+	for _, version := range []schema.GroupVersion{sveltosv1alpha1.GroupVersion, sveltosv1beta1.GroupVersion} {
+		t.Run(version.Version, func(t *testing.T) {
+			mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{version})
+			mapper.Add(version.WithKind(sveltosv1beta1.ProfileKind), meta.RESTScopeNamespace)
+			mapper.Add(version.WithKind(sveltosv1beta1.ClusterProfileKind), meta.RESTScopeRoot)
+			cl := fakeclient.WithApply(fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper)).Build()
+
+			ctx := context.Background()
+			_, _, err := ReconcileProfile(ctx, cl, "default", "test", opts)
+			require.NoError(t, err)
+			_, _, err = ReconcileClusterProfile(ctx, cl, "test", opts)
+			require.NoError(t, err)
+
+			if version == sveltosv1alpha1.GroupVersion {
+				p := &sveltosv1alpha1.Profile{}
+				require.NoError(t, cl.Get(ctx, client.ObjectKey{Namespace: "default", Name: "test"}, p))
+				// the clusters the services are withdrawn from are not selected
+				require.Equal(t, "env=prod,!hmc.mirantis.com/services-draining", string(p.Spec.ClusterSelector))
+				cp := &sveltosv1alpha1.ClusterProfile{}
+				require.NoError(t, cl.Get(ctx, client.ObjectKey{Name: "test"}, cp))
+				require.Equal(t, "env=prod,!hmc.mirantis.com/services-draining", string(cp.Spec.ClusterSelector))
+			} else {
+				p := &sveltosv1beta1.Profile{}
+				require.NoError(t, cl.Get(ctx, client.ObjectKey{Namespace: "default", Name: "test"}, p))
+				require.Equal(t, metav1.LabelSelector{
+					MatchLabels: map[string]string{"env": "prod"},
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: hmc.ServicesDrainingLabelKey, Operator: metav1.LabelSelectorOpDoesNotExist},
+					},
+				}, p.Spec.ClusterSelector.LabelSelector)
+			}
+
+			require.NoError(t, DeleteProfile(ctx, cl, "default", "test"))
+			require.NoError(t, DeleteClusterProfile(ctx, cl, "test"))
+
+			remaining := &metav1.PartialObjectMetadataList{}
+			remaining.SetGroupVersionKind(version.WithKind(sveltosv1beta1.ProfileKind + "List"))
+			require.NoError(t, cl.List(ctx, remaining))
+			require.Empty(t, remaining.Items)
+		})
+	}
+}
+
+func TestDeleteClusterProfiles(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{sveltosv1beta1.GroupVersion})
+	mapper.Add(sveltosv1beta1.GroupVersion.WithKind(sveltosv1beta1.ProfileKind), meta.RESTScopeNamespace)
+	mapper.Add(sveltosv1beta1.GroupVersion.WithKind(sveltosv1beta1.ClusterProfileKind), meta.RESTScopeRoot)
+	cl := fakeclient.WithApply(fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper)).Build()
+
+	ctx := context.Background()
+	labels := map[string]string{"owner": "test"}
+	for _, name := range []string{"test-aws", "test-azure"} {
+		_, _, err := ReconcileClusterProfile(ctx, cl, name, ReconcileProfileOpts{Labels: labels, Priority: 100})
+		require.NoError(t, err)
+	}
+	_, _, err := ReconcileClusterProfile(ctx, cl, "other", ReconcileProfileOpts{Priority: 100})
+	require.NoError(t, err)
+
+	require.NoError(t, DeleteClusterProfiles(ctx, cl, labels, "test-aws"))
+
+	remaining := &sveltosv1beta1.ClusterProfileList{}
+	require.NoError(t, cl.List(ctx, remaining))
+	names := make([]string, 0, len(remaining.Items))
+	for _, cp := range remaining.Items {
+		names = append(names, cp.Name)
+	}
+	require.ElementsMatch(t, []string{"test-aws", "other"}, names)
+}
+
+func TestListProfiles(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{sveltosv1beta1.GroupVersion})
+	mapper.Add(sveltosv1beta1.GroupVersion.WithKind(sveltosv1beta1.ProfileKind), meta.RESTScopeNamespace)
+	cl := fakeclient.WithApply(fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper)).Build()
+
+	ctx := context.Background()
+	labels := map[string]string{"owner": "test"}
+	_, _, err := ReconcileProfile(ctx, cl, "default", "test", ReconcileProfileOpts{Labels: labels, Priority: 100})
+	require.NoError(t, err)
+	_, _, err = ReconcileProfile(ctx, cl, "default", "other", ReconcileProfileOpts{Priority: 100})
+	require.NoError(t, err)
+
+	names, err := ListProfiles(ctx, cl, "default", labels)
+	require.NoError(t, err)
+	require.Equal(t, []string{"test"}, names)
+
+	require.NoError(t, DeleteProfiles(ctx, cl, "default", labels))
+	names, err = ListProfiles(ctx, cl, "default", labels)
+	require.NoError(t, err)
+	require.Empty(t, names)
+}
+
+func TestProfilesChanged(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{sveltosv1beta1.GroupVersion})
+	mapper.Add(sveltosv1beta1.GroupVersion.WithKind(sveltosv1beta1.ProfileKind), meta.RESTScopeNamespace)
+	cl := fakeclient.WithApply(fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper)).Build()
+
+	ctx := context.Background()
+	labels := map[string]string{"owner": "test"}
+	opts := ReconcileProfileOpts{Labels: labels, Priority: 100}
+
+	changed, exist, err := ProfilesChanged(ctx, cl, "default", labels, map[string]ReconcileProfileOpts{"test": opts})
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.False(t, exist)
+
+	_, _, err = ReconcileProfile(ctx, cl, "default", "test", opts)
+	require.NoError(t, err)
+
+	changed, exist, err = ProfilesChanged(ctx, cl, "default", labels, map[string]ReconcileProfileOpts{"test": opts})
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.True(t, exist)
+
+	opts.Priority = 200
+	changed, _, err = ProfilesChanged(ctx, cl, "default", labels, map[string]ReconcileProfileOpts{"test": opts})
+	require.NoError(t, err)
+	require.True(t, changed)
+}
+
+func TestDerivedProfileName(t *testing.T) {
+	name := DerivedProfileName("services", "uid", "matcher/aws")
+	require.Regexp(t, `^services-[0-9a-f]{10}$`, name)
+	require.Equal(t, name, DerivedProfileName("services", "uid", "matcher/aws"), "the name is stable")
+
+	// the names of the same profile derived for the different owners or purposes differ
+	require.NotEqual(t, name, DerivedProfileName("services", "other-uid", "matcher/aws"))
+	require.NotEqual(t, name, DerivedProfileName("services", "uid", "matcher/azure"))
+
+	// the name of the derived profile fits into the maximum length
+	long := DerivedProfileName(strings.Repeat("a", maxProfileNameLength), "uid", "matcher/aws")
+	require.Len(t, long, maxProfileNameLength)
+}
@@ -0,0 +1,241 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sveltos
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	sveltosv1alpha1 "github.com/projectsveltos/addon-controller/api/v1alpha1"
+	sveltosv1beta1 "github.com/projectsveltos/addon-controller/api/v1beta1"
+	libsveltosv1beta1 "github.com/projectsveltos/libsveltos/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/Mirantis/hmc/internal/errdefs"
+)
+
+// RolloutProfileName returns the name of the profile deploying a change of the services of
+// the profile of the given name owned by the object of the given UID on the rollout clusters.
+func RolloutProfileName(name string, uid types.UID) string {
+	return DerivedProfileName(name, uid, "rollout")
+}
+
+// Revision returns the revision of the services deployed by the profiles built from the options
+// keyed by the profile name. The revision changes with the spec of any profile except for the
+// clusters selected, the changes of the selection are applied without rolling them out.
+func Revision(profiles map[string]ReconcileProfileOpts) (string, error) {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		opts := profiles[name]
+		opts.LabelSelector, opts.ClusterRefs = metav1.LabelSelector{}, nil
+		spec, err := Spec(&opts)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s=%s;", name, specHash(spec))
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))[:16], nil
+}
+
+// AppliedRevision returns the revision set on the Profile, or the ClusterProfile if the namespace
+// is empty, and whether the profile exists. The revision is empty if the profile has none.
+func AppliedRevision(ctx context.Context, cl client.Client, namespace, name string) (string, bool, error) {
+	kind := sveltosv1beta1.ProfileKind
+	if namespace == "" {
+		kind = sveltosv1beta1.ClusterProfileKind
+	}
+	version, err := ServedVersion(cl)
+	if err != nil {
+		return "", false, err
+	}
+
+	profile := &metav1.PartialObjectMetadata{}
+	profile.SetGroupVersionKind(schema.GroupVersionKind{Group: sveltosv1beta1.GroupVersion.Group, Version: version, Kind: kind})
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, profile); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("failed to get %s %s: %w", kind, name, err)
+	}
+	return profile.Annotations[RevisionAnnotation], true, nil
+}
+
+// SetClusterSelector sets the cluster selector of the Profile, or the ClusterProfile if the namespace
+// is empty, leaving the rest of its spec intact, e.g. while a change of the services is rolled out.
+// It returns true if the selector has been changed.
+func SetClusterSelector(ctx context.Context, cl client.Client, namespace, name string, selector metav1.LabelSelector) (bool, error) {
+	kind := sveltosv1beta1.ProfileKind
+	if namespace == "" {
+		kind = sveltosv1beta1.ClusterProfileKind
+	}
+	version, err := ServedVersion(cl)
+	if err != nil {
+		return false, err
+	}
+
+	var value any = libsveltosv1beta1.Selector{LabelSelector: withoutDrainingClusters(selector)}
+	switch version {
+	case sveltosv1beta1.GroupVersion.Version:
+	case sveltosv1alpha1.GroupVersion.Version:
+		legacy := &sveltosv1alpha1.Profile{}
+		if err := legacy.ConvertFrom(&sveltosv1beta1.Profile{Spec: sveltosv1beta1.Spec{ClusterSelector: value.(libsveltosv1beta1.Selector)}}); err != nil {
+			return false, fmt.Errorf("failed to convert the cluster selector: %w", err)
+		}
+		value = legacy.Spec.ClusterSelector
+	default:
+		return false, errdefs.Terminal(fmt.Errorf("unsupported version %s of the Sveltos API", version))
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal the cluster selector: %w", err)
+	}
+	var desired any
+	if err := json.Unmarshal(raw, &desired); err != nil {
+		return false, fmt.Errorf("failed to unmarshal the cluster selector: %w", err)
+	}
+
+	profile := &unstructured.Unstructured{}
+	profile.SetAPIVersion(sveltosv1beta1.GroupVersion.Group + "/" + version)
+	profile.SetKind(kind)
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, profile); err != nil {
+		return false, fmt.Errorf("failed to get %s %s: %w", kind, name, err)
+	}
+	if current, _, _ := unstructured.NestedFieldNoCopy(profile.Object, "spec", "clusterSelector"); equality.Semantic.DeepEqual(current, desired) {
+		return false, nil
+	}
+
+	patch, err := json.Marshal([]map[string]any{{"op": "add", "path": "/spec/clusterSelector", "value": desired}})
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal the patch of %s %s: %w", kind, name, err)
+	}
+	if err := cl.Patch(ctx, profile, client.RawPatch(types.JSONPatchType, patch)); err != nil {
+		return false, fmt.Errorf("failed to set the cluster selector of %s %s: %w", kind, name, err)
+	}
+	return true, nil
+}
+
+// MatchingClusters returns the clusters matching the Profile, or the ClusterProfile if the namespace is empty.
+func MatchingClusters(ctx context.Context, cl client.Client, namespace, name string) ([]corev1.ObjectReference, error) {
+	kind := sveltosv1beta1.ProfileKind
+	if namespace == "" {
+		kind = sveltosv1beta1.ClusterProfileKind
+	}
+	version, err := ServedVersion(cl)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &unstructured.Unstructured{}
+	profile.SetAPIVersion(sveltosv1beta1.GroupVersion.Group + "/" + version)
+	profile.SetKind(kind)
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, profile); err != nil {
+		return nil, fmt.Errorf("failed to get %s %s: %w", kind, name, err)
+	}
+
+	// the matching clusters are the same in all of the served versions
+	raw, _, err := unstructured.NestedSlice(profile.Object, "status", "matchingClusters")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the matching clusters of %s %s: %w", kind, name, err)
+	}
+
+	clusters := make([]corev1.ObjectReference, 0, len(raw))
+	for _, entry := range raw {
+		entryMap, ok := entry.(map[string]any)
+		if !ok {
+			continue
+		}
+		ref := corev1.ObjectReference{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(entryMap, &ref); err != nil {
+			return nil, fmt.Errorf("failed to parse the matching clusters of %s %s: %w", kind, name, err)
+		}
+		clusters = append(clusters, ref)
+	}
+	return clusters, nil
+}
+
+// ClusterStatus is the deployment status of the helm charts of the profiles on a cluster.
+type ClusterStatus struct {
+	// Status is Failed if the charts of any of the profiles failed, Provisioned if the charts
+	// of all of the profiles are provisioned and Provisioning otherwise.
+	Status sveltosv1beta1.FeatureStatus
+	// Message is the failure message.
+	Message string
+}
+
+// ClusterStatuses returns the deployment status of the helm charts of the Profiles, or the
+// ClusterProfiles if the namespace is empty, keyed by the namespace/name of the cluster.
+// The clusters Sveltos has not reported the status on yet are missing.
+func ClusterStatuses(ctx context.Context, cl client.Client, namespace string, profiles []string) (map[string]ClusterStatus, error) {
+	labelKey := ProfileLabelKey
+	if namespace == "" {
+		labelKey = ClusterProfileLabelKey
+	}
+
+	result := map[string]ClusterStatus{}
+	for _, profile := range profiles {
+		_, list, err := listClusterSummaries(ctx, cl, client.InNamespace(namespace), client.MatchingLabels{labelKey: profile})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range list.Items {
+			feature, err := helmFeatureSummary(&item)
+			if err != nil {
+				return nil, err
+			}
+
+			clusterNamespace, _, _ := unstructured.NestedString(item.Object, "spec", "clusterNamespace")
+			clusterName, _, _ := unstructured.NestedString(item.Object, "spec", "clusterName")
+			key := clusterNamespace + "/" + clusterName
+
+			status := ClusterStatus{Status: sveltosv1beta1.FeatureStatusProvisioning}
+			if feature != nil {
+				status.Status = feature.Status
+				if feature.FailureMessage != nil {
+					status.Message = *feature.FailureMessage
+				}
+			}
+			if status.Status == sveltosv1beta1.FeatureStatusFailedNonRetriable {
+				status.Status = sveltosv1beta1.FeatureStatusFailed
+			}
+
+			current, ok := result[key]
+			switch {
+			case !ok, status.Status == sveltosv1beta1.FeatureStatusFailed,
+				current.Status == sveltosv1beta1.FeatureStatusProvisioned && status.Status != sveltosv1beta1.FeatureStatusProvisioned:
+				result[key] = status
+			}
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sveltos
+
+import (
+	"context"
+	"testing"
+
+	sveltosv1beta1 "github.com/projectsveltos/addon-controller/api/v1beta1"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestRevision(t *testing.T) {
+	profiles := map[string]ReconcileProfileOpts{
+		"mcs":        {Priority: 100, HelmChartOpts: []HelmChartOpts{{ReleaseName: "ingress", ChartVersion: "1.0.0"}}},
+		"mcs--force": {Priority: 100, HelmChartOpts: []HelmChartOpts{{ReleaseName: "kyverno", ChartVersion: "1.0.0"}}},
+	}
+
+	revision, err := Revision(profiles)
+	require.NoError(t, err)
+	again, err := Revision(profiles)
+	require.NoError(t, err)
+	require.Equal(t, revision, again)
+
+	// the selection of the clusters is not a part of the revision
+	for name, opts := range profiles {
+		opts.LabelSelector = metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}
+		profiles[name] = opts
+	}
+	selected, err := Revision(profiles)
+	require.NoError(t, err)
+	require.Equal(t, revision, selected)
+
+	profiles["mcs--force"].HelmChartOpts[0].ChartVersion = "1.1.0"
+	changed, err := Revision(profiles)
+	require.NoError(t, err)
+	require.NotEqual(t, revision, changed)
+}
+
+func TestClusterStatuses(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{sveltosv1beta1.GroupVersion})
+	mapper.Add(sveltosv1beta1.GroupVersion.WithKind(sveltosv1beta1.ProfileKind), meta.RESTScopeNamespace)
+	mapper.Add(sveltosv1beta1.GroupVersion.WithKind(sveltosv1beta1.ClusterSummaryKind), meta.RESTScopeNamespace)
+
+	summary := func(name, profile, cluster string, status sveltosv1beta1.FeatureStatus, message string) *sveltosv1beta1.ClusterSummary {
+		cs := &sveltosv1beta1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      name,
+				Labels:    map[string]string{ClusterProfileLabelKey: profile},
+			},
+			Spec: sveltosv1beta1.ClusterSummarySpec{ClusterNamespace: "default", ClusterName: cluster},
+		}
+		if status != "" {
+			cs.Status.FeatureSummaries = []sveltosv1beta1.FeatureSummary{{FeatureID: sveltosv1beta1.FeatureHelm, Status: status}}
+			if message != "" {
+				cs.Status.FeatureSummaries[0].FailureMessage = ptr.To(message)
+			}
+		}
+		return cs
+	}
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper).WithObjects(
+		summary("a-rollout", "mcs--rollout", "a", sveltosv1beta1.FeatureStatusProvisioned, ""),
+		summary("a-force", "mcs--rollout--force", "a", sveltosv1beta1.FeatureStatusProvisioned, ""),
+		summary("b-rollout", "mcs--rollout", "b", sveltosv1beta1.FeatureStatusProvisioned, ""),
+		summary("b-force", "mcs--rollout--force", "b", sveltosv1beta1.FeatureStatusFailedNonRetriable, "health check failed"),
+		summary("c-rollout", "mcs--rollout", "c", "", ""),
+		summary("d-rollout", "mcs", "d", sveltosv1beta1.FeatureStatusProvisioned, ""),
+	).Build()
+
+	statuses, err := ClusterStatuses(context.Background(), cl, "", []string{"mcs--rollout", "mcs--rollout--force"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]ClusterStatus{
+		"default/a": {Status: sveltosv1beta1.FeatureStatusProvisioned},
+		"default/b": {Status: sveltosv1beta1.FeatureStatusFailed, Message: "health check failed"},
+		"default/c": {Status: sveltosv1beta1.FeatureStatusProvisioning},
+	}, statuses)
+}
@@ -0,0 +1,143 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sveltos
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	sveltosv1beta1 "github.com/projectsveltos/addon-controller/api/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ServiceStatus is the deployment status of a helm chart on a cluster.
+type ServiceStatus struct {
+	// Profile is the profile deploying the chart, e.g. "Profile/name" or "ClusterProfile/name".
+	Profile string
+	// Name is the name of the helm release.
+	Name string
+	// Namespace is the namespace of the helm release.
+	Namespace string
+	// Status is either the status of the helm charts of the profile, e.g. Provisioned,
+	// or Conflict if another profile manages the chart. It is empty until Sveltos reports it.
+	Status string
+	// Message is the conflict or the failure message.
+	Message string
+}
+
+// ClusterServiceStatuses returns the status of the helm charts deployed on the cluster
+// by the Profiles and the ClusterProfiles, sorted by profile and release.
+func ClusterServiceStatuses(ctx context.Context, cl client.Client, namespace, clusterName string) ([]ServiceStatus, error) {
+	_, list, err := listClusterSummaries(ctx, cl, client.InNamespace(namespace), client.MatchingLabels{
+		sveltosv1beta1.ClusterNameLabel: clusterName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []ServiceStatus
+	for _, item := range list.Items {
+		summaries, err := helmChartSummaries(&item)
+		if err != nil {
+			return nil, err
+		}
+
+		profile := "Profile/" + item.GetLabels()[ProfileLabelKey]
+		if name, ok := item.GetLabels()[ClusterProfileLabelKey]; ok {
+			profile = "ClusterProfile/" + name
+		}
+
+		feature, err := helmFeatureSummary(&item)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, summary := range summaries {
+			status := ServiceStatus{
+				Profile:   profile,
+				Name:      summary.ReleaseName,
+				Namespace: summary.ReleaseNamespace,
+			}
+
+			switch {
+			case summary.Status == sveltosv1beta1.HelmChartStatusConflict:
+				status.Status = string(sveltosv1beta1.HelmChartStatusConflict)
+				status.Message = summary.ConflictMessage
+			case feature != nil:
+				status.Status = string(feature.Status)
+				if feature.FailureMessage != nil {
+					status.Message = *feature.FailureMessage
+				}
+			}
+
+			result = append(result, status)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Profile != result[j].Profile {
+			return result[i].Profile < result[j].Profile
+		}
+		return result[i].Namespace+"/"+result[i].Name < result[j].Namespace+"/"+result[j].Name
+	})
+	return result, nil
+}
+
+// ClusterProfiles returns the Kind/name of the Profiles and the ClusterProfiles
+// deploying the services on the cluster, sorted.
+func ClusterProfiles(ctx context.Context, cl client.Client, namespace, clusterName string) ([]string, error) {
+	_, list, err := listClusterSummaries(ctx, cl, client.InNamespace(namespace), client.MatchingLabels{
+		sveltosv1beta1.ClusterNameLabel: clusterName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make([]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		if profile := profileOf(&item); profile != "" {
+			profiles = append(profiles, profile)
+		}
+	}
+	sort.Strings(profiles)
+	return profiles, nil
+}
+
+// helmFeatureSummary returns the summary of the helm feature of the ClusterSummary or nil if it is not reported yet.
+func helmFeatureSummary(clusterSummary *unstructured.Unstructured) (*sveltosv1beta1.FeatureSummary, error) {
+	raw, _, err := unstructured.NestedSlice(clusterSummary.Object, "status", "featureSummaries")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the feature summaries of ClusterSummary %s: %w", client.ObjectKeyFromObject(clusterSummary), err)
+	}
+
+	for _, entry := range raw {
+		entryMap, ok := entry.(map[string]any)
+		if !ok {
+			continue
+		}
+		summary := &sveltosv1beta1.FeatureSummary{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(entryMap, summary); err != nil {
+			return nil, fmt.Errorf("failed to parse the feature summaries of ClusterSummary %s: %w", client.ObjectKeyFromObject(clusterSummary), err)
+		}
+		if summary.FeatureID == sveltosv1beta1.FeatureHelm {
+			return summary, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sveltos
+
+import (
+	"context"
+	"testing"
+
+	sveltosv1beta1 "github.com/projectsveltos/addon-controller/api/v1beta1"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestClusterServiceStatuses(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{sveltosv1beta1.GroupVersion})
+	mapper.Add(sveltosv1beta1.GroupVersion.WithKind(sveltosv1beta1.ProfileKind), meta.RESTScopeNamespace)
+	mapper.Add(sveltosv1beta1.GroupVersion.WithKind(sveltosv1beta1.ClusterSummaryKind), meta.RESTScopeNamespace)
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper).WithObjects(
+		&sveltosv1beta1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "profile-summary",
+				Labels:    map[string]string{ProfileLabelKey: "cluster", sveltosv1beta1.ClusterNameLabel: "cluster"},
+			},
+			Status: sveltosv1beta1.ClusterSummaryStatus{
+				FeatureSummaries: []sveltosv1beta1.FeatureSummary{{
+					FeatureID:      sveltosv1beta1.FeatureHelm,
+					Status:         sveltosv1beta1.FeatureStatusFailed,
+					FailureMessage: ptr.To("install failed"),
+				}},
+				HelmReleaseSummaries: []sveltosv1beta1.HelmChartSummary{
+					{ReleaseName: "kyverno", ReleaseNamespace: "kyverno", Status: sveltosv1beta1.HelmChartStatusManaging},
+					{ReleaseName: "ingress", ReleaseNamespace: "ingress", Status: sveltosv1beta1.HelmChartStatusConflict, ConflictMessage: "ClusterSummary other managing it"},
+				},
+			},
+		},
+		&sveltosv1beta1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "clusterprofile-summary",
+				Labels:    map[string]string{ClusterProfileLabelKey: "mcs", sveltosv1beta1.ClusterNameLabel: "cluster"},
+			},
+			Status: sveltosv1beta1.ClusterSummaryStatus{
+				HelmReleaseSummaries: []sveltosv1beta1.HelmChartSummary{
+					{ReleaseName: "ingress", ReleaseNamespace: "ingress", Status: sveltosv1beta1.HelmChartStatusManaging},
+				},
+			},
+		},
+		&sveltosv1beta1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "other-summary",
+				Labels:    map[string]string{ProfileLabelKey: "other", sveltosv1beta1.ClusterNameLabel: "other"},
+			},
+		},
+	).Build()
+
+	statuses, err := ClusterServiceStatuses(context.Background(), cl, "default", "cluster")
+	require.NoError(t, err)
+	require.Equal(t, []ServiceStatus{
+		{Profile: "ClusterProfile/mcs", Name: "ingress", Namespace: "ingress"},
+		{Profile: "Profile/cluster", Name: "ingress", Namespace: "ingress", Status: "Conflict", Message: "ClusterSummary other managing it"},
+		{Profile: "Profile/cluster", Name: "kyverno", Namespace: "kyverno", Status: "Failed", Message: "install failed"},
+	}, statuses)
+
+	profiles, err := ClusterProfiles(context.Background(), cl, "default", "cluster")
+	require.NoError(t, err)
+	require.Equal(t, []string{"ClusterProfile/mcs", "Profile/cluster"}, profiles)
+}
@@ -15,14 +15,22 @@
 package telemetry
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/segmentio/analytics-go"
 
+	"github.com/Mirantis/hmc/api/v1alpha1"
 	"github.com/Mirantis/hmc/internal/build"
 )
 
 const (
 	managedClusterCreateEvent    = "managed-cluster-create"
 	managedClusterHeartbeatEvent = "managed-cluster-heartbeat"
+	managedClusterDeleteEvent    = "managed-cluster-delete"
+	templateUpgradeEvent         = "template-upgrade"
+	serviceDeployEvent           = "service-deploy"
 )
 
 func TrackManagedClusterCreate(id, managedClusterID, template string, dryRun bool) error {
@@ -47,13 +55,102 @@ func TrackManagedClusterHeartbeat(id, managedClusterID, clusterID, template, tem
 	return TrackEvent(managedClusterHeartbeatEvent, id, props)
 }
 
+func TrackManagedClusterDelete(id, managedClusterID, template string, providers []string, success bool) error {
+	props := map[string]any{
+		"hmcVersion":       build.Version,
+		"managedClusterID": managedClusterID,
+		"template":         template,
+		"providers":        providers,
+		"success":          success,
+	}
+	return TrackEvent(managedClusterDeleteEvent, id, props)
+}
+
+func TrackTemplateUpgrade(id, managedClusterID, fromTemplate, toTemplate, toTemplateHelmChartVersion string, providers []string, success bool) error {
+	props := map[string]any{
+		"hmcVersion":               build.Version,
+		"managedClusterID":         managedClusterID,
+		"fromTemplate":             fromTemplate,
+		"template":                 toTemplate,
+		"templateHelmChartVersion": toTemplateHelmChartVersion,
+		"providers":                providers,
+		"success":                  success,
+	}
+	return TrackEvent(templateUpgradeEvent, id, props)
+}
+
+func TrackServiceDeploy(id, ownerKind, ownerID, serviceTemplate, serviceTemplateHelmChartVersion string, success bool) error {
+	props := map[string]any{
+		"hmcVersion":                      build.Version,
+		"ownerKind":                       ownerKind,
+		"ownerID":                         ownerID,
+		"serviceTemplate":                 serviceTemplate,
+		"serviceTemplateHelmChartVersion": serviceTemplateHelmChartVersion,
+		"success":                         success,
+	}
+	return TrackEvent(serviceDeployEvent, id, props)
+}
+
+// localSinkTimeout is the timeout of writing a single event to the local sink.
+const localSinkTimeout = 10 * time.Second
+
+// TrackEvent tracks the event according to the configured telemetry mode:
+// the event is dropped if the telemetry is disabled, written to the local
+// sink in the local mode, and sent to the analytics service otherwise.
+// The event is delivered asynchronously if the Worker is running.
 func TrackEvent(name, id string, properties map[string]any) error {
+	event := Event{
+		Timestamp:  time.Now().UTC(),
+		Name:       name,
+		ID:         id,
+		Properties: properties,
+	}
+
+	if w := activeWorker.Load(); w != nil {
+		if mode, _ := currentConfig(); mode != v1alpha1.TelemetryModeDisabled {
+			w.enqueue(event)
+		}
+		return nil
+	}
+
+	return deliver(event)
+}
+
+func deliver(event Event) error {
+	return deliverBatch(context.Background(), []Event{event})
+}
+
+// deliverBatch delivers the events at once to the BatchSink, one by one to the other sinks.
+func deliverBatch(ctx context.Context, events []Event) error {
+	mode, sink := currentConfig()
+	switch mode {
+	case v1alpha1.TelemetryModeDisabled:
+		return nil
+	case v1alpha1.TelemetryModeLocal:
+		ctx, cancel := context.WithTimeout(ctx, localSinkTimeout)
+		defer cancel()
+		if batchSink, ok := sink.(BatchSink); ok {
+			return batchSink.WriteBatch(ctx, events)
+		}
+		var errs error
+		for _, event := range events {
+			errs = errors.Join(errs, sink.Write(ctx, event))
+		}
+		return errs
+	}
+
 	if analyticsClient == nil {
 		return nil
 	}
-	return analyticsClient.Enqueue(analytics.Track{
-		AnonymousId: id,
-		Event:       name,
-		Properties:  properties,
-	})
+	// the analytics client sends the enqueued events in batches on its own
+	var errs error
+	for _, event := range events {
+		errs = errors.Join(errs, analyticsClient.Enqueue(analytics.Track{
+			AnonymousId: event.ID,
+			Event:       event.Name,
+			Properties:  event.Properties,
+			Timestamp:   event.Timestamp,
+		}))
+	}
+	return errs
 }
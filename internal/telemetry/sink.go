@@ -0,0 +1,189 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+const (
+	// DefaultConfigMapName is the default name of the ConfigMap the events are written to in the local mode.
+	DefaultConfigMapName = "hmc-telemetry"
+	// DefaultConfigMapMaxEvents is the default number of the most recent events kept in the ConfigMap.
+	DefaultConfigMapMaxEvents = 100
+
+	configMapEventsKey = "events"
+)
+
+// Event is a single telemetry event.
+type Event struct {
+	Timestamp  time.Time      `json:"timestamp"`
+	Name       string         `json:"event"`
+	ID         string         `json:"anonymousId"`
+	Properties map[string]any `json:"properties,omitempty"`
+}
+
+// Sink receives the telemetry events in the local mode.
+type Sink interface {
+	Write(ctx context.Context, event Event) error
+}
+
+// BatchSink is the Sink writing several events at once.
+type BatchSink interface {
+	Sink
+	WriteBatch(ctx context.Context, events []Event) error
+}
+
+// LogSink writes the telemetry events to the controller logs.
+type LogSink struct{}
+
+// Write implements Sink.
+func (LogSink) Write(_ context.Context, event Event) error {
+	ctrl.Log.WithName("telemetry").Info("Telemetry event", "event", event.Name, "id", event.ID, "properties", event.Properties)
+	return nil
+}
+
+// ConfigMapSink writes the telemetry events as JSON lines to a ConfigMap
+// keeping only the most recent events.
+type ConfigMapSink struct {
+	client.Client
+
+	// Namespace is the namespace of the ConfigMap.
+	Namespace string
+	// Name is the name of the ConfigMap, defaults to DefaultConfigMapName.
+	Name string
+	// MaxEvents is the number of the most recent events kept, defaults to DefaultConfigMapMaxEvents.
+	MaxEvents int
+}
+
+var _ BatchSink = (*ConfigMapSink)(nil)
+
+// Write implements Sink.
+func (s *ConfigMapSink) Write(ctx context.Context, event Event) error {
+	return s.WriteBatch(ctx, []Event{event})
+}
+
+// WriteBatch implements BatchSink, the events are written with a single update of the ConfigMap.
+func (s *ConfigMapSink) WriteBatch(ctx context.Context, batch []Event) error {
+	lines := make([]string, 0, len(batch))
+	for _, event := range batch {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal telemetry event: %w", err)
+		}
+		lines = append(lines, string(line))
+	}
+
+	name := s.Name
+	if name == "" {
+		name = DefaultConfigMapName
+	}
+	maxEvents := s.MaxEvents
+	if maxEvents == 0 {
+		maxEvents = DefaultConfigMapMaxEvents
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm := &corev1.ConfigMap{}
+		err := s.Get(ctx, client.ObjectKey{Namespace: s.Namespace, Name: name}, cm)
+		if apierrors.IsNotFound(err) {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: s.Namespace,
+					Labels:    map[string]string{v1alpha1.HMCManagedLabelKey: v1alpha1.HMCManagedLabelValue},
+				},
+				Data: map[string]string{configMapEventsKey: strings.Join(lastEvents(lines, maxEvents), "\n")},
+			}
+			return s.Create(ctx, cm)
+		}
+		if err != nil {
+			return err
+		}
+
+		var events []string
+		if existing := cm.Data[configMapEventsKey]; existing != "" {
+			events = strings.Split(existing, "\n")
+		}
+		events = lastEvents(append(events, lines...), maxEvents)
+
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		cm.Data[configMapEventsKey] = strings.Join(events, "\n")
+		return s.Update(ctx, cm)
+	})
+}
+
+// lastEvents returns the given number of the most recent events.
+func lastEvents(events []string, n int) []string {
+	if len(events) > n {
+		return events[len(events)-n:]
+	}
+	return events
+}
+
+var (
+	configMu sync.RWMutex
+	mode          = v1alpha1.TelemetryModeEnabled
+	sinks         = map[v1alpha1.TelemetrySink]Sink{v1alpha1.TelemetrySinkLogs: LogSink{}}
+	sink     Sink = LogSink{}
+)
+
+// RegisterSink registers the Sink used for the given kind of the local sink.
+func RegisterSink(kind v1alpha1.TelemetrySink, s Sink) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	sinks[kind] = s
+}
+
+// Configure sets the telemetry mode and the local sink from the Management
+// telemetry settings. Nil settings enable the telemetry.
+func Configure(telemetry *v1alpha1.Telemetry) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	mode, sink = v1alpha1.TelemetryModeEnabled, LogSink{}
+	if telemetry == nil {
+		return
+	}
+
+	if telemetry.Mode != "" {
+		mode = telemetry.Mode
+	}
+	if s, ok := sinks[telemetry.LocalSink]; ok {
+		sink = s
+	}
+}
+
+func currentConfig() (v1alpha1.TelemetryMode, Sink) {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return mode, sink
+}
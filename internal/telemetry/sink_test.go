@@ -0,0 +1,101 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+func TestTelemetryModes(t *testing.T) {
+	sink := &fakeSink{}
+	RegisterSink(testSinkKind, sink)
+	t.Cleanup(func() { Configure(nil) })
+
+	// the telemetry is enabled with the logs sink by default
+	Configure(nil)
+	mode, current := currentConfig()
+	require.Equal(t, v1alpha1.TelemetryModeEnabled, mode)
+	require.Equal(t, LogSink{}, current)
+
+	// the events are written to the configured sink in the local mode
+	Configure(&v1alpha1.Telemetry{Mode: v1alpha1.TelemetryModeLocal, LocalSink: testSinkKind})
+	require.NoError(t, TrackEvent("local", "id", nil))
+
+	// and are dropped once the telemetry is disabled
+	Configure(&v1alpha1.Telemetry{Mode: v1alpha1.TelemetryModeDisabled, LocalSink: testSinkKind})
+	require.NoError(t, TrackEvent("disabled", "id", nil))
+
+	batches, _ := sink.state()
+	require.Equal(t, [][]string{{"local"}}, batches)
+
+	// the sinks not registered fall back to the logs
+	Configure(&v1alpha1.Telemetry{Mode: v1alpha1.TelemetryModeLocal, LocalSink: "unknown"})
+	mode, current = currentConfig()
+	require.Equal(t, v1alpha1.TelemetryModeLocal, mode)
+	require.Equal(t, LogSink{}, current)
+}
+
+func TestConfigMapSink(t *testing.T) {
+	ctx := context.Background()
+	cl := fake.NewClientBuilder().Build()
+	sink := &ConfigMapSink{Client: cl, Namespace: "hmc-system", MaxEvents: 3}
+
+	storedEvents := func() []string {
+		t.Helper()
+		cm := &corev1.ConfigMap{}
+		require.NoError(t, cl.Get(ctx, client.ObjectKey{Namespace: "hmc-system", Name: DefaultConfigMapName}, cm))
+		var names []string
+		for _, line := range strings.Split(cm.Data[configMapEventsKey], "\n") {
+			event := Event{}
+			require.NoError(t, json.Unmarshal([]byte(line), &event))
+			names = append(names, event.Name)
+		}
+		return names
+	}
+	newEvent := func(name string) Event {
+		return Event{Timestamp: time.Now().UTC(), Name: name, ID: "id", Properties: map[string]any{"template": "aws"}}
+	}
+
+	// the ConfigMap is created with the first event
+	require.NoError(t, sink.Write(ctx, newEvent("first")))
+	require.Equal(t, []string{"first"}, storedEvents())
+
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, cl.Get(ctx, client.ObjectKey{Namespace: "hmc-system", Name: DefaultConfigMapName}, cm))
+	require.Equal(t, v1alpha1.HMCManagedLabelValue, cm.Labels[v1alpha1.HMCManagedLabelKey])
+
+	// the events are appended keeping only the most recent ones
+	require.NoError(t, sink.WriteBatch(ctx, []Event{newEvent("second"), newEvent("third"), newEvent("fourth")}))
+	require.Equal(t, []string{"second", "third", "fourth"}, storedEvents())
+
+	// the events are appended to the ConfigMap created by someone else
+	custom := &ConfigMapSink{Client: cl, Namespace: "hmc-system", Name: "custom"}
+	require.NoError(t, cl.Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "hmc-system", Name: "custom"}}))
+	require.NoError(t, custom.Write(ctx, newEvent("custom")))
+	require.NoError(t, cl.Get(ctx, client.ObjectKey{Namespace: "hmc-system", Name: "custom"}, cm))
+	require.Contains(t, cm.Data[configMapEventsKey], `"event":"custom"`)
+}
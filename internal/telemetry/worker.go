@@ -0,0 +1,184 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/Mirantis/hmc/internal/metrics"
+)
+
+const (
+	defaultQueueSize     = 1000
+	defaultBatchSize     = 50
+	defaultFlushInterval = 5 * time.Second
+	defaultMaxRetries    = 3
+	defaultRetryBackoff  = time.Second
+	defaultFlushTimeout  = 10 * time.Second
+
+	resultDelivered = "delivered"
+	resultFailed    = "failed"
+	resultDropped   = "dropped"
+)
+
+// activeWorker is the running Worker the events are enqueued to, the events
+// are delivered synchronously if there is none.
+var activeWorker atomic.Pointer[Worker]
+
+// Worker delivers the telemetry events asynchronously, so the reconciliation
+// is not affected by the latency and the failures of the telemetry endpoint.
+// The events are buffered in a bounded queue and delivered in batches, the
+// events not fitting into the queue are dropped. The batch is delivered at once
+// and retried as a whole, a failing endpoint holds the queue for FlushTimeout at most.
+type Worker struct {
+	// QueueSize is the maximum number of the buffered events.
+	QueueSize int
+	// BatchSize is the maximum number of the events delivered at once.
+	BatchSize int
+	// FlushInterval is the maximum time an event is buffered for.
+	FlushInterval time.Duration
+	// MaxRetries is the number of the delivery retries of a batch.
+	MaxRetries int
+	// RetryBackoff is the initial delay between the retries, it is doubled on every retry.
+	RetryBackoff time.Duration
+	// FlushTimeout is the maximum time the delivery of a batch is retried for.
+	FlushTimeout time.Duration
+
+	queue   chan Event
+	dropped atomic.Int64
+}
+
+// NeedLeaderElection implements the LeaderElectionRunnable interface, the
+// Worker delivers the events of any replica.
+func (*Worker) NeedLeaderElection() bool {
+	return false
+}
+
+// Start runs the Worker until the context is done, the buffered events are flushed on exit.
+func (w *Worker) Start(ctx context.Context) error {
+	w.setDefaults()
+	w.queue = make(chan Event, w.QueueSize)
+	activeWorker.Store(w)
+	defer activeWorker.CompareAndSwap(w, nil)
+
+	ticker := time.NewTicker(w.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, w.BatchSize)
+	for {
+		select {
+		case event := <-w.queue:
+			batch = append(batch, event)
+			if len(batch) < w.BatchSize {
+				continue
+			}
+		case <-ticker.C:
+		case <-ctx.Done():
+			activeWorker.CompareAndSwap(w, nil)
+			// drain the queue without retries, the manager is shutting down
+			for {
+				select {
+				case event := <-w.queue:
+					batch = append(batch, event)
+				default:
+					w.flush(context.WithoutCancel(ctx), batch, 0)
+					return nil
+				}
+			}
+		}
+
+		w.flush(ctx, batch, w.MaxRetries)
+		batch = batch[:0]
+	}
+}
+
+func (w *Worker) setDefaults() {
+	if w.QueueSize <= 0 {
+		w.QueueSize = defaultQueueSize
+	}
+	if w.BatchSize <= 0 {
+		w.BatchSize = defaultBatchSize
+	}
+	if w.FlushInterval <= 0 {
+		w.FlushInterval = defaultFlushInterval
+	}
+	if w.MaxRetries < 0 {
+		w.MaxRetries = 0
+	} else if w.MaxRetries == 0 {
+		w.MaxRetries = defaultMaxRetries
+	}
+	if w.RetryBackoff <= 0 {
+		w.RetryBackoff = defaultRetryBackoff
+	}
+	if w.FlushTimeout <= 0 {
+		w.FlushTimeout = defaultFlushTimeout
+	}
+}
+
+// enqueue buffers the event without blocking, the event is dropped if the queue is full.
+func (w *Worker) enqueue(event Event) {
+	select {
+	case w.queue <- event:
+	default:
+		w.dropped.Add(1)
+		metrics.IncTelemetryEvents(resultDropped)
+	}
+}
+
+func (w *Worker) flush(ctx context.Context, batch []Event, retries int) {
+	l := log.FromContext(ctx).WithName("telemetry worker")
+
+	if dropped := w.dropped.Swap(0); dropped > 0 {
+		l.V(1).Info("Dropped telemetry events due to the full queue", "count", dropped)
+	}
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.FlushTimeout)
+	defer cancel()
+
+	if err := w.deliver(ctx, batch, retries); err != nil {
+		l.V(1).Info("Failed to deliver telemetry events", "count", len(batch), "error", err.Error())
+		metrics.AddTelemetryEvents(resultFailed, len(batch))
+		return
+	}
+	metrics.AddTelemetryEvents(resultDelivered, len(batch))
+	l.V(1).Info("Flushed telemetry events", "count", len(batch))
+}
+
+// deliver delivers the batch retrying it with the backoff until the context is done.
+func (w *Worker) deliver(ctx context.Context, batch []Event, retries int) error {
+	backoff := w.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := deliverBatch(ctx, batch)
+		if err == nil || attempt >= retries {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+const testSinkKind v1alpha1.TelemetrySink = "test"
+
+// fakeSink records the delivered batches, the first failures writes fail.
+type fakeSink struct {
+	mu       sync.Mutex
+	batches  [][]string
+	attempts int
+	failures int
+}
+
+func (s *fakeSink) Write(ctx context.Context, event Event) error {
+	return s.WriteBatch(ctx, []Event{event})
+}
+
+func (s *fakeSink) WriteBatch(_ context.Context, events []Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attempts++
+	if s.failures != 0 {
+		s.failures--
+		return errors.New("unavailable")
+	}
+	names := make([]string, 0, len(events))
+	for _, event := range events {
+		names = append(names, event.Name)
+	}
+	s.batches = append(s.batches, names)
+	return nil
+}
+
+func (s *fakeSink) state() ([][]string, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.batches, s.attempts
+}
+
+// useSink delivers the events to the given sink in the local mode for the duration of the test.
+func useSink(t *testing.T, sink Sink) {
+	t.Helper()
+	RegisterSink(testSinkKind, sink)
+	Configure(&v1alpha1.Telemetry{Mode: v1alpha1.TelemetryModeLocal, LocalSink: testSinkKind})
+	t.Cleanup(func() { Configure(nil) })
+}
+
+// startWorker runs the Worker until the returned function is called.
+func startWorker(t *testing.T, w *Worker) (stop func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Start(ctx) }()
+	require.Eventually(t, func() bool { return activeWorker.Load() == w }, time.Second, time.Millisecond)
+
+	return func() {
+		cancel()
+		require.NoError(t, <-done)
+		require.Nil(t, activeWorker.Load())
+	}
+}
+
+func TestWorkerBatches(t *testing.T) {
+	sink := &fakeSink{}
+	useSink(t, sink)
+
+	w := &Worker{BatchSize: 2, FlushInterval: time.Hour}
+	stop := startWorker(t, w)
+
+	for _, name := range []string{"a", "b", "c"} {
+		require.NoError(t, TrackEvent(name, "id", nil))
+	}
+	// the full batch is delivered at once without waiting for the flush interval
+	require.Eventually(t, func() bool {
+		batches, _ := sink.state()
+		return len(batches) == 1
+	}, time.Second, time.Millisecond)
+
+	// the rest of the queue is drained on the shutdown
+	stop()
+	batches, attempts := sink.state()
+	require.Equal(t, [][]string{{"a", "b"}, {"c"}}, batches)
+	require.Equal(t, 2, attempts)
+}
+
+func TestWorkerDropsEventsOnFullQueue(t *testing.T) {
+	w := &Worker{}
+	w.queue = make(chan Event, 1)
+
+	w.enqueue(Event{Name: "a"})
+	w.enqueue(Event{Name: "b"})
+	w.enqueue(Event{Name: "c"})
+	require.Len(t, w.queue, 1)
+	require.Equal(t, int64(2), w.dropped.Load())
+
+	// the dropped events are reported on the next flush
+	w.setDefaults()
+	w.flush(context.Background(), nil, 0)
+	require.Zero(t, w.dropped.Load())
+}
+
+func TestWorkerRetries(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		worker           *Worker
+		failures         int
+		expectedBatches  int
+		expectedAttempts int
+	}{
+		{
+			name:             "delivered after the retries",
+			worker:           &Worker{MaxRetries: 3, RetryBackoff: time.Millisecond},
+			failures:         2,
+			expectedBatches:  1,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "failed after the retries",
+			worker:           &Worker{MaxRetries: 2, RetryBackoff: time.Millisecond},
+			failures:         5,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "failed after the flush timeout",
+			worker:           &Worker{MaxRetries: 100, RetryBackoff: 20 * time.Millisecond, FlushTimeout: 50 * time.Millisecond},
+			failures:         100,
+			expectedAttempts: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sink := &fakeSink{failures: tc.failures}
+			useSink(t, sink)
+
+			tc.worker.setDefaults()
+			tc.worker.flush(context.Background(), []Event{{Name: "a"}, {Name: "b"}}, tc.worker.MaxRetries)
+
+			batches, attempts := sink.state()
+			require.Len(t, batches, tc.expectedBatches)
+			require.Equal(t, tc.expectedAttempts, attempts)
+		})
+	}
+}
+
+func TestWorkerDrainsWithoutRetries(t *testing.T) {
+	sink := &fakeSink{failures: 1}
+	useSink(t, sink)
+
+	w := &Worker{FlushInterval: time.Hour, MaxRetries: 5, RetryBackoff: time.Hour}
+	stop := startWorker(t, w)
+	require.NoError(t, TrackEvent("a", "id", nil))
+
+	// the shutdown is not delayed by the retries of the failing sink
+	stop()
+	batches, attempts := sink.state()
+	require.Empty(t, batches)
+	require.Equal(t, 1, attempts)
+}
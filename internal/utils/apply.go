@@ -0,0 +1,183 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/csaupgrade"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/Mirantis/hmc/internal/audit"
+)
+
+// FieldManager is the field manager HMC applies the objects with.
+const FieldManager = "hmc-controller"
+
+// legacyFieldManagers are the field managers the objects were updated by
+// before HMC switched to the server-side apply, the fields owned by them are
+// taken over by the FieldManager.
+var legacyFieldManagers = sets.New(userAgentFieldManager(rest.DefaultKubernetesUserAgent()))
+
+var conflictManagerRegexp = regexp.MustCompile(`conflict with "([^"]+)"`)
+
+type apiReaderKey struct{}
+
+// WithAPIReader returns the context reading the applied objects with the given uncached reader.
+// The cache of the manager strips the managed fields, the legacy field managers are found with it.
+func WithAPIReader(ctx context.Context, r client.Reader) context.Context {
+	return context.WithValue(ctx, apiReaderKey{}, r)
+}
+
+// APIReader returns the uncached reader carried by the context, the given reader otherwise.
+func APIReader(ctx context.Context, r client.Reader) client.Reader {
+	if apiReader, ok := ctx.Value(apiReaderKey{}).(client.Reader); ok {
+		return apiReader
+	}
+	return r
+}
+
+// ConflictError is returned when the applied fields are owned by other field managers.
+type ConflictError struct {
+	Err error
+	// Object is the kind and the key of the applied object.
+	Object string
+	// Managers are the field managers owning the conflicting fields.
+	Managers []string
+	// Fields are the conflicting fields.
+	Fields []string
+}
+
+func (e *ConflictError) Error() string {
+	if len(e.Managers) == 0 {
+		return fmt.Sprintf("conflicting fields of %s: %v", e.Object, e.Err)
+	}
+	return fmt.Sprintf("fields %s of %s are managed by %s", strings.Join(e.Fields, ", "), e.Object, strings.Join(e.Managers, ", "))
+}
+
+func (e *ConflictError) Unwrap() error { return e.Err }
+
+// Apply applies the object with the server-side apply under the FieldManager
+// and returns the performed operation. The fields owned by other field managers
+// are not overridden, a ConflictError is returned instead. The current object is
+// read with the APIReader of the context to see its managed fields.
+func Apply(ctx context.Context, cl client.Client, obj client.Object) (controllerutil.OperationResult, error) {
+	gvk, err := apiutil.GVKForObject(obj, cl.Scheme())
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	obj.SetResourceVersion("")
+	obj.SetManagedFields(nil)
+
+	current, ok := obj.DeepCopyObject().(client.Object)
+	if !ok {
+		return controllerutil.OperationResultNone, fmt.Errorf("unexpected object type %T", obj)
+	}
+	err = APIReader(ctx, cl).Get(ctx, client.ObjectKeyFromObject(obj), current)
+	if client.IgnoreNotFound(err) != nil {
+		return controllerutil.OperationResultNone, fmt.Errorf("failed to get %s %s: %w", gvk.Kind, client.ObjectKeyFromObject(obj), err)
+	}
+	exists := err == nil
+
+	if exists {
+		if err := upgradeManagedFields(ctx, cl, current); err != nil {
+			return controllerutil.OperationResultNone, err
+		}
+	}
+
+	if err := cl.Patch(ctx, obj, client.Apply, client.FieldOwner(FieldManager)); err != nil {
+		if apierrors.IsConflict(err) {
+			return controllerutil.OperationResultNone, newConflictError(fmt.Sprintf("%s %s", gvk.Kind, client.ObjectKeyFromObject(obj)), err)
+		}
+		return controllerutil.OperationResultNone, err
+	}
+
+	switch {
+	case !exists:
+		audit.Record(ctx, audit.ActionCreate, obj, "")
+		return controllerutil.OperationResultCreated, nil
+	case obj.GetResourceVersion() != current.GetResourceVersion():
+		diff, err := audit.Diff(current, obj)
+		if err != nil {
+			// the object is applied already, the change is recorded without the summary
+			ctrl.LoggerFrom(ctx).Error(err, "failed to summarize the changes for the audit", "kind", gvk.Kind, "object", client.ObjectKeyFromObject(obj))
+		}
+		audit.Record(ctx, audit.ActionUpdate, obj, diff)
+		return controllerutil.OperationResultUpdated, nil
+	default:
+		return controllerutil.OperationResultNone, nil
+	}
+}
+
+// userAgentFieldManager returns the field manager the API server records the updates
+// made without one under, i.e. the name of the binary in the user agent of the client.
+func userAgentFieldManager(userAgent string) string {
+	name, _, _ := strings.Cut(userAgent, "/")
+	return name
+}
+
+// upgradeManagedFields moves the fields owned by the legacy field managers to the FieldManager.
+func upgradeManagedFields(ctx context.Context, cl client.Client, current client.Object) error {
+	patch, err := csaupgrade.UpgradeManagedFieldsPatch(current, legacyFieldManagers, FieldManager)
+	if err != nil {
+		return fmt.Errorf("failed to upgrade the managed fields of %s: %w", client.ObjectKeyFromObject(current), err)
+	}
+	if patch == nil {
+		return nil
+	}
+
+	if err := cl.Patch(ctx, current, client.RawPatch(types.JSONPatchType, patch)); err != nil {
+		return fmt.Errorf("failed to upgrade the managed fields of %s: %w", client.ObjectKeyFromObject(current), err)
+	}
+	return nil
+}
+
+func newConflictError(object string, err error) error {
+	conflict := &ConflictError{Err: err, Object: object}
+
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) || status.Status().Details == nil {
+		return conflict
+	}
+
+	managers := sets.New[string]()
+	for _, cause := range status.Status().Details.Causes {
+		if cause.Type != metav1.CauseTypeFieldManagerConflict {
+			continue
+		}
+		if m := conflictManagerRegexp.FindStringSubmatch(cause.Message); m != nil {
+			managers.Insert(m[1])
+		}
+		conflict.Fields = append(conflict.Fields, cause.Field)
+	}
+	conflict.Managers = sets.List(managers)
+	slices.Sort(conflict.Fields)
+
+	return conflict
+}
@@ -0,0 +1,190 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/Mirantis/hmc/test/fakeclient"
+)
+
+func TestApply(t *testing.T) {
+	ctx := context.Background()
+	cl := fakeclient.WithApply(fake.NewClientBuilder().WithScheme(scheme.Scheme)).Build()
+
+	configMap := func(value string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+			Data:       map[string]string{"key": value},
+		}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		value    string
+		expected controllerutil.OperationResult
+	}{
+		{name: "create", value: "a", expected: controllerutil.OperationResultCreated},
+		{name: "unchanged", value: "a", expected: controllerutil.OperationResultNone},
+		{name: "update", value: "b", expected: controllerutil.OperationResultUpdated},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			operation, err := Apply(ctx, cl, configMap(tc.value))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if operation != tc.expected {
+				t.Errorf("expected operation %s, got %s", tc.expected, operation)
+			}
+		})
+	}
+}
+
+func TestApplyRemovesOmittedFields(t *testing.T) {
+	ctx := context.Background()
+	cl := fakeclient.WithApply(fake.NewClientBuilder().WithScheme(scheme.Scheme)).Build()
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", Labels: map[string]string{"a": "a", "b": "b"}},
+		Data:       map[string]string{"a": "a", "b": "b"},
+	}
+	if _, err := Apply(ctx, cl, configMap.DeepCopy()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	configMap.Labels = map[string]string{"a": "a"}
+	configMap.Data = map[string]string{"a": "a"}
+	operation, err := Apply(ctx, cl, configMap.DeepCopy())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if operation != controllerutil.OperationResultUpdated {
+		t.Errorf("expected operation %s, got %s", controllerutil.OperationResultUpdated, operation)
+	}
+
+	actual := &corev1.ConfigMap{}
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(configMap), actual); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(actual.Data, configMap.Data) {
+		t.Errorf("expected data %v, got %v", configMap.Data, actual.Data)
+	}
+	if !reflect.DeepEqual(actual.Labels, configMap.Labels) {
+		t.Errorf("expected labels %v, got %v", configMap.Labels, actual.Labels)
+	}
+}
+
+func TestApplyUpgradesLegacyFieldManagers(t *testing.T) {
+	legacyManager := sets.List(legacyFieldManagers)[0]
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "test",
+			ManagedFields: []metav1.ManagedFieldsEntry{{
+				Manager:    legacyManager,
+				Operation:  metav1.ManagedFieldsOperationUpdate,
+				APIVersion: "v1",
+				FieldsType: "FieldsV1",
+				FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:data":{".":{},"f:key":{}}}`)},
+			}},
+		},
+		Data: map[string]string{"key": "a"},
+	}
+	apiServer := fakeclient.WithApply(fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(configMap.DeepCopy())).Build()
+	// the cache of the manager strips the managed fields
+	stripManagedFields := cache.TransformStripManagedFields()
+	cached := interceptor.NewClient(apiServer, interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if err := c.Get(ctx, key, obj, opts...); err != nil {
+				return err
+			}
+			_, err := stripManagedFields(obj)
+			return err
+		},
+	})
+
+	for _, tc := range []struct {
+		name     string
+		ctx      context.Context
+		expected []string
+	}{
+		{name: "cached", ctx: context.Background(), expected: []string{legacyManager}},
+		{name: "uncached", ctx: WithAPIReader(context.Background(), apiServer), expected: []string{FieldManager}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			applied := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"}, Data: map[string]string{"key": "b"}}
+			if _, err := Apply(tc.ctx, cached, applied); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			actual := &corev1.ConfigMap{}
+			if err := apiServer.Get(tc.ctx, client.ObjectKeyFromObject(configMap), actual); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var managers []string
+			for _, entry := range actual.ManagedFields {
+				managers = append(managers, entry.Manager)
+			}
+			if !reflect.DeepEqual(managers, tc.expected) {
+				t.Errorf("expected field managers %v, got %v", tc.expected, managers)
+			}
+		})
+	}
+}
+
+func TestUserAgentFieldManager(t *testing.T) {
+	if manager := userAgentFieldManager("manager/v0.0.0 (linux/amd64) kubernetes/$Format"); manager != "manager" {
+		t.Errorf("expected field manager manager, got %s", manager)
+	}
+}
+
+func TestNewConflictError(t *testing.T) {
+	err := apierrors.NewApplyConflict([]metav1.StatusCause{
+		{Type: metav1.CauseTypeFieldManagerConflict, Message: `conflict with "kubectl" using v1`, Field: ".spec.suspend"},
+		{Type: metav1.CauseTypeFieldManagerConflict, Message: `conflict with "flux" using v2`, Field: ".spec.interval"},
+	}, "Apply failed with 2 conflicts")
+
+	conflict := &ConflictError{}
+	if !errors.As(newConflictError("HelmRelease default/test", err), &conflict) {
+		t.Fatalf("expected a ConflictError")
+	}
+	if !reflect.DeepEqual(conflict.Managers, []string{"flux", "kubectl"}) {
+		t.Errorf("unexpected managers %v", conflict.Managers)
+	}
+	if !reflect.DeepEqual(conflict.Fields, []string{".spec.interval", ".spec.suspend"}) {
+		t.Errorf("unexpected fields %v", conflict.Fields)
+	}
+	expected := "fields .spec.interval, .spec.suspend of HelmRelease default/test are managed by flux, kubectl"
+	if conflict.Error() != expected {
+		t.Errorf("expected error %q, got %q", expected, conflict.Error())
+	}
+	if !apierrors.IsConflict(conflict) {
+		t.Errorf("expected the conflict error to be unwrapped")
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// KubeconfigCAFingerprint returns the SHA-256 fingerprint of the DER encoded CA certificate
+// of the cluster of the current context of the kubeconfig in the sha256:<hex> form.
+func KubeconfigCAFingerprint(kubeconfig []byte) (string, error) {
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse kubeconfig: %w", err)
+	}
+
+	kubeContext, ok := config.Contexts[config.CurrentContext]
+	if !ok {
+		return "", fmt.Errorf("context %q is not found in kubeconfig", config.CurrentContext)
+	}
+	cluster, ok := config.Clusters[kubeContext.Cluster]
+	if !ok {
+		return "", fmt.Errorf("cluster %q is not found in kubeconfig", kubeContext.Cluster)
+	}
+	if len(cluster.CertificateAuthorityData) == 0 {
+		return "", errors.New("kubeconfig does not contain the CA certificate")
+	}
+
+	block, _ := pem.Decode(cluster.CertificateAuthorityData)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return "", errors.New("failed to decode the CA certificate of kubeconfig")
+	}
+	if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+		return "", fmt.Errorf("failed to parse the CA certificate of kubeconfig: %w", err)
+	}
+
+	sum := sha256.Sum256(block.Bytes)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
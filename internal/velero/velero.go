@@ -0,0 +1,262 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package velero manages the Velero objects used to back up and restore
+// the management plane. The Velero API is not vendored, the objects are
+// handled as unstructured.
+package velero
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultNamespace is the namespace Velero is installed into by default.
+	DefaultNamespace = "velero"
+
+	// ScheduleNameLabelKey is the label set by Velero on the Backups created by a Schedule.
+	ScheduleNameLabelKey = "velero.io/schedule-name"
+)
+
+// Phases of the Velero Backups and Restores.
+const (
+	PhaseCompleted        = "Completed"
+	PhasePartiallyFailed  = "PartiallyFailed"
+	PhaseFailed           = "Failed"
+	PhaseFailedValidation = "FailedValidation"
+)
+
+var (
+	// ScheduleGVK is the GroupVersionKind of the Velero Schedule.
+	ScheduleGVK = schema.GroupVersionKind{Group: "velero.io", Version: "v1", Kind: "Schedule"}
+	// BackupGVK is the GroupVersionKind of the Velero Backup.
+	BackupGVK = schema.GroupVersionKind{Group: "velero.io", Version: "v1", Kind: "Backup"}
+	// RestoreGVK is the GroupVersionKind of the Velero Restore.
+	RestoreGVK = schema.GroupVersionKind{Group: "velero.io", Version: "v1", Kind: "Restore"}
+)
+
+// ManagementGroups are the API groups of the objects composing the management plane.
+var ManagementGroups = []string{
+	"hmc.mirantis.com",
+	"source.toolkit.fluxcd.io",
+	"helm.toolkit.fluxcd.io",
+	"cluster.x-k8s.io",
+	"infrastructure.cluster.x-k8s.io",
+	"controlplane.cluster.x-k8s.io",
+	"bootstrap.cluster.x-k8s.io",
+	"addons.cluster.x-k8s.io",
+	"config.projectsveltos.io",
+	"lib.projectsveltos.io",
+}
+
+// managementCoreResources are the core resources backed up along with the
+// management plane: the credentials and the kubeconfigs of the clusters.
+var managementCoreResources = []string{"secrets", "configmaps", "namespaces"}
+
+// New returns a new unstructured Velero object of the given kind.
+func New(gvk schema.GroupVersionKind, namespace, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+// IsInstalled returns true if the Velero API is served by the cluster.
+func IsInstalled(dc discovery.DiscoveryInterface) (bool, error) {
+	resources, err := dc.ServerResourcesForGroupVersion(ScheduleGVK.GroupVersion().String())
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to discover %s resources: %w", ScheduleGVK.GroupVersion(), err)
+	}
+	return slices.ContainsFunc(resources.APIResources, func(r metav1.APIResource) bool { return r.Kind == ScheduleGVK.Kind }), nil
+}
+
+// ManagementResources returns the resources to back up along with the
+// management plane in the Velero "resource.group" notation.
+func ManagementResources(dc discovery.DiscoveryInterface) ([]string, error) {
+	lists, err := discovery.ServerPreferredResources(dc)
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, fmt.Errorf("failed to discover server resources: %w", err)
+	}
+
+	resources := slices.Clone(managementCoreResources)
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil || !slices.Contains(ManagementGroups, gv.Group) {
+			continue
+		}
+		for _, r := range list.APIResources {
+			if strings.Contains(r.Name, "/") {
+				continue
+			}
+			resources = append(resources, r.Name+"."+gv.Group)
+		}
+	}
+
+	slices.Sort(resources)
+	return slices.Compact(resources), nil
+}
+
+// ScheduleOpts defines the Velero Schedule of the management plane backups.
+type ScheduleOpts struct {
+	OwnerReference    *metav1.OwnerReference
+	Schedule          string
+	StorageLocation   string
+	TTL               metav1.Duration
+	IncludedResources []string
+	Paused            bool
+}
+
+// ReconcileSchedule creates or updates the Velero Schedule of the management plane backups.
+func ReconcileSchedule(ctx context.Context, cl client.Client, namespace, name string, opts ScheduleOpts) error {
+	schedule := New(ScheduleGVK, namespace, name)
+
+	_, err := ctrl.CreateOrUpdate(ctx, cl, schedule, func() error {
+		if opts.OwnerReference != nil {
+			schedule.SetOwnerReferences([]metav1.OwnerReference{*opts.OwnerReference})
+		}
+
+		template := map[string]any{
+			"includedNamespaces":      []any{"*"},
+			"includedResources":       toAnySlice(opts.IncludedResources),
+			"includeClusterResources": true,
+			"snapshotVolumes":         false,
+		}
+		if opts.StorageLocation != "" {
+			template["storageLocation"] = opts.StorageLocation
+		}
+		if opts.TTL.Duration > 0 {
+			template["ttl"] = opts.TTL.Duration.String()
+		}
+
+		return unstructured.SetNestedMap(schedule.Object, map[string]any{
+			"schedule": opts.Schedule,
+			"paused":   opts.Paused,
+			"template": template,
+		}, "spec")
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reconcile Velero Schedule %s/%s: %w", namespace, name, err)
+	}
+	return nil
+}
+
+// ListScheduleBackups returns the Velero Backups created by the given Schedule
+// sorted by the creation time, the most recent first.
+func ListScheduleBackups(ctx context.Context, cl client.Client, namespace, schedule string) ([]unstructured.Unstructured, error) {
+	backups, err := ListBackups(ctx, cl, namespace, client.MatchingLabels{ScheduleNameLabelKey: schedule})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Velero Backups of the Schedule %s/%s: %w", namespace, schedule, err)
+	}
+	return backups, nil
+}
+
+// ListBackups returns the Velero Backups in the namespace matching the given
+// options sorted by the creation time, the most recent first.
+func ListBackups(ctx context.Context, cl client.Client, namespace string, opts ...client.ListOption) ([]unstructured.Unstructured, error) {
+	backups := &unstructured.UnstructuredList{}
+	backups.SetGroupVersionKind(BackupGVK.GroupVersion().WithKind(BackupGVK.Kind + "List"))
+	if err := cl.List(ctx, backups, append(opts, client.InNamespace(namespace))...); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(backups.Items, func(a, b unstructured.Unstructured) int {
+		return b.GetCreationTimestamp().Time.Compare(a.GetCreationTimestamp().Time)
+	})
+	return backups.Items, nil
+}
+
+// Phase returns the phase of the given Velero Backup or Restore.
+func Phase(obj *unstructured.Unstructured) string {
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	return phase
+}
+
+// IsTerminal returns true if the given phase of a Velero Backup or Restore is final.
+func IsTerminal(phase string) bool {
+	switch phase {
+	case PhaseCompleted, PhasePartiallyFailed, PhaseFailed, PhaseFailedValidation:
+		return true
+	default:
+		return false
+	}
+}
+
+// FailureMessage returns the human-readable reason of the failure of the
+// given Velero Backup or Restore or an empty string if it has not failed.
+func FailureMessage(obj *unstructured.Unstructured) string {
+	phase := Phase(obj)
+	switch phase {
+	case PhasePartiallyFailed, PhaseFailed:
+		if reason, _, _ := unstructured.NestedString(obj.Object, "status", "failureReason"); reason != "" {
+			return fmt.Sprintf("%s %s: %s", obj.GetKind(), phase, reason)
+		}
+		errs, _, _ := unstructured.NestedInt64(obj.Object, "status", "errors")
+		return fmt.Sprintf("%s %s with %d errors", obj.GetKind(), phase, errs)
+	case PhaseFailedValidation:
+		validationErrors, _, _ := unstructured.NestedStringSlice(obj.Object, "status", "validationErrors")
+		return fmt.Sprintf("%s %s: %s", obj.GetKind(), phase, strings.Join(validationErrors, "; "))
+	default:
+		return ""
+	}
+}
+
+// ReconcileRestore creates the Velero Restore of the given Backup if it does not exist yet.
+// The existing objects are updated from the backup.
+func ReconcileRestore(ctx context.Context, cl client.Client, namespace, name, backupName string, ownerReference *metav1.OwnerReference) (*unstructured.Unstructured, error) {
+	restore := New(RestoreGVK, namespace, name)
+
+	_, err := ctrl.CreateOrUpdate(ctx, cl, restore, func() error {
+		if ownerReference != nil {
+			restore.SetOwnerReferences([]metav1.OwnerReference{*ownerReference})
+		}
+		if ts := restore.GetCreationTimestamp(); !ts.IsZero() {
+			// the spec of the started restore is not changed
+			return nil
+		}
+
+		return unstructured.SetNestedMap(restore.Object, map[string]any{
+			"backupName":             backupName,
+			"includedNamespaces":     []any{"*"},
+			"existingResourcePolicy": "update",
+			"restorePVs":             false,
+		}, "spec")
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile Velero Restore %s/%s: %w", namespace, name, err)
+	}
+	return restore, nil
+}
+
+func toAnySlice(s []string) []any {
+	res := make([]any, 0, len(s))
+	for _, v := range s {
+		res = append(res, v)
+	}
+	return res
+}
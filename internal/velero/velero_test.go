@@ -0,0 +1,281 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package velero
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newDiscovery(resources ...*metav1.APIResourceList) *fakediscovery.FakeDiscovery {
+	return &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: resources}}
+}
+
+func TestIsInstalled(t *testing.T) {
+	veleroResources := &metav1.APIResourceList{
+		GroupVersion: "velero.io/v1",
+		APIResources: []metav1.APIResource{{Name: "schedules", Kind: "Schedule"}, {Name: "backups", Kind: "Backup"}},
+	}
+
+	installed, err := IsInstalled(newDiscovery(veleroResources))
+	if err != nil || !installed {
+		t.Errorf("expected Velero to be installed, got %t, %v", installed, err)
+	}
+
+	installed, err = IsInstalled(newDiscovery())
+	if err != nil || installed {
+		t.Errorf("expected Velero not to be installed, got %t, %v", installed, err)
+	}
+
+	installed, err = IsInstalled(newDiscovery(&metav1.APIResourceList{
+		GroupVersion: "velero.io/v1",
+		APIResources: []metav1.APIResource{{Name: "backups", Kind: "Backup"}},
+	}))
+	if err != nil || installed {
+		t.Errorf("expected Velero without schedules not to be installed, got %t, %v", installed, err)
+	}
+
+	dc := newDiscovery(veleroResources)
+	dc.PrependReactor("get", "resource", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("connection refused")
+	})
+	if _, err := IsInstalled(dc); err == nil {
+		t.Error("expected the discovery error to be returned")
+	}
+}
+
+func TestManagementResources(t *testing.T) {
+	dc := newDiscovery(
+		&metav1.APIResourceList{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{{Name: "secrets"}, {Name: "pods"}, {Name: "pods/status"}},
+		},
+		&metav1.APIResourceList{
+			GroupVersion: "hmc.mirantis.com/v1alpha1",
+			APIResources: []metav1.APIResource{{Name: "managedclusters"}, {Name: "managedclusters/status"}, {Name: "managements"}},
+		},
+		&metav1.APIResourceList{
+			GroupVersion: "cluster.x-k8s.io/v1beta1",
+			APIResources: []metav1.APIResource{{Name: "clusters"}},
+		},
+		&metav1.APIResourceList{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{{Name: "deployments"}},
+		},
+	)
+
+	resources, err := ManagementResources(dc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"clusters.cluster.x-k8s.io",
+		"configmaps",
+		"managedclusters.hmc.mirantis.com",
+		"managements.hmc.mirantis.com",
+		"namespaces",
+		"secrets",
+	}
+	if !slices.Equal(resources, expected) {
+		t.Errorf("expected resources %v, got %v", expected, resources)
+	}
+}
+
+func TestReconcileSchedule(t *testing.T) {
+	ctx := context.Background()
+	cl := fake.NewClientBuilder().Build()
+
+	owner := &metav1.OwnerReference{APIVersion: "hmc.mirantis.com/v1alpha1", Kind: "ManagementBackup", Name: "daily", UID: "uid", Controller: ptr.To(true)}
+	opts := ScheduleOpts{
+		OwnerReference:    owner,
+		Schedule:          "@daily",
+		StorageLocation:   "default",
+		TTL:               metav1.Duration{Duration: 24 * time.Hour},
+		IncludedResources: []string{"secrets"},
+	}
+	if err := ReconcileSchedule(ctx, cl, DefaultNamespace, "daily", opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	schedule := New(ScheduleGVK, DefaultNamespace, "daily")
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(schedule), schedule); err != nil {
+		t.Fatalf("failed to get the Schedule: %v", err)
+	}
+	if refs := schedule.GetOwnerReferences(); len(refs) != 1 || refs[0].UID != owner.UID {
+		t.Errorf("expected the owner reference %v, got %v", owner, refs)
+	}
+	if s, _, _ := unstructured.NestedString(schedule.Object, "spec", "schedule"); s != "@daily" {
+		t.Errorf("expected the schedule @daily, got %q", s)
+	}
+	if ttl, _, _ := unstructured.NestedString(schedule.Object, "spec", "template", "ttl"); ttl != "24h0m0s" {
+		t.Errorf("expected the ttl 24h0m0s, got %q", ttl)
+	}
+	if loc, _, _ := unstructured.NestedString(schedule.Object, "spec", "template", "storageLocation"); loc != "default" {
+		t.Errorf("expected the storage location default, got %q", loc)
+	}
+
+	opts.Paused = true
+	opts.StorageLocation = ""
+	opts.TTL = metav1.Duration{}
+	opts.IncludedResources = []string{"configmaps", "secrets"}
+	if err := ReconcileSchedule(ctx, cl, DefaultNamespace, "daily", opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(schedule), schedule); err != nil {
+		t.Fatalf("failed to get the Schedule: %v", err)
+	}
+	if paused, _, _ := unstructured.NestedBool(schedule.Object, "spec", "paused"); !paused {
+		t.Error("expected the Schedule to be paused")
+	}
+	if _, found, _ := unstructured.NestedString(schedule.Object, "spec", "template", "ttl"); found {
+		t.Error("expected the unset ttl to be removed")
+	}
+	if _, found, _ := unstructured.NestedString(schedule.Object, "spec", "template", "storageLocation"); found {
+		t.Error("expected the unset storage location to be removed")
+	}
+	if resources, _, _ := unstructured.NestedStringSlice(schedule.Object, "spec", "template", "includedResources"); !slices.Equal(resources, opts.IncludedResources) {
+		t.Errorf("expected the included resources %v, got %v", opts.IncludedResources, resources)
+	}
+}
+
+func newBackup(name, schedule, phase string, created time.Time) *unstructured.Unstructured {
+	backup := New(BackupGVK, DefaultNamespace, name)
+	backup.SetCreationTimestamp(metav1.NewTime(created))
+	if schedule != "" {
+		backup.SetLabels(map[string]string{ScheduleNameLabelKey: schedule})
+	}
+	if phase != "" {
+		_ = unstructured.SetNestedField(backup.Object, phase, "status", "phase")
+	}
+	return backup
+}
+
+func TestListScheduleBackups(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	other := newBackup("other", "weekly", PhaseCompleted, now)
+	other.SetNamespace("default")
+	cl := fake.NewClientBuilder().WithObjects(
+		newBackup("daily-1", "daily", PhaseCompleted, now.Add(-2*time.Hour)),
+		newBackup("daily-3", "daily", PhaseFailed, now),
+		newBackup("daily-2", "daily", PhaseCompleted, now.Add(-time.Hour)),
+		newBackup("manual", "", PhaseCompleted, now),
+		newBackup("weekly-1", "weekly", PhaseCompleted, now),
+		other,
+	).Build()
+
+	backups, err := ListScheduleBackups(context.Background(), cl, DefaultNamespace, "daily")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, b := range backups {
+		names = append(names, b.GetName())
+	}
+	if expected := []string{"daily-3", "daily-2", "daily-1"}; !slices.Equal(names, expected) {
+		t.Errorf("expected the backups %v, got %v", expected, names)
+	}
+}
+
+func TestFailureMessage(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		status   map[string]any
+		expected string
+	}{
+		{name: "in progress", status: map[string]any{"phase": "InProgress"}},
+		{name: "completed", status: map[string]any{"phase": PhaseCompleted}},
+		{
+			name:     "failed with reason",
+			status:   map[string]any{"phase": PhaseFailed, "failureReason": "storage location is unavailable"},
+			expected: "Backup Failed: storage location is unavailable",
+		},
+		{
+			name:     "partially failed",
+			status:   map[string]any{"phase": PhasePartiallyFailed, "errors": int64(3)},
+			expected: "Backup PartiallyFailed with 3 errors",
+		},
+		{
+			name:     "failed validation",
+			status:   map[string]any{"phase": PhaseFailedValidation, "validationErrors": []any{"invalid schedule", "missing location"}},
+			expected: "Backup FailedValidation: invalid schedule; missing location",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			backup := New(BackupGVK, DefaultNamespace, "backup")
+			backup.Object["status"] = tc.status
+			if msg := FailureMessage(backup); msg != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, msg)
+			}
+		})
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	for _, phase := range []string{PhaseCompleted, PhasePartiallyFailed, PhaseFailed, PhaseFailedValidation} {
+		if !IsTerminal(phase) {
+			t.Errorf("expected the %s phase to be terminal", phase)
+		}
+	}
+	for _, phase := range []string{"", "New", "InProgress", "WaitingForPluginOperations"} {
+		if IsTerminal(phase) {
+			t.Errorf("expected the %q phase not to be terminal", phase)
+		}
+	}
+}
+
+func TestReconcileRestore(t *testing.T) {
+	ctx := context.Background()
+	cl := fake.NewClientBuilder().Build()
+
+	owner := &metav1.OwnerReference{APIVersion: "hmc.mirantis.com/v1alpha1", Kind: "ManagementRestore", Name: "restore", UID: "uid", Controller: ptr.To(true)}
+	restore, err := ReconcileRestore(ctx, cl, DefaultNamespace, "restore", "daily-1", owner)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if backup, _, _ := unstructured.NestedString(restore.Object, "spec", "backupName"); backup != "daily-1" {
+		t.Errorf("expected the backup daily-1, got %q", backup)
+	}
+	if policy, _, _ := unstructured.NestedString(restore.Object, "spec", "existingResourcePolicy"); policy != "update" {
+		t.Errorf("expected the existing resource policy update, got %q", policy)
+	}
+
+	// the spec of the started restore is not changed,
+	// the creation timestamp is not set by the fake client
+	restore.SetCreationTimestamp(metav1.Now())
+	if err := cl.Update(ctx, restore); err != nil {
+		t.Fatalf("failed to update the Restore: %v", err)
+	}
+	restore, err = ReconcileRestore(ctx, cl, DefaultNamespace, "restore", "daily-2", owner)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if backup, _, _ := unstructured.NestedString(restore.Object, "spec", "backupName"); backup != "daily-1" {
+		t.Errorf("expected the backup of the started restore to be kept, got %q", backup)
+	}
+}
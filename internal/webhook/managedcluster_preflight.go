@@ -0,0 +1,156 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	hmcv1alpha1 "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// The names of the preflight checks.
+const (
+	PreflightCheckSchema           = "Schema"
+	PreflightCheckTemplate         = "Template"
+	PreflightCheckClusterName      = "ClusterName"
+	PreflightCheckProvidersEnabled = "ProvidersEnabled"
+	PreflightCheckCredential       = "Credential"
+	PreflightCheckServices         = "Services"
+	PreflightCheckMetadata         = "Metadata"
+)
+
+// PreflightCheck is the result of one of the preflight checks.
+type PreflightCheck struct {
+	// Name is the name of the check.
+	Name string `json:"name"`
+	// Message describes the reason of the failure.
+	Message string `json:"message,omitempty"`
+	// Passed is true if the check succeeded.
+	Passed bool `json:"passed"`
+}
+
+// PreflightReport is the result of the preflight checks of a ManagedCluster.
+type PreflightReport struct {
+	// Checks are the results of the checks in the order they are performed.
+	Checks []PreflightCheck `json:"checks"`
+	// Warnings are the warnings the admission webhook would return, e.g. about deprecated templates.
+	Warnings []string `json:"warnings,omitempty"`
+	// Valid is true if all of the checks passed.
+	Valid bool `json:"valid"`
+}
+
+func (r *PreflightReport) add(name string, err error) bool {
+	check := PreflightCheck{Name: name, Passed: err == nil}
+	if err != nil {
+		check.Message = err.Error()
+	}
+	r.Checks = append(r.Checks, check)
+	return check.Passed
+}
+
+// Err returns the error listing the failed checks, or nil if all of the checks passed.
+func (r *PreflightReport) Err() error {
+	if r.Valid {
+		return nil
+	}
+
+	failed := make([]string, 0, len(r.Checks))
+	for _, check := range r.Checks {
+		if !check.Passed {
+			failed = append(failed, check.Name+": "+check.Message)
+		}
+	}
+	return fmt.Errorf("the ManagedCluster failed the preflight checks: %s", strings.Join(failed, "; "))
+}
+
+// isDryRun returns true if the admission request of the context is a dry-run request.
+func isDryRun(ctx context.Context) bool {
+	req, err := admission.RequestFromContext(ctx)
+	return err == nil && req.DryRun != nil && *req.DryRun
+}
+
+// Preflight runs the validations of the admission webhook for the creation of the ManagedCluster
+// independently from each other and reports the result of each of them. The checks depending
+// on the ClusterTemplate fail if the template is not usable.
+func (v *ManagedClusterValidator) Preflight(ctx context.Context, mc *hmcv1alpha1.ManagedCluster) *PreflightReport {
+	report := &PreflightReport{}
+
+	report.add(PreflightCheckSchema, validateConfig(mc))
+
+	template, err := v.getManagedClusterTemplate(ctx, mc.Namespace, mc.Spec.Template)
+	if err == nil {
+		err = isTemplateValid(template)
+	}
+	if err == nil {
+		var warnings admission.Warnings
+		warnings, err = validateTemplatesDeprecation(ctx, v.Client, template, nil)
+		report.Warnings = warnings
+	}
+	templateUsable := report.add(PreflightCheckTemplate, err)
+	if !templateUsable {
+		templateErr := fmt.Errorf("the ClusterTemplate %q is not usable", mc.Spec.Template)
+		report.add(PreflightCheckClusterName, templateErr)
+		report.add(PreflightCheckProvidersEnabled, templateErr)
+		report.add(PreflightCheckCredential, templateErr)
+	} else {
+		report.add(PreflightCheckClusterName, validateClusterName(mc.Name, template))
+		report.add(PreflightCheckProvidersEnabled, v.validateProvidersEnabled(ctx, template))
+		report.add(PreflightCheckCredential, v.validateCredential(ctx, mc, template))
+	}
+
+	err = validateServiceTemplates(ctx, v.Client, mc.Namespace, mc.Spec.Services)
+	if err == nil {
+		err = validateBackup(ctx, v.Client, mc.Namespace, mc.Spec.Backup)
+	}
+	if err == nil && templateUsable {
+		var warnings admission.Warnings
+		// the warnings include the ones about the ClusterTemplate
+		if warnings, err = validateTemplatesDeprecation(ctx, v.Client, template, mc.Spec.Services); err == nil {
+			report.Warnings = warnings
+			err = validateK8sCompatibility(ctx, v.Client, template, mc)
+		}
+	}
+	report.add(PreflightCheckServices, err)
+
+	err = validateClusterMetadata(mc)
+	if err == nil {
+		err = validateNodePools(template, mc.Spec.NodePools)
+	}
+	report.add(PreflightCheckMetadata, err)
+
+	report.Valid = true
+	for _, check := range report.Checks {
+		report.Valid = report.Valid && check.Passed
+	}
+	return report
+}
+
+// validateConfig checks the cluster configuration is an object, as required by the template values.
+func validateConfig(mc *hmcv1alpha1.ManagedCluster) error {
+	if mc.Spec.Config == nil || len(mc.Spec.Config.Raw) == 0 {
+		return nil
+	}
+
+	var config map[string]any
+	if err := json.Unmarshal(mc.Spec.Config.Raw, &config); err != nil {
+		return fmt.Errorf("spec.config must be an object: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+	"github.com/Mirantis/hmc/test/objects/template"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestManagedClusterPreflight(t *testing.T) {
+	g := NewWithT(t)
+
+	validTemplate := template.NewClusterTemplate(
+		template.WithName(testTemplateName),
+		template.WithProvidersStatus(v1alpha1.Providers{
+			"infrastructure-aws",
+			"control-plane-k0smotron",
+			"bootstrap-k0smotron",
+		}),
+		template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+	)
+
+	tests := []struct {
+		name            string
+		managedCluster  *v1alpha1.ManagedCluster
+		existingObjects []runtime.Object
+		failed          map[string]string
+	}{
+		{
+			name: "should pass all of the checks",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{mgmt, cred, validTemplate},
+		},
+		{
+			name: "should fail the checks depending on the missing template",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithCredential(testCredentialName),
+				managedcluster.WithConfig(`["not", "an", "object"]`),
+			),
+			existingObjects: []runtime.Object{mgmt, cred},
+			failed: map[string]string{
+				PreflightCheckSchema:           "spec.config must be an object: json: cannot unmarshal array into Go value of type map[string]interface {}",
+				PreflightCheckTemplate:         "clustertemplates.hmc.mirantis.com \"template-test\" not found",
+				PreflightCheckClusterName:      "the ClusterTemplate \"template-test\" is not usable",
+				PreflightCheckProvidersEnabled: "the ClusterTemplate \"template-test\" is not usable",
+				PreflightCheckCredential:       "the ClusterTemplate \"template-test\" is not usable",
+			},
+		},
+		{
+			name: "should report the credential and the services independently",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithCredential("missing"),
+				managedcluster.WithServiceTemplate("missing-svc"),
+			),
+			existingObjects: []runtime.Object{mgmt, cred, validTemplate},
+			failed: map[string]string{
+				PreflightCheckCredential: "credentials.hmc.mirantis.com \"missing\" not found",
+				PreflightCheckServices:   "the ServiceTemplate default/missing-svc is not found",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(tt.existingObjects...).Build()
+			validator := &ManagedClusterValidator{Client: c}
+			report := validator.Preflight(context.Background(), tt.managedCluster)
+
+			g.Expect(report.Checks).To(HaveLen(7))
+			failed := map[string]string{}
+			for _, check := range report.Checks {
+				if !check.Passed {
+					failed[check.Name] = check.Message
+				}
+			}
+			if len(tt.failed) == 0 {
+				g.Expect(failed).To(BeEmpty())
+				g.Expect(report.Valid).To(BeTrue())
+			} else {
+				g.Expect(failed).To(Equal(tt.failed))
+				g.Expect(report.Valid).To(BeFalse())
+			}
+		})
+	}
+}
+
+func TestManagedClusterPreflightDryRun(t *testing.T) {
+	g := NewWithT(t)
+
+	mc := managedcluster.NewManagedCluster(
+		managedcluster.WithClusterTemplate(testTemplateName),
+		managedcluster.WithCredential("missing"),
+		managedcluster.WithServiceTemplate("missing-svc"),
+	)
+	validator := &ManagedClusterValidator{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(mgmt, cred).Build()}
+
+	// The regular creation fails on the first failed validation.
+	ctx := admission.NewContextWithRequest(context.Background(), admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Create},
+	})
+	_, err := validator.ValidateCreate(ctx, mc)
+	g.Expect(err).To(MatchError(ContainSubstring(`clustertemplates.hmc.mirantis.com "template-test" not found`)))
+	g.Expect(err.Error()).NotTo(ContainSubstring("preflight"))
+
+	// The dry-run creation reports all of the failed checks.
+	ctx = admission.NewContextWithRequest(context.Background(), admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Create, DryRun: ptr.To(true)},
+	})
+	_, err = validator.ValidateCreate(ctx, mc)
+	g.Expect(err).To(MatchError(ContainSubstring("the ManagedCluster failed the preflight checks: " +
+		`Template: clustertemplates.hmc.mirantis.com "template-test" not found; ` +
+		`ClusterName: the ClusterTemplate "template-test" is not usable; ` +
+		`ProvidersEnabled: the ClusterTemplate "template-test" is not usable; ` +
+		`Credential: the ClusterTemplate "template-test" is not usable; ` +
+		"Services: the ServiceTemplate default/missing-svc is not found")))
+}
@@ -0,0 +1,119 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmcv1alpha1 "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// validateClusterQuotas validates the ManagedCluster fits the ClusterQuotas of its namespace.
+// The old ManagedCluster is nil on creation. On update the template is checked only
+// if changed and the limits are checked only if the number of the workers grows,
+// so that the clusters admitted before the quota was lowered may still be updated.
+func validateClusterQuotas(ctx context.Context, cl client.Client, mc, oldMC *hmcv1alpha1.ManagedCluster, template *hmcv1alpha1.ClusterTemplate) error {
+	quotas := &hmcv1alpha1.ClusterQuotaList{}
+	if err := cl.List(ctx, quotas, client.InNamespace(mc.Namespace)); err != nil {
+		return fmt.Errorf("failed to list ClusterQuotas: %w", err)
+	}
+	if len(quotas.Items) == 0 {
+		return nil
+	}
+
+	if oldMC == nil || oldMC.Spec.Template != mc.Spec.Template {
+		for _, quota := range quotas.Items {
+			if err := quota.AllowsTemplate(template); err != nil {
+				return err
+			}
+		}
+	}
+
+	mgmt := new(hmcv1alpha1.Management)
+	if err := cl.Get(ctx, client.ObjectKey{Name: hmcv1alpha1.ManagementName}, mgmt); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to get Management: %w", err)
+	}
+	templates := map[string]*hmcv1alpha1.ClusterTemplate{mc.Spec.Template: template}
+
+	workers, err := clusterWorkersNumber(ctx, cl, mc, mgmt, templates)
+	if err != nil {
+		return err
+	}
+	if oldMC != nil {
+		oldWorkers, err := clusterWorkersNumber(ctx, cl, oldMC, mgmt, templates)
+		if err != nil {
+			return err
+		}
+		if workers <= oldWorkers {
+			return nil
+		}
+	}
+
+	clusters := &hmcv1alpha1.ManagedClusterList{}
+	if err := cl.List(ctx, clusters, client.InNamespace(mc.Namespace)); err != nil {
+		return fmt.Errorf("failed to list ManagedClusters: %w", err)
+	}
+
+	var clustersNumber, workersNumber int32
+	for _, cluster := range clusters.Items {
+		if cluster.Name == mc.Name {
+			continue
+		}
+		clusterWorkers, err := clusterWorkersNumber(ctx, cl, &cluster, mgmt, templates)
+		if err != nil {
+			return err
+		}
+		clustersNumber++
+		workersNumber += clusterWorkers
+	}
+	workersNumber += workers
+	if oldMC == nil {
+		clustersNumber++
+	} else {
+		// the number of the clusters does not change on update
+		clustersNumber = 0
+	}
+
+	for _, quota := range quotas.Items {
+		if exceeded := quota.Exceeded(clustersNumber, workersNumber); len(exceeded) > 0 {
+			return fmt.Errorf("the ClusterQuota %s is exceeded: %s", quota.Name, strings.Join(exceeded, ", "))
+		}
+	}
+
+	return nil
+}
+
+// clusterWorkersNumber returns the number of the worker machines of the ManagedCluster
+// completed with the defaults of its ClusterTemplate and of the Management. The templates
+// are cached in the given map by name, a missing template contributes no defaults.
+func clusterWorkersNumber(ctx context.Context, cl client.Client, mc *hmcv1alpha1.ManagedCluster, mgmt *hmcv1alpha1.Management, templates map[string]*hmcv1alpha1.ClusterTemplate) (int32, error) {
+	template, ok := templates[mc.Spec.Template]
+	if !ok {
+		template = new(hmcv1alpha1.ClusterTemplate)
+		if err := cl.Get(ctx, client.ObjectKey{Namespace: mc.Namespace, Name: mc.Spec.Template}, template); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return 0, fmt.Errorf("failed to get ClusterTemplate %s: %w", mc.Spec.Template, err)
+			}
+			template = nil
+		}
+		templates[mc.Spec.Template] = template
+	}
+	return mc.WorkersNumber(template, mgmt), nil
+}
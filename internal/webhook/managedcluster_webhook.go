@@ -16,40 +16,82 @@ package webhook
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"slices"
 	"strings"
+	"time"
 
-	"github.com/Masterminds/semver/v3"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	hmcv1alpha1 "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/helm"
 )
 
 type ManagedClusterValidator struct {
 	client.Client
 }
 
-const invalidManagedClusterMsg = "the ManagedCluster is invalid"
+const (
+	invalidManagedClusterMsg = "the ManagedCluster is invalid"
+
+	// managedClusterMutatePath is the path of the mutating webhook of the ManagedCluster.
+	managedClusterMutatePath = "/mutate-hmc-mirantis-com-v1alpha1-managedcluster"
+)
 
 var errClusterUpgradeForbidden = errors.New("cluster upgrade is forbidden")
 
 func (v *ManagedClusterValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	v.Client = mgr.GetClient()
+	// The mutating webhook is registered directly so that the warnings of Default are returned
+	// with the patch, the builder only registers the validating one.
+	mgr.GetWebhookServer().Register(managedClusterMutatePath, &admission.Webhook{
+		Handler: &warningDefaulter{Handler: admission.WithCustomDefaulter(mgr.GetScheme(), &hmcv1alpha1.ManagedCluster{}, v).Handler},
+	})
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(&hmcv1alpha1.ManagedCluster{}).
 		WithValidator(v).
-		WithDefaulter(v).
 		Complete()
 }
 
+type defaultingWarningsKey struct{}
+
+// warningDefaulter returns the warnings added by the wrapped defaulter with addDefaultingWarnings
+// in the admission response.
+type warningDefaulter struct {
+	admission.Handler
+}
+
+// Handle implements admission.Handler.
+func (h *warningDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	var warnings admission.Warnings
+	resp := h.Handler.Handle(context.WithValue(ctx, defaultingWarningsKey{}, &warnings), req)
+	resp.Warnings = append(resp.Warnings, warnings...)
+	return resp
+}
+
+// addDefaultingWarnings adds the warnings to the admission response of the warningDefaulter
+// handling the request of the context.
+func addDefaultingWarnings(ctx context.Context, warnings ...string) {
+	if w, ok := ctx.Value(defaultingWarningsKey{}).(*admission.Warnings); ok {
+		*w = append(*w, warnings...)
+	}
+}
+
 var (
 	_ webhook.CustomValidator = &ManagedClusterValidator{}
 	_ webhook.CustomDefaulter = &ManagedClusterValidator{}
@@ -62,6 +104,15 @@ func (v *ManagedClusterValidator) ValidateCreate(ctx context.Context, obj runtim
 		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected ManagedCluster but got a %T", obj))
 	}
 
+	// The dry-run creation reports all of the failed preflight checks at once for the pre-flight
+	// validation of the UI and CLI. The API server authenticates and authorizes such requests as
+	// any other creation of the ManagedCluster.
+	if isDryRun(ctx) {
+		if report := v.Preflight(ctx, managedCluster); !report.Valid {
+			return report.Warnings, fmt.Errorf("%s: %v", invalidManagedClusterMsg, report.Err())
+		}
+	}
+
 	template, err := v.getManagedClusterTemplate(ctx, managedCluster.Namespace, managedCluster.Spec.Template)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
@@ -71,6 +122,59 @@ func (v *ManagedClusterValidator) ValidateCreate(ctx context.Context, obj runtim
 		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
 	}
 
+	if err := validateClusterName(managedCluster.Name, template); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+	}
+
+	if err := v.validateProvidersEnabled(ctx, template); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+	}
+
+	if err := validateClusterQuotas(ctx, v.Client, managedCluster, nil, template); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+	}
+
+	if err := validateServiceTemplates(ctx, v.Client, managedCluster.Namespace, managedCluster.Spec.Services); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+	}
+
+	if err := validateBackup(ctx, v.Client, managedCluster.Namespace, managedCluster.Spec.Backup); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+	}
+
+	if err := validateDNS(ctx, v.Client, managedCluster); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+	}
+
+	if err := validateAutoscaler(ctx, v.Client, managedCluster.Namespace, managedCluster.Spec.Autoscaler); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+	}
+
+	if err := validateClusterMetadata(managedCluster); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+	}
+
+	if err := validateNodePools(template, managedCluster.Spec.NodePools); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+	}
+
+	if err := validateMaintenanceWindow(managedCluster.Spec.MaintenanceWindow); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+	}
+
+	if err := validateReadinessGates(managedCluster.Spec.ReadinessGates); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+	}
+
+	warnings, err := validateTemplatesDeprecation(ctx, v.Client, template, managedCluster.Spec.Services)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+	}
+
+	if err := validateConfigSections(template, &managedCluster.Spec); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+	}
+
 	if err := validateK8sCompatibility(ctx, v.Client, template, managedCluster); err != nil {
 		return admission.Warnings{"Failed to validate k8s version compatibility with ServiceTemplates"}, fmt.Errorf("failed to validate k8s compatibility: %v", err)
 	}
@@ -79,7 +183,47 @@ func (v *ManagedClusterValidator) ValidateCreate(ctx context.Context, obj runtim
 		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
 	}
 
-	return nil, nil
+	return warnings, nil
+}
+
+// clusterNameMaxLengths holds the maximum length of the cluster name for the
+// providers deriving the names of cloud resources from it.
+var clusterNameMaxLengths = map[string]struct {
+	maxLength int
+	reason    string
+}{
+	"infrastructure-aws": {
+		maxLength: 32,
+		reason:    "AWS load balancer names are derived from the cluster name and limited to 32 characters",
+	},
+	"infrastructure-azure": {
+		maxLength: 44,
+		reason:    "Azure resource names are derived from the cluster name and must fit the Azure naming limits",
+	},
+}
+
+// validateClusterName checks the name of the cluster against the strictest
+// of the constraints of the template providers. The name is used as a label
+// value and as a part of names of cloud resources, so it must be a valid
+// DNS-1123 label regardless of the providers.
+func validateClusterName(name string, template *hmcv1alpha1.ClusterTemplate) error {
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return fmt.Errorf("cluster name %q is invalid: %s", name, strings.Join(errs, "; "))
+	}
+
+	for _, provider := range template.Status.Providers {
+		constraint, ok := clusterNameMaxLengths[provider]
+		if !ok {
+			continue
+		}
+
+		if len(name) > constraint.maxLength {
+			return fmt.Errorf("cluster name %q is too long for provider %s: must be no more than %d characters (%s)",
+				name, provider, constraint.maxLength, constraint.reason)
+		}
+	}
+
+	return nil
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
@@ -92,6 +236,10 @@ func (v *ManagedClusterValidator) ValidateUpdate(ctx context.Context, oldObj, ne
 	if !ok {
 		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected ManagedCluster but got a %T", newObj))
 	}
+	if oldManagedCluster.Spec.CloneFrom != newManagedCluster.Spec.CloneFrom {
+		return nil, fmt.Errorf("%s: the cloneFrom field is immutable", invalidManagedClusterMsg)
+	}
+
 	oldTemplate := oldManagedCluster.Spec.Template
 	newTemplate := newManagedCluster.Spec.Template
 
@@ -100,26 +248,294 @@ func (v *ManagedClusterValidator) ValidateUpdate(ctx context.Context, oldObj, ne
 		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
 	}
 
+	var warnings admission.Warnings
 	if oldTemplate != newTemplate {
 		if !slices.Contains(oldManagedCluster.Status.AvailableUpgrades, newTemplate) {
 			msg := fmt.Sprintf("Cluster can't be upgraded from %s to %s. This upgrade sequence is not allowed", oldTemplate, newTemplate)
-			return admission.Warnings{msg}, errClusterUpgradeForbidden
+			if newManagedCluster.Annotations[hmcv1alpha1.AllowTemplateChangeAnnotation] != "true" {
+				return admission.Warnings{msg}, errClusterUpgradeForbidden
+			}
+			warnings = append(warnings, msg+", proceeding since the "+hmcv1alpha1.AllowTemplateChangeAnnotation+" annotation is set")
 		}
 
 		if err := isTemplateValid(template); err != nil {
 			return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
 		}
 
+		deprecationWarnings, err := validateTemplatesDeprecation(ctx, v.Client, template, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+		}
+		warnings = append(warnings, deprecationWarnings...)
+
+		if err := v.validateProvidersEnabled(ctx, template); err != nil {
+			return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+		}
+
 		if err := validateK8sCompatibility(ctx, v.Client, template, newManagedCluster); err != nil {
 			return admission.Warnings{"Failed to validate k8s version compatibility with ServiceTemplates"}, fmt.Errorf("failed to validate k8s compatibility: %v", err)
 		}
 	}
 
+	if err := validateClusterMetadata(newManagedCluster); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+	}
+
+	if err := validateNodePools(template, newManagedCluster.Spec.NodePools); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+	}
+
+	if err := validateMaintenanceWindow(newManagedCluster.Spec.MaintenanceWindow); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+	}
+
+	if err := validateReadinessGates(newManagedCluster.Spec.ReadinessGates); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+	}
+
+	if err := validateClusterQuotas(ctx, v.Client, newManagedCluster, oldManagedCluster, template); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+	}
+
+	if err := validateConfigSections(template, &newManagedCluster.Spec); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+	}
+
+	if !equality.Semantic.DeepEqual(oldManagedCluster.Spec.Backup, newManagedCluster.Spec.Backup) {
+		if err := validateBackup(ctx, v.Client, newManagedCluster.Namespace, newManagedCluster.Spec.Backup); err != nil {
+			return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+		}
+	}
+
+	if !equality.Semantic.DeepEqual(oldManagedCluster.Spec.Autoscaler, newManagedCluster.Spec.Autoscaler) {
+		if err := validateAutoscaler(ctx, v.Client, newManagedCluster.Namespace, newManagedCluster.Spec.Autoscaler); err != nil {
+			return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+		}
+	}
+
+	if !equality.Semantic.DeepEqual(oldManagedCluster.Spec.DNS, newManagedCluster.Spec.DNS) {
+		if err := validateDNS(ctx, v.Client, newManagedCluster); err != nil {
+			return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+		}
+	}
+
+	if !equality.Semantic.DeepEqual(oldManagedCluster.Spec.Services, newManagedCluster.Spec.Services) {
+		if err := validateServiceTemplates(ctx, v.Client, newManagedCluster.Namespace, newManagedCluster.Spec.Services); err != nil {
+			return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
+		}
+
+		if oldTemplate == newTemplate {
+			if err := validateK8sCompatibility(ctx, v.Client, template, newManagedCluster); err != nil {
+				return admission.Warnings{"Failed to validate k8s version compatibility with ServiceTemplates"}, fmt.Errorf("failed to validate k8s compatibility: %v", err)
+			}
+		}
+	}
+
 	if err := v.validateCredential(ctx, newManagedCluster, template); err != nil {
 		return nil, fmt.Errorf("%s: %v", invalidManagedClusterMsg, err)
 	}
 
-	return nil, nil
+	return warnings, nil
+}
+
+// validateServiceTemplates checks that the ServiceTemplates of the enabled
+// services exist in the given namespace and are valid.
+func validateServiceTemplates(ctx context.Context, cl client.Client, namespace string, services []hmcv1alpha1.ServiceSpec) error {
+	for _, svc := range services {
+		if svc.Disable {
+			continue
+		}
+
+		svcTpl := new(hmcv1alpha1.ServiceTemplate)
+		if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: svc.Template}, svcTpl); err != nil {
+			if apierrors.IsNotFound(err) {
+				return fmt.Errorf("the ServiceTemplate %s/%s is not found", namespace, svc.Template)
+			}
+			return fmt.Errorf("failed to get ServiceTemplate %s/%s: %w", namespace, svc.Template, err)
+		}
+
+		if !svcTpl.Status.Valid {
+			return fmt.Errorf("the ServiceTemplate %s/%s is not valid: %s", namespace, svc.Template, svcTpl.Status.ValidationError)
+		}
+	}
+
+	return nil
+}
+
+// validateBackup validates the ServiceTemplate of the backup agent of the cluster.
+func validateBackup(ctx context.Context, cl client.Client, namespace string, backup *hmcv1alpha1.ManagedClusterBackupSpec) error {
+	if backup == nil {
+		return nil
+	}
+
+	return validateServiceTemplates(ctx, cl, namespace, []hmcv1alpha1.ServiceSpec{{
+		Template: backup.Template,
+		Name:     hmcv1alpha1.ManagedClusterBackupReleaseName,
+	}})
+}
+
+// validateDNS validates the DNS config of the cluster completed with the DNS config
+// of the Management and the ServiceTemplate of external-dns.
+func validateDNS(ctx context.Context, cl client.Client, mc *hmcv1alpha1.ManagedCluster) error {
+	if mc.Spec.DNS == nil {
+		return nil
+	}
+
+	mgmt := new(hmcv1alpha1.Management)
+	if err := cl.Get(ctx, client.ObjectKey{Name: hmcv1alpha1.ManagementName}, mgmt); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to get Management: %w", err)
+	}
+
+	config := mc.Spec.DNS.WithDefaults(mgmt.Spec.DNS)
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid DNS config: %w", err)
+	}
+
+	return validateServiceTemplates(ctx, cl, mc.Namespace, []hmcv1alpha1.ServiceSpec{{
+		Template: config.Template,
+		Name:     hmcv1alpha1.DNSReleaseName,
+	}})
+}
+
+// validateAutoscaler validates the ServiceTemplate of cluster-autoscaler of the cluster.
+func validateAutoscaler(ctx context.Context, cl client.Client, namespace string, autoscaler *hmcv1alpha1.ClusterAutoscalerSpec) error {
+	if autoscaler == nil {
+		return nil
+	}
+
+	return validateServiceTemplates(ctx, cl, namespace, []hmcv1alpha1.ServiceSpec{{
+		Template: autoscaler.Template,
+		Name:     hmcv1alpha1.AutoscalerReleaseName,
+	}})
+}
+
+// validateClusterMetadata validates the labels and the annotations propagated to the CAPI Cluster.
+func validateClusterMetadata(mc *hmcv1alpha1.ManagedCluster) error {
+	specPath := field.NewPath("spec")
+	errs := metav1validation.ValidateLabels(mc.Spec.ClusterLabels, specPath.Child("clusterLabels"))
+	errs = append(errs, apivalidation.ValidateAnnotations(mc.Spec.ClusterAnnotations, specPath.Child("clusterAnnotations"))...)
+	return errs.ToAggregate()
+}
+
+// validateNodePools validates the labels, the taints, the autoscaling and the images of the nodes of the worker pools.
+// The pools not applied by the template are rejected.
+func validateNodePools(template *hmcv1alpha1.ClusterTemplate, nodePools []hmcv1alpha1.NodePoolSpec) error {
+	if len(nodePools) == 0 {
+		return nil
+	}
+
+	supportedPools, known, err := templateNodePools(template)
+	if err != nil {
+		return err
+	}
+
+	var errs field.ErrorList
+	for i, pool := range nodePools {
+		poolPath := field.NewPath("spec", "nodePools").Index(i)
+		if known && !slices.Contains(supportedPools, pool.Name) {
+			if len(supportedPools) == 0 {
+				errs = append(errs, field.Forbidden(poolPath, fmt.Sprintf("the node pools are not applied by the ClusterTemplate %s", template.Name)))
+			} else {
+				errs = append(errs, field.NotSupported(poolPath.Child("name"), pool.Name, supportedPools))
+			}
+		}
+		errs = append(errs, metav1validation.ValidateLabels(pool.Labels, poolPath.Child("labels"))...)
+
+		for j, taint := range pool.Taints {
+			taintPath := poolPath.Child("taints").Index(j)
+			for _, msg := range validation.IsQualifiedName(taint.Key) {
+				errs = append(errs, field.Invalid(taintPath.Child("key"), taint.Key, msg))
+			}
+			if taint.Value != "" {
+				for _, msg := range validation.IsValidLabelValue(taint.Value) {
+					errs = append(errs, field.Invalid(taintPath.Child("value"), taint.Value, msg))
+				}
+			}
+			switch taint.Effect {
+			case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+			default:
+				errs = append(errs, field.NotSupported(taintPath.Child("effect"), taint.Effect, []corev1.TaintEffect{
+					corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute,
+				}))
+			}
+		}
+
+		if pool.Autoscaling != nil && pool.Autoscaling.MinSize > pool.Autoscaling.MaxSize {
+			errs = append(errs, field.Invalid(poolPath.Child("autoscaling", "minSize"), pool.Autoscaling.MinSize,
+				"must be less than or equal to maxSize"))
+		}
+
+		if image := pool.ImageRef; image != nil {
+			imagePath := poolPath.Child("imageRef")
+			switch {
+			case image.ID == "" && image.Marketplace == nil:
+				errs = append(errs, field.Required(imagePath, "either id or marketplace must be set"))
+			case image.ID != "" && image.Marketplace != nil:
+				errs = append(errs, field.Forbidden(imagePath.Child("marketplace"), "may not be set together with id"))
+			case template != nil && !helm.NodePoolImageApplied(template.Status.ConfigSections, pool):
+				errs = append(errs, field.Forbidden(imagePath, fmt.Sprintf("the image of the node pool is not applied by the ClusterTemplate %s", template.Name)))
+			}
+		}
+	}
+	return errs.ToAggregate()
+}
+
+// templateNodePools returns the names of the node pools applied by the template
+// declared in the nodePools properties of its values schema, known is false if
+// the template has no values schema.
+func templateNodePools(template *hmcv1alpha1.ClusterTemplate) (pools []string, known bool, _ error) {
+	if template == nil || template.Status.ConfigSchema == nil {
+		return nil, false, nil
+	}
+
+	var schema struct {
+		Properties struct {
+			NodePools struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"nodePools"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(template.Status.ConfigSchema.Raw, &schema); err != nil {
+		return nil, false, fmt.Errorf("failed to parse the values schema of the ClusterTemplate %s: %w", template.Name, err)
+	}
+
+	pools = make([]string, 0, len(schema.Properties.NodePools.Properties))
+	for name := range schema.Properties.NodePools.Properties {
+		pools = append(pools, name)
+	}
+	slices.Sort(pools)
+	return pools, true, nil
+}
+
+// validateMaintenanceWindow validates the time zone and the windows of the maintenance window.
+func validateMaintenanceWindow(window *hmcv1alpha1.MaintenanceWindow) error {
+	if window == nil {
+		return nil
+	}
+	return window.Validate()
+}
+
+// validateReadinessGates rejects the readiness gates referring to the conditions set by HMC,
+// the gates are satisfied by the external controllers only.
+func validateReadinessGates(gates []hmcv1alpha1.ReadinessGate) error {
+	var errs field.ErrorList
+	for i, gate := range gates {
+		if slices.Contains(hmcv1alpha1.ManagedClusterConditionTypes, gate.ConditionType) {
+			errs = append(errs, field.Invalid(field.NewPath("spec", "readinessGates").Index(i).Child("conditionType"), gate.ConditionType,
+				"the condition is set by HMC"))
+		}
+	}
+	return errs.ToAggregate()
+}
+
+// validateConfigSections rejects the typed config sections which
+// the template does not declare, hence can not apply.
+func validateConfigSections(template *hmcv1alpha1.ClusterTemplate, spec *hmcv1alpha1.ManagedClusterSpec) error {
+	unsupported := helm.UnsupportedConfigSections(template.Status.ConfigSections, spec)
+	if len(unsupported) == 0 {
+		return nil
+	}
+	return fmt.Errorf("the config sections %s are not supported by the ClusterTemplate %s", strings.Join(unsupported, ", "), template.Name)
 }
 
 func validateK8sCompatibility(ctx context.Context, cl client.Client, template *hmcv1alpha1.ClusterTemplate, mc *hmcv1alpha1.ManagedCluster) error {
@@ -127,11 +543,6 @@ func validateK8sCompatibility(ctx context.Context, cl client.Client, template *h
 		return nil // nothing to do
 	}
 
-	mcVersion, err := semver.NewVersion(template.Status.KubernetesVersion)
-	if err != nil { // should never happen
-		return fmt.Errorf("failed to parse k8s version %s of the ManagedCluster %s/%s: %w", template.Status.KubernetesVersion, mc.Namespace, mc.Name, err)
-	}
-
 	for _, v := range mc.Spec.Services {
 		if v.Disable {
 			continue
@@ -142,20 +553,15 @@ func validateK8sCompatibility(ctx context.Context, cl client.Client, template *h
 			return fmt.Errorf("failed to get ServiceTemplate %s/%s: %w", mc.Namespace, v.Template, err)
 		}
 
-		constraint := svcTpl.Status.KubernetesConstraint
-		if constraint == "" {
-			continue
-		}
-
-		tplConstraint, err := semver.NewConstraint(constraint)
+		compatible, err := svcTpl.IsCompatibleWith(template.Status.KubernetesVersion)
 		if err != nil { // should never happen
-			return fmt.Errorf("failed to parse k8s constrained version %s of the ServiceTemplate %s/%s: %w", constraint, mc.Namespace, v.Template, err)
+			return fmt.Errorf("failed to check k8s compatibility of the ManagedCluster %s/%s: %w", mc.Namespace, mc.Name, err)
 		}
 
-		if !tplConstraint.Check(mcVersion) {
+		if !compatible {
 			return fmt.Errorf("k8s version %s of the ManagedCluster %s/%s does not satisfy constrained version %s from the ServiceTemplate %s/%s",
 				template.Status.KubernetesVersion, mc.Namespace, mc.Name,
-				constraint, mc.Namespace, v.Template)
+				svcTpl.Status.KubernetesConstraint, mc.Namespace, v.Template)
 		}
 	}
 
@@ -174,6 +580,33 @@ func (v *ManagedClusterValidator) Default(ctx context.Context, obj runtime.Objec
 		return apierrors.NewBadRequest(fmt.Sprintf("expected ManagedCluster but got a %T", obj))
 	}
 
+	if managedCluster.Spec.CloneFrom != "" || managedCluster.Spec.Template == "" || managedCluster.Spec.Credential == "" {
+		req, err := admission.RequestFromContext(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get the admission request: %w", err)
+		}
+		if req.Operation == admissionv1.Create {
+			if managedCluster.Spec.CloneFrom != "" {
+				if err := v.cloneManagedCluster(ctx, managedCluster); err != nil {
+					return fmt.Errorf("failed to clone the ManagedCluster %s: %w", managedCluster.Spec.CloneFrom, err)
+				}
+			}
+			if err := v.setNamespaceDefaults(ctx, managedCluster); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := v.normalizeTemplate(ctx, managedCluster); err != nil {
+		return err
+	}
+
+	warnings, err := v.pruneConfig(ctx, managedCluster)
+	if err != nil {
+		return err
+	}
+	addDefaultingWarnings(ctx, warnings...)
+
 	// Only apply defaults when there's no configuration provided;
 	// if template ref is empty, then nothing to default
 	if managedCluster.Spec.Config != nil || managedCluster.Spec.Template == "" {
@@ -199,6 +632,223 @@ func (v *ManagedClusterValidator) Default(ctx context.Context, obj runtime.Objec
 	return nil
 }
 
+// normalizeTemplate trims and lowercases the name of the ClusterTemplate of the ManagedCluster
+// and resolves the "<chain>@latest" name to the latest ClusterTemplate of the ClusterTemplateChain.
+func (v *ManagedClusterValidator) normalizeTemplate(ctx context.Context, managedCluster *hmcv1alpha1.ManagedCluster) error {
+	name := strings.ToLower(strings.TrimSpace(managedCluster.Spec.Template))
+	chainName, isLatest := strings.CutSuffix(name, hmcv1alpha1.LatestTemplateSuffix)
+	if !isLatest {
+		managedCluster.Spec.Template = name
+		return nil
+	}
+
+	chain := new(hmcv1alpha1.ClusterTemplateChain)
+	if err := v.Get(ctx, client.ObjectKey{Namespace: managedCluster.Namespace, Name: chainName}, chain); err != nil {
+		return fmt.Errorf("failed to resolve the template %s: %w", name, err)
+	}
+	if !hmcv1alpha1.IsTemplateChainValid(&chain.Status) {
+		return fmt.Errorf("failed to resolve the template %s: the ClusterTemplateChain %s is invalid", name, chainName)
+	}
+
+	latest := chain.Spec.LatestTemplates()
+	switch len(latest) {
+	case 0:
+		return fmt.Errorf("failed to resolve the template %s: the ClusterTemplateChain %s has no latest template", name, chainName)
+	case 1:
+		managedCluster.Spec.Template = latest[0]
+		return nil
+	default:
+		return fmt.Errorf("failed to resolve the template %s: the ClusterTemplateChain %s has several latest templates: %s", name, chainName, strings.Join(latest, ", "))
+	}
+}
+
+// pruneConfig removes the values the values schema of the ClusterTemplate disallows from the config
+// of the ManagedCluster and returns the warning listing the paths of the removed values.
+// The missing template is left to be reported by the validation.
+func (v *ManagedClusterValidator) pruneConfig(ctx context.Context, managedCluster *hmcv1alpha1.ManagedCluster) (admission.Warnings, error) {
+	if managedCluster.Spec.Config == nil || managedCluster.Spec.Template == "" {
+		return nil, nil
+	}
+
+	template, err := v.getManagedClusterTemplate(ctx, managedCluster.Namespace, managedCluster.Spec.Template)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not get template for the managedcluster: %w", err)
+	}
+	if template.Status.ConfigSchema == nil {
+		return nil, nil
+	}
+
+	values, err := managedCluster.HelmValues()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the config: %w", err)
+	}
+	pruned, err := helm.PruneValues(template.Status.ConfigSchema.Raw, values)
+	if err != nil || len(pruned) == 0 {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the config: %w", err)
+	}
+	managedCluster.Spec.Config = &apiextensionsv1.JSON{Raw: raw}
+	return admission.Warnings{fmt.Sprintf("The config values disallowed by the ClusterTemplate %s are pruned: %s", template.Name, strings.Join(pruned, ", "))}, nil
+}
+
+// setNamespaceDefaults sets the Credential and the ClusterTemplate of the created ManagedCluster
+// to the ones marked as the defaults of the namespace unless they are set.
+func (v *ManagedClusterValidator) setNamespaceDefaults(ctx context.Context, managedCluster *hmcv1alpha1.ManagedCluster) error {
+	if managedCluster.Spec.Template == "" {
+		templates := new(hmcv1alpha1.ClusterTemplateList)
+		if err := v.List(ctx, templates, client.InNamespace(managedCluster.Namespace)); err != nil {
+			return fmt.Errorf("failed to list ClusterTemplates: %w", err)
+		}
+		var names []string
+		for _, template := range templates.Items {
+			if hmcv1alpha1.IsNamespaceDefault(&template) {
+				names = append(names, template.Name)
+			}
+		}
+		name, err := namespaceDefault(hmcv1alpha1.ClusterTemplateKind, managedCluster.Namespace, names)
+		if err != nil {
+			return err
+		}
+		managedCluster.Spec.Template = name
+	}
+
+	if managedCluster.Spec.Credential == "" {
+		credentials := new(hmcv1alpha1.CredentialList)
+		if err := v.List(ctx, credentials, client.InNamespace(managedCluster.Namespace)); err != nil {
+			return fmt.Errorf("failed to list Credentials: %w", err)
+		}
+		var names []string
+		for _, cred := range credentials.Items {
+			if hmcv1alpha1.IsNamespaceDefault(&cred) {
+				names = append(names, cred.Name)
+			}
+		}
+		name, err := namespaceDefault(hmcv1alpha1.CredentialKind, managedCluster.Namespace, names)
+		if err != nil {
+			return err
+		}
+		managedCluster.Spec.Credential = name
+	}
+
+	return nil
+}
+
+// namespaceDefault returns the only object of the kind marked as the default of the namespace.
+// Nothing is defaulted if no object is marked, several marked objects are ambiguous.
+func namespaceDefault(kind, namespace string, names []string) (string, error) {
+	switch len(names) {
+	case 0:
+		return "", nil
+	case 1:
+		return names[0], nil
+	}
+	slices.Sort(names)
+	return "", fmt.Errorf("several %ss are annotated as the default of the namespace %s: %s", kind, namespace, strings.Join(names, ", "))
+}
+
+// instanceSpecificConfigKeys are the top-level config values identifying a single cluster
+// which are not copied to the clones of the cluster.
+var instanceSpecificConfigKeys = []string{"clusterIdentity", "controlPlaneEndpointIP"}
+
+// cloneManagedCluster populates the fields of the spec of the ManagedCluster not set on the creation
+// from the spec of the ManagedCluster referenced by the CloneFrom.
+func (v *ManagedClusterValidator) cloneManagedCluster(ctx context.Context, managedCluster *hmcv1alpha1.ManagedCluster) error {
+	source := new(hmcv1alpha1.ManagedCluster)
+	if err := v.Get(ctx, client.ObjectKey{Namespace: managedCluster.Namespace, Name: managedCluster.Spec.CloneFrom}, source); err != nil {
+		return err
+	}
+
+	sourceConfig, err := source.HelmValues()
+	if err != nil {
+		return fmt.Errorf("failed to parse the config: %w", err)
+	}
+	for _, key := range instanceSpecificConfigKeys {
+		delete(sourceConfig, key)
+	}
+	config, err := managedCluster.HelmValues()
+	if err != nil {
+		return fmt.Errorf("failed to parse the config: %w", err)
+	}
+	merged, err := helm.MergeValues(hmcv1alpha1.ConfigMergeStrategyMerge, nil, sourceConfig, config)
+	if err != nil {
+		return err
+	}
+	if len(merged) > 0 {
+		raw, err := json.Marshal(merged)
+		if err != nil {
+			return fmt.Errorf("failed to marshal the config: %w", err)
+		}
+		managedCluster.Spec.Config = &apiextensionsv1.JSON{Raw: raw}
+	}
+
+	spec, sourceSpec := &managedCluster.Spec, source.Spec.DeepCopy()
+	if spec.Template == "" {
+		spec.Template = sourceSpec.Template
+	}
+	if spec.Credential == "" {
+		spec.Credential = sourceSpec.Credential
+	}
+	if spec.Services == nil {
+		spec.Services = sourceSpec.Services
+	}
+	if spec.ServicesDrainTimeout == nil {
+		spec.ServicesDrainTimeout = sourceSpec.ServicesDrainTimeout
+	}
+	if spec.PreDeleteCleanup == nil {
+		spec.PreDeleteCleanup = sourceSpec.PreDeleteCleanup
+	}
+	if spec.ReadinessGates == nil {
+		spec.ReadinessGates = sourceSpec.ReadinessGates
+	}
+	if spec.MaintenanceWindow == nil {
+		spec.MaintenanceWindow = sourceSpec.MaintenanceWindow
+	}
+	if spec.Backup == nil {
+		spec.Backup = sourceSpec.Backup
+	}
+	if spec.DNS == nil {
+		spec.DNS = sourceSpec.DNS
+	}
+	if spec.Autoscaler == nil {
+		spec.Autoscaler = sourceSpec.Autoscaler
+	}
+	if spec.ClusterLabels == nil {
+		spec.ClusterLabels = sourceSpec.ClusterLabels
+	}
+	if spec.ClusterAnnotations == nil {
+		spec.ClusterAnnotations = sourceSpec.ClusterAnnotations
+	}
+	if spec.NodePools == nil {
+		spec.NodePools = sourceSpec.NodePools
+	}
+	if spec.MachineHealthCheck == nil {
+		spec.MachineHealthCheck = sourceSpec.MachineHealthCheck
+	}
+	if spec.HelmRelease == nil {
+		spec.HelmRelease = sourceSpec.HelmRelease
+	}
+	if spec.ControlPlane == nil {
+		spec.ControlPlane = sourceSpec.ControlPlane
+	}
+	if spec.Workers == nil {
+		spec.Workers = sourceSpec.Workers
+	}
+	if spec.Network == nil {
+		spec.Network = sourceSpec.Network
+	}
+	if spec.SSHKey == "" {
+		spec.SSHKey = sourceSpec.SSHKey
+	}
+	return nil
+}
+
 func (v *ManagedClusterValidator) getManagedClusterTemplate(ctx context.Context, templateNamespace, templateName string) (tpl *hmcv1alpha1.ClusterTemplate, err error) {
 	tpl = new(hmcv1alpha1.ClusterTemplate)
 	return tpl, v.Get(ctx, client.ObjectKey{Namespace: templateNamespace, Name: templateName}, tpl)
@@ -216,6 +866,54 @@ func (v *ManagedClusterValidator) getManagedClusterCredential(ctx context.Contex
 	return cred, nil
 }
 
+// validateProvidersEnabled checks that all of the providers required by the template are enabled in the Management.
+func (v *ManagedClusterValidator) validateProvidersEnabled(ctx context.Context, template *hmcv1alpha1.ClusterTemplate) error {
+	mgmt := &hmcv1alpha1.Management{}
+	if err := v.Get(ctx, client.ObjectKey{Name: hmcv1alpha1.ManagementName}, mgmt); err != nil {
+		return fmt.Errorf("failed to get Management: %w", err)
+	}
+
+	if missing := mgmt.MissingProviders(template.Status.Providers); len(missing) > 0 {
+		return fmt.Errorf("the providers required by the template %q are not enabled in the Management: %s", template.Name, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// validateTemplatesDeprecation forbids the use of the sunset ClusterTemplate and
+// ServiceTemplates of the enabled services and warns about the deprecated ones.
+func validateTemplatesDeprecation(ctx context.Context, cl client.Client, template *hmcv1alpha1.ClusterTemplate, services []hmcv1alpha1.ServiceSpec) (admission.Warnings, error) {
+	now := time.Now()
+	if template.Spec.IsSunset(now) {
+		return nil, errors.New(template.Spec.DeprecationMessage(hmcv1alpha1.ClusterTemplateKind, template.Name, now))
+	}
+
+	var warnings admission.Warnings
+	if template.Spec.IsDeprecated() {
+		warnings = append(warnings, template.Spec.DeprecationMessage(hmcv1alpha1.ClusterTemplateKind, template.Name, now))
+	}
+
+	for _, svc := range services {
+		if svc.Disable {
+			continue
+		}
+
+		svcTpl := new(hmcv1alpha1.ServiceTemplate)
+		if err := cl.Get(ctx, client.ObjectKey{Namespace: template.Namespace, Name: svc.Template}, svcTpl); err != nil {
+			return nil, fmt.Errorf("failed to get ServiceTemplate %s/%s: %w", template.Namespace, svc.Template, err)
+		}
+
+		if svcTpl.Spec.IsSunset(now) {
+			return nil, errors.New(svcTpl.Spec.DeprecationMessage(hmcv1alpha1.ServiceTemplateKind, svcTpl.Name, now))
+		}
+		if svcTpl.Spec.IsDeprecated() {
+			warnings = append(warnings, svcTpl.Spec.DeprecationMessage(hmcv1alpha1.ServiceTemplateKind, svcTpl.Name, now))
+		}
+	}
+
+	return warnings, nil
+}
+
 func isTemplateValid(template *hmcv1alpha1.ClusterTemplate) error {
 	if !template.Status.Valid {
 		return fmt.Errorf("the template is not valid: %s", template.Status.ValidationError)
@@ -250,7 +948,37 @@ func (v *ManagedClusterValidator) validateCredential(ctx context.Context, manage
 		return errors.New("credential is not Ready")
 	}
 
-	return isCredMatchTemplate(cred, template)
+	if err := isCredMatchTemplate(cred, template); err != nil {
+		return err
+	}
+	return v.validateIdentityCredentials(ctx, managedCluster, template)
+}
+
+// validateIdentityCredentials checks the Credentials of the identity values are ready and match the template.
+func (v *ManagedClusterValidator) validateIdentityCredentials(ctx context.Context, managedCluster *hmcv1alpha1.ManagedCluster, template *hmcv1alpha1.ClusterTemplate) error {
+	paths := make([]string, 0, len(managedCluster.Spec.IdentityCredentials))
+	for path := range managedCluster.Spec.IdentityCredentials {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	for _, path := range paths {
+		if !hmcv1alpha1.IsIdentityValuesPath(path) {
+			return fmt.Errorf("invalid identity values path %q, expected the dot-separated keys", path)
+		}
+		name := managedCluster.Spec.IdentityCredentials[path]
+		cred, err := v.getManagedClusterCredential(ctx, managedCluster.Namespace, name)
+		if err != nil {
+			return fmt.Errorf("failed to get the credential %s of the identity value %s: %w", name, path, err)
+		}
+		if cred.Status.State != hmcv1alpha1.CredentialReady {
+			return fmt.Errorf("the credential %s of the identity value %s is not Ready", name, path)
+		}
+		if err := isCredMatchTemplate(cred, template); err != nil {
+			return fmt.Errorf("the credential %s of the identity value %s: %w", name, path, err)
+		}
+	}
+	return nil
 }
 
 func isCredMatchTemplate(cred *hmcv1alpha1.Credential, template *hmcv1alpha1.ClusterTemplate) error {
@@ -16,13 +16,18 @@ package webhook
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
@@ -31,6 +36,7 @@ import (
 	"github.com/Mirantis/hmc/test/objects/managedcluster"
 	"github.com/Mirantis/hmc/test/objects/management"
 	"github.com/Mirantis/hmc/test/objects/template"
+	tc "github.com/Mirantis/hmc/test/objects/templatechain"
 	"github.com/Mirantis/hmc/test/scheme"
 )
 
@@ -69,6 +75,8 @@ func TestManagedClusterValidateCreate(t *testing.T) {
 		},
 	})
 
+	sunsetDate := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
 	tests := []struct {
 		name            string
 		managedCluster  *v1alpha1.ManagedCluster
@@ -82,8 +90,401 @@ func TestManagedClusterValidateCreate(t *testing.T) {
 			err:            "the ManagedCluster is invalid: clustertemplates.hmc.mirantis.com \"\" not found",
 		},
 		{
-			name: "should fail if the ClusterTemplate is not found in the ManagedCluster's namespace",
+			name: "should fail if the ClusterTemplate is not found in the ManagedCluster's namespace",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithNamespace(testNamespace),
+				),
+			},
+			err: fmt.Sprintf("the ManagedCluster is invalid: clustertemplates.hmc.mirantis.com \"%s\" not found", testTemplateName),
+		},
+		{
+			name: "should fail if the cluster template was found but is invalid (some validation error)",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{
+						Valid:           false,
+						ValidationError: "validation error example",
+					}),
+				),
+			},
+			err: "the ManagedCluster is invalid: the template is not valid: validation error example",
+		},
+		{
+			name: "should succeed",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithProvidersStatus(v1alpha1.Providers{
+						"infrastructure-aws",
+						"control-plane-k0smotron",
+						"bootstrap-k0smotron",
+					}),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+		},
+		{
+			name: "should fail if the ClusterTemplate is sunset",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithDeprecation(v1alpha1.TemplateDeprecation{SunsetDate: &sunsetDate}),
+					template.WithProvidersStatus(v1alpha1.Providers{
+						"infrastructure-aws",
+						"control-plane-k0smotron",
+						"bootstrap-k0smotron",
+					}),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+			err: fmt.Sprintf("the ManagedCluster is invalid: ClusterTemplate %s is sunset since 2024-01-01T00:00:00Z", testTemplateName),
+		},
+		{
+			name: "should succeed with a warning if the ClusterTemplate is deprecated",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithDeprecation(v1alpha1.TemplateDeprecation{Deprecated: true}),
+					template.WithProvidersStatus(v1alpha1.Providers{
+						"infrastructure-aws",
+						"control-plane-k0smotron",
+						"bootstrap-k0smotron",
+					}),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+			warnings: admission.Warnings{fmt.Sprintf("ClusterTemplate %s is deprecated", testTemplateName)},
+		},
+		{
+			name: "should fail if the config sections are not supported by the ClusterTemplate",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithCredential(testCredentialName),
+				managedcluster.WithWorkerPool(v1alpha1.WorkerPoolConfig{Name: "worker", Replicas: ptr.To[int32](3)}),
+				managedcluster.WithWorkerPool(v1alpha1.WorkerPoolConfig{Name: "gpu", Replicas: ptr.To[int32](1)}),
+				managedcluster.WithSSHKey("key"),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithClusterStatusConfigSections(map[string][]string{
+						"spec.workers[worker].replicas": {"workersNumber"},
+						"spec.sshKey":                   {"sshKeyName"},
+					}),
+					template.WithProvidersStatus(v1alpha1.Providers{
+						"infrastructure-aws",
+						"control-plane-k0smotron",
+						"bootstrap-k0smotron",
+					}),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+			err: fmt.Sprintf("the ManagedCluster is invalid: the config sections spec.workers[gpu].replicas are not supported by the ClusterTemplate %s", testTemplateName),
+		},
+		{
+			name: "should fail if the ServiceTemplate is not found",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithServiceTemplate(testTemplateName),
+				managedcluster.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+			err: fmt.Sprintf("the ManagedCluster is invalid: the ServiceTemplate default/%s is not found", testTemplateName),
+		},
+		{
+			name: "should fail if the ServiceTemplate of the backup agent is not found",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithBackup(&v1alpha1.ManagedClusterBackupSpec{
+					Template: "velero",
+					Schedule: "@daily",
+				}),
+				managedcluster.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+			err: "the ManagedCluster is invalid: the ServiceTemplate default/velero is not found",
+		},
+		{
+			name: "should fail if the DNS zone is not set",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithDNS(&v1alpha1.DNSConfig{
+					Provider:          "aws",
+					CredentialsSecret: "route53",
+					Template:          "external-dns",
+				}),
+				managedcluster.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+			err: "the ManagedCluster is invalid: invalid DNS config: the DNS zone is not set",
+		},
+		{
+			name: "should fail if the cluster labels are invalid",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithClusterLabels(map[string]string{"env": "prod!"}),
+				managedcluster.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+			err: `the ManagedCluster is invalid: spec.clusterLabels: Invalid value: "prod!": a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')`,
+		},
+		{
+			name: "should fail if the readiness gates refer to the conditions set by HMC",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithReadinessGates("ValidationPipelinePassed", v1alpha1.HelmReleaseReadyCondition, v1alpha1.ReadyCondition),
+				managedcluster.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+			err: `the ManagedCluster is invalid: [spec.readinessGates[1].conditionType: Invalid value: "HelmReleaseReady": the condition is set by HMC, spec.readinessGates[2].conditionType: Invalid value: "Ready": the condition is set by HMC]`,
+		},
+		{
+			name: "should fail if the node pool taint is invalid",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithNodePool(v1alpha1.NodePoolSpec{
+					Name:   "worker",
+					Labels: map[string]string{"example.com/pool": "gpu"},
+					Taints: []corev1.Taint{{Key: "gpu", Value: "true", Effect: "NoRun"}},
+				}),
+				managedcluster.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+			err: `the ManagedCluster is invalid: spec.nodePools[0].taints[0].effect: Unsupported value: "NoRun": supported values: "NoSchedule", "PreferNoSchedule", "NoExecute"`,
+		},
+		{
+			name: "should fail if the node pool is not applied by the template",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithNodePool(v1alpha1.NodePoolSpec{Name: "gpu", Labels: map[string]string{"example.com/pool": "gpu"}}),
+				managedcluster.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+					template.WithConfigSchemaStatus(`{"properties":{"nodePools":{"type":"object","properties":{"worker":{"type":"object"}}}}}`),
+				),
+			},
+			err: `the ManagedCluster is invalid: spec.nodePools[0].name: Unsupported value: "gpu": supported values: "worker"`,
+		},
+		{
+			name: "should fail if the template does not apply the node pools",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithNodePool(v1alpha1.NodePoolSpec{Name: "worker", Labels: map[string]string{"example.com/pool": "gpu"}}),
+				managedcluster.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+					template.WithConfigSchemaStatus(`{"properties":{"nodePools":{"type":"object"}}}`),
+				),
+			},
+			err: `the ManagedCluster is invalid: spec.nodePools[0]: Forbidden: the node pools are not applied by the ClusterTemplate ` + testTemplateName,
+		},
+		{
+			name: "should fail if the node pool autoscaling minimum exceeds the maximum",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithNodePool(v1alpha1.NodePoolSpec{
+					Name:        "worker",
+					Autoscaling: &v1alpha1.NodePoolAutoscaling{MinSize: 5, MaxSize: 3},
+				}),
+				managedcluster.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+			err: "the ManagedCluster is invalid: spec.nodePools[0].autoscaling.minSize: Invalid value: 5: must be less than or equal to maxSize",
+		},
+		{
+			name: "should fail if the node pool image sets both the id and the marketplace image",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithNodePool(v1alpha1.NodePoolSpec{
+					Name: "worker",
+					ImageRef: &v1alpha1.ImageRef{
+						ID:          "ami-1",
+						Marketplace: &v1alpha1.MarketplaceImage{Publisher: "pub", Offer: "capi", SKU: "sku", Version: "1.0.0"},
+					},
+				}),
+				managedcluster.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+			err: "the ManagedCluster is invalid: spec.nodePools[0].imageRef.marketplace: Forbidden: may not be set together with id",
+		},
+		{
+			name: "should fail if the node pool image is not applied by the ClusterTemplate",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithNodePool(v1alpha1.NodePoolSpec{Name: "worker", ImageRef: &v1alpha1.ImageRef{ID: "ami-1"}}),
+				managedcluster.WithNodePool(v1alpha1.NodePoolSpec{Name: "infra", ImageRef: &v1alpha1.ImageRef{ID: "ami-2"}}),
+				managedcluster.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+					template.WithConfigSchemaStatus(`{"properties":{"nodePools":{"type":"object","properties":{"worker":{"type":"object"},"infra":{"type":"object"}}}}}`),
+					template.WithClusterStatusConfigSections(map[string][]string{
+						"spec.nodePools[worker].imageRef.id": {"worker.amiID"},
+					}),
+				),
+			},
+			err: "the ManagedCluster is invalid: spec.nodePools[1].imageRef: Forbidden: the image of the node pool is not applied by the ClusterTemplate " + testTemplateName,
+		},
+		{
+			name: "should fail if the ServiceTemplate is invalid",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithServiceTemplate(testTemplateName),
+				managedcluster.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+				template.NewServiceTemplate(
+					template.WithName(testTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{
+						Valid:           false,
+						ValidationError: "validation error example",
+					}),
+				),
+			},
+			err: fmt.Sprintf("the ManagedCluster is invalid: the ServiceTemplate default/%s is not valid: validation error example", testTemplateName),
+		},
+		{
+			name: "cluster template k8s version does not satisfy service template constraints",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithServiceTemplate(testTemplateName),
+			),
+			existingObjects: []runtime.Object{
+				cred,
+				management.NewManagement(management.WithAvailableProviders(v1alpha1.Providers{
+					"infrastructure-aws",
+					"control-plane-k0smotron",
+					"bootstrap-k0smotron",
+				})),
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+					template.WithClusterStatusK8sVersion("v1.30.0"),
+				),
+				template.NewServiceTemplate(
+					template.WithName(testTemplateName),
+					template.WithServiceK8sConstraint("<1.30"),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+			err:      fmt.Sprintf(`failed to validate k8s compatibility: k8s version v1.30.0 of the ManagedCluster default/%s does not satisfy constrained version <1.30 from the ServiceTemplate default/%s`, managedcluster.DefaultName, testTemplateName),
+			warnings: admission.Warnings{"Failed to validate k8s version compatibility with ServiceTemplates"},
+		},
+		{
+			name: "should fail if the cluster name is not a valid DNS-1123 label",
 			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithName("cluster.name"),
 				managedcluster.WithClusterTemplate(testTemplateName),
 				managedcluster.WithCredential(testCredentialName),
 			),
@@ -92,14 +493,15 @@ func TestManagedClusterValidateCreate(t *testing.T) {
 				cred,
 				template.NewClusterTemplate(
 					template.WithName(testTemplateName),
-					template.WithNamespace(testNamespace),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
 				),
 			},
-			err: fmt.Sprintf("the ManagedCluster is invalid: clustertemplates.hmc.mirantis.com \"%s\" not found", testTemplateName),
+			err: `the ManagedCluster is invalid: cluster name "cluster.name" is invalid: must not contain dots`,
 		},
 		{
-			name: "should fail if the cluster template was found but is invalid (some validation error)",
+			name: "should fail if the cluster name exceeds the provider limits",
 			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithName("a-very-long-cluster-name-for-aws-provider"),
 				managedcluster.WithClusterTemplate(testTemplateName),
 				managedcluster.WithCredential(testCredentialName),
 			),
@@ -108,16 +510,18 @@ func TestManagedClusterValidateCreate(t *testing.T) {
 				cred,
 				template.NewClusterTemplate(
 					template.WithName(testTemplateName),
-					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{
-						Valid:           false,
-						ValidationError: "validation error example",
+					template.WithProvidersStatus(v1alpha1.Providers{
+						"infrastructure-aws",
+						"control-plane-k0smotron",
+						"bootstrap-k0smotron",
 					}),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
 				),
 			},
-			err: "the ManagedCluster is invalid: the template is not valid: validation error example",
+			err: `the ManagedCluster is invalid: cluster name "a-very-long-cluster-name-for-aws-provider" is too long for provider infrastructure-aws: must be no more than 32 characters (AWS load balancer names are derived from the cluster name and limited to 32 characters)`,
 		},
 		{
-			name: "should succeed",
+			name: "should fail if the template providers are not enabled in the Management",
 			managedCluster: managedcluster.NewManagedCluster(
 				managedcluster.WithClusterTemplate(testTemplateName),
 				managedcluster.WithCredential(testCredentialName),
@@ -128,40 +532,57 @@ func TestManagedClusterValidateCreate(t *testing.T) {
 				template.NewClusterTemplate(
 					template.WithName(testTemplateName),
 					template.WithProvidersStatus(v1alpha1.Providers{
-						"infrastructure-aws",
+						"infrastructure-azure",
 						"control-plane-k0smotron",
 						"bootstrap-k0smotron",
 					}),
 					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
 				),
 			},
+			err: fmt.Sprintf(`the ManagedCluster is invalid: the providers required by the template %q are not enabled in the Management: infrastructure-azure`, testTemplateName),
 		},
 		{
-			name: "cluster template k8s version does not satisfy service template constraints",
+			name: "should fail if the ClusterTemplate is not allowed by the ClusterQuota",
 			managedCluster: managedcluster.NewManagedCluster(
 				managedcluster.WithClusterTemplate(testTemplateName),
-				managedcluster.WithServiceTemplate(testTemplateName),
+				managedcluster.WithCredential(testCredentialName),
 			),
 			existingObjects: []runtime.Object{
+				mgmt,
 				cred,
-				management.NewManagement(management.WithAvailableProviders(v1alpha1.Providers{
-					"infrastructure-aws",
-					"control-plane-k0smotron",
-					"bootstrap-k0smotron",
-				})),
 				template.NewClusterTemplate(
 					template.WithName(testTemplateName),
+					template.WithProvidersStatus(v1alpha1.Providers{"infrastructure-aws"}),
 					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
-					template.WithClusterStatusK8sVersion("v1.30.0"),
 				),
-				template.NewServiceTemplate(
+				&v1alpha1.ClusterQuota{
+					ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: managedcluster.DefaultNamespace},
+					Spec:       v1alpha1.ClusterQuotaSpec{AllowedTemplates: []string{"azure-*"}},
+				},
+			},
+			err: fmt.Sprintf("the ManagedCluster is invalid: the ClusterTemplate %s is not allowed by the ClusterQuota quota", testTemplateName),
+		},
+		{
+			name: "should fail if the ClusterQuota is exceeded",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
 					template.WithName(testTemplateName),
-					template.WithServiceK8sConstraint("<1.30"),
+					template.WithProvidersStatus(v1alpha1.Providers{"infrastructure-aws"}),
 					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
 				),
+				managedcluster.NewManagedCluster(managedcluster.WithName("existing")),
+				&v1alpha1.ClusterQuota{
+					ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: managedcluster.DefaultNamespace},
+					Spec:       v1alpha1.ClusterQuotaSpec{MaxClusters: ptr.To[int32](1)},
+				},
 			},
-			err:      fmt.Sprintf(`failed to validate k8s compatibility: k8s version v1.30.0 of the ManagedCluster default/%s does not satisfy constrained version <1.30 from the ServiceTemplate default/%s`, managedcluster.DefaultName, testTemplateName),
-			warnings: admission.Warnings{"Failed to validate k8s version compatibility with ServiceTemplates"},
+			err: "the ManagedCluster is invalid: the ClusterQuota quota is exceeded: 2 clusters exceed the limit of 1",
 		},
 		{
 			name:           "should fail if the credential is unset",
@@ -209,6 +630,59 @@ func TestManagedClusterValidateCreate(t *testing.T) {
 			},
 			err: "the ManagedCluster is invalid: credential is not Ready",
 		},
+		{
+			name: "should fail if the credential of an identity value is not Ready",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithCredential(testCredentialName),
+				managedcluster.WithIdentityCredential("controlPlane.identityRef", "cp-cred"),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				credential.NewCredential(
+					credential.WithName("cp-cred"),
+					credential.WithState(v1alpha1.CredentialNotFound),
+					credential.WithIdentityRef(
+						&corev1.ObjectReference{
+							Kind: "AWSClusterRoleIdentity",
+							Name: "cp-role",
+						}),
+				),
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithProvidersStatus(v1alpha1.Providers{
+						"infrastructure-aws",
+						"control-plane-k0smotron",
+						"bootstrap-k0smotron",
+					}),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+			err: "the ManagedCluster is invalid: the credential cp-cred of the identity value controlPlane.identityRef is not Ready",
+		},
+		{
+			name: "should fail if the identity values path is invalid",
+			managedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithCredential(testCredentialName),
+				managedcluster.WithIdentityCredential("controlPlane..identityRef", testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithProvidersStatus(v1alpha1.Providers{
+						"infrastructure-aws",
+						"control-plane-k0smotron",
+						"bootstrap-k0smotron",
+					}),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+			err: "the ManagedCluster is invalid: invalid identity values path \"controlPlane..identityRef\", expected the dot-separated keys",
+		},
 		{
 			name: "should fail if credential and template providers doesn't match",
 			managedCluster: managedcluster.NewManagedCluster(
@@ -297,6 +771,22 @@ func TestManagedClusterValidateUpdate(t *testing.T) {
 			},
 			err: "the ManagedCluster is invalid: the template is not valid: validation error example",
 		},
+		{
+			name:              "should fail if the readiness gates refer to the conditions set by HMC",
+			oldManagedCluster: managedcluster.NewManagedCluster(managedcluster.WithClusterTemplate(testTemplateName)),
+			newManagedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithReadinessGates(v1alpha1.ServicesK8sCompatibleCondition),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+			err: `the ManagedCluster is invalid: spec.readinessGates[0].conditionType: Invalid value: "ServicesK8sCompatible": the condition is set by HMC`,
+		},
 		{
 			name: "update spec.template: should fail if the template is not in the list of available",
 			oldManagedCluster: managedcluster.NewManagedCluster(
@@ -332,6 +822,32 @@ func TestManagedClusterValidateUpdate(t *testing.T) {
 			warnings: admission.Warnings{fmt.Sprintf("Cluster can't be upgraded from %s to %s. This upgrade sequence is not allowed", testTemplateName, upgradeTargetTemplateName)},
 			err:      "cluster upgrade is forbidden",
 		},
+		{
+			name: "update spec.template: should succeed with a warning if the template is not in the list of available but the override annotation is set",
+			oldManagedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithCredential(testCredentialName),
+				managedcluster.WithAvailableUpgrades([]string{}),
+			),
+			newManagedCluster: managedcluster.NewManagedCluster(
+				managedcluster.WithClusterTemplate(upgradeTargetTemplateName),
+				managedcluster.WithCredential(testCredentialName),
+				managedcluster.WithAnnotations(map[string]string{v1alpha1.AllowTemplateChangeAnnotation: "true"}),
+			),
+			existingObjects: []runtime.Object{
+				mgmt, cred,
+				template.NewClusterTemplate(
+					template.WithName(upgradeTargetTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+					template.WithProvidersStatus(v1alpha1.Providers{
+						"infrastructure-aws",
+						"control-plane-k0smotron",
+						"bootstrap-k0smotron",
+					}),
+				),
+			},
+			warnings: admission.Warnings{fmt.Sprintf("Cluster can't be upgraded from %s to %s. This upgrade sequence is not allowed, proceeding since the %s annotation is set", testTemplateName, upgradeTargetTemplateName, v1alpha1.AllowTemplateChangeAnnotation)},
+		},
 		{
 			name: "update spec.template: should succeed if the template is in the list of available",
 			oldManagedCluster: managedcluster.NewManagedCluster(
@@ -417,7 +933,7 @@ func TestManagedClusterValidateUpdate(t *testing.T) {
 func TestManagedClusterDefault(t *testing.T) {
 	g := NewWithT(t)
 
-	ctx := context.Background()
+	ctx := admission.NewContextWithRequest(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Create}})
 
 	managedClusterConfig := `{"foo":"bar"}`
 
@@ -497,3 +1013,282 @@ func TestManagedClusterDefault(t *testing.T) {
 		})
 	}
 }
+
+func TestManagedClusterDefaultNamespaceDefaults(t *testing.T) {
+	g := NewWithT(t)
+
+	createCtx := admission.NewContextWithRequest(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Create}})
+	updateCtx := admission.NewContextWithRequest(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Update}})
+
+	namespaceDefault := map[string]string{v1alpha1.NamespaceDefaultAnnotation: "true"}
+	validStatus := template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true})
+	existingObjects := []runtime.Object{
+		template.NewClusterTemplate(template.WithName(testTemplateName), template.WithAnnotations(namespaceDefault), validStatus),
+		template.NewClusterTemplate(template.WithName("other-template"), validStatus),
+		template.NewClusterTemplate(template.WithName("foreign-template"), template.WithNamespace("other"), template.WithAnnotations(namespaceDefault)),
+		credential.NewCredential(credential.WithName(testCredentialName), credential.WithAnnotations(namespaceDefault)),
+		credential.NewCredential(credential.WithName("other-cred")),
+	}
+
+	tests := []struct {
+		name            string
+		ctx             context.Context
+		input           *v1alpha1.ManagedCluster
+		output          *v1alpha1.ManagedCluster
+		existingObjects []runtime.Object
+		err             string
+	}{
+		{
+			name:            "should set the template and the credential marked as the namespace defaults",
+			ctx:             createCtx,
+			input:           managedcluster.NewManagedCluster(),
+			output:          managedcluster.NewManagedCluster(managedcluster.WithClusterTemplate(testTemplateName), managedcluster.WithCredential(testCredentialName)),
+			existingObjects: existingObjects,
+		},
+		{
+			name:            "should keep the template and the credential set on the creation",
+			ctx:             createCtx,
+			input:           managedcluster.NewManagedCluster(managedcluster.WithClusterTemplate("other-template"), managedcluster.WithCredential("other-cred")),
+			output:          managedcluster.NewManagedCluster(managedcluster.WithClusterTemplate("other-template"), managedcluster.WithCredential("other-cred")),
+			existingObjects: existingObjects,
+		},
+		{
+			name:   "should not set anything if no objects are marked as the namespace defaults",
+			ctx:    createCtx,
+			input:  managedcluster.NewManagedCluster(),
+			output: managedcluster.NewManagedCluster(),
+		},
+		{
+			name:            "should not set the namespace defaults on update",
+			ctx:             updateCtx,
+			input:           managedcluster.NewManagedCluster(managedcluster.WithClusterTemplate(testTemplateName)),
+			output:          managedcluster.NewManagedCluster(managedcluster.WithClusterTemplate(testTemplateName)),
+			existingObjects: existingObjects,
+		},
+		{
+			name:   "should fail if several credentials are marked as the namespace defaults",
+			ctx:    createCtx,
+			input:  managedcluster.NewManagedCluster(managedcluster.WithClusterTemplate(testTemplateName)),
+			output: managedcluster.NewManagedCluster(managedcluster.WithClusterTemplate(testTemplateName)),
+			existingObjects: append(slices.Clone(existingObjects),
+				credential.NewCredential(credential.WithName("second-cred"), credential.WithAnnotations(namespaceDefault))),
+			err: "several Credentials are annotated as the default of the namespace default: " + testCredentialName + ", second-cred",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(_ *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(tt.existingObjects...).Build()
+			validator := &ManagedClusterValidator{Client: c}
+			err := validator.Default(tt.ctx, tt.input)
+			if tt.err != "" {
+				g.Expect(err).To(MatchError(tt.err))
+			} else {
+				g.Expect(err).To(Succeed())
+			}
+			g.Expect(tt.input).To(Equal(tt.output))
+		})
+	}
+}
+
+func TestManagedClusterDefaultNormalize(t *testing.T) {
+	g := NewWithT(t)
+
+	ctx := admission.NewContextWithRequest(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Update}})
+	validStatus := template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true})
+	existingObjects := []runtime.Object{
+		template.NewClusterTemplate(template.WithName("aws-0-0-1"), validStatus),
+		template.NewClusterTemplate(
+			template.WithName("aws-0-0-2"),
+			validStatus,
+			template.WithConfigSchemaStatus(`{"type":"object","additionalProperties":false,"properties":{"region":{"type":"string"}}}`),
+		),
+		tc.NewClusterTemplateChain(
+			tc.WithName("aws"),
+			tc.WithNamespace(managedcluster.DefaultNamespace),
+			tc.WithSupportedTemplates([]v1alpha1.SupportedTemplate{
+				{Name: "aws-0-0-1", AvailableUpgrades: []v1alpha1.AvailableUpgrade{{Name: "aws-0-0-2"}}},
+				{Name: "aws-0-0-2"},
+			}),
+		),
+		tc.NewClusterTemplateChain(
+			tc.WithName("ambiguous"),
+			tc.WithNamespace(managedcluster.DefaultNamespace),
+			tc.WithSupportedTemplates([]v1alpha1.SupportedTemplate{{Name: "aws-0-0-1"}, {Name: "aws-0-0-2"}}),
+		),
+	}
+
+	tests := []struct {
+		name     string
+		input    *v1alpha1.ManagedCluster
+		output   *v1alpha1.ManagedCluster
+		err      string
+		warnings admission.Warnings
+	}{
+		{
+			name:   "should normalize the template name",
+			input:  managedcluster.NewManagedCluster(managedcluster.WithClusterTemplate(" AWS-0-0-1 ")),
+			output: managedcluster.NewManagedCluster(managedcluster.WithClusterTemplate("aws-0-0-1")),
+		},
+		{
+			name:   "should resolve the latest template of the chain",
+			input:  managedcluster.NewManagedCluster(managedcluster.WithClusterTemplate("aws@latest"), managedcluster.WithConfig(`{"region":"us-east-2"}`)),
+			output: managedcluster.NewManagedCluster(managedcluster.WithClusterTemplate("aws-0-0-2"), managedcluster.WithConfig(`{"region":"us-east-2"}`)),
+		},
+		{
+			name:   "should fail if the chain has several latest templates",
+			input:  managedcluster.NewManagedCluster(managedcluster.WithClusterTemplate("ambiguous@latest")),
+			output: managedcluster.NewManagedCluster(managedcluster.WithClusterTemplate("ambiguous@latest")),
+			err:    "failed to resolve the template ambiguous@latest: the ClusterTemplateChain ambiguous has several latest templates: aws-0-0-1, aws-0-0-2",
+		},
+		{
+			name:   "should fail if the chain does not exist",
+			input:  managedcluster.NewManagedCluster(managedcluster.WithClusterTemplate("missing@latest")),
+			output: managedcluster.NewManagedCluster(managedcluster.WithClusterTemplate("missing@latest")),
+			err:    `failed to resolve the template missing@latest: clustertemplatechains.hmc.mirantis.com "missing" not found`,
+		},
+		{
+			name:     "should prune the config values disallowed by the template schema",
+			input:    managedcluster.NewManagedCluster(managedcluster.WithClusterTemplate("aws-0-0-2"), managedcluster.WithConfig(`{"region":"us-east-2","regoin":"us-west-2"}`)),
+			output:   managedcluster.NewManagedCluster(managedcluster.WithClusterTemplate("aws-0-0-2"), managedcluster.WithConfig(`{"region":"us-east-2"}`)),
+			warnings: admission.Warnings{"The config values disallowed by the ClusterTemplate aws-0-0-2 are pruned: regoin"},
+		},
+		{
+			name:   "should not prune the config without the template schema",
+			input:  managedcluster.NewManagedCluster(managedcluster.WithClusterTemplate("aws-0-0-1"), managedcluster.WithConfig(`{"regoin":"us-west-2"}`)),
+			output: managedcluster.NewManagedCluster(managedcluster.WithClusterTemplate("aws-0-0-1"), managedcluster.WithConfig(`{"regoin":"us-west-2"}`)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(_ *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(existingObjects...).Build()
+			validator := &ManagedClusterValidator{Client: c}
+			var warnings admission.Warnings
+			err := validator.Default(context.WithValue(ctx, defaultingWarningsKey{}, &warnings), tt.input)
+			if tt.err != "" {
+				g.Expect(err).To(MatchError(tt.err))
+			} else {
+				g.Expect(err).To(Succeed())
+			}
+			g.Expect(tt.input).To(Equal(tt.output))
+			g.Expect(warnings).To(Equal(tt.warnings))
+		})
+	}
+}
+
+func TestWarningDefaulter(t *testing.T) {
+	g := NewWithT(t)
+
+	existingObjects := []runtime.Object{
+		template.NewClusterTemplate(
+			template.WithName("aws-0-0-2"),
+			template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+			template.WithConfigSchemaStatus(`{"type":"object","additionalProperties":false,"properties":{"region":{"type":"string"}}}`),
+		),
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(existingObjects...).Build()
+	handler := &warningDefaulter{Handler: admission.WithCustomDefaulter(scheme.Scheme, &v1alpha1.ManagedCluster{}, &ManagedClusterValidator{Client: c}).Handler}
+
+	raw, err := json.Marshal(managedcluster.NewManagedCluster(
+		managedcluster.WithClusterTemplate("aws-0-0-2"),
+		managedcluster.WithCredential(testCredentialName),
+		managedcluster.WithConfig(`{"region":"us-east-2","regoin":"us-west-2"}`),
+	))
+	g.Expect(err).To(Succeed())
+
+	resp := handler.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+	}})
+	g.Expect(resp.Allowed).To(BeTrue())
+	g.Expect(resp.Patches).NotTo(BeEmpty())
+	g.Expect(resp.Warnings).To(Equal([]string{"The config values disallowed by the ClusterTemplate aws-0-0-2 are pruned: regoin"}))
+}
+
+func TestManagedClusterDefaultCloneFrom(t *testing.T) {
+	g := NewWithT(t)
+
+	createCtx := admission.NewContextWithRequest(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Create}})
+	updateCtx := admission.NewContextWithRequest(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Update}})
+
+	source := managedcluster.NewManagedCluster(
+		managedcluster.WithName("source"),
+		managedcluster.WithClusterTemplate(testTemplateName),
+		managedcluster.WithCredential(testCredentialName),
+		managedcluster.WithConfig(`{"region":"us-east-2","controlPlaneEndpointIP":"10.0.0.1","worker":{"instanceType":"t3.small","amiID":"ami-1"}}`),
+		managedcluster.WithClusterLabels(map[string]string{"env": "prod"}),
+	)
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		input  *v1alpha1.ManagedCluster
+		output *v1alpha1.ManagedCluster
+		err    string
+	}{
+		{
+			name:  "should populate the spec from the source cluster",
+			ctx:   createCtx,
+			input: managedcluster.NewManagedCluster(managedcluster.WithCloneFrom("source")),
+			output: managedcluster.NewManagedCluster(
+				managedcluster.WithCloneFrom("source"),
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithCredential(testCredentialName),
+				managedcluster.WithConfig(`{"region":"us-east-2","worker":{"amiID":"ami-1","instanceType":"t3.small"}}`),
+				managedcluster.WithClusterLabels(map[string]string{"env": "prod"}),
+			),
+		},
+		{
+			name: "should keep the fields set on the creation",
+			ctx:  createCtx,
+			input: managedcluster.NewManagedCluster(
+				managedcluster.WithCloneFrom("source"),
+				managedcluster.WithCredential("other-cred"),
+				managedcluster.WithConfig(`{"worker":{"instanceType":"t3.large"}}`),
+			),
+			output: managedcluster.NewManagedCluster(
+				managedcluster.WithCloneFrom("source"),
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithCredential("other-cred"),
+				managedcluster.WithConfig(`{"region":"us-east-2","worker":{"amiID":"ami-1","instanceType":"t3.large"}}`),
+				managedcluster.WithClusterLabels(map[string]string{"env": "prod"}),
+			),
+		},
+		{
+			name: "should not clone on update",
+			ctx:  updateCtx,
+			input: managedcluster.NewManagedCluster(
+				managedcluster.WithCloneFrom("source"),
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithConfig(`{"foo":"bar"}`),
+			),
+			output: managedcluster.NewManagedCluster(
+				managedcluster.WithCloneFrom("source"),
+				managedcluster.WithClusterTemplate(testTemplateName),
+				managedcluster.WithConfig(`{"foo":"bar"}`),
+			),
+		},
+		{
+			name:   "should fail if the source cluster does not exist",
+			ctx:    createCtx,
+			input:  managedcluster.NewManagedCluster(managedcluster.WithCloneFrom("missing")),
+			output: managedcluster.NewManagedCluster(managedcluster.WithCloneFrom("missing")),
+			err:    `failed to clone the ManagedCluster missing: managedclusters.hmc.mirantis.com "missing" not found`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(_ *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(source).Build()
+			validator := &ManagedClusterValidator{Client: c}
+			err := validator.Default(tt.ctx, tt.input)
+			if tt.err != "" {
+				g.Expect(err).To(MatchError(tt.err))
+			} else {
+				g.Expect(err).To(Succeed())
+			}
+			g.Expect(tt.input).To(Equal(tt.output))
+		})
+	}
+}
@@ -16,9 +16,13 @@ package webhook
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -27,13 +31,21 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	hmcv1alpha1 "github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/helm"
 )
 
 type ManagementValidator struct {
 	client.Client
 }
 
-var errManagementDeletionForbidden = errors.New("management deletion is forbidden")
+var (
+	errManagementDeletionForbidden = errors.New("management deletion is forbidden")
+	errProvidersRemovalForbidden   = errors.New("providers removal is forbidden")
+	errProviderUpgradeForbidden    = errors.New("provider upgrade is forbidden")
+)
+
+// maxReportedClusters is the maximum number of the blocking ManagedClusters listed in the errors.
+const maxReportedClusters = 10
 
 func (v *ManagementValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	v.Client = mgr.GetClient()
@@ -50,12 +62,21 @@ var (
 )
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
-func (*ManagementValidator) ValidateCreate(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+func (*ManagementValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	mgmt, ok := obj.(*hmcv1alpha1.Management)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected Management but got a %T", obj))
+	}
+
+	if err := validateGlobalClusterDefaults(mgmt); err != nil {
+		return nil, fmt.Errorf("the Management is invalid: %w", err)
+	}
+
 	return nil, nil
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
-func (v *ManagementValidator) ValidateUpdate(ctx context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+func (v *ManagementValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
 	const invalidMgmtMsg = "the Management is invalid"
 
 	mgmt, ok := newObj.(*hmcv1alpha1.Management)
@@ -63,6 +84,26 @@ func (v *ManagementValidator) ValidateUpdate(ctx context.Context, _, newObj runt
 		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected Management but got a %T", newObj))
 	}
 
+	if err := validateMaintenanceWindow(mgmt.Spec.MaintenanceWindow); err != nil {
+		return nil, fmt.Errorf("%s: %w", invalidMgmtMsg, err)
+	}
+
+	if mgmt.Spec.Proxy != nil {
+		if err := mgmt.Spec.Proxy.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", invalidMgmtMsg, err)
+		}
+	}
+
+	if err := validateGlobalClusterDefaults(mgmt); err != nil {
+		return nil, fmt.Errorf("%s: %w", invalidMgmtMsg, err)
+	}
+
+	if oldMgmt, ok := oldObj.(*hmcv1alpha1.Management); ok {
+		if err := v.validateProvidersRemoval(ctx, oldMgmt, mgmt); err != nil {
+			return admission.Warnings{"The providers can't be removed from the Management while ManagedClusters require them"}, err
+		}
+	}
+
 	release := new(hmcv1alpha1.Release)
 	if err := v.Get(ctx, client.ObjectKey{Name: mgmt.Spec.Release}, release); err != nil {
 		// TODO: probably we do not want this skip if extra checks will be introduced
@@ -72,6 +113,16 @@ func (v *ManagementValidator) ValidateUpdate(ctx context.Context, _, newObj runt
 		return nil, fmt.Errorf("failed to get Release %s: %w", mgmt.Spec.Release, err)
 	}
 
+	if oldMgmt, ok := oldObj.(*hmcv1alpha1.Management); ok {
+		if err := v.validateUpgradePaths(ctx, oldMgmt, mgmt, release); err != nil {
+			return admission.Warnings{"The ProviderTemplates can be upgraded only along the paths of the ProviderTemplateChains"}, err
+		}
+	}
+
+	if err := v.validateComponentsConfig(ctx, mgmt, release); err != nil {
+		return admission.Warnings{"The config of the components must match the values schema of their ProviderTemplates"}, fmt.Errorf("%s: %w", invalidMgmtMsg, err)
+	}
+
 	capiTplName := release.Spec.CAPI.Template
 	if mgmt.Spec.Core != nil && mgmt.Spec.Core.CAPI.Template != "" {
 		capiTplName = mgmt.Spec.Core.CAPI.Template
@@ -128,15 +179,221 @@ func (v *ManagementValidator) ValidateUpdate(ctx context.Context, _, newObj runt
 	return nil, nil
 }
 
+// validateGlobalClusterDefaults checks the global cluster defaults are the values
+// object, the invalid defaults would fail the reconciliation of every ManagedCluster.
+func validateGlobalClusterDefaults(mgmt *hmcv1alpha1.Management) error {
+	if mgmt.Spec.GlobalClusterDefaults == nil {
+		return nil
+	}
+
+	var values any
+	if err := json.Unmarshal(mgmt.Spec.GlobalClusterDefaults.Raw, &values); err != nil {
+		return fmt.Errorf("failed to parse the global cluster defaults: %w", err)
+	}
+	if _, ok := values.(map[string]any); !ok && values != nil {
+		return errors.New("the global cluster defaults must be an object")
+	}
+	return nil
+}
+
+// validateUpgradePaths checks the templates of the components are changed
+// only to the ones listed as available upgrades in the valid ProviderTemplateChains
+// supporting the currently installed templates. The components with templates
+// not supported by any chain may be changed freely.
+func (v *ManagementValidator) validateUpgradePaths(ctx context.Context, oldMgmt, mgmt *hmcv1alpha1.Management, release *hmcv1alpha1.Release) error {
+	var (
+		chains *hmcv1alpha1.ProviderTemplateChainList
+		errs   error
+	)
+	for _, c := range componentTemplates(mgmt, release) {
+		current := oldMgmt.Status.Components[c.name].Template
+		if current == "" || current == c.template {
+			continue
+		}
+
+		if chains == nil {
+			chains = new(hmcv1alpha1.ProviderTemplateChainList)
+			if err := v.List(ctx, chains); err != nil {
+				return fmt.Errorf("failed to list ProviderTemplateChains: %w", err)
+			}
+		}
+
+		var (
+			restricted bool
+			allowed    bool
+		)
+		for _, chain := range chains.Items {
+			if !hmcv1alpha1.IsTemplateChainValid(&chain.Status) {
+				continue
+			}
+			supported, upgrades := chain.Spec.UpgradesOf(current)
+			restricted = restricted || supported
+			allowed = allowed || slices.Contains(upgrades, c.template)
+		}
+
+		if restricted && !allowed {
+			errs = errors.Join(errs, fmt.Errorf("the upgrade of the %s component from %s to %s is not available in the ProviderTemplateChains", c.name, current, c.template))
+		}
+	}
+
+	if errs != nil {
+		return fmt.Errorf("%w: %w", errProviderUpgradeForbidden, errs)
+	}
+	return nil
+}
+
+// validateComponentsConfig validates the config of the components against
+// the values schema of their ProviderTemplates.
+func (v *ManagementValidator) validateComponentsConfig(ctx context.Context, mgmt *hmcv1alpha1.Management, release *hmcv1alpha1.Release) error {
+	var errs error
+	for _, c := range componentTemplates(mgmt, release) {
+		if c.config == nil || c.template == "" {
+			continue
+		}
+
+		tpl := new(hmcv1alpha1.ProviderTemplate)
+		if err := v.Get(ctx, client.ObjectKey{Name: c.template}, tpl); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue // reported by the other checks
+			}
+			return fmt.Errorf("failed to get ProviderTemplate %s: %w", c.template, err)
+		}
+
+		if err := helm.ValidateValues(tpl.Status.ConfigSchema, tpl.Status.Config, c.config); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("invalid config of the %s component: %w", c.name, err))
+		}
+	}
+	return errs
+}
+
+type componentTemplate struct {
+	name, template string
+	config         *apiextensionsv1.JSON
+}
+
+// componentTemplates returns the templates of the components of the Management,
+// the templates not set explicitly are taken from the Release.
+func componentTemplates(mgmt *hmcv1alpha1.Management, release *hmcv1alpha1.Release) []componentTemplate {
+	if mgmt.Spec.Core == nil {
+		return nil
+	}
+
+	hmcTpl, capiTpl := mgmt.Spec.Core.HMC.Template, mgmt.Spec.Core.CAPI.Template
+	if hmcTpl == "" {
+		hmcTpl = release.Spec.HMC.Template
+	}
+	if capiTpl == "" {
+		capiTpl = release.Spec.CAPI.Template
+	}
+
+	templates := []componentTemplate{
+		{name: hmcv1alpha1.CoreHMCName, template: hmcTpl, config: mgmt.Spec.Core.HMC.Config},
+		{name: hmcv1alpha1.CoreCAPIName, template: capiTpl, config: mgmt.Spec.Core.CAPI.Config},
+	}
+	for _, p := range mgmt.Spec.Providers {
+		tpl := p.Template
+		if tpl == "" {
+			tpl = release.ProviderTemplate(p.Name)
+		}
+		templates = append(templates, componentTemplate{name: p.Name, template: tpl, config: p.Config})
+	}
+
+	return templates
+}
+
+// validateProvidersRemoval checks none of the ManagedClusters require the providers removed from the Management.
+func (v *ManagementValidator) validateProvidersRemoval(ctx context.Context, oldMgmt, mgmt *hmcv1alpha1.Management) error {
+	var removed hmcv1alpha1.Providers
+	for _, p := range oldMgmt.Spec.Providers {
+		if slices.ContainsFunc(mgmt.Spec.Providers, func(np hmcv1alpha1.Provider) bool { return np.Name == p.Name }) {
+			continue
+		}
+
+		tplName := oldMgmt.Status.Components[p.Name].Template
+		if tplName == "" {
+			tplName = p.Template
+		}
+		if tplName == "" {
+			continue // the provider has never been deployed
+		}
+
+		pTpl := new(hmcv1alpha1.ProviderTemplate)
+		if err := v.Get(ctx, client.ObjectKey{Name: tplName}, pTpl); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to get ProviderTemplate %s: %w", tplName, err)
+		}
+		removed = append(removed, pTpl.Status.Providers...)
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	clusters, err := v.clustersRequiringProviders(ctx, removed)
+	if err != nil {
+		return err
+	}
+	if len(clusters) > 0 {
+		return fmt.Errorf("%w: the ManagedClusters require the providers: %s", errProvidersRemovalForbidden, formatClusters(clusters))
+	}
+	return nil
+}
+
+// clustersRequiringProviders returns the names of the ManagedClusters
+// whose templates require any of the given providers.
+func (v *ManagementValidator) clustersRequiringProviders(ctx context.Context, providers hmcv1alpha1.Providers) ([]string, error) {
+	managedClusters := &hmcv1alpha1.ManagedClusterList{}
+	if err := v.List(ctx, managedClusters); err != nil {
+		return nil, fmt.Errorf("failed to list ManagedClusters: %w", err)
+	}
+
+	templates := make(map[client.ObjectKey]*hmcv1alpha1.ClusterTemplate)
+	var clusters []string
+	for _, cluster := range managedClusters.Items {
+		key := client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Spec.Template}
+		tpl, ok := templates[key]
+		if !ok {
+			tpl = new(hmcv1alpha1.ClusterTemplate)
+			if err := v.Get(ctx, key, tpl); err != nil {
+				if !apierrors.IsNotFound(err) {
+					return nil, fmt.Errorf("failed to get ClusterTemplate %s: %w", key, err)
+				}
+				tpl = nil
+			}
+			templates[key] = tpl
+		}
+
+		if tpl != nil && slices.ContainsFunc(tpl.Status.Providers, func(p string) bool { return slices.Contains(providers, p) }) {
+			clusters = append(clusters, client.ObjectKeyFromObject(&cluster).String())
+		}
+	}
+	return clusters, nil
+}
+
+// formatClusters returns the list of the names of the clusters limited to maxReportedClusters.
+func formatClusters(clusters []string) string {
+	if len(clusters) <= maxReportedClusters {
+		return strings.Join(clusters, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(clusters[:maxReportedClusters], ", "), len(clusters)-maxReportedClusters)
+}
+
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
 func (v *ManagementValidator) ValidateDelete(ctx context.Context, _ runtime.Object) (admission.Warnings, error) {
 	managedClusters := &hmcv1alpha1.ManagedClusterList{}
-	err := v.Client.List(ctx, managedClusters, client.Limit(1))
+	err := v.Client.List(ctx, managedClusters)
 	if err != nil {
 		return nil, err
 	}
 	if len(managedClusters.Items) > 0 {
-		return admission.Warnings{"The Management object can't be removed if ManagedCluster objects still exist"}, errManagementDeletionForbidden
+		clusters := make([]string, 0, len(managedClusters.Items))
+		for _, cluster := range managedClusters.Items {
+			clusters = append(clusters, client.ObjectKeyFromObject(&cluster).String())
+		}
+		return admission.Warnings{"The Management object can't be removed if ManagedCluster objects still exist"},
+			fmt.Errorf("%w: the ManagedClusters still exist: %s", errManagementDeletionForbidden, formatClusters(clusters))
 	}
 	return nil, nil
 }
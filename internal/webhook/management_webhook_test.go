@@ -21,6 +21,8 @@ import (
 
 	. "github.com/onsi/gomega"
 	admissionv1 "k8s.io/api/admission/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -30,6 +32,7 @@ import (
 	"github.com/Mirantis/hmc/test/objects/management"
 	"github.com/Mirantis/hmc/test/objects/release"
 	"github.com/Mirantis/hmc/test/objects/template"
+	tc "github.com/Mirantis/hmc/test/objects/templatechain"
 	"github.com/Mirantis/hmc/test/scheme"
 )
 
@@ -64,6 +67,20 @@ func TestManagementValidateUpdate(t *testing.T) {
 			name:       "no release and no core capi tpl set, should succeed",
 			management: management.NewManagement(),
 		},
+		{
+			name:       "invalid proxy, should fail",
+			management: management.NewManagement(management.WithProxy(&v1alpha1.ProxyConfig{HTTPSProxy: "proxy.example.com:3128"})),
+			err:        `the Management is invalid: invalid httpsProxy "proxy.example.com:3128": must be an http or https URL`,
+		},
+		{
+			name:       "global cluster defaults not an object, should fail",
+			management: management.NewManagement(management.WithGlobalClusterDefaults(`["proxy"]`)),
+			err:        "the Management is invalid: the global cluster defaults must be an object",
+		},
+		{
+			name:       "global cluster defaults object, should succeed",
+			management: management.NewManagement(management.WithGlobalClusterDefaults(`{"proxy":{"httpProxy":"http://proxy:3128"}}`)),
+		},
 		{
 			name:            "no capi providertemplate, should fail",
 			management:      management.NewManagement(management.WithRelease(release.DefaultName)),
@@ -201,6 +218,215 @@ func TestManagementValidateUpdate(t *testing.T) {
 	}
 }
 
+func TestManagementValidateProvidersRemoval(t *testing.T) {
+	g := NewWithT(t)
+
+	ctx := admission.NewContextWithRequest(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Update}})
+
+	const (
+		awsTplName   = "aws-provider-tpl"
+		azureTplName = "azure-provider-tpl"
+	)
+
+	oldMgmt := management.NewManagement(
+		management.WithProviders([]v1alpha1.Provider{
+			{Name: "aws", Component: v1alpha1.Component{Template: awsTplName}},
+			{Name: "azure", Component: v1alpha1.Component{Template: azureTplName}},
+		}),
+	)
+
+	providerTemplates := []runtime.Object{
+		template.NewProviderTemplate(template.WithName(awsTplName), template.WithProvidersStatus(v1alpha1.Providers{"infrastructure-aws"})),
+		template.NewProviderTemplate(template.WithName(azureTplName), template.WithProvidersStatus(v1alpha1.Providers{"infrastructure-azure"})),
+		template.NewClusterTemplate(template.WithName("aws-standalone"), template.WithNamespace(managedcluster.DefaultNamespace),
+			template.WithProvidersStatus(v1alpha1.Providers{"infrastructure-aws"})),
+	}
+
+	tests := []struct {
+		name            string
+		management      *v1alpha1.Management
+		existingObjects []runtime.Object
+		err             string
+	}{
+		{
+			name:            "should succeed if no ManagedClusters require the removed provider",
+			management:      management.NewManagement(management.WithProviders([]v1alpha1.Provider{{Name: "aws", Component: v1alpha1.Component{Template: awsTplName}}})),
+			existingObjects: append(providerTemplates, managedcluster.NewManagedCluster(managedcluster.WithClusterTemplate("aws-standalone"))),
+		},
+		{
+			name:            "should fail if ManagedClusters require the removed provider",
+			management:      management.NewManagement(management.WithProviders([]v1alpha1.Provider{{Name: "azure", Component: v1alpha1.Component{Template: azureTplName}}})),
+			existingObjects: append(providerTemplates, managedcluster.NewManagedCluster(managedcluster.WithClusterTemplate("aws-standalone"))),
+			err:             "providers removal is forbidden: the ManagedClusters require the providers: default/managedcluster",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(_ *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(tt.existingObjects...).Build()
+			validator := &ManagementValidator{Client: c}
+
+			_, err := validator.ValidateUpdate(ctx, oldMgmt, tt.management)
+			if tt.err != "" {
+				g.Expect(err).To(MatchError(tt.err))
+			} else {
+				g.Expect(err).To(Succeed())
+			}
+		})
+	}
+}
+
+func TestManagementValidateUpgradePaths(t *testing.T) {
+	ctx := admission.NewContextWithRequest(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Update}})
+
+	const (
+		awsTplName     = "aws-provider-tpl"
+		awsTplNameNext = "aws-provider-tpl-next"
+		awsTplNameSkip = "aws-provider-tpl-skip"
+	)
+
+	oldMgmt := management.NewManagement(
+		management.WithRelease(release.DefaultName),
+		management.WithCoreComponents(&v1alpha1.Core{}),
+		management.WithProviders([]v1alpha1.Provider{{Name: "aws", Component: v1alpha1.Component{Template: awsTplName}}}),
+		management.WithComponentsStatus(map[string]v1alpha1.ComponentStatus{
+			"aws": {Template: awsTplName},
+		}),
+	)
+	withAwsTemplate := func(tplName string) *v1alpha1.Management {
+		return management.NewManagement(
+			management.WithRelease(release.DefaultName),
+			management.WithCoreComponents(&v1alpha1.Core{}),
+			management.WithProviders([]v1alpha1.Provider{{Name: "aws", Component: v1alpha1.Component{Template: tplName}}}),
+		)
+	}
+
+	chain := tc.NewProviderTemplateChain(tc.WithSupportedTemplates([]v1alpha1.SupportedTemplate{
+		{Name: awsTplName, AvailableUpgrades: []v1alpha1.AvailableUpgrade{{Name: awsTplNameNext}}},
+		{Name: awsTplNameNext},
+	}))
+	invalidChain := tc.NewProviderTemplateChain(tc.WithSupportedTemplates(chain.Spec.SupportedTemplates))
+	invalidChain.Status.Conditions = []metav1.Condition{{Type: v1alpha1.TemplateChainValidCondition, Status: metav1.ConditionFalse}}
+
+	existingObjects := []runtime.Object{
+		release.New(),
+		template.NewProviderTemplate(template.WithName(release.DefaultCAPITemplateName)),
+		template.NewProviderTemplate(template.WithName(awsTplNameNext)),
+		template.NewProviderTemplate(template.WithName(awsTplNameSkip)),
+	}
+
+	tests := []struct {
+		name            string
+		management      *v1alpha1.Management
+		existingObjects []runtime.Object
+		warnings        admission.Warnings
+		err             string
+	}{
+		{
+			name:            "should succeed if no ProviderTemplateChain supports the current template",
+			management:      withAwsTemplate(awsTplNameSkip),
+			existingObjects: existingObjects,
+		},
+		{
+			name:            "should succeed if the upgrade is available in the ProviderTemplateChain",
+			management:      withAwsTemplate(awsTplNameNext),
+			existingObjects: append(existingObjects, chain),
+		},
+		{
+			name:            "should succeed if the ProviderTemplateChain is invalid",
+			management:      withAwsTemplate(awsTplNameSkip),
+			existingObjects: append(existingObjects, invalidChain),
+		},
+		{
+			name:            "should fail if the upgrade is not available in the ProviderTemplateChain",
+			management:      withAwsTemplate(awsTplNameSkip),
+			existingObjects: append(existingObjects, chain),
+			warnings:        admission.Warnings{"The ProviderTemplates can be upgraded only along the paths of the ProviderTemplateChains"},
+			err:             fmt.Sprintf("provider upgrade is forbidden: the upgrade of the aws component from %s to %s is not available in the ProviderTemplateChains", awsTplName, awsTplNameSkip),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(tt.existingObjects...).Build()
+			validator := &ManagementValidator{Client: c}
+
+			warnings, err := validator.ValidateUpdate(ctx, oldMgmt, tt.management)
+			if tt.err != "" {
+				g.Expect(err).To(MatchError(tt.err))
+			} else {
+				g.Expect(err).To(Succeed())
+			}
+
+			g.Expect(warnings).To(Equal(tt.warnings))
+		})
+	}
+}
+
+func TestManagementValidateComponentsConfig(t *testing.T) {
+	ctx := admission.NewContextWithRequest(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Update}})
+
+	const awsTplName = "aws-provider-tpl"
+
+	withAwsConfig := func(config string) *v1alpha1.Management {
+		return management.NewManagement(
+			management.WithRelease(release.DefaultName),
+			management.WithCoreComponents(&v1alpha1.Core{}),
+			management.WithProviders([]v1alpha1.Provider{{Name: "aws", Component: v1alpha1.Component{
+				Template: awsTplName,
+				Config:   &apiextensionsv1.JSON{Raw: []byte(config)},
+			}}}),
+		)
+	}
+
+	awsTpl := template.NewProviderTemplate(template.WithName(awsTplName))
+	awsTpl.Status.ConfigSchema = &apiextensionsv1.JSON{Raw: []byte(`{"type":"object","properties":{"manager":{"type":"object","properties":{"maxConcurrentReconciles":{"type":"integer","minimum":1}}}}}`)}
+
+	existingObjects := []runtime.Object{
+		release.New(),
+		template.NewProviderTemplate(template.WithName(release.DefaultCAPITemplateName)),
+		awsTpl,
+	}
+
+	tests := []struct {
+		name       string
+		management *v1alpha1.Management
+		warnings   admission.Warnings
+		err        string
+	}{
+		{
+			name:       "should succeed if the config matches the schema",
+			management: withAwsConfig(`{"manager":{"maxConcurrentReconciles":5}}`),
+		},
+		{
+			name:       "should fail if the config does not match the schema",
+			management: withAwsConfig(`{"manager":{"maxConcurrentReconciles":0}}`),
+			warnings:   admission.Warnings{"The config of the components must match the values schema of their ProviderTemplates"},
+			err:        "the Management is invalid: invalid config of the aws component: manager.maxConcurrentReconciles: Must be greater than or equal to 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(existingObjects...).Build()
+			validator := &ManagementValidator{Client: c}
+
+			warnings, err := validator.ValidateUpdate(ctx, management.NewManagement(), tt.management)
+			if tt.err != "" {
+				g.Expect(err).To(MatchError(tt.err))
+			} else {
+				g.Expect(err).To(Succeed())
+			}
+
+			g.Expect(warnings).To(Equal(tt.warnings))
+		})
+	}
+}
+
 func TestManagementValidateDelete(t *testing.T) {
 	g := NewWithT(t)
 
@@ -218,7 +444,7 @@ func TestManagementValidateDelete(t *testing.T) {
 			management:      management.NewManagement(),
 			existingObjects: []runtime.Object{managedcluster.NewManagedCluster()},
 			warnings:        admission.Warnings{"The Management object can't be removed if ManagedCluster objects still exist"},
-			err:             "management deletion is forbidden",
+			err:             "management deletion is forbidden: the ManagedClusters still exist: default/managedcluster",
 		},
 		{
 			name:       "should succeed",
@@ -0,0 +1,191 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+const (
+	invalidMultiClusterServiceMsg = "the MultiClusterService is invalid"
+
+	// maxServicesPriority is the maximum priority of the services converted to the Sveltos tier.
+	maxServicesPriority = 2147483646
+)
+
+type MultiClusterServiceValidator struct {
+	client.Client
+	SystemNamespace string
+}
+
+func (v *MultiClusterServiceValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	v.Client = mgr.GetClient()
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&v1alpha1.MultiClusterService{}).
+		WithValidator(v).
+		Complete()
+}
+
+var _ webhook.CustomValidator = &MultiClusterServiceValidator{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (v *MultiClusterServiceValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	mcs, ok := obj.(*v1alpha1.MultiClusterService)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected MultiClusterService but got a %T", obj))
+	}
+
+	if err := validateMatchers(&mcs.Spec); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidMultiClusterServiceMsg, err)
+	}
+
+	if err := validateRollout(&mcs.Spec); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidMultiClusterServiceMsg, err)
+	}
+
+	if err := validateServiceTemplates(ctx, v.Client, v.SystemNamespace, mcs.Spec.Services); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidMultiClusterServiceMsg, err)
+	}
+
+	return nil, nil
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (v *MultiClusterServiceValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldMCS, ok := oldObj.(*v1alpha1.MultiClusterService)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected MultiClusterService but got a %T", oldObj))
+	}
+	newMCS, ok := newObj.(*v1alpha1.MultiClusterService)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected MultiClusterService but got a %T", newObj))
+	}
+
+	if err := validateMatchers(&newMCS.Spec); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidMultiClusterServiceMsg, err)
+	}
+
+	if err := validateRollout(&newMCS.Spec); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidMultiClusterServiceMsg, err)
+	}
+
+	if equality.Semantic.DeepEqual(oldMCS.Spec.Services, newMCS.Spec.Services) {
+		return nil, nil
+	}
+
+	if err := validateServiceTemplates(ctx, v.Client, v.SystemNamespace, newMCS.Spec.Services); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidMultiClusterServiceMsg, err)
+	}
+
+	return nil, nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (*MultiClusterServiceValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateMatchers validates the matchers of the MultiClusterService refer to the defined services.
+// The ClusterProfiles of the matchers take the priorities above the priority of the services.
+func validateMatchers(spec *v1alpha1.MultiClusterServiceSpec) error {
+	if len(spec.Matchers) == 0 {
+		return nil
+	}
+
+	if int64(spec.ServicesPriority)+int64(len(spec.Matchers)) > maxServicesPriority {
+		return fmt.Errorf("the services priority %d leaves no room for the priorities of %d matchers, the maximum priority is %d",
+			spec.ServicesPriority, len(spec.Matchers), maxServicesPriority)
+	}
+
+	names := make(map[string]struct{}, len(spec.Matchers))
+	for _, matcher := range spec.Matchers {
+		if _, ok := names[matcher.Name]; ok {
+			return fmt.Errorf("the matcher %s is defined more than once", matcher.Name)
+		}
+		names[matcher.Name] = struct{}{}
+
+		if _, err := metav1.LabelSelectorAsSelector(&matcher.ClusterSelector); err != nil {
+			return fmt.Errorf("invalid cluster selector of the matcher %s: %w", matcher.Name, err)
+		}
+
+		for _, override := range matcher.Services {
+			if !slices.ContainsFunc(spec.Services, func(svc v1alpha1.ServiceSpec) bool { return svc.Name == override.Name }) {
+				return fmt.Errorf("the matcher %s overrides the values of the undefined service %s", matcher.Name, override.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateRollout validates the rollout strategy of the services. The rollout profiles take the
+// priority above the priority of the services, which leaves no room for the matchers and the
+// services taken over regardless of the priority.
+func validateRollout(spec *v1alpha1.MultiClusterServiceSpec) error {
+	if spec.Rollout == nil {
+		return nil
+	}
+
+	if len(spec.Matchers) > 0 {
+		return errors.New("the rollout of the services is not supported along with the matchers")
+	}
+	if spec.ServicesPriority >= maxServicesPriority {
+		return fmt.Errorf("the services priority %d leaves no room for the priority of the rollout, the maximum priority is %d",
+			spec.ServicesPriority, maxServicesPriority-1)
+	}
+	for _, svc := range spec.Services {
+		if svc.ConflictPolicy == v1alpha1.ServiceConflictPolicyForce {
+			return fmt.Errorf("the rollout of the service %s with the %s conflict policy is not supported", svc.Name, svc.ConflictPolicy)
+		}
+	}
+
+	if spec.Rollout.MaxClusters != nil {
+		maxClusters, err := intstr.GetScaledValueFromIntOrPercent(spec.Rollout.MaxClusters, 100, true)
+		if err != nil {
+			return fmt.Errorf("invalid maxClusters of the rollout: %w", err)
+		}
+		if maxClusters < 1 {
+			return fmt.Errorf("the maxClusters %s of the rollout must be positive", spec.Rollout.MaxClusters.String())
+		}
+	}
+	if spec.Rollout.SoakTime.Duration < 0 {
+		return fmt.Errorf("the soakTime %s of the rollout must not be negative", spec.Rollout.SoakTime.Duration)
+	}
+
+	names := make(map[string]struct{}, len(spec.Rollout.HealthChecks))
+	for _, check := range spec.Rollout.HealthChecks {
+		if _, ok := names[check.Name]; ok {
+			return fmt.Errorf("the health check %s is defined more than once", check.Name)
+		}
+		names[check.Name] = struct{}{}
+	}
+
+	return nil
+}
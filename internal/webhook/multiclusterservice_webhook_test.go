@@ -0,0 +1,199 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/internal/utils"
+	"github.com/Mirantis/hmc/test/objects/multiclusterservice"
+	"github.com/Mirantis/hmc/test/objects/template"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestMultiClusterServiceValidateCreate(t *testing.T) {
+	g := NewWithT(t)
+
+	ctx := context.Background()
+
+	const testServiceTemplateName = "test-service-template"
+
+	tests := []struct {
+		name            string
+		mcs             *v1alpha1.MultiClusterService
+		existingObjects []runtime.Object
+		err             string
+		warnings        admission.Warnings
+	}{
+		{
+			name: "should fail if the ServiceTemplate is not found",
+			mcs:  multiclusterservice.NewMultiClusterService(multiclusterservice.WithServiceTemplate(testServiceTemplateName)),
+			err:  "the MultiClusterService is invalid: the ServiceTemplate hmc-system/test-service-template is not found",
+		},
+		{
+			name: "should fail if the ServiceTemplate is not in the system namespace",
+			mcs:  multiclusterservice.NewMultiClusterService(multiclusterservice.WithServiceTemplate(testServiceTemplateName)),
+			existingObjects: []runtime.Object{
+				template.NewServiceTemplate(
+					template.WithName(testServiceTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+			err: "the MultiClusterService is invalid: the ServiceTemplate hmc-system/test-service-template is not found",
+		},
+		{
+			name: "should fail if the ServiceTemplate is invalid",
+			mcs:  multiclusterservice.NewMultiClusterService(multiclusterservice.WithServiceTemplate(testServiceTemplateName)),
+			existingObjects: []runtime.Object{
+				template.NewServiceTemplate(
+					template.WithName(testServiceTemplateName),
+					template.WithNamespace(utils.DefaultSystemNamespace),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{
+						Valid:           false,
+						ValidationError: "validation error example",
+					}),
+				),
+			},
+			err: "the MultiClusterService is invalid: the ServiceTemplate hmc-system/test-service-template is not valid: validation error example",
+		},
+		{
+			name: "should fail if a matcher overrides an undefined service",
+			mcs: multiclusterservice.NewMultiClusterService(
+				multiclusterservice.WithServiceTemplate(testServiceTemplateName),
+				multiclusterservice.WithMatcher("aws", map[string]string{"provider": "aws"}, v1alpha1.ServiceValuesOverride{Name: "ingress"}),
+			),
+			err: "the MultiClusterService is invalid: the matcher aws overrides the values of the undefined service ingress",
+		},
+		{
+			name: "should fail if a matcher is defined more than once",
+			mcs: multiclusterservice.NewMultiClusterService(
+				multiclusterservice.WithServiceTemplate(testServiceTemplateName),
+				multiclusterservice.WithMatcher("aws", map[string]string{"provider": "aws"}),
+				multiclusterservice.WithMatcher("aws", map[string]string{"provider": "eks"}),
+			),
+			err: "the MultiClusterService is invalid: the matcher aws is defined more than once",
+		},
+		{
+			name: "should fail if the priorities of the matchers exceed the maximum",
+			mcs: multiclusterservice.NewMultiClusterService(
+				multiclusterservice.WithServiceTemplate(testServiceTemplateName),
+				multiclusterservice.WithServicesPriority(2147483646),
+				multiclusterservice.WithMatcher("aws", map[string]string{"provider": "aws"}),
+			),
+			err: "the MultiClusterService is invalid: the services priority 2147483646 leaves no room for the priorities of 1 matchers, the maximum priority is 2147483646",
+		},
+		{
+			name: "should fail if the rollout is set along with the matchers",
+			mcs: multiclusterservice.NewMultiClusterService(
+				multiclusterservice.WithServiceTemplate(testServiceTemplateName),
+				multiclusterservice.WithMatcher("aws", map[string]string{"provider": "aws"}),
+				multiclusterservice.WithRollout(v1alpha1.ServiceRollout{}),
+			),
+			err: "the MultiClusterService is invalid: the rollout of the services is not supported along with the matchers",
+		},
+		{
+			name: "should fail if the priority of the rollout exceeds the maximum",
+			mcs: multiclusterservice.NewMultiClusterService(
+				multiclusterservice.WithServiceTemplate(testServiceTemplateName),
+				multiclusterservice.WithServicesPriority(2147483646),
+				multiclusterservice.WithRollout(v1alpha1.ServiceRollout{}),
+			),
+			err: "the MultiClusterService is invalid: the services priority 2147483646 leaves no room for the priority of the rollout, the maximum priority is 2147483645",
+		},
+		{
+			name: "should fail if the rollout has no clusters per step",
+			mcs: multiclusterservice.NewMultiClusterService(
+				multiclusterservice.WithServiceTemplate(testServiceTemplateName),
+				multiclusterservice.WithRollout(v1alpha1.ServiceRollout{MaxClusters: ptr.To(intstr.FromInt32(0))}),
+			),
+			err: "the MultiClusterService is invalid: the maxClusters 0 of the rollout must be positive",
+		},
+		{
+			name: "should succeed",
+			mcs:  multiclusterservice.NewMultiClusterService(multiclusterservice.WithServiceTemplate(testServiceTemplateName)),
+			existingObjects: []runtime.Object{
+				template.NewServiceTemplate(
+					template.WithName(testServiceTemplateName),
+					template.WithNamespace(utils.DefaultSystemNamespace),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+		},
+		{
+			name: "should succeed with the rollout",
+			mcs: multiclusterservice.NewMultiClusterService(
+				multiclusterservice.WithServiceTemplate(testServiceTemplateName),
+				multiclusterservice.WithRollout(v1alpha1.ServiceRollout{
+					MaxClusters:    ptr.To(intstr.FromString("25%")),
+					SoakTime:       metav1.Duration{Duration: 10 * time.Minute},
+					AbortOnFailure: true,
+					HealthChecks:   []v1alpha1.ServiceHealthCheck{{Name: "deployments", Group: "apps", Version: "v1", Kind: "Deployment"}},
+				}),
+			),
+			existingObjects: []runtime.Object{
+				template.NewServiceTemplate(
+					template.WithName(testServiceTemplateName),
+					template.WithNamespace(utils.DefaultSystemNamespace),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+		},
+		{
+			name: "should succeed with matchers",
+			mcs: multiclusterservice.NewMultiClusterService(
+				multiclusterservice.WithServiceTemplate(testServiceTemplateName),
+				multiclusterservice.WithServicesPriority(100),
+				multiclusterservice.WithMatcher("aws", map[string]string{"provider": "aws"}, v1alpha1.ServiceValuesOverride{
+					Name:   testServiceTemplateName,
+					Values: &apiextensionsv1.JSON{Raw: []byte(`{"ingressClass":"alb"}`)},
+				}),
+			),
+			existingObjects: []runtime.Object{
+				template.NewServiceTemplate(
+					template.WithName(testServiceTemplateName),
+					template.WithNamespace(utils.DefaultSystemNamespace),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(tt.existingObjects...).Build()
+			validator := &MultiClusterServiceValidator{Client: c, SystemNamespace: utils.DefaultSystemNamespace}
+			warn, err := validator.ValidateCreate(ctx, tt.mcs)
+			if tt.err != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tt.err))
+			} else {
+				g.Expect(err).To(Succeed())
+			}
+
+			g.Expect(warn).To(Equal(tt.warnings))
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	hmcv1alpha1 "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+var errNamespaceDeletionForbidden = errors.New("namespace deletion is forbidden")
+
+// NamespaceValidator prevents the deletion of the namespaces hosting ManagedClusters,
+// the deletion of the namespace removes the HelmReleases of the clusters before
+// the clusters are deprovisioned and leaves the cloud resources orphaned.
+type NamespaceValidator struct {
+	client.Client
+}
+
+func (v *NamespaceValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	v.Client = mgr.GetClient()
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&corev1.Namespace{}).
+		WithValidator(v).
+		Complete()
+}
+
+var _ webhook.CustomValidator = &NamespaceValidator{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (*NamespaceValidator) ValidateCreate(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (*NamespaceValidator) ValidateUpdate(_ context.Context, _, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (v *NamespaceValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	namespace, ok := obj.(*corev1.Namespace)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected Namespace but got a %T", obj))
+	}
+
+	managedClusters := &hmcv1alpha1.ManagedClusterList{}
+	if err := v.List(ctx, managedClusters, client.InNamespace(namespace.Name)); err != nil {
+		return nil, fmt.Errorf("failed to list ManagedClusters: %w", err)
+	}
+	if len(managedClusters.Items) == 0 {
+		return nil, nil
+	}
+
+	if namespace.Annotations[hmcv1alpha1.ForceNamespaceDeletionAnnotation] == "true" {
+		return admission.Warnings{"The namespace is forcibly deleted with the ManagedClusters, the resources of the clusters may be left orphaned"}, nil
+	}
+
+	clusters := make([]string, 0, len(managedClusters.Items))
+	for _, cluster := range managedClusters.Items {
+		clusters = append(clusters, cluster.Name)
+	}
+	return admission.Warnings{fmt.Sprintf("Delete the ManagedClusters first or set the %s annotation to \"true\"", hmcv1alpha1.ForceNamespaceDeletionAnnotation)},
+		fmt.Errorf("%w: the namespace contains the ManagedClusters: %s", errNamespaceDeletionForbidden, formatClusters(clusters))
+}
@@ -0,0 +1,86 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestNamespaceValidateDelete(t *testing.T) {
+	g := NewWithT(t)
+
+	ctx := admission.NewContextWithRequest(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Delete}})
+
+	tests := []struct {
+		name            string
+		namespace       *corev1.Namespace
+		existingObjects []runtime.Object
+		err             string
+		warnings        admission.Warnings
+	}{
+		{
+			name:      "should succeed if the namespace has no ManagedClusters",
+			namespace: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: managedcluster.DefaultNamespace}},
+			existingObjects: []runtime.Object{
+				managedcluster.NewManagedCluster(managedcluster.WithNamespace("other")),
+			},
+		},
+		{
+			name:            "should fail if the namespace has ManagedClusters",
+			namespace:       &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: managedcluster.DefaultNamespace}},
+			existingObjects: []runtime.Object{managedcluster.NewManagedCluster()},
+			warnings:        admission.Warnings{`Delete the ManagedClusters first or set the hmc.mirantis.com/force-namespace-deletion annotation to "true"`},
+			err:             "namespace deletion is forbidden: the namespace contains the ManagedClusters: managedcluster",
+		},
+		{
+			name: "should succeed with a warning if the deletion is forced",
+			namespace: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:        managedcluster.DefaultNamespace,
+				Annotations: map[string]string{v1alpha1.ForceNamespaceDeletionAnnotation: "true"},
+			}},
+			existingObjects: []runtime.Object{managedcluster.NewManagedCluster()},
+			warnings:        admission.Warnings{"The namespace is forcibly deleted with the ManagedClusters, the resources of the clusters may be left orphaned"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(_ *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(tt.existingObjects...).Build()
+			validator := &NamespaceValidator{Client: c}
+
+			warn, err := validator.ValidateDelete(ctx, tt.namespace)
+			if tt.err != "" {
+				g.Expect(err).To(MatchError(tt.err))
+			} else {
+				g.Expect(err).To(Succeed())
+			}
+
+			g.Expect(warn).To(Equal(tt.warnings))
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+const invalidNamespacedMultiClusterServiceMsg = "the NamespacedMultiClusterService is invalid"
+
+type NamespacedMultiClusterServiceValidator struct {
+	client.Client
+}
+
+func (v *NamespacedMultiClusterServiceValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	v.Client = mgr.GetClient()
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&v1alpha1.NamespacedMultiClusterService{}).
+		WithValidator(v).
+		Complete()
+}
+
+var _ webhook.CustomValidator = &NamespacedMultiClusterServiceValidator{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (v *NamespacedMultiClusterServiceValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	nmcs, ok := obj.(*v1alpha1.NamespacedMultiClusterService)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected NamespacedMultiClusterService but got a %T", obj))
+	}
+
+	if err := validateNamespacedSpec(nmcs); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidNamespacedMultiClusterServiceMsg, err)
+	}
+
+	if err := validateServiceTemplates(ctx, v.Client, nmcs.Namespace, nmcs.Spec.Services); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidNamespacedMultiClusterServiceMsg, err)
+	}
+
+	return nil, nil
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (v *NamespacedMultiClusterServiceValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldNMCS, ok := oldObj.(*v1alpha1.NamespacedMultiClusterService)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected NamespacedMultiClusterService but got a %T", oldObj))
+	}
+	newNMCS, ok := newObj.(*v1alpha1.NamespacedMultiClusterService)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected NamespacedMultiClusterService but got a %T", newObj))
+	}
+
+	if err := validateNamespacedSpec(newNMCS); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidNamespacedMultiClusterServiceMsg, err)
+	}
+
+	if equality.Semantic.DeepEqual(oldNMCS.Spec.Services, newNMCS.Spec.Services) {
+		return nil, nil
+	}
+
+	if err := validateServiceTemplates(ctx, v.Client, newNMCS.Namespace, newNMCS.Spec.Services); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidNamespacedMultiClusterServiceMsg, err)
+	}
+
+	return nil, nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (*NamespacedMultiClusterServiceValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateNamespacedSpec validates the matchers and the rollout of the NamespacedMultiClusterService and
+// that none of its cluster selectors targets the clusters of another namespace.
+func validateNamespacedSpec(nmcs *v1alpha1.NamespacedMultiClusterService) error {
+	if err := validateMatchers(&nmcs.Spec); err != nil {
+		return err
+	}
+	if err := validateRollout(&nmcs.Spec); err != nil {
+		return err
+	}
+
+	if err := validateSelectorNamespace(&nmcs.Spec.ClusterSelector, nmcs.Namespace); err != nil {
+		return fmt.Errorf("invalid cluster selector: %w", err)
+	}
+	for _, matcher := range nmcs.Spec.Matchers {
+		if err := validateSelectorNamespace(&matcher.ClusterSelector, nmcs.Namespace); err != nil {
+			return fmt.Errorf("invalid cluster selector of the matcher %s: %w", matcher.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateSelectorNamespace validates the selector does not select the clusters
+// of ManagedClusters in a namespace other than the given one.
+func validateSelectorNamespace(selector *metav1.LabelSelector, namespace string) error {
+	errNamespace := errors.New("only the clusters in the namespace " + namespace + " may be selected")
+
+	if value, ok := selector.MatchLabels[v1alpha1.FluxHelmChartNamespaceKey]; ok && value != namespace {
+		return errNamespace
+	}
+
+	for _, req := range selector.MatchExpressions {
+		if req.Key != v1alpha1.FluxHelmChartNamespaceKey {
+			continue
+		}
+		if req.Operator != metav1.LabelSelectorOpIn {
+			return fmt.Errorf("the operator %s is not supported for the label %s", req.Operator, req.Key)
+		}
+		for _, value := range req.Values {
+			if value != namespace {
+				return errNamespace
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/template"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestNamespacedMultiClusterServiceValidateCreate(t *testing.T) {
+	g := NewWithT(t)
+
+	ctx := context.Background()
+
+	const (
+		testNamespace           = "team-a"
+		testServiceTemplateName = "test-service-template"
+	)
+
+	newNMCS := func(selector metav1.LabelSelector) *v1alpha1.NamespacedMultiClusterService {
+		return &v1alpha1.NamespacedMultiClusterService{
+			ObjectMeta: metav1.ObjectMeta{Name: "nmcs", Namespace: testNamespace},
+			Spec: v1alpha1.MultiClusterServiceSpec{
+				ClusterSelector: selector,
+				Services:        []v1alpha1.ServiceSpec{{Name: "ingress", Template: testServiceTemplateName}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name            string
+		nmcs            *v1alpha1.NamespacedMultiClusterService
+		existingObjects []runtime.Object
+		err             string
+	}{
+		{
+			name: "should fail if the ServiceTemplate is not in the namespace",
+			nmcs: newNMCS(metav1.LabelSelector{}),
+			existingObjects: []runtime.Object{
+				template.NewServiceTemplate(
+					template.WithName(testServiceTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+			err: "the NamespacedMultiClusterService is invalid: the ServiceTemplate team-a/test-service-template is not found",
+		},
+		{
+			name: "should fail if the cluster selector targets another namespace",
+			nmcs: newNMCS(metav1.LabelSelector{MatchLabels: map[string]string{v1alpha1.FluxHelmChartNamespaceKey: "team-b"}}),
+			err:  "the NamespacedMultiClusterService is invalid: invalid cluster selector: only the clusters in the namespace team-a may be selected",
+		},
+		{
+			name: "should fail if the cluster selector excludes a namespace",
+			nmcs: newNMCS(metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: v1alpha1.FluxHelmChartNamespaceKey, Operator: metav1.LabelSelectorOpNotIn, Values: []string{"team-b"}},
+			}}),
+			err: "the NamespacedMultiClusterService is invalid: invalid cluster selector: the operator NotIn is not supported for the label helm.toolkit.fluxcd.io/namespace",
+		},
+		{
+			name: "should succeed",
+			nmcs: newNMCS(metav1.LabelSelector{MatchLabels: map[string]string{v1alpha1.FluxHelmChartNamespaceKey: testNamespace}}),
+			existingObjects: []runtime.Object{
+				template.NewServiceTemplate(
+					template.WithName(testServiceTemplateName),
+					template.WithNamespace(testNamespace),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(tt.existingObjects...).Build()
+			validator := &NamespacedMultiClusterServiceValidator{Client: c}
+			warn, err := validator.ValidateCreate(ctx, tt.nmcs)
+			if tt.err != "" {
+				g.Expect(err).To(MatchError(tt.err))
+			} else {
+				g.Expect(err).To(Succeed())
+			}
+
+			g.Expect(warn).To(BeEmpty())
+		})
+	}
+}
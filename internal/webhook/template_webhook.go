@@ -75,7 +75,10 @@ func (v *ClusterTemplateValidator) ValidateDelete(ctx context.Context, obj runti
 	}
 
 	if len(managedClusters.Items) > 0 {
-		return admission.Warnings{"The ClusterTemplate object can't be removed if ManagedCluster objects referencing it still exist"}, errTemplateDeletionForbidden
+		if template.Annotations[v1alpha1.ForceTemplateDeletionAnnotation] == "true" {
+			return admission.Warnings{"The ClusterTemplate is used by ManagedClusters, the clusters will fail to reconcile"}, nil
+		}
+		return admission.Warnings{fmt.Sprintf("The ClusterTemplate object can't be removed if ManagedCluster objects referencing it still exist, set the %s annotation to \"true\" to force the deletion", v1alpha1.ForceTemplateDeletionAnnotation)}, errTemplateDeletionForbidden
 	}
 
 	return nil, nil
@@ -130,7 +133,10 @@ func (v *ServiceTemplateValidator) ValidateDelete(ctx context.Context, obj runti
 	}
 
 	if len(managedClusters.Items) > 0 {
-		return admission.Warnings{"The ServiceTemplate object can't be removed if ManagedCluster objects referencing it still exist"}, errTemplateDeletionForbidden
+		if tmpl.Annotations[v1alpha1.ForceTemplateDeletionAnnotation] == "true" {
+			return admission.Warnings{"The ServiceTemplate is used by ManagedClusters, the services will fail to deploy"}, nil
+		}
+		return admission.Warnings{fmt.Sprintf("The ServiceTemplate object can't be removed if ManagedCluster objects referencing it still exist, set the %s annotation to \"true\" to force the deletion", v1alpha1.ForceTemplateDeletionAnnotation)}, errTemplateDeletionForbidden
 	}
 
 	return nil, nil
@@ -49,9 +49,22 @@ func TestClusterTemplateValidateDelete(t *testing.T) {
 				managedcluster.WithNamespace(namespace),
 				managedcluster.WithClusterTemplate(tpl.Name),
 			)},
-			warnings: admission.Warnings{"The ClusterTemplate object can't be removed if ManagedCluster objects referencing it still exist"},
+			warnings: admission.Warnings{"The ClusterTemplate object can't be removed if ManagedCluster objects referencing it still exist, set the hmc.mirantis.com/force-deletion annotation to \"true\" to force the deletion"},
 			err:      "template deletion is forbidden",
 		},
+		{
+			name: "should succeed with a warning if the deletion is forced",
+			template: template.NewClusterTemplate(
+				template.WithName(tpl.Name),
+				template.WithNamespace(namespace),
+				template.WithAnnotations(map[string]string{v1alpha1.ForceTemplateDeletionAnnotation: "true"}),
+			),
+			existingObjects: []runtime.Object{managedcluster.NewManagedCluster(
+				managedcluster.WithNamespace(namespace),
+				managedcluster.WithClusterTemplate(tpl.Name),
+			)},
+			warnings: admission.Warnings{"The ClusterTemplate is used by ManagedClusters, the clusters will fail to reconcile"},
+		},
 		{
 			name:     "should succeed if some ManagedCluster from another namespace references the template",
 			template: tpl,
@@ -118,9 +131,24 @@ func TestServiceTemplateValidateDelete(t *testing.T) {
 					managedcluster.WithServiceTemplate(tmpl.Name),
 				),
 			},
-			warnings: admission.Warnings{"The ServiceTemplate object can't be removed if ManagedCluster objects referencing it still exist"},
+			warnings: admission.Warnings{"The ServiceTemplate object can't be removed if ManagedCluster objects referencing it still exist, set the hmc.mirantis.com/force-deletion annotation to \"true\" to force the deletion"},
 			err:      errTemplateDeletionForbidden.Error(),
 		},
+		{
+			title: "should succeed with a warning if the deletion is forced",
+			template: template.NewServiceTemplate(
+				template.WithNamespace(tmpl.Namespace),
+				template.WithName(tmpl.Name),
+				template.WithAnnotations(map[string]string{v1alpha1.ForceTemplateDeletionAnnotation: "true"}),
+			),
+			existingObjects: []runtime.Object{
+				managedcluster.NewManagedCluster(
+					managedcluster.WithNamespace(tmpl.Namespace),
+					managedcluster.WithServiceTemplate(tmpl.Name),
+				),
+			},
+			warnings: admission.Warnings{"The ServiceTemplate is used by ManagedClusters, the services will fail to deploy"},
+		},
 		{
 			title:    "should succeed if managedCluster referencing ServiceTemplate is another namespace",
 			template: tmpl,
@@ -124,20 +124,52 @@ func (*ServiceTemplateChainValidator) Default(_ context.Context, _ runtime.Objec
 	return nil
 }
 
-func isTemplateChainValid(spec v1alpha1.TemplateChainSpec) admission.Warnings {
-	supportedTemplates := make(map[string]bool)
-	availableForUpgrade := make(map[string]bool)
-	for _, supportedTemplate := range spec.SupportedTemplates {
-		supportedTemplates[supportedTemplate.Name] = true
-		for _, template := range supportedTemplate.AvailableUpgrades {
-			availableForUpgrade[template.Name] = true
-		}
+type ProviderTemplateChainValidator struct {
+	client.Client
+}
+
+func (in *ProviderTemplateChainValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	in.Client = mgr.GetClient()
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&v1alpha1.ProviderTemplateChain{}).
+		WithValidator(in).
+		WithDefaulter(in).
+		Complete()
+}
+
+var (
+	_ webhook.CustomValidator = &ProviderTemplateChainValidator{}
+	_ webhook.CustomDefaulter = &ProviderTemplateChainValidator{}
+)
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (*ProviderTemplateChainValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	chain, ok := obj.(*v1alpha1.ProviderTemplateChain)
+	if !ok {
+		return admission.Warnings{"Wrong object"}, apierrors.NewBadRequest(fmt.Sprintf("expected ProviderTemplateChain but got a %T", obj))
 	}
-	warnings := admission.Warnings{}
-	for template := range availableForUpgrade {
-		if !supportedTemplates[template] {
-			warnings = append(warnings, fmt.Sprintf("template %s is allowed for upgrade but is not present in the list of spec.SupportedTemplates", template))
-		}
+	warnings := isTemplateChainValid(chain.Spec)
+	if len(warnings) > 0 {
+		return warnings, errInvalidTemplateChainSpec
 	}
-	return warnings
+	return nil, nil
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (*ProviderTemplateChainValidator) ValidateUpdate(_ context.Context, _, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (*ProviderTemplateChainValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type.
+func (*ProviderTemplateChainValidator) Default(_ context.Context, _ runtime.Object) error {
+	return nil
+}
+
+func isTemplateChainValid(spec v1alpha1.TemplateChainSpec) admission.Warnings {
+	return spec.Validate()
 }
@@ -63,6 +63,28 @@ func TestClusterTemplateChainValidateCreate(t *testing.T) {
 			name:  "should succeed",
 			chain: tc.NewClusterTemplateChain(tc.WithName("test"), tc.WithSupportedTemplates(append(supportedTemplates, v1alpha1.SupportedTemplate{Name: upgradeToTemplateName}))),
 		},
+		{
+			name: "should fail if a template is allowed for upgrade to itself",
+			chain: tc.NewClusterTemplateChain(tc.WithName("test"), tc.WithSupportedTemplates([]v1alpha1.SupportedTemplate{
+				{Name: upgradeFromTemplateName, AvailableUpgrades: []v1alpha1.AvailableUpgrade{{Name: upgradeFromTemplateName}}},
+			})),
+			warnings: admission.Warnings{
+				"template template-1-0-1 is allowed for upgrade to itself",
+			},
+			err: "the template chain spec is invalid",
+		},
+		{
+			name: "should fail if the upgrade sequence is cyclic",
+			chain: tc.NewClusterTemplateChain(tc.WithName("test"), tc.WithSupportedTemplates([]v1alpha1.SupportedTemplate{
+				{Name: upgradeFromTemplateName, AvailableUpgrades: []v1alpha1.AvailableUpgrade{{Name: upgradeToTemplateName}}},
+				{Name: upgradeToTemplateName, AvailableUpgrades: []v1alpha1.AvailableUpgrade{{Name: "template-1-0-3"}}},
+				{Name: "template-1-0-3", AvailableUpgrades: []v1alpha1.AvailableUpgrade{{Name: upgradeFromTemplateName}}},
+			})),
+			warnings: admission.Warnings{
+				"upgrade sequence template-1-0-1 -> template-1-0-2 -> template-1-0-3 -> template-1-0-1 is cyclic",
+			},
+			err: "the template chain spec is invalid",
+		},
 	}
 
 	for _, tt := range tests {
@@ -183,3 +205,48 @@ func TestServiceTemplateChainValidateCreate(t *testing.T) {
 		})
 	}
 }
+
+func TestProviderTemplateChainValidateCreate(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		chain    *v1alpha1.ProviderTemplateChain
+		warnings admission.Warnings
+		err      string
+	}{
+		{
+			name: "should succeed",
+			chain: tc.NewProviderTemplateChain(tc.WithSupportedTemplates([]v1alpha1.SupportedTemplate{
+				{Name: "cluster-api-0-0-1", AvailableUpgrades: []v1alpha1.AvailableUpgrade{{Name: "cluster-api-0-0-2"}}},
+				{Name: "cluster-api-0-0-2"},
+			})),
+		},
+		{
+			name: "should fail if spec is invalid: incorrect supported templates",
+			chain: tc.NewProviderTemplateChain(tc.WithSupportedTemplates([]v1alpha1.SupportedTemplate{
+				{Name: "cluster-api-0-0-1", AvailableUpgrades: []v1alpha1.AvailableUpgrade{{Name: "cluster-api-0-0-2"}}},
+			})),
+			warnings: admission.Warnings{
+				"template cluster-api-0-0-2 is allowed for upgrade but is not present in the list of spec.SupportedTemplates",
+			},
+			err: errInvalidTemplateChainSpec.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			validator := &ProviderTemplateChainValidator{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()}
+			warn, err := validator.ValidateCreate(ctx, tt.chain)
+			if tt.err != "" {
+				g.Expect(err).To(MatchError(tt.err))
+			} else {
+				g.Expect(err).To(Succeed())
+			}
+
+			g.Expect(warn).To(Equal(tt.warnings))
+		})
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+const invalidUpgradeGroupMsg = "the UpgradeGroup is invalid"
+
+type UpgradeGroupValidator struct {
+	client.Client
+}
+
+func (v *UpgradeGroupValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	v.Client = mgr.GetClient()
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&v1alpha1.UpgradeGroup{}).
+		WithValidator(v).
+		Complete()
+}
+
+var _ webhook.CustomValidator = &UpgradeGroupValidator{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (v *UpgradeGroupValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	group, ok := obj.(*v1alpha1.UpgradeGroup)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected UpgradeGroup but got a %T", obj))
+	}
+
+	if err := v.validateUpgradeGroup(ctx, group); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidUpgradeGroupMsg, err)
+	}
+	return nil, nil
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (v *UpgradeGroupValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldGroup, ok := oldObj.(*v1alpha1.UpgradeGroup)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected UpgradeGroup but got a %T", oldObj))
+	}
+	newGroup, ok := newObj.(*v1alpha1.UpgradeGroup)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected UpgradeGroup but got a %T", newObj))
+	}
+
+	if equality.Semantic.DeepEqual(oldGroup.Spec.ClusterSelector, newGroup.Spec.ClusterSelector) &&
+		oldGroup.Spec.Template == newGroup.Spec.Template {
+		return nil, nil
+	}
+
+	if err := v.validateUpgradeGroup(ctx, newGroup); err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidUpgradeGroupMsg, err)
+	}
+	return nil, nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (*UpgradeGroupValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateUpgradeGroup validates the template of the UpgradeGroup is an available upgrade of each of
+// the selected ManagedClusters not using it yet, and that no other UpgradeGroup of the namespace
+// has the same cluster selector or selects any of the clusters, so that the groups do not
+// upgrade the same clusters to different templates.
+func (v *UpgradeGroupValidator) validateUpgradeGroup(ctx context.Context, group *v1alpha1.UpgradeGroup) error {
+	clusters, err := v.selectedClusters(ctx, group)
+	if err != nil {
+		return err
+	}
+
+	var errs error
+	for _, cluster := range clusters {
+		if cluster.Spec.Template != group.Spec.Template && !slices.Contains(cluster.Status.AvailableUpgrades, group.Spec.Template) {
+			errs = errors.Join(errs, fmt.Errorf("the ClusterTemplate %s is not in the available upgrades of the ManagedCluster %s", group.Spec.Template, cluster.Name))
+		}
+	}
+
+	groups := &v1alpha1.UpgradeGroupList{}
+	if err := v.List(ctx, groups, client.InNamespace(group.Namespace)); err != nil {
+		return fmt.Errorf("failed to list UpgradeGroups: %w", err)
+	}
+	for _, other := range groups.Items {
+		if other.Name == group.Name {
+			continue
+		}
+		if equality.Semantic.DeepEqual(other.Spec.ClusterSelector, group.Spec.ClusterSelector) {
+			errs = errors.Join(errs, fmt.Errorf("the UpgradeGroup %s has the same cluster selector", other.Name))
+			continue
+		}
+
+		otherClusters, err := v.selectedClusters(ctx, &other)
+		if err != nil {
+			continue
+		}
+		for _, cluster := range clusters {
+			if slices.ContainsFunc(otherClusters, func(c v1alpha1.ManagedCluster) bool { return c.Name == cluster.Name }) {
+				errs = errors.Join(errs, fmt.Errorf("the ManagedCluster %s is selected by the UpgradeGroup %s as well", cluster.Name, other.Name))
+			}
+		}
+	}
+
+	return errs
+}
+
+// selectedClusters returns the ManagedClusters of the namespace selected by the UpgradeGroup.
+func (v *UpgradeGroupValidator) selectedClusters(ctx context.Context, group *v1alpha1.UpgradeGroup) ([]v1alpha1.ManagedCluster, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&group.Spec.ClusterSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cluster selector: %w", err)
+	}
+
+	clusters := &v1alpha1.ManagedClusterList{}
+	if err := v.List(ctx, clusters, client.InNamespace(group.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("failed to list ManagedClusters: %w", err)
+	}
+	return clusters.Items, nil
+}
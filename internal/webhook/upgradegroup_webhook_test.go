@@ -0,0 +1,112 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/test/objects/managedcluster"
+	"github.com/Mirantis/hmc/test/scheme"
+)
+
+func TestUpgradeGroupValidateCreate(t *testing.T) {
+	g := NewWithT(t)
+
+	ctx := context.Background()
+
+	const testNamespace = "team-a"
+
+	newGroup := func(name string, labels map[string]string) *v1alpha1.UpgradeGroup {
+		return &v1alpha1.UpgradeGroup{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+			Spec: v1alpha1.UpgradeGroupSpec{
+				ClusterSelector: metav1.LabelSelector{MatchLabels: labels},
+				Template:        "template-0-0-2",
+			},
+		}
+	}
+	newCluster := func(name string, labels map[string]string, availableUpgrades ...string) *v1alpha1.ManagedCluster {
+		mc := managedcluster.NewManagedCluster(
+			managedcluster.WithName(name),
+			managedcluster.WithNamespace(testNamespace),
+			managedcluster.WithClusterTemplate("template-0-0-1"),
+			managedcluster.WithAvailableUpgrades(availableUpgrades),
+		)
+		mc.Labels = labels
+		return mc
+	}
+
+	tests := []struct {
+		name            string
+		group           *v1alpha1.UpgradeGroup
+		existingObjects []runtime.Object
+		err             string
+	}{
+		{
+			name:  "should fail if the template is not an available upgrade of a selected cluster",
+			group: newGroup("prod", map[string]string{"env": "prod"}),
+			existingObjects: []runtime.Object{
+				newCluster("a", map[string]string{"env": "prod"}, "template-0-0-2"),
+				newCluster("b", map[string]string{"env": "prod"}),
+			},
+			err: "the UpgradeGroup is invalid: the ClusterTemplate template-0-0-2 is not in the available upgrades of the ManagedCluster b",
+		},
+		{
+			name:            "should fail if another UpgradeGroup has the same selector",
+			group:           newGroup("prod", map[string]string{"env": "prod"}),
+			existingObjects: []runtime.Object{newGroup("prod-too", map[string]string{"env": "prod"})},
+			err:             "the UpgradeGroup is invalid: the UpgradeGroup prod-too has the same cluster selector",
+		},
+		{
+			name:  "should fail if another UpgradeGroup selects the same cluster",
+			group: newGroup("prod", map[string]string{"env": "prod"}),
+			existingObjects: []runtime.Object{
+				newGroup("eu", map[string]string{"region": "eu"}),
+				newCluster("a", map[string]string{"env": "prod", "region": "eu"}, "template-0-0-2"),
+			},
+			err: "the UpgradeGroup is invalid: the ManagedCluster a is selected by the UpgradeGroup eu as well",
+		},
+		{
+			name:  "should succeed",
+			group: newGroup("prod", map[string]string{"env": "prod"}),
+			existingObjects: []runtime.Object{
+				newGroup("dev", map[string]string{"env": "dev"}),
+				newCluster("a", map[string]string{"env": "prod"}, "template-0-0-2"),
+				newCluster("b", map[string]string{"env": "dev"}),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(tt.existingObjects...).Build()
+			validator := &UpgradeGroupValidator{Client: c}
+			warn, err := validator.ValidateCreate(ctx, tt.group)
+			if tt.err != "" {
+				g.Expect(err).To(MatchError(tt.err))
+			} else {
+				g.Expect(err).To(Succeed())
+			}
+
+			g.Expect(warn).To(BeEmpty())
+		})
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package conditions interprets the conditions of the HMC objects. The controllers
+// aggregate the conditions into the Ready condition with it, external tooling may
+// use it to interpret the conditions the same way.
+package conditions
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// Severity is the severity of the issue reported by a condition.
+type Severity int
+
+const (
+	// SeverityNone means the condition reports no issue.
+	SeverityNone Severity = iota
+	// SeverityWarning means the condition reports an issue not affecting the readiness.
+	SeverityWarning
+	// SeverityProgressing means the condition is not satisfied yet.
+	SeverityProgressing
+	// SeverityError means the condition is failed.
+	SeverityError
+)
+
+// String implements fmt.Stringer.
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "Warning"
+	case SeverityProgressing:
+		return "Progressing"
+	case SeverityError:
+		return "Error"
+	default:
+		return "None"
+	}
+}
+
+// negativePolarity are the condition types set to True only while the issue they report persists.
+var negativePolarity = map[string]bool{
+	hmc.TemplateDeprecatedCondition: true,
+	hmc.CleanupIncompleteCondition:  true,
+	hmc.DeletingCondition:           true,
+	hmc.ServiceConflictCondition:    true,
+	hmc.ApplyConflictCondition:      true,
+}
+
+// order is the order the issues of the same severity are reported in,
+// following the lifecycle of a ManagedCluster. Unlisted types go last in alphabetical order.
+var order = []string{
+	hmc.CredentialReadyCondition,
+	hmc.TemplateReadyCondition,
+	hmc.ProvidersEnabledCondition,
+	hmc.QuotaAdmittedCondition,
+	hmc.LifecycleHooksCompletedCondition,
+	hmc.HelmChartReadyCondition,
+	hmc.HelmReleaseReadyCondition,
+	hmc.KubeconfigValidCondition,
+	hmc.CredentialsPropagatedCondition,
+	hmc.ServicesK8sCompatibleCondition,
+	hmc.ServiceConflictCondition,
+	hmc.ApplyConflictCondition,
+	hmc.GitOpsRegisteredCondition,
+	hmc.TemplateDeprecatedCondition,
+	hmc.DeletingCondition,
+	hmc.CleanupIncompleteCondition,
+}
+
+// IsNegativePolarity returns true if the condition of the given type reports an issue when set to True.
+func IsNegativePolarity(conditionType string) bool {
+	return negativePolarity[conditionType]
+}
+
+// SeverityOf returns the severity of the issue reported by the condition. The conditions
+// of negative polarity only produce warnings, the rest are progressing while Unknown
+// and failed while False.
+func SeverityOf(condition metav1.Condition) Severity {
+	if IsNegativePolarity(condition.Type) {
+		if condition.Status == metav1.ConditionTrue {
+			return SeverityWarning
+		}
+		return SeverityNone
+	}
+
+	switch condition.Status {
+	case metav1.ConditionFalse:
+		return SeverityError
+	case metav1.ConditionUnknown:
+		return SeverityProgressing
+	default:
+		return SeverityNone
+	}
+}
+
+// Issue is an issue reported by a condition.
+type Issue struct {
+	// Type is the type of the condition.
+	Type string
+	// Message is the message of the condition, or its type if the message is empty.
+	Message string
+	// Severity is the severity of the issue.
+	Severity Severity
+}
+
+// Issues returns the issues reported by the conditions except for the skipped types,
+// the most severe first. The issues of the same severity follow the lifecycle of the cluster.
+func Issues(conditions []metav1.Condition, skip ...string) []Issue {
+	var issues []Issue
+	for _, c := range conditions {
+		severity := SeverityOf(c)
+		if severity == SeverityNone || slices.Contains(skip, c.Type) {
+			continue
+		}
+
+		message := c.Message
+		if message == "" {
+			message = c.Type
+		}
+		issues = append(issues, Issue{Type: c.Type, Message: message, Severity: severity})
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Severity != issues[j].Severity {
+			return issues[i].Severity > issues[j].Severity
+		}
+		oi, oj := rank(issues[i].Type), rank(issues[j].Type)
+		if oi != oj {
+			return oi < oj
+		}
+		return issues[i].Type < issues[j].Type
+	})
+	return issues
+}
+
+// Message joins the messages of the issues, dropping the duplicates.
+func Message(issues []Issue) string {
+	seen := make(map[string]bool, len(issues))
+	messages := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		message := strings.TrimRight(strings.TrimSpace(issue.Message), ".")
+		if seen[message] {
+			continue
+		}
+		seen[message] = true
+		messages = append(messages, message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Summary aggregates the conditions into the condition of the given type, e.g. Ready.
+// It is False if any of the conditions failed, Unknown if any of them is not satisfied yet
+// and True with the given message otherwise. The message of the False and Unknown summary
+// is made of the messages of the most severe issues. The warnings do not affect the summary.
+func Summary(conditionType, readyMessage string, conditions []metav1.Condition) metav1.Condition {
+	summary := metav1.Condition{
+		Type:    conditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  hmc.SucceededReason,
+		Message: readyMessage,
+	}
+
+	issues := Issues(conditions, conditionType)
+	if len(issues) == 0 || issues[0].Severity == SeverityWarning {
+		return summary
+	}
+
+	top := issues[0].Severity
+	n := 0
+	for n < len(issues) && issues[n].Severity == top {
+		n++
+	}
+
+	summary.Message = Message(issues[:n])
+	if top == SeverityError {
+		summary.Status = metav1.ConditionFalse
+		summary.Reason = hmc.FailedReason
+	} else {
+		summary.Status = metav1.ConditionUnknown
+		summary.Reason = hmc.ProgressingReason
+	}
+	return summary
+}
+
+func rank(conditionType string) int {
+	if i := slices.Index(order, conditionType); i >= 0 {
+		return i
+	}
+	return len(order)
+}
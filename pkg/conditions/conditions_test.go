@@ -0,0 +1,81 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conditions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+func TestSummary(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		conditions []metav1.Condition
+		expected   metav1.Condition
+	}{
+		{
+			name: "ready with warnings",
+			conditions: []metav1.Condition{
+				{Type: hmc.ReadyCondition, Status: metav1.ConditionFalse, Message: "stale"},
+				{Type: hmc.HelmReleaseReadyCondition, Status: metav1.ConditionTrue, Message: "released"},
+				{Type: hmc.TemplateDeprecatedCondition, Status: metav1.ConditionTrue, Message: "deprecated"},
+			},
+			expected: metav1.Condition{Type: hmc.ReadyCondition, Status: metav1.ConditionTrue, Reason: hmc.SucceededReason, Message: "ready"},
+		},
+		{
+			name: "progressing",
+			conditions: []metav1.Condition{
+				{Type: hmc.HelmReleaseReadyCondition, Status: metav1.ConditionUnknown, Message: "installing"},
+				{Type: hmc.HelmChartReadyCondition, Status: metav1.ConditionUnknown, Message: "fetching."},
+				{Type: hmc.ServiceConflictCondition, Status: metav1.ConditionTrue, Message: "conflict"},
+			},
+			expected: metav1.Condition{Type: hmc.ReadyCondition, Status: metav1.ConditionUnknown, Reason: hmc.ProgressingReason, Message: "fetching; installing"},
+		},
+		{
+			name: "failed messages ordered and deduplicated",
+			conditions: []metav1.Condition{
+				{Type: hmc.HelmReleaseReadyCondition, Status: metav1.ConditionUnknown, Message: "installing"},
+				{Type: "Custom", Status: metav1.ConditionFalse},
+				{Type: hmc.TemplateReadyCondition, Status: metav1.ConditionFalse, Message: "template not found"},
+				{Type: hmc.CredentialReadyCondition, Status: metav1.ConditionFalse, Message: "template not found."},
+			},
+			expected: metav1.Condition{Type: hmc.ReadyCondition, Status: metav1.ConditionFalse, Reason: hmc.FailedReason, Message: "template not found; Custom"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, Summary(hmc.ReadyCondition, "ready", tc.conditions))
+		})
+	}
+}
+
+func TestIssues(t *testing.T) {
+	issues := Issues([]metav1.Condition{
+		{Type: hmc.CleanupIncompleteCondition, Status: metav1.ConditionTrue, Message: "lingering"},
+		{Type: hmc.DeletingCondition, Status: metav1.ConditionTrue, Message: "blocked"},
+		{Type: hmc.GitOpsRegisteredCondition, Status: metav1.ConditionTrue},
+		{Type: hmc.HelmReleaseReadyCondition, Status: metav1.ConditionFalse, Message: "failed"},
+		{Type: hmc.ReadyCondition, Status: metav1.ConditionFalse, Message: "failed"},
+	}, hmc.ReadyCondition)
+
+	require.Equal(t, []Issue{
+		{Type: hmc.HelmReleaseReadyCondition, Message: "failed", Severity: SeverityError},
+		{Type: hmc.DeletingCondition, Message: "blocked", Severity: SeverityWarning},
+		{Type: hmc.CleanupIncompleteCondition, Message: "lingering", Severity: SeverityWarning},
+	}, issues)
+}
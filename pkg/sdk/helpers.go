@@ -0,0 +1,100 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// IsConditionTrue returns true if the condition of the given type is set to True on the ManagedCluster.
+func IsConditionTrue(cluster *hmc.ManagedCluster, conditionType string) bool {
+	return apimeta.IsStatusConditionTrue(cluster.Status.Conditions, conditionType)
+}
+
+// GetCondition returns the condition of the given type of the ManagedCluster or nil if it is not set.
+func GetCondition(cluster *hmc.ManagedCluster, conditionType string) *metav1.Condition {
+	return apimeta.FindStatusCondition(cluster.Status.Conditions, conditionType)
+}
+
+// SetupIndexers registers the field indexes used by HMC within the Manager.
+// The indexes are required by ListManagedClustersByTemplate, ListManagedClustersByServiceTemplate
+// and ListManagedClustersByCredential.
+func SetupIndexers(ctx context.Context, mgr ctrl.Manager) error {
+	return hmc.SetupIndexers(ctx, mgr)
+}
+
+// ListManagedClustersByTemplate returns the ManagedClusters in the namespace using the given ClusterTemplate.
+func ListManagedClustersByTemplate(ctx context.Context, c client.Client, namespace, template string) ([]hmc.ManagedCluster, error) {
+	clusters := &hmc.ManagedClusterList{}
+	if err := c.List(ctx, clusters, client.InNamespace(namespace), client.MatchingFields{hmc.TemplateKey: template}); err != nil {
+		return nil, fmt.Errorf("failed to list ManagedClusters: %w", err)
+	}
+	return clusters.Items, nil
+}
+
+// ListManagedClustersByServiceTemplate returns the ManagedClusters in the namespace using the given ServiceTemplate.
+func ListManagedClustersByServiceTemplate(ctx context.Context, c client.Client, namespace, template string) ([]hmc.ManagedCluster, error) {
+	clusters := &hmc.ManagedClusterList{}
+	if err := c.List(ctx, clusters, client.InNamespace(namespace), client.MatchingFields{hmc.ServicesTemplateKey: template}); err != nil {
+		return nil, fmt.Errorf("failed to list ManagedClusters: %w", err)
+	}
+	return clusters.Items, nil
+}
+
+// ListManagedClustersByCredential returns the ManagedClusters in the namespace using the given Credential.
+func ListManagedClustersByCredential(ctx context.Context, c client.Client, namespace, credential string) ([]hmc.ManagedCluster, error) {
+	clusters := &hmc.ManagedClusterList{}
+	if err := c.List(ctx, clusters, client.InNamespace(namespace), client.MatchingFields{hmc.CredentialKey: credential}); err != nil {
+		return nil, fmt.Errorf("failed to list ManagedClusters: %w", err)
+	}
+	return clusters.Items, nil
+}
+
+// OwnerLabels returns the labels set on the objects deployed for the ManagedCluster,
+// such as the CAPI Cluster and the Machines.
+func OwnerLabels(cluster *hmc.ManagedCluster) map[string]string {
+	return map[string]string{
+		hmc.FluxHelmChartNamespaceKey: cluster.Namespace,
+		hmc.FluxHelmChartNameKey:      cluster.Name,
+	}
+}
+
+// GetOwnerManagedCluster returns the ManagedCluster the given object has been deployed for.
+func GetOwnerManagedCluster(ctx context.Context, c client.Client, obj client.Object) (*hmc.ManagedCluster, error) {
+	name, ok := obj.GetLabels()[hmc.FluxHelmChartNameKey]
+	if !ok {
+		return nil, errors.New("object is not owned by a ManagedCluster")
+	}
+
+	namespace := obj.GetLabels()[hmc.FluxHelmChartNamespaceKey]
+	if namespace == "" {
+		namespace = obj.GetNamespace()
+	}
+
+	cluster := &hmc.ManagedCluster{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, cluster); err != nil {
+		return nil, fmt.Errorf("failed to get ManagedCluster %s/%s: %w", namespace, name, err)
+	}
+	return cluster, nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sdk helps out-of-tree controllers to extend the ManagedCluster
+// lifecycle. A controller registers a LifecycleHook object for one of the
+// lifecycle phases, the HMC controller waits for the hook to be acknowledged
+// for a ManagedCluster before moving the cluster past the phase.
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+// DefaultRequeueInterval is the default interval the Hook is retried with.
+const DefaultRequeueInterval = 10 * time.Second
+
+// Hook is implemented by the out-of-tree controllers participating in the ManagedCluster lifecycle.
+type Hook interface {
+	// Run performs the hook for the given ManagedCluster. It returns true
+	// once the hook is completed, the completion is then acknowledged and
+	// the hook is not run for the cluster anymore.
+	Run(ctx context.Context, cluster *hmc.ManagedCluster) (done bool, err error)
+}
+
+// HookFunc is an adapter allowing to use ordinary functions as a Hook.
+type HookFunc func(ctx context.Context, cluster *hmc.ManagedCluster) (bool, error)
+
+// Run implements Hook.
+func (f HookFunc) Run(ctx context.Context, cluster *hmc.ManagedCluster) (bool, error) {
+	return f(ctx, cluster)
+}
+
+// HookReconciler runs the Hook for the ManagedClusters matching the
+// LifecycleHook object and acknowledges the completion of the hook.
+type HookReconciler struct {
+	client.Client
+
+	// Hook is the hook to run.
+	Hook Hook
+	// LifecycleHook is the reference to the LifecycleHook object registering the hook.
+	LifecycleHook client.ObjectKey
+	// RequeueInterval is the interval the Hook is retried with, defaults to DefaultRequeueInterval.
+	RequeueInterval time.Duration
+}
+
+// Reconcile runs the Hook for a ManagedCluster.
+func (r *HookReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := ctrl.LoggerFrom(ctx)
+
+	if req.Namespace != r.LifecycleHook.Namespace {
+		return ctrl.Result{}, nil
+	}
+
+	hook := &hmc.LifecycleHook{}
+	if err := r.Get(ctx, r.LifecycleHook, hook); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get LifecycleHook %s: %w", r.LifecycleHook, err)
+	}
+
+	cluster := &hmc.ManagedCluster{}
+	if err := r.Get(ctx, req.NamespacedName, cluster); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	matches, err := hook.Matches(cluster)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("invalid cluster selector of LifecycleHook %s: %w", r.LifecycleHook, err)
+	}
+	if !matches || hook.IsAcknowledged(cluster) || !InPhase(cluster, hook.Spec.Phase) {
+		return ctrl.Result{}, nil
+	}
+
+	l.Info("Running lifecycle hook", "hook", r.LifecycleHook, "phase", hook.Spec.Phase)
+	done, err := r.Hook.Run(ctx, cluster)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !done {
+		return ctrl.Result{RequeueAfter: r.requeueInterval()}, nil
+	}
+
+	return ctrl.Result{}, Acknowledge(ctx, r.Client, r.LifecycleHook, cluster, "")
+}
+
+func (r *HookReconciler) requeueInterval() time.Duration {
+	if r.RequeueInterval == 0 {
+		return DefaultRequeueInterval
+	}
+	return r.RequeueInterval
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *HookReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("lifecyclehook-" + r.LifecycleHook.Name).
+		For(&hmc.ManagedCluster{}).
+		Complete(r)
+}
+
+// InPhase returns true if the ManagedCluster is in the given lifecycle phase.
+func InPhase(cluster *hmc.ManagedCluster, phase hmc.LifecycleHookPhase) bool {
+	deleting := !cluster.DeletionTimestamp.IsZero()
+
+	switch phase {
+	case hmc.LifecycleHookPreProvision:
+		return !deleting
+	case hmc.LifecycleHookPostReady:
+		return !deleting && IsConditionTrue(cluster, hmc.HelmReleaseReadyCondition)
+	case hmc.LifecycleHookPreDelete:
+		return deleting
+	default:
+		return false
+	}
+}
+
+// Acknowledge records the completion of the LifecycleHook for the given ManagedCluster.
+func Acknowledge(ctx context.Context, c client.Client, hookKey client.ObjectKey, cluster *hmc.ManagedCluster, message string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		hook := &hmc.LifecycleHook{}
+		if err := c.Get(ctx, hookKey, hook); err != nil {
+			return err
+		}
+
+		if !hook.Acknowledge(cluster, message) {
+			return nil
+		}
+
+		return c.Status().Update(ctx, hook)
+	})
+}
@@ -10,6 +10,10 @@
     {{- include "cluster.name" . }}-md
 {{- end }}
 
+{{- define "machinehealthcheck.name" -}}
+    {{- include "cluster.name" . }}-mhc
+{{- end }}
+
 {{- define "awsmanagedcontrolplane.name" -}}
     {{- include "cluster.name" . }}-cp
 {{- end }}
@@ -17,3 +21,65 @@
 {{- define "eksconfigtemplate.name" -}}
     {{- include "cluster.name" . }}-machine-config
 {{- end }}
+
+{{- define "worker.nodeLabels" -}}
+    {{- $labels := list }}
+    {{- range $key, $value := dig "worker" "labels" dict (.Values.nodePools | default dict) }}
+        {{- $labels = append $labels (printf "%s=%s" $key $value) }}
+    {{- end }}
+    {{- join "," $labels }}
+{{- end }}
+
+{{- define "worker.nodeTaints" -}}
+    {{- $taints := list }}
+    {{- range dig "worker" "taints" list (.Values.nodePools | default dict) }}
+        {{- if .value }}
+            {{- $taints = append $taints (printf "%s=%s:%s" .key .value .effect) }}
+        {{- else }}
+            {{- $taints = append $taints (printf "%s:%s" .key .effect) }}
+        {{- end }}
+    {{- end }}
+    {{- join "," $taints }}
+{{- end }}
+
+{{- define "machine.trustEnabled" -}}
+    {{- if or .Values.proxy.httpProxy .Values.proxy.httpsProxy .Values.trustedCA }}true{{- end }}
+{{- end }}
+
+{{- define "proxy.noProxy" -}}
+    {{- $noProxy := concat (.Values.proxy.noProxy | default list) .Values.clusterNetwork.pods.cidrBlocks .Values.clusterNetwork.services.cidrBlocks (list "localhost" "127.0.0.1" ".svc" ".cluster.local") }}
+    {{- join "," (uniq $noProxy) }}
+{{- end }}
+
+{{- define "machine.trustFiles" -}}
+    {{- if or .Values.proxy.httpProxy .Values.proxy.httpsProxy }}
+        {{- range list "containerd" "kubelet" }}
+- path: /etc/systemd/system/{{ . }}.service.d/http-proxy.conf
+  permissions: "0644"
+  content: |
+    [Service]
+            {{- with $.Values.proxy.httpProxy }}
+    Environment="HTTP_PROXY={{ . }}"
+            {{- end }}
+            {{- with $.Values.proxy.httpsProxy }}
+    Environment="HTTPS_PROXY={{ . }}"
+            {{- end }}
+    Environment="NO_PROXY={{ include "proxy.noProxy" $ }}"
+        {{- end }}
+    {{- end }}
+    {{- with .Values.trustedCA }}
+- path: /etc/pki/ca-trust/source/anchors/hmc-trusted-ca.crt
+  permissions: "0644"
+  content: |
+    {{- . | nindent 4 }}
+    {{- end }}
+{{- end }}
+
+{{- define "machine.trustCommands" -}}
+    {{- with .Values.trustedCA }}
+- update-ca-trust extract
+    {{- end }}
+    {{- if or .Values.proxy.httpProxy .Values.proxy.httpsProxy }}
+- systemctl daemon-reload
+    {{- end }}
+{{- end }}
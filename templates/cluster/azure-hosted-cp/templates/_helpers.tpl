@@ -17,3 +17,74 @@
 {{- define "machinedeployment.name" -}}
     {{- include "cluster.name" . }}-md
 {{- end }}
+
+{{- define "machinehealthcheck.name" -}}
+    {{- include "cluster.name" . }}-mhc
+{{- end }}
+
+{{- define "worker.nodeLabels" -}}
+    {{- $labels := list }}
+    {{- range $key, $value := dig "worker" "labels" dict (.Values.nodePools | default dict) }}
+        {{- $labels = append $labels (printf "%s=%s" $key $value) }}
+    {{- end }}
+    {{- join "," $labels }}
+{{- end }}
+
+{{- define "worker.nodeTaints" -}}
+    {{- $taints := list }}
+    {{- range dig "worker" "taints" list (.Values.nodePools | default dict) }}
+        {{- if .value }}
+            {{- $taints = append $taints (printf "%s=%s:%s" .key .value .effect) }}
+        {{- else }}
+            {{- $taints = append $taints (printf "%s:%s" .key .effect) }}
+        {{- end }}
+    {{- end }}
+    {{- join "," $taints }}
+{{- end }}
+
+{{- define "machine.trustEnabled" -}}
+    {{- if or .Values.proxy.httpProxy .Values.proxy.httpsProxy .Values.trustedCA }}true{{- end }}
+{{- end }}
+
+{{- define "proxy.noProxy" -}}
+    {{- $noProxy := concat (.Values.proxy.noProxy | default list) .Values.clusterNetwork.pods.cidrBlocks .Values.clusterNetwork.services.cidrBlocks (list "localhost" "127.0.0.1" ".svc" ".cluster.local") }}
+    {{- join "," (uniq $noProxy) }}
+{{- end }}
+
+{{- define "machine.trustFiles" -}}
+    {{- $ctx := .ctx }}
+    {{- if or $ctx.Values.proxy.httpProxy $ctx.Values.proxy.httpsProxy }}
+- path: /etc/systemd/system/{{ .unit }}.service.d/http-proxy.conf
+  permissions: "0644"
+  content: |
+    [Service]
+        {{- with $ctx.Values.proxy.httpProxy }}
+    Environment="HTTP_PROXY={{ . }}"
+        {{- end }}
+        {{- with $ctx.Values.proxy.httpsProxy }}
+    Environment="HTTPS_PROXY={{ . }}"
+        {{- end }}
+    Environment="NO_PROXY={{ include "proxy.noProxy" $ctx }}"
+    {{- end }}
+    {{- with $ctx.Values.trustedCA }}
+- path: /usr/local/share/ca-certificates/hmc-trusted-ca.crt
+  permissions: "0644"
+  content: |
+    {{- . | nindent 4 }}
+    {{- end }}
+{{- end }}
+
+{{- define "machine.trustCommands" -}}
+    {{- with .Values.trustedCA }}
+- update-ca-certificates
+    {{- end }}
+    {{- with .Values.proxy.httpProxy }}
+- {{ printf "export HTTP_PROXY=%s" . | quote }}
+    {{- end }}
+    {{- with .Values.proxy.httpsProxy }}
+- {{ printf "export HTTPS_PROXY=%s" . | quote }}
+    {{- end }}
+    {{- if or .Values.proxy.httpProxy .Values.proxy.httpsProxy }}
+- {{ printf "export NO_PROXY=%s" (include "proxy.noProxy" .) | quote }}
+    {{- end }}
+{{- end }}
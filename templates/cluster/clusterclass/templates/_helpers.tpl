@@ -0,0 +1,14 @@
+{{- define "cluster.name" -}}
+    {{- .Release.Name | trunc 63 | trimSuffix "-" }}
+{{- end }}
+
+{{- define "cluster.variables" -}}
+    {{- $variables := list }}
+    {{- range $name, $value := .Values.variables }}
+        {{- $variables = append $variables (dict "name" $name "value" $value) }}
+    {{- end }}
+    {{- if and .Values.clusterIdentityVariable .Values.clusterIdentity }}
+        {{- $variables = append $variables (dict "name" .Values.clusterIdentityVariable "value" .Values.clusterIdentity) }}
+    {{- end }}
+    {{- toYaml $variables }}
+{{- end }}
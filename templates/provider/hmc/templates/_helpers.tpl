@@ -108,3 +108,29 @@ hmc-webhook
 - list
 - watch
 {{- end -}}
+
+{{/*
+The controller arguments configuring the store of the propagated Secrets
+*/}}
+{{- define "hmc.secretStore.args" -}}
+- --secret-store={{ .Values.controller.secretStore.type }}
+{{- if eq .Values.controller.secretStore.type "vault" }}
+- --vault-address={{ .Values.controller.secretStore.vault.address }}
+- --vault-mount={{ .Values.controller.secretStore.vault.mount }}
+- --vault-key-prefix={{ .Values.controller.secretStore.vault.keyPrefix }}
+- --cluster-secret-store={{ .Values.controller.secretStore.clusterSecretStore }}
+{{- end }}
+{{- end -}}
+
+{{/*
+The controller environment variables configuring the store of the propagated Secrets
+*/}}
+{{- define "hmc.secretStore.env" -}}
+{{- if and (eq .Values.controller.secretStore.type "vault") .Values.controller.secretStore.vault.tokenSecret }}
+- name: VAULT_TOKEN
+  valueFrom:
+    secretKeyRef:
+      name: {{ .Values.controller.secretStore.vault.tokenSecret }}
+      key: token
+{{- end }}
+{{- end -}}
@@ -0,0 +1,160 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fakeclient extends the controller-runtime fake client for the unit tests.
+package fakeclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// WithApply emulates the server-side apply the fake client does not support,
+// the applied object is created or merged onto the existing one. The fields
+// applied before and omitted from the applied object are removed, as the
+// fields owned by the field manager are.
+func WithApply(b *fake.ClientBuilder) *fake.ClientBuilder {
+	var (
+		mu      sync.Mutex
+		applied = make(map[string]map[string]any)
+	)
+
+	return b.WithInterceptorFuncs(interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if patch.Type() != types.ApplyPatchType {
+				return c.Patch(ctx, obj, patch, opts...)
+			}
+
+			data, err := patch.Data(obj)
+			if err != nil {
+				return err
+			}
+			fields := make(map[string]any)
+			if err := json.Unmarshal(data, &fields); err != nil {
+				return err
+			}
+			gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+			if err != nil {
+				return err
+			}
+			key := gvk.String() + "/" + client.ObjectKeyFromObject(obj).String()
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			err = c.Create(ctx, obj)
+			if !apierrors.IsAlreadyExists(err) {
+				if err == nil {
+					applied[key] = fields
+				}
+				return err
+			}
+
+			// the fields applied before and omitted now are removed
+			if previous, ok := applied[key]; ok {
+				removeOmitted(previous, fields)
+				if data, err = json.Marshal(fields); err != nil {
+					return err
+				}
+			}
+
+			// the unchanged objects are not patched to keep the resource version as the API server does
+			current, ok := obj.DeepCopyObject().(client.Object)
+			if !ok {
+				return fmt.Errorf("unexpected object type %T", obj)
+			}
+			if err := c.Get(ctx, client.ObjectKeyFromObject(obj), current); err != nil {
+				return err
+			}
+			merged, ok := current.DeepCopyObject().(client.Object)
+			if !ok {
+				return fmt.Errorf("unexpected object type %T", obj)
+			}
+			if err := mergePatch(merged, data); err != nil {
+				return err
+			}
+			if equality.Semantic.DeepEqual(current, merged) {
+				applied[key] = withoutNulls(fields)
+				return c.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+			}
+
+			if err := c.Patch(ctx, obj, client.RawPatch(types.MergePatchType, data)); err != nil {
+				return err
+			}
+			applied[key] = withoutNulls(fields)
+			return nil
+		},
+	})
+}
+
+// removeOmitted sets the fields of the previous apply omitted from the current one to null,
+// so the merge patch removes them.
+func removeOmitted(previous, current map[string]any) {
+	for k, v := range previous {
+		cur, ok := current[k]
+		if !ok {
+			current[k] = nil
+			continue
+		}
+		prevMap, prevOK := v.(map[string]any)
+		curMap, curOK := cur.(map[string]any)
+		if prevOK && curOK {
+			removeOmitted(prevMap, curMap)
+		}
+	}
+}
+
+// withoutNulls returns the fields without the nulls set by removeOmitted.
+func withoutNulls(fields map[string]any) map[string]any {
+	out := make(map[string]any, len(fields))
+	for k, v := range fields {
+		switch v := v.(type) {
+		case nil:
+		case map[string]any:
+			out[k] = withoutNulls(v)
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// mergePatch applies the JSON merge patch to the object.
+func mergePatch(obj client.Object, patch []byte) error {
+	original, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	patched, err := jsonpatch.MergePatch(original, patch)
+	if err != nil {
+		return err
+	}
+	// the object is reset since the unmarshalling merges the maps
+	v := reflect.ValueOf(obj).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	return json.Unmarshal(patched, obj)
+}
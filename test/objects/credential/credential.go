@@ -64,3 +64,9 @@ func WithState(state v1alpha1.CredentialState) Opt {
 		p.Status.State = state
 	}
 }
+
+func WithAnnotations(annotations map[string]string) Opt {
+	return func(p *v1alpha1.Credential) {
+		p.Annotations = annotations
+	}
+}
@@ -54,6 +54,12 @@ func WithNamespace(namespace string) Opt {
 	}
 }
 
+func WithAnnotations(annotations map[string]string) Opt {
+	return func(p *v1alpha1.ManagedCluster) {
+		p.Annotations = annotations
+	}
+}
+
 func WithDryRun(dryRun bool) Opt {
 	return func(p *v1alpha1.ManagedCluster) {
 		p.Spec.DryRun = dryRun
@@ -82,14 +88,79 @@ func WithServiceTemplate(templateName string) Opt {
 	}
 }
 
+func WithBackup(backup *v1alpha1.ManagedClusterBackupSpec) Opt {
+	return func(p *v1alpha1.ManagedCluster) {
+		p.Spec.Backup = backup
+	}
+}
+
+func WithDNS(dns *v1alpha1.DNSConfig) Opt {
+	return func(p *v1alpha1.ManagedCluster) {
+		p.Spec.DNS = dns
+	}
+}
+
+func WithClusterLabels(labels map[string]string) Opt {
+	return func(p *v1alpha1.ManagedCluster) {
+		p.Spec.ClusterLabels = labels
+	}
+}
+
+func WithReadinessGates(conditionTypes ...string) Opt {
+	return func(p *v1alpha1.ManagedCluster) {
+		for _, conditionType := range conditionTypes {
+			p.Spec.ReadinessGates = append(p.Spec.ReadinessGates, v1alpha1.ReadinessGate{ConditionType: conditionType})
+		}
+	}
+}
+
+func WithNodePool(pool v1alpha1.NodePoolSpec) Opt {
+	return func(p *v1alpha1.ManagedCluster) {
+		p.Spec.NodePools = append(p.Spec.NodePools, pool)
+	}
+}
+
+func WithWorkerPool(pool v1alpha1.WorkerPoolConfig) Opt {
+	return func(p *v1alpha1.ManagedCluster) {
+		p.Spec.Workers = append(p.Spec.Workers, pool)
+	}
+}
+
+func WithSSHKey(key string) Opt {
+	return func(p *v1alpha1.ManagedCluster) {
+		p.Spec.SSHKey = key
+	}
+}
+
 func WithCredential(credName string) Opt {
 	return func(p *v1alpha1.ManagedCluster) {
 		p.Spec.Credential = credName
 	}
 }
 
+func WithIdentityCredential(path, credName string) Opt {
+	return func(p *v1alpha1.ManagedCluster) {
+		if p.Spec.IdentityCredentials == nil {
+			p.Spec.IdentityCredentials = make(map[string]string)
+		}
+		p.Spec.IdentityCredentials[path] = credName
+	}
+}
+
 func WithAvailableUpgrades(availableUpgrades []string) Opt {
 	return func(p *v1alpha1.ManagedCluster) {
 		p.Status.AvailableUpgrades = availableUpgrades
 	}
 }
+
+func WithCloneFrom(name string) Opt {
+	return func(p *v1alpha1.ManagedCluster) {
+		p.Spec.CloneFrom = name
+	}
+}
+
+func WithServicesPriority(priority int32) Opt {
+	return func(p *v1alpha1.ManagedCluster) {
+		p.Spec.ServicesPriority = priority
+	}
+}
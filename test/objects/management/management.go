@@ -15,6 +15,7 @@
 package management
 
 import (
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/Mirantis/hmc/api/v1alpha1"
@@ -76,6 +77,18 @@ func WithComponentsStatus(components map[string]v1alpha1.ComponentStatus) Opt {
 	}
 }
 
+func WithProxy(proxy *v1alpha1.ProxyConfig) Opt {
+	return func(p *v1alpha1.Management) {
+		p.Spec.Proxy = proxy
+	}
+}
+
+func WithGlobalClusterDefaults(raw string) Opt {
+	return func(p *v1alpha1.Management) {
+		p.Spec.GlobalClusterDefaults = &apiextensionsv1.JSON{Raw: []byte(raw)}
+	}
+}
+
 func WithRelease(v string) Opt {
 	return func(management *v1alpha1.Management) {
 		management.Spec.Release = v
@@ -0,0 +1,77 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiclusterservice
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Mirantis/hmc/api/v1alpha1"
+)
+
+const (
+	DefaultName = "multiclusterservice"
+)
+
+type Opt func(mcs *v1alpha1.MultiClusterService)
+
+func NewMultiClusterService(opts ...Opt) *v1alpha1.MultiClusterService {
+	mcs := &v1alpha1.MultiClusterService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: DefaultName,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(mcs)
+	}
+	return mcs
+}
+
+func WithName(name string) Opt {
+	return func(mcs *v1alpha1.MultiClusterService) {
+		mcs.Name = name
+	}
+}
+
+func WithServiceTemplate(templateName string) Opt {
+	return func(mcs *v1alpha1.MultiClusterService) {
+		mcs.Spec.Services = append(mcs.Spec.Services, v1alpha1.ServiceSpec{
+			Template: templateName,
+			Name:     templateName,
+		})
+	}
+}
+
+func WithServicesPriority(priority int32) Opt {
+	return func(mcs *v1alpha1.MultiClusterService) {
+		mcs.Spec.ServicesPriority = priority
+	}
+}
+
+func WithMatcher(name string, matchLabels map[string]string, services ...v1alpha1.ServiceValuesOverride) Opt {
+	return func(mcs *v1alpha1.MultiClusterService) {
+		mcs.Spec.Matchers = append(mcs.Spec.Matchers, v1alpha1.ServiceMatcher{
+			Name:            name,
+			ClusterSelector: metav1.LabelSelector{MatchLabels: matchLabels},
+			Services:        services,
+		})
+	}
+}
+
+func WithRollout(rollout v1alpha1.ServiceRollout) Opt {
+	return func(mcs *v1alpha1.MultiClusterService) {
+		mcs.Spec.Rollout = &rollout
+	}
+}
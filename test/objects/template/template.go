@@ -101,6 +101,12 @@ func WithLabels(labels map[string]string) Opt {
 	}
 }
 
+func WithAnnotations(annotations map[string]string) Opt {
+	return func(t Template) {
+		t.SetAnnotations(annotations)
+	}
+}
+
 func ManagedByHMC() Opt {
 	return func(template Template) {
 		labels := template.GetLabels()
@@ -133,6 +139,19 @@ func WithServiceK8sConstraint(v string) Opt {
 	}
 }
 
+func WithDeprecation(deprecation v1alpha1.TemplateDeprecation) Opt {
+	return func(template Template) {
+		switch tt := template.(type) {
+		case *v1alpha1.ClusterTemplate:
+			tt.Spec.TemplateDeprecation = deprecation
+		case *v1alpha1.ServiceTemplate:
+			tt.Spec.TemplateDeprecation = deprecation
+		default:
+			panic(fmt.Sprintf("unexpected obj typed %T, expected *ClusterTemplate or *ServiceTemplate", tt))
+		}
+	}
+}
+
 func WithValidationStatus(validationStatus v1alpha1.TemplateValidationStatus) Opt {
 	return func(t Template) {
 		status := t.GetCommonStatus()
@@ -174,6 +193,20 @@ func WithConfigStatus(config string) Opt {
 	}
 }
 
+func WithConfigSchemaStatus(schema string) Opt {
+	return func(t Template) {
+		configSchema := &apiextensionsv1.JSON{Raw: []byte(schema)}
+		switch v := t.(type) {
+		case *v1alpha1.ClusterTemplate:
+			v.Status.ConfigSchema = configSchema
+		case *v1alpha1.ProviderTemplate:
+			v.Status.ConfigSchema = configSchema
+		default:
+			panic(fmt.Sprintf("unexpected type %T", t))
+		}
+	}
+}
+
 func WithProviderStatusCAPIContracts(coreAndProvidersContracts ...string) Opt {
 	if len(coreAndProvidersContracts)&1 != 0 {
 		panic("non even number of arguments")
@@ -208,3 +241,13 @@ func WithClusterStatusK8sVersion(v string) Opt {
 		ct.Status.KubernetesVersion = v
 	}
 }
+
+func WithClusterStatusConfigSections(sections map[string][]string) Opt {
+	return func(template Template) {
+		ct, ok := template.(*v1alpha1.ClusterTemplate)
+		if !ok {
+			panic(fmt.Sprintf("unexpected type %T, expected ClusterTemplate", template))
+		}
+		ct.Status.ConfigSections = sections
+	}
+}
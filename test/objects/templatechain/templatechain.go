@@ -47,6 +47,14 @@ func NewServiceTemplateChain(opts ...Opt) *v1alpha1.ServiceTemplateChain {
 	}
 }
 
+func NewProviderTemplateChain(opts ...Opt) *v1alpha1.ProviderTemplateChain {
+	tc := NewTemplateChain(opts...)
+	return &v1alpha1.ProviderTemplateChain{
+		ObjectMeta: tc.ObjectMeta,
+		Spec:       tc.Spec,
+	}
+}
+
 func NewTemplateChain(opts ...Opt) *TemplateChain {
 	tc := &TemplateChain{
 		ObjectMeta: metav1.ObjectMeta{
@@ -17,12 +17,14 @@ package scheme
 import (
 	hcv2 "github.com/fluxcd/helm-controller/api/v2"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	sveltosv1alpha1 "github.com/projectsveltos/addon-controller/api/v1alpha1"
 	sveltosv1beta1 "github.com/projectsveltos/addon-controller/api/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 
 	"github.com/Mirantis/hmc/api/v1alpha1"
+	"github.com/Mirantis/hmc/api/v1beta1"
 )
 
 var (
@@ -31,8 +33,10 @@ var (
 	builder = runtime.SchemeBuilder{
 		clientgoscheme.AddToScheme,
 		v1alpha1.AddToScheme,
+		v1beta1.AddToScheme,
 		sourcev1.AddToScheme,
 		hcv2.AddToScheme,
+		sveltosv1alpha1.AddToScheme,
 		sveltosv1beta1.AddToScheme,
 	}
 )